@@ -1,22 +1,46 @@
 package olake
 
 import (
+	"errors"
 	"os"
 
+	"github.com/datazip-inc/olake/drivers"
 	"github.com/datazip-inc/olake/logger"
 	protocol "github.com/datazip-inc/olake/protocol"
 	"github.com/datazip-inc/olake/safego"
+	_ "github.com/datazip-inc/olake/writers/file"    // registering jsonl, csv and avro file writer
+	_ "github.com/datazip-inc/olake/writers/kafka"   // registering kafka writer
+	_ "github.com/datazip-inc/olake/writers/null"    // registering null writer
 	_ "github.com/datazip-inc/olake/writers/parquet" // registering local parquet writer
+	_ "github.com/datazip-inc/olake/writers/stdout"  // registering stdout writer
 )
 
-func RegisterDriver(driver protocol.Driver) {
+// Run executes the command line with the driver registered under name; binaries bundling
+// several drivers run another one with --driver or the driver key of the config
+func Run(name string) {
 	defer safego.Recovery(true)
 
+	driver, err := drivers.New(protocol.DriverName(name))
+	if err != nil {
+		logger.Fatal(err)
+	}
+
 	// Execute the root command
-	err := protocol.CreateRootCommand(true, driver).Execute()
+	err = protocol.CreateRootCommand(true, driver).Execute()
+	if errors.Is(err, protocol.ErrPartialSync) {
+		logger.Error(err)
+		logger.Close()
+		os.Exit(protocol.PartialSyncExitCode)
+	}
+	if errors.Is(err, protocol.ErrQualityCheck) {
+		logger.Error(err)
+		logger.Close()
+		os.Exit(protocol.QualityCheckExitCode)
+	}
 	if err != nil {
 		logger.Fatal(err)
 	}
 
+	logger.Close()
 	os.Exit(0)
 }
@@ -8,4 +8,10 @@ const (
 	OlakeID              = "olake_id"
 	OlakeTimestamp       = "olake_insert_time"
 	CDCDeletedAt         = "_cdc_deleted_at"
+	SyncID               = "olake_sync_id"
+	Partial              = "olake_partial"
+	MetaSyncedAt         = "_olake_synced_at"
+	MetaSyncID           = "_olake_sync_id"
+	MetaRawID            = "_olake_raw_id"
+	MetaOp               = "_olake_op"
 )
@@ -11,6 +11,8 @@ type Driver struct {
 	cachedStreams sync.Map // locally cached streams; It contains all streams
 	CDCSupport    bool     // Used in CDC mode
 	State         *types.State
+	// streams sampled at once by discover; the default of the driver when 0
+	discoverConcurrency int
 }
 
 var DefaultColumns = map[string]types.DataType{
@@ -48,6 +50,21 @@ func (d *Driver) GetStream(streamID string) (bool, *types.Stream) {
 	return found, val.(*types.Stream)
 }
 
+// SetDiscoverConcurrency bounds the streams discover samples at once; 0 keeps the default of
+// the driver
+func (d *Driver) SetDiscoverConcurrency(limit int) {
+	d.discoverConcurrency = limit
+}
+
+// DiscoverConcurrency returns the streams discover samples at once; fallback is the default of
+// the driver, usually max_threads of its config
+func (d *Driver) DiscoverConcurrency(fallback int) int {
+	if d.discoverConcurrency > 0 {
+		return d.discoverConcurrency
+	}
+	return max(fallback, 1)
+}
+
 func NewBase() *Driver {
 	return &Driver{
 		cachedStreams: sync.Map{},
@@ -0,0 +1,164 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/datazip-inc/olake/drivers/base"
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+	"github.com/datazip-inc/olake/utils"
+)
+
+const (
+	writetimeCursorKey = "writetime"
+	writetimeColumn    = "olake_writetime"
+)
+
+// backfill reads a table in parallel over token ranges; in incremental mode only rows whose
+// cursor column was written after the last synced writetime are emitted
+func (c *Cassandra) backfill(ctx context.Context, pool *protocol.WriterPool, stream protocol.Stream) error {
+	table, err := c.getTable(stream)
+	if err != nil {
+		return err
+	}
+
+	incremental := stream.GetSyncMode() == types.INCREMENTAL
+	var lastWritetime int64
+	if incremental {
+		if cursor := c.State.GetCursor(stream.Self(), writetimeCursorKey); cursor != nil {
+			lastWritetime, err = typeutils.ReformatInt64(cursor)
+			if err != nil {
+				return fmt.Errorf("invalid writetime cursor in state: %s", err)
+			}
+		}
+		logger.Infof("Starting incremental sync for stream [%s] from writetime[%d]", stream.ID(), lastWritetime)
+	}
+	// writes landing while the sync runs may hide behind a higher writetime read from another range
+	syncStartTime := time.Now().UnixMicro()
+
+	chunks := c.State.GetChunks(stream.Self())
+	var chunksArray []types.Chunk
+	if chunks == nil || chunks.Len() == 0 {
+		logger.Infof("Starting full load for stream [%s]", stream.ID())
+		chunksArray = splitTokenRanges(c.config.SplitCount)
+		c.State.SetChunks(stream.Self(), types.NewSet(chunksArray...))
+	} else {
+		chunksArray = chunks.Array()
+		sort.Slice(chunksArray, func(i, j int) bool {
+			lowerI, _, _, _ := chunkBounds(chunksArray[i])
+			lowerJ, _, _, _ := chunkBounds(chunksArray[j])
+			return lowerI < lowerJ
+		})
+	}
+
+	cursorField := utils.Ternary(incremental, stream.Cursor(), "").(string)
+	primaryKeys := stream.GetStream().SourceDefinedPrimaryKey.Array()
+	var maxMutex sync.Mutex
+	maxWritetime := lastWritetime
+
+	logger.Infof("Running backfill for %d token ranges", len(chunksArray))
+	// notice: err is declared in return, reason: defer call can access it
+	processChunk := func(ctx context.Context, chunk types.Chunk) (err error) {
+		lower, upper, inclusive, err := chunkBounds(chunk)
+		if err != nil {
+			return fmt.Errorf("invalid token range[%v-%v]: %s", chunk.Min, chunk.Max, err)
+		}
+
+		threadContext, cancelThread := context.WithCancel(ctx)
+		defer cancelThread()
+
+		waitChannel := make(chan error, 1)
+		insert, err := pool.NewThread(threadContext, stream, protocol.WithErrorChannel(waitChannel))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			insert.Close()
+			if err == nil {
+				// wait for chunk completion
+				err = <-waitChannel
+			}
+		}()
+
+		query := buildTokenRangeQuery(c.config.Keyspace, table, cursorField, inclusive)
+		return base.RetryOnBackoff(c.config.RetryCount, 1*time.Minute, func() error {
+			iter := c.session.Query(query, lower, upper).WithContext(ctx).PageSize(c.config.PageSize).Iter()
+			for {
+				row := types.NewRecord()
+				if !iter.MapScan(row) {
+					break
+				}
+				if incremental {
+					writetime, _ := typeutils.ReformatInt64(row[writetimeColumn])
+					delete(row, writetimeColumn)
+					if writetime <= lastWritetime {
+						types.ReleaseRecord(row)
+						continue
+					}
+					maxMutex.Lock()
+					maxWritetime = max(maxWritetime, writetime)
+					maxMutex.Unlock()
+				}
+				for column, value := range row {
+					row[column] = normalizeValue(value)
+				}
+				if err := insert.Insert(types.CreatePooledRawRecord(utils.GetKeysHash(row, primaryKeys...), row, 0)); err != nil {
+					return fmt.Errorf("failed to insert record: %s", err)
+				}
+			}
+			return iter.Close()
+		})
+	}
+
+	err = utils.Concurrent(ctx, chunksArray, c.config.MaxThreads, func(ctx context.Context, chunk types.Chunk, number int) error {
+		batchStartTime := time.Now()
+		if err := processChunk(ctx, chunk); err != nil {
+			return err
+		}
+		// remove success chunk from state
+		c.State.RemoveChunk(stream.Self(), chunk)
+		logger.Debugf("token range[%d] with min[%v]-max[%v] completed in %0.2f seconds", number, chunk.Min, chunk.Max, time.Since(batchStartTime).Seconds())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if incremental {
+		c.State.SetCursor(stream.Self(), writetimeCursorKey, min(maxWritetime, syncStartTime))
+	}
+	return nil
+}
+
+// buildTokenRangeQuery selects all columns of a table within (lower, upper] of the token ring,
+// or [lower, upper] when inclusive, including the writetime of cursorField when set
+func buildTokenRangeQuery(keyspace string, table *Table, cursorField string, inclusive bool) string {
+	columns := make([]string, 0, len(table.Columns)+1)
+	for _, column := range table.Columns {
+		columns = append(columns, quoteIdentifier(column))
+	}
+	if cursorField != "" {
+		columns = append(columns, fmt.Sprintf("WRITETIME(%s) AS %s", quoteIdentifier(cursorField), writetimeColumn))
+	}
+
+	partitionKeys := make([]string, 0, len(table.PartitionKeys))
+	for _, key := range table.PartitionKeys {
+		partitionKeys = append(partitionKeys, quoteIdentifier(key))
+	}
+	token := fmt.Sprintf("token(%s)", strings.Join(partitionKeys, ", "))
+
+	return fmt.Sprintf("SELECT %s FROM %s.%s WHERE %s %s ? AND %s <= ?",
+		strings.Join(columns, ", "), quoteIdentifier(keyspace), quoteIdentifier(table.Name),
+		token, utils.Ternary(inclusive, ">=", ">"), token)
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
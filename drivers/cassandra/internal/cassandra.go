@@ -0,0 +1,212 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/datazip-inc/olake/drivers"
+	"github.com/datazip-inc/olake/drivers/base"
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/gocql/gocql"
+)
+
+const (
+	discoverTime        = 5 * time.Minute // maximum time allowed to discover all the streams
+	defaultBackoffCount = 3
+	getTablesQuery      = `SELECT table_name FROM system_schema.tables WHERE keyspace_name = ?`
+	getColumnsQuery     = `SELECT column_name, kind, position, type FROM system_schema.columns WHERE keyspace_name = ? AND table_name = ?`
+)
+
+type Cassandra struct {
+	*base.Driver
+	config  *Config
+	session *gocql.Session
+	tables  sync.Map // stream id to *Table; populated in discover
+}
+
+func init() {
+	drivers.Register("cassandra", func() protocol.Driver {
+		return &Cassandra{Driver: base.NewBase()}
+	})
+}
+
+// Table holds the columns of a cassandra table needed to build token range queries
+type Table struct {
+	Name          string
+	PartitionKeys []string
+	Columns       []string
+}
+
+// config reference; must be pointer
+func (c *Cassandra) GetConfigRef() protocol.Config {
+	c.config = &Config{}
+	return c.config
+}
+
+func (c *Cassandra) Spec() any {
+	return Config{}
+}
+
+func (c *Cassandra) Setup() error {
+	if err := c.config.Validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
+
+	cluster, err := c.config.Cluster()
+	if err != nil {
+		return err
+	}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		return fmt.Errorf("failed to connect cassandra: %s", err)
+	}
+	c.session = session
+
+	// check for default backoff count
+	if c.config.RetryCount < 0 {
+		logger.Infof("setting backoff retry count to default value %d", defaultBackoffCount)
+		c.config.RetryCount = defaultBackoffCount
+	} else {
+		// add 1 for first run
+		c.config.RetryCount += 1
+	}
+	return nil
+}
+
+func (c *Cassandra) Check() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	return c.session.Query(`SELECT release_version FROM system.local`).WithContext(ctx).Exec()
+}
+
+func (c *Cassandra) SetupState(state *types.State) {
+	state.Type = types.StreamType
+	c.State = state
+}
+
+func (c *Cassandra) Close() error {
+	if c.session != nil {
+		c.session.Close()
+	}
+	return c.config.Network.Close()
+}
+
+func (c *Cassandra) Type() string {
+	return "Cassandra"
+}
+
+func (c *Cassandra) Discover(_ bool) ([]*types.Stream, error) {
+	streams := c.GetStreams()
+	if len(streams) != 0 {
+		return streams, nil
+	}
+
+	logger.Infof("Starting discover for Cassandra keyspace %s", c.config.Keyspace)
+	discoverCtx, cancel := context.WithTimeout(context.Background(), discoverTime)
+	defer cancel()
+
+	var tableNames []string
+	iter := c.session.Query(getTablesQuery, c.config.Keyspace).WithContext(discoverCtx).Iter()
+	var tableName string
+	for iter.Scan(&tableName) {
+		tableNames = append(tableNames, tableName)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to list tables: %s", err)
+	}
+
+	failures := &types.DiscoverError{}
+	err := utils.Concurrent(discoverCtx, tableNames, c.DiscoverConcurrency(c.config.MaxThreads), func(ctx context.Context, tableName string, _ int) error {
+		stream, err := c.populateStream(ctx, tableName)
+		if err != nil {
+			// the others are still sampled; failures are reported once they are
+			failures.Add(utils.StreamIdentifier(tableName, c.config.Keyspace), err)
+			return nil
+		}
+		stream.SyncMode = c.config.DefaultMode
+		// cache stream
+		c.AddStream(stream)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetStreams(), failures.Err()
+}
+
+func (c *Cassandra) Read(ctx context.Context, pool *protocol.WriterPool, stream protocol.Stream) error {
+	switch stream.GetSyncMode() {
+	case types.FULLREFRESH, types.INCREMENTAL:
+		return c.backfill(ctx, pool, stream)
+	}
+
+	return nil
+}
+
+func (c *Cassandra) populateStream(ctx context.Context, tableName string) (*types.Stream, error) {
+	type column struct {
+		name     string
+		kind     string
+		position int
+		cqlType  string
+	}
+
+	var columns []column
+	iter := c.session.Query(getColumnsQuery, c.config.Keyspace, tableName).WithContext(ctx).Iter()
+	var col column
+	for iter.Scan(&col.name, &col.kind, &col.position, &col.cqlType) {
+		columns = append(columns, col)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	// partition keys need to be passed to token() in their defined order
+	sort.SliceStable(columns, func(i, j int) bool {
+		return columns[i].position < columns[j].position
+	})
+
+	stream := types.NewStream(tableName, c.config.Keyspace).WithSyncMode(types.FULLREFRESH)
+	table := &Table{Name: tableName}
+	for _, col := range columns {
+		table.Columns = append(table.Columns, col.name)
+		switch col.kind {
+		case "partition_key":
+			table.PartitionKeys = append(table.PartitionKeys, col.name)
+			stream.WithPrimaryKey(col.name)
+		case "clustering":
+			stream.WithPrimaryKey(col.name)
+		case "regular":
+			if writetimeSupported(col.cqlType) {
+				stream.WithCursorField(col.name)
+			}
+		}
+		stream.UpsertField(col.name, cqlTypeToDataType(col.cqlType), col.kind == "regular")
+	}
+	if len(table.PartitionKeys) == 0 {
+		return nil, fmt.Errorf("no partition key found")
+	}
+
+	// incremental reads compare WRITETIME of the cursor column with the last synced one
+	if stream.AvailableCursorFields.Len() > 0 {
+		stream.WithSyncMode(types.INCREMENTAL)
+	}
+
+	c.tables.Store(stream.ID(), table)
+	return stream, nil
+}
+
+func (c *Cassandra) getTable(stream protocol.Stream) (*Table, error) {
+	table, found := c.tables.Load(stream.ID())
+	if !found {
+		return nil, fmt.Errorf("table for stream[%s] not discovered", stream.ID())
+	}
+	return table.(*Table), nil
+}
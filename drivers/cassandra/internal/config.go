@@ -0,0 +1,96 @@
+package driver
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/network"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/gocql/gocql"
+)
+
+type Config struct {
+	Hosts       []string       `json:"hosts" validate:"required,min=1"`
+	Port        int            `json:"port"`
+	Keyspace    string         `json:"keyspace" validate:"required"`
+	Username    string         `json:"username"`
+	Password    string         `json:"password"`
+	Consistency string         `json:"consistency"`
+	TLS         bool           `json:"tls"`
+	SplitCount  int            `json:"split_count"` // number of token ranges each table is split into
+	PageSize    int            `json:"page_size"`
+	MaxThreads  int            `json:"max_threads"`
+	DefaultMode types.SyncMode `json:"default_mode"`
+	RetryCount  int            `json:"backoff_retry_count"`
+	// proxy and tls settings; tls here enables tls on the connection
+	Network *network.Config `json:"network,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	if c.Port == 0 {
+		c.Port = 9042
+	}
+	if c.Consistency == "" {
+		c.Consistency = "LOCAL_QUORUM"
+	}
+	if _, err := gocql.ParseConsistencyWrapper(c.Consistency); err != nil {
+		return fmt.Errorf("invalid consistency[%s]: %s", c.Consistency, err)
+	}
+	if err := c.Network.Validate(); err != nil {
+		return err
+	}
+	if c.MaxThreads <= 0 {
+		// set default threads
+		logger.Info("setting max threads to default[10]")
+		c.MaxThreads = 10
+	}
+	if c.SplitCount <= 0 {
+		// a few ranges per thread keeps threads busy when token ownership is skewed
+		c.SplitCount = c.MaxThreads * 4
+	}
+	if c.PageSize <= 0 {
+		c.PageSize = 5000
+	}
+	if c.DefaultMode == "" {
+		c.DefaultMode = types.FULLREFRESH
+	}
+
+	return utils.Validate(c)
+}
+
+// Cluster returns the gocql cluster configuration; Validate must be called first
+func (c *Config) Cluster() (*gocql.ClusterConfig, error) {
+	cluster := gocql.NewCluster(c.Hosts...)
+	cluster.Port = c.Port
+	cluster.Keyspace = c.Keyspace
+	cluster.Consistency, _ = gocql.ParseConsistencyWrapper(c.Consistency)
+	cluster.Timeout = 1 * time.Minute
+	cluster.ConnectTimeout = 30 * time.Second
+	cluster.NumConns = c.MaxThreads
+	if c.Username != "" {
+		cluster.Authenticator = gocql.PasswordAuthenticator{
+			Username: c.Username,
+			Password: c.Password,
+		}
+	}
+	tlsConfig, err := c.Network.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil && c.TLS {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if tlsConfig != nil {
+		cluster.SslOpts = &gocql.SslOptions{
+			Config:                 tlsConfig,
+			EnableHostVerification: !tlsConfig.InsecureSkipVerify,
+		}
+	}
+	if c.Network.Enabled() {
+		cluster.Dialer = c.Network
+	}
+	return cluster, nil
+}
@@ -0,0 +1,113 @@
+package driver
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+	"github.com/gocql/gocql"
+)
+
+// cqlTypeToDataType maps a CQL type as reported by system_schema.columns into an olake type;
+// frozen wrappers are unwrapped and user defined types resolve to objects
+func cqlTypeToDataType(cqlType string) types.DataType {
+	cqlType = strings.ToLower(strings.TrimSpace(cqlType))
+	if inner, found := unwrapType(cqlType, "frozen"); found {
+		return cqlTypeToDataType(inner)
+	}
+
+	switch {
+	case strings.HasPrefix(cqlType, "list<"), strings.HasPrefix(cqlType, "set<"), strings.HasPrefix(cqlType, "tuple<"):
+		return types.Array
+	case strings.HasPrefix(cqlType, "map<"):
+		return types.Object
+	}
+
+	switch cqlType {
+	case "ascii", "text", "varchar", "inet", "uuid", "timeuuid", "blob", "decimal", "varint", "duration":
+		return types.String
+	case "tinyint", "smallint", "int", "bigint", "counter", "time":
+		return types.Int64
+	case "float", "double":
+		return types.Float64
+	case "boolean":
+		return types.Bool
+	case "timestamp", "date":
+		return types.Timestamp
+	default:
+		// remaining names are user defined types of the keyspace
+		return types.Object
+	}
+}
+
+// writetimeSupported reports whether WRITETIME() can be selected for a column of cqlType;
+// cassandra rejects it on non-frozen collections and user defined types
+func writetimeSupported(cqlType string) bool {
+	cqlType = strings.ToLower(strings.TrimSpace(cqlType))
+	if strings.HasPrefix(cqlType, "frozen<") {
+		return true
+	}
+	return cqlTypeToDataType(cqlType) != types.Array && cqlTypeToDataType(cqlType) != types.Object
+}
+
+func unwrapType(cqlType, wrapper string) (string, bool) {
+	prefix := wrapper + "<"
+	if strings.HasPrefix(cqlType, prefix) && strings.HasSuffix(cqlType, ">") {
+		return cqlType[len(prefix) : len(cqlType)-1], true
+	}
+	return cqlType, false
+}
+
+// normalizeValue converts values scanned by gocql into values the writers understand
+func normalizeValue(value any) any {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		return value
+	case time.Duration:
+		return value.Nanoseconds()
+	case gocql.Duration:
+		return fmt.Sprintf("%dmo%dd%dns", value.Months, value.Days, value.Nanoseconds)
+	case gocql.UUID:
+		return value.String()
+	case []byte:
+		return base64.StdEncoding.EncodeToString(value)
+	case map[string]any:
+		// user defined types
+		for key, inner := range value {
+			value[key] = normalizeValue(inner)
+		}
+		return value
+	}
+
+	reflectValue := reflect.ValueOf(value)
+	switch reflectValue.Kind() {
+	case reflect.Pointer:
+		if reflectValue.IsNil() {
+			return nil
+		}
+		// *inf.Dec and *big.Int
+		if stringer, ok := value.(fmt.Stringer); ok {
+			return stringer.String()
+		}
+		return normalizeValue(reflectValue.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		items := make([]any, 0, reflectValue.Len())
+		for i := 0; i < reflectValue.Len(); i++ {
+			items = append(items, normalizeValue(reflectValue.Index(i).Interface()))
+		}
+		return items
+	case reflect.Map:
+		object := make(map[string]any, reflectValue.Len())
+		iter := reflectValue.MapRange()
+		for iter.Next() {
+			object[fmt.Sprint(normalizeValue(iter.Key().Interface()))] = normalizeValue(iter.Value().Interface())
+		}
+		return object
+	}
+	return value
+}
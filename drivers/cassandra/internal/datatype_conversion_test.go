@@ -0,0 +1,87 @@
+package driver
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+	"github.com/gocql/gocql"
+)
+
+func TestCQLTypeToDataType(t *testing.T) {
+	cases := map[string]types.DataType{
+		"text":                       types.String,
+		"uuid":                       types.String,
+		"bigint":                     types.Int64,
+		"double":                     types.Float64,
+		"boolean":                    types.Bool,
+		"timestamp":                  types.Timestamp,
+		"list<int>":                  types.Array,
+		"set<text>":                  types.Array,
+		"frozen<tuple<int, text>>":   types.Array,
+		"map<text, frozen<address>>": types.Object,
+		"frozen<address>":            types.Object,
+		"address":                    types.Object,
+	}
+	for cqlType, expected := range cases {
+		if got := cqlTypeToDataType(cqlType); got != expected {
+			t.Errorf("%s: expected %s, got %s", cqlType, expected, got)
+		}
+	}
+}
+
+func TestWritetimeSupported(t *testing.T) {
+	for cqlType, expected := range map[string]bool{
+		"text":              true,
+		"frozen<list<int>>": true,
+		"list<int>":         false,
+		"address":           false,
+	} {
+		if got := writetimeSupported(cqlType); got != expected {
+			t.Errorf("%s: expected %t, got %t", cqlType, expected, got)
+		}
+	}
+}
+
+func TestNormalizeValue(t *testing.T) {
+	uuid := gocql.TimeUUID()
+	value := normalizeValue(map[string]any{
+		"id":    uuid,
+		"tags":  []string{"a"},
+		"attrs": map[int]string{1: "x"},
+		"blob":  []byte("hi"),
+		"at":    time.Duration(5),
+	})
+	expected := map[string]any{
+		"id":    uuid.String(),
+		"tags":  []any{"a"},
+		"attrs": map[string]any{"1": "x"},
+		"blob":  "aGk=",
+		"at":    int64(5),
+	}
+	if !reflect.DeepEqual(value, expected) {
+		t.Fatalf("expected %v, got %v", expected, value)
+	}
+}
+
+func TestSplitTokenRanges(t *testing.T) {
+	chunks := splitTokenRanges(4)
+	if len(chunks) != 4 {
+		t.Fatalf("expected 4 chunks, got %d", len(chunks))
+	}
+	lower, _, inclusive, err := chunkBounds(chunks[0])
+	if err != nil || lower != math.MinInt64 || !inclusive {
+		t.Fatalf("first chunk must start inclusively at MinInt64, got %v", chunks[0])
+	}
+	_, upper, _, err := chunkBounds(chunks[3])
+	if err != nil || upper != math.MaxInt64 {
+		t.Fatalf("last chunk must end at MaxInt64, got %v", chunks[3])
+	}
+	for i := 1; i < len(chunks); i++ {
+		if chunks[i].Min != chunks[i-1].Max {
+			t.Fatalf("chunks %d and %d are not contiguous", i-1, i)
+		}
+	}
+}
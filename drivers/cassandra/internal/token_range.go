@@ -0,0 +1,47 @@
+package driver
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+// splitTokenRanges splits the Murmur3Partitioner token ring [MinInt64, MaxInt64] into count
+// contiguous chunks. Bounds are kept as strings so they survive a state file round trip
+// without losing int64 precision. Every chunk is read as token > Min AND token <= Max except
+// the first one, which must include MinInt64 itself
+func splitTokenRanges(count int) []types.Chunk {
+	if count <= 0 {
+		count = 1
+	}
+	ringSize := new(big.Int).Sub(big.NewInt(math.MaxInt64), big.NewInt(math.MinInt64))
+	step := new(big.Int).Div(ringSize, big.NewInt(int64(count)))
+
+	chunks := make([]types.Chunk, 0, count)
+	lower := big.NewInt(math.MinInt64)
+	for i := 0; i < count; i++ {
+		upper := new(big.Int).Add(lower, step)
+		if i == count-1 {
+			upper = big.NewInt(math.MaxInt64)
+		}
+		chunks = append(chunks, types.Chunk{Min: lower.String(), Max: upper.String()})
+		lower = upper
+	}
+	return chunks
+}
+
+// chunkBounds parses a token chunk, reporting whether the lower bound is inclusive
+func chunkBounds(chunk types.Chunk) (int64, int64, bool, error) {
+	lower, err := strconv.ParseInt(fmt.Sprint(chunk.Min), 10, 64)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	upper, err := strconv.ParseInt(fmt.Sprint(chunk.Max), 10, 64)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return lower, upper, lower == math.MinInt64, nil
+}
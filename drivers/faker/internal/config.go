@@ -0,0 +1,78 @@
+package driver
+
+import (
+	"fmt"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+)
+
+type Config struct {
+	Streams []StreamConfig `json:"streams" validate:"required,min=1,dive"`
+	// seed of the generated values; the same seed always produces the same rows
+	Seed        int64          `json:"seed"`
+	MaxThreads  int            `json:"max_threads"`
+	DefaultMode types.SyncMode `json:"default_mode"`
+}
+
+// StreamConfig generates Rows rows with an integer id column and the configured columns
+type StreamConfig struct {
+	Name    string         `json:"name" validate:"required"`
+	Rows    int64          `json:"rows" validate:"required,gt=0"`
+	Columns []ColumnConfig `json:"columns"`
+	// throughput cap of the stream in rows per second; unlimited when 0
+	RowsPerSecond int64 `json:"rows_per_second"`
+}
+
+type ColumnConfig struct {
+	Name string         `json:"name" validate:"required"`
+	Type types.DataType `json:"type" validate:"required"`
+	// number of distinct values; unlimited when 0
+	Cardinality int64 `json:"cardinality"`
+	// share of null values between 0 and 1
+	NullRatio float64 `json:"null_ratio"`
+	// length of generated strings
+	Length int `json:"length"`
+}
+
+func (c *Config) Validate() error {
+	if c.MaxThreads <= 0 {
+		// set default threads
+		logger.Info("setting max threads to default[4]")
+		c.MaxThreads = 4
+	}
+	if c.DefaultMode == "" {
+		c.DefaultMode = types.FULLREFRESH
+	}
+
+	names := make(map[string]bool)
+	for idx := range c.Streams {
+		stream := &c.Streams[idx]
+		if names[stream.Name] {
+			return fmt.Errorf("duplicate stream[%s] in config", stream.Name)
+		}
+		names[stream.Name] = true
+
+		columns := map[string]bool{idColumn: true}
+		for cidx := range stream.Columns {
+			column := &stream.Columns[cidx]
+			if columns[column.Name] {
+				return fmt.Errorf("stream[%s]: duplicate column[%s]", stream.Name, column.Name)
+			}
+			columns[column.Name] = true
+
+			if !utils.ExistInArray(supportedTypes, column.Type) {
+				return fmt.Errorf("stream[%s]: unsupported type[%s] of column[%s]; supported are %v", stream.Name, column.Type, column.Name, supportedTypes)
+			}
+			if column.NullRatio < 0 || column.NullRatio > 1 {
+				return fmt.Errorf("stream[%s]: null_ratio of column[%s] must be between 0 and 1", stream.Name, column.Name)
+			}
+			if column.Length <= 0 {
+				column.Length = 16
+			}
+		}
+	}
+
+	return utils.Validate(c)
+}
@@ -0,0 +1,198 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/datazip-inc/olake/drivers"
+	"github.com/datazip-inc/olake/drivers/base"
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+)
+
+const (
+	namespace = "faker"
+	// rows generated per writer thread
+	chunkSize int64 = 100_000
+)
+
+type Faker struct {
+	*base.Driver
+	config *Config
+}
+
+func init() {
+	drivers.Register("faker", func() protocol.Driver {
+		return &Faker{Driver: base.NewBase()}
+	})
+}
+
+// config reference; must be pointer
+func (f *Faker) GetConfigRef() protocol.Config {
+	f.config = &Config{}
+	return f.config
+}
+
+func (f *Faker) Spec() any {
+	return Config{}
+}
+
+func (f *Faker) Setup() error {
+	if err := f.config.Validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
+	return nil
+}
+
+func (f *Faker) Check() error {
+	return nil
+}
+
+func (f *Faker) SetupState(state *types.State) {
+	state.Type = types.StreamType
+	f.State = state
+}
+
+func (f *Faker) Type() string {
+	return "Faker"
+}
+
+func (f *Faker) Discover(_ bool) ([]*types.Stream, error) {
+	streams := f.GetStreams()
+	if len(streams) != 0 {
+		return streams, nil
+	}
+
+	for _, streamConfig := range f.config.Streams {
+		stream := types.NewStream(streamConfig.Name, namespace).WithSyncMode(types.FULLREFRESH, types.INCREMENTAL).WithPrimaryKey(idColumn).WithCursorField(idColumn)
+		stream.UpsertField(idColumn, types.Int64, false)
+		for _, column := range streamConfig.Columns {
+			stream.UpsertField(column.Name, column.Type, column.NullRatio > 0)
+		}
+		stream.SyncMode = f.config.DefaultMode
+		f.AddStream(stream)
+	}
+
+	return f.GetStreams(), nil
+}
+
+// Read generates the rows of a stream; incremental reads continue after the last generated id,
+// so raising rows in the config simulates a growing source
+func (f *Faker) Read(ctx context.Context, pool *protocol.WriterPool, stream protocol.Stream) error {
+	streamConfig, err := f.streamConfig(stream.Name())
+	if err != nil {
+		return err
+	}
+
+	start := int64(1)
+	if stream.GetSyncMode() == types.INCREMENTAL {
+		if cursor := f.State.GetCursor(stream.Self(), idColumn); cursor != nil {
+			last, err := toInt64(cursor)
+			if err != nil {
+				return fmt.Errorf("invalid cursor[%v] of stream[%s]: %s", cursor, stream.ID(), err)
+			}
+			start = last + 1
+		}
+	}
+	if start > streamConfig.Rows {
+		logger.Infof("no new rows to generate for stream[%s]", stream.ID())
+		return nil
+	}
+
+	var chunks [][2]int64
+	for from := start; from <= streamConfig.Rows; from += chunkSize {
+		chunks = append(chunks, [2]int64{from, min(from+chunkSize-1, streamConfig.Rows)})
+	}
+	pool.AddRecordsToSync(stream, streamConfig.Rows-start+1)
+	logger.Infof("generating rows %d to %d of stream[%s] in %d chunks", start, streamConfig.Rows, stream.ID(), len(chunks))
+
+	gen := newGenerator(f.config.Seed, streamConfig.Columns)
+	limiter := newThrottle(streamConfig.RowsPerSecond)
+	err = utils.Concurrent(ctx, chunks, f.config.MaxThreads, func(ctx context.Context, chunk [2]int64, _ int) error {
+		return f.generateChunk(ctx, pool, stream, gen, limiter, chunk[0], chunk[1])
+	})
+	if err != nil {
+		return err
+	}
+
+	if stream.GetSyncMode() == types.INCREMENTAL {
+		f.State.SetCursor(stream.Self(), idColumn, streamConfig.Rows)
+	}
+	return nil
+}
+
+func (f *Faker) generateChunk(ctx context.Context, pool *protocol.WriterPool, stream protocol.Stream, gen *generator, limiter *throttle, from, to int64) (err error) {
+	waitChannel := make(chan error, 1)
+	insert, err := pool.NewThread(ctx, stream, protocol.WithErrorChannel(waitChannel))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		insert.Close()
+		if err == nil {
+			// wait for writer completion
+			err = <-waitChannel
+		}
+	}()
+
+	for row := from; row <= to; row++ {
+		limiter.Wait()
+		record := gen.Row(row)
+		if err := insert.Insert(types.CreateRawRecord(utils.GetKeysHash(record, idColumn), record, 0)); err != nil {
+			return fmt.Errorf("failed to insert row[%d]: %s", row, err)
+		}
+	}
+	return nil
+}
+
+// CountRows returns the rows the stream generates; the source of faker streams is the config
+func (f *Faker) CountRows(_ context.Context, stream protocol.Stream) (int64, error) {
+	streamConfig, err := f.streamConfig(stream.Name())
+	if err != nil {
+		return 0, err
+	}
+	return streamConfig.Rows, nil
+}
+
+// ScanRows generates every row of the stream again; rows only depend on the seed and their id
+func (f *Faker) ScanRows(ctx context.Context, stream protocol.Stream, fn func(row map[string]any) error) error {
+	streamConfig, err := f.streamConfig(stream.Name())
+	if err != nil {
+		return err
+	}
+	gen := newGenerator(f.config.Seed, streamConfig.Columns)
+	for row := int64(1); row <= streamConfig.Rows; row++ {
+		if row%chunkSize == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := fn(gen.Row(row)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Faker) streamConfig(name string) (*StreamConfig, error) {
+	for idx := range f.config.Streams {
+		if f.config.Streams[idx].Name == name {
+			return &f.config.Streams[idx], nil
+		}
+	}
+	return nil, fmt.Errorf("stream[%s] not found in config", name)
+}
+
+// toInt64 reads cursors which are float64 once loaded from a state file
+func toInt64(value any) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("unexpected type %T", value)
+	}
+}
@@ -0,0 +1,34 @@
+package driver
+
+import (
+	"testing"
+
+	"github.com/datazip-inc/olake/drivers"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/testkit"
+)
+
+func TestAcceptance(t *testing.T) {
+	testkit.Run(t, &testkit.Suite{
+		NewDriver: func() protocol.Driver {
+			driver, err := drivers.New("faker")
+			if err != nil {
+				t.Fatal(err)
+			}
+			return driver
+		},
+		Config: map[string]any{
+			"seed": 7,
+			"streams": []map[string]any{{
+				"name": "users",
+				"rows": 250,
+				"columns": []map[string]any{
+					{"name": "name", "type": "string", "length": 12},
+					{"name": "age", "type": "integer", "cardinality": 80},
+					{"name": "signed_up_at", "type": "timestamp", "null_ratio": 0.1},
+					{"name": "tags", "type": "array"},
+				},
+			}},
+		},
+	})
+}
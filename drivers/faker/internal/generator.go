@@ -0,0 +1,115 @@
+package driver
+
+import (
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+const idColumn = "id"
+
+var (
+	supportedTypes = []types.DataType{types.Int64, types.Float64, types.String, types.Bool, types.Timestamp, types.Object, types.Array}
+	epoch          = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// generator produces the value of every column of a row from the row number alone, so that
+// rows can be generated in any order by any number of threads and stay reproducible
+type generator struct {
+	seed    uint64
+	columns []ColumnConfig
+}
+
+func newGenerator(seed int64, columns []ColumnConfig) *generator {
+	return &generator{seed: uint64(seed), columns: columns}
+}
+
+func (g *generator) Row(row int64) map[string]any {
+	record := make(map[string]any, len(g.columns)+1)
+	record[idColumn] = row
+	for idx := range g.columns {
+		column := &g.columns[idx]
+		columnSeed := g.seed ^ (uint64(idx+1) * 0x9e3779b97f4a7c15)
+		if column.NullRatio > 0 && float64(mix(columnSeed^0xabcdef, uint64(row))%1_000_000)/1_000_000 < column.NullRatio {
+			record[column.Name] = nil
+			continue
+		}
+
+		valueIndex := uint64(row)
+		if column.Cardinality > 0 {
+			valueIndex %= uint64(column.Cardinality)
+		}
+		record[column.Name] = value(column, mix(columnSeed, valueIndex))
+	}
+	return record
+}
+
+func value(column *ColumnConfig, hash uint64) any {
+	switch column.Type {
+	case types.Int64:
+		return int64(hash % 1_000_000_000)
+	case types.Float64:
+		return float64(hash%100_000_000) / 100
+	case types.Bool:
+		return hash&1 == 1
+	case types.Timestamp:
+		// spread over five years
+		return epoch.Add(time.Duration(hash%(5*365*24*3600)) * time.Second)
+	case types.Object:
+		return map[string]any{"key": randomString(hash, column.Length), "value": int64(hash % 1000)}
+	case types.Array:
+		return []any{int64(hash % 1000), int64((hash >> 16) % 1000), int64((hash >> 32) % 1000)}
+	default:
+		return randomString(hash, column.Length)
+	}
+}
+
+func randomString(hash uint64, length int) string {
+	buf := make([]byte, length)
+	for idx := range buf {
+		hash = mix(hash, uint64(idx))
+		buf[idx] = alphabet[hash%uint64(len(alphabet))]
+	}
+	return string(buf)
+}
+
+// mix is the splitmix64 finalizer over seed and index
+func mix(seed, index uint64) uint64 {
+	z := seed + index*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// throttle paces a stream to a rows per second limit shared by all of its threads
+type throttle struct {
+	interval time.Duration
+	next     chan time.Time
+}
+
+func newThrottle(rowsPerSecond int64) *throttle {
+	if rowsPerSecond <= 0 {
+		return nil
+	}
+	t := &throttle{interval: time.Second / time.Duration(rowsPerSecond), next: make(chan time.Time, 1)}
+	t.next <- time.Now()
+	return t
+}
+
+// Wait blocks until the next row may be generated
+func (t *throttle) Wait() {
+	if t == nil {
+		return
+	}
+	next := <-t.next
+	if delay := time.Until(next); delay > 0 {
+		time.Sleep(delay)
+	}
+	// don't let a slow writer build up a burst
+	if now := time.Now(); next.Before(now.Add(-time.Second)) {
+		next = now
+	}
+	t.next <- next.Add(t.interval)
+}
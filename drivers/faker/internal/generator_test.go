@@ -0,0 +1,54 @@
+package driver
+
+import (
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestGeneratorDeterministic(t *testing.T) {
+	columns := []ColumnConfig{
+		{Name: "name", Type: types.String, Length: 8},
+		{Name: "score", Type: types.Float64},
+		{Name: "created_at", Type: types.Timestamp},
+	}
+	first := newGenerator(42, columns).Row(7)
+	second := newGenerator(42, columns).Row(7)
+	for column, value := range first {
+		if second[column] != value {
+			t.Fatalf("column[%s] differs between runs: %v != %v", column, value, second[column])
+		}
+	}
+	if len(first["name"].(string)) != 8 {
+		t.Fatalf("expected string of length 8, got %q", first["name"])
+	}
+	if other := newGenerator(43, columns).Row(7); other["name"] == first["name"] {
+		t.Fatal("expected a different seed to change values")
+	}
+}
+
+func TestGeneratorCardinalityAndNulls(t *testing.T) {
+	gen := newGenerator(1, []ColumnConfig{
+		{Name: "country", Type: types.String, Length: 4, Cardinality: 5},
+		{Name: "note", Type: types.String, Length: 4, NullRatio: 0.5},
+	})
+
+	countries := make(map[any]bool)
+	nulls := 0
+	for row := int64(1); row <= 1000; row++ {
+		record := gen.Row(row)
+		if record["id"] != row {
+			t.Fatalf("expected id %d, got %v", row, record["id"])
+		}
+		countries[record["country"]] = true
+		if record["note"] == nil {
+			nulls++
+		}
+	}
+	if len(countries) != 5 {
+		t.Fatalf("expected 5 distinct countries, got %d", len(countries))
+	}
+	if nulls < 400 || nulls > 600 {
+		t.Fatalf("expected about half nulls, got %d of 1000", nulls)
+	}
+}
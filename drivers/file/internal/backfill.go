@@ -0,0 +1,53 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/files"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+)
+
+// backfill reads every file matching the stream path, one file per thread
+func (f *File) backfill(backfillCtx context.Context, pool *protocol.WriterPool, stream protocol.Stream) error {
+	streamConfig, err := f.streamConfig(stream.Name())
+	if err != nil {
+		return err
+	}
+
+	found, err := f.listFiles(backfillCtx, streamConfig)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Starting full load for stream [%s] with %d files", stream.ID(), len(found))
+
+	return utils.Concurrent(backfillCtx, found, f.config.MaxThreads, func(ctx context.Context, file files.FileInfo, _ int) (err error) {
+		fileStartTime := time.Now()
+		waitChannel := make(chan error, 1)
+		insert, err := pool.NewThread(ctx, stream, protocol.WithErrorChannel(waitChannel))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			insert.Close()
+			if err == nil {
+				// wait for file completion
+				err = <-waitChannel
+			}
+			if err == nil {
+				logger.Infof("file[%s] completed in %0.2f seconds", file.Path, time.Since(fileStartTime).Seconds())
+			}
+		}()
+
+		return f.readFile(ctx, streamConfig, file.Path, func(record map[string]any) (bool, error) {
+			if err := insert.Insert(types.CreateRawRecord(utils.GetKeysHash(record, files.FileColumn, files.RowColumn), record, 0)); err != nil {
+				return false, fmt.Errorf("failed to insert record of file[%s]: %s", file.Path, err)
+			}
+			return true, nil
+		})
+	})
+}
@@ -0,0 +1,84 @@
+package driver
+
+import (
+	"fmt"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/files"
+	"github.com/datazip-inc/olake/pkg/network"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+)
+
+type Config struct {
+	Streams     []StreamConfig `json:"streams" validate:"required,min=1,dive"`
+	S3          *S3Config      `json:"s3,omitempty"`
+	GCS         *GCSConfig     `json:"gcs,omitempty"`
+	Sheets      *SheetsConfig  `json:"google_sheets,omitempty"`
+	SampleSize  int            `json:"sample_size"`
+	MaxThreads  int            `json:"max_threads"`
+	DefaultMode types.SyncMode `json:"default_mode"`
+	// proxy and tls settings of s3, gcs and http sources
+	Network *network.Config `json:"network,omitempty"`
+}
+
+// StreamConfig maps all files matching Path into stream Name;
+// Path is a local path, s3://, gs://, http(s):// URL or gsheets://<spreadsheet id>/<sheet>
+type StreamConfig struct {
+	Name string `json:"name" validate:"required"`
+	Path string `json:"path" validate:"required"`
+	files.Options
+}
+
+type S3Config struct {
+	Region    string `json:"region"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+	Endpoint  string `json:"endpoint"`
+}
+
+type GCSConfig struct {
+	// OAuth2 access token; public buckets can be read without one
+	AccessToken string `json:"access_token"`
+}
+
+type SheetsConfig struct {
+	// OAuth2 access token; sheets shared through link can be read without one
+	AccessToken string `json:"access_token"`
+}
+
+func (c *Config) Validate() error {
+	if err := c.Network.Validate(); err != nil {
+		return err
+	}
+	if c.SampleSize <= 0 {
+		c.SampleSize = 1000
+	}
+	if c.MaxThreads <= 0 {
+		// set default threads
+		logger.Info("setting max threads to default[10]")
+		c.MaxThreads = 10
+	}
+	if c.DefaultMode == "" {
+		c.DefaultMode = types.FULLREFRESH
+	}
+
+	names := make(map[string]bool)
+	for idx := range c.Streams {
+		stream := &c.Streams[idx]
+		if names[stream.Name] {
+			return fmt.Errorf("duplicate stream name[%s] in config", stream.Name)
+		}
+		names[stream.Name] = true
+
+		if isSheet(stream.Path) {
+			// sheets are exported as csv
+			stream.Format = files.CSV
+		}
+		if err := stream.Options.Validate(); err != nil {
+			return fmt.Errorf("stream[%s]: %s", stream.Name, err)
+		}
+	}
+
+	return utils.Validate(c)
+}
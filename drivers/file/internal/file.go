@@ -0,0 +1,195 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/datazip-inc/olake/drivers"
+	"github.com/datazip-inc/olake/drivers/base"
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/files"
+	"github.com/datazip-inc/olake/pkg/httpclient"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+	"github.com/datazip-inc/olake/utils"
+)
+
+const (
+	discoverTime = 5 * time.Minute // maximum time allowed to discover all the streams
+	namespace    = "files"
+)
+
+type File struct {
+	*base.Driver
+	config *Config
+	s3     *s3Source
+	// shared by the gcs, sheets and http sources
+	http *httpclient.Client
+}
+
+func init() {
+	drivers.Register("file", func() protocol.Driver {
+		return &File{Driver: base.NewBase()}
+	})
+}
+
+// config reference; must be pointer
+func (f *File) GetConfigRef() protocol.Config {
+	f.config = &Config{}
+	return f.config
+}
+
+func (f *File) Spec() any {
+	return Config{}
+}
+
+func (f *File) Setup() error {
+	if err := f.config.Validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
+
+	transport, err := f.config.Network.HTTPTransport()
+	if err != nil {
+		return err
+	}
+	f.http = httpclient.New(httpclient.Config{Transport: transport})
+	if f.config.S3 != nil {
+		source, err := newS3Source(f.config.S3, f.http.Client)
+		if err != nil {
+			return err
+		}
+		f.s3 = source
+	}
+	return nil
+}
+
+// Check makes sure every configured path matches at least one file
+func (f *File) Check() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	for _, streamConfig := range f.config.Streams {
+		found, err := f.listFiles(ctx, streamConfig)
+		if err != nil {
+			return err
+		}
+		if len(found) == 0 {
+			return fmt.Errorf("no files found for stream[%s] at [%s]", streamConfig.Name, streamConfig.Path)
+		}
+	}
+	return nil
+}
+
+func (f *File) SetupState(state *types.State) {
+	state.Type = types.StreamType
+	f.State = state
+}
+
+func (f *File) Type() string {
+	return "File"
+}
+
+func (f *File) Discover(_ bool) ([]*types.Stream, error) {
+	streams := f.GetStreams()
+	if len(streams) != 0 {
+		return streams, nil
+	}
+
+	logger.Infof("Starting discover for %d file streams", len(f.config.Streams))
+	discoverCtx, cancel := context.WithTimeout(context.Background(), discoverTime)
+	defer cancel()
+
+	failures := &types.DiscoverError{}
+	err := utils.Concurrent(discoverCtx, f.config.Streams, f.DiscoverConcurrency(f.config.MaxThreads), func(ctx context.Context, streamConfig StreamConfig, _ int) error {
+		stream, err := f.produceStreamSchema(ctx, streamConfig)
+		if err != nil {
+			// the others are still sampled; failures are reported once they are
+			failures.Add(streamConfig.Name, err)
+			return nil
+		}
+		stream.SyncMode = f.config.DefaultMode
+		// cache stream
+		f.AddStream(stream)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return f.GetStreams(), failures.Err()
+}
+
+func (f *File) Read(ctx context.Context, pool *protocol.WriterPool, stream protocol.Stream) error {
+	switch stream.GetSyncMode() {
+	case types.FULLREFRESH:
+		return f.backfill(ctx, pool, stream)
+	}
+
+	return nil
+}
+
+// sample rows from the first files of a stream to resolve its schema
+func (f *File) produceStreamSchema(ctx context.Context, streamConfig StreamConfig) (*types.Stream, error) {
+	logger.Infof("producing type schema for stream [%s] from [%s]", streamConfig.Name, streamConfig.Path)
+
+	found, err := f.listFiles(ctx, streamConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := types.NewStream(streamConfig.Name, namespace).WithSyncMode(types.FULLREFRESH).WithPrimaryKey(files.FileColumn, files.RowColumn)
+	var objects []map[string]any
+	for _, file := range found {
+		if len(objects) >= f.config.SampleSize {
+			break
+		}
+		err := f.readFile(ctx, streamConfig, file.Path, func(record map[string]any) (bool, error) {
+			objects = append(objects, record)
+			return len(objects) < f.config.SampleSize, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return stream, typeutils.Resolve(stream, objects...)
+}
+
+func (f *File) listFiles(ctx context.Context, streamConfig StreamConfig) ([]files.FileInfo, error) {
+	source, err := f.sourceFor(streamConfig.Path)
+	if err != nil {
+		return nil, err
+	}
+	found, err := source.List(ctx, streamConfig.Path)
+	if err != nil {
+		return nil, err
+	}
+	sortFiles(found)
+	return found, nil
+}
+
+func (f *File) readFile(ctx context.Context, streamConfig StreamConfig, file string, emit files.EmitFunc) error {
+	source, err := f.sourceFor(file)
+	if err != nil {
+		return err
+	}
+	reader, err := source.Open(ctx, file)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	return files.Read(reader, file, streamConfig.Options, emit)
+}
+
+// returns the configured stream for the stream name
+func (f *File) streamConfig(name string) (StreamConfig, error) {
+	for _, streamConfig := range f.config.Streams {
+		if streamConfig.Name == name {
+			return streamConfig, nil
+		}
+	}
+	return StreamConfig{}, fmt.Errorf("stream[%s] not present in config", name)
+}
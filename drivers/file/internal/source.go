@@ -0,0 +1,256 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/datazip-inc/olake/pkg/files"
+	"github.com/goccy/go-json"
+)
+
+const sheetsScheme = "gsheets://"
+
+func isSheet(path string) bool {
+	return strings.HasPrefix(path, sheetsScheme)
+}
+
+// sourceFor picks the storage backend from the path scheme
+func (f *File) sourceFor(path string) (files.Source, error) {
+	switch {
+	case strings.HasPrefix(path, "s3://"):
+		if f.s3 == nil {
+			return nil, fmt.Errorf("s3 config required to read [%s]", path)
+		}
+		return f.s3, nil
+	case strings.HasPrefix(path, "gs://"):
+		token := ""
+		if f.config.GCS != nil {
+			token = f.config.GCS.AccessToken
+		}
+		return &gcsSource{client: f.http.Client, token: token}, nil
+	case isSheet(path):
+		token := ""
+		if f.config.Sheets != nil {
+			token = f.config.Sheets.AccessToken
+		}
+		return &httpSource{client: f.http.Client, token: token}, nil
+	case strings.HasPrefix(path, "http://"), strings.HasPrefix(path, "https://"):
+		return &httpSource{client: f.http.Client}, nil
+	default:
+		return &localSource{}, nil
+	}
+}
+
+type localSource struct{}
+
+func (l *localSource) List(_ context.Context, pattern string) ([]files.FileInfo, error) {
+	pattern = strings.TrimPrefix(pattern, "file://")
+	root := filepath.Dir(files.GlobPrefix(pattern) + "x")
+
+	var found []files.FileInfo
+	err := filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !files.Match(pattern, path) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		found = append(found, files.FileInfo{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	return found, err
+}
+
+func (l *localSource) Open(_ context.Context, file string) (io.ReadCloser, error) {
+	return os.Open(file)
+}
+
+type s3Source struct {
+	client *s3.S3
+}
+
+func newS3Source(config *S3Config, client *http.Client) (*s3Source, error) {
+	s3Config := aws.Config{
+		Region:     aws.String(config.Region),
+		HTTPClient: client,
+	}
+	if config.AccessKey != "" && config.SecretKey != "" {
+		s3Config.Credentials = credentials.NewStaticCredentials(config.AccessKey, config.SecretKey, "")
+	}
+	if config.Endpoint != "" {
+		s3Config.Endpoint = aws.String(config.Endpoint)
+		s3Config.S3ForcePathStyle = aws.Bool(true)
+	}
+	sess, err := session.NewSession(&s3Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %s", err)
+	}
+	return &s3Source{client: s3.New(sess)}, nil
+}
+
+func splitBucketURL(path, scheme string) (string, string) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(path, scheme), "/")
+	return bucket, key
+}
+
+func (s *s3Source) List(ctx context.Context, pattern string) ([]files.FileInfo, error) {
+	bucket, keyPattern := splitBucketURL(pattern, "s3://")
+	var found []files.FileInfo
+	err := s.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(files.GlobPrefix(keyPattern)),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, object := range page.Contents {
+			if files.Match(keyPattern, *object.Key) {
+				found = append(found, files.FileInfo{
+					Path:    fmt.Sprintf("s3://%s/%s", bucket, *object.Key),
+					Size:    aws.Int64Value(object.Size),
+					ModTime: aws.TimeValue(object.LastModified),
+				})
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list [%s]: %s", pattern, err)
+	}
+	return found, nil
+}
+
+func (s *s3Source) Open(ctx context.Context, file string) (io.ReadCloser, error) {
+	bucket, key := splitBucketURL(file, "s3://")
+	output, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get [%s]: %s", file, err)
+	}
+	return output.Body, nil
+}
+
+// gcsSource talks to the GCS JSON API directly
+type gcsSource struct {
+	client *http.Client
+	token  string
+}
+
+func (g *gcsSource) List(ctx context.Context, pattern string) ([]files.FileInfo, error) {
+	bucket, keyPattern := splitBucketURL(pattern, "gs://")
+	var found []files.FileInfo
+	pageToken := ""
+	for {
+		query := url.Values{"prefix": {files.GlobPrefix(keyPattern)}}
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+		var page struct {
+			Items []struct {
+				Name    string    `json:"name"`
+				Size    int64     `json:"size,string"`
+				Updated time.Time `json:"updated"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		listURL := fmt.Sprintf("https://storage.googleapis.com/storage/v1/b/%s/o?%s", url.PathEscape(bucket), query.Encode())
+		if err := g.getJSON(ctx, listURL, &page); err != nil {
+			return nil, fmt.Errorf("failed to list [%s]: %s", pattern, err)
+		}
+		for _, item := range page.Items {
+			if files.Match(keyPattern, item.Name) {
+				found = append(found, files.FileInfo{Path: fmt.Sprintf("gs://%s/%s", bucket, item.Name), Size: item.Size, ModTime: item.Updated})
+			}
+		}
+		if page.NextPageToken == "" {
+			return found, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+func (g *gcsSource) Open(ctx context.Context, file string) (io.ReadCloser, error) {
+	bucket, key := splitBucketURL(file, "gs://")
+	return openURL(ctx, g.client, fmt.Sprintf("https://storage.googleapis.com/storage/v1/b/%s/o/%s?alt=media", url.PathEscape(bucket), url.PathEscape(key)), g.token)
+}
+
+func (g *gcsSource) getJSON(ctx context.Context, rawURL string, dest any) error {
+	body, err := openURL(ctx, g.client, rawURL, g.token)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	return json.NewDecoder(body).Decode(dest)
+}
+
+// httpSource reads single files over http(s); google sheets are exported as csv
+type httpSource struct {
+	client *http.Client
+	token  string
+}
+
+func (h *httpSource) List(_ context.Context, pattern string) ([]files.FileInfo, error) {
+	if !isSheet(pattern) && files.GlobPrefix(pattern) != pattern {
+		return nil, fmt.Errorf("glob patterns are not supported over http: %s", pattern)
+	}
+	return []files.FileInfo{{Path: pattern}}, nil
+}
+
+func (h *httpSource) Open(ctx context.Context, file string) (io.ReadCloser, error) {
+	if isSheet(file) {
+		file = sheetExportURL(file)
+	}
+	return openURL(ctx, h.client, file, h.token)
+}
+
+// sheetExportURL converts gsheets://<spreadsheet id>/<sheet name> into its csv export url
+func sheetExportURL(path string) string {
+	spreadsheet, sheet := splitBucketURL(path, sheetsScheme)
+	query := url.Values{"tqx": {"out:csv"}}
+	if sheet != "" {
+		query.Set("sheet", sheet)
+	}
+	return fmt.Sprintf("https://docs.google.com/spreadsheets/d/%s/gviz/tq?%s", url.PathEscape(spreadsheet), query.Encode())
+}
+
+func openURL(ctx context.Context, client *http.Client, rawURL, token string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s returned status %s", rawURL, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// sortFiles keeps partitioned file sets in a stable order between runs
+func sortFiles(found []files.FileInfo) {
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Path < found[j].Path
+	})
+}
@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/datazip-inc/olake"
+	_ "github.com/datazip-inc/olake/drivers/file/internal" // registering file driver
+)
+
+func main() {
+	olake.Run("file")
+}
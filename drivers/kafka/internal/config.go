@@ -0,0 +1,53 @@
+package driver
+
+import (
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/exclusion"
+	"github.com/datazip-inc/olake/pkg/network"
+	"github.com/datazip-inc/olake/pkg/schemaregistry"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+)
+
+type Config struct {
+	Brokers []string `json:"brokers" validate:"required,gt=0"`
+	// topics to discover; all topics except internal ones when empty
+	Topics []string `json:"topics"`
+	// payloads framed with a schema id are decoded with the registered schema, and registered
+	// value schemas are used as stream schemas instead of sampling
+	SchemaRegistry *schemaregistry.Config `json:"schema_registry,omitempty"`
+	SampleSize     int                    `json:"sample_size"`
+	MaxThreads     int                    `json:"max_threads"`
+	DefaultMode    types.SyncMode         `json:"default_mode"`
+	// proxy and tls settings of the broker connections
+	Network *network.Config `json:"network,omitempty"`
+	// topics left out of discover and syncs; internal topics, starting with an underscore, by
+	// default
+	Exclusions *exclusion.Config `json:"exclusions,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	if err := c.Network.Validate(); err != nil {
+		return err
+	}
+	if c.SampleSize <= 0 {
+		c.SampleSize = 1000
+	}
+	if c.MaxThreads <= 0 {
+		// set default threads
+		logger.Info("setting max threads to default[10]")
+		c.MaxThreads = 10
+	}
+	if c.DefaultMode == "" {
+		c.DefaultMode = types.FULLREFRESH
+	}
+	if err := c.Exclusions.Validate(); err != nil {
+		return err
+	}
+	if c.SchemaRegistry != nil {
+		if err := c.SchemaRegistry.Validate(); err != nil {
+			return err
+		}
+	}
+	return utils.Validate(c)
+}
@@ -0,0 +1,60 @@
+package driver
+
+import (
+	"context"
+	"errors"
+
+	"github.com/datazip-inc/olake/pkg/jsonx"
+	"github.com/datazip-inc/olake/pkg/schemaregistry"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	partitionField = "_kafka_partition"
+	offsetField    = "_kafka_offset"
+	keyField       = "_kafka_key"
+	timestampField = "_kafka_timestamp"
+	// column of payloads that are not objects
+	valueField = "value"
+)
+
+// decodeMessage returns a record of the payload columns and the message metadata
+func decodeMessage(ctx context.Context, registry *schemaregistry.Client, message kafka.Message) (map[string]any, error) {
+	record, err := decodeValue(ctx, registry, message.Value)
+	if err != nil {
+		return nil, err
+	}
+	record[partitionField] = int64(message.Partition)
+	record[offsetField] = message.Offset
+	record[timestampField] = message.Time.UTC()
+	if message.Key != nil {
+		record[keyField] = string(message.Key)
+	} else {
+		record[keyField] = nil
+	}
+	return record, nil
+}
+
+// decodeValue decodes payloads framed with a registered schema, then JSON objects; anything
+// else is kept as a string in the value column
+func decodeValue(ctx context.Context, registry *schemaregistry.Client, value []byte) (map[string]any, error) {
+	// tombstones carry no payload
+	if value == nil {
+		return make(map[string]any), nil
+	}
+	if registry != nil {
+		record, err := registry.Decode(ctx, value)
+		if err == nil {
+			return record, nil
+		}
+		if !errors.Is(err, schemaregistry.ErrNotFramed) {
+			return nil, err
+		}
+	}
+
+	record := make(map[string]any)
+	if err := jsonx.Unmarshal(value, &record); err == nil {
+		return record, nil
+	}
+	return map[string]any{valueField: string(value)}, nil
+}
@@ -0,0 +1,68 @@
+package driver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/pkg/schemaregistry"
+	"github.com/segmentio/kafka-go"
+)
+
+func TestDecodeMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"schemaType":"JSON","schema":"{\"type\":\"object\"}"}`))
+	}))
+	defer server.Close()
+	registry, err := schemaregistry.New(&schemaregistry.Config{URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name     string
+		registry *schemaregistry.Client
+		value    []byte
+		column   string
+		expected any
+	}{
+		{"json object", nil, []byte(`{"id":"a"}`), "id", "a"},
+		{"plain text", nil, []byte("hello"), valueField, "hello"},
+		{"json array kept as text", nil, []byte(`[1,2]`), valueField, "[1,2]"},
+		{"framed json", registry, schemaregistry.Frame(1, []byte(`{"id":"b"}`)), "id", "b"},
+		{"unframed with registry", registry, []byte(`{"id":"c"}`), "id", "c"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			record, err := decodeMessage(context.Background(), test.registry, kafka.Message{Partition: 2, Offset: 7, Key: []byte("k"), Value: test.value, Time: now})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if record[test.column] != test.expected {
+				t.Fatalf("expected %s=%v, got %v", test.column, test.expected, record)
+			}
+			if record[partitionField] != int64(2) || record[offsetField] != int64(7) || record[keyField] != "k" {
+				t.Fatalf("unexpected metadata in %v", record)
+			}
+		})
+	}
+
+	record, err := decodeMessage(context.Background(), nil, kafka.Message{Offset: 1, Time: now})
+	if err != nil || record[keyField] != nil || len(record) != 4 {
+		t.Fatalf("expected tombstone with metadata only, got %v: %v", record, err)
+	}
+}
+
+func TestLoadOffsets(t *testing.T) {
+	// state read back from a file holds json numbers
+	offsets := loadOffsets(map[string]any{"0": float64(12), "1": float64(3)})
+	if offsets["0"] != 12 || offsets["1"] != 3 {
+		t.Fatalf("unexpected offsets %v", offsets)
+	}
+	if len(loadOffsets(nil)) != 0 {
+		t.Fatal("expected no offsets without cursor")
+	}
+}
@@ -0,0 +1,234 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/datazip-inc/olake/drivers"
+	"github.com/datazip-inc/olake/drivers/base"
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/schemaregistry"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	discoverTime = 5 * time.Minute // maximum time allowed to discover all the streams
+	// all topics share a namespace; kafka has no notion of databases
+	namespace = "kafka"
+)
+
+// internal topics of brokers, schema registries and connect, left out unless included by
+// exclusions
+var systemTopics = []string{"_*"}
+
+type Kafka struct {
+	*base.Driver
+	config   *Config
+	registry *schemaregistry.Client
+	// connects to brokers through the configured proxy and tls
+	dialer *kafka.Dialer
+}
+
+func init() {
+	drivers.Register("kafka", func() protocol.Driver {
+		return &Kafka{Driver: base.NewBase()}
+	})
+}
+
+// config reference; must be pointer
+func (k *Kafka) GetConfigRef() protocol.Config {
+	k.config = &Config{}
+	return k.config
+}
+
+func (k *Kafka) Spec() any {
+	return Config{}
+}
+
+func (k *Kafka) Setup() error {
+	if err := k.config.Validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
+	tlsConfig, err := k.config.Network.TLSConfig()
+	if err != nil {
+		return err
+	}
+	k.dialer = &kafka.Dialer{Timeout: 10 * time.Second, DualStack: true, TLS: tlsConfig}
+	if k.config.Network.Enabled() {
+		k.dialer.DialFunc = k.config.Network.DialContext
+	}
+	if k.config.SchemaRegistry != nil {
+		registry, err := schemaregistry.New(k.config.SchemaRegistry)
+		if err != nil {
+			return err
+		}
+		k.registry = registry
+	}
+	return k.Check()
+}
+
+func (k *Kafka) Check() error {
+	conn, err := k.dial()
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (k *Kafka) SetupState(state *types.State) {
+	state.Type = types.StreamType
+	k.State = state
+}
+
+func (k *Kafka) Type() string {
+	return "Kafka"
+}
+
+// dial connects to the first reachable broker
+func (k *Kafka) dial() (*kafka.Conn, error) {
+	var err error
+	for _, broker := range k.config.Brokers {
+		var conn *kafka.Conn
+		if conn, err = k.dialer.Dial("tcp", broker); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to connect to kafka brokers: %s", err)
+}
+
+// partitions returns the partition ids of every topic to sync
+func (k *Kafka) partitions(topics ...string) (map[string][]int, error) {
+	conn, err := k.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions(topics...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read partitions: %s", err)
+	}
+	selected := types.NewSet(k.config.Topics...)
+	topicPartitions := make(map[string][]int)
+	for _, partition := range partitions {
+		if k.config.Exclusions.Excluded(systemTopics, partition.Topic) || (selected.Len() > 0 && !selected.Exists(partition.Topic)) {
+			continue
+		}
+		topicPartitions[partition.Topic] = append(topicPartitions[partition.Topic], partition.ID)
+	}
+	return topicPartitions, nil
+}
+
+// Discover produces one stream per topic; typed by the registered value schema when
+// available, else from a sample of messages
+func (k *Kafka) Discover(_ bool) ([]*types.Stream, error) {
+	streams := k.GetStreams()
+	if len(streams) != 0 {
+		return streams, nil
+	}
+
+	topicPartitions, err := k.partitions()
+	if err != nil {
+		return nil, err
+	}
+	topics := make([]string, 0, len(topicPartitions))
+	for topic := range topicPartitions {
+		topics = append(topics, topic)
+	}
+	logger.Infof("Starting discover for %d kafka topics", len(topics))
+
+	discoverCtx, cancel := context.WithTimeout(context.Background(), discoverTime)
+	defer cancel()
+	failures := &types.DiscoverError{}
+	err = utils.Concurrent(discoverCtx, topics, k.DiscoverConcurrency(k.config.MaxThreads), func(ctx context.Context, topic string, _ int) error {
+		stream, err := k.produceStreamSchema(ctx, topic, topicPartitions[topic])
+		if err != nil {
+			// the others are still sampled; failures are reported once they are
+			failures.Add(topic, err)
+			return nil
+		}
+		stream.SyncMode = k.config.DefaultMode
+		// cache stream
+		k.AddStream(stream)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return k.GetStreams(), failures.Err()
+}
+
+func (k *Kafka) produceStreamSchema(ctx context.Context, topic string, partitions []int) (*types.Stream, error) {
+	logger.Infof("producing type schema for topic [%s]", topic)
+	stream := types.NewStream(topic, namespace).
+		WithSyncMode(types.FULLREFRESH, types.INCREMENTAL).
+		WithPrimaryKey(partitionField, offsetField).
+		WithCursorField(offsetField)
+	stream.UpsertField(partitionField, types.Int64, false)
+	stream.UpsertField(offsetField, types.Int64, false)
+	stream.UpsertField(timestampField, types.TimestampMilli, false)
+	stream.UpsertField(keyField, types.String, true)
+
+	if k.registry != nil {
+		schema, err := k.registeredSchema(ctx, topic)
+		if err != nil {
+			return nil, err
+		}
+		if schema != nil {
+			schema.Properties.Range(func(column, property any) bool {
+				stream.Schema.AddTypes(column.(string), property.(*types.Property).Type.Array()...)
+				return true
+			})
+			return stream, nil
+		}
+	}
+
+	var objects []map[string]any
+	for _, partition := range partitions {
+		err := k.readPartition(ctx, topic, partition, -1, func(record map[string]any) (bool, error) {
+			objects = append(objects, record)
+			return len(objects) < k.config.SampleSize, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(objects) >= k.config.SampleSize {
+			break
+		}
+	}
+	return stream, typeutils.Resolve(stream, objects...)
+}
+
+// registeredSchema returns the types of the latest value schema of topic; nil when the topic
+// has no usable registered schema
+func (k *Kafka) registeredSchema(ctx context.Context, topic string) (*types.TypeSchema, error) {
+	schema, err := k.registry.Latest(ctx, schemaregistry.TopicSubject(topic))
+	if errors.Is(err, schemaregistry.ErrSubjectNotFound) {
+		logger.Infof("no schema registered for topic[%s]; sampling messages", topic)
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	typeSchema, err := schema.TypeSchema()
+	if err != nil {
+		logger.Warnf("registered schema of topic[%s] can not be used: %s; sampling messages", topic, err)
+		return nil, nil
+	}
+	return typeSchema, nil
+}
+
+func (k *Kafka) Read(ctx context.Context, pool *protocol.WriterPool, stream protocol.Stream) error {
+	switch stream.GetSyncMode() {
+	case types.FULLREFRESH, types.INCREMENTAL:
+		return k.readTopic(ctx, pool, stream)
+	}
+
+	return nil
+}
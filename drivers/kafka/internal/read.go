@@ -0,0 +1,179 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	offsetsCursorKey = "offsets"
+	// incremental syncs save partition offsets after every checkpointInterval messages
+	checkpointInterval = 10000
+	earliestOffset     = -1
+)
+
+// readTopic reads every partition of the stream topic up to its high watermark at the time the
+// partition is opened; incremental syncs resume every partition from the offset saved in state
+func (k *Kafka) readTopic(ctx context.Context, pool *protocol.WriterPool, stream protocol.Stream) error {
+	topicPartitions, err := k.partitions(stream.Name())
+	if err != nil {
+		return err
+	}
+	incremental := stream.GetSyncMode() == types.INCREMENTAL
+	offsets := make(map[string]int64)
+	if incremental {
+		offsets = loadOffsets(k.State.GetCursor(stream.Self(), offsetsCursorKey))
+	}
+	logger.Infof("Starting %s sync for topic [%s] with %d known offsets", stream.GetSyncMode(), stream.Name(), len(offsets))
+
+	var mutex sync.Mutex
+	checkpoint := func(partition string, next int64) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		offsets[partition] = next
+		k.State.SetCursor(stream.Self(), offsetsCursorKey, copyOffsets(offsets))
+	}
+	primaryKeys := stream.GetStream().SourceDefinedPrimaryKey.Array()
+
+	return utils.Concurrent(ctx, topicPartitions[stream.Name()], k.config.MaxThreads, func(ctx context.Context, partition int, _ int) (err error) {
+		key := strconv.Itoa(partition)
+		mutex.Lock()
+		start, found := offsets[key]
+		mutex.Unlock()
+		if !found {
+			start = earliestOffset
+		}
+
+		waitChannel := make(chan error, 1)
+		insert, err := pool.NewThread(ctx, stream, protocol.WithErrorChannel(waitChannel))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			insert.Close()
+			if err == nil {
+				// wait for writer completion
+				err = <-waitChannel
+			}
+		}()
+
+		read := 0
+		next := start
+		err = k.readPartition(ctx, stream.Name(), partition, start, func(record map[string]any) (bool, error) {
+			if err := insert.Insert(types.CreateRawRecord(utils.GetKeysHash(record, primaryKeys...), record, 0)); err != nil {
+				return false, fmt.Errorf("failed to insert message of partition[%d]: %s", partition, err)
+			}
+			next = record[offsetField].(int64) + 1
+			read++
+			if incremental && read%checkpointInterval == 0 {
+				checkpoint(key, next)
+			}
+			return true, nil
+		})
+		if err != nil {
+			return err
+		}
+		if incremental && next != start {
+			checkpoint(key, next)
+		}
+		logger.Infof("Read %d messages from partition[%d] of topic[%s]", read, partition, stream.Name())
+		return nil
+	})
+}
+
+// readPartition calls process with every message from start up to the partition high watermark
+// until process returns false; start before the first retained offset reads from the earliest
+func (k *Kafka) readPartition(ctx context.Context, topic string, partition int, start int64, process func(record map[string]any) (bool, error)) error {
+	first, last, err := k.partitionOffsets(ctx, topic, partition)
+	if err != nil {
+		return err
+	}
+	if start < first {
+		if start != earliestOffset {
+			logger.Warnf("offset %d of partition[%d] in topic[%s] is no longer retained; reading from %d", start, partition, topic, first)
+		}
+		start = first
+	}
+	if start >= last {
+		return nil
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   k.config.Brokers,
+		Topic:     topic,
+		Partition: partition,
+		MaxBytes:  10e6,
+		Dialer:    k.dialer,
+	})
+	defer reader.Close()
+	if err := reader.SetOffset(start); err != nil {
+		return fmt.Errorf("failed to seek partition[%d] of topic[%s]: %s", partition, topic, err)
+	}
+
+	for {
+		message, err := reader.ReadMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read partition[%d] of topic[%s]: %s", partition, topic, err)
+		}
+		record, err := decodeMessage(ctx, k.registry, message)
+		if err != nil {
+			return fmt.Errorf("failed to decode message at offset %d of partition[%d]: %s", message.Offset, partition, err)
+		}
+		proceed, err := process(record)
+		if err != nil || !proceed || message.Offset+1 >= last {
+			return err
+		}
+	}
+}
+
+// partitionOffsets returns the first retained offset and the high watermark of a partition
+func (k *Kafka) partitionOffsets(ctx context.Context, topic string, partition int) (int64, int64, error) {
+	var err error
+	for _, broker := range k.config.Brokers {
+		var conn *kafka.Conn
+		if conn, err = k.dialer.DialLeader(ctx, "tcp", broker, topic, partition); err != nil {
+			continue
+		}
+		first, last, err := conn.ReadOffsets()
+		conn.Close()
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to read offsets of partition[%d] in topic[%s]: %s", partition, topic, err)
+		}
+		return first, last, nil
+	}
+	return 0, 0, fmt.Errorf("failed to connect to leader of partition[%d] in topic[%s]: %s", partition, topic, err)
+}
+
+// loadOffsets reads offsets cursor from state; state loaded from a file holds map[string]any
+func loadOffsets(cursor any) map[string]int64 {
+	offsets := make(map[string]int64)
+	switch cursor := cursor.(type) {
+	case map[string]int64:
+		for partition, offset := range cursor {
+			offsets[partition] = offset
+		}
+	case map[string]any:
+		for partition, offset := range cursor {
+			if offset, ok := offset.(float64); ok {
+				offsets[partition] = int64(offset)
+			}
+		}
+	}
+	return offsets
+}
+
+func copyOffsets(offsets map[string]int64) map[string]int64 {
+	copied := make(map[string]int64, len(offsets))
+	for partition, offset := range offsets {
+		copied[partition] = offset
+	}
+	return copied
+}
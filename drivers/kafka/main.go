@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/datazip-inc/olake"
+	_ "github.com/datazip-inc/olake/drivers/kafka/internal" // registering kafka driver
+)
+
+func main() {
+	olake.Run("kafka")
+}
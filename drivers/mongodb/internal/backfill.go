@@ -21,10 +21,9 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/readconcern"
 )
 
-func (m *Mongo) backfill(stream protocol.Stream, pool *protocol.WriterPool) error {
+func (m *Mongo) backfill(backfillCtx context.Context, stream protocol.Stream, pool *protocol.WriterPool) error {
 	collection := m.client.Database(stream.Namespace(), options.Database().SetReadConcern(readconcern.Majority())).Collection(stream.Name())
 	chunks := m.State.GetChunks(stream.Self())
-	backfillCtx := context.TODO()
 	var chunksArray []types.Chunk
 	if chunks == nil || chunks.Len() == 0 {
 		// Full load case
@@ -40,7 +39,7 @@ func (m *Mongo) backfill(stream protocol.Stream, pool *protocol.WriterPool) erro
 		}
 
 		logger.Infof("Total expected count for stream %s: %d", stream.ID(), recordCount)
-		pool.AddRecordsToSync(recordCount)
+		pool.AddRecordsToSync(stream, recordCount)
 
 		// Generate and update chunks
 		chunksArray, err = m.splitChunks(backfillCtx, collection, stream)
@@ -49,7 +48,7 @@ func (m *Mongo) backfill(stream protocol.Stream, pool *protocol.WriterPool) erro
 		}
 		m.State.SetChunks(stream.Self(), types.NewSet(chunksArray...))
 	} else {
-		// TODO: to get estimated time need to update pool.AddRecordsToSync(totalCount) (Can be done via storing some vars in state)
+		// TODO: to get estimated time need to update pool.AddRecordsToSync(stream, totalCount) (Can be done via storing some vars in state)
 		rawChunkArray := chunks.Array()
 		for _, chunk := range rawChunkArray {
 			minID, _ := primitive.ObjectIDFromHex(chunk.Min.(string))
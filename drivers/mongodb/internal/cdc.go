@@ -3,6 +3,8 @@ package driver
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/datazip-inc/olake/constants"
 	"github.com/datazip-inc/olake/logger"
@@ -16,14 +18,22 @@ import (
 )
 
 type CDCDocument struct {
-	OperationType string         `json:"operationType"`
-	FullDocument  map[string]any `json:"fullDocument"`
+	OperationType     string             `json:"operationType" bson:"operationType"`
+	FullDocument      map[string]any     `json:"fullDocument" bson:"fullDocument"`
+	DocumentKey       map[string]any     `json:"documentKey" bson:"documentKey"`
+	UpdateDescription *UpdateDescription `json:"updateDescription" bson:"updateDescription"`
 }
 
-func (m *Mongo) RunChangeStream(pool *protocol.WriterPool, streams ...protocol.Stream) error {
+// UpdateDescription lists the fields changed by an update; paths of nested fields are dotted
+type UpdateDescription struct {
+	UpdatedFields map[string]any `json:"updatedFields" bson:"updatedFields"`
+	RemovedFields []string       `json:"removedFields" bson:"removedFields"`
+}
+
+func (m *Mongo) RunChangeStream(ctx context.Context, pool *protocol.WriterPool, streams ...protocol.Stream) error {
 	// TODO: concurrency based on configuration
-	return utils.Concurrent(context.TODO(), streams, len(streams), func(ctx context.Context, stream protocol.Stream, executionNumber int) error {
-		return m.changeStreamSync(stream, pool)
+	return utils.Concurrent(ctx, streams, len(streams), func(ctx context.Context, stream protocol.Stream, executionNumber int) error {
+		return m.changeStreamSync(ctx, stream, pool)
 	})
 }
 
@@ -38,8 +48,7 @@ func (m *Mongo) StateType() types.StateType {
 }
 
 // does full load on empty state
-func (m *Mongo) changeStreamSync(stream protocol.Stream, pool *protocol.WriterPool) error {
-	cdcCtx := context.TODO()
+func (m *Mongo) changeStreamSync(cdcCtx context.Context, stream protocol.Stream, pool *protocol.WriterPool) error {
 	collection := m.client.Database(stream.Namespace(), options.Database().SetReadConcern(readconcern.Majority())).Collection(stream.Name())
 	changeStreamOpts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
 	pipeline := mongo.Pipeline{
@@ -64,7 +73,7 @@ func (m *Mongo) changeStreamSync(stream protocol.Stream, pool *protocol.WriterPo
 		// save resume token
 		m.State.SetCursor(stream.Self(), cdcCursorField, prevResumeToken)
 
-		if err := m.backfill(stream, pool); err != nil {
+		if err := m.backfill(cdcCtx, stream, pool); err != nil {
 			return err
 		}
 		logger.Infof("backfill done for stream[%s]", stream.ID())
@@ -80,7 +89,7 @@ func (m *Mongo) changeStreamSync(stream protocol.Stream, pool *protocol.WriterPo
 	}
 	defer cursor.Close(cdcCtx)
 
-	insert, err := pool.NewThread(cdcCtx, stream)
+	insert, err := pool.NewThread(cdcCtx, stream, protocol.WithChangeStream())
 	if err != nil {
 		return err
 	}
@@ -91,12 +100,19 @@ func (m *Mongo) changeStreamSync(stream protocol.Stream, pool *protocol.WriterPo
 		if err := cursor.Decode(&record); err != nil {
 			return fmt.Errorf("error while decoding: %s", err)
 		}
-		// TODO: Handle Deleted documents (Good First Issue)
-		if record.FullDocument != nil {
-			record.FullDocument["cdc_type"] = record.OperationType
+		var rawRecord types.RawRecord
+		if m.config.PartialUpdates && record.OperationType == "update" && record.UpdateDescription != nil {
+			data := partialUpdate(record)
+			handleObjectID(data)
+			rawRecord = types.CreatePartialRawRecord(utils.GetKeysHash(data, constants.MongoPrimaryID), data)
+		} else {
+			// TODO: Handle Deleted documents (Good First Issue)
+			if record.FullDocument != nil {
+				record.FullDocument["cdc_type"] = record.OperationType
+			}
+			handleObjectID(record.FullDocument)
+			rawRecord = types.CreateRawRecord(utils.GetKeysHash(record.FullDocument, constants.MongoPrimaryID), record.FullDocument, 0)
 		}
-		handleObjectID(record.FullDocument)
-		rawRecord := types.CreateRawRecord(utils.GetKeysHash(record.FullDocument, constants.MongoPrimaryID), record.FullDocument, 0)
 		err := insert.Insert(rawRecord)
 		if err != nil {
 			return err
@@ -107,6 +123,11 @@ func (m *Mongo) changeStreamSync(stream protocol.Stream, pool *protocol.WriterPo
 	if err := cursor.Err(); err != nil {
 		return fmt.Errorf("failed to iterate change streams cursor: %s", err)
 	}
+	// the post batch resume token moves past the oplog entries of other collections too, so
+	// the position advances on quiet collections instead of aging out of the oplog
+	if token := cursor.ResumeToken(); token != nil {
+		prevResumeToken = token.Lookup(cdcCursorField).StringValue()
+	}
 
 	// save state for the current stream
 	m.State.SetCursor(stream.Self(), cdcCursorField, prevResumeToken)
@@ -123,3 +144,30 @@ func (m *Mongo) getCurrentResumeToken(cdcCtx context.Context, collection *mongo.
 	resumeToken := cursor.ResumeToken()
 	return &resumeToken, nil
 }
+
+// partialUpdate returns the top level fields changed by an update event; fields the update
+// removed are null and unchanged fields are left out. Values come from the looked up document,
+// or from the update itself when the document is gone
+func partialUpdate(event CDCDocument) map[string]any {
+	data := map[string]any{
+		constants.MongoPrimaryID: event.DocumentKey[constants.MongoPrimaryID],
+		"cdc_type":               event.OperationType,
+	}
+	paths := event.UpdateDescription.RemovedFields
+	for path := range event.UpdateDescription.UpdatedFields {
+		paths = append(paths, path)
+	}
+	for _, path := range paths {
+		field, _, _ := strings.Cut(path, ".")
+		if event.FullDocument != nil {
+			data[field] = event.FullDocument[field]
+			continue
+		}
+		if value, found := event.UpdateDescription.UpdatedFields[field]; found {
+			data[field] = value
+		} else if slices.Contains(event.UpdateDescription.RemovedFields, field) {
+			data[field] = nil
+		}
+	}
+	return data
+}
@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/exclusion"
+	"github.com/datazip-inc/olake/pkg/network"
 	"github.com/datazip-inc/olake/types"
 	"github.com/datazip-inc/olake/utils"
 )
@@ -23,6 +25,13 @@ type Config struct {
 	DefaultMode       types.SyncMode `json:"default_mode"`
 	RetryCount        int            `json:"backoff_retry_count"`
 	PartitionStrategy string         `json:"partition_strategy"`
+	// updates of change streams only carry the fields they changed
+	PartialUpdates bool `json:"partial_updates"`
+	// proxy and tls settings; tls here enables tls on the connection
+	Network *network.Config `json:"network,omitempty"`
+	// collections left out of discover, matched as database.collection; the admin, local and
+	// config databases and system collections by default
+	Exclusions *exclusion.Config `json:"exclusions,omitempty"`
 }
 
 func (c *Config) URI() string {
@@ -54,5 +63,11 @@ func (c *Config) URI() string {
 
 // TODO: Add go struct validation in Config
 func (c *Config) Validate() error {
+	if err := c.Network.Validate(); err != nil {
+		return err
+	}
+	if err := c.Exclusions.Validate(); err != nil {
+		return err
+	}
 	return utils.Validate(c)
 }
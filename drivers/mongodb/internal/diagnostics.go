@@ -0,0 +1,98 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DeepCheck verifies the deployment supports change streams and the user may read and watch
+// the configured database
+func (m *Mongo) DeepCheck(ctx context.Context) []types.CheckResult {
+	if m.client == nil {
+		if err := m.Setup(); err != nil {
+			return []types.CheckResult{{Name: "connection", Message: err.Error(), Remediation: "verify the hosts, credentials and auth database"}}
+		}
+	}
+	if err := m.client.Ping(ctx, nil); err != nil {
+		return []types.CheckResult{{Name: "connection", Message: err.Error(), Remediation: "verify the hosts, credentials and auth database"}}
+	}
+	results := []types.CheckResult{{Name: "connection", Passed: true, Message: "connected"}}
+
+	// change streams need a replica set member or a mongos router
+	var hello bson.M
+	err := m.client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		results = append(results, types.CheckResult{Name: "deployment", Message: err.Error(), Remediation: "verify the server runs MongoDB 4.4 or later"})
+	} else {
+		_, replicaSet := hello["setName"]
+		router := hello["msg"] == "isdbgrid"
+		results = append(results, types.CheckResult{
+			Name:        "deployment",
+			Passed:      replicaSet || router,
+			Message:     fmt.Sprintf("replica set: %t, sharded: %t", replicaSet, router),
+			Remediation: "change streams need a replica set; convert the standalone server with rs.initiate()",
+		})
+	}
+
+	database := m.client.Database(m.config.Database)
+	if _, err := database.ListCollectionNames(ctx, bson.D{}); err != nil {
+		results = append(results, types.CheckResult{Name: "read privilege", Message: err.Error(), Remediation: fmt.Sprintf("grant the read role on database %s", m.config.Database)})
+	} else {
+		results = append(results, types.CheckResult{Name: "read privilege", Passed: true, Message: fmt.Sprintf("database %s is readable", m.config.Database)})
+	}
+
+	results = append(results, changeStreamCheck(ctx, database))
+	return results
+}
+
+// changeStreamCheck opens and closes a change stream on the database
+func changeStreamCheck(ctx context.Context, database *mongo.Database) types.CheckResult {
+	result := types.CheckResult{Name: "change stream privilege"}
+	stream, err := database.Watch(ctx, mongo.Pipeline{})
+	if err != nil {
+		result.Message = err.Error()
+		result.Remediation = fmt.Sprintf("grant the changeStream and find actions on database %s, e.g. the read role", database.Name())
+		return result
+	}
+	_ = stream.Close(ctx)
+	result.Passed = true
+	result.Message = "change stream opened"
+	return result
+}
+
+// CheckState verifies the change streams of CDC streams can resume after the tokens kept in
+// state; a token whose event fell out of the oplog can't be resumed after
+func (m *Mongo) CheckState(ctx context.Context, state *types.State, streams []protocol.Stream) []types.StateIssue {
+	var issues []types.StateIssue
+	for _, stream := range streams {
+		if stream.GetSyncMode() != types.CDC {
+			continue
+		}
+		token := state.GetCursor(stream.Self(), cdcCursorField)
+		if token == nil {
+			continue
+		}
+		collection := m.client.Database(stream.Namespace()).Collection(stream.Name())
+		cursor, err := collection.Watch(ctx, mongo.Pipeline{}, options.ChangeStream().SetResumeAfter(map[string]any{cdcCursorField: token}))
+		if err == nil {
+			// the resume token is only checked once the first batch is read
+			cursor.TryNext(ctx)
+			err = cursor.Err()
+			cursor.Close(ctx)
+		}
+		if err != nil {
+			issues = append(issues, types.StateIssue{
+				Streams:     []string{stream.ID()},
+				Message:     fmt.Sprintf("change stream of stream[%s] can't resume after the token in state: %s", stream.ID(), err),
+				Remediation: "reset its state so it is synced again from a snapshot, and size the oplog to cover the time between syncs",
+			})
+		}
+	}
+	return issues
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/datazip-inc/olake/drivers"
 	"github.com/datazip-inc/olake/drivers/base"
 	"github.com/datazip-inc/olake/logger"
 	"github.com/datazip-inc/olake/protocol"
@@ -28,6 +29,18 @@ type Mongo struct {
 	client *mongo.Client
 }
 
+var _ protocol.ChangeStreamDriver = (*Mongo)(nil)
+
+// collections of the server and of system databases, left out of discover unless included by
+// exclusions
+var systemCollections = []string{"admin.*", "local.*", "config.*", "system.*"}
+
+func init() {
+	drivers.Register("mongodb", func() protocol.Driver {
+		return &Mongo{Driver: base.NewBase()}
+	})
+}
+
 // config reference; must be pointer
 func (m *Mongo) GetConfigRef() protocol.Config {
 	m.config = &Config{}
@@ -39,10 +52,23 @@ func (m *Mongo) Spec() any {
 }
 
 func (m *Mongo) Setup() error {
+	if err := m.config.Network.Validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
 	opts := options.Client()
 	opts.ApplyURI(m.config.URI())
 	opts.SetCompressors([]string{"snappy"}) // using Snappy compression; read here https://en.wikipedia.org/wiki/Snappy_(compression)
 	opts.SetMaxPoolSize(1000)
+	tlsConfig, err := m.config.Network.TLSConfig()
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+	if m.config.Network.Enabled() {
+		opts.SetDialer(m.config.Network)
+	}
 
 	connectCtx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
 	defer cancel()
@@ -79,7 +105,10 @@ func (m *Mongo) SetupState(state *types.State) {
 }
 
 func (m *Mongo) Close() error {
-	return m.client.Disconnect(context.Background())
+	if err := m.client.Disconnect(context.Background()); err != nil {
+		return err
+	}
+	return m.config.Network.Close()
 }
 
 func (m *Mongo) Type() string {
@@ -115,14 +144,21 @@ func (m *Mongo) Discover(discoverSchema bool) ([]*types.Stream, error) {
 		if collectionType, ok := collectionInfo["type"].(string); ok && collectionType == "view" {
 			continue
 		}
-		streamNames = append(streamNames, collectionInfo["name"].(string))
+		name := collectionInfo["name"].(string)
+		if m.config.Exclusions.Excluded(systemCollections, name, utils.StreamIdentifier(name, database.Name())) {
+			continue
+		}
+		streamNames = append(streamNames, name)
 	}
 	// Either wait for covering 100k records from both sides for all streams
 	// Or wait till discoverCtx exits
-	err = utils.Concurrent(discoverCtx, streamNames, len(streamNames), func(ctx context.Context, streamName string, _ int) error {
+	// collections failing to be sampled are reported once the others are
+	failures := &types.DiscoverError{}
+	err = utils.Concurrent(discoverCtx, streamNames, m.DiscoverConcurrency(m.config.MaxThreads), func(ctx context.Context, streamName string, _ int) error {
 		stream, err := m.produceCollectionSchema(discoverCtx, database, streamName)
-		if err != nil && discoverCtx.Err() == nil { // if discoverCtx did not make an exit then throw an error
-			return fmt.Errorf("failed to process collection[%s]: %s", streamName, err)
+		if err != nil && discoverCtx.Err() == nil { // if discoverCtx did not make an exit then record the failure
+			failures.Add(utils.StreamIdentifier(streamName, database.Name()), fmt.Errorf("failed to process collection: %s", err))
+			return nil
 		}
 		stream.SyncMode = m.config.DefaultMode
 		// cache stream
@@ -133,15 +169,15 @@ func (m *Mongo) Discover(discoverSchema bool) ([]*types.Stream, error) {
 		return nil, err
 	}
 
-	return m.GetStreams(), nil
+	return m.GetStreams(), failures.Err()
 }
 
-func (m *Mongo) Read(pool *protocol.WriterPool, stream protocol.Stream) error {
+func (m *Mongo) Read(ctx context.Context, pool *protocol.WriterPool, stream protocol.Stream) error {
 	switch stream.GetSyncMode() {
 	case types.FULLREFRESH:
-		return m.backfill(stream, pool)
+		return m.backfill(ctx, stream, pool)
 	case types.CDC:
-		return m.changeStreamSync(stream, pool)
+		return m.changeStreamSync(ctx, stream, pool)
 	}
 
 	return nil
@@ -15,16 +15,20 @@ import (
 	"github.com/datazip-inc/olake/utils"
 )
 
-// Simple Full Refresh Sync; Loads table fully
-func (p *Postgres) backfill(pool *protocol.WriterPool, stream protocol.Stream) error {
-	backfillCtx := context.TODO()
-	var approxRowCount int64
-	approxRowCountQuery := jdbc.PostgresRowCountQuery(stream)
-	err := p.client.QueryRow(approxRowCountQuery).Scan(&approxRowCount)
-	if err != nil {
-		return fmt.Errorf("failed to get approx row count: %s", err)
+// Simple Full Refresh Sync; Loads table fully. Snapshots of CDC syncs pass marks to write a
+// watermark before each chunk is read
+func (p *Postgres) backfill(backfillCtx context.Context, pool *protocol.WriterPool, stream protocol.Stream, marks *watermarks) error {
+	var err error
+	// the rows of a query aren't counted by the statistics of a table
+	if !isCustom(stream) {
+		var approxRowCount int64
+		approxRowCountQuery := jdbc.PostgresRowCountQuery(stream)
+		err = p.client.QueryRow(approxRowCountQuery).Scan(&approxRowCount)
+		if err != nil {
+			return fmt.Errorf("failed to get approx row count: %s", err)
+		}
+		pool.AddRecordsToSync(stream, approxRowCount)
 	}
-	pool.AddRecordsToSync(approxRowCount)
 
 	stateChunks := p.State.GetChunks(stream.Self())
 	var splitChunks []types.Chunk
@@ -45,6 +49,17 @@ func (p *Postgres) backfill(pool *protocol.WriterPool, stream protocol.Stream) e
 
 	logger.Infof("Starting backfill for stream[%s] with %d chunks", stream.GetStream().Name, len(splitChunks))
 	processChunk := func(ctx context.Context, chunk types.Chunk, number int) (err error) {
+		// the connection is held by the transaction until it is rolled back
+		release, err := p.client.Acquire(backfillCtx, stream.ID())
+		if err != nil {
+			return err
+		}
+		defer release()
+		if marks != nil {
+			if err := marks.mark(backfillCtx, p, stream, chunk, number); err != nil {
+				return err
+			}
+		}
 		tx, err := p.client.BeginTx(backfillCtx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
 		if err != nil {
 			return err
@@ -76,19 +91,22 @@ func (p *Postgres) backfill(pool *protocol.WriterPool, stream protocol.Stream) e
 			}
 		}()
 		return setter.Capture(func(rows *sql.Rows) error {
-			// Create a map to hold column names and values
-			record := make(types.Record)
+			// Create a map to hold column names and values; reused once written
+			record := types.NewRecord()
 
 			// Scan the row into the map
 			err := utils.MapScan(rows, record)
 			if err != nil {
 				return fmt.Errorf("failed to mapScan record data: %s", err)
 			}
+			if err := reformatEncodedValues(stream, record); err != nil {
+				return err
+			}
 
 			// generate olake id
 			olakeID := utils.GetKeysHash(record, stream.GetStream().SourceDefinedPrimaryKey.Array()...)
 			// insert record
-			err = insert.Insert(types.CreateRawRecord(olakeID, record, 0))
+			err = insert.Insert(types.CreatePooledRawRecord(olakeID, record, 0))
 			if err != nil {
 				return err
 			}
@@ -186,7 +204,18 @@ func (p *Postgres) splitTableIntoChunks(stream protocol.Stream) ([]types.Chunk,
 			return splitViaBatchSize(minValue, maxValue, p.config.BatchSize)
 		}
 		return splitViaNextQuery(minValue, stream, splitColumn)
+	} else if isCustom(stream) {
+		// the rows of a query have no ctid; read in a single chunk
+		return []types.Chunk{{}}, nil
 	} else {
+		var kind string
+		if err := p.client.QueryRow(getRelationKind, stream.Namespace(), stream.Name()).Scan(&kind); err != nil {
+			return nil, fmt.Errorf("failed to get kind of relation: %s", err)
+		}
+		// neither have the rows of views and foreign tables
+		if kind == "v" || kind == "f" {
+			return []types.Chunk{{}}, nil
+		}
 		return generateCTIDRanges(stream)
 	}
 }
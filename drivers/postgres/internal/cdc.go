@@ -2,6 +2,7 @@ package driver
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -25,11 +26,26 @@ func (p *Postgres) prepareWALJSConfig(streams ...protocol.Stream) (*waljs.Config
 		InitialWaitTime:     time.Duration(p.cdcConfig.InitialWaitTime) * time.Second,
 		Tables:              types.NewSet[protocol.Stream](streams...),
 		BatchSize:           p.config.BatchSize,
+		DialFunc:            p.config.dialFunc(),
+		HeartbeatTable:      p.cdcConfig.HeartbeatTable,
 	}, nil
 }
 
-func (p *Postgres) RunChangeStream(pool *protocol.WriterPool, streams ...protocol.Stream) (err error) {
-	ctx := context.TODO()
+func (p *Postgres) RunChangeStream(ctx context.Context, pool *protocol.WriterPool, streams ...protocol.Stream) (err error) {
+	// a slot that lost wal can't be resumed; the streams are snapshotted again from a new slot
+	if err := p.checkRetention(ctx); errors.Is(err, errWALLost) {
+		if !p.cdcConfig.ResnapshotOnWALLoss {
+			return fmt.Errorf("replication slot[%s] lost wal it needs; recreate the slot and reset the state, or set resnapshot_on_wal_loss", p.cdcConfig.ReplicationSlot)
+		}
+		logger.Warnf("replication slot[%s] lost wal it needs; recreating it and snapshotting the streams again", p.cdcConfig.ReplicationSlot)
+		if err := p.recreateSlot(ctx); err != nil {
+			return err
+		}
+		p.State.SetGlobalState(nil)
+	} else if err != nil {
+		return err
+	}
+
 	gs := types.NewGlobalState(&waljs.WALState{})
 	if p.State.Global != nil {
 		if err = utils.Unmarshal(p.State.Global, gs); err != nil {
@@ -42,7 +58,7 @@ func (p *Postgres) RunChangeStream(pool *protocol.WriterPool, streams ...protoco
 		return fmt.Errorf("failed to prepare wal config: %s", err)
 	}
 
-	socket, err := waljs.NewConnection(ctx, p.client, config)
+	socket, err := waljs.NewConnection(ctx, p.client.DB, config)
 	if err != nil {
 		return fmt.Errorf("failed to create wal connection: %s", err)
 	}
@@ -74,8 +90,14 @@ func (p *Postgres) RunChangeStream(pool *protocol.WriterPool, streams ...protoco
 			needsBackfill = append(needsBackfill, s)
 		}
 	}
+	// changes the snapshots already hold are skipped when replayed
+	marks := newWatermarks()
 	if err = utils.Concurrent(ctx, needsBackfill, len(needsBackfill), func(ctx context.Context, s protocol.Stream, _ int) error {
-		if err := p.backfill(pool, s); err != nil {
+		var streamMarks *watermarks
+		if watermarked(s) {
+			streamMarks = marks
+		}
+		if err := p.backfill(ctx, pool, s, streamMarks); err != nil {
 			return fmt.Errorf("failed backfill of stream[%s]: %s", s.ID(), err)
 		}
 		gs.Streams.Insert(s.ID())
@@ -92,7 +114,7 @@ func (p *Postgres) RunChangeStream(pool *protocol.WriterPool, streams ...protoco
 	// Inserter initialization
 	for _, stream := range streams {
 		errChan := make(chan error)
-		inserter, err := pool.NewThread(ctx, stream, protocol.WithErrorChannel(errChan))
+		inserter, err := pool.NewThread(ctx, stream, protocol.WithErrorChannel(errChan), protocol.WithChangeStream())
 		if err != nil {
 			return fmt.Errorf("failed to initiate writer thread for stream[%s]: %s", stream.ID(), err)
 		}
@@ -119,8 +141,23 @@ func (p *Postgres) RunChangeStream(pool *protocol.WriterPool, streams ...protoco
 		}
 	}()
 
+	// wal piles up while changes are streamed, also for other databases of the server
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+	go p.watchRetention(watchCtx)
+	if p.cdcConfig.HeartbeatInterval > 0 {
+		go p.writeHeartbeats(watchCtx)
+	}
+
 	// Message processing
 	return socket.StreamMessages(ctx, func(msg waljs.CDCChange) error {
+		if err := reformatEncodedValues(msg.Stream, msg.Data); err != nil {
+			return err
+		}
+		if marks.covered(msg.Stream, msg.LSN, msg.Data) {
+			logger.Debugf("skipping change at lsn[%s] of stream[%s] held by its snapshot", msg.LSN, msg.Stream.ID())
+			return nil
+		}
 		pkFields := msg.Stream.GetStream().SourceDefinedPrimaryKey.Array()
 		deleteTS := utils.Ternary(msg.Kind == "delete", msg.Timestamp.UnixMilli(), int64(0)).(int64)
 		return inserters[msg.Stream].Insert(types.CreateRawRecord(
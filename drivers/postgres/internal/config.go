@@ -1,10 +1,15 @@
 package driver
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"net/url"
 	"strings"
 
+	"github.com/datazip-inc/olake/pkg/exclusion"
+	"github.com/datazip-inc/olake/pkg/network"
+	"github.com/datazip-inc/olake/pkg/sqlpool"
 	"github.com/datazip-inc/olake/types"
 	"github.com/datazip-inc/olake/utils"
 	"github.com/lib/pq"
@@ -23,12 +28,81 @@ type Config struct {
 	DefaultSyncMode  types.SyncMode    `json:"default_mode"`
 	BatchSize        int               `json:"reader_batch_size"`
 	MaxThreads       int               `json:"max_threads"`
+	// proxy to reach the database through; tls is configured in the ssl section
+	Network *network.Config `json:"network,omitempty"`
+	// connection limits shared by all streams of a sync
+	Pool *sqlpool.Config `json:"pool,omitempty"`
+	// streams read from queries instead of tables
+	CustomStreams []CustomStream `json:"custom_streams,omitempty"`
+	// relations discover lists besides tables
+	Discover *DiscoverOptions `json:"discover,omitempty"`
+	// relations left out of discover, matched as schema.relation; the schemas of the catalog
+	// by default
+	Exclusions *exclusion.Config `json:"exclusions,omitempty"`
+}
+
+// DiscoverOptions selects the kinds of relations discover lists as streams
+type DiscoverOptions struct {
+	// views are read in a single chunk and can't be synced with cdc
+	Views bool `json:"views"`
+	// materialized views and foreign tables are listed unless disabled
+	MaterializedViews *bool `json:"materialized_views,omitempty"`
+	ForeignTables     *bool `json:"foreign_tables,omitempty"`
+	// separate lists partitions next to their partitioned table, collapse only lists the
+	// partitioned table, whose stream reads the rows of every partition
+	Partitions string `json:"partitions,omitempty"`
+}
+
+const (
+	separatePartitions = "separate"
+	collapsePartitions = "collapse"
+)
+
+// relationKinds returns the relkinds of pg_class listed as streams
+func (d *DiscoverOptions) relationKinds() []string {
+	// tables, toast tables and partitioned tables
+	kinds := []string{"r", "t", "p"}
+	if d.Views {
+		kinds = append(kinds, "v")
+	}
+	if *d.MaterializedViews {
+		kinds = append(kinds, "m")
+	}
+	if *d.ForeignTables {
+		kinds = append(kinds, "f")
+	}
+	return kinds
+}
+
+// CustomStream is a stream read from the result of a query; its primary key and cursor are
+// declared as the database can't tell them
+type CustomStream struct {
+	Name string `json:"name"`
+	// defaults to custom
+	Namespace string `json:"namespace"`
+	// select statement whose rows are the records of the stream
+	Query      string   `json:"query"`
+	PrimaryKey []string `json:"primary_key"`
+	// column incremental syncs of the stream are read by
+	CursorField string `json:"cursor_field"`
 }
 
 // Capture Write Ahead Logs
 type CDC struct {
 	ReplicationSlot string `json:"replication_slot"`
 	InitialWaitTime int    `json:"intial_wait_time"`
+	// wal retained for the slot, in bytes, above which syncs warn; defaults to 1 GiB
+	RetentionWarnBytes int64 `json:"retention_warn_bytes"`
+	// drop and recreate the slot and snapshot the streams again when the server removed wal
+	// the slot still needed, instead of failing
+	ResnapshotOnWALLoss bool `json:"resnapshot_on_wal_loss"`
+	// seconds between heartbeats written to the wal while changes are streamed, so the slot
+	// advances on databases without changes to the synced tables; 0 disables
+	HeartbeatInterval int `json:"heartbeat_interval"`
+	// statement writing a heartbeat; defaults to a pg_logical_emit_message
+	HeartbeatQuery string `json:"heartbeat_query"`
+	// schema.table heartbeat_query writes to; its changes are never synced
+	HeartbeatTable string `json:"heartbeat_table"`
 }
 
 func (c *Config) Validate() error {
@@ -38,6 +112,12 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("host should not contain http or https")
 	}
 
+	if err := c.Network.Validate(); err != nil {
+		return err
+	} else if c.Network != nil && c.Network.TLS != nil {
+		return fmt.Errorf("tls of postgres is configured in the ssl section, not network")
+	}
+
 	// Validate port
 	if c.Port <= 0 || c.Port > 65535 {
 		return fmt.Errorf("invalid port number: must be between 1 and 65535")
@@ -53,6 +133,52 @@ func (c *Config) Validate() error {
 		c.MaxThreads = 2
 	}
 
+	customStreams := make(map[string]bool)
+	for idx := range c.CustomStreams {
+		custom := &c.CustomStreams[idx]
+		if custom.Name == "" || strings.TrimSpace(custom.Query) == "" {
+			return fmt.Errorf("custom stream[%d] needs a name and a query", idx)
+		}
+		if custom.Namespace == "" {
+			custom.Namespace = defaultCustomNamespace
+		}
+		id := fmt.Sprintf("%s.%s", custom.Namespace, custom.Name)
+		if customStreams[id] {
+			return fmt.Errorf("custom stream[%s] is defined more than once", id)
+		}
+		customStreams[id] = true
+	}
+
+	if err := c.Exclusions.Validate(); err != nil {
+		return err
+	}
+
+	if c.Discover == nil {
+		c.Discover = &DiscoverOptions{}
+	}
+	if c.Discover.MaterializedViews == nil {
+		listed := true
+		c.Discover.MaterializedViews = &listed
+	}
+	if c.Discover.ForeignTables == nil {
+		listed := true
+		c.Discover.ForeignTables = &listed
+	}
+	switch c.Discover.Partitions {
+	case "":
+		c.Discover.Partitions = separatePartitions
+	case separatePartitions, collapsePartitions:
+	default:
+		return fmt.Errorf("invalid discover partitions[%s]; expected %s or %s", c.Discover.Partitions, separatePartitions, collapsePartitions)
+	}
+
+	if c.Pool == nil {
+		c.Pool = &sqlpool.Config{}
+	}
+	if err := c.Pool.Validate(); err != nil {
+		return err
+	}
+
 	// construct the connection string
 	connStr := fmt.Sprintf("postgres://%s:%s@%s:%d/%s", url.QueryEscape(c.Username), url.QueryEscape(c.Password), c.Host, c.Port, url.QueryEscape(c.Database))
 	parsed, err := url.Parse(connStr)
@@ -106,13 +232,24 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// dialFunc dials the database through the configured proxy; nil dials directly
+func (c *Config) dialFunc() func(ctx context.Context, networkType, address string) (net.Conn, error) {
+	if !c.Network.Enabled() {
+		return nil
+	}
+	return c.Network.DialContext
+}
+
 type Table struct {
 	Schema string `db:"table_schema"`
 	Name   string `db:"table_name"`
+	// relkind of pg_class
+	Kind string `db:"relkind"`
 }
 
 type ColumnDetails struct {
 	Name       string  `db:"column_name"`
 	DataType   *string `db:"data_type"`
+	UDTName    *string `db:"udt_name"`
 	IsNullable *string `db:"is_nullable"`
 }
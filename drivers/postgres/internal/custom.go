@@ -0,0 +1,94 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/lib/pq"
+)
+
+const (
+	// namespace of custom streams not declaring one
+	defaultCustomNamespace = "custom"
+	// columns of the temporary view a custom stream is discovered through
+	getTempViewSchemaTmpl = `SELECT column_name, data_type, udt_name, is_nullable FROM information_schema.columns WHERE table_schema = (SELECT nspname FROM pg_namespace WHERE oid = pg_my_temp_schema()) AND table_name = $1 ORDER BY ordinal_position`
+	customViewName        = "olake_custom_stream"
+)
+
+// customRelation is a stream read from the result of the query of a custom stream
+type customRelation struct {
+	protocol.Stream
+	query string
+}
+
+func (c *customRelation) Relation() string {
+	return fmt.Sprintf("(%s) AS %s", c.query, pq.QuoteIdentifier(c.Name()))
+}
+
+// customQuery returns the query of a custom stream, without a trailing semicolon so it can be
+// selected from
+func customQuery(custom CustomStream) string {
+	return strings.TrimRight(strings.TrimSpace(custom.Query), "; \n\t")
+}
+
+// relation returns stream reading from the query of the custom stream it is, if it is one
+func (p *Postgres) relation(stream protocol.Stream) protocol.Stream {
+	for _, custom := range p.config.CustomStreams {
+		if utils.StreamIdentifier(custom.Name, custom.Namespace) == stream.ID() {
+			return &customRelation{Stream: stream, query: customQuery(custom)}
+		}
+	}
+	return stream
+}
+
+// isCustom reports if stream reads from the query of a custom stream
+func isCustom(stream protocol.Stream) bool {
+	_, ok := stream.(*customRelation)
+	return ok
+}
+
+// populateCustomStream discovers the columns of a custom stream through a temporary view of
+// its query, dropped with the transaction it is created in
+func (p *Postgres) populateCustomStream(ctx context.Context, custom CustomStream) (*types.Stream, error) {
+	stream := types.NewStream(custom.Name, custom.Namespace)
+	tx, err := p.client.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE TEMP VIEW %s AS %s", customViewName, customQuery(custom))); err != nil {
+		return nil, fmt.Errorf("invalid query of custom stream[%s]: %s", stream.ID(), err)
+	}
+	var columns []ColumnDetails
+	if err := tx.SelectContext(ctx, &columns, getTempViewSchemaTmpl, customViewName); err != nil {
+		return nil, fmt.Errorf("failed to retrieve columns of custom stream[%s]: %s", stream.ID(), err)
+	}
+	p.addColumns(stream, columns, false)
+
+	for _, key := range custom.PrimaryKey {
+		if _, err := stream.Schema.GetType(key); err != nil {
+			return nil, fmt.Errorf("primary key[%s] of custom stream[%s] is not a column of its query", key, stream.ID())
+		}
+		stream.WithPrimaryKey(key)
+	}
+
+	// changes to the rows of a query can't be captured
+	stream.WithSyncMode(types.FULLREFRESH)
+	if custom.CursorField != "" {
+		typ, err := stream.Schema.GetType(custom.CursorField)
+		if err != nil {
+			return nil, fmt.Errorf("cursor field[%s] of custom stream[%s] is not a column of its query", custom.CursorField, stream.ID())
+		}
+		if !cursorTypes[typ] {
+			return nil, fmt.Errorf("cursor field[%s] of custom stream[%s] is of type %s, which can't be a cursor", custom.CursorField, stream.ID(), typ)
+		}
+		stream.WithCursorField(custom.CursorField)
+		stream.WithSyncMode(types.INCREMENTAL)
+	}
+	return stream, nil
+}
@@ -1,7 +1,11 @@
 package driver
 
 import (
+	"fmt"
+
+	"github.com/datazip-inc/olake/protocol"
 	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
 )
 
 var pgTypeToDataTypes = map[string]types.DataType{
@@ -29,6 +33,13 @@ var pgTypeToDataTypes = map[string]types.DataType{
 	"float8":           types.Float64,
 	"real":             types.Float64,
 
+	// binary
+	"bytea": types.Bytes,
+
+	// semi-structured
+	"json":  types.JSON,
+	"jsonb": types.JSON,
+
 	// boolean
 	"bool":    types.Bool,
 	"boolean": types.Bool,
@@ -36,7 +47,6 @@ var pgTypeToDataTypes = map[string]types.DataType{
 	// strings
 	"bit varying":       types.String,
 	"box":               types.String,
-	"bytea":             types.String,
 	"character":         types.String,
 	"char":              types.String,
 	"varbit":            types.String,
@@ -55,8 +65,6 @@ var pgTypeToDataTypes = map[string]types.DataType{
 	"hstore":            types.String,
 	"name":              types.String,
 	"uuid":              types.String,
-	"json":              types.String,
-	"jsonb":             types.String,
 	"line":              types.String,
 	"lseg":              types.String,
 	"money":             types.String,
@@ -84,3 +92,43 @@ var pgTypeToDataTypes = map[string]types.DataType{
 	"ARRAY": types.Array,
 	"array": types.Array,
 }
+
+// naiveTimestampTypes are the timestamp types without a time zone
+var naiveTimestampTypes = map[string]bool{
+	"timestamp":                   true,
+	"timestamp without time zone": true,
+}
+
+// pgUserDefinedTypes maps the types of extensions, which information_schema reports as
+// USER-DEFINED, by their udt_name
+var pgUserDefinedTypes = map[string]types.DataType{
+	"geometry":  types.Geometry,
+	"geography": types.Geometry,
+}
+
+func userDefinedType(column ColumnDetails) (types.DataType, bool) {
+	if *column.DataType != "USER-DEFINED" || column.UDTName == nil {
+		return "", false
+	}
+	typ, found := pgUserDefinedTypes[*column.UDTName]
+	return typ, found
+}
+
+// reformatEncodedValues converts the values of bytea, postgis and json columns: change streams
+// carry bytea as \x prefixed hex, postgis returns geometries as hex encoded ewkb and json is
+// read as text
+func reformatEncodedValues(stream protocol.Stream, record types.Record) error {
+	for column, value := range record {
+		if value == nil {
+			continue
+		}
+		typ, err := stream.Schema().GetType(column)
+		if err != nil || (typ != types.Bytes && typ != types.Geometry && typ != types.JSON) {
+			continue
+		}
+		if record[column], err = typeutils.ReformatValue(typ, value); err != nil {
+			return fmt.Errorf("failed to reformat %s value of column[%s]: %s", typ, column, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package driver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/datazip-inc/olake/pkg/waljs"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/jackc/pglogrepl"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	getReplicationPrivilege = `SELECT rolreplication OR rolsuper FROM pg_roles WHERE rolname = current_user`
+	getWalLevel             = `SHOW wal_level`
+	getSlotDetails          = `SELECT plugin, slot_type, active FROM pg_replication_slots WHERE slot_name = $1`
+	getSlotFlushLSN         = `SELECT confirmed_flush_lsn::text FROM pg_replication_slots WHERE slot_name = $1`
+	getReadableTableCount   = `SELECT count(*) FROM pg_class c
+		JOIN pg_namespace n ON c.relnamespace = n.oid
+		WHERE has_table_privilege(c.oid, 'SELECT')
+		AND relkind IN ('r', 'm', 't', 'f', 'p')
+		AND nspname NOT LIKE 'pg_%'
+		AND nspname != 'information_schema'`
+)
+
+// DeepCheck verifies the privileges and server settings the configured sync needs; checks of
+// logical replication only run with a replication slot configured
+func (p *Postgres) DeepCheck(ctx context.Context) []types.CheckResult {
+	var client *sqlx.DB
+	if p.client != nil {
+		client = p.client.DB
+	} else {
+		// setup failed or wasn't run; connect without its validations so every check reports
+		var err error
+		if client, err = p.connect(ctx); err != nil {
+			return []types.CheckResult{{
+				Name:        "connection",
+				Message:     err.Error(),
+				Remediation: "verify host, port, credentials and that the server accepts connections from this network",
+			}}
+		}
+		defer client.Close()
+	}
+	results := []types.CheckResult{{Name: "connection", Passed: true, Message: "connected"}}
+
+	var tables int
+	if err := client.GetContext(ctx, &tables, getReadableTableCount); err != nil {
+		results = append(results, types.CheckResult{Name: "select privilege", Message: err.Error(), Remediation: "verify the user can query pg_class"})
+	} else {
+		results = append(results, types.CheckResult{
+			Name:        "select privilege",
+			Passed:      tables > 0,
+			Message:     fmt.Sprintf("%d tables readable", tables),
+			Remediation: "GRANT SELECT ON ALL TABLES IN SCHEMA <schema> TO <user>",
+		})
+	}
+
+	cdc := &CDC{}
+	if found, _ := utils.IsOfType(p.config.UpdateMethod, "replication_slot"); !found {
+		return results
+	}
+	if err := utils.Unmarshal(p.config.UpdateMethod, cdc); err != nil {
+		return append(results, types.CheckResult{Name: "update method", Message: err.Error(), Remediation: "fix update_method in the config"})
+	}
+
+	var replication bool
+	if err := client.GetContext(ctx, &replication, getReplicationPrivilege); err != nil {
+		results = append(results, types.CheckResult{Name: "replication privilege", Message: err.Error(), Remediation: "verify the user can query pg_roles"})
+	} else {
+		results = append(results, types.CheckResult{
+			Name:        "replication privilege",
+			Passed:      replication,
+			Message:     fmt.Sprintf("replication privilege: %t", replication),
+			Remediation: "ALTER ROLE <user> WITH REPLICATION",
+		})
+	}
+
+	var walLevel string
+	if err := client.GetContext(ctx, &walLevel, getWalLevel); err != nil {
+		results = append(results, types.CheckResult{Name: "wal_level", Message: err.Error(), Remediation: "verify the user can read server settings"})
+	} else {
+		results = append(results, types.CheckResult{
+			Name:        "wal_level",
+			Passed:      walLevel == "logical",
+			Message:     fmt.Sprintf("wal_level is %s", walLevel),
+			Remediation: "set wal_level = logical in postgresql.conf and restart the server",
+		})
+	}
+
+	return append(results, slotCheck(ctx, client, cdc.ReplicationSlot))
+}
+
+// slotCheck verifies the replication slot exists, decodes with wal2json and is free to consume
+func slotCheck(ctx context.Context, client *sqlx.DB, slotName string) types.CheckResult {
+	result := types.CheckResult{Name: "replication slot"}
+	var slot struct {
+		Plugin   sql.NullString `db:"plugin"`
+		SlotType string         `db:"slot_type"`
+		Active   bool           `db:"active"`
+	}
+	err := client.GetContext(ctx, &slot, getSlotDetails, slotName)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		result.Message = fmt.Sprintf("slot %s does not exist", slotName)
+		result.Remediation = fmt.Sprintf("SELECT pg_create_logical_replication_slot('%s', 'wal2json')", slotName)
+	case err != nil:
+		result.Message = err.Error()
+		result.Remediation = "verify the user can query pg_replication_slots"
+	case slot.SlotType != "logical" || slot.Plugin.String != "wal2json":
+		result.Message = fmt.Sprintf("slot %s is a %s slot with plugin %s", slotName, slot.SlotType, slot.Plugin.String)
+		result.Remediation = fmt.Sprintf("drop the slot and recreate it with SELECT pg_create_logical_replication_slot('%s', 'wal2json')", slotName)
+	case slot.Active:
+		result.Message = fmt.Sprintf("slot %s is in use by another consumer", slotName)
+		result.Remediation = "stop the other consumer or configure a dedicated slot"
+	default:
+		result.Passed = true
+		result.Message = fmt.Sprintf("slot %s is available", slotName)
+	}
+	return result
+}
+
+// CheckState verifies the replication slot still exists and is at the position kept in the
+// global state; the streams the global state covers are snapshotted again otherwise
+func (p *Postgres) CheckState(ctx context.Context, state *types.State, _ []protocol.Stream) []types.StateIssue {
+	if !p.CDCSupport || state.Global == nil {
+		return nil
+	}
+	gs := types.NewGlobalState(&waljs.WALState{})
+	if err := utils.Unmarshal(state.Global, gs); err != nil {
+		return []types.StateIssue{{Global: true, Message: fmt.Sprintf("global state is invalid: %s", err), Remediation: "reset the global state"}}
+	}
+	if gs.State.IsEmpty() {
+		return nil
+	}
+	stored, err := pglogrepl.ParseLSN(gs.State.LSN)
+	if err != nil {
+		return []types.StateIssue{{Global: true, Message: fmt.Sprintf("lsn[%s] in global state is invalid: %s", gs.State.LSN, err), Remediation: "reset the global state"}}
+	}
+
+	slotName := p.cdcConfig.ReplicationSlot
+	var confirmed string
+	err = p.client.DB.GetContext(ctx, &confirmed, getSlotFlushLSN, slotName)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return []types.StateIssue{{
+			Global:      true,
+			Message:     fmt.Sprintf("replication slot %s of the lsn in state does not exist", slotName),
+			Remediation: fmt.Sprintf("create the slot with SELECT pg_create_logical_replication_slot('%s', 'wal2json') and reset the global state", slotName),
+		}}
+	case err != nil:
+		return []types.StateIssue{{Message: fmt.Sprintf("failed to read replication slot %s: %s", slotName, err), Remediation: "verify the user can query pg_replication_slots"}}
+	}
+	if retention, err := p.slotRetention(ctx); err == nil && retention.WALStatus.String == "lost" {
+		return []types.StateIssue{{
+			Global:      true,
+			Message:     fmt.Sprintf("replication slot %s lost wal it needs", slotName),
+			Remediation: fmt.Sprintf("recreate the slot with SELECT pg_drop_replication_slot('%[1]s'), pg_create_logical_replication_slot('%[1]s', 'wal2json') and reset the global state", slotName),
+		}}
+	}
+	current, err := pglogrepl.ParseLSN(confirmed)
+	if err != nil {
+		return []types.StateIssue{{Message: fmt.Sprintf("failed to parse lsn[%s] of replication slot %s: %s", confirmed, slotName, err)}}
+	}
+	if current != stored {
+		return []types.StateIssue{{
+			Global:      true,
+			Message:     fmt.Sprintf("lsn[%s] in state is not the confirmed flush lsn[%s] of replication slot %s; changes in between can't be read", stored, current, slotName),
+			Remediation: "reset the global state; the next sync would snapshot the streams again otherwise",
+		}}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package driver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/jdbc"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+	"github.com/datazip-inc/olake/utils"
+)
+
+// cursorTypes are the types of columns detected as cursors of incremental syncs
+var cursorTypes = map[types.DataType]bool{
+	types.Int64:          true,
+	types.Timestamp:      true,
+	types.TimestampMilli: true,
+	types.TimestampMicro: true,
+	types.TimestampNano:  true,
+}
+
+// incrementalSync reads the rows after the cursor kept in state, ordered by the cursor, and
+// keeps the cursor of the last row once they are written; the first sync reads from the
+// initial cursor of the stream, or the whole table
+func (p *Postgres) incrementalSync(ctx context.Context, pool *protocol.WriterPool, stream protocol.Stream) (err error) {
+	columns := stream.CursorFields()
+	columnTypes := make([]types.DataType, len(columns))
+	for idx, column := range columns {
+		if columnTypes[idx], err = stream.Schema().GetType(column); err != nil {
+			return err
+		}
+	}
+	cursor, err := typeutils.ReadCursor(stream.Schema(), columns, p.State.GetCursor(stream.Self(), stream.Cursor()))
+	if err != nil {
+		return fmt.Errorf("invalid cursor of stream[%s] in state: %s", stream.ID(), err)
+	}
+
+	query := jdbc.PostgresWithoutState(stream)
+	// rows within the lookback are read again; the cursor kept in state doesn't move back
+	from := cursor
+	if lookback := stream.Self().StreamMetadata.Lookback; cursor != nil && lookback != "" {
+		from = append([]any{}, cursor...)
+		if from[0], err = typeutils.ApplyLookback(lookback, columnTypes[0], cursor[0]); err != nil {
+			return fmt.Errorf("failed to apply lookback[%s] to stream[%s]: %s", lookback, stream.ID(), err)
+		}
+	}
+	switch initial := stream.Self().StreamMetadata.InitialCursor; {
+	case cursor != nil:
+		query = jdbc.PostgresWithState(stream)
+		logger.Infof("Starting incremental sync for stream[%s] after cursor %v", stream.ID(), from)
+	case initial != nil:
+		// the first sync starts at the initial cursor instead of reading all history
+		if from, err = typeutils.ReadCursor(stream.Schema(), columns, initial); err != nil {
+			return fmt.Errorf("invalid initial_cursor of stream[%s]: %s", stream.ID(), err)
+		}
+		query = jdbc.PostgresFromCursor(stream)
+		logger.Infof("Starting incremental sync for stream[%s] from initial cursor %v", stream.ID(), from)
+	default:
+		logger.Infof("Starting incremental sync for stream[%s] from the start of the table", stream.ID())
+	}
+
+	release, err := p.client.Acquire(ctx, stream.ID())
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	waitChannel := make(chan error, 1)
+	insert, err := pool.NewThread(ctx, stream, protocol.WithErrorChannel(waitChannel))
+	if err != nil {
+		return fmt.Errorf("failed to create writer thread: %s", err)
+	}
+	last := cursor
+	defer func() {
+		insert.Close()
+		if err == nil {
+			err = <-waitChannel
+		}
+		// the cursor only moves forward, and only past rows written
+		if err == nil && last != nil {
+			p.State.SetCursor(stream.Self(), stream.Cursor(), typeutils.CursorState(last))
+		}
+	}()
+
+	setter := jdbc.NewReader(ctx, query, p.config.BatchSize, func(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+		return p.client.QueryContext(ctx, query, args...)
+	}, from...)
+	return setter.Capture(func(rows *sql.Rows) error {
+		record := types.NewRecord()
+		if err := utils.MapScan(rows, record); err != nil {
+			return fmt.Errorf("failed to mapScan record data: %s", err)
+		}
+		if err := reformatEncodedValues(stream, record); err != nil {
+			return err
+		}
+
+		values := make([]any, len(columns))
+		for idx, column := range columns {
+			values[idx] = record[column]
+		}
+		// rows with a null cursor are only read by the first sync
+		if _, hasNull := utils.ArrayContains(values, func(value any) bool { return value == nil }); !hasNull {
+			if last == nil {
+				last = values
+			} else if later, err := typeutils.CompareCursors(columnTypes, values, last); err != nil {
+				return fmt.Errorf("failed to compare cursor %v: %s", values, err)
+			} else if later > 0 {
+				last = values
+			}
+		}
+
+		olakeID := utils.GetKeysHash(record, stream.GetStream().SourceDefinedPrimaryKey.Array()...)
+		return insert.Insert(types.CreatePooledRawRecord(olakeID, record, 0))
+	})
+}
@@ -6,12 +6,18 @@ import (
 	"strings"
 	"time"
 
+	"github.com/datazip-inc/olake/drivers"
 	"github.com/datazip-inc/olake/drivers/base"
 	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/jdbc"
+	"github.com/datazip-inc/olake/pkg/sqlpool"
 	"github.com/datazip-inc/olake/protocol"
 	"github.com/datazip-inc/olake/types"
 	"github.com/datazip-inc/olake/utils"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 const (
@@ -19,50 +25,54 @@ const (
 	// TODO: make these queries Postgres version specific
 	// get all schemas and table
 	getPrivilegedTablesTmpl = `SELECT nspname as table_schema,
-		relname as table_name
+		relname as table_name, relkind::text as relkind
 		FROM pg_class c
 		JOIN pg_namespace n ON c.relnamespace = n.oid
 		WHERE has_table_privilege(c.oid, 'SELECT')
 		AND has_schema_privilege(current_user, nspname, 'USAGE')
-		AND relkind IN ('r', 'm', 't', 'f', 'p')
-		AND nspname NOT LIKE 'pg_%'  -- Exclude default system schemas
-		AND nspname != 'information_schema';  -- Exclude information_schema`
+		AND relkind::text = ANY($1)
+		AND NOT ($2 AND c.relispartition);  -- Exclude partitions collapsed into their table`
+	// get the kind of a relation
+	getRelationKind = `SELECT c.relkind::text FROM pg_class c JOIN pg_namespace n ON c.relnamespace = n.oid WHERE n.nspname = $1 AND c.relname = $2`
 	// get table schema
-	getTableSchemaTmpl = `SELECT column_name, data_type, is_nullable FROM information_schema.columns WHERE table_schema = $1 AND table_name = $2 ORDER BY ordinal_position`
+	getTableSchemaTmpl = `SELECT column_name, data_type, udt_name, is_nullable FROM information_schema.columns WHERE table_schema = $1 AND table_name = $2 ORDER BY ordinal_position`
 	// get primary key columns
 	getTablePrimaryKey = `SELECT column_name FROM information_schema.key_column_usage WHERE table_schema = $1 AND table_name = $2 ORDER BY ordinal_position`
 )
 
+// relations of the catalog, left out of discover unless included by exclusions
+var systemSchemas = []string{"information_schema.*", "pg_*.*"}
+
 type Postgres struct {
 	*base.Driver
-	client    *sqlx.DB
+	client    *sqlpool.Pool
 	config    *Config // postgres driver connection config
 	cdcConfig CDC
 }
 
+var (
+	_ protocol.ChangeStreamDriver = (*Postgres)(nil)
+	_ protocol.RowCounter         = (*Postgres)(nil)
+	_ protocol.RowScanner         = (*Postgres)(nil)
+)
+
+func init() {
+	drivers.Register("postgres", func() protocol.Driver {
+		return &Postgres{Driver: base.NewBase()}
+	})
+}
+
 func (p *Postgres) ChangeStreamSupported() bool {
 	return p.CDCSupport
 }
 
 func (p *Postgres) Setup() error {
-	err := p.config.Validate()
-	if err != nil {
-		return fmt.Errorf("failed to validate config: %s", err)
-	}
-
-	sqlxDB, err := sqlx.Open("pgx", p.config.Connection.String())
-	if err != nil {
-		return fmt.Errorf("failed to connect database: %s", err)
-	}
-
-	pgClient := sqlxDB.Unsafe()
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 	defer cancel()
 
-	// force a connection and test that it worked
-	err = pgClient.PingContext(ctx)
+	pgClient, err := p.connect(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to ping database: %s", err)
+		return err
 	}
 	// TODO: correct cdc setup
 	found, _ := utils.IsOfType(p.config.UpdateMethod, "replication_slot")
@@ -85,16 +95,50 @@ func (p *Postgres) Setup() error {
 			// default set 10 sec
 			cdc.InitialWaitTime = 10
 		}
+		if cdc.RetentionWarnBytes <= 0 {
+			cdc.RetentionWarnBytes = defaultRetentionWarnBytes
+		}
+		if cdc.HeartbeatInterval < 0 {
+			return fmt.Errorf("heartbeat_interval must not be negative")
+		}
+		if cdc.HeartbeatQuery == "" {
+			cdc.HeartbeatQuery = defaultHeartbeatQuery
+		}
 		// no use of it if check not being called while sync run
 		p.CDCSupport = true
 		p.cdcConfig = *cdc
 	} else {
 		logger.Info("Standard Replication is selected")
 	}
-	p.client = pgClient
+	p.client = sqlpool.New(pgClient, p.config.Pool)
 	return nil
 }
 
+// connect validates the config and returns a client with a tested connection
+func (p *Postgres) connect(ctx context.Context) (*sqlx.DB, error) {
+	err := p.config.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate config: %s", err)
+	}
+
+	connConfig, err := pgx.ParseConfig(p.config.Connection.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse connection string: %s", err)
+	}
+	if dial := p.config.dialFunc(); dial != nil {
+		connConfig.DialFunc = dial
+	}
+	sqlxDB := sqlx.NewDb(stdlib.OpenDB(*connConfig), "pgx")
+
+	pgClient := sqlxDB.Unsafe()
+	// force a connection and test that it worked
+	err = pgClient.PingContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ping database: %s", err)
+	}
+	return pgClient, nil
+}
+
 func (p *Postgres) StateType() types.StateType {
 	return types.GlobalType
 }
@@ -140,20 +184,30 @@ func (p *Postgres) Discover(discoverSchema bool) ([]*types.Stream, error) {
 	defer cancel()
 
 	var tableNamesOutput []Table
-	err := p.client.Select(&tableNamesOutput, getPrivilegedTablesTmpl)
+	err := p.client.SelectContext(discoverCtx, &tableNamesOutput, getPrivilegedTablesTmpl, pq.Array(p.config.Discover.relationKinds()), p.config.Discover.Partitions == collapsePartitions)
 	if err != nil {
 		return streams, fmt.Errorf("failed to retrieve table names: %s", err)
 	}
+	tables := tableNamesOutput[:0]
+	for _, table := range tableNamesOutput {
+		if !p.config.Exclusions.Excluded(systemSchemas, utils.StreamIdentifier(table.Name, table.Schema)) {
+			tables = append(tables, table)
+		}
+	}
+	tableNamesOutput = tables
 
-	if len(tableNamesOutput) == 0 {
+	if len(tableNamesOutput) == 0 && len(p.config.CustomStreams) == 0 {
 		logger.Warnf("no tables found")
 		return streams, nil
 	}
 
-	err = utils.Concurrent(discoverCtx, tableNamesOutput, len(tableNamesOutput), func(ctx context.Context, pgTable Table, _ int) error {
-		stream, err := p.populateStream(pgTable)
+	// tables failing to be sampled are reported once the others are
+	failures := &types.DiscoverError{}
+	err = utils.Concurrent(discoverCtx, tableNamesOutput, p.DiscoverConcurrency(p.config.MaxThreads), func(ctx context.Context, pgTable Table, _ int) error {
+		stream, err := p.populateStream(ctx, pgTable)
 		if err != nil && discoverCtx.Err() == nil {
-			return err
+			failures.Add(utils.StreamIdentifier(pgTable.Name, pgTable.Schema), err)
+			return nil
 		}
 		stream.SyncMode = p.config.DefaultSyncMode
 		// cache stream
@@ -164,29 +218,92 @@ func (p *Postgres) Discover(discoverSchema bool) ([]*types.Stream, error) {
 		return nil, err
 	}
 
-	return p.GetStreams(), nil
+	for _, custom := range p.config.CustomStreams {
+		stream, err := p.populateCustomStream(discoverCtx, custom)
+		if err != nil {
+			failures.Add(utils.StreamIdentifier(custom.Name, custom.Namespace), err)
+			continue
+		}
+		if found, _ := p.GetStream(stream.ID()); found {
+			failures.Add(stream.ID(), fmt.Errorf("custom stream has the name of a table"))
+			continue
+		}
+		stream.SyncMode = p.config.DefaultSyncMode
+		p.AddStream(stream)
+	}
+
+	return p.GetStreams(), failures.Err()
 }
 
 func (p *Postgres) Type() string {
 	return "Postgres"
 }
 
-func (p *Postgres) Read(pool *protocol.WriterPool, stream protocol.Stream) error {
+// SourceTimezone returns the time zone of the server, which timestamp columns without a time
+// zone are in when written through now() and the like
+func (p *Postgres) SourceTimezone() (*time.Location, error) {
+	var name string
+	if err := p.client.QueryRow("SHOW TimeZone").Scan(&name); err != nil {
+		return nil, fmt.Errorf("failed to get time zone: %s", err)
+	}
+	return time.LoadLocation(name)
+}
+
+func (p *Postgres) Read(ctx context.Context, pool *protocol.WriterPool, stream protocol.Stream) error {
+	stream = p.relation(stream)
+	if isCustom(stream) && stream.GetSyncMode() == types.CDC {
+		return fmt.Errorf("custom stream[%s] can't be synced with cdc", stream.ID())
+	}
 	switch stream.GetSyncMode() {
 	case types.FULLREFRESH:
-		return p.backfill(pool, stream)
+		return p.backfill(ctx, pool, stream, nil)
+	case types.INCREMENTAL:
+		return p.incrementalSync(ctx, pool, stream)
 	case types.CDC:
-		return p.RunChangeStream(pool, stream)
+		return p.RunChangeStream(ctx, pool, stream)
 	}
 
 	return nil
 }
 
-func (p *Postgres) populateStream(table Table) (*types.Stream, error) {
+// CountRows counts the rows of the table of stream; used by the row count quality check
+func (p *Postgres) CountRows(ctx context.Context, stream protocol.Stream) (int64, error) {
+	var count int64
+	if err := p.client.QueryRowContext(ctx, jdbc.PostgresExactRowCountQuery(p.relation(stream))).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count rows of stream[%s]: %s", stream.ID(), err)
+	}
+	return count, nil
+}
+
+// ScanRows reads every row of the table of stream; used by reconcile to checksum rows
+func (p *Postgres) ScanRows(ctx context.Context, stream protocol.Stream, fn func(row map[string]any) error) error {
+	release, err := p.client.Acquire(ctx, stream.ID())
+	if err != nil {
+		return err
+	}
+	defer release()
+	rows, err := p.client.QueryContext(ctx, jdbc.PostgresScanQuery(p.relation(stream)))
+	if err != nil {
+		return fmt.Errorf("failed to scan rows of stream[%s]: %s", stream.ID(), err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		row := make(map[string]any)
+		if err := utils.MapScan(rows, row); err != nil {
+			return fmt.Errorf("failed to mapScan record data: %s", err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (p *Postgres) populateStream(ctx context.Context, table Table) (*types.Stream, error) {
 	// create new stream
 	stream := types.NewStream(table.Name, table.Schema)
 	var columnSchemaOutput []ColumnDetails
-	err := p.client.Select(&columnSchemaOutput, getTableSchemaTmpl, table.Schema, table.Name)
+	err := p.client.SelectContext(ctx, &columnSchemaOutput, getTableSchemaTmpl, table.Schema, table.Name)
 	if err != nil {
 		return stream, fmt.Errorf("failed to retrieve column details for table %s[%s]: %s", table.Name, table.Schema, err)
 	}
@@ -197,20 +314,54 @@ func (p *Postgres) populateStream(table Table) (*types.Stream, error) {
 	}
 
 	var primaryKeyOutput []ColumnDetails
-	err = p.client.Select(&primaryKeyOutput, getTablePrimaryKey, table.Schema, table.Name)
+	err = p.client.SelectContext(ctx, &primaryKeyOutput, getTablePrimaryKey, table.Schema, table.Name)
 	if err != nil {
 		return stream, fmt.Errorf("failed to retrieve primary key columns for table %s[%s]: %s", table.Name, table.Schema, err)
 	}
 
-	for _, column := range columnSchemaOutput {
+	p.addColumns(stream, columnSchemaOutput, true)
+
+	// changes of views and foreign tables aren't in the wal
+	if p.CDCSupport && table.Kind != "v" && table.Kind != "f" {
+		stream.WithSyncMode(types.FULLREFRESH)
+		stream.WithSyncMode(types.CDC)
+
+	} else {
+		stream.WithSyncMode(types.FULLREFRESH)
+	}
+	// any int or timestamp column, or several of them, can be the cursor
+	if stream.AvailableCursorFields.Len() > 0 {
+		stream.WithSyncMode(types.INCREMENTAL)
+	}
+
+	// add primary keys for stream
+	for _, column := range primaryKeyOutput {
+		stream.WithPrimaryKey(column.Name)
+	}
+
+	return stream, nil
+}
+
+// addColumns adds columns to the schema of stream; with cursors, every column of a cursor type
+// is a cursor field
+func (p *Postgres) addColumns(stream *types.Stream, columns []ColumnDetails, cursors bool) {
+	for _, column := range columns {
 		datatype := types.Unknown
 		if val, found := pgTypeToDataTypes[*column.DataType]; found {
 			datatype = val
+		} else if val, found := userDefinedType(column); found {
+			datatype = val
 		} else {
 			logger.Warnf("failed to get respective type in datatypes for column: %s[%s]", column.Name, *column.DataType)
 		}
 
 		stream.UpsertField(column.Name, datatype, strings.EqualFold("yes", *column.IsNullable))
+		if naiveTimestampTypes[*column.DataType] {
+			stream.WithNaiveTimestamps(column.Name)
+		}
+		if cursors && cursorTypes[datatype] {
+			stream.WithCursorField(column.Name)
+		}
 	}
 
 	// cdc additional fields
@@ -219,20 +370,4 @@ func (p *Postgres) populateStream(table Table) (*types.Stream, error) {
 			stream.UpsertField(column, typ, true)
 		}
 	}
-
-	// TODO: Populate cursor fields for incremental purpose
-	if p.CDCSupport {
-		stream.WithSyncMode(types.FULLREFRESH)
-		stream.WithSyncMode(types.CDC)
-
-	} else {
-		stream.WithSyncMode(types.FULLREFRESH)
-	}
-
-	// add primary keys for stream
-	for _, column := range primaryKeyOutput {
-		stream.WithPrimaryKey(column.Name)
-	}
-
-	return stream, nil
 }
@@ -0,0 +1,131 @@
+package driver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/waljs"
+)
+
+const (
+	getSlotRetention = `SELECT wal_status, safe_wal_size,
+		pg_wal_lsn_diff(pg_current_wal_lsn(), restart_lsn)::bigint AS retained,
+		pg_wal_lsn_diff(pg_current_wal_lsn(), confirmed_flush_lsn)::bigint AS lag
+		FROM pg_replication_slots WHERE slot_name = $1`
+	// servers before 13 don't report whether a slot can lose wal
+	getSlotRetentionLegacy = `SELECT NULL::text AS wal_status, NULL::bigint AS safe_wal_size,
+		pg_wal_lsn_diff(pg_current_wal_lsn(), restart_lsn)::bigint AS retained,
+		pg_wal_lsn_diff(pg_current_wal_lsn(), confirmed_flush_lsn)::bigint AS lag
+		FROM pg_replication_slots WHERE slot_name = $1`
+	dropReplicationSlot   = `SELECT pg_drop_replication_slot($1)`
+	createReplicationSlot = `SELECT pg_create_logical_replication_slot($1, 'wal2json')`
+	// transactional so wal2json decodes it; recognized and skipped by the change filter
+	defaultHeartbeatQuery = `SELECT pg_logical_emit_message(true, '` + waljs.HeartbeatPrefix + `', now()::text)`
+
+	// default of retention_warn_bytes
+	defaultRetentionWarnBytes = 1 << 30
+	// interval retention is checked at while changes are streamed
+	retentionCheckInterval = time.Minute
+)
+
+// errWALLost is returned when the replication slot was invalidated after the server removed wal
+// it still needed
+var errWALLost = errors.New("replication slot lost wal it needs")
+
+// slotRetention is the wal kept for the replication slot
+type slotRetention struct {
+	// reserved, extended, unreserved or lost
+	WALStatus sql.NullString `db:"wal_status"`
+	// bytes of wal that can still be written before the slot is invalidated; null when unlimited
+	SafeWALSize sql.NullInt64 `db:"safe_wal_size"`
+	// bytes of wal the server keeps for the slot
+	Retained sql.NullInt64 `db:"retained"`
+	// bytes of wal not yet acknowledged by syncs
+	Lag sql.NullInt64 `db:"lag"`
+}
+
+func (p *Postgres) slotRetention(ctx context.Context) (*slotRetention, error) {
+	retention := &slotRetention{}
+	err := p.client.DB.GetContext(ctx, retention, getSlotRetention, p.cdcConfig.ReplicationSlot)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		err = p.client.DB.GetContext(ctx, retention, getSlotRetentionLegacy, p.cdcConfig.ReplicationSlot)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("replication slot %s does not exist", p.cdcConfig.ReplicationSlot)
+	}
+	return retention, err
+}
+
+// checkRetention warns when the replication slot retains more wal than retention_warn_bytes or
+// is about to lose wal it needs; errWALLost is returned once it lost it
+func (p *Postgres) checkRetention(ctx context.Context) error {
+	retention, err := p.slotRetention(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check wal retention of replication slot[%s]: %s", p.cdcConfig.ReplicationSlot, err)
+	}
+	slot, limit := p.cdcConfig.ReplicationSlot, p.cdcConfig.RetentionWarnBytes
+	switch {
+	case retention.WALStatus.String == "lost":
+		return errWALLost
+	case retention.WALStatus.String == "unreserved":
+		logger.ThrottledWarnf("wal-retention", "replication slot[%s] retains wal beyond max_slot_wal_keep_size and is about to lose it; sync more often or raise max_slot_wal_keep_size", slot)
+	case retention.SafeWALSize.Valid && retention.SafeWALSize.Int64 < limit:
+		logger.ThrottledWarnf("wal-retention", "replication slot[%s] loses the wal it needs after %d MB more of wal; sync more often or raise max_slot_wal_keep_size", slot, retention.SafeWALSize.Int64>>20)
+	}
+	if retention.Retained.Int64 > limit {
+		logger.ThrottledWarnf("wal-retention", "replication slot[%s] retains %d MB of wal, %d MB of it not yet synced; the disk of the server fills up while syncs lag behind", slot, retention.Retained.Int64>>20, retention.Lag.Int64>>20)
+	}
+	return nil
+}
+
+// watchRetention checks retention until ctx is done, as wal piles up while changes are streamed
+func (p *Postgres) watchRetention(ctx context.Context) {
+	ticker := time.NewTicker(retentionCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.checkRetention(ctx); errors.Is(err, errWALLost) {
+				logger.Errorf("replication slot[%s] lost wal it needs while changes were streamed", p.cdcConfig.ReplicationSlot)
+			} else if err != nil && ctx.Err() == nil {
+				logger.Warn(err)
+			}
+		}
+	}
+}
+
+// writeHeartbeats writes a heartbeat to the wal right away and every heartbeat_interval until
+// ctx is done; the position of the slot follows the heartbeats while the synced tables are
+// quiet, so the server doesn't keep the wal written meanwhile for it
+func (p *Postgres) writeHeartbeats(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(p.cdcConfig.HeartbeatInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		if _, err := p.client.DB.ExecContext(ctx, p.cdcConfig.HeartbeatQuery); err != nil && ctx.Err() == nil {
+			logger.ThrottledWarnf("wal-heartbeat", "failed to write heartbeat to replication slot[%s]: %s", p.cdcConfig.ReplicationSlot, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recreateSlot drops the replication slot that lost wal and creates it at the current position
+func (p *Postgres) recreateSlot(ctx context.Context) error {
+	slot := p.cdcConfig.ReplicationSlot
+	if _, err := p.client.DB.ExecContext(ctx, dropReplicationSlot, slot); err != nil {
+		return fmt.Errorf("failed to drop replication slot[%s]: %s", slot, err)
+	}
+	if _, err := p.client.DB.ExecContext(ctx, createReplicationSlot, slot); err != nil {
+		return fmt.Errorf("failed to create replication slot[%s]: %s", slot, err)
+	}
+	return nil
+}
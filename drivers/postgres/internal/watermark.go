@@ -0,0 +1,126 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/datazip-inc/olake/pkg/waljs"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+	"github.com/jackc/pglogrepl"
+)
+
+// non transactional so it's in the wal before the snapshot of the chunk is taken
+const emitWatermark = `SELECT pg_logical_emit_message(false, '` + waljs.WatermarkPrefix + `', $1)::text`
+
+// watermarkTypes are the types of split columns chunks can be matched to changes by; other
+// types don't order in go as in postgres
+var watermarkTypes = map[types.DataType]bool{
+	types.Int64:          true,
+	types.Float64:        true,
+	types.Timestamp:      true,
+	types.TimestampMilli: true,
+	types.TimestampMicro: true,
+	types.TimestampNano:  true,
+}
+
+// snapshotWindow is a chunk of a CDC snapshot and the low watermark written to the wal right
+// before it was read; changes committed before the watermark are in the rows read
+type snapshotWindow struct {
+	chunk types.Chunk
+	low   pglogrepl.LSN
+}
+
+// watermarks keeps the windows of the chunks the snapshots of a CDC sync read, so changes the
+// snapshot already holds aren't written again after the newer rows, DBLog style. Chunks are
+// read in parallel, in any order, while changes are replayed from before the first one.
+type watermarks struct {
+	mutex   sync.Mutex
+	windows map[string][]snapshotWindow
+}
+
+func newWatermarks() *watermarks {
+	return &watermarks{windows: make(map[string][]snapshotWindow)}
+}
+
+// watermarked reports if the changes of stream can be matched to its chunks
+func watermarked(stream protocol.Stream) bool {
+	splitColumn := stream.Self().StreamMetadata.SplitColumn
+	if splitColumn == "" {
+		// ctid changes on update
+		return false
+	}
+	typ, err := stream.Schema().GetType(splitColumn)
+	return err == nil && watermarkTypes[typ]
+}
+
+// mark writes the low watermark of chunk to the wal and keeps its window; called before the
+// transaction reading the chunk starts
+func (w *watermarks) mark(ctx context.Context, p *Postgres, stream protocol.Stream, chunk types.Chunk, number int) error {
+	var lsn string
+	if err := p.client.QueryRowContext(ctx, emitWatermark, fmt.Sprintf("%s:%d", stream.ID(), number)).Scan(&lsn); err != nil {
+		return fmt.Errorf("failed to write watermark of chunk[%d] of stream[%s]: %s", number, stream.ID(), err)
+	}
+	low, err := pglogrepl.ParseLSN(lsn)
+	if err != nil {
+		return fmt.Errorf("failed to parse lsn[%s] of watermark: %s", lsn, err)
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.windows[stream.ID()] = append(w.windows[stream.ID()], snapshotWindow{chunk: chunk, low: low})
+	return nil
+}
+
+// covered reports if a change of stream at lsn is held by the snapshot already: every chunk
+// holding the row was read after the change was committed. Chunks share their bounds, so a row
+// can be read by two of them.
+func (w *watermarks) covered(stream protocol.Stream, lsn pglogrepl.LSN, data map[string]any) bool {
+	if w == nil {
+		return false
+	}
+	w.mutex.Lock()
+	windows := w.windows[stream.ID()]
+	w.mutex.Unlock()
+	if len(windows) == 0 {
+		return false
+	}
+	splitColumn := stream.Self().StreamMetadata.SplitColumn
+	typ, _ := stream.Schema().GetType(splitColumn)
+	value := data[splitColumn]
+	if value == nil {
+		return false
+	}
+
+	found := false
+	for _, window := range windows {
+		inside, err := chunkHolds(typ, window.chunk, value)
+		if err != nil {
+			return false
+		}
+		if !inside {
+			continue
+		}
+		if lsn >= window.low {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// chunkHolds reports if value is within the bounds of chunk, read with >= and <=
+func chunkHolds(typ types.DataType, chunk types.Chunk, value any) (bool, error) {
+	if chunk.Min != nil {
+		if result, err := typeutils.CompareCursor(typ, value, chunk.Min); err != nil || result < 0 {
+			return false, err
+		}
+	}
+	if chunk.Max != nil {
+		if result, err := typeutils.CompareCursor(typ, value, chunk.Max); err != nil || result > 0 {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,49 @@
+package driver
+
+import (
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+	"github.com/jackc/pglogrepl"
+)
+
+func TestWatermarksCovered(t *testing.T) {
+	source := types.NewStream("orders", "public")
+	source.UpsertField("id", types.Int64, false)
+	stream := &types.ConfiguredStream{Stream: source, StreamMetadata: types.StreamMetadata{SplitColumn: "id"}}
+	if !watermarked(stream) {
+		t.Fatal("expected an integer split column to be watermarked")
+	}
+
+	marks := newWatermarks()
+	// chunks share their bounds; the second was read before the first
+	marks.windows[stream.ID()] = []snapshotWindow{
+		{chunk: types.Chunk{Min: int64(1), Max: int64(100)}, low: 300},
+		{chunk: types.Chunk{Min: int64(100), Max: nil}, low: 200},
+	}
+	for _, test := range []struct {
+		lsn     uint64
+		id      any
+		covered bool
+	}{
+		{lsn: 250, id: int64(50), covered: true},
+		{lsn: 350, id: int64(50), covered: false},
+		{lsn: 150, id: int64(100), covered: true},
+		// read by the second chunk before the change
+		{lsn: 250, id: int64(100), covered: false},
+		{lsn: 250, id: float64(5000), covered: false},
+		{lsn: 150, id: int64(5000), covered: true},
+		// inserted below the first chunk after the snapshot started
+		{lsn: 100, id: int64(0), covered: false},
+		{lsn: 100, id: nil, covered: false},
+	} {
+		if covered := marks.covered(stream, pglogrepl.LSN(test.lsn), map[string]any{"id": test.id}); covered != test.covered {
+			t.Errorf("expected change of id %v at lsn %d covered: %t, got %t", test.id, test.lsn, test.covered, covered)
+		}
+	}
+
+	var none *watermarks
+	if none.covered(stream, 1, map[string]any{"id": int64(50)}) {
+		t.Fatal("expected no changes covered without watermarks")
+	}
+}
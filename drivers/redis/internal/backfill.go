@@ -0,0 +1,59 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/datazip-inc/olake/drivers/base"
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+)
+
+// backfill scans every key matching the stream pattern and writes its decoded records
+func (r *Redis) backfill(backfillCtx context.Context, pool *protocol.WriterPool, stream protocol.Stream) (err error) {
+	streamConfig, err := r.streamConfig(stream.Name())
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Starting full load for stream [%s] with pattern [%s]", stream.ID(), streamConfig.Pattern)
+	primaryKeys := stream.GetStream().SourceDefinedPrimaryKey.Array()
+
+	waitChannel := make(chan error, 1)
+	insert, err := pool.NewThread(backfillCtx, stream, protocol.WithErrorChannel(waitChannel))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		insert.Close()
+		if err == nil {
+			// wait for writer completion
+			err = <-waitChannel
+		}
+	}()
+
+	return r.scanKeys(backfillCtx, streamConfig.Pattern, func(keys []string) (bool, error) {
+		pool.AddRecordsToSync(stream, int64(len(keys)))
+		err := utils.Concurrent(backfillCtx, keys, r.config.MaxThreads, func(ctx context.Context, key string, _ int) error {
+			var records []map[string]any
+			err := base.RetryOnBackoff(r.config.RetryCount, 1*time.Minute, func() (err error) {
+				_, records, err = r.fetchKey(ctx, key)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, record := range records {
+				if err := insert.Insert(types.CreateRawRecord(utils.GetKeysHash(record, primaryKeys...), record, 0)); err != nil {
+					return fmt.Errorf("failed to insert key[%s]: %s", key, err)
+				}
+			}
+			return nil
+		})
+		return err == nil, err
+	})
+}
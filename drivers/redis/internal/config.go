@@ -0,0 +1,75 @@
+package driver
+
+import (
+	"fmt"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/network"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+)
+
+type Config struct {
+	Host        string         `json:"host" validate:"required"`
+	Port        int            `json:"port"`
+	Username    string         `json:"username"`
+	Password    string         `json:"password"`
+	Database    int            `json:"database"`
+	TLS         bool           `json:"tls"`
+	Streams     []StreamConfig `json:"streams" validate:"required,min=1,dive"`
+	ScanCount   int64          `json:"scan_count"`
+	SampleSize  int            `json:"sample_size"`
+	MaxThreads  int            `json:"max_threads"`
+	DefaultMode types.SyncMode `json:"default_mode"`
+	RetryCount  int            `json:"backoff_retry_count"`
+	// proxy and tls settings; tls here replaces the tls flag
+	Network *network.Config `json:"network,omitempty"`
+}
+
+// StreamConfig maps every key matching Pattern into the stream Name
+type StreamConfig struct {
+	Name    string `json:"name" validate:"required"`
+	Pattern string `json:"pattern" validate:"required"`
+}
+
+func (c *Config) Address() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+// Namespace of all streams; redis databases are only addressable by index
+func (c *Config) Namespace() string {
+	return fmt.Sprintf("db%d", c.Database)
+}
+
+func (c *Config) Validate() error {
+	if err := c.Network.Validate(); err != nil {
+		return err
+	}
+	if c.Port == 0 {
+		c.Port = 6379
+	}
+	if c.ScanCount <= 0 {
+		c.ScanCount = 1000
+	}
+	if c.SampleSize <= 0 {
+		c.SampleSize = 1000
+	}
+	if c.MaxThreads <= 0 {
+		// set default threads
+		logger.Info("setting max threads to default[10]")
+		c.MaxThreads = 10
+	}
+	if c.DefaultMode == "" {
+		c.DefaultMode = types.FULLREFRESH
+	}
+
+	names := make(map[string]bool)
+	for _, stream := range c.Streams {
+		if names[stream.Name] {
+			return fmt.Errorf("duplicate stream name[%s] in config", stream.Name)
+		}
+		names[stream.Name] = true
+	}
+
+	return utils.Validate(c)
+}
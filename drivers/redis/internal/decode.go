@@ -0,0 +1,144 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyField     = "key"
+	typeField    = "type"
+	valueField   = "value"
+	entryIDField = "id"
+
+	stringType = "string"
+	hashType   = "hash"
+	listType   = "list"
+	setType    = "set"
+	zsetType   = "zset"
+	streamType = "stream"
+)
+
+// scanKeys iterates over all keys matching pattern with SCAN, handing every page to process
+// until the cursor is exhausted or process returns false
+func (r *Redis) scanKeys(ctx context.Context, pattern string, process func(keys []string) (bool, error)) error {
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, pattern, r.config.ScanCount).Result()
+		if err != nil {
+			return fmt.Errorf("failed to scan pattern[%s]: %s", pattern, err)
+		}
+		if len(keys) > 0 {
+			proceed, err := process(keys)
+			if err != nil {
+				return err
+			}
+			if !proceed {
+				return nil
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// fetchKey reads a key according to its TYPE and returns the records it produces;
+// stream keys produce a record per entry while every other type produces exactly one.
+// An empty keyType is returned when the key vanished or holds an unsupported type
+func (r *Redis) fetchKey(ctx context.Context, key string) (string, []map[string]any, error) {
+	keyType, err := r.client.Type(ctx, key).Result()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get type of key[%s]: %s", key, err)
+	}
+
+	var value any
+	switch keyType {
+	case stringType:
+		value, err = r.client.Get(ctx, key).Result()
+	case hashType:
+		var fields map[string]string
+		fields, err = r.client.HGetAll(ctx, key).Result()
+		value = hashValue(fields)
+	case listType:
+		var items []string
+		items, err = r.client.LRange(ctx, key, 0, -1).Result()
+		value = listValue(items)
+	case setType:
+		var members []string
+		members, err = r.client.SMembers(ctx, key).Result()
+		value = listValue(members)
+	case zsetType:
+		var members []redis.Z
+		members, err = r.client.ZRangeWithScores(ctx, key, 0, -1).Result()
+		value = zsetValue(members)
+	case streamType:
+		messages, err := r.client.XRange(ctx, key, "-", "+").Result()
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read stream[%s]: %s", key, err)
+		}
+		return keyType, streamRecords(key, messages), nil
+	case "none":
+		// key expired or was deleted after SCAN returned it
+		return "", nil, nil
+	default:
+		logger.Warnf("skipping key[%s] with unsupported type[%s]", key, keyType)
+		return "", nil, nil
+	}
+	if err == redis.Nil {
+		return "", nil, nil
+	} else if err != nil {
+		return "", nil, fmt.Errorf("failed to read %s key[%s]: %s", keyType, key, err)
+	}
+
+	return keyType, []map[string]any{keyRecord(key, keyType, value)}, nil
+}
+
+func keyRecord(key, keyType string, value any) map[string]any {
+	return map[string]any{
+		keyField:   key,
+		typeField:  keyType,
+		valueField: value,
+	}
+}
+
+func hashValue(fields map[string]string) map[string]any {
+	value := make(map[string]any, len(fields))
+	for field, fieldValue := range fields {
+		value[field] = fieldValue
+	}
+	return value
+}
+
+func listValue(items []string) []any {
+	value := make([]any, 0, len(items))
+	for _, item := range items {
+		value = append(value, item)
+	}
+	return value
+}
+
+func zsetValue(members []redis.Z) []any {
+	value := make([]any, 0, len(members))
+	for _, member := range members {
+		value = append(value, map[string]any{
+			"member": fmt.Sprint(member.Member),
+			"score":  member.Score,
+		})
+	}
+	return value
+}
+
+func streamRecords(key string, messages []redis.XMessage) []map[string]any {
+	records := make([]map[string]any, 0, len(messages))
+	for _, message := range messages {
+		record := keyRecord(key, streamType, message.Values)
+		record[entryIDField] = message.ID
+		records = append(records, record)
+	}
+	return records
+}
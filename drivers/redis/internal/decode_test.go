@@ -0,0 +1,43 @@
+package driver
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestZsetValue(t *testing.T) {
+	value := zsetValue([]redis.Z{{Member: "a", Score: 1.5}, {Member: 2, Score: 3}})
+	expected := []any{
+		map[string]any{"member": "a", "score": 1.5},
+		map[string]any{"member": "2", "score": float64(3)},
+	}
+	if !reflect.DeepEqual(value, expected) {
+		t.Fatalf("expected %v, got %v", expected, value)
+	}
+}
+
+func TestStreamRecords(t *testing.T) {
+	records := streamRecords("events", []redis.XMessage{
+		{ID: "1-0", Values: map[string]any{"a": "1"}},
+		{ID: "1-1", Values: map[string]any{"b": "2"}},
+	})
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	for i, id := range []string{"1-0", "1-1"} {
+		if records[i][entryIDField] != id || records[i][keyField] != "events" || records[i][typeField] != streamType {
+			t.Fatalf("unexpected record %v", records[i])
+		}
+	}
+}
+
+func TestHashAndListValue(t *testing.T) {
+	if value := hashValue(map[string]string{"f": "v"}); !reflect.DeepEqual(value, map[string]any{"f": "v"}) {
+		t.Fatalf("unexpected hash value %v", value)
+	}
+	if value := listValue([]string{"x", "y"}); !reflect.DeepEqual(value, []any{"x", "y"}) {
+		t.Fatalf("unexpected list value %v", value)
+	}
+}
@@ -0,0 +1,128 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	offsetsCursorKey = "offsets"
+	// XREAD start id to read a stream from its first entry
+	streamStartID = "0-0"
+)
+
+// incrementalSync reads redis stream keys matching the stream pattern with XREAD,
+// resuming every key from the last entry id recorded in state
+func (r *Redis) incrementalSync(syncCtx context.Context, pool *protocol.WriterPool, stream protocol.Stream) (err error) {
+	streamConfig, err := r.streamConfig(stream.Name())
+	if err != nil {
+		return err
+	}
+
+	offsets := loadOffsets(r.State.GetCursor(stream.Self(), offsetsCursorKey))
+	logger.Infof("Starting incremental sync for stream [%s] with %d known offsets", stream.ID(), len(offsets))
+	primaryKeys := stream.GetStream().SourceDefinedPrimaryKey.Array()
+
+	waitChannel := make(chan error, 1)
+	insert, err := pool.NewThread(syncCtx, stream, protocol.WithErrorChannel(waitChannel))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		insert.Close()
+		if err == nil {
+			// wait for writer completion
+			err = <-waitChannel
+		}
+	}()
+
+	return r.scanKeys(syncCtx, streamConfig.Pattern, func(keys []string) (bool, error) {
+		streamKeys := []string{}
+		for _, key := range keys {
+			keyType, err := r.client.Type(syncCtx, key).Result()
+			if err != nil {
+				return false, fmt.Errorf("failed to get type of key[%s]: %s", key, err)
+			}
+			if keyType != streamType {
+				logger.Debugf("skipping non stream key[%s] of type[%s] in incremental sync", key, keyType)
+				continue
+			}
+			streamKeys = append(streamKeys, key)
+		}
+
+		for len(streamKeys) > 0 {
+			results, err := r.client.XRead(syncCtx, &redis.XReadArgs{
+				Streams: xreadStreams(streamKeys, offsets),
+				Count:   r.config.ScanCount,
+				Block:   -1, // never block; incremental sync only drains what is present
+			}).Result()
+			if err == redis.Nil {
+				break
+			} else if err != nil {
+				return false, fmt.Errorf("failed to xread streams: %s", err)
+			}
+
+			// only keys that returned entries may have more pending
+			streamKeys = streamKeys[:0]
+			for _, result := range results {
+				for _, record := range streamRecords(result.Stream, result.Messages) {
+					if err := insert.Insert(types.CreateRawRecord(utils.GetKeysHash(record, primaryKeys...), record, 0)); err != nil {
+						return false, fmt.Errorf("failed to insert entry of key[%s]: %s", result.Stream, err)
+					}
+				}
+				if len(result.Messages) > 0 {
+					offsets[result.Stream] = result.Messages[len(result.Messages)-1].ID
+					streamKeys = append(streamKeys, result.Stream)
+				}
+			}
+			r.State.SetCursor(stream.Self(), offsetsCursorKey, copyOffsets(offsets))
+		}
+		return true, nil
+	})
+}
+
+// xreadStreams builds the XREAD STREAMS argument; all keys followed by their start ids
+func xreadStreams(keys []string, offsets map[string]string) []string {
+	streams := make([]string, 0, 2*len(keys))
+	streams = append(streams, keys...)
+	for _, key := range keys {
+		id, found := offsets[key]
+		if !found {
+			id = streamStartID
+		}
+		streams = append(streams, id)
+	}
+	return streams
+}
+
+// loadOffsets reads offsets cursor from state; state loaded from a file holds map[string]any
+func loadOffsets(cursor any) map[string]string {
+	offsets := make(map[string]string)
+	switch cursor := cursor.(type) {
+	case map[string]string:
+		for key, id := range cursor {
+			offsets[key] = id
+		}
+	case map[string]any:
+		for key, id := range cursor {
+			if id, ok := id.(string); ok {
+				offsets[key] = id
+			}
+		}
+	}
+	return offsets
+}
+
+func copyOffsets(offsets map[string]string) map[string]string {
+	copied := make(map[string]string, len(offsets))
+	for key, id := range offsets {
+		copied[key] = id
+	}
+	return copied
+}
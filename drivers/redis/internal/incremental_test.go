@@ -0,0 +1,29 @@
+package driver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestXreadStreams(t *testing.T) {
+	streams := xreadStreams([]string{"a", "b"}, map[string]string{"b": "5-1"})
+	expected := []string{"a", "b", streamStartID, "5-1"}
+	if !reflect.DeepEqual(streams, expected) {
+		t.Fatalf("expected %v, got %v", expected, streams)
+	}
+}
+
+func TestLoadOffsets(t *testing.T) {
+	expected := map[string]string{"a": "1-0"}
+	// cursor set during the same run
+	if offsets := loadOffsets(map[string]string{"a": "1-0"}); !reflect.DeepEqual(offsets, expected) {
+		t.Fatalf("expected %v, got %v", expected, offsets)
+	}
+	// cursor unmarshalled from state file
+	if offsets := loadOffsets(map[string]any{"a": "1-0", "b": 2}); !reflect.DeepEqual(offsets, expected) {
+		t.Fatalf("expected %v, got %v", expected, offsets)
+	}
+	if offsets := loadOffsets(nil); len(offsets) != 0 {
+		t.Fatalf("expected empty offsets, got %v", offsets)
+	}
+}
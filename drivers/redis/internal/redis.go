@@ -0,0 +1,215 @@
+package driver
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/datazip-inc/olake/drivers"
+	"github.com/datazip-inc/olake/drivers/base"
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/network"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	discoverTime        = 5 * time.Minute // maximum time allowed to discover all the streams
+	defaultBackoffCount = 3
+)
+
+type Redis struct {
+	*base.Driver
+	config *Config
+	client *redis.Client
+}
+
+func init() {
+	drivers.Register("redis", func() protocol.Driver {
+		return &Redis{Driver: base.NewBase()}
+	})
+}
+
+// config reference; must be pointer
+func (r *Redis) GetConfigRef() protocol.Config {
+	r.config = &Config{}
+	return r.config
+}
+
+func (r *Redis) Spec() any {
+	return Config{}
+}
+
+func (r *Redis) Setup() error {
+	if err := r.config.Validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
+
+	opts := &redis.Options{
+		Addr:     r.config.Address(),
+		Username: r.config.Username,
+		Password: r.config.Password,
+		DB:       r.config.Database,
+	}
+	tlsConfig, err := r.config.Network.TLSConfig()
+	if err != nil {
+		return err
+	}
+	if tlsConfig == nil && r.config.TLS {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	opts.TLSConfig = tlsConfig
+	if r.config.Network.Enabled() {
+		// redis skips its own tls when given a dialer
+		opts.Dialer = func(ctx context.Context, networkType, address string) (net.Conn, error) {
+			conn, err := r.config.Network.DialContext(ctx, networkType, address)
+			if err != nil || tlsConfig == nil {
+				return conn, err
+			}
+			return network.ClientTLS(conn, tlsConfig, address), nil
+		}
+	}
+	r.client = redis.NewClient(opts)
+
+	pingCtx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+	if err := r.client.Ping(pingCtx).Err(); err != nil {
+		return fmt.Errorf("failed to ping redis: %s", err)
+	}
+
+	// check for default backoff count
+	if r.config.RetryCount < 0 {
+		logger.Infof("setting backoff retry count to default value %d", defaultBackoffCount)
+		r.config.RetryCount = defaultBackoffCount
+	} else {
+		// add 1 for first run
+		r.config.RetryCount += 1
+	}
+	return nil
+}
+
+func (r *Redis) Check() error {
+	pingCtx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	return r.client.Ping(pingCtx).Err()
+}
+
+func (r *Redis) SetupState(state *types.State) {
+	state.Type = types.StreamType
+	r.State = state
+}
+
+func (r *Redis) Close() error {
+	if r.client == nil {
+		return nil
+	}
+	if err := r.client.Close(); err != nil {
+		return err
+	}
+	return r.config.Network.Close()
+}
+
+func (r *Redis) Type() string {
+	return "Redis"
+}
+
+// Discover produces one stream per configured key pattern, typing it from a sample of matching keys
+func (r *Redis) Discover(_ bool) ([]*types.Stream, error) {
+	streams := r.GetStreams()
+	if len(streams) != 0 {
+		return streams, nil
+	}
+
+	logger.Infof("Starting discover for Redis database %d", r.config.Database)
+	discoverCtx, cancel := context.WithTimeout(context.Background(), discoverTime)
+	defer cancel()
+
+	failures := &types.DiscoverError{}
+	err := utils.Concurrent(discoverCtx, r.config.Streams, r.DiscoverConcurrency(r.config.MaxThreads), func(ctx context.Context, streamConfig StreamConfig, _ int) error {
+		stream, err := r.produceStreamSchema(ctx, streamConfig)
+		if err != nil {
+			// the others are still sampled; failures are reported once they are
+			failures.Add(streamConfig.Pattern, err)
+			return nil
+		}
+		stream.SyncMode = r.config.DefaultMode
+		// cache stream
+		r.AddStream(stream)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetStreams(), failures.Err()
+}
+
+func (r *Redis) Read(ctx context.Context, pool *protocol.WriterPool, stream protocol.Stream) error {
+	switch stream.GetSyncMode() {
+	case types.FULLREFRESH:
+		return r.backfill(ctx, pool, stream)
+	case types.INCREMENTAL:
+		return r.incrementalSync(ctx, pool, stream)
+	}
+
+	return nil
+}
+
+// sample keys matching the stream pattern and resolve the stream schema from them
+func (r *Redis) produceStreamSchema(ctx context.Context, streamConfig StreamConfig) (*types.Stream, error) {
+	logger.Infof("producing type schema for stream [%s] with pattern [%s]", streamConfig.Name, streamConfig.Pattern)
+
+	stream := types.NewStream(streamConfig.Name, r.config.Namespace()).WithSyncMode(types.FULLREFRESH)
+	stream.UpsertField(keyField, types.String, false)
+	stream.UpsertField(typeField, types.String, false)
+
+	var objects []map[string]any
+	onlyStreams := true
+	sampled := 0
+	err := r.scanKeys(ctx, streamConfig.Pattern, func(keys []string) (bool, error) {
+		for _, key := range keys {
+			keyType, records, err := r.fetchKey(ctx, key)
+			if err != nil {
+				return false, err
+			}
+			if keyType == "" {
+				continue
+			}
+			onlyStreams = onlyStreams && keyType == streamType
+			objects = append(objects, records...)
+			sampled++
+			if sampled >= r.config.SampleSize {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// stream entries carry monotonically increasing ids that can be resumed from
+	if sampled > 0 && onlyStreams {
+		stream.WithSyncMode(types.INCREMENTAL).WithCursorField(entryIDField).WithPrimaryKey(keyField, entryIDField)
+	} else {
+		stream.WithPrimaryKey(keyField)
+	}
+
+	return stream, typeutils.Resolve(stream, objects...)
+}
+
+// returns the configured pattern for the stream
+func (r *Redis) streamConfig(name string) (StreamConfig, error) {
+	for _, streamConfig := range r.config.Streams {
+		if streamConfig.Name == name {
+			return streamConfig, nil
+		}
+	}
+	return StreamConfig{}, fmt.Errorf("stream[%s] not present in config", name)
+}
@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/datazip-inc/olake"
+	_ "github.com/datazip-inc/olake/drivers/redis/internal" // registering redis driver
+)
+
+func main() {
+	olake.Run("redis")
+}
@@ -0,0 +1,62 @@
+// Package drivers is the registry source drivers add themselves to, so a binary can bundle
+// several drivers and pick one by name
+package drivers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/datazip-inc/olake/protocol"
+)
+
+// Factory returns a new instance of a driver
+type Factory func() protocol.Driver
+
+var (
+	mutex    sync.RWMutex
+	registry = map[string]Factory{}
+)
+
+// Register makes the driver returned by factory available under name; drivers register in the
+// init of their package. Registering a name twice panics
+func Register(name string, factory Factory) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	name = strings.ToLower(name)
+	if factory == nil {
+		panic(fmt.Sprintf("driver[%s] registered without a factory", name))
+	}
+	if _, found := registry[name]; found {
+		panic(fmt.Sprintf("driver[%s] registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// New returns a new instance of the driver registered under name
+func New(name string) (protocol.Driver, error) {
+	mutex.RLock()
+	factory, found := registry[strings.ToLower(name)]
+	mutex.RUnlock()
+	if !found {
+		available := Names()
+		if len(available) == 0 {
+			return nil, fmt.Errorf("unknown driver[%s]; no drivers are registered in this binary", name)
+		}
+		return nil, fmt.Errorf("unknown driver[%s]; available drivers: %s", name, strings.Join(available, ", "))
+	}
+	return factory(), nil
+}
+
+// Names returns the sorted names of the registered drivers
+func Names() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,35 @@
+package drivers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/datazip-inc/olake/protocol"
+)
+
+func TestRegistry(t *testing.T) {
+	var created int
+	Register("Test-Source", func() protocol.Driver {
+		created++
+		return nil
+	})
+
+	if _, err := New("test-source"); err != nil {
+		t.Fatalf("expected names to be case insensitive: %s", err)
+	}
+	if created != 1 {
+		t.Fatalf("expected the factory to be called once, got %d", created)
+	}
+
+	_, err := New("missing")
+	if err == nil || !strings.Contains(err.Error(), "available drivers: test-source") {
+		t.Fatalf("expected unknown driver error listing the registered drivers, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected registering a name twice to panic")
+		}
+	}()
+	Register("test-source", func() protocol.Driver { return nil })
+}
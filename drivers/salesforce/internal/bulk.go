@@ -0,0 +1,167 @@
+package driver
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/datazip-inc/olake/drivers/base"
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+)
+
+const (
+	bulkPollInterval = 5 * time.Second
+	bulkPageSize     = 50000
+)
+
+type bulkJob struct {
+	ID           string `json:"id"`
+	State        string `json:"state"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+// bulkSync exports the whole object with a Bulk API 2.0 query job
+func (s *Salesforce) bulkSync(ctx context.Context, pool *protocol.WriterPool, stream protocol.Stream) error {
+	_, err := s.bulkExport(ctx, pool, stream)
+	return err
+}
+
+// bulkExport runs a bulk query over all fields of the object and returns the time the
+// job was created, which is the point incremental syncs continue from
+func (s *Salesforce) bulkExport(ctx context.Context, pool *protocol.WriterPool, stream protocol.Stream) (_ time.Time, err error) {
+	fields, err := s.getFields(stream)
+	if err != nil {
+		return time.Time{}, err
+	}
+	fieldNames := make([]string, 0, len(fields))
+	dataTypes := make(map[string]types.DataType, len(fields))
+	for _, field := range fields {
+		fieldNames = append(fieldNames, field.Name)
+		dataTypes[field.Name] = salesforceTypeToDataType(field.Type)
+	}
+
+	startTime := time.Now().UTC()
+	var job bulkJob
+	err = s.client.JSON(ctx, http.MethodPost, s.client.dataPath("/jobs/query"), map[string]string{
+		"operation": "query",
+		"query":     fmt.Sprintf("SELECT %s FROM %s", strings.Join(fieldNames, ", "), stream.Name()),
+	}, &job)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to create bulk job: %s", err)
+	}
+	logger.Infof("Created bulk job[%s] for stream [%s]", job.ID, stream.ID())
+
+	if err := s.waitForBulkJob(ctx, &job); err != nil {
+		return time.Time{}, err
+	}
+
+	waitChannel := make(chan error, 1)
+	insert, err := pool.NewThread(ctx, stream, protocol.WithErrorChannel(waitChannel))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer func() {
+		insert.Close()
+		if err == nil {
+			// wait for writer completion
+			err = <-waitChannel
+		}
+	}()
+
+	locator := ""
+	for {
+		err = base.RetryOnBackoff(s.config.RetryCount, 1*time.Minute, func() error {
+			next, err := s.readBulkPage(ctx, job.ID, locator, dataTypes, func(record map[string]any) error {
+				return insert.Insert(types.CreateRawRecord(utils.GetKeysHash(record, primaryKey), record, 0))
+			})
+			if err == nil {
+				locator = next
+			}
+			return err
+		})
+		if err != nil {
+			return time.Time{}, err
+		}
+		if locator == "" {
+			return startTime, nil
+		}
+	}
+}
+
+func (s *Salesforce) waitForBulkJob(ctx context.Context, job *bulkJob) error {
+	for {
+		switch job.State {
+		case "JobComplete":
+			return nil
+		case "Failed", "Aborted":
+			return fmt.Errorf("bulk job[%s] %s: %s", job.ID, strings.ToLower(job.State), job.ErrorMessage)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(bulkPollInterval):
+		}
+		if err := s.client.JSON(ctx, http.MethodGet, s.client.dataPath("/jobs/query/"+job.ID), nil, job); err != nil {
+			return fmt.Errorf("failed to poll bulk job[%s]: %s", job.ID, err)
+		}
+	}
+}
+
+// readBulkPage emits one page of bulk results and returns the locator of the next page
+func (s *Salesforce) readBulkPage(ctx context.Context, jobID, locator string, dataTypes map[string]types.DataType, emit func(map[string]any) error) (string, error) {
+	query := url.Values{"maxRecords": {fmt.Sprint(bulkPageSize)}}
+	if locator != "" {
+		query.Set("locator", locator)
+	}
+	resp, err := s.client.Do(ctx, http.MethodGet, s.client.dataPath(fmt.Sprintf("/jobs/query/%s/results?%s", jobID, query.Encode())), nil, map[string]string{"Accept": "text/csv"})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch bulk results: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if err := readBulkCSV(resp.Body, dataTypes, emit); err != nil {
+		return "", err
+	}
+
+	next := resp.Header.Get("Sforce-Locator")
+	if next == "null" {
+		next = ""
+	}
+	return next, nil
+}
+
+func readBulkCSV(reader io.Reader, dataTypes map[string]types.DataType, emit func(map[string]any) error) error {
+	csvReader := csv.NewReader(reader)
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read bulk results header: %s", err)
+	}
+
+	for {
+		row, err := csvReader.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read bulk results: %s", err)
+		}
+
+		record := make(map[string]any, len(header))
+		for idx, column := range header {
+			record[column] = parseCSVValue(dataTypes[column], row[idx])
+		}
+		if err := emit(record); err != nil {
+			return err
+		}
+	}
+}
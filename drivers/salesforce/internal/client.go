@@ -0,0 +1,187 @@
+package driver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/httpclient"
+	"github.com/datazip-inc/olake/pkg/oauth"
+	"github.com/goccy/go-json"
+)
+
+// Client is a minimal Salesforce REST client that re-authenticates on expired sessions
+type Client struct {
+	config *Config
+	http   *httpclient.Client
+	// refreshes the session when a refresh token is configured
+	tokens      *oauth.TokenSource
+	mutex       sync.RWMutex
+	token       *oauth.Token
+	accessToken string
+	instanceURL string
+}
+
+// NewClient retries throttled and unavailable responses up to the backoff retry count and
+// keeps to the configured request rate
+func NewClient(config *Config, credentials oauth.CredentialStore) (*Client, error) {
+	transport, err := config.Network.HTTPTransport()
+	if err != nil {
+		return nil, err
+	}
+	client := &Client{
+		config: config,
+		http: httpclient.New(httpclient.Config{
+			Timeout:   10 * time.Minute,
+			Retry:     httpclient.RetryPolicy{MaxAttempts: config.RetryCount},
+			RateLimit: config.RequestsPerSecond,
+			Transport: transport,
+		}),
+	}
+	if config.RefreshToken != "" {
+		client.tokens = oauth.NewTokenSource(config.oauthConfig(transport))
+		if credentials != nil {
+			client.tokens.Persist(credentials, oauth.RefreshTokenKey)
+		}
+	}
+	return client, nil
+}
+
+// Authenticate fetches an access token with the refresh token or username-password flow
+func (c *Client) Authenticate(ctx context.Context) error {
+	if c.tokens != nil {
+		return c.refresh(ctx)
+	}
+
+	form := url.Values{
+		"client_id":     {c.config.ClientID},
+		"client_secret": {c.config.ClientSecret},
+		"grant_type":    {"password"},
+		"username":      {c.config.Username},
+		"password":      {c.config.Password},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.LoginURL+"/services/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to authenticate: %s", err)
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		InstanceURL      string `json:"instance_url"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("failed to decode token response: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to authenticate: %s: %s", token.Error, token.ErrorDescription)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.accessToken = token.AccessToken
+	c.instanceURL = strings.TrimSuffix(token.InstanceURL, "/")
+	return nil
+}
+
+// refresh replaces a rejected session with a refreshed one; salesforce sends the instance url
+// with every token
+func (c *Client) refresh(ctx context.Context) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.token != nil {
+		c.tokens.Invalidate(c.token)
+	}
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to authenticate: %s", err)
+	}
+	c.token = token
+	c.accessToken = token.AccessToken
+	if instanceURL := token.ExtraString("instance_url"); instanceURL != "" {
+		c.instanceURL = strings.TrimSuffix(instanceURL, "/")
+	}
+	return nil
+}
+
+// dataPath prefixes path with the versioned data api
+func (c *Client) dataPath(path string) string {
+	return fmt.Sprintf("/services/data/v%s%s", c.config.APIVersion, path)
+}
+
+// Do sends a request to a path relative to the instance, retrying once with a fresh
+// session on 401; the response body must be closed by the caller
+func (c *Client) Do(ctx context.Context, method, path string, body any, headers map[string]string) (*http.Response, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		c.mutex.RLock()
+		req, err := http.NewRequestWithContext(ctx, method, c.instanceURL+path, bytes.NewReader(payload))
+		if err != nil {
+			c.mutex.RUnlock()
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+c.accessToken)
+		c.mutex.RUnlock()
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			resp.Body.Close()
+			logger.Info("salesforce session expired, re-authenticating")
+			if err := c.Authenticate(ctx); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode >= http.StatusBadRequest {
+			defer resp.Body.Close()
+			message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			return nil, fmt.Errorf("%s %s returned %s: %s", method, path, resp.Status, message)
+		}
+		return resp, nil
+	}
+}
+
+// JSON sends a request and decodes the json response into out
+func (c *Client) JSON(ctx context.Context, method, path string, body, out any) error {
+	resp, err := c.Do(ctx, method, path, body, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
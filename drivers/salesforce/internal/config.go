@@ -0,0 +1,90 @@
+package driver
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/network"
+	"github.com/datazip-inc/olake/pkg/oauth"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+)
+
+const defaultLoginURL = "https://login.salesforce.com"
+
+type Config struct {
+	LoginURL     string `json:"login_url"`
+	ClientID     string `json:"client_id" validate:"required"`
+	ClientSecret string `json:"client_secret" validate:"required"`
+	// refresh token of a connected app; used when present
+	RefreshToken string `json:"refresh_token"`
+	// username-password flow; password must include the security token when required
+	Username    string         `json:"username"`
+	Password    string         `json:"password"`
+	APIVersion  string         `json:"api_version"`
+	Objects     []string       `json:"objects"` // objects to discover; all replicable objects when empty
+	StartDate   string         `json:"start_date"`
+	MaxThreads  int            `json:"max_threads"`
+	DefaultMode types.SyncMode `json:"default_mode"`
+	RetryCount  int            `json:"backoff_retry_count"`
+	// api requests sent per second; unlimited when 0
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	// proxy and tls settings of the api and login connections
+	Network *network.Config `json:"network,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	if c.LoginURL == "" {
+		c.LoginURL = defaultLoginURL
+	}
+	c.LoginURL = strings.TrimSuffix(c.LoginURL, "/")
+	if c.APIVersion == "" {
+		c.APIVersion = "59.0"
+	}
+	c.APIVersion = strings.TrimPrefix(c.APIVersion, "v")
+	if c.RefreshToken == "" && (c.Username == "" || c.Password == "") {
+		return fmt.Errorf("either refresh_token or username and password are required")
+	}
+	if c.StartDate != "" {
+		if _, err := time.Parse(time.RFC3339, c.StartDate); err != nil {
+			return fmt.Errorf("invalid start_date[%s]; expected RFC3339: %s", c.StartDate, err)
+		}
+	}
+	if err := c.Network.Validate(); err != nil {
+		return err
+	}
+	if c.RequestsPerSecond < 0 {
+		return fmt.Errorf("invalid requests_per_second[%v]; expected 0 for unlimited or more", c.RequestsPerSecond)
+	}
+	if c.MaxThreads <= 0 {
+		// set default threads
+		logger.Info("setting max threads to default[5]")
+		c.MaxThreads = 5
+	}
+	if c.DefaultMode == "" {
+		c.DefaultMode = types.FULLREFRESH
+	}
+
+	return utils.Validate(c)
+}
+
+// oauthConfig of the connected app; the callback url of the authorize command,
+// http://127.0.0.1:8085/callback, must be allowed by the app
+func (c *Config) oauthConfig(transport http.RoundTripper) *oauth.Config {
+	loginURL := strings.TrimSuffix(c.LoginURL, "/")
+	if loginURL == "" {
+		loginURL = defaultLoginURL
+	}
+	return &oauth.Config{
+		Transport:    transport,
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		AuthURL:      loginURL + "/services/oauth2/authorize",
+		TokenURL:     loginURL + "/services/oauth2/token",
+		Scopes:       []string{"api", "refresh_token"},
+		RefreshToken: c.RefreshToken,
+	}
+}
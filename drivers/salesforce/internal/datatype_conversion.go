@@ -0,0 +1,101 @@
+package driver
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+)
+
+// Field is a field of a describe call
+type Field struct {
+	Name              string `json:"name"`
+	Type              string `json:"type"`
+	Nillable          bool   `json:"nillable"`
+	CompoundFieldName string `json:"compoundFieldName"`
+}
+
+// salesforceTypeToDataType maps a describe field type into an olake type
+func salesforceTypeToDataType(fieldType string) types.DataType {
+	switch strings.ToLower(fieldType) {
+	case "boolean":
+		return types.Bool
+	case "int", "long":
+		return types.Int64
+	case "double", "currency", "percent":
+		return types.Float64
+	case "date", "datetime":
+		return types.Timestamp
+	case "address", "location", "json":
+		return types.Object
+	default:
+		// id, reference, string, picklist, multipicklist, textarea, email, phone, url, time, combobox, encryptedstring ...
+		return types.String
+	}
+}
+
+// bulkQueryable reports whether a field can be selected in a Bulk API query; compound
+// fields are not supported and base64 fields can't be exported in csv
+func bulkQueryable(field Field) bool {
+	switch strings.ToLower(field.Type) {
+	case "address", "location", "base64":
+		return false
+	}
+	return true
+}
+
+// parseCSVValue converts a bulk csv cell into a value of the field's type; bulk results
+// don't distinguish null from empty strings
+func parseCSVValue(dataType types.DataType, value string) any {
+	if value == "" {
+		return nil
+	}
+	switch dataType {
+	case types.Bool:
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	case types.Int64:
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return parsed
+		}
+		// long values may be exported with a fraction
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return int64(parsed)
+		}
+	case types.Float64:
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	case types.Timestamp:
+		if parsed, err := parseTimestamp(value); err == nil {
+			return parsed
+		}
+	}
+	return value
+}
+
+// parseTimestamp parses salesforce date and datetime values
+func parseTimestamp(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02T15:04:05.000-0700", "2006-01-02T15:04:05.000Z0700", time.RFC3339Nano, "2006-01-02"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.UTC(), nil
+		}
+	}
+	return typeutils.ReformatDate(value)
+}
+
+// normalizeJSONValue converts REST api values into values of the field's type
+func normalizeJSONValue(dataType types.DataType, value any) any {
+	if str, ok := value.(string); ok && dataType != types.String {
+		return parseCSVValue(dataType, str)
+	}
+	if dataType == types.Int64 {
+		if parsed, err := typeutils.ReformatInt64(value); err == nil {
+			return parsed
+		}
+	}
+	return value
+}
@@ -0,0 +1,67 @@
+package driver
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestParseCSVValue(t *testing.T) {
+	timestamp := time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)
+	cases := []struct {
+		dataType types.DataType
+		value    string
+		expected any
+	}{
+		{types.String, "", nil},
+		{types.Bool, "true", true},
+		{types.Int64, "42", int64(42)},
+		{types.Int64, "42.0", int64(42)},
+		{types.Float64, "1.5", 1.5},
+		{types.Timestamp, "2024-03-01T10:30:00.000Z", timestamp},
+		{types.Timestamp, "2024-03-01T12:30:00.000+0200", timestamp},
+		{types.String, "abc", "abc"},
+	}
+	for _, c := range cases {
+		got := parseCSVValue(c.dataType, c.value)
+		if gotTime, ok := got.(time.Time); ok {
+			if !gotTime.Equal(c.expected.(time.Time)) {
+				t.Errorf("%s[%s]: expected %v, got %v", c.dataType, c.value, c.expected, got)
+			}
+			continue
+		}
+		if got != c.expected {
+			t.Errorf("%s[%s]: expected %v, got %v", c.dataType, c.value, c.expected, got)
+		}
+	}
+}
+
+func TestReadBulkCSV(t *testing.T) {
+	input := "Id,IsDeleted,Amount\n001,false,10.5\n002,true,\n"
+	var records []map[string]any
+	err := readBulkCSV(strings.NewReader(input), map[string]types.DataType{
+		"Id":        types.String,
+		"IsDeleted": types.Bool,
+		"Amount":    types.Float64,
+	}, func(record map[string]any) error {
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0]["Amount"] != 10.5 || records[1]["IsDeleted"] != true || records[1]["Amount"] != nil {
+		t.Fatalf("unexpected records %v", records)
+	}
+}
+
+func TestBulkQueryable(t *testing.T) {
+	if bulkQueryable(Field{Name: "BillingAddress", Type: "address"}) {
+		t.Fatal("compound address field must not be queried with bulk")
+	}
+	if !bulkQueryable(Field{Name: "BillingCity", Type: "string", CompoundFieldName: "BillingAddress"}) {
+		t.Fatal("components of compound fields must be queried")
+	}
+}
@@ -0,0 +1,160 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+)
+
+const (
+	latestDateCoveredKey = "latest_date_covered"
+	// salesforce keeps updated and deleted record ids for 30 days
+	replicationWindow = 30 * 24 * time.Hour
+	// maximum ids per sobject collections retrieve call
+	retrieveBatchSize = 200
+)
+
+// incrementalSync replicates records changed since the cursor with the updated and
+// deleted endpoints; without a cursor the object is exported with bulk first
+func (s *Salesforce) incrementalSync(ctx context.Context, pool *protocol.WriterPool, stream protocol.Stream) (err error) {
+	start, err := s.replicationStart(stream)
+	if err != nil {
+		return err
+	}
+	if start.IsZero() {
+		logger.Infof("No cursor found for stream [%s]; running bulk export first", stream.ID())
+		exportStart, err := s.bulkExport(ctx, pool, stream)
+		if err != nil {
+			return err
+		}
+		s.State.SetCursor(stream.Self(), latestDateCoveredKey, exportStart.Format(time.RFC3339))
+		return nil
+	}
+
+	end := time.Now().UTC()
+	if end.Sub(start) < time.Minute {
+		logger.Infof("Skipping stream [%s]; last sync was less than a minute ago", stream.ID())
+		return nil
+	}
+	if end.Sub(start) > replicationWindow {
+		return fmt.Errorf("cursor %s of stream [%s] is older than salesforce replication window of 30 days; run a full refresh", start, stream.ID())
+	}
+
+	window := url.Values{"start": {start.Format(time.RFC3339)}, "end": {end.Format(time.RFC3339)}}
+	var updated struct {
+		IDs               []string `json:"ids"`
+		LatestDateCovered string   `json:"latestDateCovered"`
+	}
+	if err := s.client.JSON(ctx, http.MethodGet, s.client.dataPath(fmt.Sprintf("/sobjects/%s/updated/?%s", stream.Name(), window.Encode())), nil, &updated); err != nil {
+		return fmt.Errorf("failed to fetch updated ids: %s", err)
+	}
+	var deleted struct {
+		DeletedRecords []struct {
+			ID          string `json:"id"`
+			DeletedDate string `json:"deletedDate"`
+		} `json:"deletedRecords"`
+	}
+	if err := s.client.JSON(ctx, http.MethodGet, s.client.dataPath(fmt.Sprintf("/sobjects/%s/deleted/?%s", stream.Name(), window.Encode())), nil, &deleted); err != nil {
+		return fmt.Errorf("failed to fetch deleted ids: %s", err)
+	}
+	logger.Infof("Stream [%s] has %d updated and %d deleted records since %s", stream.ID(), len(updated.IDs), len(deleted.DeletedRecords), start)
+	pool.AddRecordsToSync(stream, int64(len(updated.IDs)+len(deleted.DeletedRecords)))
+
+	waitChannel := make(chan error, 1)
+	insert, err := pool.NewThread(ctx, stream, protocol.WithErrorChannel(waitChannel))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		insert.Close()
+		if err == nil {
+			// wait for writer completion
+			err = <-waitChannel
+		}
+		if err == nil {
+			s.State.SetCursor(stream.Self(), latestDateCoveredKey, updated.LatestDateCovered)
+		}
+	}()
+
+	if err := s.retrieveRecords(ctx, stream, updated.IDs, func(record map[string]any) error {
+		return insert.Insert(types.CreateRawRecord(utils.GetKeysHash(record, primaryKey), record, 0))
+	}); err != nil {
+		return err
+	}
+
+	for _, deletedRecord := range deleted.DeletedRecords {
+		deletedAt, err := parseTimestamp(deletedRecord.DeletedDate)
+		if err != nil {
+			return fmt.Errorf("invalid deletedDate[%s]: %s", deletedRecord.DeletedDate, err)
+		}
+		record := map[string]any{primaryKey: deletedRecord.ID}
+		if err := insert.Insert(types.CreateRawRecord(utils.GetKeysHash(record, primaryKey), record, deletedAt.UnixMilli())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replicationStart returns the cursor of the stream, start_date or zero time
+func (s *Salesforce) replicationStart(stream protocol.Stream) (time.Time, error) {
+	cursor := s.State.GetCursor(stream.Self(), latestDateCoveredKey)
+	if cursor == nil {
+		if s.config.StartDate == "" {
+			return time.Time{}, nil
+		}
+		cursor = s.config.StartDate
+	}
+	start, err := parseTimestamp(fmt.Sprint(cursor))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s cursor in state: %s", latestDateCoveredKey, err)
+	}
+	return start, nil
+}
+
+// retrieveRecords fetches full records by id with the sobject collections api
+func (s *Salesforce) retrieveRecords(ctx context.Context, stream protocol.Stream, ids []string, emit func(map[string]any) error) error {
+	fields, err := s.getFields(stream)
+	if err != nil {
+		return err
+	}
+	fieldNames := make([]string, 0, len(fields))
+	dataTypes := make(map[string]types.DataType, len(fields))
+	for _, field := range fields {
+		fieldNames = append(fieldNames, field.Name)
+		dataTypes[field.Name] = salesforceTypeToDataType(field.Type)
+	}
+
+	for start := 0; start < len(ids); start += retrieveBatchSize {
+		batch := ids[start:min(start+retrieveBatchSize, len(ids))]
+		var records []map[string]any
+		err := s.client.JSON(ctx, http.MethodPost, s.client.dataPath("/composite/sobjects/"+stream.Name()), map[string]any{
+			"ids":    batch,
+			"fields": fieldNames,
+		}, &records)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve records: %s", err)
+		}
+
+		for _, record := range records {
+			// records deleted after listing are returned as null
+			if record == nil {
+				continue
+			}
+			delete(record, "attributes")
+			for key, value := range record {
+				record[key] = normalizeJSONValue(dataTypes[key], value)
+			}
+			if err := emit(record); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
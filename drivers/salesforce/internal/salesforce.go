@@ -0,0 +1,206 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/datazip-inc/olake/drivers"
+	"github.com/datazip-inc/olake/drivers/base"
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/oauth"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+)
+
+const (
+	discoverTime        = 10 * time.Minute // maximum time allowed to discover all the streams
+	defaultBackoffCount = 3
+	namespace           = "salesforce"
+	primaryKey          = "Id"
+	cursorField         = "SystemModstamp"
+)
+
+type Salesforce struct {
+	*base.Driver
+	config  *Config
+	client  *Client
+	objects sync.Map // stream id to []Field; populated in discover
+	// state the refresh token is persisted in when salesforce rotates it
+	credentials oauth.CredentialStore
+}
+
+func init() {
+	drivers.Register("salesforce", func() protocol.Driver {
+		return &Salesforce{Driver: base.NewBase()}
+	})
+}
+
+// config reference; must be pointer
+func (s *Salesforce) GetConfigRef() protocol.Config {
+	s.config = &Config{}
+	return s.config
+}
+
+func (s *Salesforce) Spec() any {
+	return Config{}
+}
+
+func (s *Salesforce) Setup() error {
+	if err := s.config.Validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
+
+	// check for default backoff count
+	if s.config.RetryCount < 0 {
+		logger.Infof("setting backoff retry count to default value %d", defaultBackoffCount)
+		s.config.RetryCount = defaultBackoffCount
+	} else {
+		// add 1 for first run
+		s.config.RetryCount += 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+	client, err := NewClient(s.config, s.credentials)
+	if err != nil {
+		return err
+	}
+	s.client = client
+	return s.client.Authenticate(ctx)
+}
+
+func (s *Salesforce) Check() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	return s.client.JSON(ctx, http.MethodGet, s.client.dataPath("/limits"), nil, nil)
+}
+
+func (s *Salesforce) OAuthConfig() (*oauth.Config, error) {
+	// the authorize command runs before there is a refresh token, so the config isn't validated
+	if err := s.config.Network.Validate(); err != nil {
+		return nil, err
+	}
+	transport, err := s.config.Network.HTTPTransport()
+	if err != nil {
+		return nil, err
+	}
+	return s.config.oauthConfig(transport), nil
+}
+
+func (s *Salesforce) SetCredentialStore(store oauth.CredentialStore) {
+	s.credentials = store
+}
+
+func (s *Salesforce) SetupState(state *types.State) {
+	state.Type = types.StreamType
+	s.State = state
+}
+
+func (s *Salesforce) Type() string {
+	return "Salesforce"
+}
+
+func (s *Salesforce) Discover(_ bool) ([]*types.Stream, error) {
+	streams := s.GetStreams()
+	if len(streams) != 0 {
+		return streams, nil
+	}
+
+	logger.Infof("Starting discover for Salesforce")
+	discoverCtx, cancel := context.WithTimeout(context.Background(), discoverTime)
+	defer cancel()
+
+	var global struct {
+		Sobjects []struct {
+			Name          string `json:"name"`
+			Queryable     bool   `json:"queryable"`
+			Replicateable bool   `json:"replicateable"`
+		} `json:"sobjects"`
+	}
+	if err := s.client.JSON(discoverCtx, http.MethodGet, s.client.dataPath("/sobjects"), nil, &global); err != nil {
+		return nil, fmt.Errorf("failed to list objects: %s", err)
+	}
+
+	var objectNames []string
+	for _, object := range global.Sobjects {
+		// only replicable objects support the updated/deleted endpoints
+		if !object.Queryable || !object.Replicateable {
+			continue
+		}
+		if len(s.config.Objects) > 0 && !utils.ExistInArray(s.config.Objects, object.Name) {
+			continue
+		}
+		objectNames = append(objectNames, object.Name)
+	}
+
+	failures := &types.DiscoverError{}
+	err := utils.Concurrent(discoverCtx, objectNames, s.DiscoverConcurrency(s.config.MaxThreads), func(ctx context.Context, objectName string, _ int) error {
+		stream, err := s.produceObjectSchema(ctx, objectName)
+		if err != nil {
+			// the others are still sampled; failures are reported once they are
+			failures.Add(objectName, err)
+			return nil
+		}
+		stream.SyncMode = s.config.DefaultMode
+		// cache stream
+		s.AddStream(stream)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetStreams(), failures.Err()
+}
+
+func (s *Salesforce) Read(ctx context.Context, pool *protocol.WriterPool, stream protocol.Stream) error {
+	switch stream.GetSyncMode() {
+	case types.FULLREFRESH:
+		return s.bulkSync(ctx, pool, stream)
+	case types.INCREMENTAL:
+		return s.incrementalSync(ctx, pool, stream)
+	}
+
+	return nil
+}
+
+func (s *Salesforce) produceObjectSchema(ctx context.Context, objectName string) (*types.Stream, error) {
+	logger.Infof("producing type schema for object [%s]", objectName)
+
+	var describe struct {
+		Fields []Field `json:"fields"`
+	}
+	if err := s.client.JSON(ctx, http.MethodGet, s.client.dataPath("/sobjects/"+url.PathEscape(objectName)+"/describe"), nil, &describe); err != nil {
+		return nil, err
+	}
+
+	stream := types.NewStream(objectName, namespace).WithSyncMode(types.FULLREFRESH).WithPrimaryKey(primaryKey)
+	var fields []Field
+	for _, field := range describe.Fields {
+		if !bulkQueryable(field) {
+			continue
+		}
+		fields = append(fields, field)
+		stream.UpsertField(field.Name, salesforceTypeToDataType(field.Type), field.Nillable)
+		if field.Name == cursorField {
+			stream.WithSyncMode(types.INCREMENTAL).WithCursorField(cursorField)
+		}
+	}
+
+	s.objects.Store(stream.ID(), fields)
+	return stream, nil
+}
+
+func (s *Salesforce) getFields(stream protocol.Stream) ([]Field, error) {
+	fields, found := s.objects.Load(stream.ID())
+	if !found {
+		return nil, fmt.Errorf("object for stream[%s] not discovered", stream.ID())
+	}
+	return fields.([]Field), nil
+}
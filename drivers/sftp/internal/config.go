@@ -0,0 +1,87 @@
+package driver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/files"
+	"github.com/datazip-inc/olake/pkg/network"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+)
+
+const (
+	ProtocolSFTP = "sftp"
+	ProtocolFTP  = "ftp"
+	ProtocolFTPS = "ftps"
+)
+
+type Config struct {
+	Protocol    string         `json:"protocol" validate:"required,oneof=sftp ftp ftps"`
+	Host        string         `json:"host" validate:"required"`
+	Port        int            `json:"port"`
+	Username    string         `json:"username"`
+	Password    string         `json:"password"`
+	PrivateKey  string         `json:"private_key"` // PEM encoded key for sftp
+	Passphrase  string         `json:"private_key_passphrase"`
+	HostKey     string         `json:"host_key"` // authorized_keys formatted host key for sftp; unverified when empty
+	Streams     []StreamConfig `json:"streams" validate:"required,min=1,dive"`
+	SampleSize  int            `json:"sample_size"`
+	MaxThreads  int            `json:"max_threads"`
+	DefaultMode types.SyncMode `json:"default_mode"`
+	RetryCount  int            `json:"backoff_retry_count"`
+	// proxy to reach the server through; tls settings apply to ftps
+	Network *network.Config `json:"network,omitempty"`
+}
+
+// StreamConfig maps all remote files matching the glob Path into stream Name
+type StreamConfig struct {
+	Name string `json:"name" validate:"required"`
+	Path string `json:"path" validate:"required"`
+	files.Options
+}
+
+func (c *Config) Validate() error {
+	c.Protocol = strings.ToLower(c.Protocol)
+	if c.Port == 0 {
+		c.Port = utils.Ternary(c.Protocol == ProtocolSFTP, 22, 21).(int)
+	}
+	if err := c.Network.Validate(); err != nil {
+		return err
+	} else if c.Network != nil && c.Network.TLS != nil && c.Protocol != ProtocolFTPS {
+		return fmt.Errorf("network tls settings only apply to ftps, found protocol[%s]", c.Protocol)
+	}
+	if c.SampleSize <= 0 {
+		c.SampleSize = 1000
+	}
+	if c.MaxThreads <= 0 {
+		// set default threads
+		logger.Info("setting max threads to default[5]")
+		c.MaxThreads = 5
+	}
+	if c.DefaultMode == "" {
+		c.DefaultMode = types.FULLREFRESH
+	}
+
+	names := make(map[string]bool)
+	for idx := range c.Streams {
+		stream := &c.Streams[idx]
+		if names[stream.Name] {
+			return fmt.Errorf("duplicate stream name[%s] in config", stream.Name)
+		}
+		names[stream.Name] = true
+		if !strings.HasPrefix(stream.Path, "/") {
+			return fmt.Errorf("stream[%s]: path must be absolute, found [%s]", stream.Name, stream.Path)
+		}
+		if err := stream.Options.Validate(); err != nil {
+			return fmt.Errorf("stream[%s]: %s", stream.Name, err)
+		}
+	}
+
+	return utils.Validate(c)
+}
+
+func (c *Config) Address() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
@@ -0,0 +1,50 @@
+package driver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/pkg/files"
+)
+
+func TestCursor(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cursor := &Cursor{FilesAtCursor: map[string]bool{}}
+
+	first := files.FileInfo{Path: "/a.csv", ModTime: base}
+	sameTime := files.FileInfo{Path: "/b.csv", ModTime: base}
+	older := files.FileInfo{Path: "/c.csv", ModTime: base.Add(-time.Minute)}
+	newer := files.FileInfo{Path: "/d.csv", ModTime: base.Add(time.Minute)}
+
+	cursor.Advance(first)
+	if cursor.Pending(first) {
+		t.Fatal("synced file must not be pending")
+	}
+	if !cursor.Pending(sameTime) {
+		t.Fatal("file sharing the cursor time must be pending until synced")
+	}
+	if cursor.Pending(older) {
+		t.Fatal("file older than cursor must not be pending")
+	}
+	if !cursor.Pending(newer) {
+		t.Fatal("newer file must be pending")
+	}
+
+	cursor.Advance(newer)
+	if !cursor.ModifiedAt.Equal(newer.ModTime) || len(cursor.FilesAtCursor) != 1 {
+		t.Fatalf("cursor must move to newer file, got %v", cursor)
+	}
+}
+
+func TestWalkRoot(t *testing.T) {
+	for pattern, root := range map[string]string{
+		"/feeds/*.csv":          "/feeds/",
+		"/feeds/2024-*/**/*.gz": "/feeds/",
+		"/feeds/data.csv":       "/feeds/",
+		"/*.csv":                "/",
+	} {
+		if got := walkRoot(pattern); got != root {
+			t.Errorf("%s: expected %s, got %s", pattern, root, got)
+		}
+	}
+}
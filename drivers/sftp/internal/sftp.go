@@ -0,0 +1,218 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/datazip-inc/olake/drivers"
+	"github.com/datazip-inc/olake/drivers/base"
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/files"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+	"github.com/datazip-inc/olake/utils"
+)
+
+const (
+	discoverTime        = 5 * time.Minute // maximum time allowed to discover all the streams
+	defaultBackoffCount = 3
+	// modification time of the file a record was read from; cursor of incremental streams
+	ModifiedAtColumn = "_modified_at"
+)
+
+type remoteSource interface {
+	files.Source
+	Close() error
+}
+
+type SFTP struct {
+	*base.Driver
+	config *Config
+	source remoteSource
+}
+
+func init() {
+	drivers.Register("sftp", func() protocol.Driver {
+		return &SFTP{Driver: base.NewBase()}
+	})
+}
+
+// config reference; must be pointer
+func (s *SFTP) GetConfigRef() protocol.Config {
+	s.config = &Config{}
+	return s.config
+}
+
+func (s *SFTP) Spec() any {
+	return Config{}
+}
+
+func (s *SFTP) Setup() error {
+	if err := s.config.Validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
+
+	var err error
+	switch s.config.Protocol {
+	case ProtocolSFTP:
+		s.source, err = newSFTPSource(s.config)
+	default:
+		s.source, err = newFTPSource(s.config)
+	}
+	if err != nil {
+		return err
+	}
+
+	// check for default backoff count
+	if s.config.RetryCount < 0 {
+		logger.Infof("setting backoff retry count to default value %d", defaultBackoffCount)
+		s.config.RetryCount = defaultBackoffCount
+	} else {
+		// add 1 for first run
+		s.config.RetryCount += 1
+	}
+	return nil
+}
+
+// Check makes sure every configured path can be listed
+func (s *SFTP) Check() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	for _, streamConfig := range s.config.Streams {
+		if _, err := s.listFiles(ctx, streamConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SFTP) SetupState(state *types.State) {
+	state.Type = types.StreamType
+	s.State = state
+}
+
+func (s *SFTP) Close() error {
+	if s.source == nil {
+		return nil
+	}
+	if err := s.source.Close(); err != nil {
+		return err
+	}
+	return s.config.Network.Close()
+}
+
+func (s *SFTP) Type() string {
+	return "SFTP"
+}
+
+func (s *SFTP) Discover(_ bool) ([]*types.Stream, error) {
+	streams := s.GetStreams()
+	if len(streams) != 0 {
+		return streams, nil
+	}
+
+	logger.Infof("Starting discover for %d %s streams", len(s.config.Streams), s.config.Protocol)
+	discoverCtx, cancel := context.WithTimeout(context.Background(), discoverTime)
+	defer cancel()
+
+	failures := &types.DiscoverError{}
+	err := utils.Concurrent(discoverCtx, s.config.Streams, s.DiscoverConcurrency(s.config.MaxThreads), func(ctx context.Context, streamConfig StreamConfig, _ int) error {
+		stream, err := s.produceStreamSchema(ctx, streamConfig)
+		if err != nil {
+			// the others are still sampled; failures are reported once they are
+			failures.Add(streamConfig.Name, err)
+			return nil
+		}
+		stream.SyncMode = s.config.DefaultMode
+		// cache stream
+		s.AddStream(stream)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetStreams(), failures.Err()
+}
+
+func (s *SFTP) Read(ctx context.Context, pool *protocol.WriterPool, stream protocol.Stream) error {
+	switch stream.GetSyncMode() {
+	case types.FULLREFRESH, types.INCREMENTAL:
+		return s.readFiles(ctx, pool, stream)
+	}
+
+	return nil
+}
+
+func (s *SFTP) produceStreamSchema(ctx context.Context, streamConfig StreamConfig) (*types.Stream, error) {
+	logger.Infof("producing type schema for stream [%s] from [%s]", streamConfig.Name, streamConfig.Path)
+
+	found, err := s.listFiles(ctx, streamConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := types.NewStream(streamConfig.Name, s.config.Host).
+		WithSyncMode(types.FULLREFRESH, types.INCREMENTAL).
+		WithPrimaryKey(files.FileColumn, files.RowColumn).
+		WithCursorField(ModifiedAtColumn)
+	stream.UpsertField(ModifiedAtColumn, types.Timestamp, false)
+
+	var objects []map[string]any
+	for _, file := range found {
+		if len(objects) >= s.config.SampleSize {
+			break
+		}
+		err := s.readFile(ctx, streamConfig, file, func(record map[string]any) (bool, error) {
+			objects = append(objects, record)
+			return len(objects) < s.config.SampleSize, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return stream, typeutils.Resolve(stream, objects...)
+}
+
+// listFiles returns files matching the stream path ordered by modification time
+func (s *SFTP) listFiles(ctx context.Context, streamConfig StreamConfig) ([]files.FileInfo, error) {
+	found, err := s.source.List(ctx, streamConfig.Path)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].ModTime.Equal(found[j].ModTime) {
+			return found[i].Path < found[j].Path
+		}
+		return found[i].ModTime.Before(found[j].ModTime)
+	})
+	return found, nil
+}
+
+func (s *SFTP) readFile(ctx context.Context, streamConfig StreamConfig, file files.FileInfo, emit files.EmitFunc) error {
+	reader, err := s.source.Open(ctx, file.Path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	return files.Read(reader, file.Path, streamConfig.Options, func(record map[string]any) (bool, error) {
+		record[ModifiedAtColumn] = file.ModTime.UTC()
+		return emit(record)
+	})
+}
+
+// returns the configured stream for the stream name
+func (s *SFTP) streamConfig(name string) (StreamConfig, error) {
+	for _, streamConfig := range s.config.Streams {
+		if streamConfig.Name == name {
+			return streamConfig, nil
+		}
+	}
+	return StreamConfig{}, fmt.Errorf("stream[%s] not present in config", name)
+}
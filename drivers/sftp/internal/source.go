@@ -0,0 +1,205 @@
+package driver
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/files"
+	"github.com/jlaffaye/ftp"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+const dialTimeout = 30 * time.Second
+
+// walkRoot is the directory listing has to start from to match pattern
+func walkRoot(pattern string) string {
+	prefix := files.GlobPrefix(pattern)
+	for len(prefix) > 1 && prefix[len(prefix)-1] != '/' {
+		prefix = prefix[:len(prefix)-1]
+	}
+	return prefix
+}
+
+// dial connects to address directly or through the configured proxy
+func (c *Config) dial(networkType, address string) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+	return c.Network.DialContext(ctx, networkType, address)
+}
+
+type sftpSource struct {
+	ssh    *ssh.Client
+	client *sftp.Client
+}
+
+func newSFTPSource(config *Config) (*sftpSource, error) {
+	sshConfig := &ssh.ClientConfig{
+		User:    config.Username,
+		Timeout: dialTimeout,
+	}
+	if config.PrivateKey != "" {
+		var signer ssh.Signer
+		var err error
+		if config.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(config.PrivateKey), []byte(config.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(config.PrivateKey))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %s", err)
+		}
+		sshConfig.Auth = append(sshConfig.Auth, ssh.PublicKeys(signer))
+	}
+	if config.Password != "" {
+		sshConfig.Auth = append(sshConfig.Auth, ssh.Password(config.Password))
+	}
+	if config.HostKey != "" {
+		hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(config.HostKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse host key: %s", err)
+		}
+		sshConfig.HostKeyCallback = ssh.FixedHostKey(hostKey)
+	} else {
+		logger.Warn("host_key not set; sftp server identity will not be verified")
+		sshConfig.HostKeyCallback = ssh.InsecureIgnoreHostKey() // #nosec G106
+	}
+
+	conn, err := config.dial("tcp", config.Address())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect [%s]: %s", config.Address(), err)
+	}
+	sshConn, channels, requests, err := ssh.NewClientConn(conn, config.Address(), sshConfig)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect [%s]: %s", config.Address(), err)
+	}
+	sshClient := ssh.NewClient(sshConn, channels, requests)
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		return nil, fmt.Errorf("failed to start sftp session: %s", err)
+	}
+	return &sftpSource{ssh: sshClient, client: client}, nil
+}
+
+func (s *sftpSource) List(_ context.Context, pattern string) ([]files.FileInfo, error) {
+	var found []files.FileInfo
+	walker := s.client.Walk(walkRoot(pattern))
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return nil, fmt.Errorf("failed to list [%s]: %s", walker.Path(), err)
+		}
+		info := walker.Stat()
+		if info.IsDir() || !files.Match(pattern, walker.Path()) {
+			continue
+		}
+		found = append(found, files.FileInfo{Path: walker.Path(), Size: info.Size(), ModTime: info.ModTime()})
+	}
+	return found, nil
+}
+
+func (s *sftpSource) Open(_ context.Context, file string) (io.ReadCloser, error) {
+	return s.client.Open(file)
+}
+
+func (s *sftpSource) Close() error {
+	s.client.Close()
+	return s.ssh.Close()
+}
+
+// ftpSource dials a dedicated connection for every transfer; an ftp control
+// connection handles one data transfer at a time
+type ftpSource struct {
+	config *Config
+	conn   *ftp.ServerConn // used for listing
+}
+
+func newFTPSource(config *Config) (*ftpSource, error) {
+	source := &ftpSource{config: config}
+	conn, err := source.dial()
+	if err != nil {
+		return nil, err
+	}
+	source.conn = conn
+	return source, nil
+}
+
+func (f *ftpSource) dial() (*ftp.ServerConn, error) {
+	// data connections are dialed the same way, so passive transfers also go through the proxy
+	options := []ftp.DialOption{ftp.DialWithTimeout(dialTimeout), ftp.DialWithDialFunc(f.config.dial)}
+	if f.config.Protocol == ProtocolFTPS {
+		tlsConfig, err := f.config.Network.TLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = f.config.Host
+		}
+		options = append(options, ftp.DialWithExplicitTLS(tlsConfig))
+	}
+	conn, err := ftp.Dial(f.config.Address(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect [%s]: %s", f.config.Address(), err)
+	}
+	if err := conn.Login(f.config.Username, f.config.Password); err != nil {
+		_ = conn.Quit()
+		return nil, fmt.Errorf("failed to login: %s", err)
+	}
+	return conn, nil
+}
+
+func (f *ftpSource) List(_ context.Context, pattern string) ([]files.FileInfo, error) {
+	var found []files.FileInfo
+	walker := f.conn.Walk(walkRoot(pattern))
+	for walker.Next() {
+		if err := walker.Err(); err != nil {
+			return nil, fmt.Errorf("failed to list [%s]: %s", walker.Path(), err)
+		}
+		entry := walker.Stat()
+		if entry.Type != ftp.EntryTypeFile || !files.Match(pattern, walker.Path()) {
+			continue
+		}
+		found = append(found, files.FileInfo{Path: walker.Path(), Size: int64(entry.Size), ModTime: entry.Time})
+	}
+	return found, walker.Err()
+}
+
+func (f *ftpSource) Open(_ context.Context, file string) (io.ReadCloser, error) {
+	conn, err := f.dial()
+	if err != nil {
+		return nil, err
+	}
+	response, err := conn.Retr(file)
+	if err != nil {
+		_ = conn.Quit()
+		return nil, fmt.Errorf("failed to retrieve [%s]: %s", file, err)
+	}
+	return &ftpReader{Response: response, conn: conn}, nil
+}
+
+func (f *ftpSource) Close() error {
+	return f.conn.Quit()
+}
+
+// ftpReader closes the transfer connection along with the response
+type ftpReader struct {
+	*ftp.Response
+	conn *ftp.ServerConn
+}
+
+func (r *ftpReader) Close() error {
+	err := r.Response.Close()
+	if quitErr := r.conn.Quit(); err == nil {
+		err = quitErr
+	}
+	return err
+}
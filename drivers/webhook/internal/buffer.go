@@ -0,0 +1,114 @@
+package driver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/goccy/go-json"
+)
+
+// Entry is one received webhook stored in the buffer
+type Entry struct {
+	ID         string            `json:"id"`
+	ReceivedAt int64             `json:"received_at"` // unix milliseconds
+	Headers    map[string]string `json:"headers"`
+	Body       json.RawMessage   `json:"body,omitempty"`
+	RawBody    string            `json:"raw_body,omitempty"` // body when it isn't json
+}
+
+// Buffer is an append only file of entries; a request is acknowledged only after its
+// entry is synced to disk, so nothing acknowledged is lost if the process dies before
+// the entry reaches the destination
+type Buffer struct {
+	mutex sync.Mutex
+	path  string
+	file  *os.File
+}
+
+func OpenBuffer(dir, name string) (*Buffer, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create buffer dir: %s", err)
+	}
+	path := filepath.Join(dir, name+".jsonl")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open buffer[%s]: %s", path, err)
+	}
+	return &Buffer{path: path, file: file}, nil
+}
+
+// Append durably stores an entry
+func (b *Buffer) Append(entry *Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, err := b.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return b.file.Sync()
+}
+
+// ReadFrom returns complete entries stored after offset and the offset following them
+func (b *Buffer) ReadFrom(offset int64) ([]*Entry, int64, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	reader := bufio.NewReader(io.NewSectionReader(b.file, offset, 1<<62))
+	var entries []*Entry
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// partial line of an interrupted write is left for a later read
+			return entries, offset, nil
+		} else if err != nil {
+			return nil, offset, err
+		}
+
+		entry := &Entry{}
+		if err := json.Unmarshal(line, entry); err != nil {
+			return nil, offset, fmt.Errorf("corrupt entry at offset %d of buffer[%s]: %s", offset, b.path, err)
+		}
+		entries = append(entries, entry)
+		offset += int64(len(line))
+	}
+}
+
+// Size returns the current size of the buffer file
+func (b *Buffer) Size() (int64, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	info, err := b.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// Compact truncates the buffer when every entry up to offset is consumed; returns
+// true when it did and the consumer has to restart from offset 0
+func (b *Buffer) Compact(offset int64) (bool, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	info, err := b.file.Stat()
+	if err != nil {
+		return false, err
+	}
+	if offset == 0 || info.Size() != offset {
+		return false, nil
+	}
+	return true, b.file.Truncate(0)
+}
+
+func (b *Buffer) Close() error {
+	return b.file.Close()
+}
@@ -0,0 +1,94 @@
+package driver
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/datazip-inc/olake/utils"
+	"github.com/spf13/viper"
+)
+
+const (
+	SchemeHMACSHA256 = "hmac-sha256"
+	SchemeGitHub     = "github"
+	SchemeStripe     = "stripe"
+	SchemeNone       = "none"
+)
+
+type Config struct {
+	ListenAddress string           `json:"listen_address"`
+	Endpoints     []EndpointConfig `json:"endpoints" validate:"required,min=1,dive"`
+	BufferDir     string           `json:"buffer_dir"`    // durable buffer location; defaults to <config folder>/webhook_buffer
+	MaxBodySize   int64            `json:"max_body_size"` // bytes
+	// seconds to keep listening before finishing the sync; listens until interrupted when 0
+	RunDuration int `json:"run_duration"`
+	// seconds between flushes of received webhooks to the destination; buffer offsets are
+	// committed to state after every flush
+	FlushInterval int `json:"flush_interval"`
+}
+
+// EndpointConfig receives webhooks on Path into stream Name
+type EndpointConfig struct {
+	Name string `json:"name" validate:"required"`
+	Path string `json:"path" validate:"required"`
+	// signature scheme: hmac-sha256, github, stripe or none
+	Scheme string `json:"scheme"`
+	Secret string `json:"secret"`
+	// header carrying the hex encoded signature for hmac-sha256
+	SignatureHeader string `json:"signature_header"`
+	// maximum age in seconds of stripe signature timestamps
+	Tolerance int `json:"tolerance"`
+}
+
+func (c *Config) Validate() error {
+	if c.ListenAddress == "" {
+		c.ListenAddress = ":8090"
+	}
+	if c.BufferDir == "" {
+		c.BufferDir = filepath.Join(viper.GetString("CONFIG_FOLDER"), "webhook_buffer")
+	}
+	if c.MaxBodySize <= 0 {
+		c.MaxBodySize = 5 * 1024 * 1024
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = 60
+	}
+
+	names := make(map[string]bool)
+	paths := make(map[string]bool)
+	for idx := range c.Endpoints {
+		endpoint := &c.Endpoints[idx]
+		if names[endpoint.Name] || paths[endpoint.Path] {
+			return fmt.Errorf("duplicate endpoint[%s] on path[%s] in config", endpoint.Name, endpoint.Path)
+		}
+		names[endpoint.Name] = true
+		paths[endpoint.Path] = true
+
+		if !strings.HasPrefix(endpoint.Path, "/") {
+			endpoint.Path = "/" + endpoint.Path
+		}
+		if endpoint.Scheme == "" {
+			endpoint.Scheme = SchemeHMACSHA256
+		}
+		switch endpoint.Scheme {
+		case SchemeHMACSHA256:
+			if endpoint.SignatureHeader == "" {
+				endpoint.SignatureHeader = "X-Signature"
+			}
+		case SchemeGitHub, SchemeStripe:
+		case SchemeNone:
+			continue
+		default:
+			return fmt.Errorf("endpoint[%s]: unsupported scheme[%s]", endpoint.Name, endpoint.Scheme)
+		}
+		if endpoint.Secret == "" {
+			return fmt.Errorf("endpoint[%s]: secret required for scheme[%s]", endpoint.Name, endpoint.Scheme)
+		}
+		if endpoint.Tolerance <= 0 {
+			endpoint.Tolerance = 300
+		}
+	}
+
+	return utils.Validate(c)
+}
@@ -0,0 +1,96 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/goccy/go-json"
+)
+
+// newServer routes every configured endpoint to its buffer
+func newServer(config *Config, buffers map[string]*Buffer) *http.Server {
+	mux := http.NewServeMux()
+	for idx := range config.Endpoints {
+		endpoint := &config.Endpoints[idx]
+		mux.Handle(endpoint.Path, &handler{endpoint: endpoint, buffer: buffers[endpoint.Name], maxBodySize: config.MaxBodySize})
+	}
+
+	return &http.Server{
+		Addr:              config.ListenAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+type handler struct {
+	endpoint    *EndpointConfig
+	buffer      *Buffer
+	maxBodySize int64
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	if err := verifySignature(h.endpoint, r.Header, body, time.Now()); err != nil {
+		logger.ThrottledWarnf("rejected:"+h.endpoint.Name, "rejected webhook on endpoint[%s] from %s: %s", h.endpoint.Name, r.RemoteAddr, err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	entry := newEntry(r.Header, body)
+	if err := h.buffer.Append(entry); err != nil {
+		// sender is expected to retry on failure
+		logger.Errorf("failed to buffer webhook on endpoint[%s]: %s", h.endpoint.Name, err)
+		http.Error(w, "failed to store payload", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprintf(w, `{"id":%q}`, entry.ID)
+}
+
+func newEntry(header http.Header, body []byte) *Entry {
+	entry := &Entry{
+		ID:         utils.ULID(),
+		ReceivedAt: time.Now().UTC().UnixMilli(),
+		Headers:    make(map[string]string, len(header)),
+	}
+	for key, values := range header {
+		// signatures are of no use downstream
+		if isSignatureHeader(key) {
+			continue
+		}
+		entry.Headers[key] = strings.Join(values, ",")
+	}
+	if json.Valid(body) {
+		entry.Body = body
+	} else {
+		entry.RawBody = string(body)
+	}
+	return entry
+}
+
+func isSignatureHeader(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "signature") || key == "authorization"
+}
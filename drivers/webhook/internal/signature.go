@@ -0,0 +1,82 @@
+package driver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// verifySignature validates the request signature of body as per the endpoint scheme
+func verifySignature(endpoint *EndpointConfig, header http.Header, body []byte, now time.Time) error {
+	switch endpoint.Scheme {
+	case SchemeNone:
+		return nil
+	case SchemeGitHub:
+		// X-Hub-Signature-256: sha256=<hex>
+		signature, found := strings.CutPrefix(header.Get("X-Hub-Signature-256"), "sha256=")
+		if !found {
+			return ErrInvalidSignature
+		}
+		return compareHexMAC(endpoint.Secret, body, signature)
+	case SchemeStripe:
+		return verifyStripeSignature(endpoint, header.Get("Stripe-Signature"), body, now)
+	default:
+		return compareHexMAC(endpoint.Secret, body, strings.TrimPrefix(header.Get(endpoint.SignatureHeader), "sha256="))
+	}
+}
+
+// verifyStripeSignature validates Stripe-Signature: t=<unix>,v1=<hex>[,v1=<hex>]
+// where the mac is computed over "<t>.<body>"
+func verifyStripeSignature(endpoint *EndpointConfig, header string, body []byte, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > time.Duration(endpoint.Tolerance)*time.Second || age < -time.Duration(endpoint.Tolerance)*time.Second {
+		return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidSignature)
+	}
+
+	signed := append([]byte(timestamp+"."), body...)
+	for _, signature := range signatures {
+		if compareHexMAC(endpoint.Secret, signed, signature) == nil {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+func compareHexMAC(secret string, payload []byte, signature string) error {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
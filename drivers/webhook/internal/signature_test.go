@@ -0,0 +1,108 @@
+package driver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func sign(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"action":"opened"}`)
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name     string
+		endpoint EndpointConfig
+		header   http.Header
+		valid    bool
+	}{
+		{
+			name:     "github",
+			endpoint: EndpointConfig{Scheme: SchemeGitHub, Secret: "s3cret"},
+			header:   http.Header{"X-Hub-Signature-256": {"sha256=" + sign("s3cret", string(body))}},
+			valid:    true,
+		},
+		{
+			name:     "github wrong secret",
+			endpoint: EndpointConfig{Scheme: SchemeGitHub, Secret: "s3cret"},
+			header:   http.Header{"X-Hub-Signature-256": {"sha256=" + sign("other", string(body))}},
+		},
+		{
+			name:     "stripe",
+			endpoint: EndpointConfig{Scheme: SchemeStripe, Secret: "whsec", Tolerance: 300},
+			header:   http.Header{"Stripe-Signature": {fmt.Sprintf("t=%d,v1=%s", now.Unix(), sign("whsec", fmt.Sprintf("%d.%s", now.Unix(), body)))}},
+			valid:    true,
+		},
+		{
+			name:     "stripe expired",
+			endpoint: EndpointConfig{Scheme: SchemeStripe, Secret: "whsec", Tolerance: 300},
+			header:   http.Header{"Stripe-Signature": {fmt.Sprintf("t=%d,v1=%s", now.Unix()-600, sign("whsec", fmt.Sprintf("%d.%s", now.Unix()-600, body)))}},
+		},
+		{
+			name:     "hmac",
+			endpoint: EndpointConfig{Scheme: SchemeHMACSHA256, Secret: "key", SignatureHeader: "X-Signature"},
+			header:   http.Header{"X-Signature": {sign("key", string(body))}},
+			valid:    true,
+		},
+		{
+			name:     "hmac missing",
+			endpoint: EndpointConfig{Scheme: SchemeHMACSHA256, Secret: "key", SignatureHeader: "X-Signature"},
+			header:   http.Header{},
+		},
+		{
+			name:     "none",
+			endpoint: EndpointConfig{Scheme: SchemeNone},
+			header:   http.Header{},
+			valid:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := verifySignature(&test.endpoint, test.header, body, now)
+			if (err == nil) != test.valid {
+				t.Fatalf("expected valid=%v, got error %v", test.valid, err)
+			}
+		})
+	}
+}
+
+func TestBufferReadFromAndCompact(t *testing.T) {
+	buffer, err := OpenBuffer(t.TempDir(), "events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buffer.Close()
+
+	for _, id := range []string{"a", "b"} {
+		if err := buffer.Append(&Entry{ID: id, Body: []byte(`{"k":1}`)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, offset, err := buffer.ReadFrom(0)
+	if err != nil || len(entries) != 2 || entries[1].ID != "b" {
+		t.Fatalf("unexpected read: %v %v", entries, err)
+	}
+	if entries, _, _ := buffer.ReadFrom(offset); len(entries) != 0 {
+		t.Fatalf("expected no entries after offset, got %d", len(entries))
+	}
+
+	compacted, err := buffer.Compact(offset)
+	if err != nil || !compacted {
+		t.Fatalf("expected compaction, got %v %v", compacted, err)
+	}
+	if size, _ := buffer.Size(); size != 0 {
+		t.Fatalf("expected empty buffer, got %d bytes", size)
+	}
+}
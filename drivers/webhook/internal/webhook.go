@@ -0,0 +1,288 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/datazip-inc/olake/drivers"
+	"github.com/datazip-inc/olake/drivers/base"
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/goccy/go-json"
+)
+
+const (
+	namespace    = "webhook"
+	primaryKey   = "id"
+	offsetCursor = "buffer_offset"
+)
+
+type Webhook struct {
+	*base.Driver
+	config *Config
+}
+
+var _ protocol.ChangeStreamDriver = (*Webhook)(nil)
+
+func init() {
+	drivers.Register("webhook", func() protocol.Driver {
+		return &Webhook{Driver: base.NewBase()}
+	})
+}
+
+// config reference; must be pointer
+func (w *Webhook) GetConfigRef() protocol.Config {
+	w.config = &Config{}
+	return w.config
+}
+
+func (w *Webhook) Spec() any {
+	return Config{}
+}
+
+func (w *Webhook) Setup() error {
+	if err := w.config.Validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
+
+	// received webhooks are only ever delivered through the change stream
+	w.CDCSupport = true
+	return nil
+}
+
+// Check verifies that the listen address can be bound and the buffer dir is writable
+func (w *Webhook) Check() error {
+	listener, err := net.Listen("tcp", w.config.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %s", w.config.ListenAddress, err)
+	}
+	_ = listener.Close()
+
+	if err := os.MkdirAll(w.config.BufferDir, 0o750); err != nil {
+		return fmt.Errorf("failed to create buffer dir: %s", err)
+	}
+	probe, err := os.CreateTemp(w.config.BufferDir, ".check-*")
+	if err != nil {
+		return fmt.Errorf("buffer dir not writable: %s", err)
+	}
+	_ = probe.Close()
+	return os.Remove(probe.Name())
+}
+
+func (w *Webhook) SetupState(state *types.State) {
+	state.Type = types.StreamType
+	w.State = state
+}
+
+func (w *Webhook) Type() string {
+	return "Webhook"
+}
+
+func (w *Webhook) Discover(_ bool) ([]*types.Stream, error) {
+	streams := w.GetStreams()
+	if len(streams) != 0 {
+		return streams, nil
+	}
+
+	for _, endpoint := range w.config.Endpoints {
+		stream := types.NewStream(endpoint.Name, namespace).WithSyncMode(types.CDC).WithPrimaryKey(primaryKey)
+		stream.UpsertField("id", types.String, false)
+		stream.UpsertField("received_at", types.TimestampMilli, false)
+		stream.UpsertField("headers", types.Object, true)
+		stream.UpsertField("payload", types.Object, true)
+		stream.UpsertField("raw_payload", types.String, true)
+		stream.SyncMode = types.CDC
+		w.AddStream(stream)
+	}
+
+	return w.GetStreams(), nil
+}
+
+func (w *Webhook) Read(_ context.Context, _ *protocol.WriterPool, stream protocol.Stream) error {
+	return fmt.Errorf("stream[%s]: webhook streams only support sync mode %s", stream.ID(), types.CDC)
+}
+
+func (w *Webhook) SetupGlobalState(_ *types.State) error {
+	// buffer offsets are tracked per stream
+	return nil
+}
+
+func (w *Webhook) StateType() types.StateType {
+	return types.StreamType
+}
+
+// RunChangeStream listens for webhooks until interrupted (or for the configured run duration)
+// and flushes them to the writer at every flush interval
+func (w *Webhook) RunChangeStream(ctx context.Context, pool *protocol.WriterPool, streams ...protocol.Stream) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if w.config.RunDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(w.config.RunDuration)*time.Second)
+		defer cancel()
+	}
+
+	buffers := make(map[string]*Buffer)
+	defer func() {
+		for _, buffer := range buffers {
+			_ = buffer.Close()
+		}
+	}()
+	for _, endpoint := range w.config.Endpoints {
+		buffer, err := OpenBuffer(w.config.BufferDir, endpoint.Name)
+		if err != nil {
+			return err
+		}
+		buffers[endpoint.Name] = buffer
+	}
+
+	server := newServer(w.config, buffers)
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Infof("listening for webhooks on %s", w.config.ListenAddress)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	consumeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		// stop consuming if the listener fails
+		if err, failed := <-serverErr; failed {
+			logger.Errorf("webhook listener failed: %s", err)
+			cancel()
+		}
+	}()
+
+	err := utils.Concurrent(consumeCtx, streams, len(streams), func(ctx context.Context, stream protocol.Stream, _ int) error {
+		buffer, found := buffers[stream.Name()]
+		if !found {
+			return fmt.Errorf("no endpoint configured for stream[%s]", stream.ID())
+		}
+		return w.consume(ctx, pool, stream, buffer)
+	})
+
+	// stop accepting webhooks before the buffers are closed
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer shutdownCancel()
+	if shutdownErr := server.Shutdown(shutdownCtx); shutdownErr != nil {
+		logger.Warnf("failed to shutdown webhook listener gracefully: %s", shutdownErr)
+	}
+	if err != nil {
+		return err
+	}
+	if err, failed := <-serverErr; failed {
+		return fmt.Errorf("webhook listener failed: %s", err)
+	}
+	return nil
+}
+
+// consume flushes buffered entries of a stream to the writer until ctx is done
+func (w *Webhook) consume(ctx context.Context, pool *protocol.WriterPool, stream protocol.Stream, buffer *Buffer) error {
+	offset := int64(0)
+	if value, ok := w.State.GetCursor(stream.Self(), offsetCursor).(float64); ok {
+		offset = int64(value)
+	} else if value, ok := w.State.GetCursor(stream.Self(), offsetCursor).(int64); ok {
+		offset = value
+	}
+	// buffer was compacted after the offset got persisted
+	if size, err := buffer.Size(); err == nil && offset > size {
+		offset = 0
+	}
+
+	ticker := time.NewTicker(time.Duration(w.config.FlushInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		next, err := w.flush(ctx, pool, stream, buffer, offset)
+		if err != nil {
+			return err
+		}
+		offset = next
+
+		select {
+		case <-ctx.Done():
+			// pick up whatever got acknowledged before the listener shut down
+			_, err := w.flush(context.Background(), pool, stream, buffer, offset)
+			return err
+		case <-ticker.C:
+		}
+	}
+}
+
+// flush writes all entries after offset and commits the new offset to state once the
+// writer has finished with them
+func (w *Webhook) flush(ctx context.Context, pool *protocol.WriterPool, stream protocol.Stream, buffer *Buffer, offset int64) (next int64, err error) {
+	entries, next, err := buffer.ReadFrom(offset)
+	if err != nil || len(entries) == 0 {
+		return offset, err
+	}
+
+	waitChannel := make(chan error, 1)
+	insert, err := pool.NewThread(ctx, stream, protocol.WithErrorChannel(waitChannel), protocol.WithChangeStream())
+	if err != nil {
+		return offset, err
+	}
+	defer func() {
+		insert.Close()
+		if err == nil {
+			err = <-waitChannel
+		}
+		if err != nil {
+			next = offset
+			return
+		}
+
+		compacted, compactErr := buffer.Compact(next)
+		if compactErr != nil {
+			logger.Warnf("failed to compact buffer of stream[%s]: %s", stream.ID(), compactErr)
+		} else if compacted {
+			next = 0
+		}
+		w.State.SetCursor(stream.Self(), offsetCursor, next)
+	}()
+
+	for _, entry := range entries {
+		record := entryRecord(entry)
+		if err := insert.Insert(types.CreateRawRecord(utils.GetKeysHash(record, primaryKey), record, 0)); err != nil {
+			return offset, err
+		}
+	}
+	logger.Infof("flushed %d webhooks of stream[%s]", len(entries), stream.ID())
+	return next, nil
+}
+
+func entryRecord(entry *Entry) map[string]any {
+	headers := make(map[string]any, len(entry.Headers))
+	for key, value := range entry.Headers {
+		headers[key] = value
+	}
+	record := map[string]any{
+		"id":          entry.ID,
+		"received_at": time.UnixMilli(entry.ReceivedAt).UTC(),
+		"headers":     headers,
+	}
+	if len(entry.Body) > 0 {
+		var payload any
+		if err := json.Unmarshal(entry.Body, &payload); err == nil {
+			if object, ok := payload.(map[string]any); ok {
+				record["payload"] = object
+				return record
+			}
+		}
+		record["raw_payload"] = string(entry.Body)
+		return record
+	}
+	record["raw_payload"] = entry.RawBody
+	return record
+}
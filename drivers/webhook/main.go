@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/datazip-inc/olake"
+	_ "github.com/datazip-inc/olake/drivers/webhook/internal" // registering webhook driver
+)
+
+func main() {
+	olake.Run("webhook")
+}
@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// LogQueueSizeKey is the viper key of the number of messages buffered by the async writer;
+// 0 writes synchronously
+const LogQueueSizeKey = "LOG_QUEUE_SIZE"
+
+// asyncWriter moves console and file writes off the calling goroutine; info and lower
+// messages are dropped instead of blocking once the queue is full, errors never are
+type asyncWriter struct {
+	out     zerolog.LevelWriter
+	queue   chan queuedMessage
+	dropped atomic.Int64
+	done    chan struct{}
+
+	// held for reading while queueing so Close doesn't close the queue under a sender
+	state  sync.RWMutex
+	closed bool
+	// serializes writes to out between the drain goroutine and writes after Close
+	mutex sync.Mutex
+}
+
+// queuedMessage keeps the level so the console can still filter by it
+type queuedMessage struct {
+	level zerolog.Level
+	p     []byte
+}
+
+func newAsyncWriter(out zerolog.LevelWriter, size int) *asyncWriter {
+	writer := &asyncWriter{
+		out:   out,
+		queue: make(chan queuedMessage, size),
+		done:  make(chan struct{}),
+	}
+	go writer.drain()
+	return writer
+}
+
+func (a *asyncWriter) drain() {
+	defer close(a.done)
+	for message := range a.queue {
+		_, _ = a.write(message.level, message.p)
+	}
+}
+
+func (a *asyncWriter) write(level zerolog.Level, p []byte) (int, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.out.WriteLevel(level, p)
+}
+
+func (a *asyncWriter) Write(p []byte) (int, error) {
+	return a.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (a *asyncWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	// the process exits right after fatal messages, so queued messages go first
+	if level >= zerolog.FatalLevel && level != zerolog.NoLevel {
+		a.Close()
+	}
+
+	a.state.RLock()
+	defer a.state.RUnlock()
+	if a.closed {
+		return a.write(level, p)
+	}
+	// zerolog reuses p once Write returns
+	message := queuedMessage{level: level, p: append([]byte(nil), p...)}
+	if level == zerolog.ErrorLevel {
+		a.queue <- message
+		return len(p), nil
+	}
+	select {
+	case a.queue <- message:
+	default:
+		a.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Close writes the queued messages; later messages are written synchronously
+func (a *asyncWriter) Close() {
+	a.state.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.state.Unlock()
+	<-a.done
+}
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// slowWriter stands in for a console or disk that can't keep up with the log rate
+type slowWriter struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+	delay time.Duration
+}
+
+func (s *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(s.delay)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *slowWriter) String() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.buf.String()
+}
+
+func TestAsyncWriterDropsInfoKeepsErrors(t *testing.T) {
+	out := &slowWriter{delay: time.Millisecond}
+	writer := newAsyncWriter(zerolog.MultiLevelWriter(out), 2)
+	log := zerolog.New(writer)
+
+	for i := 0; i < 50; i++ {
+		log.Info().Int("i", i).Msg("row")
+	}
+	log.Error().Msg("failed")
+	writer.Close()
+	// written synchronously once closed
+	log.Info().Msg("after close")
+
+	if writer.dropped.Load() == 0 {
+		t.Fatal("expected info messages to be dropped once the queue is full")
+	}
+	written := out.String()
+	if !strings.Contains(written, `"failed"`) || !strings.Contains(written, `"after close"`) {
+		t.Fatalf("expected error and post close messages to be written, got %s", written)
+	}
+	if lines := strings.Count(written, "\n"); int64(lines)+writer.dropped.Load() != 52 {
+		t.Fatalf("expected every message written or counted as dropped, got %d written, %d dropped", lines, writer.dropped.Load())
+	}
+}
+
+func BenchmarkSyncLogWriter(b *testing.B) {
+	log := zerolog.New(&slowWriter{delay: 10 * time.Microsecond})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Info().Int("record", i).Msg("synced record")
+	}
+}
+
+func BenchmarkAsyncLogWriter(b *testing.B) {
+	writer := newAsyncWriter(zerolog.MultiLevelWriter(&slowWriter{delay: 10 * time.Microsecond}), 10000)
+	log := zerolog.New(writer)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		log.Info().Int("record", i).Msg("synced record")
+	}
+	b.StopTimer()
+	writer.Close()
+	b.ReportMetric(float64(writer.dropped.Load())/float64(b.N), "dropped/op")
+}
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// consoleOutput formats console messages; interactive output redirects it to draw messages
+// above its display
+var consoleOutput = &console{out: os.Stdout, level: zerolog.DebugLevel}
+
+type console struct {
+	mutex  sync.Mutex
+	out    io.Writer
+	level  zerolog.Level
+	format zerolog.ConsoleWriter
+}
+
+// consoleTarget receives the formatted messages of a console
+type consoleTarget struct {
+	console *console
+}
+
+func (t consoleTarget) Write(p []byte) (int, error) {
+	// called by format while the console mutex is held
+	return t.console.out.Write(p)
+}
+
+func (c *console) Write(p []byte) (int, error) {
+	return c.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (c *console) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if level < c.level && level != zerolog.NoLevel {
+		return len(p), nil
+	}
+	return c.format.Write(p)
+}
+
+// RedirectConsole sends console messages of at least level to out until restore is called;
+// the log file still receives every message
+func RedirectConsole(out io.Writer, level zerolog.Level) (restore func()) {
+	consoleOutput.mutex.Lock()
+	defer consoleOutput.mutex.Unlock()
+	previousOut, previousLevel := consoleOutput.out, consoleOutput.level
+	consoleOutput.out, consoleOutput.level = out, level
+	return func() {
+		consoleOutput.mutex.Lock()
+		defer consoleOutput.mutex.Unlock()
+		consoleOutput.out, consoleOutput.level = previousOut, previousLevel
+	}
+}
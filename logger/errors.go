@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+
+	"github.com/rs/zerolog"
+)
+
+// maximum frames captured by WrapErr and ErrorWithStack
+const stackDepth = 32
+
+// ContextError carries the stream, driver or other context an error occurred in and the
+// stack it was first wrapped at; Error returns the message of the wrapped error unchanged
+type ContextError struct {
+	err    error
+	fields []interface{}
+	stack  []string
+}
+
+func (e *ContextError) Error() string {
+	return e.err.Error()
+}
+
+func (e *ContextError) Unwrap() error {
+	return e.err
+}
+
+// Fields returns the context as key value pairs, outermost wrap first
+func (e *ContextError) Fields() []interface{} {
+	return e.fields
+}
+
+// Stack returns the frames the error was first wrapped at
+func (e *ContextError) Stack() []string {
+	return e.stack
+}
+
+// WrapErr attaches key value pairs, e.g. "stream", stream.ID(), to err; the stack is captured
+// once by the innermost wrap
+func WrapErr(err error, fields ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	wrapped := &ContextError{err: err, fields: fields}
+	var inner *ContextError
+	if errors.As(err, &inner) {
+		wrapped.fields = append(append([]interface{}{}, fields...), inner.fields...)
+		wrapped.stack = inner.stack
+	} else {
+		wrapped.stack = callers(3)
+	}
+	return wrapped
+}
+
+func callers(skip int) []string {
+	pcs := make([]uintptr, stackDepth)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(skip, pcs)])
+	stack := []string{}
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line))
+		if !more {
+			return stack
+		}
+	}
+}
+
+// withError populates the error field and the context and stack of a wrapped error
+func withError(event *zerolog.Event, err error) *zerolog.Event {
+	event = event.Err(err)
+	var wrapped *ContextError
+	if !errors.As(err, &wrapped) {
+		return event
+	}
+	for i := 0; i+1 < len(wrapped.fields); i += 2 {
+		event = event.Interface(fmt.Sprint(wrapped.fields[i]), wrapped.fields[i+1])
+	}
+	return event.Strs("stack", wrapped.stack)
+}
+
+// logError logs an error passed as the first value with its context; the remaining values
+// form the message
+func logError(event *zerolog.Event, v ...interface{}) {
+	if len(v) == 0 {
+		event.Send()
+		return
+	}
+	if err, ok := v[0].(error); ok && err != nil {
+		event = withError(event, err)
+		if len(v) == 1 {
+			event.Msg(err.Error())
+			return
+		}
+		event.Msg(fmt.Sprint(v[1:]...))
+		return
+	}
+	event.Msgf("%s", v...)
+}
+
+// ErrorWithStack logs err with the stack of the caller, or the stack it was wrapped at
+func ErrorWithStack(err error) {
+	var wrapped *ContextError
+	if !errors.As(err, &wrapped) {
+		err = &ContextError{err: err, stack: callers(3)}
+	}
+	withError(logger.Error(), err).Msg(err.Error())
+}
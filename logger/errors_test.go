@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestWrapErr(t *testing.T) {
+	cause := errors.New("connection reset")
+	inner := WrapErr(cause, "stream", "public.orders")
+	outer := WrapErr(fmt.Errorf("read failed: %w", inner), "driver", "postgres")
+
+	if outer.Error() != "read failed: connection reset" || !errors.Is(outer, cause) {
+		t.Fatalf("expected wrapped error to keep message and cause, got %s", outer)
+	}
+	var wrapped *ContextError
+	if !errors.As(outer, &wrapped) {
+		t.Fatal("expected a ContextError")
+	}
+	if fmt.Sprint(wrapped.Fields()) != "[driver postgres stream public.orders]" {
+		t.Fatalf("expected context of every wrap, got %v", wrapped.Fields())
+	}
+	if len(wrapped.Stack()) == 0 || !strings.Contains(wrapped.Stack()[0], "TestWrapErr") {
+		t.Fatalf("expected stack captured at the innermost wrap, got %v", wrapped.Stack())
+	}
+	if WrapErr(nil, "stream", "public.orders") != nil {
+		t.Fatal("expected nil error to stay nil")
+	}
+}
+
+func TestErrorFields(t *testing.T) {
+	var buf bytes.Buffer
+	previous := logger
+	logger = zerolog.New(&buf)
+	defer func() { logger = previous }()
+
+	Error(WrapErr(errors.New("boom"), "stream", "public.orders"), "stream failed")
+	line := buf.String()
+	for _, expected := range []string{`"error":"boom"`, `"stream":"public.orders"`, `"stack":[`, `"message":"stream failed"`} {
+		if !strings.Contains(line, expected) {
+			t.Fatalf("expected %s in %s", expected, line)
+		}
+	}
+
+	buf.Reset()
+	Error("plain message")
+	if line := buf.String(); !strings.Contains(line, `"message":"plain message"`) || strings.Contains(line, `"error":`) {
+		t.Fatalf("unexpected plain message %s", line)
+	}
+}
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// time the moving averages of speeds mostly cover
+	etaWindow = 30 * time.Second
+	// time a speed is measured for before remaining times are estimated from it
+	etaWarmup = 10 * time.Second
+)
+
+// speedAverage is an exponential moving average of records per second; weighted by time, so
+// samples count the same whatever the stats interval, and corrected for starting at zero
+type speedAverage struct {
+	started  bool
+	last     int64
+	average  float64
+	weight   float64 // of the samples in average; approaches 1
+	measured time.Duration
+}
+
+// observe adds the records counted after interval; the speed is measured from the first
+// sample with records on, as records may have been read for any part of the interval before
+func (s *speedAverage) observe(records int64, interval time.Duration) {
+	if !s.started {
+		s.started, s.last = records > 0, records
+		return
+	}
+	speed := float64(records-s.last) / interval.Seconds()
+	alpha := 1 - math.Exp(-interval.Seconds()/etaWindow.Seconds())
+	s.average = alpha*speed + (1-alpha)*s.average
+	s.weight = alpha + (1-alpha)*s.weight
+	s.measured += interval
+	s.last = records
+}
+
+// remaining estimates the time left to sync total records; negative when not determined
+func (s *speedAverage) remaining(synced, total int64) time.Duration {
+	if total <= 0 {
+		return -1
+	}
+	if synced >= total {
+		return 0
+	}
+	if s.measured < etaWarmup || s.weight == 0 || s.average <= 0 {
+		return -1
+	}
+	return time.Duration(float64(total-synced) / (s.average / s.weight) * float64(time.Second))
+}
+
+// etaEstimator estimates the remaining time of a sync and its streams from moving averages of
+// their speeds and the records drivers expect, so estimates don't swing with the bursts at the
+// start of a sync
+type etaEstimator struct {
+	sync    speedAverage
+	streams map[string]*speedAverage
+	last    time.Time
+}
+
+func newETAEstimator(start time.Time) *etaEstimator {
+	return &etaEstimator{streams: make(map[string]*speedAverage), last: start}
+}
+
+// estimate sets the remaining times of sample, taken at now. The sync needs as long as its
+// records take at its speed, or as long as its slowest stream takes at the speed of that
+// stream if longer, as streams only share threads once others are done.
+func (e *etaEstimator) estimate(sample *Stats, now time.Time) {
+	interval := now.Sub(e.last)
+	e.last = now
+	if interval <= 0 {
+		sample.Remaining = -1
+		return
+	}
+	e.sync.observe(sample.SyncedRecords, interval)
+	sample.Remaining = e.sync.remaining(sample.SyncedRecords, sample.RecordsToSync)
+	for id, stream := range sample.Streams {
+		average, found := e.streams[id]
+		if !found {
+			average = &speedAverage{}
+			e.streams[id] = average
+		}
+		average.observe(stream.SyncedRecords, interval)
+		stream.Remaining = average.remaining(stream.SyncedRecords, stream.RecordsToSync)
+		sample.Streams[id] = stream
+		if sample.Remaining >= 0 && stream.Remaining > sample.Remaining {
+			sample.Remaining = stream.Remaining
+		}
+	}
+}
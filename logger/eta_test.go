@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestETAEstimator(t *testing.T) {
+	start := time.Now()
+	estimator := newETAEstimator(start)
+	sample := func(at time.Duration, orders, users int64) Stats {
+		stats := Stats{
+			SyncedRecords: orders + users,
+			RecordsToSync: 100000 + 10000,
+			Streams: map[string]StreamStats{
+				"public.orders": {SyncedRecords: orders, RecordsToSync: 100000},
+				"public.users":  {SyncedRecords: users, RecordsToSync: 10000},
+			},
+		}
+		estimator.estimate(&stats, start.Add(at))
+		return stats
+	}
+
+	// a burst of buffered rows at the start isn't taken for the speed of the sync
+	if stats := sample(2*time.Second, 20000, 0); stats.Remaining >= 0 {
+		t.Fatalf("expected no estimate before the speed is measured, got %s", stats.Remaining)
+	}
+	orders, users := int64(20000), int64(0)
+	var stats Stats
+	for at := 4 * time.Second; at <= 20*time.Second; at += 2 * time.Second {
+		// orders at 1000 and users at 100 records per second
+		orders, users = orders+2000, users+200
+		stats = sample(at, orders, users)
+	}
+	// 62000 orders are left for 62s and 8200 users for 82s; the sync as a whole would take
+	// 64s at 1100 rps, but users keep syncing for longer
+	if remaining := stats.Streams["public.orders"].Remaining; remaining < 61*time.Second || remaining > 63*time.Second {
+		t.Fatalf("expected about 62s left of orders, got %s", remaining)
+	}
+	if remaining := stats.Streams["public.users"].Remaining; remaining < 81*time.Second || remaining > 83*time.Second {
+		t.Fatalf("expected about 82s left of users, got %s", remaining)
+	}
+	if stats.Remaining != stats.Streams["public.users"].Remaining {
+		t.Fatalf("expected the sync to take as long as users, got %s", stats.Remaining)
+	}
+
+	// a stall only slows the estimate down gradually
+	stats = sample(22*time.Second, orders, users)
+	if remaining := stats.Streams["public.orders"].Remaining; remaining < 62*time.Second || remaining > 80*time.Second {
+		t.Fatalf("expected the estimate of orders to grow a little, got %s", remaining)
+	}
+	stats = sample(24*time.Second, 100000, users)
+	if stats.Streams["public.orders"].Remaining != 0 {
+		t.Fatalf("expected nothing left of synced orders, got %s", stats.Streams["public.orders"].Remaining)
+	}
+}
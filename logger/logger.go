@@ -4,8 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
-	"net/http/httputil"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -17,7 +16,11 @@ import (
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-var logger zerolog.Logger
+var (
+	logger zerolog.Logger
+	// nil when logging synchronously
+	async *asyncWriter
+)
 
 // Info writes record into os.stdout with log level INFO
 func Info(v ...interface{}) {
@@ -43,14 +46,16 @@ func Debugf(format string, v ...interface{}) {
 	logger.Debug().Msgf(format, v...)
 }
 
-// Error writes record into os.stdout with log level ERROR
+// Error writes record into os.stdout with log level ERROR; an error passed first populates
+// the error field along with the context and stack of WrapErr
 func Error(v ...interface{}) {
-	logger.Error().Msgf("%s", v...)
+	logError(logger.Error(), v...)
 }
 
-// Fatal writes record into os.stdout with log level ERROR and exits
+// Fatal writes record into os.stdout with log level ERROR and exits; an error passed first
+// is logged as in Error
 func Fatal(v ...interface{}) {
-	logger.Fatal().Msgf("%s", v...)
+	logError(logger.Fatal(), v...)
 	os.Exit(1)
 }
 
@@ -75,22 +80,13 @@ func Warnf(format string, v ...interface{}) {
 	logger.Warn().Msgf(format, v...)
 }
 
-func LogResponse(response *http.Response) {
-	respDump, err := httputil.DumpResponse(response, true)
-	if err != nil {
-		Fatal(err)
-	}
-
-	fmt.Println(string(respDump))
-}
-
-func LogRequest(req *http.Request) {
-	requestDump, err := httputil.DumpRequest(req, true)
-	if err != nil {
-		Fatal(err)
+// DebugFields writes message with key value pairs, e.g. "status", 200, as fields
+func DebugFields(message string, fields ...interface{}) {
+	event := logger.Debug()
+	for i := 0; i+1 < len(fields); i += 2 {
+		event = event.Interface(fmt.Sprint(fields[i]), fields[i+1])
 	}
-
-	fmt.Println(string(requestDump))
+	event.Msg(message)
 }
 
 // CreateFile creates a new file or overwrites an existing one with the specified filename, path, extension,
@@ -124,10 +120,36 @@ func FileLogger(content any, fileName, fileExtension string) error {
 	return nil
 }
 
-func StatsLogger(ctx context.Context, statsFunc func() (int64, int64, int64)) {
+// Stats is a sample of the sync progress taken by StatsLogger
+type Stats struct {
+	SyncedRecords  int64
+	RunningThreads int64
+	RecordsToSync  int64
+	// bytes of the records read and written, before serialization and compression
+	ReadBytes    int64
+	WrittenBytes int64
+	// stream id to its progress
+	Streams map[string]StreamStats
+	// records per second since the start
+	Speed       float64
+	Elapsed     time.Duration
+	MemoryBytes uint64
+	// estimated time left of the sync; negative when not determined
+	Remaining time.Duration
+}
+
+// StatsEmitter receives every sample of StatsLogger, e.g. to push it to a metrics backend
+type StatsEmitter func(stats Stats)
+
+// StatsLogger samples statsFunc every stats interval into stats.json, the stats history and
+// the emitters until ctx is done
+func StatsLogger(ctx context.Context, statsFunc func() Stats, emitters ...StatsEmitter) {
 	startTime := time.Now()
 	go func() {
-		ticker := time.NewTicker(2 * time.Second)
+		eta := newETAEstimator(startTime)
+		history := newStatsHistory()
+		defer history.close()
+		ticker := time.NewTicker(statsInterval())
 		defer ticker.Stop()
 		for {
 			select {
@@ -135,43 +157,85 @@ func StatsLogger(ctx context.Context, statsFunc func() (int64, int64, int64)) {
 				Info("Monitoring stopped")
 				return
 			case <-ticker.C:
-				syncedRecords, runningThreads, recordsToSync := statsFunc()
+				sample := statsFunc()
 				memStats := new(runtime.MemStats)
 				runtime.ReadMemStats(memStats)
-				speed := float64(syncedRecords) / time.Since(startTime).Seconds()
-				timeElapsed := time.Since(startTime).Seconds()
-				remainingRecords := recordsToSync - syncedRecords
+				sample.Elapsed = time.Since(startTime)
+				sample.Speed = float64(sample.SyncedRecords) / sample.Elapsed.Seconds()
+				sample.MemoryBytes = memStats.HeapInuse
+				eta.estimate(&sample, time.Now())
 				estimatedSeconds := "Not Determined"
-				if speed > 0 && remainingRecords >= 0 {
-					estimatedSeconds = fmt.Sprintf("%.2f s", float64(remainingRecords)/speed)
+				if sample.Remaining >= 0 {
+					estimatedSeconds = fmt.Sprintf("%.2f s", sample.Remaining.Seconds())
 				}
 				stats := map[string]interface{}{
-					"Running Threads":          runningThreads,
-					"Synced Records":           syncedRecords,
+					"Schema Version":           StatsSchemaVersion,
+					"Timestamp":                time.Now().UTC(),
+					"Sync ID":                  viper.GetString("SYNC_ID"),
+					"Running Threads":          sample.RunningThreads,
+					"Synced Records":           sample.SyncedRecords,
+					"Read Bytes":               sample.ReadBytes,
+					"Written Bytes":            sample.WrittenBytes,
 					"Memory":                   fmt.Sprintf("%d mb", memStats.HeapInuse/(1024*1024)),
-					"Speed":                    fmt.Sprintf("%.2f rps", speed),
-					"Seconds Elapsed":          fmt.Sprintf("%.2f", timeElapsed),
+					"Speed":                    fmt.Sprintf("%.2f rps", sample.Speed),
+					"Seconds Elapsed":          fmt.Sprintf("%.2f", sample.Elapsed.Seconds()),
 					"Estimated Remaining Time": estimatedSeconds,
+					"Streams":                  streamsStats(sample.Streams),
+				}
+				if dropped := Dropped(); dropped > 0 {
+					stats["Dropped Log Messages"] = dropped
 				}
-				if err := FileLogger(stats, "stats", ".json"); err != nil {
+				if err := FileLogger(stats, RunFileName("stats"), ".json"); err != nil {
 					Fatalf("failed to write stats in file: %s", err)
 				}
+				if err := history.append(stats); err != nil {
+					ThrottledWarnf("stats_history", "failed to append to stats history: %s", err)
+				}
+				for _, emit := range emitters {
+					emit(sample)
+				}
 			}
 		}
 	}()
 }
 
-func Init() {
-	// Configure lumberjack for log rotation
+// RunFileName suffixes name with the sync id so concurrent runs don't overwrite each other
+func RunFileName(name string) string {
+	if syncID := viper.GetString("SYNC_ID"); syncID != "" {
+		return name + "_" + syncID
+	}
+	return name
+}
+
+// viper keys of the log file settings, bound to the --log-* flags
+const (
+	LogFileKey       = "LOG_FILE"
+	LogDisabledKey   = "LOG_FILE_DISABLED"
+	LogMaxSizeKey    = "LOG_MAX_SIZE"
+	LogMaxBackupsKey = "LOG_MAX_BACKUPS"
+	LogMaxAgeKey     = "LOG_MAX_AGE"
+	LogCompressKey   = "LOG_COMPRESS"
+)
+
+func init() {
+	viper.SetDefault(LogMaxSizeKey, 100)
+	viper.SetDefault(LogMaxBackupsKey, 5)
+	viper.SetDefault(LogMaxAgeKey, 30)
+	viper.SetDefault(LogCompressKey, true)
+	viper.SetDefault(LogQueueSizeKey, 10000)
+}
+
+// logFilePath defaults to a folder per run under CONFIG_FOLDER/logs
+func logFilePath() string {
+	if path := viper.GetString(LogFileKey); path != "" {
+		return path
+	}
 	currentTimestamp := time.Now().UTC()
 	timestamp := fmt.Sprintf("%d-%02d-%02d_%02d-%02d-%02d", currentTimestamp.Year(), currentTimestamp.Month(), currentTimestamp.Day(), currentTimestamp.Hour(), currentTimestamp.Minute(), currentTimestamp.Second())
-	rotatingFile := &lumberjack.Logger{
-		Filename:   fmt.Sprintf("%s/logs/sync_%s/olake.log", viper.GetString("CONFIG_FOLDER"), timestamp), // Log file path
-		MaxSize:    100,                                                                                   // Max size in MB before log rotation
-		MaxBackups: 5,                                                                                     // Max number of old log files to retain
-		MaxAge:     30,                                                                                    // Max age in days to retain old log files
-		Compress:   true,                                                                                  // Compress old log files
-	}
+	return fmt.Sprintf("%s/logs/%s/olake.log", viper.GetString("CONFIG_FOLDER"), RunFileName("sync_"+timestamp))
+}
+
+func Init() {
 	zerolog.TimestampFunc = func() time.Time {
 		return time.Now().UTC()
 	}
@@ -185,8 +249,8 @@ func Init() {
 		"fatal": "\033[31m", // Red
 	}
 	// Create console writer
-	console := zerolog.ConsoleWriter{
-		Out:        os.Stdout,
+	format := zerolog.ConsoleWriter{
+		Out:        consoleTarget{console: consoleOutput},
 		TimeFormat: "2006-01-02 15:04:05",
 		FormatLevel: func(i interface{}) string {
 			level := i.(string)
@@ -217,8 +281,51 @@ func Init() {
 			return fmt.Sprintf("\033[90m%s\033[0m", i)
 		},
 	}
+	consoleOutput.format = format
+	writers := []io.Writer{consoleOutput}
+	// read-only containers log to the console only
+	if !viper.GetBool(LogDisabledKey) {
+		// Configure lumberjack for log rotation
+		writers = append(writers, &lumberjack.Logger{
+			Filename:   logFilePath(),
+			MaxSize:    viper.GetInt(LogMaxSizeKey),    // Max size in MB before log rotation
+			MaxBackups: viper.GetInt(LogMaxBackupsKey), // Max number of old log files to retain
+			MaxAge:     viper.GetInt(LogMaxAgeKey),     // Max age in days to retain old log files
+			Compress:   viper.GetBool(LogCompressKey),  // Compress old log files
+		})
+	}
 	// Create a multiwriter to log both console and file
-	multiwriter := zerolog.MultiLevelWriter(console, rotatingFile)
+	multiwriter := zerolog.MultiLevelWriter(writers...)
 
-	logger = zerolog.New(multiwriter).With().Timestamp().Logger()
+	var output io.Writer = multiwriter
+	if size := viper.GetInt(LogQueueSizeKey); size > 0 {
+		async = newAsyncWriter(multiwriter, size)
+		output = async
+	}
+
+	logContext := zerolog.New(output).With().Timestamp()
+	if syncID := viper.GetString("SYNC_ID"); syncID != "" {
+		logContext = logContext.Str("sync_id", syncID)
+	}
+	logger = logContext.Logger()
+}
+
+// Close writes buffered log messages; called before the process exits
+func Close() {
+	flushThrottled()
+	if async == nil {
+		return
+	}
+	async.Close()
+	if dropped := async.dropped.Load(); dropped > 0 {
+		fmt.Fprintf(os.Stderr, "dropped %d log messages as the log queue was full\n", dropped)
+	}
+}
+
+// Dropped returns the number of log messages dropped as the log queue was full
+func Dropped() int64 {
+	if async == nil {
+		return 0
+	}
+	return async.dropped.Load()
 }
@@ -0,0 +1,107 @@
+package logger
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/spf13/viper"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// StatsSchemaVersion is written to stats.json and the stats history; bumped when fields change
+// so readers of older runs can tell the formats apart
+const StatsSchemaVersion = 5
+
+// viper keys of the stats settings, bound to the --stats-* flags
+const (
+	StatsIntervalKey        = "STATS_INTERVAL"
+	StatsHistoryDisabledKey = "STATS_HISTORY_DISABLED"
+	StatsHistoryMaxSizeKey  = "STATS_HISTORY_MAX_SIZE"
+)
+
+const (
+	defaultStatsInterval = 2 * time.Second
+	statsHistoryBackups  = 3
+)
+
+func init() {
+	viper.SetDefault(StatsIntervalKey, defaultStatsInterval)
+	viper.SetDefault(StatsHistoryMaxSizeKey, 10)
+}
+
+// StreamStats is the progress of one stream in a stats sample
+type StreamStats struct {
+	SyncedRecords int64
+	RecordsToSync int64
+	// characters replaced with U+FFFD converting text to UTF-8
+	ReplacedCharacters int64
+	// bytes of the records read and written
+	ReadBytes    int64
+	WrittenBytes int64
+	// estimated time left of the stream, set by StatsLogger; negative when not determined
+	Remaining time.Duration
+}
+
+func statsInterval() time.Duration {
+	if interval := viper.GetDuration(StatsIntervalKey); interval > 0 {
+		return interval
+	}
+	return defaultStatsInterval
+}
+
+func streamsStats(streams map[string]StreamStats) map[string]any {
+	breakdown := make(map[string]any, len(streams))
+	for stream, stats := range streams {
+		values := map[string]int64{
+			"Synced Records":      stats.SyncedRecords,
+			"Records To Sync":     stats.RecordsToSync,
+			"Replaced Characters": stats.ReplacedCharacters,
+			"Read Bytes":          stats.ReadBytes,
+			"Written Bytes":       stats.WrittenBytes,
+		}
+		if stats.Remaining >= 0 {
+			values["Estimated Remaining Seconds"] = int64(stats.Remaining.Seconds())
+		}
+		breakdown[stream] = values
+	}
+	return breakdown
+}
+
+// statsHistory appends every stats sample as a json line to stats_history_<sync id>.jsonl
+// in the config folder; rotated by size so long syncs don't fill the disk
+type statsHistory struct {
+	file *lumberjack.Logger
+}
+
+// newStatsHistory returns nil when the history is disabled or there's no config folder
+func newStatsHistory() *statsHistory {
+	folder := viper.GetString("CONFIG_FOLDER")
+	if folder == "" || viper.GetBool(StatsHistoryDisabledKey) {
+		return nil
+	}
+	return &statsHistory{file: &lumberjack.Logger{
+		Filename:   filepath.Join(folder, RunFileName("stats_history")+".jsonl"),
+		MaxSize:    viper.GetInt(StatsHistoryMaxSizeKey),
+		MaxBackups: statsHistoryBackups,
+	}}
+}
+
+func (h *statsHistory) append(stats map[string]any) error {
+	if h == nil {
+		return nil
+	}
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("failed to marshal stats: %s", err)
+	}
+	_, err = h.file.Write(append(data, '\n'))
+	return err
+}
+
+func (h *statsHistory) close() {
+	if h != nil {
+		_ = h.file.Close()
+	}
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/spf13/viper"
+)
+
+func TestStatsHistory(t *testing.T) {
+	folder := t.TempDir()
+	viper.Set("CONFIG_FOLDER", folder)
+	viper.Set(StatsIntervalKey, 20*time.Millisecond)
+	defer viper.Set("CONFIG_FOLDER", nil)
+	defer viper.Set(StatsIntervalKey, nil)
+
+	samples := make(chan Stats, 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	StatsLogger(ctx, func() Stats {
+		return Stats{SyncedRecords: 10, RecordsToSync: 20, Streams: map[string]StreamStats{"ns.orders": {SyncedRecords: 10, RecordsToSync: 20}}}
+	}, func(stats Stats) { samples <- stats })
+	for i := 0; i < 3; i++ {
+		sample := <-samples
+		if sample.Elapsed <= 0 || sample.Speed <= 0 {
+			t.Fatalf("expected elapsed time and speed in sample, got %+v", sample)
+		}
+	}
+	cancel()
+
+	file, err := os.Open(filepath.Join(folder, "stats_history.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	lines := 0
+	for scanner := bufio.NewScanner(file); scanner.Scan(); lines++ {
+		var stats struct {
+			Version int                         `json:"Schema Version"`
+			Streams map[string]map[string]int64 `json:"Streams"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &stats); err != nil {
+			t.Fatal(err)
+		}
+		if stats.Version != StatsSchemaVersion || stats.Streams["ns.orders"]["Records To Sync"] != 20 {
+			t.Fatalf("unexpected stats %s", scanner.Bytes())
+		}
+	}
+	if lines < 3 {
+		t.Fatalf("expected a history line per sample, got %d", lines)
+	}
+}
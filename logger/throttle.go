@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+)
+
+// LogThrottleKey is the viper key of the number of messages logged per throttle key every
+// minute; 0 disables throttling
+const LogThrottleKey = "LOG_THROTTLE"
+
+const throttleInterval = time.Minute
+
+func init() {
+	viper.SetDefault(LogThrottleKey, 10)
+}
+
+// throttleWindow counts the messages of a key in the current interval
+type throttleWindow struct {
+	start      time.Time
+	logged     int
+	suppressed int
+}
+
+var throttle = struct {
+	sync.Mutex
+	windows map[string]*throttleWindow
+}{windows: make(map[string]*throttleWindow)}
+
+// allowed reports if a message of key may be logged and the number of messages suppressed
+// in the previous interval
+func allowed(key string, now time.Time) (bool, int) {
+	limit := viper.GetInt(LogThrottleKey)
+	if limit <= 0 {
+		return true, 0
+	}
+
+	throttle.Lock()
+	defer throttle.Unlock()
+	suppressed := 0
+	window, found := throttle.windows[key]
+	if !found || now.Sub(window.start) >= throttleInterval {
+		if found {
+			suppressed = window.suppressed
+		}
+		window = &throttleWindow{start: now}
+		throttle.windows[key] = window
+	}
+	if window.logged >= limit {
+		window.suppressed++
+		return false, 0
+	}
+	window.logged++
+	return true, suppressed
+}
+
+func throttled(event *zerolog.Event, key, format string, v ...interface{}) {
+	ok, suppressed := allowed(key, time.Now())
+	if !ok {
+		return
+	}
+	if suppressed > 0 {
+		event = event.Int("suppressed", suppressed)
+	}
+	event.Msgf(format, v...)
+}
+
+// ThrottledWarnf logs at most LOG_THROTTLE messages of key a minute; the number of
+// suppressed messages is attached to the next message of key
+func ThrottledWarnf(key, format string, v ...interface{}) {
+	throttled(logger.Warn(), key, format, v...)
+}
+
+// ThrottledErrorf logs at most LOG_THROTTLE messages of key a minute
+func ThrottledErrorf(key, format string, v ...interface{}) {
+	throttled(logger.Error(), key, format, v...)
+}
+
+// ThrottledDebugf logs at most LOG_THROTTLE messages of key a minute
+func ThrottledDebugf(key, format string, v ...interface{}) {
+	throttled(logger.Debug(), key, format, v...)
+}
+
+// flushThrottled reports messages suppressed since the last message of their key
+func flushThrottled() {
+	throttle.Lock()
+	defer throttle.Unlock()
+	keys := make([]string, 0, len(throttle.windows))
+	for key, window := range throttle.windows {
+		if window.suppressed > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		logger.Warn().Msgf("suppressed %d log messages of %s", throttle.windows[key].suppressed, key)
+		throttle.windows[key].suppressed = 0
+	}
+}
@@ -0,0 +1,34 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestThrottle(t *testing.T) {
+	viper.Set(LogThrottleKey, 2)
+	defer viper.Set(LogThrottleKey, nil)
+
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		if ok, _ := allowed("malformed:ns.orders", now); !ok {
+			t.Fatalf("expected message %d to be logged", i)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if ok, _ := allowed("malformed:ns.orders", now); ok {
+			t.Fatal("expected messages over the limit to be suppressed")
+		}
+	}
+	// keys are throttled independently
+	if ok, _ := allowed("malformed:ns.users", now); !ok {
+		t.Fatal("expected message of another key to be logged")
+	}
+
+	ok, suppressed := allowed("malformed:ns.orders", now.Add(throttleInterval))
+	if !ok || suppressed != 3 {
+		t.Fatalf("expected first message of the next interval with 3 suppressed, got %t, %d", ok, suppressed)
+	}
+}
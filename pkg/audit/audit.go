@@ -0,0 +1,287 @@
+// Package audit keeps an append-only trail of the changes olake commands make to catalogs,
+// configs and states: who made them, when, and what changed
+package audit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/datazip-inc/olake/pkg/encryption"
+	"github.com/datazip-inc/olake/pkg/statestore"
+	"github.com/goccy/go-json"
+	"github.com/spf13/viper"
+)
+
+type Kind string
+
+const (
+	Catalog Kind = "catalog"
+	Config  Kind = "config"
+	State   Kind = "state"
+)
+
+// FileName is the audit log kept in the config folder
+const FileName = "audit.jsonl"
+
+// UserEnv overrides the user recorded in entries, e.g. with the caller of a scheduler
+const UserEnv = "OLAKE_AUDIT_USER"
+
+// values of keys named or containing these are never written to the log
+var (
+	secretNames = []string{"key", "salt"}
+	secretKeys  = []string{"password", "secret", "token", "credential", "private_key", "access_key", "api_key", "passphrase", "key_file"}
+)
+
+// Change is a value that was added, removed or replaced; Path addresses it in the document,
+// e.g. streams[0].state.cursor
+type Change struct {
+	Path string `json:"path"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// Entry is a line of the audit log
+type Entry struct {
+	Time    time.Time `json:"time"`
+	User    string    `json:"user"`
+	Host    string    `json:"host,omitempty"`
+	Command string    `json:"command,omitempty"`
+	SyncID  string    `json:"sync_id,omitempty"`
+	Kind    Kind      `json:"kind"`
+	// file or state backend that was modified
+	Target  string   `json:"target"`
+	Action  string   `json:"action"`
+	Changes []Change `json:"changes"`
+}
+
+// Log persists audit entries; entries are only ever appended
+type Log interface {
+	Append(ctx context.Context, line []byte) error
+}
+
+var (
+	mutex sync.Mutex
+	log   Log
+)
+
+// Use sends entries to l instead of the audit log of the config folder
+func Use(l Log) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	log = l
+}
+
+// Record appends an entry of the changes between the before and after documents; nothing is
+// recorded when they are equal, or when there's neither a log set with Use nor a config folder
+// (--no-save)
+func Record(ctx context.Context, kind Kind, target, action string, before, after []byte) error {
+	// values of encrypted documents stay out of the log; only their paths are recorded
+	changes, err := changesOf(before, after, encryption.Enabled())
+	if err != nil {
+		return fmt.Errorf("failed to diff %s[%s]: %s", kind, target, err)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	sink := log
+	if sink == nil {
+		folder := viper.GetString("CONFIG_FOLDER")
+		if folder == "" {
+			return nil
+		}
+		sink = &FileLog{Path: filepath.Join(folder, FileName)}
+	}
+	entry := Entry{
+		Time:    time.Now().UTC(),
+		User:    currentUser(),
+		Command: viper.GetString("COMMAND"),
+		SyncID:  viper.GetString("SYNC_ID"),
+		Kind:    kind,
+		Target:  target,
+		Action:  action,
+		Changes: changes,
+	}
+	entry.Host, _ = os.Hostname()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := sink.Append(ctx, line); err != nil {
+		return fmt.Errorf("failed to append to audit log: %s", err)
+	}
+	return nil
+}
+
+func currentUser() string {
+	if name := os.Getenv(UserEnv); name != "" {
+		return name
+	}
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
+
+// Diff returns the changes from the json document before to after, ordered by path; either
+// may be empty. Values under secret looking keys are replaced with REDACTED.
+func Diff(before, after []byte) ([]Change, error) {
+	return changesOf(before, after, false)
+}
+
+func changesOf(before, after []byte, secret bool) ([]Change, error) {
+	old, err := decode(before)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := decode(after)
+	if err != nil {
+		return nil, err
+	}
+	changes := []Change{}
+	diff("", old, updated, secret, &changes)
+	return changes, nil
+}
+
+func decode(data []byte) (any, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// keeps large integers, e.g. cursors and offsets, exact
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+func diff(path string, old, updated any, secret bool, changes *[]Change) {
+	oldMap, oldIsMap := old.(map[string]any)
+	updatedMap, updatedIsMap := updated.(map[string]any)
+	if oldIsMap && updatedIsMap {
+		keys := make([]string, 0, len(oldMap)+len(updatedMap))
+		for key := range oldMap {
+			keys = append(keys, key)
+		}
+		for key := range updatedMap {
+			if _, found := oldMap[key]; !found {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			diff(child, oldMap[key], updatedMap[key], secret || isSecret(key), changes)
+		}
+		return
+	}
+
+	oldArray, oldIsArray := old.([]any)
+	updatedArray, updatedIsArray := updated.([]any)
+	if oldIsArray && updatedIsArray {
+		for idx := 0; idx < len(oldArray) || idx < len(updatedArray); idx++ {
+			var oldElem, updatedElem any
+			if idx < len(oldArray) {
+				oldElem = oldArray[idx]
+			}
+			if idx < len(updatedArray) {
+				updatedElem = updatedArray[idx]
+			}
+			diff(fmt.Sprintf("%s[%d]", path, idx), oldElem, updatedElem, secret, changes)
+		}
+		return
+	}
+
+	if reflect.DeepEqual(old, updated) {
+		return
+	}
+	*changes = append(*changes, Change{Path: path, Old: redact(old, secret), New: redact(updated, secret)})
+}
+
+func isSecret(key string) bool {
+	key = strings.ToLower(key)
+	for _, name := range secretNames {
+		if key == name {
+			return true
+		}
+	}
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact masks value when it is secret, and the secrets nested in added or removed objects
+func redact(value any, secret bool) any {
+	switch typed := value.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		redacted := make(map[string]any, len(typed))
+		for key, elem := range typed {
+			redacted[key] = redact(elem, secret || isSecret(key))
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(typed))
+		for idx, elem := range typed {
+			redacted[idx] = redact(elem, secret)
+		}
+		return redacted
+	}
+	if secret {
+		return "REDACTED"
+	}
+	return value
+}
+
+// FileLog appends entries as json lines to a local file
+type FileLog struct {
+	Path string
+}
+
+func (f *FileLog) Append(_ context.Context, line []byte) error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), os.ModePerm); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// StoreLog keeps entries in a state backend, beside the state of the connection
+type StoreLog struct {
+	Store statestore.Store
+}
+
+func (s *StoreLog) Append(ctx context.Context, line []byte) error {
+	data, err := s.Store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	return s.Store.Save(ctx, append(append(data, line...), '\n'))
+}
@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/spf13/viper"
+)
+
+func TestDiff(t *testing.T) {
+	before := `{"streams": [{"stream": "users", "state": {"cursor": 12345678901234567}}], "credentials": {"refresh": "old"}, "password": "a"}`
+	after := `{"streams": [{"stream": "users", "state": {"cursor": 12345678901234568}}, {"stream": "orders"}],
+		"credentials": {"refresh": "new"}, "password": "a",
+		"transforms": [{"column": "email", "type": "encrypt", "key": "c2VjcmV0"}]}`
+	changes, err := Diff([]byte(before), []byte(after))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Change{
+		{Path: "credentials.refresh", Old: "REDACTED", New: "REDACTED"},
+		{Path: "streams[0].state.cursor", Old: json.Number("12345678901234567"), New: json.Number("12345678901234568")},
+		{Path: "streams[1]", New: map[string]any{"stream": "orders"}},
+		{Path: "transforms", New: []any{map[string]any{"column": "email", "type": "encrypt", "key": "REDACTED"}}},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, changes)
+	}
+
+	if changes, err := Diff(nil, []byte(`{"a": 1}`)); err != nil || len(changes) != 1 || changes[0].Path != "" {
+		t.Fatalf("expected a created document as a single change, got %+v: %v", changes, err)
+	}
+	if _, err := Diff([]byte(`{`), nil); err == nil {
+		t.Fatal("expected invalid json to fail")
+	}
+}
+
+func TestRecord(t *testing.T) {
+	folder := t.TempDir()
+	viper.Set("CONFIG_FOLDER", folder)
+	viper.Set("COMMAND", "sync")
+	t.Setenv(UserEnv, "scheduler")
+	defer viper.Set("CONFIG_FOLDER", "")
+
+	ctx := context.Background()
+	if err := Record(ctx, State, "state.json", "sync", []byte(`{"a": 1}`), []byte(`{"a": 1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Record(ctx, State, "state.json", "sync", []byte(`{"a": 1}`), []byte(`{"a": 2}`)); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(folder, FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected only the change recorded, got %d entries", len(lines))
+	}
+	var entry Entry
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.User != "scheduler" || entry.Command != "sync" || entry.Kind != State || entry.Action != "sync" || len(entry.Changes) != 1 || entry.Changes[0].Path != "a" {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+}
@@ -0,0 +1,91 @@
+// Package batching sizes the batches writers commit to a destination by the latency of the
+// commits, additive increase and multiplicative decrease (AIMD): batches grow while commits
+// stay under the target latency and halve when a commit is slow or fails. Narrow tables end up
+// with large batches and wide tables with small ones, without tuning a batch size per stream.
+package batching
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Config bounds the batch sizes; the size the writer is configured with is the one batches
+// start at
+type Config struct {
+	// smallest batch, also the step batches grow by
+	MinSize int `json:"min_size,omitempty"`
+	// largest batch
+	MaxSize int `json:"max_size,omitempty"`
+	// commits slower than this shrink batches
+	TargetLatencyMs int `json:"target_latency_ms,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.MinSize < 0 || c.MaxSize < 0 || c.TargetLatencyMs < 0 {
+		return fmt.Errorf("adaptive batch sizes and target latency must not be negative")
+	}
+	if c.MinSize == 0 {
+		c.MinSize = 100
+	}
+	if c.MaxSize == 0 {
+		c.MaxSize = 100 * c.MinSize
+	}
+	if c.TargetLatencyMs == 0 {
+		c.TargetLatencyMs = 1000
+	}
+	if c.MinSize > c.MaxSize {
+		return fmt.Errorf("adaptive batch min_size[%d] is larger than max_size[%d]", c.MinSize, c.MaxSize)
+	}
+	return nil
+}
+
+// Sizer keeps the size of the next batch of a writer; a nil Sizer keeps the initial size
+type Sizer struct {
+	mutex  sync.Mutex
+	size   int
+	min    int
+	max    int
+	target time.Duration
+}
+
+// NewSizer returns a sizer starting at initial, clamped to the bounds of config; nil without
+// config, so batches keep the static size
+func NewSizer(config *Config, initial int) *Sizer {
+	if config == nil {
+		return nil
+	}
+	return &Sizer{
+		size:   min(max(initial, config.MinSize), config.MaxSize),
+		min:    config.MinSize,
+		max:    config.MaxSize,
+		target: time.Duration(config.TargetLatencyMs) * time.Millisecond,
+	}
+}
+
+// Size returns the size of the next batch, or fallback for a nil sizer
+func (s *Sizer) Size(fallback int) int {
+	if s == nil {
+		return fallback
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.size
+}
+
+// Observe adjusts the size after a commit that took latency; err is the error it failed with
+func (s *Sizer) Observe(latency time.Duration, err error) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err != nil || latency > s.target {
+		s.size = max(s.size/2, s.min)
+		return
+	}
+	s.size = min(s.size+s.min, s.max)
+}
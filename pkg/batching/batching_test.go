@@ -0,0 +1,63 @@
+package batching
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSizer(t *testing.T) {
+	config := &Config{MinSize: 10, MaxSize: 45, TargetLatencyMs: 100}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	sizer := NewSizer(config, 1000)
+	if size := sizer.Size(1000); size != 45 {
+		t.Fatalf("expected the initial size to be clamped to 45, got %d", size)
+	}
+
+	for _, step := range []struct {
+		latency time.Duration
+		err     error
+		size    int
+	}{
+		// slow commits halve batches down to the minimum
+		{latency: 200 * time.Millisecond, size: 22},
+		{latency: 150 * time.Millisecond, size: 11},
+		{latency: 150 * time.Millisecond, size: 10},
+		// fast ones grow them by the minimum up to the maximum
+		{latency: 50 * time.Millisecond, size: 20},
+		{latency: 50 * time.Millisecond, size: 30},
+		{latency: 50 * time.Millisecond, size: 40},
+		{latency: 50 * time.Millisecond, size: 45},
+		// failed commits count as slow
+		{latency: time.Millisecond, err: errors.New("timed out"), size: 22},
+	} {
+		sizer.Observe(step.latency, step.err)
+		if size := sizer.Size(0); size != step.size {
+			t.Fatalf("expected size %d after a commit of %s, got %d", step.size, step.latency, size)
+		}
+	}
+
+	var static *Sizer
+	static.Observe(time.Hour, nil)
+	if size := static.Size(500); size != 500 {
+		t.Fatalf("expected a nil sizer to keep the static size, got %d", size)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	config := &Config{}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if config.MinSize != 100 || config.MaxSize != 10000 || config.TargetLatencyMs != 1000 {
+		t.Fatalf("unexpected defaults %+v", config)
+	}
+	if err := (&Config{MinSize: 50, MaxSize: 10}).Validate(); err == nil {
+		t.Fatal("expected min_size above max_size to fail")
+	}
+	if err := (&Config{MinSize: -1}).Validate(); err == nil {
+		t.Fatal("expected a negative size to fail")
+	}
+}
@@ -0,0 +1,117 @@
+// Package charset decodes text of other character sets, such as latin1 tables or Windows-1252
+// files, into UTF-8 and replaces what is not valid UTF-8 so destinations never see it
+package charset
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// Lookup returns the encoding of a charset name or label, e.g. latin1, windows-1252 or
+// shift_jis; latin1 is decoded as Windows-1252 like browsers do
+func Lookup(name string) (encoding.Encoding, error) {
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported encoding[%s]: %s", name, err)
+	}
+	return enc, nil
+}
+
+// Converter converts the text values of records into valid UTF-8; decoders keep state, so a
+// converter must not be shared between goroutines
+type Converter struct {
+	// nil when text is UTF-8 already
+	decoder *encoding.Decoder
+}
+
+// New returns a converter of text in the charset name; empty for UTF-8
+func New(name string) (*Converter, error) {
+	if name == "" {
+		return &Converter{}, nil
+	}
+	enc, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Converter{decoder: enc.NewDecoder()}, nil
+}
+
+// Apply converts the string values of data in place, nested ones too, and returns the number
+// of characters replaced with U+FFFD as they were not valid in the charset
+func (c *Converter) Apply(data map[string]any) int {
+	replaced := 0
+	for key, value := range data {
+		converted, count := c.value(value)
+		if converted != nil {
+			data[key] = converted
+		}
+		replaced += count
+	}
+	return replaced
+}
+
+// value returns the converted v, nil when v is left as is or converted in place
+func (c *Converter) value(v any) (any, int) {
+	switch value := v.(type) {
+	case string:
+		converted, count := c.String(value)
+		if converted == value {
+			return nil, count
+		}
+		return converted, count
+	case map[string]any:
+		return nil, c.Apply(value)
+	case []any:
+		replaced := 0
+		for idx, element := range value {
+			converted, count := c.value(element)
+			if converted != nil {
+				value[idx] = converted
+			}
+			replaced += count
+		}
+		return nil, replaced
+	}
+	return nil, 0
+}
+
+// String converts s into valid UTF-8 and returns the number of characters replaced
+func (c *Converter) String(s string) (string, int) {
+	if c.decoder == nil {
+		return Sanitize(s)
+	}
+	// decoders replace bytes undefined in their charset themselves
+	decoded, err := c.decoder.String(s)
+	if err != nil {
+		return Sanitize(s)
+	}
+	if decoded == s {
+		return s, 0
+	}
+	return decoded, max(0, strings.Count(decoded, string(utf8.RuneError))-strings.Count(s, string(utf8.RuneError)))
+}
+
+// Sanitize replaces the invalid UTF-8 sequences of s with U+FFFD and returns their number
+func Sanitize(s string) (string, int) {
+	if utf8.ValidString(s) {
+		return s, 0
+	}
+	var builder strings.Builder
+	builder.Grow(len(s) + 2)
+	replaced := 0
+	for idx := 0; idx < len(s); {
+		char, size := utf8.DecodeRuneInString(s[idx:])
+		if char == utf8.RuneError && size == 1 {
+			builder.WriteRune(utf8.RuneError)
+			replaced++
+		} else {
+			builder.WriteString(s[idx : idx+size])
+		}
+		idx += size
+	}
+	return builder.String(), replaced
+}
@@ -0,0 +1,37 @@
+package charset
+
+import "testing"
+
+func TestConverter(t *testing.T) {
+	latin1, err := New("latin1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// café and 10€ in windows-1252, 0x81 isn't defined in it
+	record := map[string]any{
+		"name":  "caf\xe9",
+		"price": "10\x80",
+		"tags":  []any{"\x81", 3},
+		"owner": map[string]any{"city": "M\xfcnchen"},
+		"count": 3,
+	}
+	if replaced := latin1.Apply(record); replaced != 1 {
+		t.Fatalf("expected a replaced character, got %d", replaced)
+	}
+	if record["name"] != "café" || record["price"] != "10€" || record["tags"].([]any)[0] != "�" || record["owner"].(map[string]any)["city"] != "München" {
+		t.Fatalf("unexpected record %v", record)
+	}
+
+	utf8, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	record = map[string]any{"name": "caf\xe9 ok", "valid": "café"}
+	if replaced := utf8.Apply(record); replaced != 1 || record["name"] != "caf� ok" || record["valid"] != "café" {
+		t.Fatalf("unexpected record %v with %d replaced", record, replaced)
+	}
+
+	if _, err := New("klingon"); err == nil {
+		t.Fatal("expected an unknown charset to be rejected")
+	}
+}
@@ -0,0 +1,97 @@
+// Package compress provides streaming compression for file based writers
+package compress
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+type Codec string
+
+const (
+	None   Codec = "none"
+	Gzip   Codec = "gzip"
+	Zstd   Codec = "zstd"
+	Snappy Codec = "snappy"
+	LZ4    Codec = "lz4"
+)
+
+func (c Codec) Validate() error {
+	switch c {
+	case None, Gzip, Zstd, Snappy, LZ4:
+		return nil
+	default:
+		return fmt.Errorf("unsupported compression[%s]; expected one of none, gzip, zstd, snappy, lz4", c)
+	}
+}
+
+// Extension returns the file extension suffix of the codec including the dot
+func (c Codec) Extension() string {
+	switch c {
+	case Gzip:
+		return ".gz"
+	case Zstd:
+		return ".zst"
+	case Snappy:
+		return ".sz"
+	case LZ4:
+		return ".lz4"
+	default:
+		return ""
+	}
+}
+
+// NewWriter wraps w so that everything written is compressed on the fly; closing the returned
+// writer flushes the compressor but does not close w
+func NewWriter(w io.Writer, codec Codec) (io.WriteCloser, error) {
+	switch codec {
+	case None, "":
+		return nopCloser{w}, nil
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		return zstd.NewWriter(w)
+	case Snappy:
+		// framed snappy format, readable by any snappy stream decoder
+		return s2.NewWriter(w, s2.WriterSnappyCompat()), nil
+	case LZ4:
+		return lz4.NewWriter(w), nil
+	default:
+		return nil, codec.Validate()
+	}
+}
+
+// NewReader decompresses r as per codec
+func NewReader(r io.Reader, codec Codec) (io.ReadCloser, error) {
+	switch codec {
+	case None, "":
+		return io.NopCloser(r), nil
+	case Gzip:
+		return gzip.NewReader(r)
+	case Zstd:
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	case Snappy:
+		return io.NopCloser(s2.NewReader(r)), nil
+	case LZ4:
+		return io.NopCloser(lz4.NewReader(r)), nil
+	default:
+		return nil, codec.Validate()
+	}
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,49 @@
+package compress
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	payload := strings.Repeat(`{"id":1,"name":"olake"}`+"\n", 1000)
+	for _, codec := range []Codec{None, Gzip, Zstd, Snappy, LZ4} {
+		t.Run(string(codec), func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := NewWriter(&buf, codec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := io.WriteString(writer, payload); err != nil {
+				t.Fatal(err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if codec != None && buf.Len() >= len(payload) {
+				t.Fatalf("expected compressed output, got %d bytes for %d", buf.Len(), len(payload))
+			}
+
+			reader, err := NewReader(&buf, codec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer reader.Close()
+			decoded, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(decoded) != payload {
+				t.Fatal("round trip mismatch")
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Codec("brotli").Validate(); err == nil {
+		t.Fatal("expected error for unsupported codec")
+	}
+}
@@ -0,0 +1,286 @@
+// Package ddl renders CREATE TABLE statements for streams, for destinations where writers
+// aren't allowed to create tables themselves
+package ddl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/datazip-inc/olake/constants"
+	"github.com/datazip-inc/olake/types"
+)
+
+type Dialect string
+
+const (
+	Postgres   Dialect = "postgres"
+	Snowflake  Dialect = "snowflake"
+	ClickHouse Dialect = "clickhouse"
+)
+
+// Dialects lists the dialects statements can be generated in
+var Dialects = []Dialect{Postgres, Snowflake, ClickHouse}
+
+func ParseDialect(name string) (Dialect, error) {
+	for _, dialect := range Dialects {
+		if strings.EqualFold(name, string(dialect)) {
+			return dialect, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported dialect[%s]; expected one of postgres, snowflake, clickhouse", name)
+}
+
+type Column struct {
+	Name     string
+	Type     types.DataType
+	Nullable bool
+}
+
+// Table is the destination table of a stream
+type Table struct {
+	Namespace  string
+	Name       string
+	Columns    []Column
+	PrimaryKey []string
+}
+
+// FromStream builds the table normalized writers write stream to: the columns of its schema,
+// sorted by name, followed by the columns olake adds to every record; naming and metadata
+// may be nil
+func FromStream(stream *types.ConfiguredStream, naming *types.NamingConfig, metadata *types.MetadataColumnsConfig) *Table {
+	namespace, name := naming.Destination(stream)
+	table := &Table{Namespace: namespace, Name: name}
+
+	olakeColumns := []Column{
+		{Name: constants.OlakeID, Type: types.String},
+		{Name: constants.OlakeTimestamp, Type: types.Int64},
+		{Name: constants.CDCDeletedAt, Type: types.Int64, Nullable: true},
+	}
+	if metadata != nil {
+		for _, column := range []struct {
+			enabled bool
+			column  Column
+		}{
+			{metadata.SyncedAt, Column{Name: constants.MetaSyncedAt, Type: types.TimestampMilli, Nullable: true}},
+			{metadata.SyncID, Column{Name: constants.MetaSyncID, Type: types.String, Nullable: true}},
+			{metadata.RawID, Column{Name: constants.MetaRawID, Type: types.String, Nullable: true}},
+			{metadata.Op, Column{Name: constants.MetaOp, Type: types.String, Nullable: true}},
+		} {
+			if column.enabled {
+				olakeColumns = append(olakeColumns, column.column)
+			}
+		}
+	}
+	reserved := make(map[string]bool)
+	for _, column := range olakeColumns {
+		reserved[column.Name] = true
+	}
+
+	primaryKey := stream.GetStream().SourceDefinedPrimaryKey.Array()
+	sort.Strings(primaryKey)
+	keys := make(map[string]bool)
+	for _, key := range primaryKey {
+		keys[key] = true
+	}
+
+	// transformed values are written as strings
+	transformed := make(map[string]bool)
+	for _, transform := range stream.StreamMetadata.Transforms {
+		transformed[transform.Column] = true
+	}
+
+	stream.Schema().Properties.Range(func(key, value any) bool {
+		column := key.(string)
+		if reserved[column] {
+			return true
+		}
+		property := value.(*types.Property)
+		typ := columnType(property)
+		if transformed[column] {
+			typ = types.String
+		}
+		table.Columns = append(table.Columns, Column{
+			Name:     column,
+			Type:     typ,
+			Nullable: property.Nullable() && !keys[column],
+		})
+		return true
+	})
+	sort.Slice(table.Columns, func(i, j int) bool { return table.Columns[i].Name < table.Columns[j].Name })
+	table.Columns = append(table.Columns, olakeColumns...)
+
+	// writers deduplicate on olake_id, derived from the primary key, when the stream has none
+	table.PrimaryKey = primaryKey
+	if len(table.PrimaryKey) == 0 {
+		table.PrimaryKey = []string{constants.OlakeID}
+	}
+	return table
+}
+
+// columnType picks a type holding every type a column was seen with: numbers for mixed
+// integers and numbers, the most precise timestamp for mixed timestamps and strings otherwise
+func columnType(property *types.Property) types.DataType {
+	var seen []types.DataType
+	for _, typ := range property.Type.Array() {
+		if typ != types.Null {
+			seen = append(seen, typ)
+		}
+	}
+	switch {
+	case len(seen) == 0:
+		return types.String
+	case len(seen) == 1:
+		return seen[0]
+	}
+
+	numeric, timestamps := true, true
+	widest := types.Timestamp
+	for _, typ := range seen {
+		switch typ {
+		case types.Int64, types.Float64:
+			timestamps = false
+		case types.Timestamp, types.TimestampMilli, types.TimestampMicro, types.TimestampNano:
+			numeric = false
+			if timestampPrecision(typ) > timestampPrecision(widest) {
+				widest = typ
+			}
+		default:
+			numeric, timestamps = false, false
+		}
+	}
+	switch {
+	case numeric:
+		return types.Float64
+	case timestamps:
+		return widest
+	default:
+		return types.String
+	}
+}
+
+// timestampPrecision is the number of fractional second digits of a timestamp type
+func timestampPrecision(typ types.DataType) int {
+	switch typ {
+	case types.TimestampMilli:
+		return 3
+	case types.TimestampNano:
+		return 9
+	default:
+		return 6
+	}
+}
+
+// ColumnType is the type of the dialect a column of typ is stored as
+func (d Dialect) ColumnType(typ types.DataType) string {
+	switch d {
+	case Postgres:
+		switch typ {
+		case types.Int64:
+			return "BIGINT"
+		case types.Float64:
+			return "DOUBLE PRECISION"
+		case types.Bool:
+			return "BOOLEAN"
+		case types.Object, types.Array, types.JSON:
+			return "JSONB"
+		case types.Bytes:
+			return "BYTEA"
+		case types.Geometry:
+			// requires the postgis extension
+			return "GEOMETRY"
+		case types.Timestamp, types.TimestampMilli, types.TimestampMicro, types.TimestampNano:
+			// microseconds are the finest precision postgres keeps
+			return "TIMESTAMPTZ"
+		default:
+			return "TEXT"
+		}
+	case Snowflake:
+		switch typ {
+		case types.Int64:
+			return "NUMBER(19,0)"
+		case types.Float64:
+			return "FLOAT"
+		case types.Bool:
+			return "BOOLEAN"
+		case types.Object:
+			return "OBJECT"
+		case types.Array:
+			return "ARRAY"
+		case types.JSON:
+			return "VARIANT"
+		case types.Bytes:
+			return "BINARY"
+		case types.Geometry:
+			return "GEOMETRY"
+		case types.Timestamp, types.TimestampMilli, types.TimestampMicro, types.TimestampNano:
+			return fmt.Sprintf("TIMESTAMP_TZ(%d)", timestampPrecision(typ))
+		default:
+			return "VARCHAR"
+		}
+	case ClickHouse:
+		switch typ {
+		case types.Int64:
+			return "Int64"
+		case types.Float64:
+			return "Float64"
+		case types.Bool:
+			return "Bool"
+		case types.Timestamp, types.TimestampMilli, types.TimestampMicro, types.TimestampNano:
+			return fmt.Sprintf("DateTime64(%d, 'UTC')", timestampPrecision(typ))
+		default:
+			// objects and arrays are written as json strings; their element types are unknown,
+			// bytes, geometries (as wkb) and json text are strings of bytes
+			return "String"
+		}
+	}
+	return ""
+}
+
+// Quote quotes an identifier so it keeps its case and may hold any character
+func (d Dialect) Quote(identifier string) string {
+	if d == ClickHouse {
+		return "`" + strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(identifier) + "`"
+	}
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// CreateTable renders the statement creating table when it doesn't exist; primary key columns
+// are not nullable. ClickHouse tables are ReplacingMergeTrees ordered by the primary key, so
+// rows written again are collapsed to the latest one
+func (d Dialect) CreateTable(table *Table) string {
+	keys := make(map[string]bool)
+	quotedKeys := make([]string, 0, len(table.PrimaryKey))
+	for _, key := range table.PrimaryKey {
+		keys[key] = true
+		quotedKeys = append(quotedKeys, d.Quote(key))
+	}
+
+	definitions := make([]string, 0, len(table.Columns)+1)
+	for _, column := range table.Columns {
+		nullable := column.Nullable && !keys[column.Name]
+		typ := d.ColumnType(column.Type)
+		switch {
+		case d == ClickHouse && nullable:
+			typ = "Nullable(" + typ + ")"
+		case d != ClickHouse && !nullable:
+			typ += " NOT NULL"
+		}
+		definitions = append(definitions, fmt.Sprintf("  %s %s", d.Quote(column.Name), typ))
+	}
+
+	var builder strings.Builder
+	builder.WriteString("CREATE TABLE IF NOT EXISTS ")
+	if table.Namespace != "" {
+		builder.WriteString(d.Quote(table.Namespace) + ".")
+	}
+	builder.WriteString(d.Quote(table.Name))
+	if d == ClickHouse {
+		builder.WriteString(" (\n" + strings.Join(definitions, ",\n") + "\n)")
+		builder.WriteString(fmt.Sprintf("\nENGINE = ReplacingMergeTree(%s)\nORDER BY (%s);\n", d.Quote(constants.OlakeTimestamp), strings.Join(quotedKeys, ", ")))
+		return builder.String()
+	}
+	definitions = append(definitions, fmt.Sprintf("  PRIMARY KEY (%s)", strings.Join(quotedKeys, ", ")))
+	builder.WriteString(" (\n" + strings.Join(definitions, ",\n") + "\n);\n")
+	return builder.String()
+}
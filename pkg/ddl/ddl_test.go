@@ -0,0 +1,106 @@
+package ddl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+func testStream() *types.ConfiguredStream {
+	stream := types.NewStream("orders", "public").WithPrimaryKey("id")
+	stream.UpsertField("id", types.Int64, false)
+	stream.UpsertField("note", types.String, true)
+	stream.UpsertField("created_at", types.TimestampMicro, false)
+	stream.Schema.AddTypes("amount", types.Int64, types.Float64, types.Null)
+	stream.Schema.AddTypes("tags", types.Array)
+	return &types.ConfiguredStream{Stream: stream}
+}
+
+func TestCreateTable(t *testing.T) {
+	table := FromStream(testStream(), &types.NamingConfig{Prefix: "raw_"}, &types.MetadataColumnsConfig{Op: true})
+
+	for dialect, expected := range map[Dialect]string{
+		Postgres: `CREATE TABLE IF NOT EXISTS "public"."raw_orders" (
+  "amount" DOUBLE PRECISION,
+  "created_at" TIMESTAMPTZ NOT NULL,
+  "id" BIGINT NOT NULL,
+  "note" TEXT,
+  "tags" JSONB NOT NULL,
+  "olake_id" TEXT NOT NULL,
+  "olake_insert_time" BIGINT NOT NULL,
+  "_cdc_deleted_at" BIGINT,
+  "_olake_op" TEXT,
+  PRIMARY KEY ("id")
+);
+`,
+		Snowflake: `CREATE TABLE IF NOT EXISTS "public"."raw_orders" (
+  "amount" FLOAT,
+  "created_at" TIMESTAMP_TZ(6) NOT NULL,
+  "id" NUMBER(19,0) NOT NULL,
+  "note" VARCHAR,
+  "tags" ARRAY NOT NULL,
+  "olake_id" VARCHAR NOT NULL,
+  "olake_insert_time" NUMBER(19,0) NOT NULL,
+  "_cdc_deleted_at" NUMBER(19,0),
+  "_olake_op" VARCHAR,
+  PRIMARY KEY ("id")
+);
+`,
+		ClickHouse: "CREATE TABLE IF NOT EXISTS `public`.`raw_orders` (\n" +
+			"  `amount` Nullable(Float64),\n" +
+			"  `created_at` DateTime64(6, 'UTC'),\n" +
+			"  `id` Int64,\n" +
+			"  `note` Nullable(String),\n" +
+			"  `tags` String,\n" +
+			"  `olake_id` String,\n" +
+			"  `olake_insert_time` Int64,\n" +
+			"  `_cdc_deleted_at` Nullable(Int64),\n" +
+			"  `_olake_op` Nullable(String)\n" +
+			")\nENGINE = ReplacingMergeTree(`olake_insert_time`)\nORDER BY (`id`);\n",
+	} {
+		if statement := dialect.CreateTable(table); statement != expected {
+			t.Errorf("unexpected %s statement:\n%s", dialect, statement)
+		}
+	}
+}
+
+func TestFromStreamWithoutPrimaryKey(t *testing.T) {
+	stream := types.NewStream("events", "")
+	stream.Schema.AddTypes("payload", types.Object, types.String)
+	stream.Schema.AddTypes("at", types.TimestampMilli, types.TimestampNano)
+	table := FromStream(&types.ConfiguredStream{Stream: stream}, nil, nil)
+
+	if len(table.PrimaryKey) != 1 || table.PrimaryKey[0] != "olake_id" {
+		t.Fatalf("expected olake_id to be the primary key, got %v", table.PrimaryKey)
+	}
+	if table.Columns[0].Type != types.TimestampNano || table.Columns[1].Type != types.String {
+		t.Fatalf("expected mixed types to be widened, got %+v", table.Columns)
+	}
+	if statement := Postgres.CreateTable(table); !strings.HasPrefix(statement, `CREATE TABLE IF NOT EXISTS "events" (`) {
+		t.Fatalf("expected an unqualified table, got %s", statement)
+	}
+}
+
+func TestFromStreamWithTransforms(t *testing.T) {
+	stream := testStream()
+	stream.StreamMetadata.Transforms = []types.ColumnTransform{{Column: "id", Type: types.TransformHash, Salt: "s"}}
+	table := FromStream(stream, nil, nil)
+	for _, column := range table.Columns {
+		if column.Name == "id" && (column.Type != types.String || column.Nullable) {
+			t.Fatalf("expected the hashed key to be a non nullable string, got %+v", column)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if quoted := Postgres.Quote(`a"b`); quoted != `"a""b"` {
+		t.Fatalf("unexpected quoting %s", quoted)
+	}
+	if quoted := ClickHouse.Quote("a`b"); quoted != "`a\\`b`" {
+		t.Fatalf("unexpected quoting %s", quoted)
+	}
+	if _, err := ParseDialect("mysql"); err == nil {
+		t.Fatal("expected unsupported dialects to be rejected")
+	}
+}
@@ -0,0 +1,150 @@
+package ddl
+
+import (
+	"fmt"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+type ChangeKind string
+
+const (
+	// AddColumn adds a column; added columns are always nullable as earlier rows lack them
+	AddColumn ChangeKind = "add_column"
+	// WidenColumn changes the type of a column to one holding values of both types
+	WidenColumn ChangeKind = "widen_column"
+	// DropNotNull makes a column nullable
+	DropNotNull ChangeKind = "drop_not_null"
+)
+
+// Change is a change of a column a table needs to hold the rows of an evolved schema
+type Change struct {
+	Kind ChangeKind
+	// the column as it's changed to
+	Column Column
+	// type of a widened column before
+	From types.DataType
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case WidenColumn:
+		return fmt.Sprintf("change type of column[%s] from %s to %s", c.Column.Name, c.From, c.Column.Type)
+	case DropNotNull:
+		return fmt.Sprintf("make column[%s] nullable", c.Column.Name)
+	default:
+		return fmt.Sprintf("add column[%s] of type %s", c.Column.Name, c.Column.Type)
+	}
+}
+
+// Diff returns the changes turning current into target: the columns target adds, the columns
+// whose type changed and the columns that became nullable. Columns are never dropped, as
+// earlier rows hold them
+func Diff(current, target *Table) []Change {
+	columns := make(map[string]Column, len(current.Columns))
+	for _, column := range current.Columns {
+		columns[column.Name] = column
+	}
+	var changes []Change
+	for _, column := range target.Columns {
+		existing, found := columns[column.Name]
+		switch {
+		case !found:
+			column.Nullable = true
+			changes = append(changes, Change{Kind: AddColumn, Column: column})
+		case existing.Type != column.Type:
+			changes = append(changes, Change{Kind: WidenColumn, Column: column, From: existing.Type})
+		case !existing.Nullable && column.Nullable:
+			changes = append(changes, Change{Kind: DropNotNull, Column: column})
+		}
+	}
+	return changes
+}
+
+// Migration renders the statements making the changes of table policy allows and the dialect
+// makes in place without losing values; the changes left out are returned as skipped. Primary
+// key columns are only ever added, as tables are ordered and deduplicated by them
+func (d Dialect) Migration(table *Table, changes []Change, policy types.MigrationPolicy) (statements []string, skipped []Change) {
+	prefix := "ALTER TABLE "
+	if table.Namespace != "" {
+		prefix += d.Quote(table.Namespace) + "."
+	}
+	prefix += d.Quote(table.Name) + " "
+	keys := make(map[string]bool)
+	for _, key := range table.PrimaryKey {
+		keys[key] = true
+	}
+	for _, change := range changes {
+		allowed := policy == types.MigrateWiden || (policy == types.MigrateAddColumns && change.Kind == AddColumn)
+		if change.Kind != AddColumn && keys[change.Column.Name] {
+			allowed = false
+		}
+		statement := ""
+		if allowed {
+			statement = d.alter(change)
+		}
+		if statement == "" {
+			skipped = append(skipped, change)
+			continue
+		}
+		statements = append(statements, prefix+statement+";")
+	}
+	return statements, skipped
+}
+
+// alter renders the clause of change; empty when the dialect can't make it in place without
+// losing values
+func (d Dialect) alter(change Change) string {
+	column := d.Quote(change.Column.Name)
+	typ := d.ColumnType(change.Column.Type)
+	switch change.Kind {
+	case AddColumn:
+		if d == ClickHouse {
+			return fmt.Sprintf("ADD COLUMN IF NOT EXISTS %s Nullable(%s)", column, typ)
+		}
+		return fmt.Sprintf("ADD COLUMN IF NOT EXISTS %s %s", column, typ)
+	case DropNotNull:
+		if d == ClickHouse {
+			return fmt.Sprintf("MODIFY COLUMN %s Nullable(%s)", column, typ)
+		}
+		return fmt.Sprintf("ALTER COLUMN %s DROP NOT NULL", column)
+	case WidenColumn:
+		if !d.widens(change.From, change.Column.Type) {
+			return ""
+		}
+		from := d.ColumnType(change.From)
+		switch {
+		case from == typ:
+			// types of the dialect both map to, e.g. timestamps of any precision in postgres
+			return ""
+		case d == Postgres:
+			return fmt.Sprintf("ALTER COLUMN %s TYPE %s USING %s::%s", column, typ, column, typ)
+		case d == ClickHouse:
+			// widened columns are nullable, as mixed types come from records without a value
+			return fmt.Sprintf("MODIFY COLUMN %s Nullable(%s)", column, typ)
+		}
+	}
+	return ""
+}
+
+// widens reports whether the dialect changes columns of type from to type to in place keeping
+// their values: to text, or to timestamps of more precision. Snowflake only widens varchar
+// lengths and number precisions, which olake types don't change, and integers to floats lose
+// precision in every dialect
+func (d Dialect) widens(from, to types.DataType) bool {
+	if d == Snowflake {
+		return false
+	}
+	if to == types.String {
+		return true
+	}
+	return isTimestamp(from) && isTimestamp(to) && timestampPrecision(to) > timestampPrecision(from)
+}
+
+func isTimestamp(typ types.DataType) bool {
+	switch typ {
+	case types.Timestamp, types.TimestampMilli, types.TimestampMicro, types.TimestampNano:
+		return true
+	}
+	return false
+}
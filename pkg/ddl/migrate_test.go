@@ -0,0 +1,63 @@
+package ddl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestMigration(t *testing.T) {
+	stream := testStream()
+	before := FromStream(stream, nil, nil)
+	// a new column, a created_at of more precision, tags becoming nullable and an id key
+	// becoming a string
+	stream.GetStream().UpsertField("status", types.String, true)
+	stream.Schema().AddTypes("created_at", types.TimestampNano)
+	stream.Schema().AddTypes("tags", types.Null)
+	stream.Schema().AddTypes("id", types.String)
+	after := FromStream(stream, nil, nil)
+	changes := Diff(before, after)
+	if len(changes) != 4 {
+		t.Fatalf("expected 4 changes, got %v", changes)
+	}
+
+	for _, test := range []struct {
+		dialect    Dialect
+		policy     types.MigrationPolicy
+		statements []string
+		skipped    int
+	}{
+		{Postgres, types.MigrateNone, nil, 4},
+		{Postgres, types.MigrateAddColumns, []string{`ALTER TABLE "public"."orders" ADD COLUMN IF NOT EXISTS "status" TEXT;`}, 3},
+		// timestamps of any precision are timestamptz; keys aren't changed
+		{Postgres, types.MigrateWiden, []string{
+			`ALTER TABLE "public"."orders" ADD COLUMN IF NOT EXISTS "status" TEXT;`,
+			`ALTER TABLE "public"."orders" ALTER COLUMN "tags" DROP NOT NULL;`,
+		}, 2},
+		{ClickHouse, types.MigrateWiden, []string{
+			"ALTER TABLE `public`.`orders` MODIFY COLUMN `created_at` Nullable(DateTime64(9, 'UTC'));",
+			"ALTER TABLE `public`.`orders` ADD COLUMN IF NOT EXISTS `status` Nullable(String);",
+			"ALTER TABLE `public`.`orders` MODIFY COLUMN `tags` Nullable(String);",
+		}, 1},
+		{Snowflake, types.MigrateWiden, []string{
+			`ALTER TABLE "public"."orders" ADD COLUMN IF NOT EXISTS "status" VARCHAR;`,
+			`ALTER TABLE "public"."orders" ALTER COLUMN "tags" DROP NOT NULL;`,
+		}, 2},
+	} {
+		statements, skipped := test.dialect.Migration(after, changes, test.policy)
+		if strings.Join(statements, "\n") != strings.Join(test.statements, "\n") || len(skipped) != test.skipped {
+			t.Errorf("%s under %s: expected %v and %d skipped, got %v and skipped %v", test.dialect, test.policy, test.statements, test.skipped, statements, skipped)
+		}
+	}
+
+	// integers widened to numbers would lose precision
+	widened := Change{Kind: WidenColumn, Column: Column{Name: "amount", Type: types.Float64, Nullable: true}, From: types.Int64}
+	if statements, _ := Postgres.Migration(after, []Change{widened}, types.MigrateWiden); len(statements) != 0 {
+		t.Fatalf("expected integers not to be widened to floats, got %v", statements)
+	}
+	widened.Column.Type = types.String
+	if statements, _ := Postgres.Migration(after, []Change{widened}, types.MigrateWiden); len(statements) != 1 || statements[0] != `ALTER TABLE "public"."orders" ALTER COLUMN "amount" TYPE TEXT USING "amount"::TEXT;` {
+		t.Fatalf("expected a widening to text, got %v", statements)
+	}
+}
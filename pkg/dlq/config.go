@@ -0,0 +1,60 @@
+package dlq
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+)
+
+type SinkType string
+
+const (
+	SinkFile  SinkType = "file"
+	SinkS3    SinkType = "s3"
+	SinkKafka SinkType = "kafka"
+)
+
+type Config struct {
+	Type SinkType `json:"type"`
+	// file sink; defaults to dead_letter.jsonl in the config folder
+	Path string `json:"path,omitempty"`
+	// s3 sink; every flush uploads one json lines object under the prefix
+	Bucket    string `json:"s3_bucket,omitempty"`
+	Region    string `json:"s3_region,omitempty"`
+	AccessKey string `json:"s3_access_key,omitempty"`
+	SecretKey string `json:"s3_secret_key,omitempty"`
+	Prefix    string `json:"s3_path,omitempty"`
+	// kafka sink; letters are keyed by stream id
+	Brokers []string `json:"kafka_brokers,omitempty"`
+	Topic   string   `json:"kafka_topic,omitempty"`
+
+	BatchSize int `json:"batch_size,omitempty"`
+	// sync fails once more records than this are rejected; unlimited when 0
+	MaxLetters int64 `json:"max_letters,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	if c.BatchSize <= 0 {
+		c.BatchSize = 500
+	}
+
+	switch c.Type {
+	case "", SinkFile:
+		c.Type = SinkFile
+		if c.Path == "" {
+			c.Path = filepath.Join(viper.GetString("CONFIG_FOLDER"), "dead_letter.jsonl")
+		}
+	case SinkS3:
+		if c.Bucket == "" || c.Region == "" {
+			return fmt.Errorf("s3_bucket and s3_region required for dead letter sink[%s]", c.Type)
+		}
+	case SinkKafka:
+		if len(c.Brokers) == 0 || c.Topic == "" {
+			return fmt.Errorf("kafka_brokers and kafka_topic required for dead letter sink[%s]", c.Type)
+		}
+	default:
+		return fmt.Errorf("invalid dead letter sink type[%s]; expected one of file, s3, kafka", c.Type)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Package dlq is the dead letter queue receiving records that could not be synced, so that a
+// bad record does not abort the whole sync
+package dlq
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stage at which a record got rejected
+type Stage string
+
+const (
+	StageTransform  Stage = "transform"
+	StageValidation Stage = "validation"
+	StageWrite      Stage = "write"
+)
+
+// Letter is a rejected record with the context of its rejection
+type Letter struct {
+	Stream     string         `json:"stream"`
+	Stage      Stage          `json:"stage"`
+	Error      string         `json:"error"`
+	RejectedAt time.Time      `json:"rejected_at"`
+	Record     map[string]any `json:"record"`
+}
+
+func NewLetter(stream string, stage Stage, reason error, record map[string]any) *Letter {
+	return &Letter{
+		Stream:     stream,
+		Stage:      stage,
+		Error:      reason.Error(),
+		RejectedAt: time.Now().UTC(),
+		Record:     record,
+	}
+}
+
+// Sink persists batches of letters
+type Sink interface {
+	Write(ctx context.Context, letters []*Letter) error
+	Close() error
+}
+
+// Queue buffers letters and flushes them to the sink in batches; safe for concurrent use
+type Queue struct {
+	mutex  sync.Mutex
+	config *Config
+	sink   Sink
+	buffer []*Letter
+	count  atomic.Int64
+}
+
+func New(config *Config) (*Queue, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	var sink Sink
+	var err error
+	switch config.Type {
+	case SinkFile:
+		sink, err = newFileSink(config)
+	case SinkS3:
+		sink, err = newS3Sink(config)
+	case SinkKafka:
+		sink, err = newKafkaSink(config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup dead letter sink[%s]: %s", config.Type, err)
+	}
+
+	return &Queue{config: config, sink: sink}, nil
+}
+
+// Send queues a letter; fails once more than max_letters got rejected
+func (q *Queue) Send(ctx context.Context, letter *Letter) error {
+	count := q.count.Add(1)
+	if q.config.MaxLetters > 0 && count > q.config.MaxLetters {
+		return fmt.Errorf("dead letter limit of %d records exceeded, last error: %s", q.config.MaxLetters, letter.Error)
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.buffer = append(q.buffer, letter)
+	if len(q.buffer) < q.config.BatchSize {
+		return nil
+	}
+	return q.flush(ctx)
+}
+
+// Count returns number of letters sent so far
+func (q *Queue) Count() int64 {
+	return q.count.Load()
+}
+
+// Close flushes the pending letters and closes the sink
+func (q *Queue) Close(ctx context.Context) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if err := q.flush(ctx); err != nil {
+		_ = q.sink.Close()
+		return err
+	}
+	return q.sink.Close()
+}
+
+func (q *Queue) flush(ctx context.Context) error {
+	if len(q.buffer) == 0 {
+		return nil
+	}
+	if err := q.sink.Write(ctx, q.buffer); err != nil {
+		return fmt.Errorf("failed to write %d dead letters: %s", len(q.buffer), err)
+	}
+	q.buffer = q.buffer[:0]
+	return nil
+}
@@ -0,0 +1,43 @@
+package dlq
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQueueFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead_letter.jsonl")
+	queue, err := New(&Config{Type: SinkFile, Path: path, BatchSize: 2, MaxLetters: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if err := queue.Send(ctx, NewLetter("ns.orders", StageWrite, errors.New("boom"), map[string]any{"id": i})); err != nil {
+			t.Fatalf("unexpected error on letter %d: %s", i, err)
+		}
+	}
+	if err := queue.Send(ctx, NewLetter("ns.orders", StageWrite, errors.New("boom"), nil)); err == nil {
+		t.Fatal("expected error once max letters is exceeded")
+	}
+	if err := queue.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 letters, got %d", len(lines))
+	}
+	if !strings.Contains(lines[0], `"stage":"write"`) || !strings.Contains(lines[0], `"error":"boom"`) {
+		t.Fatalf("unexpected letter: %s", lines[0])
+	}
+}
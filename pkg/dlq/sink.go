@@ -0,0 +1,130 @@
+package dlq
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/goccy/go-json"
+	"github.com/segmentio/kafka-go"
+)
+
+func encodeLines(letters []*Letter) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, letter := range letters {
+		if err := encoder.Encode(letter); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// fileSink appends json lines to a local file
+type fileSink struct {
+	file *os.File
+}
+
+func newFileSink(config *Config) (*fileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(config.Path), os.ModePerm); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{file: file}, nil
+}
+
+func (f *fileSink) Write(_ context.Context, letters []*Letter) error {
+	lines, err := encodeLines(letters)
+	if err != nil {
+		return err
+	}
+	if _, err := f.file.Write(lines); err != nil {
+		return err
+	}
+	return f.file.Sync()
+}
+
+func (f *fileSink) Close() error {
+	return f.file.Close()
+}
+
+// s3Sink uploads every batch as an object
+type s3Sink struct {
+	client *s3.S3
+	config *Config
+}
+
+func newS3Sink(config *Config) (*s3Sink, error) {
+	s3Config := aws.Config{
+		Region: aws.String(config.Region),
+	}
+	if config.AccessKey != "" && config.SecretKey != "" {
+		s3Config.Credentials = credentials.NewStaticCredentials(config.AccessKey, config.SecretKey, "")
+	}
+	sess, err := session.NewSession(&s3Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %s", err)
+	}
+	return &s3Sink{client: s3.New(sess), config: config}, nil
+}
+
+func (s *s3Sink) Write(ctx context.Context, letters []*Letter) error {
+	lines, err := encodeLines(letters)
+	if err != nil {
+		return err
+	}
+	key := filepath.Join(s.config.Prefix, utils.TimestampedFileName("jsonl"))
+	_, err = s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(lines),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload s3://%s/%s: %s", s.config.Bucket, key, err)
+	}
+	return nil
+}
+
+func (s *s3Sink) Close() error {
+	return nil
+}
+
+// kafkaSink produces a message per letter keyed by stream id
+type kafkaSink struct {
+	writer *kafka.Writer
+}
+
+func newKafkaSink(config *Config) (*kafkaSink, error) {
+	return &kafkaSink{writer: &kafka.Writer{
+		Addr:         kafka.TCP(config.Brokers...),
+		Topic:        config.Topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}}, nil
+}
+
+func (k *kafkaSink) Write(ctx context.Context, letters []*Letter) error {
+	messages := make([]kafka.Message, 0, len(letters))
+	for _, letter := range letters {
+		value, err := json.Marshal(letter)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, kafka.Message{Key: []byte(letter.Stream), Value: value})
+	}
+	return k.writer.WriteMessages(ctx, messages...)
+}
+
+func (k *kafkaSink) Close() error {
+	return k.writer.Close()
+}
@@ -0,0 +1,181 @@
+// Package encryption seals state checkpoints and sensitive config values with AES-256-GCM,
+// since state can embed credentials or snapshot boundaries and often sits on shared volumes
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/goccy/go-json"
+)
+
+const (
+	// base64 encoded 32 byte key
+	KeyEnv = "OLAKE_ENCRYPTION_KEY"
+	// base64 encoded data key encrypted with AWS KMS; decrypted once per process
+	KMSKeyEnv = "OLAKE_ENCRYPTION_KMS_KEY"
+
+	// marks an encrypted value; the rest is the base64 encoded nonce and ciphertext
+	Prefix = "enc:v1:"
+)
+
+var (
+	ErrNoKey = fmt.Errorf("found encrypted value but neither %s nor %s is set", KeyEnv, KMSKeyEnv)
+
+	loadKey = sync.OnceValues(readKey)
+)
+
+// Enabled reports if a key is configured; state is then written encrypted
+func Enabled() bool {
+	return os.Getenv(KeyEnv) != "" || os.Getenv(KMSKeyEnv) != ""
+}
+
+func readKey() (cipher.AEAD, error) {
+	var key []byte
+	if encoded := os.Getenv(KeyEnv); encoded != "" {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %s", KeyEnv, err)
+		}
+		key = decoded
+	} else if encoded := os.Getenv(KMSKeyEnv); encoded != "" {
+		blob, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %s", KMSKeyEnv, err)
+		}
+		sess, err := session.NewSessionWithOptions(session.Options{SharedConfigState: session.SharedConfigEnable})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AWS session: %s", err)
+		}
+		output, err := kms.New(sess).Decrypt(&kms.DecryptInput{CiphertextBlob: blob})
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt data key with KMS: %s", err)
+		}
+		key = output.Plaintext
+	} else {
+		return nil, ErrNoKey
+	}
+
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt returns the plaintext as an encrypted value
+func Encrypt(plaintext []byte) (string, error) {
+	aead, err := loadKey()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return Prefix + base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+// Decrypt returns the plaintext of an encrypted value
+func Decrypt(value string) ([]byte, error) {
+	if !strings.HasPrefix(value, Prefix) {
+		return nil, fmt.Errorf("value is not encrypted")
+	}
+	aead, err := loadKey()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, Prefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encrypted value: %s", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("encrypted value is truncated")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt value; wrong key or tampered data: %s", err)
+	}
+	return plaintext, nil
+}
+
+// Seal encrypts a json document into a json string when a key is configured; documents are
+// returned as is otherwise
+func Seal(document []byte) ([]byte, error) {
+	if !Enabled() {
+		return document, nil
+	}
+	value, err := Encrypt(document)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// Open reverses Seal and decrypts every encrypted string value of the document, so configs
+// can mix plain and encrypted values
+func Open(document []byte) ([]byte, error) {
+	if !bytes.Contains(document, []byte(Prefix)) {
+		return document, nil
+	}
+
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if sealed, ok := value.(string); ok && strings.HasPrefix(sealed, Prefix) {
+		return Decrypt(sealed)
+	}
+	value, err := decryptValues(value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+func decryptValues(value any) (any, error) {
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, elem := range typed {
+			decrypted, err := decryptValues(elem)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			typed[key] = decrypted
+		}
+	case []any:
+		for idx, elem := range typed {
+			decrypted, err := decryptValues(elem)
+			if err != nil {
+				return nil, err
+			}
+			typed[idx] = decrypted
+		}
+	case string:
+		if strings.HasPrefix(typed, Prefix) {
+			plaintext, err := Decrypt(typed)
+			if err != nil {
+				return nil, err
+			}
+			return string(plaintext), nil
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,73 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func setKey(t *testing.T) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(KeyEnv, base64.StdEncoding.EncodeToString(key))
+	loadKey = sync.OnceValues(readKey)
+}
+
+func TestSealOpen(t *testing.T) {
+	setKey(t)
+
+	state := []byte(`{"type":"GLOBAL","global":{"lsn":"0/16B3748"}}`)
+	sealed, err := Seal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(sealed), "lsn") {
+		t.Fatalf("expected state to be encrypted, got %s", sealed)
+	}
+	opened, err := Open(sealed)
+	if err != nil || string(opened) != string(state) {
+		t.Fatalf("expected original state, got %s, %v", opened, err)
+	}
+}
+
+func TestOpenConfigValues(t *testing.T) {
+	setKey(t)
+
+	password, err := Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := `{"host":"localhost","port":5432,"credentials":{"password":"` + password + `"}}`
+	opened, err := Open([]byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(opened), `"password":"secret"`) || !strings.Contains(string(opened), `"port":5432`) {
+		t.Fatalf("unexpected config %s", opened)
+	}
+
+	// tampered values must not decrypt
+	tampered := password[:len(password)-4] + "AAA="
+	if _, err := Open([]byte(`{"password":"` + tampered + `"}`)); err == nil {
+		t.Fatal("expected tampered value to be rejected")
+	}
+}
+
+func TestOpenWithoutKey(t *testing.T) {
+	t.Setenv(KeyEnv, "")
+	t.Setenv(KMSKeyEnv, "")
+	loadKey = sync.OnceValues(readKey)
+
+	plain := []byte(`{"host":"localhost"}`)
+	if sealed, err := Seal(plain); err != nil || string(sealed) != string(plain) {
+		t.Fatalf("expected document unchanged without a key, got %s, %v", sealed, err)
+	}
+	if _, err := Open([]byte(`{"password":"` + Prefix + `AAAA"}`)); !errors.Is(err, ErrNoKey) {
+		t.Fatalf("expected missing key error, got %v", err)
+	}
+}
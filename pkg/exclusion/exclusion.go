@@ -0,0 +1,55 @@
+// Package exclusion leaves the system relations of a source, e.g. schemas of the catalog or
+// internal topics, out of discover so catalogs only list user data
+package exclusion
+
+import (
+	"fmt"
+	"path"
+)
+
+// Config overrides the exclusions a driver applies by default; patterns are globs, e.g.
+// "pg_*", matched against the names the driver documents
+type Config struct {
+	// patterns excluded besides the defaults
+	Exclude []string `json:"exclude,omitempty"`
+	// patterns listed even when excluded by a default
+	Include []string `json:"include,omitempty"`
+	// drop the defaults of the driver
+	DisableDefaults bool `json:"disable_defaults,omitempty"`
+}
+
+// Excluded reports whether a relation known by any of names is left out of discover; a nil
+// config applies the defaults
+func (c *Config) Excluded(defaults []string, names ...string) bool {
+	if c == nil {
+		return matchAny(defaults, names)
+	}
+	if matchAny(c.Exclude, names) {
+		return true
+	}
+	return !c.DisableDefaults && matchAny(defaults, names) && !matchAny(c.Include, names)
+}
+
+// Validate checks that every pattern is a valid glob
+func (c *Config) Validate() error {
+	if c == nil {
+		return nil
+	}
+	for _, pattern := range append(append([]string{}, c.Exclude...), c.Include...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid exclusion pattern[%s]: %s", pattern, err)
+		}
+	}
+	return nil
+}
+
+func matchAny(patterns, names []string) bool {
+	for _, pattern := range patterns {
+		for _, name := range names {
+			if matched, _ := path.Match(pattern, name); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
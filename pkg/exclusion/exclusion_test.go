@@ -0,0 +1,35 @@
+package exclusion
+
+import "testing"
+
+func TestExcluded(t *testing.T) {
+	defaults := []string{"information_schema.*", "pg_*.*"}
+	var none *Config
+	if !none.Excluded(defaults, "pg_catalog.pg_class") || none.Excluded(defaults, "public.users") {
+		t.Fatal("expected only the defaults to be excluded without a config")
+	}
+
+	config := &Config{Exclude: []string{"public.tmp_*"}, Include: []string{"pg_catalog.*"}}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for name, excluded := range map[string]bool{
+		"pg_catalog.pg_class": false,
+		"pg_toast.pg_toast_1": true,
+		"public.tmp_orders":   true,
+		"public.orders":       false,
+		"public.pg_stats":     false,
+	} {
+		if config.Excluded(defaults, name) != excluded {
+			t.Errorf("expected %s excluded: %t", name, excluded)
+		}
+	}
+
+	config = &Config{DisableDefaults: true}
+	if config.Excluded(defaults, "information_schema.tables") {
+		t.Fatal("expected no defaults once disabled")
+	}
+	if err := (&Config{Exclude: []string{"["}}).Validate(); err == nil {
+		t.Fatal("expected an invalid pattern to be rejected")
+	}
+}
@@ -0,0 +1,16 @@
+package files
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+func readCSV(reader io.Reader, opts Options, rows func(next func() ([]string, error)) error) error {
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = []rune(opts.Delimiter)[0]
+	csvReader.FieldsPerRecord = -1 // rows of partitioned exports are not always aligned
+	csvReader.LazyQuotes = true
+	csvReader.ReuseRecord = false
+
+	return rows(csvReader.Read)
+}
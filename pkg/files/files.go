@@ -0,0 +1,207 @@
+package files
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/datazip-inc/olake/pkg/charset"
+	"golang.org/x/text/transform"
+)
+
+type Format string
+
+const (
+	CSV     Format = "csv"
+	XLSX    Format = "xlsx"
+	JSONL   Format = "jsonl"
+	Parquet Format = "parquet"
+
+	// columns added to every record to locate the row it came from
+	FileColumn = "_file"
+	RowColumn  = "_row"
+)
+
+// Options controls how a file is parsed into records
+type Options struct {
+	Format    Format `json:"format"`
+	Delimiter string `json:"delimiter"`
+	Encoding  string `json:"encoding"`
+	Header    *bool  `json:"header"`
+	Sheet     string `json:"sheet"` // only for xlsx; defaults to first sheet
+}
+
+// FileInfo describes a file discovered by a Source
+type FileInfo struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Source lists and opens files of one storage system
+type Source interface {
+	// List returns all files matching a glob pattern
+	List(ctx context.Context, pattern string) ([]FileInfo, error)
+	Open(ctx context.Context, file string) (io.ReadCloser, error)
+}
+
+// EmitFunc receives parsed records; returning false stops reading
+type EmitFunc func(record map[string]any) (bool, error)
+
+// Validate sets defaults; an empty Format is detected from every file name while reading
+func (o *Options) Validate() error {
+	switch o.Format {
+	case "", CSV, XLSX, JSONL, Parquet:
+	default:
+		return fmt.Errorf("unsupported file format[%s]", o.Format)
+	}
+	if o.Delimiter == "" {
+		o.Delimiter = ","
+	}
+	if len([]rune(o.Delimiter)) != 1 {
+		return fmt.Errorf("delimiter must be a single character, found [%s]", o.Delimiter)
+	}
+	if o.Encoding != "" {
+		if _, err := charset.Lookup(o.Encoding); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o *Options) hasHeader() bool {
+	return o.Header == nil || *o.Header
+}
+
+// FormatFromPath detects the file format from its extension, ignoring a trailing .gz
+func FormatFromPath(file string) Format {
+	file = strings.TrimSuffix(strings.ToLower(file), ".gz")
+	switch path.Ext(file) {
+	case ".csv", ".tsv", ".txt":
+		return CSV
+	case ".xlsx", ".xlsm":
+		return XLSX
+	case ".jsonl", ".ndjson", ".json":
+		return JSONL
+	case ".parquet":
+		return Parquet
+	}
+	return ""
+}
+
+// Read parses a file according to opts and emits one record per row; gzip files and
+// zip archives are decompressed based on their extension
+func Read(reader io.Reader, file string, opts Options, emit EmitFunc) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	return read(reader, file, file, opts, emit)
+}
+
+// read parses reader named name; file is the location recorded in every record
+func read(reader io.Reader, file, name string, opts Options, emit EmitFunc) error {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".gz":
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return fmt.Errorf("failed to open gzip file[%s]: %s", file, err)
+		}
+		defer gzipReader.Close()
+		return read(gzipReader, file, strings.TrimSuffix(name, path.Ext(name)), opts, emit)
+	case ".zip":
+		return readZip(reader, file, opts, emit)
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = FormatFromPath(name)
+	}
+	if opts.Encoding != "" {
+		enc, _ := charset.Lookup(opts.Encoding)
+		reader = transform.NewReader(reader, enc.NewDecoder())
+	}
+
+	rows := func(next func() ([]string, error)) error {
+		return emitRows(next, file, opts.hasHeader(), emit)
+	}
+	switch format {
+	case CSV:
+		return readCSV(reader, opts, rows)
+	case XLSX:
+		return readXLSX(reader, opts, rows)
+	case JSONL:
+		return readJSONL(reader, file, emit)
+	case Parquet:
+		return readParquet(reader, file, emit)
+	default:
+		return fmt.Errorf("unable to detect format of [%s]; set format explicitly", name)
+	}
+}
+
+// emitRows turns raw rows into records keyed by header names
+func emitRows(next func() ([]string, error), file string, header bool, emit EmitFunc) error {
+	var columns []string
+	rowNumber := int64(0)
+	for {
+		row, err := next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read row %d of [%s]: %s", rowNumber+1, file, err)
+		}
+		rowNumber++
+
+		if columns == nil && header {
+			columns = InferHeader(row)
+			continue
+		}
+		for len(columns) < len(row) {
+			columns = append(columns, fmt.Sprintf("column_%d", len(columns)+1))
+		}
+
+		record := make(map[string]any, len(row)+2)
+		for idx, value := range row {
+			if value == "" {
+				record[columns[idx]] = nil
+				continue
+			}
+			record[columns[idx]] = value
+		}
+		proceed, err := emit(withLocation(record, file, rowNumber))
+		if err != nil || !proceed {
+			return err
+		}
+	}
+}
+
+func withLocation(record map[string]any, file string, rowNumber int64) map[string]any {
+	record[FileColumn] = file
+	record[RowColumn] = rowNumber
+	return record
+}
+
+// InferHeader normalizes header cells into unique column names; blank cells are
+// named after their position
+func InferHeader(row []string) []string {
+	columns := make([]string, 0, len(row))
+	seen := make(map[string]int)
+	for idx, cell := range row {
+		column := strings.TrimSpace(strings.TrimPrefix(cell, "\ufeff"))
+		if column == "" {
+			column = fmt.Sprintf("column_%d", idx+1)
+		}
+		if count, found := seen[column]; found {
+			seen[column] = count + 1
+			column = fmt.Sprintf("%s_%d", column, count+1)
+		} else {
+			seen[column] = 1
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
@@ -0,0 +1,99 @@
+package files
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	input := "id;name;;name\n1;a;x;b\n2;;y\n"
+	var records []map[string]any
+	err := Read(strings.NewReader(input), "data.csv", Options{Delimiter: ";"}, func(record map[string]any) (bool, error) {
+		records = append(records, record)
+		return true, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []map[string]any{
+		{"id": "1", "name": "a", "column_3": "x", "name_2": "b", FileColumn: "data.csv", RowColumn: int64(2)},
+		{"id": "2", "name": nil, "column_3": "y", FileColumn: "data.csv", RowColumn: int64(3)},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Fatalf("expected %v, got %v", expected, records)
+	}
+}
+
+func TestReadCSVWithoutHeader(t *testing.T) {
+	header := false
+	var records []map[string]any
+	err := Read(strings.NewReader("1,2\n"), "data.csv", Options{Header: &header}, func(record map[string]any) (bool, error) {
+		records = append(records, record)
+		return true, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0]["column_1"] != "1" || records[0]["column_2"] != "2" {
+		t.Fatalf("unexpected records %v", records)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		match         bool
+	}{
+		{"data/*.csv", "data/a.csv", true},
+		{"data/*.csv", "data/x/a.csv", false},
+		{"data/**/*.csv", "data/a.csv", true},
+		{"data/**/*.csv", "data/dt=1/part/a.csv", true},
+		{"data/**/*.csv", "other/a.csv", false},
+	}
+	for _, c := range cases {
+		if got := Match(c.pattern, c.name); got != c.match {
+			t.Errorf("Match(%s, %s): expected %t", c.pattern, c.name, c.match)
+		}
+	}
+	if prefix := GlobPrefix("data/dt=*/a.csv"); prefix != "data/dt=" {
+		t.Fatalf("unexpected prefix %s", prefix)
+	}
+}
+
+func TestReadCompressed(t *testing.T) {
+	var gzipped bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipped)
+	_, _ = gzipWriter.Write([]byte("{\"id\": 1}\n\n{\"id\": 2}\n"))
+	_ = gzipWriter.Close()
+
+	var zipped bytes.Buffer
+	zipWriter := zip.NewWriter(&zipped)
+	entry, _ := zipWriter.Create("part.jsonl.gz")
+	_, _ = entry.Write(gzipped.Bytes())
+	entry, _ = zipWriter.Create("rows.csv")
+	_, _ = entry.Write([]byte("id\n3\n"))
+	_ = zipWriter.Close()
+
+	var records []map[string]any
+	err := Read(bytes.NewReader(zipped.Bytes()), "feed.zip", Options{}, func(record map[string]any) (bool, error) {
+		records = append(records, record)
+		return true, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []map[string]any{
+		{"id": float64(1), FileColumn: "feed.zip!/part.jsonl.gz", RowColumn: int64(1)},
+		{"id": float64(2), FileColumn: "feed.zip!/part.jsonl.gz", RowColumn: int64(3)},
+		{"id": "3", FileColumn: "feed.zip!/rows.csv", RowColumn: int64(2)},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Fatalf("expected %v, got %v", expected, records)
+	}
+}
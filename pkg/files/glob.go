@@ -0,0 +1,41 @@
+package files
+
+import (
+	"path"
+	"strings"
+)
+
+// GlobPrefix returns the part of a glob pattern before its first meta character; object
+// stores list by prefix and the remaining pattern is matched on the listed keys
+func GlobPrefix(pattern string) string {
+	if idx := strings.IndexAny(pattern, "*?[{"); idx >= 0 {
+		return pattern[:idx]
+	}
+	return pattern
+}
+
+// Match reports whether name matches the glob pattern; "**" matches across directories
+func Match(pattern, name string) bool {
+	if !strings.Contains(pattern, "**") {
+		matched, _ := path.Match(pattern, name)
+		return matched
+	}
+
+	prefix, suffix, _ := strings.Cut(pattern, "**")
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	rest := strings.TrimPrefix(name, prefix)
+	suffix = strings.TrimPrefix(suffix, "/")
+	// try the suffix against every trailing sub path
+	for {
+		if Match(suffix, rest) {
+			return true
+		}
+		idx := strings.Index(rest, "/")
+		if idx < 0 {
+			return false
+		}
+		rest = rest[idx+1:]
+	}
+}
@@ -0,0 +1,37 @@
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/goccy/go-json"
+)
+
+// maximum size of one jsonl line
+const maxLineSize = 64 * 1024 * 1024
+
+func readJSONL(reader io.Reader, file string, emit EmitFunc) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	rowNumber := int64(0)
+	for scanner.Scan() {
+		rowNumber++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		record := make(map[string]any)
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("failed to parse line %d of [%s]: %s", rowNumber, file, err)
+		}
+		proceed, err := emit(withLocation(record, file, rowNumber))
+		if err != nil || !proceed {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,52 @@
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func readParquet(reader io.Reader, file string, emit EmitFunc) error {
+	// parquet footer is at the end of the file; random access is required
+	var readerAt io.ReaderAt
+	var size int64
+	if osFile, ok := reader.(*os.File); ok {
+		info, err := osFile.Stat()
+		if err != nil {
+			return err
+		}
+		readerAt, size = osFile, info.Size()
+	} else {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return fmt.Errorf("failed to read parquet file[%s]: %s", file, err)
+		}
+		readerAt, size = bytes.NewReader(data), int64(len(data))
+	}
+
+	parquetFile, err := parquet.OpenFile(readerAt, size)
+	if err != nil {
+		return fmt.Errorf("failed to open parquet file[%s]: %s", file, err)
+	}
+	rows := parquet.NewReader(parquetFile)
+	defer rows.Close()
+
+	rowNumber := int64(0)
+	for {
+		record := make(map[string]any)
+		if err := rows.Read(&record); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read row %d of [%s]: %s", rowNumber+1, file, err)
+		}
+		rowNumber++
+
+		proceed, err := emit(withLocation(record, file, rowNumber))
+		if err != nil || !proceed {
+			return err
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package files
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func readXLSX(reader io.Reader, opts Options, rows func(next func() ([]string, error)) error) error {
+	workbook, err := excelize.OpenReader(reader)
+	if err != nil {
+		return fmt.Errorf("failed to open workbook: %s", err)
+	}
+	defer workbook.Close()
+
+	sheet := opts.Sheet
+	if sheet == "" {
+		sheet = workbook.GetSheetName(0)
+	}
+	sheetRows, err := workbook.Rows(sheet)
+	if err != nil {
+		return fmt.Errorf("failed to read sheet[%s]: %s", sheet, err)
+	}
+	defer sheetRows.Close()
+
+	return rows(func() ([]string, error) {
+		if !sheetRows.Next() {
+			if err := sheetRows.Error(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		return sheetRows.Columns()
+	})
+}
@@ -0,0 +1,60 @@
+package files
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// readZip reads every file of a zip archive; records are located as <archive>!/<entry>
+func readZip(reader io.Reader, file string, opts Options, emit EmitFunc) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read archive[%s]: %s", file, err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("failed to open archive[%s]: %s", file, err)
+	}
+
+	entries := make([]*zip.File, 0, len(archive.File))
+	for _, entry := range archive.File {
+		// skip directories and os metadata
+		if entry.FileInfo().IsDir() || strings.HasPrefix(entry.Name, "__MACOSX/") {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	stopped := false
+	stoppable := func(record map[string]any) (bool, error) {
+		proceed, err := emit(record)
+		stopped = !proceed
+		return proceed, err
+	}
+	for _, entry := range entries {
+		if err := readZipEntry(entry, file, opts, stoppable); err != nil {
+			return err
+		}
+		if stopped {
+			return nil
+		}
+	}
+	return nil
+}
+
+func readZipEntry(entry *zip.File, file string, opts Options, emit EmitFunc) error {
+	entryReader, err := entry.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open [%s] in archive[%s]: %s", entry.Name, file, err)
+	}
+	defer entryReader.Close()
+
+	return read(entryReader, fmt.Sprintf("%s!/%s", file, entry.Name), entry.Name, opts, emit)
+}
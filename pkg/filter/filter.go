@@ -0,0 +1,72 @@
+// Package filter evaluates row filters written in the expr language
+// (https://expr-lang.org) against records, so rows a stream doesn't need are never written
+package filter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+)
+
+// Filter is a compiled row filter; safe for concurrent use
+type Filter struct {
+	expression string
+	program    *vm.Program
+}
+
+// Compile compiles an expression evaluating to a bool over the columns of a record, e.g.
+// `status != "deleted" && created_at > '2023-01-01'`. Columns missing from a record are nil;
+// columns whose names aren't identifiers are read with $env["column-name"].
+func Compile(expression string) (*Filter, error) {
+	program, err := expr.Compile(expression, expr.AsBool(), expr.AllowUndefinedVariables())
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter[%s]: %s", expression, err)
+	}
+	return &Filter{expression: expression, program: program}, nil
+}
+
+func (f *Filter) String() string {
+	return f.expression
+}
+
+// Match reports whether record passes the filter. Timestamps are compared as RFC 3339
+// strings, so they can be compared with date literals.
+func (f *Filter) Match(record map[string]any) (bool, error) {
+	matched, err := vm.Run(f.program, env(record))
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate filter[%s]: %s", f.expression, err)
+	}
+	return matched.(bool), nil
+}
+
+// env returns record with timestamps formatted; record is copied only when it has any
+func env(record map[string]any) map[string]any {
+	var formatted map[string]any
+	for column, value := range record {
+		var timestamp time.Time
+		switch v := value.(type) {
+		case time.Time:
+			timestamp = v
+		case *time.Time:
+			if v == nil {
+				continue
+			}
+			timestamp = *v
+		default:
+			continue
+		}
+		if formatted == nil {
+			formatted = make(map[string]any, len(record))
+			for key, value := range record {
+				formatted[key] = value
+			}
+		}
+		formatted[column] = timestamp.UTC().Format(time.RFC3339Nano)
+	}
+	if formatted == nil {
+		return record
+	}
+	return formatted
+}
@@ -0,0 +1,48 @@
+package filter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilter(t *testing.T) {
+	if _, err := Compile(`status !=`); err == nil {
+		t.Fatal("expected a syntax error")
+	}
+	if _, err := Compile(`status`); err != nil {
+		t.Fatalf("undefined types are checked at runtime: %s", err)
+	}
+
+	filter, err := Compile(`status != "deleted" && created_at > '2023-01-01' && $env["order-count"] >= 2`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		record  map[string]any
+		matched bool
+	}{
+		{map[string]any{"status": "active", "created_at": created, "order-count": int32(2)}, true},
+		{map[string]any{"status": "deleted", "created_at": created, "order-count": 5}, false},
+		{map[string]any{"status": "active", "created_at": time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), "order-count": 5}, false},
+		{map[string]any{"status": "active", "created_at": &created, "order-count": 1.5}, false},
+		// missing columns are nil
+		{map[string]any{"created_at": "2024-01-01", "order-count": 3}, true},
+	} {
+		matched, err := filter.Match(test.record)
+		if err != nil {
+			t.Fatalf("record %v: %s", test.record, err)
+		}
+		if matched != test.matched {
+			t.Fatalf("record %v: expected %t, got %t", test.record, test.matched, matched)
+		}
+	}
+
+	record := map[string]any{"created_at": created}
+	if _, err := filter.Match(record); err == nil {
+		t.Fatal("expected an error comparing a missing number")
+	}
+	if _, ok := record["created_at"].(time.Time); !ok {
+		t.Fatal("expected the record to be left as is")
+	}
+}
@@ -0,0 +1,102 @@
+// Package frames batches json records into zstd compressed frames prefixed with their length,
+// so processes consuming the records of a sync over a pipe spend less on framing than with a
+// json object per line. A frame is the big endian uint32 length of its payload followed by the
+// payload: a zstd frame of the records of the batch, each ended by a newline.
+package frames
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// MaxFrameSize bounds the decompressed size of a frame readers accept
+const MaxFrameSize = 256 << 20
+
+// Writer writes batches of records as frames; not safe for concurrent use
+type Writer struct {
+	w       io.Writer
+	encoder *zstd.Encoder
+	frame   []byte
+}
+
+func NewWriter(w io.Writer) (*Writer, error) {
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{w: w, encoder: encoder}, nil
+}
+
+// WriteFrame writes records, json documents without newlines, as one frame
+func (w *Writer) WriteFrame(records [][]byte) error {
+	var batch bytes.Buffer
+	for _, record := range records {
+		batch.Write(record)
+		batch.WriteByte('\n')
+	}
+	if batch.Len() > MaxFrameSize {
+		return fmt.Errorf("frame of %d bytes exceeds %d bytes", batch.Len(), MaxFrameSize)
+	}
+	w.frame = append(w.frame[:0], 0, 0, 0, 0)
+	w.frame = w.encoder.EncodeAll(batch.Bytes(), w.frame)
+	binary.BigEndian.PutUint32(w.frame, uint32(len(w.frame)-4))
+	_, err := w.w.Write(w.frame)
+	return err
+}
+
+func (w *Writer) Close() error {
+	return w.encoder.Close()
+}
+
+// Reader reads the records of frames written by Writer
+type Reader struct {
+	r       io.Reader
+	decoder *zstd.Decoder
+	payload []byte
+}
+
+func NewReader(r io.Reader) (*Reader, error) {
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(MaxFrameSize))
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{r: r, decoder: decoder}, nil
+}
+
+// Next returns the records of the next frame; io.EOF once no frames are left
+func (r *Reader) Next() ([][]byte, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(r.r, prefix[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("truncated frame length")
+		}
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(prefix[:])
+	if size > MaxFrameSize {
+		return nil, fmt.Errorf("frame of %d bytes exceeds %d bytes", size, MaxFrameSize)
+	}
+	if cap(r.payload) < int(size) {
+		r.payload = make([]byte, size)
+	}
+	r.payload = r.payload[:size]
+	if _, err := io.ReadFull(r.r, r.payload); err != nil {
+		return nil, fmt.Errorf("truncated frame: %s", err)
+	}
+	batch, err := r.decoder.DecodeAll(r.payload, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress frame: %s", err)
+	}
+	if len(batch) == 0 {
+		return nil, nil
+	}
+	return bytes.Split(bytes.TrimSuffix(batch, []byte{'\n'}), []byte{'\n'}), nil
+}
+
+func (r *Reader) Close() {
+	r.decoder.Close()
+}
@@ -0,0 +1,67 @@
+package frames
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestFramesRoundTrip(t *testing.T) {
+	var stream bytes.Buffer
+	writer, err := NewWriter(&stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batches := [][][]byte{
+		{[]byte(`{"id":1}`), []byte(`{"id":2}`)},
+		{},
+		{[]byte(`{"id":3,"name":"narrow"}`)},
+	}
+	for _, batch := range batches {
+		if err := writer.WriteFrame(batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewReader(&stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	for _, batch := range batches {
+		records, err := reader.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprintf("%s", records) != fmt.Sprintf("%s", batch) {
+			t.Fatalf("expected records %s, got %s", batch, records)
+		}
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Fatalf("expected io.EOF after the last frame, got %v", err)
+	}
+}
+
+func TestFramesCompressNarrowRecords(t *testing.T) {
+	var stream bytes.Buffer
+	writer, err := NewWriter(&stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := make([][]byte, 1000)
+	lines := 0
+	for idx := range records {
+		records[idx] = []byte(fmt.Sprintf(`{"stream":"app.events","olake_id":"%d","data":{"id":%d}}`, idx, idx))
+		lines += len(records[idx]) + 1
+	}
+	if err := writer.WriteFrame(records); err != nil {
+		t.Fatal(err)
+	}
+	if stream.Len()*4 > lines {
+		t.Fatalf("expected a frame well below the %d bytes of json lines, got %d", lines, stream.Len())
+	}
+}
@@ -0,0 +1,186 @@
+// Package geo reads geometries in WKB (ISO and PostGIS EWKB, raw or hex encoded), WKT (with an
+// optional SRID=<srid>; prefix) and GeoJSON, and writes them back in any of these encodings
+package geo
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// Type is a geometry type with its WKB code
+type Type uint32
+
+const (
+	Point              Type = 1
+	LineString         Type = 2
+	Polygon            Type = 3
+	MultiPoint         Type = 4
+	MultiLineString    Type = 5
+	MultiPolygon       Type = 6
+	GeometryCollection Type = 7
+)
+
+var typeNames = map[Type]string{
+	Point:              "Point",
+	LineString:         "LineString",
+	Polygon:            "Polygon",
+	MultiPoint:         "MultiPoint",
+	MultiLineString:    "MultiLineString",
+	MultiPolygon:       "MultiPolygon",
+	GeometryCollection: "GeometryCollection",
+}
+
+func (t Type) String() string {
+	if name, found := typeNames[t]; found {
+		return name
+	}
+	return fmt.Sprintf("Type(%d)", uint32(t))
+}
+
+// typeNamed returns the type of a WKT or GeoJSON name, matched case insensitively
+func typeNamed(name string) (Type, bool) {
+	for typ, typeName := range typeNames {
+		if strings.EqualFold(typeName, name) {
+			return typ, true
+		}
+	}
+	return 0, false
+}
+
+// Geometry is a decoded geometry; positions hold x and y followed by z and then m when the
+// geometry has them
+type Geometry struct {
+	Type Type
+	// spatial reference id; 0 when unknown
+	SRID int
+	HasZ bool
+	HasM bool
+	// Point; empty when nil
+	Coordinates []float64
+	// LineString
+	Positions [][]float64
+	// Polygon; the first ring is the exterior
+	Rings [][][]float64
+	// MultiPoint, MultiLineString, MultiPolygon and GeometryCollection
+	Geometries []*Geometry
+}
+
+// dimensions returns the number of values of every position
+func (g *Geometry) dimensions() int {
+	dimensions := 2
+	if g.HasZ {
+		dimensions++
+	}
+	if g.HasM {
+		dimensions++
+	}
+	return dimensions
+}
+
+// Format is an encoding of geometries
+type Format string
+
+const (
+	FormatWKB     Format = "wkb"
+	FormatWKT     Format = "wkt"
+	FormatGeoJSON Format = "geojson"
+)
+
+// Detect returns the encoding of a geometry value; empty when v doesn't look like a geometry.
+// Byte slices are WKB when they start with a byte order mark and text otherwise; strings are
+// hex encoded WKB, GeoJSON objects or WKT
+func Detect(v any) Format {
+	switch value := v.(type) {
+	case []byte:
+		if len(value) >= 5 && (value[0] == 0 || value[0] == 1) {
+			return FormatWKB
+		}
+		return detectText(string(value))
+	case string:
+		return detectText(value)
+	case map[string]any:
+		if _, found := value["type"]; found {
+			return FormatGeoJSON
+		}
+	}
+	return ""
+}
+
+func detectText(text string) Format {
+	text = strings.TrimSpace(text)
+	switch {
+	case text == "":
+		return ""
+	case strings.HasPrefix(text, "{"):
+		return FormatGeoJSON
+	case isHexWKB(text):
+		return FormatWKB
+	}
+	upper := strings.ToUpper(text)
+	if strings.HasPrefix(upper, "SRID=") {
+		return FormatWKT
+	}
+	for _, name := range typeNames {
+		if strings.HasPrefix(upper, strings.ToUpper(name)) {
+			return FormatWKT
+		}
+	}
+	return ""
+}
+
+func isHexWKB(text string) bool {
+	if len(text) < 10 || len(text)%2 != 0 || (!strings.HasPrefix(text, "00") && !strings.HasPrefix(text, "01")) {
+		return false
+	}
+	for _, char := range text {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", char) {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse decodes a geometry in any of the detected encodings
+func Parse(v any) (*Geometry, error) {
+	switch Detect(v) {
+	case FormatWKB:
+		data, isBytes := v.([]byte)
+		// hex encoded wkb may be read as bytes, as postgis columns are by lib/pq
+		if !isBytes || isHexWKB(strings.TrimSpace(string(data))) {
+			text, isString := v.(string)
+			if !isString {
+				text = string(data)
+			}
+			decoded, err := hex.DecodeString(strings.TrimSpace(text))
+			if err != nil {
+				return nil, fmt.Errorf("invalid hex encoded wkb: %s", err)
+			}
+			data = decoded
+		}
+		return ParseWKB(data)
+	case FormatWKT:
+		text, isString := v.(string)
+		if !isString {
+			text = string(v.([]byte))
+		}
+		return ParseWKT(text)
+	case FormatGeoJSON:
+		object, isObject := v.(map[string]any)
+		if !isObject {
+			var text []byte
+			if str, isString := v.(string); isString {
+				text = []byte(str)
+			} else {
+				text = v.([]byte)
+			}
+			if err := json.Unmarshal(text, &object); err != nil {
+				return nil, fmt.Errorf("invalid geojson: %s", err)
+			}
+		}
+		return ParseGeoJSON(object)
+	}
+	return nil, fmt.Errorf("value of type %T is not a wkb, wkt or geojson geometry", v)
+}
@@ -0,0 +1,76 @@
+package geo
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	for _, wkt := range []string{
+		"POINT (1 2)",
+		"POINT Z (1 2 3)",
+		"POINT EMPTY",
+		"SRID=4326;LINESTRING (30 10, 10 30, 40 40)",
+		"POLYGON ((35 10, 45 45, 15 40, 10 20, 35 10), (20 30, 35 35, 30 20, 20 30))",
+		"MULTIPOINT ((10 40), (40 30))",
+		"MULTILINESTRING ((10 10, 20 20), (40 40, 30 30))",
+		"MULTIPOLYGON (((30 20, 45 40, 10 40, 30 20)), ((15 5, 40 10, 10 20, 15 5)))",
+		"GEOMETRYCOLLECTION (POINT (40 10), LINESTRING (10 10, 20 20))",
+		"LINESTRING M (1 2 5, 3 4 6)",
+	} {
+		geometry, err := ParseWKT(wkt)
+		if err != nil {
+			t.Fatalf("%s: %s", wkt, err)
+		}
+		if encoded := geometry.WKT(); encoded != wkt {
+			t.Fatalf("expected %s, got %s", wkt, encoded)
+		}
+		decoded, err := ParseWKB(geometry.WKB())
+		if err != nil {
+			t.Fatalf("%s: %s", wkt, err)
+		}
+		if !reflect.DeepEqual(decoded, geometry) {
+			t.Fatalf("%s: wkb round trip gave %s", wkt, decoded.WKT())
+		}
+		if geometry.HasM {
+			continue
+		}
+		fromJSON, err := ParseGeoJSON(geometry.GeoJSON())
+		if err != nil {
+			t.Fatalf("%s: %s", wkt, err)
+		}
+		fromJSON.SRID = geometry.SRID
+		if fromJSON.WKT() != wkt {
+			t.Fatalf("%s: geojson round trip gave %s", wkt, fromJSON.WKT())
+		}
+	}
+}
+
+func TestParseDetects(t *testing.T) {
+	// SRID=4326;POINT(1 2) as PostGIS returns it in text
+	ewkb := "0101000020E6100000000000000000F03F0000000000000040"
+	raw, _ := hex.DecodeString(ewkb)
+	for _, value := range []any{
+		ewkb,
+		[]byte(ewkb),
+		raw,
+		"SRID=4326;POINT(1 2)",
+		`{"type": "Point", "coordinates": [1, 2]}`,
+		map[string]any{"type": "Point", "coordinates": []any{1.0, 2.0}},
+	} {
+		geometry, err := Parse(value)
+		if err != nil {
+			t.Fatalf("%v: %s", value, err)
+		}
+		geometry.SRID = 4326
+		if wkt := geometry.WKT(); wkt != "SRID=4326;POINT (1 2)" {
+			t.Fatalf("%v: unexpected geometry %s", value, wkt)
+		}
+	}
+	for _, value := range []any{"hello", 12, "POINT (1)", []byte{1, 99, 0, 0, 0}} {
+		if _, err := Parse(value); err == nil {
+			t.Fatalf("expected %v to be rejected", value)
+		}
+	}
+}
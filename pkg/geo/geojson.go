@@ -0,0 +1,200 @@
+package geo
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/goccy/go-json"
+)
+
+// ParseGeoJSON decodes a GeoJSON geometry object; a third coordinate is z
+func ParseGeoJSON(object map[string]any) (*Geometry, error) {
+	name, _ := object["type"].(string)
+	typ, found := typeNamed(name)
+	if !found {
+		return nil, fmt.Errorf("invalid geojson geometry type %q", name)
+	}
+	geometry := &Geometry{Type: typ}
+	if typ == GeometryCollection {
+		members, _ := object["geometries"].([]any)
+		for _, member := range members {
+			memberObject, ok := member.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid geojson geometry collection member %v", member)
+			}
+			child, err := ParseGeoJSON(memberObject)
+			if err != nil {
+				return nil, err
+			}
+			geometry.Geometries = append(geometry.Geometries, child)
+		}
+		return geometry, nil
+	}
+
+	coordinates := object["coordinates"]
+	var err error
+	switch typ {
+	case Point:
+		if list, _ := coordinates.([]any); len(list) > 0 {
+			geometry.Coordinates, err = geoJSONPosition(coordinates)
+		}
+	case LineString:
+		geometry.Positions, err = geoJSONPositions(coordinates)
+	case Polygon:
+		geometry.Rings, err = geoJSONRings(coordinates)
+	case MultiPoint:
+		var positions [][]float64
+		positions, err = geoJSONPositions(coordinates)
+		for _, position := range positions {
+			geometry.Geometries = append(geometry.Geometries, &Geometry{Type: Point, Coordinates: position})
+		}
+	case MultiLineString:
+		var lines [][][]float64
+		lines, err = geoJSONRings(coordinates)
+		for _, line := range lines {
+			geometry.Geometries = append(geometry.Geometries, &Geometry{Type: LineString, Positions: line})
+		}
+	case MultiPolygon:
+		list, _ := coordinates.([]any)
+		for _, polygon := range list {
+			rings, ringsErr := geoJSONRings(polygon)
+			if ringsErr != nil {
+				return nil, ringsErr
+			}
+			geometry.Geometries = append(geometry.Geometries, &Geometry{Type: Polygon, Rings: rings})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	geometry.HasZ = geometry.firstPositionSize() == 3
+	for _, child := range geometry.Geometries {
+		child.HasZ = geometry.HasZ
+	}
+	return geometry, nil
+}
+
+// firstPositionSize returns the number of coordinates of the first position; 0 when empty
+func (g *Geometry) firstPositionSize() int {
+	switch {
+	case g.Coordinates != nil:
+		return len(g.Coordinates)
+	case len(g.Positions) > 0:
+		return len(g.Positions[0])
+	case len(g.Rings) > 0 && len(g.Rings[0]) > 0:
+		return len(g.Rings[0][0])
+	case len(g.Geometries) > 0:
+		return g.Geometries[0].firstPositionSize()
+	}
+	return 0
+}
+
+func geoJSONPosition(value any) ([]float64, error) {
+	list, ok := value.([]any)
+	if !ok || len(list) < 2 || len(list) > 3 {
+		return nil, fmt.Errorf("invalid geojson position %v", value)
+	}
+	position := make([]float64, len(list))
+	for idx, coordinate := range list {
+		switch number := coordinate.(type) {
+		case float64:
+			position[idx] = number
+		case int:
+			position[idx] = float64(number)
+		case int64:
+			position[idx] = float64(number)
+		case json.Number:
+			parsed, err := strconv.ParseFloat(string(number), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid geojson coordinate %v", coordinate)
+			}
+			position[idx] = parsed
+		default:
+			return nil, fmt.Errorf("invalid geojson coordinate %v", coordinate)
+		}
+	}
+	return position, nil
+}
+
+func geoJSONPositions(value any) ([][]float64, error) {
+	list, _ := value.([]any)
+	positions := make([][]float64, 0, len(list))
+	for _, element := range list {
+		position, err := geoJSONPosition(element)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, position)
+	}
+	return positions, nil
+}
+
+func geoJSONRings(value any) ([][][]float64, error) {
+	list, _ := value.([]any)
+	rings := make([][][]float64, 0, len(list))
+	for _, element := range list {
+		ring, err := geoJSONPositions(element)
+		if err != nil {
+			return nil, err
+		}
+		rings = append(rings, ring)
+	}
+	return rings, nil
+}
+
+// GeoJSON encodes the geometry as a GeoJSON geometry object; m values and the SRID, which
+// GeoJSON has no place for, are left out
+func (g *Geometry) GeoJSON() map[string]any {
+	object := map[string]any{"type": g.Type.String()}
+	dimensions := 2
+	if g.HasZ {
+		dimensions = 3
+	}
+	position := func(values []float64) []any {
+		// z comes right after x and y, m last
+		result := make([]any, 0, dimensions)
+		for idx := 0; idx < dimensions && idx < len(values); idx++ {
+			result = append(result, values[idx])
+		}
+		return result
+	}
+	positions := func(values [][]float64) []any {
+		result := make([]any, 0, len(values))
+		for _, value := range values {
+			result = append(result, position(value))
+		}
+		return result
+	}
+	rings := func(values [][][]float64) []any {
+		result := make([]any, 0, len(values))
+		for _, value := range values {
+			result = append(result, positions(value))
+		}
+		return result
+	}
+	switch g.Type {
+	case Point:
+		if g.Coordinates == nil {
+			object["coordinates"] = []any{}
+		} else {
+			object["coordinates"] = position(g.Coordinates)
+		}
+	case LineString:
+		object["coordinates"] = positions(g.Positions)
+	case Polygon:
+		object["coordinates"] = rings(g.Rings)
+	case GeometryCollection:
+		members := make([]any, 0, len(g.Geometries))
+		for _, child := range g.Geometries {
+			members = append(members, child.GeoJSON())
+		}
+		object["geometries"] = members
+	default:
+		coordinates := make([]any, 0, len(g.Geometries))
+		for _, child := range g.Geometries {
+			coordinates = append(coordinates, child.GeoJSON()["coordinates"])
+		}
+		object["coordinates"] = coordinates
+	}
+	return object
+}
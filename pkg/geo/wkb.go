@@ -0,0 +1,262 @@
+package geo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// flags of PostGIS extended wkb
+const (
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+// ParseWKB decodes ISO wkb and PostGIS extended wkb
+func ParseWKB(data []byte) (*Geometry, error) {
+	reader := &wkbReader{data: data}
+	geometry, err := reader.geometry()
+	if err != nil {
+		return nil, fmt.Errorf("invalid wkb: %s", err)
+	}
+	if reader.offset != len(data) {
+		return nil, fmt.Errorf("invalid wkb: %d trailing bytes", len(data)-reader.offset)
+	}
+	return geometry, nil
+}
+
+type wkbReader struct {
+	data   []byte
+	offset int
+	order  binary.ByteOrder
+}
+
+func (r *wkbReader) read(size int) ([]byte, error) {
+	if r.offset+size > len(r.data) {
+		return nil, fmt.Errorf("unexpected end at byte %d", r.offset)
+	}
+	chunk := r.data[r.offset : r.offset+size]
+	r.offset += size
+	return chunk, nil
+}
+
+func (r *wkbReader) uint32() (uint32, error) {
+	chunk, err := r.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return r.order.Uint32(chunk), nil
+}
+
+// count reads a number of elements, bounded by the bytes left so corrupt input can't
+// allocate huge slices
+func (r *wkbReader) count(minSize int) (int, error) {
+	count, err := r.uint32()
+	if err != nil {
+		return 0, err
+	}
+	if int64(count)*int64(minSize) > int64(len(r.data)-r.offset) {
+		return 0, fmt.Errorf("count %d exceeds the data left", count)
+	}
+	return int(count), nil
+}
+
+func (r *wkbReader) position(dimensions int) ([]float64, error) {
+	position := make([]float64, dimensions)
+	for idx := range position {
+		chunk, err := r.read(8)
+		if err != nil {
+			return nil, err
+		}
+		position[idx] = math.Float64frombits(r.order.Uint64(chunk))
+	}
+	return position, nil
+}
+
+func (r *wkbReader) positions(dimensions int) ([][]float64, error) {
+	count, err := r.count(8 * dimensions)
+	if err != nil {
+		return nil, err
+	}
+	positions := make([][]float64, count)
+	for idx := range positions {
+		if positions[idx], err = r.position(dimensions); err != nil {
+			return nil, err
+		}
+	}
+	return positions, nil
+}
+
+func (r *wkbReader) geometry() (*Geometry, error) {
+	mark, err := r.read(1)
+	if err != nil {
+		return nil, err
+	}
+	switch mark[0] {
+	case 0:
+		r.order = binary.BigEndian
+	case 1:
+		r.order = binary.LittleEndian
+	default:
+		return nil, fmt.Errorf("invalid byte order %d", mark[0])
+	}
+	code, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	geometry := &Geometry{
+		HasZ: code&ewkbZ != 0,
+		HasM: code&ewkbM != 0,
+	}
+	if code&ewkbSRID != 0 {
+		srid, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		geometry.SRID = int(srid)
+	}
+	code &^= ewkbZ | ewkbM | ewkbSRID
+	// iso wkb adds 1000 for z, 2000 for m and 3000 for both
+	switch code / 1000 {
+	case 1:
+		geometry.HasZ = true
+	case 2:
+		geometry.HasM = true
+	case 3:
+		geometry.HasZ, geometry.HasM = true, true
+	}
+	geometry.Type = Type(code % 1000)
+	dimensions := geometry.dimensions()
+
+	switch geometry.Type {
+	case Point:
+		position, err := r.position(dimensions)
+		if err != nil {
+			return nil, err
+		}
+		// empty points are written with NaN coordinates
+		if !math.IsNaN(position[0]) {
+			geometry.Coordinates = position
+		}
+	case LineString:
+		if geometry.Positions, err = r.positions(dimensions); err != nil {
+			return nil, err
+		}
+	case Polygon:
+		count, err := r.count(4)
+		if err != nil {
+			return nil, err
+		}
+		geometry.Rings = make([][][]float64, count)
+		for idx := range geometry.Rings {
+			if geometry.Rings[idx], err = r.positions(dimensions); err != nil {
+				return nil, err
+			}
+		}
+	case MultiPoint, MultiLineString, MultiPolygon, GeometryCollection:
+		count, err := r.count(5)
+		if err != nil {
+			return nil, err
+		}
+		order := r.order
+		geometry.Geometries = make([]*Geometry, count)
+		for idx := range geometry.Geometries {
+			if geometry.Geometries[idx], err = r.geometry(); err != nil {
+				return nil, err
+			}
+			r.order = order
+		}
+	default:
+		return nil, fmt.Errorf("unsupported geometry type %d", code)
+	}
+	return geometry, nil
+}
+
+// WKB encodes the geometry in little endian ISO wkb, or in PostGIS extended wkb when it has
+// an SRID so the SRID is kept
+func (g *Geometry) WKB() []byte {
+	var buffer bytes.Buffer
+	g.writeWKB(&buffer, g.SRID != 0)
+	return buffer.Bytes()
+}
+
+func (g *Geometry) writeWKB(buffer *bytes.Buffer, extended bool) {
+	buffer.WriteByte(1)
+	code := uint32(g.Type)
+	if extended {
+		if g.HasZ {
+			code |= ewkbZ
+		}
+		if g.HasM {
+			code |= ewkbM
+		}
+		if g.SRID != 0 {
+			code |= ewkbSRID
+		}
+	} else {
+		switch {
+		case g.HasZ && g.HasM:
+			code += 3000
+		case g.HasZ:
+			code += 1000
+		case g.HasM:
+			code += 2000
+		}
+	}
+	writeUint32(buffer, code)
+	if extended && g.SRID != 0 {
+		//nolint:gosec,G115
+		writeUint32(buffer, uint32(g.SRID))
+	}
+
+	dimensions := g.dimensions()
+	switch g.Type {
+	case Point:
+		position := g.Coordinates
+		if position == nil {
+			position = make([]float64, dimensions)
+			for idx := range position {
+				position[idx] = math.NaN()
+			}
+		}
+		writePosition(buffer, position)
+	case LineString:
+		writePositions(buffer, g.Positions)
+	case Polygon:
+		writeUint32(buffer, uint32(len(g.Rings)))
+		for _, ring := range g.Rings {
+			writePositions(buffer, ring)
+		}
+	default:
+		writeUint32(buffer, uint32(len(g.Geometries)))
+		for _, child := range g.Geometries {
+			// children carry their own header; the srid is only written once
+			copied := *child
+			copied.SRID = 0
+			copied.writeWKB(buffer, extended)
+		}
+	}
+}
+
+func writeUint32(buffer *bytes.Buffer, value uint32) {
+	var chunk [4]byte
+	binary.LittleEndian.PutUint32(chunk[:], value)
+	buffer.Write(chunk[:])
+}
+
+func writePosition(buffer *bytes.Buffer, position []float64) {
+	var chunk [8]byte
+	for _, value := range position {
+		binary.LittleEndian.PutUint64(chunk[:], math.Float64bits(value))
+		buffer.Write(chunk[:])
+	}
+}
+
+func writePositions(buffer *bytes.Buffer, positions [][]float64) {
+	writeUint32(buffer, uint32(len(positions)))
+	for _, position := range positions {
+		writePosition(buffer, position)
+	}
+}
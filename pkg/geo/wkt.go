@@ -0,0 +1,335 @@
+package geo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseWKT decodes wkt, with an optional SRID=<srid>; prefix as written by PostGIS
+func ParseWKT(text string) (*Geometry, error) {
+	srid := 0
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(strings.ToUpper(text), "SRID=") {
+		prefix, rest, found := strings.Cut(text, ";")
+		if !found {
+			return nil, fmt.Errorf("invalid wkt: srid without geometry")
+		}
+		parsed, err := strconv.Atoi(strings.TrimSpace(prefix[len("SRID="):]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid wkt srid: %s", err)
+		}
+		srid, text = parsed, rest
+	}
+	parser := &wktParser{tokens: tokenizeWKT(text)}
+	geometry, err := parser.geometry()
+	if err != nil {
+		return nil, fmt.Errorf("invalid wkt: %s", err)
+	}
+	if parser.offset != len(parser.tokens) {
+		return nil, fmt.Errorf("invalid wkt: unexpected %q", parser.tokens[parser.offset])
+	}
+	geometry.SRID = srid
+	return geometry, nil
+}
+
+// tokenizeWKT splits text into words, numbers and the punctuation ( ) ,
+func tokenizeWKT(text string) []string {
+	var tokens []string
+	start := -1
+	for idx, char := range text {
+		separator := unicode.IsSpace(char) || char == '(' || char == ')' || char == ','
+		if separator {
+			if start >= 0 {
+				tokens = append(tokens, text[start:idx])
+				start = -1
+			}
+			if !unicode.IsSpace(char) {
+				tokens = append(tokens, string(char))
+			}
+		} else if start < 0 {
+			start = idx
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, text[start:])
+	}
+	return tokens
+}
+
+type wktParser struct {
+	tokens []string
+	offset int
+}
+
+func (p *wktParser) peek() string {
+	if p.offset < len(p.tokens) {
+		return p.tokens[p.offset]
+	}
+	return ""
+}
+
+func (p *wktParser) next() string {
+	token := p.peek()
+	p.offset++
+	return token
+}
+
+func (p *wktParser) expect(token string) error {
+	if next := p.next(); next != token {
+		return fmt.Errorf("expected %q, got %q", token, next)
+	}
+	return nil
+}
+
+func (p *wktParser) geometry() (*Geometry, error) {
+	name := p.next()
+	typ, found := typeNamed(name)
+	if !found {
+		return nil, fmt.Errorf("unknown geometry type %q", name)
+	}
+	geometry := &Geometry{Type: typ}
+	switch strings.ToUpper(p.peek()) {
+	case "Z":
+		geometry.HasZ = true
+		p.next()
+	case "M":
+		geometry.HasM = true
+		p.next()
+	case "ZM":
+		geometry.HasZ, geometry.HasM = true, true
+		p.next()
+	}
+	if strings.EqualFold(p.peek(), "EMPTY") {
+		p.next()
+		return geometry, nil
+	}
+	// the dimensions of untagged geometries follow from their first position
+	tagged := geometry.HasZ || geometry.HasM
+	var err error
+	switch typ {
+	case Point:
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		if geometry.Coordinates, err = p.position(geometry, &tagged); err != nil {
+			return nil, err
+		}
+		return geometry, p.expect(")")
+	case LineString:
+		geometry.Positions, err = p.positions(geometry, &tagged)
+	case Polygon:
+		geometry.Rings, err = p.rings(geometry, &tagged)
+	case MultiPoint:
+		err = p.list(func() error {
+			// points may or may not be wrapped in parentheses
+			wrapped := p.peek() == "("
+			if wrapped {
+				p.next()
+			}
+			position, err := p.position(geometry, &tagged)
+			if err != nil {
+				return err
+			}
+			geometry.Geometries = append(geometry.Geometries, &Geometry{Type: Point, Coordinates: position})
+			if wrapped {
+				return p.expect(")")
+			}
+			return nil
+		})
+	case MultiLineString:
+		err = p.list(func() error {
+			positions, err := p.positions(geometry, &tagged)
+			geometry.Geometries = append(geometry.Geometries, &Geometry{Type: LineString, Positions: positions})
+			return err
+		})
+	case MultiPolygon:
+		err = p.list(func() error {
+			rings, err := p.rings(geometry, &tagged)
+			geometry.Geometries = append(geometry.Geometries, &Geometry{Type: Polygon, Rings: rings})
+			return err
+		})
+	case GeometryCollection:
+		err = p.list(func() error {
+			child, err := p.geometry()
+			if err != nil {
+				return err
+			}
+			geometry.Geometries = append(geometry.Geometries, child)
+			return nil
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	// members of multi geometries share the dimensions of the collection
+	if typ != GeometryCollection {
+		for _, child := range geometry.Geometries {
+			child.HasZ, child.HasM = geometry.HasZ, geometry.HasM
+		}
+	}
+	return geometry, nil
+}
+
+// list parses a parenthesized, comma separated list of elements
+func (p *wktParser) list(element func() error) error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for {
+		if err := element(); err != nil {
+			return err
+		}
+		switch token := p.next(); token {
+		case ",":
+		case ")":
+			return nil
+		default:
+			return fmt.Errorf("expected \",\" or \")\", got %q", token)
+		}
+	}
+}
+
+func (p *wktParser) positions(geometry *Geometry, tagged *bool) ([][]float64, error) {
+	var positions [][]float64
+	err := p.list(func() error {
+		position, err := p.position(geometry, tagged)
+		positions = append(positions, position)
+		return err
+	})
+	return positions, err
+}
+
+func (p *wktParser) rings(geometry *Geometry, tagged *bool) ([][][]float64, error) {
+	var rings [][][]float64
+	err := p.list(func() error {
+		ring, err := p.positions(geometry, tagged)
+		rings = append(rings, ring)
+		return err
+	})
+	return rings, err
+}
+
+func (p *wktParser) position(geometry *Geometry, tagged *bool) ([]float64, error) {
+	var position []float64
+	for {
+		token := p.peek()
+		if token == "" || token == "," || token == ")" || token == "(" {
+			break
+		}
+		value, err := strconv.ParseFloat(p.next(), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid coordinate %q", token)
+		}
+		position = append(position, value)
+	}
+	if !*tagged {
+		switch len(position) {
+		case 3:
+			geometry.HasZ = true
+		case 4:
+			geometry.HasZ, geometry.HasM = true, true
+		}
+		*tagged = true
+	}
+	if len(position) != geometry.dimensions() {
+		return nil, fmt.Errorf("expected %d coordinates, got %d", geometry.dimensions(), len(position))
+	}
+	return position, nil
+}
+
+// WKT encodes the geometry in wkt, prefixed with SRID=<srid>; when it has an SRID
+func (g *Geometry) WKT() string {
+	var builder strings.Builder
+	if g.SRID != 0 {
+		fmt.Fprintf(&builder, "SRID=%d;", g.SRID)
+	}
+	g.writeWKT(&builder)
+	return builder.String()
+}
+
+func (g *Geometry) writeWKT(builder *strings.Builder) {
+	builder.WriteString(strings.ToUpper(g.Type.String()))
+	switch {
+	case g.HasZ && g.HasM:
+		builder.WriteString(" ZM")
+	case g.HasZ:
+		builder.WriteString(" Z")
+	case g.HasM:
+		builder.WriteString(" M")
+	}
+	if g.empty() {
+		builder.WriteString(" EMPTY")
+		return
+	}
+	builder.WriteByte(' ')
+	g.writeWKTBody(builder)
+}
+
+func (g *Geometry) empty() bool {
+	switch g.Type {
+	case Point:
+		return g.Coordinates == nil
+	case LineString:
+		return len(g.Positions) == 0
+	case Polygon:
+		return len(g.Rings) == 0
+	default:
+		return len(g.Geometries) == 0
+	}
+}
+
+// writeWKTBody writes the parenthesized coordinates of the geometry
+func (g *Geometry) writeWKTBody(builder *strings.Builder) {
+	switch g.Type {
+	case Point:
+		builder.WriteByte('(')
+		writeWKTPosition(builder, g.Coordinates)
+		builder.WriteByte(')')
+	case LineString:
+		writeWKTPositions(builder, g.Positions)
+	case Polygon:
+		builder.WriteByte('(')
+		for idx, ring := range g.Rings {
+			if idx > 0 {
+				builder.WriteString(", ")
+			}
+			writeWKTPositions(builder, ring)
+		}
+		builder.WriteByte(')')
+	default:
+		builder.WriteByte('(')
+		for idx, child := range g.Geometries {
+			if idx > 0 {
+				builder.WriteString(", ")
+			}
+			if g.Type == GeometryCollection {
+				child.writeWKT(builder)
+			} else {
+				child.writeWKTBody(builder)
+			}
+		}
+		builder.WriteByte(')')
+	}
+}
+
+func writeWKTPosition(builder *strings.Builder, position []float64) {
+	for idx, value := range position {
+		if idx > 0 {
+			builder.WriteByte(' ')
+		}
+		builder.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	}
+}
+
+func writeWKTPositions(builder *strings.Builder, positions [][]float64) {
+	builder.WriteByte('(')
+	for idx, position := range positions {
+		if idx > 0 {
+			builder.WriteString(", ")
+		}
+		writeWKTPosition(builder, position)
+	}
+	builder.WriteByte(')')
+}
@@ -0,0 +1,68 @@
+// Package httpclient builds the http clients of API based drivers from a stack of
+// http.RoundTripper middleware: logging with redaction, retries, per-host rate limits and
+// latency metrics
+package httpclient
+
+import (
+	"net/http"
+	"time"
+)
+
+// Middleware wraps a round tripper, e.g. to retry or log its requests
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to http.RoundTripper
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Chain wraps base with the middlewares; the first middleware sees the request first
+func Chain(base http.RoundTripper, middlewares ...Middleware) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		base = middlewares[i](base)
+	}
+	return base
+}
+
+type Config struct {
+	// timeout of a request including its retries; 0 disables
+	Timeout time.Duration
+	Retry   RetryPolicy
+	// requests per second sent to each host; 0 disables limiting
+	RateLimit float64
+	// requests sent at once before the rate limit applies; defaults to 1
+	Burst int
+	// headers and query parameters masked in logs in addition to the usual credentials
+	Redact []string
+	// transport requests are finally sent with; http.DefaultTransport when nil
+	Transport http.RoundTripper
+}
+
+// Client is an http.Client sending requests through the middleware stack
+type Client struct {
+	*http.Client
+	Metrics *Metrics
+}
+
+// New returns a client that retries failed requests, limits the request rate per host, logs
+// every attempt and records its latency
+func New(config Config) *Client {
+	metrics := NewMetrics()
+	middlewares := []Middleware{Retry(config.Retry), Logging(config.Redact...)}
+	if config.RateLimit > 0 {
+		middlewares = append(middlewares, RateLimit(config.RateLimit, config.Burst))
+	}
+	middlewares = append(middlewares, metrics.Middleware)
+	return &Client{
+		Client: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: Chain(config.Transport, middlewares...),
+		},
+		Metrics: metrics,
+	}
+}
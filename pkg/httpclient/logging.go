@@ -0,0 +1,73 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+)
+
+const redacted = "REDACTED"
+
+// credentials masked by default; matched case-insensitively
+var sensitive = []string{
+	"authorization", "proxy-authorization", "cookie", "set-cookie", "x-api-key", "api-key", "apikey",
+	"access_token", "refresh_token", "client_secret", "password", "token", "key", "signature", "x-amz-signature",
+}
+
+// Logging logs method, url, status and latency of every request at debug level; credentials
+// in headers and query parameters are masked
+func Logging(redact ...string) Middleware {
+	masked := make(map[string]bool)
+	for _, name := range append(append([]string{}, sensitive...), redact...) {
+		masked[strings.ToLower(name)] = true
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			fields := []interface{}{
+				"method", req.Method,
+				"url", redactURL(req.URL, masked),
+				"request_headers", redactHeaders(req.Header, masked),
+				"latency_ms", time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				logger.DebugFields("http request failed", append(fields, "error", err.Error())...)
+				return nil, err
+			}
+			fields = append(fields, "status", resp.StatusCode, "response_headers", redactHeaders(resp.Header, masked), "content_length", resp.ContentLength)
+			logger.DebugFields("http request", fields...)
+			return resp, nil
+		})
+	}
+}
+
+func redactURL(u *url.URL, masked map[string]bool) string {
+	clone := *u
+	if clone.User != nil {
+		clone.User = url.User(clone.User.Username())
+	}
+	query := clone.Query()
+	for name := range query {
+		if masked[strings.ToLower(name)] {
+			query.Set(name, redacted)
+		}
+	}
+	clone.RawQuery = query.Encode()
+	return clone.String()
+}
+
+func redactHeaders(header http.Header, masked map[string]bool) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		if masked[strings.ToLower(name)] {
+			headers[name] = redacted
+			continue
+		}
+		headers[name] = strings.Join(values, ", ")
+	}
+	return headers
+}
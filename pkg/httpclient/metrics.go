@@ -0,0 +1,91 @@
+package httpclient
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+)
+
+// HostMetrics counts the requests sent to a host; every retry is a request
+type HostMetrics struct {
+	Requests     int64         `json:"requests"`
+	Errors       int64         `json:"errors"`        // requests failed without a response
+	Throttled    int64         `json:"throttled"`     // 429 responses
+	ServerErrors int64         `json:"server_errors"` // 5xx responses
+	TotalLatency time.Duration `json:"total_latency"`
+	MaxLatency   time.Duration `json:"max_latency"`
+}
+
+func (h HostMetrics) AverageLatency() time.Duration {
+	if h.Requests == 0 {
+		return 0
+	}
+	return h.TotalLatency / time.Duration(h.Requests)
+}
+
+type Metrics struct {
+	mutex sync.Mutex
+	hosts map[string]*HostMetrics
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{hosts: make(map[string]*HostMetrics)}
+}
+
+// Middleware records the latency and outcome of every request
+func (m *Metrics) Middleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		latency := time.Since(start)
+
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		host, found := m.hosts[req.URL.Host]
+		if !found {
+			host = &HostMetrics{}
+			m.hosts[req.URL.Host] = host
+		}
+		host.Requests++
+		host.TotalLatency += latency
+		host.MaxLatency = max(host.MaxLatency, latency)
+		switch {
+		case err != nil:
+			host.Errors++
+		case resp.StatusCode == http.StatusTooManyRequests:
+			host.Throttled++
+		case resp.StatusCode >= http.StatusInternalServerError:
+			host.ServerErrors++
+		}
+		return resp, err
+	})
+}
+
+// Snapshot returns the metrics of every host requests were sent to
+func (m *Metrics) Snapshot() map[string]HostMetrics {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	snapshot := make(map[string]HostMetrics, len(m.hosts))
+	for host, metrics := range m.hosts {
+		snapshot[host] = *metrics
+	}
+	return snapshot
+}
+
+// Log writes the metrics of every host
+func (m *Metrics) Log() {
+	snapshot := m.Snapshot()
+	hosts := make([]string, 0, len(snapshot))
+	for host := range snapshot {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		metrics := snapshot[host]
+		logger.Infof("http requests to %s: %d sent, %d failed, %d throttled, %d server errors, %s average and %s max latency",
+			host, metrics.Requests, metrics.Errors, metrics.Throttled, metrics.ServerErrors, metrics.AverageLatency().Round(time.Millisecond), metrics.MaxLatency.Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,59 @@
+package httpclient
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimit delays requests so each host gets at most perSecond of them, after an initial
+// burst
+func RateLimit(perSecond float64, burst int) Middleware {
+	burst = max(burst, 1)
+	var mutex sync.Mutex
+	buckets := make(map[string]*bucket)
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			mutex.Lock()
+			hostBucket, found := buckets[req.URL.Host]
+			if !found {
+				hostBucket = &bucket{rate: perSecond, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+				buckets[req.URL.Host] = hostBucket
+			}
+			mutex.Unlock()
+
+			if wait := hostBucket.reserve(time.Now()); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-req.Context().Done():
+					timer.Stop()
+					return nil, req.Context().Err()
+				case <-timer.C:
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// bucket is a token bucket; tokens go negative for requests waiting on a token
+type bucket struct {
+	mutex  sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes a token and returns how long to wait until it is available
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
@@ -0,0 +1,136 @@
+package httpclient
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+)
+
+const (
+	defaultAttempts  = 3
+	defaultBaseDelay = 500 * time.Millisecond
+	defaultMaxDelay  = 30 * time.Second
+)
+
+// RetryPolicy of requests failing with 429, 502, 503 or 504, or with a network error when
+// the method is idempotent
+type RetryPolicy struct {
+	// attempts including the first; defaults to 3, 1 disables retries
+	MaxAttempts int
+	// delay before the first retry, doubled on every following one; defaults to 500ms
+	BaseDelay time.Duration
+	// cap of the delay, also of a Retry-After sent by the server; defaults to 30s
+	MaxDelay time.Duration
+	// retry requests of any method after network errors; for endpoints safe to repeat such as
+	// token refreshes
+	RetryAnyMethod bool
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaultAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = defaultBaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = defaultMaxDelay
+	}
+	return p
+}
+
+// Retry resends failed requests with exponential backoff and jitter; requests with a body are
+// only retried when it can be replayed
+func Retry(policy RetryPolicy) Middleware {
+	policy = policy.withDefaults()
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			for attempt := 1; ; attempt++ {
+				resp, err := next.RoundTrip(req)
+				if attempt >= policy.MaxAttempts || !policy.retryable(req, resp, err) {
+					return resp, err
+				}
+				delay := policy.backoff(attempt, resp)
+				if err != nil {
+					logger.ThrottledWarnf("http_retry:"+req.URL.Host, "retrying %s request to %s in %s after error: %s", req.Method, req.URL.Host, delay, err)
+				} else {
+					logger.ThrottledWarnf("http_retry:"+req.URL.Host, "retrying %s request to %s in %s after status %d", req.Method, req.URL.Host, delay, resp.StatusCode)
+					// drained so the connection is reused
+					_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+					resp.Body.Close()
+				}
+
+				timer := time.NewTimer(delay)
+				select {
+				case <-req.Context().Done():
+					timer.Stop()
+					return nil, req.Context().Err()
+				case <-timer.C:
+				}
+				if req.Body != nil && req.Body != http.NoBody {
+					body, err := req.GetBody()
+					if err != nil {
+						return nil, fmt.Errorf("failed to replay request body: %s", err)
+					}
+					req = req.Clone(req.Context())
+					req.Body = body
+				}
+			}
+		})
+	}
+}
+
+func (p RetryPolicy) retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		// the server may have processed a request that failed in flight
+		return p.RetryAnyMethod || idempotent(req.Method)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// backoff honours Retry-After, otherwise draws from [0, base * 2^(attempt-1)]
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if after := retryAfter(resp.Header.Get("Retry-After")); after > 0 {
+			return min(after, p.MaxDelay)
+		}
+	}
+	ceiling := min(p.BaseDelay<<(attempt-1), p.MaxDelay)
+	return time.Duration(rand.Int64N(int64(ceiling))) + time.Millisecond
+}
+
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
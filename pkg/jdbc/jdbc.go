@@ -2,25 +2,83 @@ package jdbc
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/datazip-inc/olake/protocol"
 	"github.com/datazip-inc/olake/types"
 )
 
+// Relation is implemented by streams read from something other than the table named after
+// them, e.g. the result of a query; Relation returns what queries select from
+type Relation interface {
+	Relation() string
+}
+
+// postgresRelation returns what the queries of stream select from
+func postgresRelation(stream protocol.Stream) string {
+	if relation, ok := stream.(Relation); ok {
+		return relation.Relation()
+	}
+	return fmt.Sprintf(`"%s"."%s"`, stream.Namespace(), stream.Name())
+}
+
+// PostgresWithoutState reads every row of the table ordered by its cursor
 func PostgresWithoutState(stream protocol.Stream) string {
-	return fmt.Sprintf(`SELECT * FROM "%s"."%s" ORDER BY %s`, stream.Namespace(), stream.Name(), stream.Cursor())
+	return fmt.Sprintf(`SELECT * FROM %s ORDER BY %s`, postgresRelation(stream), cursorOrder(stream.CursorFields()))
 }
 
+// PostgresWithState reads the rows after the cursor values given as parameters, ordered by
+// the cursor; composite cursors compare as row values, which is lexicographic
 func PostgresWithState(stream protocol.Stream) string {
-	return fmt.Sprintf(`SELECT * FROM "%s"."%s" where "%s">$1 ORDER BY "%s" ASC NULLS FIRST`, stream.Namespace(), stream.Name(), stream.Cursor(), stream.Cursor())
+	return postgresCursorQuery(stream, ">")
+}
+
+// PostgresFromCursor reads the rows from the cursor values given as parameters on, like
+// PostgresWithState but including rows at the cursor
+func PostgresFromCursor(stream protocol.Stream) string {
+	return postgresCursorQuery(stream, ">=")
+}
+
+func postgresCursorQuery(stream protocol.Stream, operator string) string {
+	columns := stream.CursorFields()
+	quoted := make([]string, len(columns))
+	params := make([]string, len(columns))
+	for idx, column := range columns {
+		quoted[idx] = fmt.Sprintf(`"%s"`, column)
+		params[idx] = fmt.Sprintf("$%d", idx+1)
+	}
+	condition := fmt.Sprintf("%s%s%s", quoted[0], operator, params[0])
+	if len(columns) > 1 {
+		condition = fmt.Sprintf("(%s)%s(%s)", strings.Join(quoted, ","), operator, strings.Join(params, ","))
+	}
+	return fmt.Sprintf(`SELECT * FROM %s where %s ORDER BY %s`, postgresRelation(stream), condition, cursorOrder(columns))
+}
+
+func cursorOrder(columns []string) string {
+	order := make([]string, len(columns))
+	for idx, column := range columns {
+		order[idx] = fmt.Sprintf(`"%s" ASC NULLS FIRST`, column)
+	}
+	return strings.Join(order, ", ")
 }
 
 func PostgresRowCountQuery(stream protocol.Stream) string {
 	return fmt.Sprintf(`SELECT reltuples::bigint AS approx_row_count FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace  WHERE c.relname = '%s' AND n.nspname = '%s';`, stream.Name(), stream.Namespace())
 }
 
+// PostgresExactRowCountQuery counts the rows of the table; unlike PostgresRowCountQuery it
+// scans the table
+func PostgresExactRowCountQuery(stream protocol.Stream) string {
+	return fmt.Sprintf(`SELECT COUNT(*) FROM %s;`, postgresRelation(stream))
+}
+
+// PostgresScanQuery reads every row of the table in no particular order
+func PostgresScanQuery(stream protocol.Stream) string {
+	return fmt.Sprintf(`SELECT * FROM %s;`, postgresRelation(stream))
+}
+
 func PostgresMinMaxQuery(stream protocol.Stream, filterColumn string) string {
-	return fmt.Sprintf(`SELECT MIN(%s) AS min_value, MAX(%s) AS max_value FROM "%s"."%s";`, filterColumn, filterColumn, stream.Namespace(), stream.Name())
+	return fmt.Sprintf(`SELECT MIN(%s) AS min_value, MAX(%s) AS max_value FROM %s;`, filterColumn, filterColumn, postgresRelation(stream))
 }
 
 func PostgresRelPageCount(stream protocol.Stream) string {
@@ -31,11 +89,11 @@ func PostgresWalLSNQuery() string {
 	return `SELECT pg_current_wal_lsn()::text::pg_lsn`
 }
 func NextChunkEndQuery(stream protocol.Stream, filterColumn string, filterValue interface{}, batchSize int) string {
-	return fmt.Sprintf(`SELECT MAX(%s) FROM (SELECT %s FROM "%s"."%s" WHERE %s > %v ORDER BY %s ASC LIMIT %d) AS T`, filterColumn, filterColumn, stream.Namespace(), stream.Name(), filterColumn, filterValue, filterColumn, batchSize)
+	return fmt.Sprintf(`SELECT MAX(%s) FROM (SELECT %s FROM %s WHERE %s > %v ORDER BY %s ASC LIMIT %d) AS T`, filterColumn, filterColumn, postgresRelation(stream), filterColumn, filterValue, filterColumn, batchSize)
 }
 
 func MinQuery(stream protocol.Stream, filterColumn string, filterValue interface{}) string {
-	return fmt.Sprintf(`SELECT MIN(%s) FROM "%s"."%s" WHERE %s > %v`, filterColumn, stream.Namespace(), stream.Name(), filterColumn, filterValue)
+	return fmt.Sprintf(`SELECT MIN(%s) FROM %s WHERE %s > %v`, filterColumn, postgresRelation(stream), filterColumn, filterValue)
 }
 
 func BuildSplitScanQuery(stream protocol.Stream, filterColumn string, chunk types.Chunk) string {
@@ -48,5 +106,9 @@ func BuildSplitScanQuery(stream protocol.Stream, filterColumn string, chunk type
 		condition = fmt.Sprintf("%s <= %v", filterColumn, chunk.Max)
 	}
 
-	return fmt.Sprintf(`SELECT * FROM "%s"."%s" WHERE %s`, stream.Namespace(), stream.Name(), condition)
+	// a single chunk reads everything
+	if condition == "" {
+		return fmt.Sprintf(`SELECT * FROM %s`, postgresRelation(stream))
+	}
+	return fmt.Sprintf(`SELECT * FROM %s WHERE %s`, postgresRelation(stream), condition)
 }
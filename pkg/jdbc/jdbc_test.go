@@ -0,0 +1,43 @@
+package jdbc
+
+import (
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestIncrementalQueries(t *testing.T) {
+	stream := &types.ConfiguredStream{Stream: types.NewStream("orders", "public"), CursorField: "updated_at"}
+	if query := PostgresWithState(stream); query != `SELECT * FROM "public"."orders" where "updated_at">$1 ORDER BY "updated_at" ASC NULLS FIRST` {
+		t.Fatalf("unexpected query %s", query)
+	}
+
+	stream.CursorField = "updated_at, id"
+	if query := PostgresWithState(stream); query != `SELECT * FROM "public"."orders" where ("updated_at","id")>($1,$2) ORDER BY "updated_at" ASC NULLS FIRST, "id" ASC NULLS FIRST` {
+		t.Fatalf("unexpected query %s", query)
+	}
+	if query := PostgresFromCursor(stream); query != `SELECT * FROM "public"."orders" where ("updated_at","id")>=($1,$2) ORDER BY "updated_at" ASC NULLS FIRST, "id" ASC NULLS FIRST` {
+		t.Fatalf("unexpected query %s", query)
+	}
+	if query := PostgresWithoutState(stream); query != `SELECT * FROM "public"."orders" ORDER BY "updated_at" ASC NULLS FIRST, "id" ASC NULLS FIRST` {
+		t.Fatalf("unexpected query %s", query)
+	}
+}
+
+type queryStream struct {
+	*types.ConfiguredStream
+}
+
+func (s queryStream) Relation() string {
+	return `(SELECT * FROM orders WHERE paid) AS "paid_orders"`
+}
+
+func TestRelationQueries(t *testing.T) {
+	stream := queryStream{&types.ConfiguredStream{Stream: types.NewStream("paid_orders", "custom"), CursorField: "id"}}
+	if query := PostgresWithState(stream); query != `SELECT * FROM (SELECT * FROM orders WHERE paid) AS "paid_orders" where "id">$1 ORDER BY "id" ASC NULLS FIRST` {
+		t.Fatalf("unexpected query %s", query)
+	}
+	if query := BuildSplitScanQuery(stream, "ctid", types.Chunk{}); query != `SELECT * FROM (SELECT * FROM orders WHERE paid) AS "paid_orders"` {
+		t.Fatalf("unexpected query %s", query)
+	}
+}
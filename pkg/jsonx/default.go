@@ -0,0 +1,6 @@
+//go:build !purego
+
+package jsonx
+
+// Default is the codec of the package functions
+var Default = Fast
@@ -0,0 +1,96 @@
+// Package jsonx is the json codec of the record hot paths: decoding changes read from sources
+// and encoding records written to destinations. The default codec is go-json, which compiles
+// an encoder and decoder once per type instead of reflecting on each value; builds with the
+// purego tag fall back to encoding/json for platforms where its unsafe code can't run. Buffers
+// records are encoded to are pooled so writing a record doesn't allocate one.
+package jsonx
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"sync"
+
+	"github.com/goccy/go-json"
+)
+
+// maxPooledBuffer bounds the capacity of buffers returned to the pool, so one large record
+// doesn't keep its buffer alive for the rest of the sync
+const maxPooledBuffer = 1 << 20
+
+// Codec encodes and decodes json documents; implementations produce the same documents, with
+// map keys sorted
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+	// Encode appends v followed by a newline to buf
+	Encode(buf *bytes.Buffer, v any) error
+}
+
+var (
+	// Fast is the go-json codec
+	Fast Codec = fastCodec{}
+	// Standard is the encoding/json codec
+	Standard Codec = standardCodec{}
+)
+
+var buffers = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// GetBuffer returns an empty buffer from the pool; PutBuffer it once its bytes are written
+func GetBuffer() *bytes.Buffer {
+	return buffers.Get().(*bytes.Buffer)
+}
+
+// PutBuffer returns buf to the pool; its bytes must not be used afterwards
+func PutBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	buffers.Put(buf)
+}
+
+func Marshal(v any) ([]byte, error) {
+	return Default.Marshal(v)
+}
+
+func Unmarshal(data []byte, v any) error {
+	return Default.Unmarshal(data, v)
+}
+
+// Encode appends v followed by a newline to buf
+func Encode(buf *bytes.Buffer, v any) error {
+	return Default.Encode(buf, v)
+}
+
+type fastCodec struct{}
+
+func (fastCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (fastCodec) Unmarshal(data []byte, v any) error {
+	// v doesn't escape to the heap through the decoder
+	return json.UnmarshalNoEscape(data, v)
+}
+
+func (fastCodec) Encode(buf *bytes.Buffer, v any) error {
+	return json.NewEncoder(buf).Encode(v)
+}
+
+type standardCodec struct{}
+
+func (standardCodec) Marshal(v any) ([]byte, error) {
+	return stdjson.Marshal(v)
+}
+
+func (standardCodec) Unmarshal(data []byte, v any) error {
+	return stdjson.Unmarshal(data, v)
+}
+
+func (standardCodec) Encode(buf *bytes.Buffer, v any) error {
+	return stdjson.NewEncoder(buf).Encode(v)
+}
@@ -0,0 +1,90 @@
+package jsonx
+
+import (
+	"bytes"
+	"testing"
+)
+
+var record = map[string]any{
+	"id":         float64(42),
+	"email":      "jane@example.com",
+	"active":     true,
+	"score":      1234.5,
+	"tags":       []any{"a", "b", "c"},
+	"address":    map[string]any{"city": "Pune", "zip": "411001", "lines": []any{"1 Main St", "Floor 2"}},
+	"updated_at": "2024-01-02T03:04:05.123456Z",
+	"deleted":    nil,
+}
+
+func TestCodecsMatch(t *testing.T) {
+	fast, err := Fast.Marshal(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	standard, err := Standard.Marshal(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fast, standard) {
+		t.Fatalf("expected codecs to write the same document:\n%s\n%s", fast, standard)
+	}
+
+	for name, codec := range map[string]Codec{"fast": Fast, "standard": Standard} {
+		buf := GetBuffer()
+		if err := codec.Encode(buf, record); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), append(standard, '\n')) {
+			t.Errorf("%s: expected encoded line %s, got %s", name, standard, buf.Bytes())
+		}
+		PutBuffer(buf)
+
+		decoded := make(map[string]any)
+		if err := codec.Unmarshal(standard, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		again, _ := Standard.Marshal(decoded)
+		if !bytes.Equal(again, standard) {
+			t.Errorf("%s: expected round trip to keep the document, got %s", name, again)
+		}
+	}
+}
+
+func benchmarkMarshal(b *testing.B, codec Codec) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := codec.Marshal(record); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkEncode(b *testing.B, codec Codec) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := GetBuffer()
+		if err := codec.Encode(buf, record); err != nil {
+			b.Fatal(err)
+		}
+		PutBuffer(buf)
+	}
+}
+
+func benchmarkUnmarshal(b *testing.B, codec Codec) {
+	data, _ := Standard.Marshal(record)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		decoded := make(map[string]any)
+		if err := codec.Unmarshal(data, &decoded); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalFast(b *testing.B)       { benchmarkMarshal(b, Fast) }
+func BenchmarkMarshalStandard(b *testing.B)   { benchmarkMarshal(b, Standard) }
+func BenchmarkEncodeFast(b *testing.B)        { benchmarkEncode(b, Fast) }
+func BenchmarkEncodeStandard(b *testing.B)    { benchmarkEncode(b, Standard) }
+func BenchmarkUnmarshalFast(b *testing.B)     { benchmarkUnmarshal(b, Fast) }
+func BenchmarkUnmarshalStandard(b *testing.B) { benchmarkUnmarshal(b, Standard) }
@@ -0,0 +1,175 @@
+// Package migrate upgrades versioned files, such as catalogs and states, written by older
+// releases to the format the running release reads
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/audit"
+	"github.com/datazip-inc/olake/pkg/encryption"
+	"github.com/goccy/go-json"
+)
+
+// VersionKey is the top level field holding the format version of a document; documents
+// without it are version 0
+const VersionKey = "version"
+
+// Migration upgrades a document from version From to From+1
+type Migration struct {
+	From        int
+	Description string
+	Up          func(document map[string]any) error
+}
+
+// Format is a versioned document format; Migrations[i] upgrades version i, so the current
+// version is the number of migrations
+type Format struct {
+	Name       string
+	Migrations []Migration
+}
+
+// Version is the version documents of the format are written in
+func (f *Format) Version() int {
+	return len(f.Migrations)
+}
+
+// Upgrade migrates document to the current version in place and returns the version it had
+func (f *Format) Upgrade(document map[string]any) (int, error) {
+	version, err := documentVersion(document)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %s", f.Name, err)
+	}
+	if version > f.Version() {
+		return version, fmt.Errorf("%s version %d is newer than version %d supported by this release; upgrade olake", f.Name, version, f.Version())
+	}
+	for _, migration := range f.Migrations[version:] {
+		if err := migration.Up(document); err != nil {
+			return version, fmt.Errorf("failed to migrate %s from version %d: %s", f.Name, migration.From, err)
+		}
+		logger.Infof("Migrated %s from version %d to %d: %s", f.Name, migration.From, migration.From+1, migration.Description)
+	}
+	document[VersionKey] = f.Version()
+	return version, nil
+}
+
+// UpgradeData migrates a serialized document and returns the version it was written in; data
+// is returned as is when it is current
+func (f *Format) UpgradeData(data []byte) ([]byte, int, error) {
+	var document map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// keeps large integers, e.g. cursors and offsets, exact
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse %s: %s", f.Name, err)
+	}
+	// documents serialized as null have nothing to migrate
+	if document == nil {
+		return data, f.Version(), nil
+	}
+	version, err := f.Upgrade(document)
+	if err != nil || version == f.Version() {
+		return data, version, err
+	}
+	upgraded, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, version, err
+	}
+	return upgraded, version, nil
+}
+
+// LoadFile unmarshals the file at path into dest; a file written in an older version is
+// upgraded on disk first, keeping the original next to it as <path>.v<version>.bak
+func (f *Format) LoadFile(path string, dest any) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s[%s]: %s", f.Name, path, err)
+	}
+	data, err := encryption.Open(raw)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s[%s]: %s", f.Name, path, err)
+	}
+	upgraded, version, err := f.UpgradeData(data)
+	if err != nil {
+		return fmt.Errorf("%s[%s]: %s", f.Name, path, err)
+	}
+	if version != f.Version() {
+		if err := f.rewrite(path, raw, data, upgraded, version); err != nil {
+			return err
+		}
+		data = upgraded
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("failed to unmarshal %s[%s]: %s", f.Name, path, err)
+	}
+	return nil
+}
+
+// rewrite replaces the file with the upgraded document after backing up the original; data is
+// the original document decrypted
+func (f *Format) rewrite(path string, raw, data, upgraded []byte, version int) error {
+	plain := upgraded
+	sealed := bytes.HasPrefix(bytes.TrimSpace(raw), []byte(`"`+encryption.Prefix))
+	if !sealed && bytes.Contains(raw, []byte(encryption.Prefix)) {
+		// writing the document back would store its encrypted values in plain text
+		logger.Warnf("%s[%s] holds encrypted values; upgraded in memory only", f.Name, path)
+		return nil
+	}
+	if sealed {
+		var err error
+		if upgraded, err = encryption.Seal(upgraded); err != nil {
+			return fmt.Errorf("failed to encrypt upgraded %s[%s]: %s", f.Name, path, err)
+		}
+	}
+
+	backup := fmt.Sprintf("%s.v%d.bak", path, version)
+	if err := os.WriteFile(backup, raw, 0o600); err != nil {
+		return fmt.Errorf("failed to back up %s[%s]: %s", f.Name, path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	// written beside the file and renamed so a crash leaves either version intact
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, upgraded, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write upgraded %s[%s]: %s", f.Name, path, err)
+	}
+	if err := os.Rename(temp, path); err != nil {
+		return fmt.Errorf("failed to replace %s[%s]: %s", f.Name, path, err)
+	}
+	logger.Infof("Upgraded %s[%s] to version %d; the original is kept in %s", f.Name, path, f.Version(), backup)
+	if err := audit.Record(context.Background(), audit.Kind(f.Name), path, "upgrade", data, plain); err != nil {
+		logger.Warnf("failed to record upgrade of %s[%s] in audit log: %s", f.Name, path, err)
+	}
+	return nil
+}
+
+func documentVersion(document map[string]any) (int, error) {
+	value, found := document[VersionKey]
+	if !found {
+		return 0, nil
+	}
+	var version int
+	switch typed := value.(type) {
+	case json.Number:
+		parsed, err := typed.Int64()
+		if err != nil {
+			return 0, fmt.Errorf("version[%s] is not an integer", typed)
+		}
+		version = int(parsed)
+	case float64:
+		version = int(typed)
+	case int:
+		version = typed
+	default:
+		return 0, fmt.Errorf("version[%v] is not an integer", value)
+	}
+	if version < 0 {
+		return 0, fmt.Errorf("negative version[%d]", version)
+	}
+	return version, nil
+}
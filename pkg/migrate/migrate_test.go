@@ -0,0 +1,91 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+var testFormat = &Format{
+	Name: "catalog",
+	Migrations: []Migration{
+		{From: 0, Description: "rename streams", Up: func(document map[string]any) error {
+			document["items"] = document["streams"]
+			delete(document, "streams")
+			return nil
+		}},
+		{From: 1, Description: "default mode", Up: func(document map[string]any) error {
+			if _, found := document["mode"]; !found {
+				document["mode"] = "full_refresh"
+			}
+			return nil
+		}},
+	},
+}
+
+type testDocument struct {
+	Version int    `json:"version"`
+	Items   []any  `json:"items"`
+	Mode    string `json:"mode"`
+	Cursor  uint64 `json:"cursor"`
+}
+
+func TestLoadFileUpgrades(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.json")
+	original := `{"streams": ["users"], "cursor": 18446744073709551615}`
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	document := &testDocument{}
+	if err := testFormat.LoadFile(path, document); err != nil {
+		t.Fatal(err)
+	}
+	if document.Version != 2 || len(document.Items) != 1 || document.Mode != "full_refresh" {
+		t.Fatalf("expected document upgraded to version 2, got %+v", document)
+	}
+	if document.Cursor != 18446744073709551615 {
+		t.Fatalf("expected large integers to stay exact, got %d", document.Cursor)
+	}
+
+	backup, err := os.ReadFile(path + ".v0.bak")
+	if err != nil || string(backup) != original {
+		t.Fatalf("expected the original to be backed up, got %q: %v", backup, err)
+	}
+	rewritten, _ := os.ReadFile(path)
+	if !strings.Contains(string(rewritten), `"version": 2`) {
+		t.Fatalf("expected the file to be upgraded on disk, got %s", rewritten)
+	}
+
+	// current files are read as is
+	if err := testFormat.LoadFile(path, &testDocument{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".v2.bak"); !os.IsNotExist(err) {
+		t.Fatal("expected no backup of a current file")
+	}
+}
+
+func TestUpgradeData(t *testing.T) {
+	data, version, err := testFormat.UpgradeData([]byte(`{"version": 1, "items": []}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	document := map[string]any{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatal(err)
+	}
+	if version != 1 || document["mode"] != "full_refresh" {
+		t.Fatalf("expected only the second migration to run, got version %d and %v", version, document)
+	}
+
+	if _, _, err := testFormat.UpgradeData([]byte(`{"version": 3}`)); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Fatalf("expected documents of newer releases to be rejected, got %v", err)
+	}
+	if _, _, err := testFormat.UpgradeData([]byte(`{"version": "two"}`)); err == nil {
+		t.Fatal("expected a non integer version to be rejected")
+	}
+}
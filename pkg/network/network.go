@@ -0,0 +1,279 @@
+// Package network holds the network section shared by driver and writer configs: the proxy or
+// ssh tunnel connections go through and the tls settings of the connection to the service
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config is the network section of a driver or writer config
+type Config struct {
+	Proxy *ProxyConfig `json:"proxy,omitempty"`
+	// bastion host connections are forwarded through; the bastion itself is reached through
+	// the proxy when both are set
+	SSHTunnel *SSHTunnelConfig `json:"ssh_tunnel,omitempty"`
+	TLS       *TLSConfig       `json:"tls,omitempty"`
+}
+
+type ProxyConfig struct {
+	// http://, https:// or socks5:// url of the proxy; credentials go in the url
+	URL string `json:"url"`
+	// hosts connected to directly; an entry matches the host and its subdomains
+	NoProxy []string `json:"no_proxy,omitempty"`
+
+	parsed *url.URL
+}
+
+type TLSConfig struct {
+	// pem encoded certificates, or the path of a file holding them, trusted in addition to the
+	// system roots
+	CA string `json:"ca,omitempty"`
+	// pem encoded client certificate and key, or paths of the files; enables mutual tls
+	ClientCert string `json:"client_cert,omitempty"`
+	ClientKey  string `json:"client_key,omitempty"`
+	// 1.2 (default) or 1.3
+	MinVersion string `json:"min_version,omitempty"`
+	// name verified against the server certificate when it differs from the host connected to
+	ServerName string `json:"server_name,omitempty"`
+	// skips verification of the server certificate; for testing only
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.Proxy != nil {
+		parsed, err := url.Parse(c.Proxy.URL)
+		if err != nil || parsed.Host == "" {
+			return fmt.Errorf("invalid proxy url[%s]", redactURL(c.Proxy.URL))
+		}
+		switch parsed.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("invalid proxy scheme[%s]; expected one of http, https, socks5", parsed.Scheme)
+		}
+		c.Proxy.parsed = parsed
+	}
+	if c.SSHTunnel != nil {
+		if err := c.SSHTunnel.validate(); err != nil {
+			return err
+		}
+	}
+	if c.TLS != nil {
+		if (c.TLS.ClientCert == "") != (c.TLS.ClientKey == "") {
+			return fmt.Errorf("tls client_cert and client_key must be set together")
+		}
+		if _, err := c.TLS.minVersion(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Enabled reports if the section changes how connections are made
+func (c *Config) Enabled() bool {
+	return c != nil && (c.Proxy != nil || c.SSHTunnel != nil || c.TLS != nil)
+}
+
+// TLSConfig returns the tls config of the connection to the service; nil without a tls section
+// so drivers keep their own defaults
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	if c == nil || c.TLS == nil {
+		return nil, nil
+	}
+	return c.TLS.build()
+}
+
+func (t *TLSConfig) minVersion() (uint16, error) {
+	switch t.MinVersion {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("invalid tls min_version[%s]; expected 1.2 or 1.3", t.MinVersion)
+	}
+}
+
+func (t *TLSConfig) build() (*tls.Config, error) {
+	minVersion, err := t.minVersion()
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		MinVersion:         minVersion,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify, //nolint:gosec // opt-in for testing
+	}
+	if t.CA != "" {
+		ca, err := pemOrFile(t.CA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tls ca: %s", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in tls ca")
+		}
+		config.RootCAs = pool
+	}
+	if t.ClientCert != "" {
+		cert, err := pemOrFile(t.ClientCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tls client_cert: %s", err)
+		}
+		key, err := pemOrFile(t.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tls client_key: %s", err)
+		}
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tls client certificate: %s", err)
+		}
+		config.Certificates = []tls.Certificate{pair}
+	}
+	return config, nil
+}
+
+// pemOrFile returns value when it is pem encoded, otherwise the content of the file at value
+func pemOrFile(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+	return os.ReadFile(value)
+}
+
+// HTTPTransport returns a transport going through the proxy with the tls config; a clone of
+// http.DefaultTransport when the section is empty
+func (c *Config) HTTPTransport() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c == nil {
+		return transport, nil
+	}
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	if c.SSHTunnel != nil {
+		// the proxy, if any, is only used to reach the bastion
+		transport.Proxy = nil
+		transport.DialContext = c.DialContext
+	} else if c.Proxy != nil {
+		proxyURL, err := c.Proxy.url()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(proxyURL.Scheme, "socks5") {
+			// http.Transport only proxies over http; socks goes through the dialer
+			transport.Proxy = nil
+			transport.DialContext = c.DialContext
+		} else {
+			transport.Proxy = func(req *http.Request) (*url.URL, error) {
+				if c.Proxy.bypassed(req.URL.Hostname()) {
+					return nil, nil
+				}
+				return proxyURL, nil
+			}
+		}
+	}
+	return transport, nil
+}
+
+var defaultDialer = &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+
+// DialContext opens a tcp connection to address through the ssh tunnel or the proxy; drivers
+// with their own protocol, e.g. database and message queue clients, dial with it
+func (c *Config) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if c != nil && c.SSHTunnel != nil {
+		return c.SSHTunnel.dial(ctx, c.dialProxy, network, address)
+	}
+	return c.dialProxy(ctx, network, address)
+}
+
+// Close closes the ssh tunnel; connections opened through it are closed with it
+func (c *Config) Close() error {
+	if c == nil || c.SSHTunnel == nil {
+		return nil
+	}
+	return c.SSHTunnel.close()
+}
+
+func (c *Config) dialProxy(ctx context.Context, network, address string) (net.Conn, error) {
+	if c == nil || c.Proxy == nil {
+		return defaultDialer.DialContext(ctx, network, address)
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if c.Proxy.bypassed(host) {
+		return defaultDialer.DialContext(ctx, network, address)
+	}
+	proxyURL, err := c.Proxy.url()
+	if err != nil {
+		return nil, err
+	}
+	switch proxyURL.Scheme {
+	case "socks5", "socks5h":
+		return dialSOCKS5(ctx, proxyURL, network, address)
+	default:
+		return dialConnect(ctx, proxyURL, address)
+	}
+}
+
+func (p *ProxyConfig) url() (*url.URL, error) {
+	if p.parsed != nil {
+		return p.parsed, nil
+	}
+	parsed, err := url.Parse(p.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url[%s]", redactURL(p.URL))
+	}
+	return parsed, nil
+}
+
+func (p *ProxyConfig) bypassed(host string) bool {
+	host = strings.ToLower(host)
+	for _, entry := range p.NoProxy {
+		entry = strings.ToLower(strings.TrimPrefix(entry, "."))
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL drops the password of a proxy url from error messages
+func redactURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.User == nil {
+		return raw
+	}
+	parsed.User = url.User(parsed.User.Username())
+	return parsed.String()
+}
+
+// ClientTLS starts tls on a connection dialed with DialContext, for clients that skip their
+// own tls when given a dialer; the server name defaults to the host of address
+func ClientTLS(conn net.Conn, config *tls.Config, address string) net.Conn {
+	if config.ServerName == "" {
+		config = config.Clone()
+		config.ServerName, _, _ = net.SplitHostPort(address)
+	}
+	return tls.Client(conn, config)
+}
@@ -0,0 +1,318 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// echoServer writes back everything it reads
+func echoServer(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener
+}
+
+// connectProxy tunnels CONNECT requests carrying the expected credentials
+func connectProxy(t *testing.T, credentials string, tunnels *atomic.Int32) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				req, err := http.ReadRequest(bufio.NewReader(conn))
+				if err != nil || req.Method != http.MethodConnect {
+					return
+				}
+				if req.Header.Get("Proxy-Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)) {
+					_, _ = io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+					return
+				}
+				target, err := net.Dial("tcp", req.Host)
+				if err != nil {
+					_, _ = io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+					return
+				}
+				defer target.Close()
+				tunnels.Add(1)
+				_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection Established\r\n\r\n")
+				go func() { _, _ = io.Copy(target, conn) }()
+				_, _ = io.Copy(conn, target)
+			}()
+		}
+	}()
+	return listener
+}
+
+func roundTrip(t *testing.T, conn net.Conn) {
+	t.Helper()
+	defer conn.Close()
+	if _, err := io.WriteString(conn, "ping"); err != nil {
+		t.Fatal(err)
+	}
+	reply := make([]byte, 4)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		t.Fatal(err)
+	}
+	if string(reply) != "ping" {
+		t.Fatalf("expected echo of ping, got %q", reply)
+	}
+}
+
+func TestDialThroughProxy(t *testing.T) {
+	echo := echoServer(t)
+	defer echo.Close()
+	var tunnels atomic.Int32
+	proxy := connectProxy(t, "user:secret", &tunnels)
+	defer proxy.Close()
+
+	config := &Config{Proxy: &ProxyConfig{URL: "http://user:secret@" + proxy.Addr().String()}}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := config.DialContext(context.Background(), "tcp", echo.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip(t, conn)
+	if tunnels.Load() != 1 {
+		t.Fatalf("expected the connection to be tunneled, got %d tunnels", tunnels.Load())
+	}
+
+	config = &Config{Proxy: &ProxyConfig{URL: "http://user:wrong@" + proxy.Addr().String()}}
+	_, err = config.DialContext(context.Background(), "tcp", echo.Addr().String())
+	if err == nil || !strings.Contains(err.Error(), "407") {
+		t.Fatalf("expected refused CONNECT, got %v", err)
+	}
+}
+
+func TestNoProxy(t *testing.T) {
+	echo := echoServer(t)
+	defer echo.Close()
+
+	// nothing listens on the proxy address, only a bypassed host can be reached
+	config := &Config{Proxy: &ProxyConfig{URL: "http://127.0.0.1:1", NoProxy: []string{"127.0.0.1"}}}
+	conn, err := config.DialContext(context.Background(), "tcp", echo.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip(t, conn)
+
+	proxy := &ProxyConfig{NoProxy: []string{".example.com"}}
+	for host, expected := range map[string]bool{"example.com": true, "db.example.com": true, "badexample.com": false} {
+		if proxy.bypassed(host) != expected {
+			t.Errorf("expected bypass of %s to be %t", host, expected)
+		}
+	}
+}
+
+func TestHTTPTransportCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	get := func(config *Config) error {
+		transport, err := config.HTTPTransport()
+		if err != nil {
+			return err
+		}
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	if err := get(nil); err == nil {
+		t.Fatal("expected the self signed certificate to be rejected without a ca")
+	}
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := get(&Config{TLS: &TLSConfig{CA: string(ca), MinVersion: "1.3"}}); err != nil {
+		t.Fatalf("expected the configured ca to be trusted: %s", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for name, config := range map[string]*Config{
+		"proxy scheme":    {Proxy: &ProxyConfig{URL: "ftp://proxy:21"}},
+		"proxy host":      {Proxy: &ProxyConfig{URL: "http://"}},
+		"client key":      {TLS: &TLSConfig{ClientCert: "cert.pem"}},
+		"tls min version": {TLS: &TLSConfig{MinVersion: "1.0"}},
+	} {
+		if err := config.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+
+	config := &Config{Proxy: &ProxyConfig{URL: "http://proxy:8080"}}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	var empty *Config
+	if err := empty.Validate(); err != nil || empty.Enabled() {
+		t.Fatal("expected a missing section to be valid and disabled")
+	}
+}
+
+// sshBastion forwards direct-tcpip channels of clients logging in with password
+func sshBastion(t *testing.T, password string, tunnels *atomic.Int32) (net.Listener, ssh.PublicKey) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(_ ssh.ConnMetadata, given []byte) (*ssh.Permissions, error) {
+			if string(given) != password {
+				return nil, fmt.Errorf("wrong password")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, channels, requests, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(requests)
+				for channel := range channels {
+					var forward struct {
+						Host       string
+						Port       uint32
+						OriginHost string
+						OriginPort uint32
+					}
+					if channel.ChannelType() != "direct-tcpip" || ssh.Unmarshal(channel.ExtraData(), &forward) != nil {
+						_ = channel.Reject(ssh.UnknownChannelType, "unsupported")
+						continue
+					}
+					target, err := net.Dial("tcp", net.JoinHostPort(forward.Host, fmt.Sprint(forward.Port)))
+					if err != nil {
+						_ = channel.Reject(ssh.ConnectionFailed, err.Error())
+						continue
+					}
+					accepted, channelRequests, err := channel.Accept()
+					if err != nil {
+						target.Close()
+						continue
+					}
+					tunnels.Add(1)
+					go ssh.DiscardRequests(channelRequests)
+					go func() {
+						defer accepted.Close()
+						defer target.Close()
+						go func() { _, _ = io.Copy(target, accepted) }()
+						_, _ = io.Copy(accepted, target)
+					}()
+				}
+			}()
+		}
+	}()
+	return listener, signer.PublicKey()
+}
+
+func TestSSHTunnel(t *testing.T) {
+	echo := echoServer(t)
+	defer echo.Close()
+	var tunnels atomic.Int32
+	bastion, hostKey := sshBastion(t, "secret", &tunnels)
+	defer bastion.Close()
+	host, port, _ := net.SplitHostPort(bastion.Addr().String())
+	bastionPort, _ := strconv.Atoi(port)
+
+	config := &Config{SSHTunnel: &SSHTunnelConfig{
+		Host:     host,
+		Port:     bastionPort,
+		Username: "olake",
+		Password: "secret",
+		HostKey:  string(ssh.MarshalAuthorizedKey(hostKey)),
+	}}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	defer config.Close()
+	for i := 0; i < 2; i++ {
+		conn, err := config.DialContext(context.Background(), "tcp", echo.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		roundTrip(t, conn)
+	}
+	if tunnels.Load() != 2 {
+		t.Fatalf("expected both connections to be forwarded, got %d", tunnels.Load())
+	}
+
+	// a bastion presenting another key is refused
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	otherSigner, _ := ssh.NewSignerFromKey(otherKey)
+	config = &Config{SSHTunnel: &SSHTunnelConfig{
+		Host:     host,
+		Port:     bastionPort,
+		Username: "olake",
+		Password: "secret",
+		HostKey:  string(ssh.MarshalAuthorizedKey(otherSigner.PublicKey())),
+	}}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.DialContext(context.Background(), "tcp", echo.Addr().String()); err == nil || !strings.Contains(err.Error(), "host key") {
+		t.Fatalf("expected host key mismatch, got %v", err)
+	}
+
+	unverified := &Config{SSHTunnel: &SSHTunnelConfig{Host: host, Username: "olake", Password: "secret"}}
+	if err := unverified.Validate(); err == nil {
+		t.Fatal("expected a tunnel without host key verification to be rejected")
+	}
+}
@@ -0,0 +1,188 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const sshKeepAliveInterval = 30 * time.Second
+
+// SSHTunnelConfig forwards connections through a bastion host, for services in private networks
+type SSHTunnelConfig struct {
+	Host string `json:"host"`
+	// defaults to 22
+	Port     int    `json:"port,omitempty"`
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+	// pem encoded private key or the path of the key file
+	PrivateKey string `json:"private_key,omitempty"`
+	Passphrase string `json:"private_key_passphrase,omitempty"`
+	// authorized_keys formatted host key of the bastion
+	HostKey string `json:"host_key,omitempty"`
+	// path of a known_hosts file listing the bastion
+	KnownHosts string `json:"known_hosts,omitempty"`
+	// skips verification of the bastion's host key; for testing only
+	InsecureIgnoreHostKey bool `json:"insecure_ignore_host_key,omitempty"`
+
+	// one ssh connection is shared by all connections of the driver
+	mutex  sync.Mutex
+	client *ssh.Client
+}
+
+func (s *SSHTunnelConfig) validate() error {
+	if s.Host == "" || s.Username == "" {
+		return fmt.Errorf("ssh_tunnel host and username are required")
+	}
+	if s.Port == 0 {
+		s.Port = 22
+	}
+	if s.Password == "" && s.PrivateKey == "" {
+		return fmt.Errorf("ssh_tunnel needs a password or private_key")
+	}
+	if s.HostKey == "" && s.KnownHosts == "" && !s.InsecureIgnoreHostKey {
+		return fmt.Errorf("ssh_tunnel needs a host_key or known_hosts to verify the bastion; set insecure_ignore_host_key to skip verification")
+	}
+	return nil
+}
+
+func (s *SSHTunnelConfig) address() string {
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
+
+func (s *SSHTunnelConfig) clientConfig() (*ssh.ClientConfig, error) {
+	config := &ssh.ClientConfig{User: s.Username, Timeout: defaultDialer.Timeout}
+	if s.PrivateKey != "" {
+		key, err := pemOrFile(s.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ssh_tunnel private_key: %s", err)
+		}
+		var signer ssh.Signer
+		if s.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(s.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ssh_tunnel private_key: %s", err)
+		}
+		config.Auth = append(config.Auth, ssh.PublicKeys(signer))
+	}
+	if s.Password != "" {
+		config.Auth = append(config.Auth, ssh.Password(s.Password))
+	}
+
+	switch {
+	case s.HostKey != "":
+		hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(s.HostKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ssh_tunnel host_key: %s", err)
+		}
+		config.HostKeyCallback = ssh.FixedHostKey(hostKey)
+		config.HostKeyAlgorithms = []string{hostKey.Type()}
+	case s.KnownHosts != "":
+		callback, err := knownhosts.New(s.KnownHosts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ssh_tunnel known_hosts: %s", err)
+		}
+		config.HostKeyCallback = callback
+	default:
+		logger.Warn("ssh_tunnel host key verification is disabled")
+		config.HostKeyCallback = ssh.InsecureIgnoreHostKey() // #nosec G106
+	}
+	return config, nil
+}
+
+// dial opens a connection to address forwarded by the bastion; the bastion is reached with
+// dialBastion
+func (s *SSHTunnelConfig) dial(ctx context.Context, dialBastion func(context.Context, string, string) (net.Conn, error), network, address string) (net.Conn, error) {
+	client, err := s.connect(ctx, dialBastion)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s through ssh tunnel %s: %s", address, s.address(), err)
+	}
+	return conn, nil
+}
+
+// connect returns the shared ssh client, reconnecting when the previous connection was lost
+func (s *SSHTunnelConfig) connect(ctx context.Context, dialBastion func(context.Context, string, string) (net.Conn, error)) (*ssh.Client, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.client != nil {
+		return s.client, nil
+	}
+
+	config, err := s.clientConfig()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dialBastion(ctx, "tcp", s.address())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ssh bastion %s: %s", s.address(), err)
+	}
+	// the handshake is bounded by ctx; the tunnel itself is not
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	sshConn, channels, requests, err := ssh.NewClientConn(conn, s.address(), config)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ssh handshake with bastion %s failed: %s", s.address(), err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	client := ssh.NewClient(sshConn, channels, requests)
+	logger.Infof("Opened ssh tunnel through %s", s.address())
+
+	s.client = client
+	go s.keepAlive(client)
+	return client, nil
+}
+
+// keepAlive stops idle bastions from dropping the tunnel and forgets the client once its
+// connection is lost, so the next dial reconnects
+func (s *SSHTunnelConfig) keepAlive(client *ssh.Client) {
+	done := make(chan struct{})
+	go func() {
+		_ = client.Wait()
+		close(done)
+	}()
+	ticker := time.NewTicker(sshKeepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			s.mutex.Lock()
+			if s.client == client {
+				s.client = nil
+			}
+			s.mutex.Unlock()
+			return
+		case <-ticker.C:
+			if _, _, err := client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+				logger.Warnf("ssh tunnel through %s lost: %s", s.address(), err)
+				client.Close()
+			}
+		}
+	}
+}
+
+func (s *SSHTunnelConfig) close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.client == nil {
+		return nil
+	}
+	err := s.client.Close()
+	s.client = nil
+	return err
+}
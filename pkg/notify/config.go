@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+)
+
+type WebhookFormat string
+
+const (
+	FormatJSON  WebhookFormat = "json"
+	FormatSlack WebhookFormat = "slack"
+)
+
+// Event of the sync a webhook is notified about
+type Event string
+
+const (
+	EventStart   Event = "start"
+	EventSuccess Event = "success"
+	EventPartial Event = "partial"
+	EventFailure Event = "failure"
+)
+
+// Config is the notifications section of the destination config
+type Config struct {
+	StatsD   *StatsDConfig   `json:"statsd,omitempty"`
+	Webhooks []WebhookConfig `json:"webhooks,omitempty"`
+}
+
+// StatsDConfig pushes the sync stats as gauges over udp at every stats interval
+type StatsDConfig struct {
+	// host:port of the statsd or datadog agent
+	Address string `json:"address"`
+	// defaults to olake.
+	Prefix string `json:"prefix,omitempty"`
+	// dogstatsd tags as key:value, e.g. env:prod; plain statsd servers don't accept tags
+	Tags []string `json:"tags,omitempty"`
+}
+
+type WebhookConfig struct {
+	URL string `json:"url"`
+	// json posts the event as is, slack posts a message for incoming webhooks
+	Format  WebhookFormat     `json:"format,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// events to notify about; all when empty
+	Events []Event `json:"events,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	if c.StatsD != nil {
+		if _, _, err := net.SplitHostPort(c.StatsD.Address); err != nil {
+			return fmt.Errorf("invalid statsd address[%s]: %s", c.StatsD.Address, err)
+		}
+		if c.StatsD.Prefix == "" {
+			c.StatsD.Prefix = "olake."
+		}
+	}
+	for idx := range c.Webhooks {
+		webhook := &c.Webhooks[idx]
+		if parsed, err := url.Parse(webhook.URL); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("invalid webhook url[%s]", webhook.URL)
+		}
+		switch webhook.Format {
+		case "":
+			webhook.Format = FormatJSON
+		case FormatJSON, FormatSlack:
+		default:
+			return fmt.Errorf("invalid webhook format[%s]; expected one of json, slack", webhook.Format)
+		}
+		for _, event := range webhook.Events {
+			switch event {
+			case EventStart, EventSuccess, EventPartial, EventFailure:
+			default:
+				return fmt.Errorf("invalid webhook event[%s]; expected one of start, success, partial, failure", event)
+			}
+		}
+	}
+	return nil
+}
+
+func (w *WebhookConfig) subscribed(event Event) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, subscribed := range w.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
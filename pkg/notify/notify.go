@@ -0,0 +1,65 @@
+// Package notify pushes sync stats to statsd and posts sync lifecycle events to webhooks
+package notify
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/datazip-inc/olake/logger"
+)
+
+// Notifier delivers the notifications of one sync; failed deliveries are logged and never
+// fail the sync
+type Notifier struct {
+	config *Config
+	client *http.Client
+	statsd *StatsD
+}
+
+func New(config *Config) (*Notifier, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	notifier := &Notifier{config: config, client: &http.Client{}}
+	if config.StatsD != nil {
+		statsd, err := NewStatsD(config.StatsD)
+		if err != nil {
+			return nil, err
+		}
+		notifier.statsd = statsd
+	}
+	return notifier, nil
+}
+
+// StatsEmitters returns the emitters to pass to logger.StatsLogger
+func (n *Notifier) StatsEmitters() []logger.StatsEmitter {
+	if n == nil || n.statsd == nil {
+		return nil
+	}
+	return []logger.StatsEmitter{n.statsd.Emit}
+}
+
+// Notify posts the notification to every webhook subscribed to its event
+func (n *Notifier) Notify(ctx context.Context, notification *Notification) {
+	if n == nil {
+		return
+	}
+	for idx := range n.config.Webhooks {
+		webhook := &n.config.Webhooks[idx]
+		if !webhook.subscribed(notification.Event) {
+			continue
+		}
+		if err := postWebhook(ctx, n.client, webhook, notification); err != nil {
+			logger.Warnf("failed to notify webhook[%s] of sync %s: %s", webhook.URL, notification.Event, err)
+		}
+	}
+}
+
+func (n *Notifier) Close() {
+	if n == nil || n.statsd == nil {
+		return
+	}
+	if err := n.statsd.Close(); err != nil {
+		logger.Warnf("failed to close statsd connection: %s", err)
+	}
+}
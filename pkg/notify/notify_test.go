@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/goccy/go-json"
+)
+
+func TestWebhookEvents(t *testing.T) {
+	var attempts atomic.Int32
+	received := make(chan map[string]any, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// first delivery fails to exercise the retry
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("expected configured header, got %q", r.Header.Get("Authorization"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		payload := map[string]any{}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("invalid payload %s: %s", body, err)
+		}
+		received <- payload
+	}))
+	defer server.Close()
+
+	notifier, err := New(&Config{Webhooks: []WebhookConfig{
+		{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}, Events: []Event{EventFailure}},
+		{URL: server.URL, Format: FormatSlack, Headers: map[string]string{"Authorization": "Bearer token"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer notifier.Close()
+
+	startedAt := time.Now()
+	finishedAt := startedAt.Add(time.Minute)
+	notifier.Notify(context.Background(), &Notification{Event: EventFailure, SyncID: "sync", Driver: "postgres", Destination: "PARQUET",
+		StartedAt: startedAt, FinishedAt: &finishedAt, Error: "connection refused"})
+	close(received)
+
+	var payloads []map[string]any
+	for payload := range received {
+		payloads = append(payloads, payload)
+	}
+	if len(payloads) != 2 {
+		t.Fatalf("expected both webhooks notified, got %v", payloads)
+	}
+	if payloads[0]["event"] != "failure" || payloads[0]["error"] != "connection refused" {
+		t.Fatalf("unexpected json payload %v", payloads[0])
+	}
+	if text, _ := payloads[1]["text"].(string); !strings.Contains(text, "sync sync from postgres to PARQUET failed after 1m0s") {
+		t.Fatalf("unexpected slack payload %v", payloads[1])
+	}
+}
+
+func TestWebhookSubscription(t *testing.T) {
+	webhook := &WebhookConfig{Events: []Event{EventFailure, EventPartial}}
+	if webhook.subscribed(EventStart) || !webhook.subscribed(EventPartial) {
+		t.Fatal("expected only subscribed events")
+	}
+	if !(&WebhookConfig{}).subscribed(EventStart) {
+		t.Fatal("expected all events without a subscription")
+	}
+}
+
+func TestStatsD(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	notifier, err := New(&Config{StatsD: &StatsDConfig{Address: listener.LocalAddr().String(), Tags: []string{"env:test"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer notifier.Close()
+	for _, emit := range notifier.StatsEmitters() {
+		emit(logger.Stats{SyncedRecords: 42, RecordsToSync: 100, Speed: 10.5, WrittenBytes: 4096})
+	}
+
+	buf := make([]byte, 1024)
+	_ = listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := listener.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(buf[:n]), "\n")
+	if lines[0] != "olake.records.synced:42|g|#env:test" {
+		t.Fatalf("unexpected gauge %q", lines[0])
+	}
+	if !strings.Contains(string(buf[:n]), "olake.records_per_second:10.50|g|#env:test") {
+		t.Fatalf("expected speed gauge in %q", buf[:n])
+	}
+	if !strings.Contains(string(buf[:n]), "olake.bytes.written:4096|g|#env:test") {
+		t.Fatalf("expected written bytes gauge in %q", buf[:n])
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	for _, config := range []*Config{
+		{StatsD: &StatsDConfig{Address: "localhost"}},
+		{Webhooks: []WebhookConfig{{URL: "hooks.slack.com"}}},
+		{Webhooks: []WebhookConfig{{URL: "https://hooks.slack.com", Format: "xml"}}},
+		{Webhooks: []WebhookConfig{{URL: "https://hooks.slack.com", Events: []Event{"done"}}}},
+	} {
+		if err := config.Validate(); err == nil {
+			t.Fatalf("expected invalid config %+v", config)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/datazip-inc/olake/logger"
+)
+
+// StatsD sends gauges over udp; a lost datagram only loses one sample
+type StatsD struct {
+	conn   net.Conn
+	prefix string
+	tags   string
+}
+
+func NewStatsD(config *StatsDConfig) (*StatsD, error) {
+	conn, err := net.Dial("udp", config.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to statsd[%s]: %s", config.Address, err)
+	}
+	statsd := &StatsD{conn: conn, prefix: config.Prefix}
+	if len(config.Tags) > 0 {
+		statsd.tags = "|#" + strings.Join(config.Tags, ",")
+	}
+	return statsd, nil
+}
+
+// Emit is a logger.StatsEmitter; all gauges of a sample go in one datagram
+func (s *StatsD) Emit(stats logger.Stats) {
+	var buf bytes.Buffer
+	for _, gauge := range []struct {
+		name  string
+		value any
+	}{
+		{"records.synced", stats.SyncedRecords},
+		{"records.to_sync", stats.RecordsToSync},
+		{"bytes.read", stats.ReadBytes},
+		{"bytes.written", stats.WrittenBytes},
+		{"threads.running", stats.RunningThreads},
+		{"records_per_second", fmt.Sprintf("%.2f", stats.Speed)},
+		{"elapsed_seconds", fmt.Sprintf("%.0f", stats.Elapsed.Seconds())},
+		{"memory.heap_bytes", stats.MemoryBytes},
+	} {
+		fmt.Fprintf(&buf, "%s%s:%v|g%s\n", s.prefix, gauge.name, gauge.value, s.tags)
+	}
+	if stats.Remaining >= 0 {
+		fmt.Fprintf(&buf, "%seta_seconds:%.0f|g%s\n", s.prefix, stats.Remaining.Seconds(), s.tags)
+	}
+	if _, err := s.conn.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))); err != nil {
+		logger.ThrottledWarnf("statsd", "failed to send stats to statsd: %s", err)
+	}
+}
+
+func (s *StatsD) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	webhookTimeout  = 10 * time.Second
+	webhookAttempts = 3
+)
+
+// Notification is posted to json webhooks
+type Notification struct {
+	Event       Event      `json:"event"`
+	SyncID      string     `json:"sync_id"`
+	Driver      string     `json:"driver"`
+	Destination string     `json:"destination"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Records     int64      `json:"records"`
+	Error       string     `json:"error,omitempty"`
+	// summary of the streams of a finished sync
+	Summary any `json:"summary,omitempty"`
+}
+
+func (n *Notification) slackMessage() map[string]string {
+	text := ""
+	switch n.Event {
+	case EventStart:
+		text = fmt.Sprintf(":arrows_counterclockwise: OLake sync %s from %s to %s started", n.SyncID, n.Driver, n.Destination)
+	case EventSuccess:
+		text = fmt.Sprintf(":white_check_mark: OLake sync %s from %s to %s succeeded with %d records in %s", n.SyncID, n.Driver, n.Destination, n.Records, n.duration())
+	case EventPartial:
+		text = fmt.Sprintf(":warning: OLake sync %s from %s to %s partially succeeded with %d records in %s: %s", n.SyncID, n.Driver, n.Destination, n.Records, n.duration(), n.Error)
+	case EventFailure:
+		text = fmt.Sprintf(":x: OLake sync %s from %s to %s failed after %s: %s", n.SyncID, n.Driver, n.Destination, n.duration(), n.Error)
+	}
+	return map[string]string{"text": text}
+}
+
+func (n *Notification) duration() time.Duration {
+	if n.FinishedAt == nil {
+		return 0
+	}
+	return n.FinishedAt.Sub(n.StartedAt).Round(time.Second)
+}
+
+func postWebhook(ctx context.Context, client *http.Client, webhook *WebhookConfig, notification *Notification) error {
+	var payload any = notification
+	if webhook.Format == FormatSlack {
+		payload = notification.slackMessage()
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < webhookAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt) * time.Second):
+			}
+		}
+		if lastErr = post(ctx, client, webhook, body); lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+func post(ctx context.Context, client *http.Client, webhook *WebhookConfig, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range webhook.Headers {
+		request.Header.Set(key, value)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Authorize runs the authorization code flow with PKCE: prompt is called with the url the
+// user opens in a browser, the provider redirects to a local callback server and the code it
+// passes is exchanged for a token. Meant for the initial setup of a connector; the refresh
+// token of the result goes into its config
+func Authorize(ctx context.Context, config *Config, prompt func(authURL string)) (*Token, error) {
+	if config.AuthURL == "" {
+		return nil, fmt.Errorf("oauth auth_url is required for the authorization code flow")
+	}
+	listener, err := net.Listen("tcp", config.CallbackAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start callback server on %s: %s", config.CallbackAddress, err)
+	}
+	redirectURL := fmt.Sprintf("http://%s/callback", listener.Addr())
+
+	state, verifier := randomString(), randomString()
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {config.ClientID},
+		"redirect_uri":          {redirectURL},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+		// asks providers such as google for a refresh token
+		"access_type": {"offline"},
+	}
+	if len(config.Scopes) > 0 {
+		query.Set("scope", strings.Join(config.Scopes, " "))
+	}
+	separator := "?"
+	if strings.Contains(config.AuthURL, "?") {
+		separator = "&"
+	}
+
+	codes := make(chan string, 1)
+	failures := make(chan error, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback" {
+			http.NotFound(w, r)
+			return
+		}
+		params := r.URL.Query()
+		switch {
+		case params.Get("state") != state:
+			http.Error(w, "invalid state", http.StatusBadRequest)
+			return
+		case params.Get("error") != "":
+			failures <- fmt.Errorf("authorization denied: %s: %s", params.Get("error"), params.Get("error_description"))
+			http.Error(w, "Authorization failed, see the olake logs.", http.StatusBadRequest)
+			return
+		case params.Get("code") == "":
+			http.Error(w, "missing code", http.StatusBadRequest)
+			return
+		}
+		select {
+		case codes <- params.Get("code"):
+		default:
+		}
+		_, _ = w.Write([]byte("Authorization complete, you can close this window."))
+	})}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			failures <- fmt.Errorf("callback server failed: %s", err)
+		}
+	}()
+	defer server.Close()
+
+	prompt(config.AuthURL + separator + query.Encode())
+	var code string
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("authorization not completed: %s", ctx.Err())
+	case err := <-failures:
+		return nil, err
+	case code = <-codes:
+	}
+
+	return requestToken(ctx, tokenClient(config).Client, config, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"code_verifier": {verifier},
+	})
+}
+
+func randomString() string {
+	buf := make([]byte, 32)
+	_, _ = rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
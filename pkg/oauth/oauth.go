@@ -0,0 +1,141 @@
+// Package oauth manages the oauth2 tokens of API based drivers: the authorization code flow
+// run once at setup, access token refreshes with retries and persistence of rotated refresh
+// tokens in the state
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/datazip-inc/olake/pkg/httpclient"
+	"github.com/goccy/go-json"
+)
+
+// RefreshTokenKey is the state credential a rotated refresh token is persisted under
+const RefreshTokenKey = "oauth_refresh_token"
+
+// Config of the oauth2 client of a driver; usually built by the driver from its own config
+type Config struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	// authorization endpoint; only used by the authorization code flow
+	AuthURL  string   `json:"auth_url,omitempty"`
+	TokenURL string   `json:"token_url"`
+	Scopes   []string `json:"scopes,omitempty"`
+	// refresh token obtained at setup; a rotated token persisted in the state takes precedence.
+	// The client credentials grant is used without one
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// host:port the local callback server of the authorization code flow listens on; defaults
+	// to 127.0.0.1:8085
+	CallbackAddress string `json:"callback_address,omitempty"`
+	// transport of token requests, e.g. through the proxy of the driver; the default when nil
+	Transport http.RoundTripper `json:"-"`
+}
+
+func (c *Config) Validate() error {
+	if c.ClientID == "" {
+		return fmt.Errorf("oauth client_id is required")
+	}
+	if _, err := url.ParseRequestURI(c.TokenURL); err != nil {
+		return fmt.Errorf("invalid oauth token_url[%s]: %s", c.TokenURL, err)
+	}
+	if c.CallbackAddress == "" {
+		c.CallbackAddress = "127.0.0.1:8085"
+	}
+	return nil
+}
+
+// Token is the response of the token endpoint
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	TokenType    string    `json:"token_type,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+	// every field of the response, e.g. the instance_url of salesforce
+	Extra map[string]any `json:"-"`
+}
+
+// expiryDelta refreshes tokens before they expire so requests in flight don't fail
+const expiryDelta = time.Minute
+
+// Valid reports if the token can be used; a token without expiry is valid until rejected
+func (t *Token) Valid(now time.Time) bool {
+	return t != nil && t.AccessToken != "" && (t.Expiry.IsZero() || now.Add(expiryDelta).Before(t.Expiry))
+}
+
+// ExtraString returns a string field of the token response
+func (t *Token) ExtraString(key string) string {
+	value, _ := t.Extra[key].(string)
+	return value
+}
+
+// tokenClient retries token requests; repeating a grant is safe as the server only issues a
+// new token
+func tokenClient(config *Config) *httpclient.Client {
+	return httpclient.New(httpclient.Config{
+		Timeout:   time.Minute,
+		Retry:     httpclient.RetryPolicy{MaxAttempts: 5, RetryAnyMethod: true},
+		Redact:    []string{"code", "code_verifier"},
+		Transport: config.Transport,
+	})
+}
+
+// requestToken posts the grant in form to the token endpoint
+func requestToken(ctx context.Context, client *http.Client, config *Config, form url.Values) (*Token, error) {
+	form.Set("client_id", config.ClientID)
+	if config.ClientSecret != "" {
+		form.Set("client_secret", config.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request token: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %s", err)
+	}
+	fields := map[string]any{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode token response with status %d: %s", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token request failed with status %d: %v: %v", resp.StatusCode, fields["error"], fields["error_description"])
+	}
+	return parseToken(fields, time.Now())
+}
+
+func parseToken(fields map[string]any, now time.Time) (*Token, error) {
+	token := &Token{Extra: fields}
+	token.AccessToken, _ = fields["access_token"].(string)
+	token.RefreshToken, _ = fields["refresh_token"].(string)
+	token.TokenType, _ = fields["token_type"].(string)
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("token response without access_token")
+	}
+	// some providers send expires_in as a string
+	var expiresIn int64
+	switch value := fields["expires_in"].(type) {
+	case float64:
+		expiresIn = int64(value)
+	case string:
+		expiresIn, _ = strconv.ParseInt(value, 10, 64)
+	}
+	if expiresIn > 0 {
+		token.Expiry = now.Add(time.Duration(expiresIn) * time.Second)
+	}
+	return token, nil
+}
@@ -0,0 +1,143 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type memoryStore map[string]string
+
+func (m memoryStore) GetCredential(key string) string { return m[key] }
+func (m memoryStore) SetCredential(key, value string) { m[key] = value }
+
+// tokenServer issues access-<n> tokens and rotates the refresh token on every refresh
+func tokenServer(t *testing.T, issued *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		n := issued.Add(1)
+		switch r.Form.Get("grant_type") {
+		case "refresh_token":
+			if r.Form.Get("refresh_token") != fmt.Sprintf("refresh-%d", n-1) {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":"invalid_grant","error_description":"expired refresh token"}`)
+				return
+			}
+			fmt.Fprintf(w, `{"access_token":"access-%d","refresh_token":"refresh-%d","expires_in":"3600","instance_url":"https://org.example.com"}`, n, n)
+		case "client_credentials":
+			fmt.Fprintf(w, `{"access_token":"access-%d","token_type":"bearer","expires_in":3600}`, n)
+		case "authorization_code":
+			if r.Form.Get("code") != "code" || r.Form.Get("code_verifier") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":"invalid_grant"}`)
+				return
+			}
+			fmt.Fprintf(w, `{"access_token":"access-%d","refresh_token":"refresh-%d"}`, n, n)
+		}
+	}))
+}
+
+func TestRefreshRotation(t *testing.T) {
+	var issued atomic.Int32
+	server := tokenServer(t, &issued)
+	defer server.Close()
+
+	store := memoryStore{}
+	source := NewTokenSource(&Config{ClientID: "client", TokenURL: server.URL, RefreshToken: "refresh-0"})
+	source.Persist(store, RefreshTokenKey)
+	token, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "access-1" || token.ExtraString("instance_url") != "https://org.example.com" || time.Until(token.Expiry) < 59*time.Minute {
+		t.Fatalf("unexpected token %+v", token)
+	}
+	if store[RefreshTokenKey] != "refresh-1" {
+		t.Fatalf("expected rotated refresh token persisted, got %v", store)
+	}
+	// valid tokens are reused
+	if again, _ := source.Token(context.Background()); again != token {
+		t.Fatal("expected cached token")
+	}
+
+	// a later sync starts from the persisted token; the configured one is no longer valid
+	next := NewTokenSource(&Config{ClientID: "client", TokenURL: server.URL, RefreshToken: "refresh-0"})
+	next.Persist(store, RefreshTokenKey)
+	if token, err := next.Token(context.Background()); err != nil || token.AccessToken != "access-2" {
+		t.Fatalf("expected refresh with the persisted token, got %v, %v", token, err)
+	}
+}
+
+func TestClientCredentials(t *testing.T) {
+	var issued atomic.Int32
+	server := tokenServer(t, &issued)
+	defer server.Close()
+
+	var authorization atomic.Value
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first token is rejected to exercise the refresh
+		if r.Header.Get("Authorization") == "Bearer access-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		authorization.Store(r.Header.Get("Authorization"))
+	}))
+	defer api.Close()
+
+	source := NewTokenSource(&Config{ClientID: "client", TokenURL: server.URL})
+	client := &http.Client{Transport: source.Middleware(http.DefaultTransport)}
+	resp, err := client.Post(api.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || authorization.Load() != "Bearer access-2" {
+		t.Fatalf("expected request resent with a refreshed token, got %d with %v", resp.StatusCode, authorization.Load())
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	var issued atomic.Int32
+	server := tokenServer(t, &issued)
+	defer server.Close()
+
+	config := &Config{ClientID: "client", AuthURL: "https://provider.example.com/authorize", TokenURL: server.URL, CallbackAddress: "127.0.0.1:0"}
+	token, err := Authorize(context.Background(), config, func(authURL string) {
+		// the browser follows the redirect of the provider to the callback server
+		parsed, err := url.Parse(authURL)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		query := parsed.Query()
+		if query.Get("code_challenge_method") != "S256" {
+			t.Errorf("expected pkce in %s", authURL)
+		}
+		callback := query.Get("redirect_uri") + "?" + url.Values{"code": {"code"}, "state": {query.Get("state")}}.Encode()
+		go func() {
+			if _, err := http.Get(query.Get("redirect_uri") + "?state=forged&code=other"); err != nil {
+				t.Error(err)
+			}
+			resp, err := http.Get(callback)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.RefreshToken != "refresh-1" {
+		t.Fatalf("unexpected token %+v", token)
+	}
+}
@@ -0,0 +1,134 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/httpclient"
+)
+
+// CredentialStore persists rotated refresh tokens; implemented by types.State
+type CredentialStore interface {
+	GetCredential(key string) string
+	SetCredential(key, value string)
+}
+
+// TokenSource hands out access tokens, refreshing them before they expire
+type TokenSource struct {
+	config *Config
+	client *http.Client
+	mutex  sync.Mutex
+	token  *Token
+	store  CredentialStore
+	key    string
+}
+
+func NewTokenSource(config *Config) *TokenSource {
+	return &TokenSource{config: config, client: tokenClient(config).Client}
+}
+
+// Persist stores refresh tokens rotated by the provider under key, and prefers the stored
+// token over the configured one; without it a rotated token is lost when the sync exits
+func (s *TokenSource) Persist(store CredentialStore, key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.store = store
+	s.key = key
+}
+
+// Token returns a valid access token
+func (s *TokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.token.Valid(time.Now()) {
+		return s.token, nil
+	}
+
+	form := url.Values{}
+	refreshToken := s.refreshToken()
+	if refreshToken != "" {
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", refreshToken)
+	} else {
+		form.Set("grant_type", "client_credentials")
+		if len(s.config.Scopes) > 0 {
+			form.Set("scope", strings.Join(s.config.Scopes, " "))
+		}
+	}
+	token, err := requestToken(ctx, s.client, s.config, form)
+	if err != nil {
+		return nil, err
+	}
+	if token.RefreshToken == "" {
+		// the refresh token stays valid when the provider doesn't rotate it
+		token.RefreshToken = refreshToken
+	} else if token.RefreshToken != refreshToken && s.store != nil {
+		logger.Info("oauth refresh token rotated, persisting it in the state")
+		s.store.SetCredential(s.key, token.RefreshToken)
+	}
+	s.token = token
+	return token, nil
+}
+
+func (s *TokenSource) refreshToken() string {
+	if s.token != nil && s.token.RefreshToken != "" {
+		return s.token.RefreshToken
+	}
+	if s.store != nil {
+		if stored := s.store.GetCredential(s.key); stored != "" {
+			return stored
+		}
+	}
+	return s.config.RefreshToken
+}
+
+// Invalidate forces a refresh on the next call of Token, e.g. after the token was rejected
+func (s *TokenSource) Invalidate(rejected *Token) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	// another request may have refreshed it already
+	if s.token == rejected && s.token != nil {
+		s.token = &Token{RefreshToken: s.token.RefreshToken}
+	}
+}
+
+// Middleware authorizes requests with the access token; a request answered with 401 is sent
+// once more with a refreshed token when its body can be replayed
+func (s *TokenSource) Middleware(next http.RoundTripper) http.RoundTripper {
+	return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		for attempt := 0; ; attempt++ {
+			token, err := s.Token(req.Context())
+			if err != nil {
+				return nil, err
+			}
+			authorized := req.Clone(req.Context())
+			if attempt > 0 && req.GetBody != nil {
+				if authorized.Body, err = req.GetBody(); err != nil {
+					return nil, fmt.Errorf("failed to replay request body: %s", err)
+				}
+			}
+			authorized.Header.Set("Authorization", tokenType(token)+" "+token.AccessToken)
+			resp, err := next.RoundTrip(authorized)
+			replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+			if err != nil || resp.StatusCode != http.StatusUnauthorized || attempt > 0 || !replayable {
+				return resp, err
+			}
+			resp.Body.Close()
+			s.Invalidate(token)
+		}
+	})
+}
+
+func tokenType(token *Token) string {
+	// providers answer bearer in any case, servers expect it capitalized
+	if token.TokenType == "" || strings.EqualFold(token.TokenType, "bearer") {
+		return "Bearer"
+	}
+	return token.TokenType
+}
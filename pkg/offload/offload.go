@@ -0,0 +1,156 @@
+// Package offload stores column values too large to write inline, so the destination gets a
+// url to the value in place of the value
+package offload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/spf13/viper"
+)
+
+type StoreType string
+
+const (
+	StoreFile StoreType = "file"
+	StoreS3   StoreType = "s3"
+	StoreGCS  StoreType = "gcs"
+)
+
+// gcs is reached through its s3 compatible api with hmac keys
+const gcsEndpoint = "https://storage.googleapis.com"
+
+// Config is the large_value_store of the destination config
+type Config struct {
+	Type StoreType `json:"type"`
+	// file store; defaults to large_values in the config folder
+	Path string `json:"path,omitempty"`
+	// s3 and gcs stores; values are uploaded under the prefix
+	Bucket    string `json:"bucket,omitempty"`
+	Region    string `json:"region,omitempty"`
+	Endpoint  string `json:"endpoint,omitempty"`
+	AccessKey string `json:"access_key,omitempty"`
+	SecretKey string `json:"secret_key,omitempty"`
+	Prefix    string `json:"prefix,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	switch c.Type {
+	case "", StoreFile:
+		c.Type = StoreFile
+		if c.Path == "" {
+			c.Path = filepath.Join(viper.GetString("CONFIG_FOLDER"), "large_values")
+		}
+	case StoreS3, StoreGCS:
+		if c.Bucket == "" {
+			return fmt.Errorf("bucket required for large value store[%s]", c.Type)
+		}
+		if c.Type == StoreGCS && c.Endpoint == "" {
+			c.Endpoint = gcsEndpoint
+		}
+		if c.Region == "" {
+			c.Region = "auto"
+		}
+	default:
+		return fmt.Errorf("invalid large value store type[%s]; expected one of file, s3, gcs", c.Type)
+	}
+	return nil
+}
+
+// Store keeps offloaded values; safe for concurrent use
+type Store interface {
+	// Put stores value under a key derived from its content and returns its url; putting
+	// the same value again returns the same url, so retried records don't leave copies
+	Put(ctx context.Context, namespace, stream, column string, value []byte) (string, error)
+}
+
+// New returns the store of config
+func New(config *Config) (Store, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.Type == StoreFile {
+		return &fileStore{root: config.Path}, nil
+	}
+	s3Config := aws.Config{
+		Region: aws.String(config.Region),
+	}
+	if config.Endpoint != "" {
+		s3Config.Endpoint = aws.String(config.Endpoint)
+	}
+	if config.AccessKey != "" && config.SecretKey != "" {
+		s3Config.Credentials = credentials.NewStaticCredentials(config.AccessKey, config.SecretKey, "")
+	}
+	sess, err := session.NewSession(&s3Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %s", err)
+	}
+	scheme := "s3"
+	if config.Type == StoreGCS {
+		scheme = "gs"
+	}
+	return &objectStore{client: s3.New(sess), bucket: config.Bucket, prefix: config.Prefix, scheme: scheme}, nil
+}
+
+// Key returns the relative key of value, e.g. public/users/avatar/<sha256>
+func Key(namespace, stream, column string, value []byte) string {
+	sum := sha256.Sum256(value)
+	return path.Join(namespace, stream, column, hex.EncodeToString(sum[:]))
+}
+
+type fileStore struct {
+	root string
+}
+
+func (f *fileStore) Put(_ context.Context, namespace, stream, column string, value []byte) (string, error) {
+	file := filepath.Join(f.root, filepath.FromSlash(Key(namespace, stream, column, value)))
+	if _, err := os.Stat(file); err != nil {
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			return "", fmt.Errorf("failed to create large value folder: %s", err)
+		}
+		// written aside and renamed so readers never see a partial value
+		partial := file + ".partial"
+		if err := os.WriteFile(partial, value, 0o644); err != nil {
+			return "", fmt.Errorf("failed to write large value[%s]: %s", file, err)
+		}
+		if err := os.Rename(partial, file); err != nil {
+			return "", fmt.Errorf("failed to write large value[%s]: %s", file, err)
+		}
+	}
+	absolute, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(absolute)}).String(), nil
+}
+
+type objectStore struct {
+	client *s3.S3
+	bucket string
+	prefix string
+	scheme string
+}
+
+func (o *objectStore) Put(ctx context.Context, namespace, stream, column string, value []byte) (string, error) {
+	key := path.Join(o.prefix, Key(namespace, stream, column, value))
+	_, err := o.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(o.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(value),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload %s://%s/%s: %s", o.scheme, o.bucket, key, err)
+	}
+	return fmt.Sprintf("%s://%s/%s", o.scheme, o.bucket, key), nil
+}
@@ -0,0 +1,40 @@
+package offload
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	store, err := New(&Config{Path: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	value := []byte("a value too large to write inline")
+	first, err := store.Put(ctx, "public", "users", "bio", value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := store.Put(ctx, "public", "users", "bio", value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Fatalf("expected the same url for the same value, got %s and %s", first, second)
+	}
+	location, err := url.Parse(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := os.ReadFile(location.Path)
+	if err != nil || string(stored) != string(value) {
+		t.Fatalf("expected the value at %s, got %q: %v", first, stored, err)
+	}
+
+	if _, err := New(&Config{Type: StoreS3}); err == nil {
+		t.Fatal("expected an s3 store without bucket to be rejected")
+	}
+}
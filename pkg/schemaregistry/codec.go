@@ -0,0 +1,70 @@
+package schemaregistry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/goccy/go-json"
+	"github.com/linkedin/goavro/v2"
+)
+
+// Decode decodes a framed message into a record using the schema the message references
+func (c *Client) Decode(ctx context.Context, message []byte) (map[string]any, error) {
+	id, payload, err := Unframe(message)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := c.SchemaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	record := make(map[string]any)
+	switch schema.SchemaType() {
+	case JSON:
+		if err := json.Unmarshal(payload, &record); err != nil {
+			return nil, fmt.Errorf("failed to decode json payload of schema[%d]: %s", id, err)
+		}
+	case Avro:
+		codec, err := c.avroCodec(schema)
+		if err != nil {
+			return nil, err
+		}
+		native, _, err := codec.NativeFromBinary(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode avro payload of schema[%d]: %s", id, err)
+		}
+		// standard json unwraps unions into plain values
+		text, err := codec.TextualFromNative(nil, native)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert avro payload of schema[%d]: %s", id, err)
+		}
+		if err := json.Unmarshal(text, &record); err != nil {
+			return nil, fmt.Errorf("avro payload of schema[%d] is not a record: %s", id, err)
+		}
+	default:
+		return nil, fmt.Errorf("decoding %s payloads is not supported", schema.SchemaType())
+	}
+	return record, nil
+}
+
+// EncodeJSON frames record as a JSON payload of schema id
+func EncodeJSON(id int, record map[string]any) ([]byte, error) {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	return Frame(id, payload), nil
+}
+
+func (c *Client) avroCodec(schema *Schema) (*goavro.Codec, error) {
+	if codec, found := c.codecs.Load(schema.ID); found {
+		return codec.(*goavro.Codec), nil
+	}
+	codec, err := goavro.NewCodecForStandardJSONFull(schema.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse avro schema[%d]: %s", schema.ID, err)
+	}
+	c.codecs.Store(schema.ID, codec)
+	return codec, nil
+}
@@ -0,0 +1,210 @@
+package schemaregistry
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/datazip-inc/olake/types"
+	"github.com/goccy/go-json"
+)
+
+// TypeSchema converts a registered schema of record payloads to olake types
+func (s *Schema) TypeSchema() (*types.TypeSchema, error) {
+	var definition map[string]any
+	if s.SchemaType() == Protobuf {
+		return nil, fmt.Errorf("deriving types from %s schemas is not supported", Protobuf)
+	}
+	if err := json.Unmarshal([]byte(s.Schema), &definition); err != nil {
+		return nil, fmt.Errorf("failed to parse schema[%d]: %s", s.ID, err)
+	}
+
+	schema := types.NewTypeSchema()
+	switch s.SchemaType() {
+	case Avro:
+		if definition["type"] != "record" {
+			return nil, fmt.Errorf("schema[%d] is not an avro record", s.ID)
+		}
+		fields, _ := definition["fields"].([]any)
+		for _, field := range fields {
+			field, ok := field.(map[string]any)
+			if !ok {
+				continue
+			}
+			name, _ := field["name"].(string)
+			schema.AddTypes(name, avroTypes(field["type"])...)
+		}
+	case JSON:
+		properties, _ := definition["properties"].(map[string]any)
+		required := types.NewSet[string]()
+		if names, ok := definition["required"].([]any); ok {
+			for _, name := range names {
+				if name, ok := name.(string); ok {
+					required.Insert(name)
+				}
+			}
+		}
+		for name, property := range properties {
+			property, _ := property.(map[string]any)
+			dataTypes := jsonSchemaTypes(property)
+			if !required.Exists(name) {
+				dataTypes = append(dataTypes, types.Null)
+			}
+			schema.AddTypes(name, dataTypes...)
+		}
+	}
+	return schema, nil
+}
+
+func avroTypes(avroType any) []types.DataType {
+	switch avroType := avroType.(type) {
+	case []any: // union
+		var dataTypes []types.DataType
+		for _, member := range avroType {
+			dataTypes = append(dataTypes, avroTypes(member)...)
+		}
+		return dataTypes
+	case map[string]any:
+		switch avroType["logicalType"] {
+		case "timestamp-millis", "local-timestamp-millis":
+			return []types.DataType{types.TimestampMilli}
+		case "timestamp-micros", "local-timestamp-micros":
+			return []types.DataType{types.TimestampMicro}
+		case "date":
+			return []types.DataType{types.Timestamp}
+		case "decimal":
+			return []types.DataType{types.Float64}
+		}
+		return avroTypes(avroType["type"])
+	case string:
+		switch avroType {
+		case "null":
+			return []types.DataType{types.Null}
+		case "boolean":
+			return []types.DataType{types.Bool}
+		case "int", "long":
+			return []types.DataType{types.Int64}
+		case "float", "double":
+			return []types.DataType{types.Float64}
+		case "string", "enum":
+			return []types.DataType{types.String}
+		case "bytes", "fixed":
+			return []types.DataType{types.Bytes}
+		case "array":
+			return []types.DataType{types.Array}
+		}
+		// records, maps and references to named types
+		return []types.DataType{types.Object}
+	}
+	return []types.DataType{types.Unknown}
+}
+
+func jsonSchemaTypes(property map[string]any) []types.DataType {
+	var names []string
+	switch typ := property["type"].(type) {
+	case string:
+		names = []string{typ}
+	case []any:
+		for _, name := range typ {
+			if name, ok := name.(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	var dataTypes []types.DataType
+	for _, name := range names {
+		switch name {
+		case "null":
+			dataTypes = append(dataTypes, types.Null)
+		case "boolean":
+			dataTypes = append(dataTypes, types.Bool)
+		case "integer":
+			dataTypes = append(dataTypes, types.Int64)
+		case "number":
+			dataTypes = append(dataTypes, types.Float64)
+		case "string":
+			if format, _ := property["format"].(string); format == "date-time" || format == "date" {
+				dataTypes = append(dataTypes, types.Timestamp)
+			} else {
+				dataTypes = append(dataTypes, types.String)
+			}
+		case "object":
+			dataTypes = append(dataTypes, types.Object)
+		case "array":
+			dataTypes = append(dataTypes, types.Array)
+		}
+	}
+	if len(dataTypes) == 0 {
+		return []types.DataType{types.Unknown}
+	}
+	return dataTypes
+}
+
+// JSONSchema returns the JSON schema of records typed by schema
+func JSONSchema(schema *types.TypeSchema) (string, error) {
+	properties := make(map[string]any)
+	required := []string{}
+	schema.Properties.Range(func(key, value any) bool {
+		property := value.(*types.Property)
+		var names []string
+		format := ""
+		anyValue := false
+		for _, dataType := range property.Type.Array() {
+			switch dataType {
+			case types.JSON:
+				anyValue = true
+			case types.Null:
+				names = append(names, "null")
+			case types.Bool:
+				names = append(names, "boolean")
+			case types.Int64:
+				names = append(names, "integer")
+			case types.Float64:
+				names = append(names, "number")
+			case types.Object:
+				names = append(names, "object")
+			case types.Array:
+				names = append(names, "array")
+			case types.Timestamp, types.TimestampMilli, types.TimestampMicro, types.TimestampNano:
+				names = append(names, "string")
+				format = "date-time"
+			default:
+				names = append(names, "string")
+			}
+		}
+		sort.Strings(names)
+		definition := map[string]any{"type": dedupe(names)}
+		if format != "" {
+			definition["format"] = format
+		}
+		if anyValue {
+			// json columns hold any value
+			definition = map[string]any{}
+		}
+		properties[key.(string)] = definition
+		if !property.Nullable() {
+			required = append(required, key.(string))
+		}
+		return true
+	})
+	sort.Strings(required)
+
+	data, err := json.Marshal(map[string]any{
+		"$schema":    "http://json-schema.org/draft-07/schema#",
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	})
+	return string(data), err
+}
+
+// dedupe removes repeats from sorted names
+func dedupe(names []string) []string {
+	unique := names[:0]
+	for idx, name := range names {
+		if idx == 0 || names[idx-1] != name {
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
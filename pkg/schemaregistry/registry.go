@@ -0,0 +1,185 @@
+// Package schemaregistry is a client of the Confluent Schema Registry REST API, used by the
+// Kafka source and sink to decode and encode payloads with their registered schemas
+package schemaregistry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/datazip-inc/olake/pkg/network"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/goccy/go-json"
+)
+
+type SchemaType string
+
+const (
+	Avro     SchemaType = "AVRO"
+	Protobuf SchemaType = "PROTOBUF"
+	JSON     SchemaType = "JSON"
+)
+
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+var ErrSubjectNotFound = errors.New("subject not found in schema registry")
+
+type Config struct {
+	URL      string `json:"url" validate:"required"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// proxy and tls settings of the registry connection
+	Network *network.Config `json:"network,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	if _, err := url.Parse(c.URL); err != nil {
+		return fmt.Errorf("invalid schema registry url[%s]: %s", c.URL, err)
+	}
+	if err := c.Network.Validate(); err != nil {
+		return err
+	}
+	return utils.Validate(c)
+}
+
+// Schema is a registered schema; Type is empty in registry responses for Avro schemas
+type Schema struct {
+	ID      int        `json:"id"`
+	Subject string     `json:"subject,omitempty"`
+	Version int        `json:"version,omitempty"`
+	Type    SchemaType `json:"schemaType,omitempty"`
+	Schema  string     `json:"schema"`
+}
+
+func (s *Schema) SchemaType() SchemaType {
+	if s.Type == "" {
+		return Avro
+	}
+	return s.Type
+}
+
+// Client caches schemas by id as registered schemas are immutable; safe for concurrent use
+type Client struct {
+	config *Config
+	http   *http.Client
+	mutex  sync.RWMutex
+	byID   map[int]*Schema
+	codecs sync.Map // schema id to decoder
+}
+
+func New(config *Config) (*Client, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	transport, err := config.Network.HTTPTransport()
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		config: config,
+		http:   &http.Client{Timeout: time.Minute, Transport: transport},
+		byID:   make(map[int]*Schema),
+	}, nil
+}
+
+// TopicSubject returns the value subject of a topic under the default topic name strategy
+func TopicSubject(topic string) string {
+	return topic + "-value"
+}
+
+// SchemaByID returns the schema registered with id
+func (c *Client) SchemaByID(ctx context.Context, id int) (*Schema, error) {
+	c.mutex.RLock()
+	schema, found := c.byID[id]
+	c.mutex.RUnlock()
+	if found {
+		return schema, nil
+	}
+
+	schema = &Schema{}
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, schema); err != nil {
+		return nil, fmt.Errorf("failed to fetch schema[%d]: %s", id, err)
+	}
+	schema.ID = id
+
+	c.mutex.Lock()
+	c.byID[id] = schema
+	c.mutex.Unlock()
+	return schema, nil
+}
+
+// Latest returns the latest schema version of subject; ErrSubjectNotFound if none is registered
+func (c *Client) Latest(ctx context.Context, subject string) (*Schema, error) {
+	schema := &Schema{}
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/subjects/%s/versions/latest", url.PathEscape(subject)), nil, schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// Register registers schema under subject, returning its id; registering an existing schema
+// returns the existing id
+func (c *Client) Register(ctx context.Context, subject string, typ SchemaType, schema string) (int, error) {
+	request := &Schema{Schema: schema}
+	if typ != Avro {
+		request.Type = typ
+	}
+	response := &Schema{}
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/subjects/%s/versions", url.PathEscape(subject)), request, response); err != nil {
+		return 0, fmt.Errorf("failed to register schema for subject[%s]: %s", subject, err)
+	}
+	return response.ID, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.config.URL+path, payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", contentType)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.config.Username != "" {
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		registryErr := struct {
+			Code    int    `json:"error_code"`
+			Message string `json:"message"`
+		}{}
+		_ = json.Unmarshal(data, &registryErr)
+		// 40401 is subject not found, 40402 version not found
+		if resp.StatusCode == http.StatusNotFound && (registryErr.Code == 40401 || registryErr.Code == 40402) {
+			return ErrSubjectNotFound
+		}
+		return fmt.Errorf("schema registry responded with status %d: %s", resp.StatusCode, registryErr.Message)
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,129 @@
+package schemaregistry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+	"github.com/goccy/go-json"
+	"github.com/linkedin/goavro/v2"
+)
+
+const userSchema = `{"type":"record","name":"User","fields":[
+	{"name":"id","type":"long"},
+	{"name":"email","type":["null","string"],"default":null},
+	{"name":"created_at","type":{"type":"long","logicalType":"timestamp-millis"}}
+]}`
+
+func newTestClient(t *testing.T) *Client {
+	schemas := map[string]*Schema{
+		"/schemas/ids/1": {Schema: userSchema},
+		"/schemas/ids/2": {Type: JSON, Schema: `{"type":"object","properties":{"id":{"type":"integer"},"tags":{"type":"array"}},"required":["id"]}`},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if schema, found := schemas[r.URL.Path]; found {
+			_ = json.NewEncoder(w).Encode(schema)
+			return
+		}
+		if r.Method == http.MethodPost && r.URL.Path == "/subjects/users-value/versions" {
+			_ = json.NewEncoder(w).Encode(map[string]int{"id": 3})
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error_code": 40401, "message": "Subject not found."})
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := New(&Config{URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestFrame(t *testing.T) {
+	id, payload, err := Unframe(Frame(42, []byte("data")))
+	if err != nil || id != 42 || string(payload) != "data" {
+		t.Fatalf("round trip failed: id=%d payload=%q err=%v", id, payload, err)
+	}
+	if _, _, err := Unframe([]byte("{}")); !errors.Is(err, ErrNotFramed) {
+		t.Fatalf("expected ErrNotFramed, got %v", err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	codec, err := goavro.NewCodec(userSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := codec.BinaryFromNative(nil, map[string]any{
+		"id":         int64(7),
+		"email":      goavro.Union("string", "a@b.c"),
+		"created_at": int64(1700000000000),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := client.Decode(ctx, Frame(1, payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record["id"] != float64(7) || record["email"] != "a@b.c" {
+		t.Fatalf("unexpected avro record %v", record)
+	}
+
+	record, err = client.Decode(ctx, Frame(2, []byte(`{"id":1,"tags":["x"]}`)))
+	if err != nil || record["id"] != float64(1) {
+		t.Fatalf("unexpected json record %v: %v", record, err)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	if _, err := client.Latest(ctx, TopicSubject("missing")); !errors.Is(err, ErrSubjectNotFound) {
+		t.Fatalf("expected ErrSubjectNotFound, got %v", err)
+	}
+	id, err := client.Register(ctx, TopicSubject("users"), JSON, "{}")
+	if err != nil || id != 3 {
+		t.Fatalf("expected id 3, got %d: %v", id, err)
+	}
+}
+
+func TestTypeSchema(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	expected := map[int]map[string][]types.DataType{
+		1: {"id": {types.Int64}, "email": {types.Null, types.String}, "created_at": {types.TimestampMilli}},
+		2: {"id": {types.Int64}, "tags": {types.Array, types.Null}},
+	}
+	for id, columns := range expected {
+		schema, err := client.SchemaByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		typeSchema, err := schema.TypeSchema()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for column, dataTypes := range columns {
+			found, property := typeSchema.GetProperty(column)
+			if !found || property.Type.Len() != len(dataTypes) {
+				t.Fatalf("schema[%d] column[%s]: expected %v, got %v", id, column, dataTypes, property)
+			}
+			for _, dataType := range dataTypes {
+				if !property.Type.Exists(dataType) {
+					t.Fatalf("schema[%d] column[%s]: missing %s", id, column, dataType)
+				}
+			}
+		}
+	}
+}
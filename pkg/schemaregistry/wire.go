@@ -0,0 +1,36 @@
+package schemaregistry
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// payloads are framed with a magic byte followed by the big endian schema id
+const (
+	magicByte  = 0
+	headerSize = 5
+)
+
+var ErrNotFramed = errors.New("payload is not framed with a schema id")
+
+// Frame prefixes payload with the wire format header of schema id
+func Frame(id int, payload []byte) []byte {
+	framed := make([]byte, headerSize, headerSize+len(payload))
+	framed[0] = magicByte
+	binary.BigEndian.PutUint32(framed[1:headerSize], uint32(id))
+	return append(framed, payload...)
+}
+
+// FrameProtobuf frames a protobuf payload; protobuf frames carry the index of the message in
+// the schema after the id, a single zero byte for the first message
+func FrameProtobuf(id int, payload []byte) []byte {
+	return Frame(id, append([]byte{0}, payload...))
+}
+
+// Unframe returns the schema id and the payload of a framed message
+func Unframe(message []byte) (int, []byte, error) {
+	if len(message) < headerSize || message[0] != magicByte {
+		return 0, nil, ErrNotFramed
+	}
+	return int(binary.BigEndian.Uint32(message[1:headerSize])), message[headerSize:], nil
+}
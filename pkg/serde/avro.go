@@ -0,0 +1,161 @@
+package serde
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/datazip-inc/olake/types"
+	"github.com/goccy/go-json"
+	"github.com/linkedin/goavro/v2"
+)
+
+// avroEncoder encodes records as Avro binary; every field is a union with null as columns may
+// be missing from a record
+type avroEncoder struct {
+	schema  string
+	codec   *goavro.Codec
+	columns []column
+	members []string // union member name of every column
+}
+
+// AvroSchema returns the Avro record schema of records typed by schema
+func AvroSchema(name string, schema *types.TypeSchema) (string, error) {
+	columns, err := columns(schema)
+	if err != nil {
+		return "", err
+	}
+	definition, _ := avroDefinition(name, columns)
+	return definition, nil
+}
+
+func avroDefinition(name string, columns []column) (string, []string) {
+	fields := make([]map[string]any, 0, len(columns))
+	members := make([]string, 0, len(columns))
+	for _, column := range columns {
+		avroType, member := avroType(column.dataType)
+		fields = append(fields, map[string]any{
+			"name":    column.identifier,
+			"type":    []any{"null", avroType},
+			"default": nil,
+		})
+		members = append(members, member)
+	}
+	definition, _ := json.Marshal(map[string]any{
+		"type":   "record",
+		"name":   types.SanitizeIdentifier(name),
+		"fields": fields,
+	})
+	return string(definition), members
+}
+
+// avroType returns the Avro type of a data type and its name as a union member
+func avroType(dataType types.DataType) (any, string) {
+	switch dataType {
+	case types.Int64:
+		return "long", "long"
+	case types.Float64:
+		return "double", "double"
+	case types.Bool:
+		return "boolean", "boolean"
+	case types.Timestamp, types.TimestampMilli:
+		return map[string]any{"type": "long", "logicalType": "timestamp-millis"}, "long.timestamp-millis"
+	case types.TimestampMicro, types.TimestampNano:
+		return map[string]any{"type": "long", "logicalType": "timestamp-micros"}, "long.timestamp-micros"
+	case types.Bytes:
+		return "bytes", "bytes"
+	default:
+		// nested values are carried as JSON strings, geometries as WKT
+		return "string", "string"
+	}
+}
+
+func newAvroEncoder(name string, schema *types.TypeSchema) (*avroEncoder, error) {
+	columns, err := columns(schema)
+	if err != nil {
+		return nil, err
+	}
+	definition, members := avroDefinition(name, columns)
+	codec, err := goavro.NewCodec(definition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build avro codec: %s", err)
+	}
+	return &avroEncoder{schema: definition, codec: codec, columns: columns, members: members}, nil
+}
+
+func (a *avroEncoder) Encode(record map[string]any) ([]byte, error) {
+	native, err := a.native(record)
+	if err != nil {
+		return nil, err
+	}
+	return a.codec.BinaryFromNative(nil, native)
+}
+
+// native converts record into the goavro representation of the schema record
+func (a *avroEncoder) native(record map[string]any) (map[string]any, error) {
+	native := make(map[string]any, len(a.columns))
+	for idx, column := range a.columns {
+		value, found := record[column.name]
+		if !found || value == nil {
+			native[column.identifier] = nil
+			continue
+		}
+		converted, err := column.value(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert column[%s]: %s", column.name, err)
+		}
+		native[column.identifier] = goavro.Union(a.members[idx], converted)
+	}
+	return native, nil
+}
+
+func (a *avroEncoder) Schema() string {
+	return a.schema
+}
+
+// avroBlockSize is the number of records per object container file block
+const avroBlockSize = 1000
+
+// AvroFileWriter writes records to an Avro object container file
+type AvroFileWriter struct {
+	encoder *avroEncoder
+	writer  *goavro.OCFWriter
+	block   []any
+}
+
+// NewAvroFileWriter writes the container header to w; compression is an Avro codec name:
+// null, deflate or snappy
+func NewAvroFileWriter(w io.Writer, name string, schema *types.TypeSchema, compression string) (*AvroFileWriter, error) {
+	encoder, err := newAvroEncoder(name, schema)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := goavro.NewOCFWriter(goavro.OCFConfig{W: w, Codec: encoder.codec, CompressionName: compression})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create avro file writer: %s", err)
+	}
+	return &AvroFileWriter{encoder: encoder, writer: writer, block: make([]any, 0, avroBlockSize)}, nil
+}
+
+func (a *AvroFileWriter) Write(record map[string]any) error {
+	native, err := a.encoder.native(record)
+	if err != nil {
+		return err
+	}
+	a.block = append(a.block, native)
+	if len(a.block) < avroBlockSize {
+		return nil
+	}
+	return a.Flush()
+}
+
+// Flush writes buffered records as a block
+func (a *AvroFileWriter) Flush() error {
+	if len(a.block) == 0 {
+		return nil
+	}
+	if err := a.writer.Append(a.block); err != nil {
+		return fmt.Errorf("failed to write avro block: %s", err)
+	}
+	a.block = a.block[:0]
+	return nil
+}
@@ -0,0 +1,148 @@
+package serde
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	// registers the well known timestamp type with the global registry
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	protobufPackage   = "olake"
+	timestampMessage  = ".google.protobuf.Timestamp"
+	timestampProtoDep = "google/protobuf/timestamp.proto"
+)
+
+// protobufEncoder encodes records as proto3 messages; every field is optional so missing
+// columns are distinguishable from zero values
+type protobufEncoder struct {
+	schema     string
+	descriptor protoreflect.MessageDescriptor
+	columns    []column
+}
+
+func newProtobufEncoder(name string, schema *types.TypeSchema) (*protobufEncoder, error) {
+	columns, err := columns(schema)
+	if err != nil {
+		return nil, err
+	}
+	file := protobufFile(types.SanitizeIdentifier(name), columns)
+	descriptor, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build protobuf descriptor: %s", err)
+	}
+	return &protobufEncoder{
+		schema:     protobufDefinition(file),
+		descriptor: descriptor.Messages().Get(0),
+		columns:    columns,
+	}, nil
+}
+
+// protobufFile returns a file holding a single message with a field per column; field numbers
+// follow the sorted column order
+func protobufFile(name string, columns []column) *descriptorpb.FileDescriptorProto {
+	message := &descriptorpb.DescriptorProto{Name: proto.String(name)}
+	usesTimestamp := false
+	for idx, column := range columns {
+		field := &descriptorpb.FieldDescriptorProto{
+			Name:           proto.String(column.identifier),
+			JsonName:       proto.String(column.identifier),
+			Number:         proto.Int32(int32(idx + 1)),
+			Label:          descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Proto3Optional: proto.Bool(true),
+			// proto3 optional fields live in a synthetic oneof of their own
+			OneofIndex: proto.Int32(int32(idx)),
+		}
+		switch column.dataType {
+		case types.Int64:
+			field.Type = descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum()
+		case types.Float64:
+			field.Type = descriptorpb.FieldDescriptorProto_TYPE_DOUBLE.Enum()
+		case types.Bool:
+			field.Type = descriptorpb.FieldDescriptorProto_TYPE_BOOL.Enum()
+		case types.Bytes:
+			field.Type = descriptorpb.FieldDescriptorProto_TYPE_BYTES.Enum()
+		case types.Timestamp, types.TimestampMilli, types.TimestampMicro, types.TimestampNano:
+			field.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+			field.TypeName = proto.String(timestampMessage)
+			usesTimestamp = true
+		default:
+			field.Type = descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+		}
+		message.Field = append(message.Field, field)
+		message.OneofDecl = append(message.OneofDecl, &descriptorpb.OneofDescriptorProto{
+			Name: proto.String("_" + column.identifier),
+		})
+	}
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:        proto.String(name + ".proto"),
+		Package:     proto.String(protobufPackage),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{message},
+	}
+	if usesTimestamp {
+		file.Dependency = []string{timestampProtoDep}
+	}
+	return file
+}
+
+// protobufDefinition renders the file as .proto source, the form schema registries expect
+func protobufDefinition(file *descriptorpb.FileDescriptorProto) string {
+	var builder strings.Builder
+	builder.WriteString("syntax = \"proto3\";\n")
+	fmt.Fprintf(&builder, "package %s;\n", file.GetPackage())
+	for _, dependency := range file.GetDependency() {
+		fmt.Fprintf(&builder, "import \"%s\";\n", dependency)
+	}
+	for _, message := range file.GetMessageType() {
+		fmt.Fprintf(&builder, "\nmessage %s {\n", message.GetName())
+		for _, field := range message.GetField() {
+			fieldType := strings.ToLower(strings.TrimPrefix(field.GetType().String(), "TYPE_"))
+			if field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
+				fieldType = strings.TrimPrefix(field.GetTypeName(), ".")
+			}
+			fmt.Fprintf(&builder, "  optional %s %s = %d;\n", fieldType, field.GetName(), field.GetNumber())
+		}
+		builder.WriteString("}\n")
+	}
+	return builder.String()
+}
+
+func (p *protobufEncoder) Encode(record map[string]any) ([]byte, error) {
+	message := dynamicpb.NewMessage(p.descriptor)
+	fields := p.descriptor.Fields()
+	for idx, column := range p.columns {
+		value, found := record[column.name]
+		if !found || value == nil {
+			continue
+		}
+		converted, err := column.value(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert column[%s]: %s", column.name, err)
+		}
+		field := fields.Get(idx)
+		if timestamp, ok := converted.(time.Time); ok {
+			nested := message.Mutable(field).Message()
+			nested.Set(nested.Descriptor().Fields().ByName("seconds"), protoreflect.ValueOfInt64(timestamp.Unix()))
+			nested.Set(nested.Descriptor().Fields().ByName("nanos"), protoreflect.ValueOfInt32(int32(timestamp.Nanosecond())))
+			continue
+		}
+		message.Set(field, protoreflect.ValueOf(converted))
+	}
+	return proto.Marshal(message)
+}
+
+func (p *protobufEncoder) Schema() string {
+	return p.schema
+}
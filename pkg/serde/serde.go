@@ -0,0 +1,103 @@
+// Package serde serializes records into the payload formats writers can emit, deriving the
+// payload schema from the olake stream schema
+package serde
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/datazip-inc/olake/pkg/jsonx"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+)
+
+type Format string
+
+const (
+	JSON     Format = "json"
+	Avro     Format = "avro"
+	Protobuf Format = "protobuf"
+)
+
+func (f Format) Validate() error {
+	switch f {
+	case JSON, Avro, Protobuf:
+		return nil
+	default:
+		return fmt.Errorf("invalid format[%s]; expected json, avro or protobuf", f)
+	}
+}
+
+// Encoder serializes records of one stream schema
+type Encoder interface {
+	Encode(record map[string]any) ([]byte, error)
+	// Schema returns the schema definition of the payloads; empty for JSON
+	Schema() string
+}
+
+// NewEncoder returns an encoder of records typed by schema; name is used as the Avro record and
+// Protobuf message name
+func NewEncoder(format Format, name string, schema *types.TypeSchema) (Encoder, error) {
+	switch format {
+	case JSON:
+		return jsonEncoder{}, nil
+	case Avro:
+		return newAvroEncoder(name, schema)
+	case Protobuf:
+		return newProtobufEncoder(name, schema)
+	default:
+		return nil, format.Validate()
+	}
+}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) Encode(record map[string]any) ([]byte, error) {
+	return jsonx.Marshal(record)
+}
+
+func (jsonEncoder) Schema() string {
+	return ""
+}
+
+// column is a schema column with the identifier used for it in the payload schema
+type column struct {
+	name       string
+	identifier string
+	dataType   types.DataType
+}
+
+// columns returns the schema columns sorted by name; identifiers must stay unique after
+// sanitization as Avro and Protobuf restrict field names
+func columns(schema *types.TypeSchema) ([]column, error) {
+	var result []column
+	schema.Properties.Range(func(key, value any) bool {
+		result = append(result, column{
+			name:       key.(string),
+			identifier: types.SanitizeIdentifier(key.(string)),
+			dataType:   value.(*types.Property).DataType(),
+		})
+		return true
+	})
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+
+	identifiers := make(map[string]string)
+	for _, column := range result {
+		if other, found := identifiers[column.identifier]; found {
+			return nil, fmt.Errorf("columns[%s] and [%s] map to the same field name[%s]", other, column.name, column.identifier)
+		}
+		identifiers[column.identifier] = column.name
+	}
+	return result, nil
+}
+
+// value converts v into the Go type of the column data type; nested values are JSON strings
+// and geometries WKT
+func (c column) value(v any) (any, error) {
+	switch c.dataType {
+	case types.Object, types.Array, types.Unknown, types.Null, types.Geometry, types.JSON:
+		return typeutils.Coerce(types.String, v)
+	default:
+		return typeutils.Coerce(c.dataType, v)
+	}
+}
@@ -0,0 +1,134 @@
+package serde
+
+import (
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+	"github.com/linkedin/goavro/v2"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func testSchema() *types.TypeSchema {
+	schema := types.NewTypeSchema()
+	schema.AddTypes("id", types.Int64)
+	schema.AddTypes("price", types.Float64, types.Null)
+	schema.AddTypes("active", types.Bool)
+	schema.AddTypes("name", types.String, types.Null)
+	schema.AddTypes("created-at", types.TimestampMilli)
+	schema.AddTypes("tags", types.Array)
+	schema.AddTypes("location", types.Geometry, types.Null)
+	return schema
+}
+
+func testRecord() map[string]any {
+	return map[string]any{
+		"id":         int64(7),
+		"price":      12.5,
+		"active":     true,
+		"created-at": time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC),
+		"tags":       []any{"a", "b"},
+		"location":   types.WKB{1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 240, 63, 0, 0, 0, 0, 0, 0, 0, 64},
+	}
+}
+
+func TestAvroRoundTrip(t *testing.T) {
+	encoder, err := NewEncoder(Avro, "orders", testSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := encoder.Encode(testRecord())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codec, err := goavro.NewCodec(encoder.Schema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	native, _, err := codec.NativeFromBinary(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := native.(map[string]any)
+	expected := map[string]any{
+		"id":         map[string]any{"long": int64(7)},
+		"price":      map[string]any{"double": 12.5},
+		"active":     map[string]any{"boolean": true},
+		"created_at": map[string]any{"long.timestamp-millis": time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)},
+		"tags":       map[string]any{"string": `["a","b"]`},
+		"location":   map[string]any{"string": "POINT (1 2)"},
+	}
+	for field, value := range expected {
+		union, ok := record[field].(map[string]any)
+		if !ok {
+			t.Fatalf("field %s: expected union, got %v", field, record[field])
+		}
+		for member, expectedValue := range value.(map[string]any) {
+			actual := union[member]
+			if timestamp, ok := actual.(time.Time); ok {
+				if !timestamp.Equal(expectedValue.(time.Time)) {
+					t.Errorf("field %s: expected %v, got %v", field, expectedValue, actual)
+				}
+			} else if actual != expectedValue {
+				t.Errorf("field %s: expected %v, got %v", field, expectedValue, actual)
+			}
+		}
+	}
+	if record["name"] != nil {
+		t.Errorf("missing column must decode as null, got %v", record["name"])
+	}
+}
+
+func TestProtobufRoundTrip(t *testing.T) {
+	encoder, err := NewEncoder(Protobuf, "orders", testSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := encoder.Encode(testRecord())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	descriptor := encoder.(*protobufEncoder).descriptor
+	message := dynamicpb.NewMessage(descriptor)
+	if err := proto.Unmarshal(payload, message); err != nil {
+		t.Fatal(err)
+	}
+	fields := descriptor.Fields()
+	if got := message.Get(fields.ByName("id")).Int(); got != 7 {
+		t.Errorf("expected id 7, got %d", got)
+	}
+	if got := message.Get(fields.ByName("price")).Float(); got != 12.5 {
+		t.Errorf("expected price 12.5, got %v", got)
+	}
+	if got := message.Get(fields.ByName("tags")).String(); got != `["a","b"]` {
+		t.Errorf("expected tags as json, got %s", got)
+	}
+	if message.Has(fields.ByName("name")) {
+		t.Error("missing column must not be set")
+	}
+
+	timestamp := &timestamppb.Timestamp{}
+	raw, err := proto.Marshal(message.Get(fields.ByName("created_at")).Message().Interface())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proto.Unmarshal(raw, timestamp); err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC); !timestamp.AsTime().Equal(expected) {
+		t.Errorf("expected created_at %v, got %v", expected, timestamp.AsTime())
+	}
+}
+
+func TestColumnCollision(t *testing.T) {
+	schema := types.NewTypeSchema()
+	schema.AddTypes("created-at", types.String)
+	schema.AddTypes("created_at", types.String)
+	if _, err := NewEncoder(Avro, "orders", schema); err == nil {
+		t.Error("expected error for columns sanitized to the same field name")
+	}
+}
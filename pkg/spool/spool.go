@@ -0,0 +1,339 @@
+// Package spool is a first in first out queue of entries kept on disk in segment files, so a
+// fast producer isn't held back by a slow consumer. Entries are prefixed with their big endian
+// uint32 length; segments are removed once read. Queues don't outlive the process: a queue is
+// removed when its consumer is done.
+package spool
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// ErrClosed is returned when pushing to a closed queue
+var ErrClosed = errors.New("queue is closed")
+
+// readChunk bounds the bytes read from a segment at once
+const readChunk = 1 << 20
+
+type Config struct {
+	// folder queues are kept in; defaults to queue in the config folder
+	Path string `json:"path,omitempty"`
+	// size in bytes a segment file is rotated at
+	SegmentSize int64 `json:"segment_size,omitempty"`
+	// bytes queued on disk at most; pushes wait for the consumer beyond it, 0 for no limit
+	MaxSize int64 `json:"max_size,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	if c.SegmentSize < 0 || c.MaxSize < 0 {
+		return fmt.Errorf("queue segment_size and max_size must not be negative")
+	}
+	if c.Path == "" {
+		c.Path = filepath.Join(viper.GetString("CONFIG_FOLDER"), "queue")
+	}
+	if c.SegmentSize == 0 {
+		c.SegmentSize = 64 << 20
+	}
+	return nil
+}
+
+// Queue is safe for concurrent pushes and a single consumer popping entries
+type Queue struct {
+	dir         string
+	segmentSize int64
+	maxSize     int64
+
+	mutex   sync.Mutex
+	pushed  chan struct{} // signaled when an entry is pushed
+	popped  chan struct{} // signaled when an entry is popped
+	done    chan struct{} // closed with the queue
+	closed  bool
+	removed bool
+	size    int64 // bytes pushed and not popped yet
+
+	// write side, guarded by mutex
+	writeSegment int
+	writeFile    *os.File
+	writer       *bufio.Writer
+	written      int64         // bytes of writeSegment, buffered ones included
+	flushed      int64         // bytes of writeSegment readable from the file
+	sizes        map[int]int64 // sizes of the segments no more entries are written to
+
+	// read side, owned by the consumer
+	readSegment int
+	readFile    *os.File
+	readOffset  int64  // bytes of readSegment read from the file
+	pending     []byte // bytes read and not popped yet, a window of buffer
+	buffer      []byte
+}
+
+// Open creates an empty queue in a new folder under the path of config, named after name
+func Open(config *Config, name string) (*Queue, error) {
+	if err := os.MkdirAll(config.Path, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create queue folder[%s]: %s", config.Path, err)
+	}
+	dir, err := os.MkdirTemp(config.Path, name+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create queue in folder[%s]: %s", config.Path, err)
+	}
+	return &Queue{
+		dir:         dir,
+		segmentSize: config.SegmentSize,
+		maxSize:     config.MaxSize,
+		pushed:      make(chan struct{}, 1),
+		popped:      make(chan struct{}, 1),
+		done:        make(chan struct{}),
+		sizes:       make(map[int]int64),
+	}, nil
+}
+
+func (q *Queue) segmentPath(number int) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%08d.seg", number))
+}
+
+func signal(channel chan struct{}) {
+	select {
+	case channel <- struct{}{}:
+	default:
+	}
+}
+
+// Push appends entry to the queue, waiting for the consumer while the queue is full
+func (q *Queue) Push(ctx context.Context, entry []byte) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for q.maxSize > 0 && q.size >= q.maxSize && !q.closed {
+		q.mutex.Unlock()
+		select {
+		case <-q.popped:
+		case <-q.done:
+		case <-ctx.Done():
+			q.mutex.Lock()
+			return ctx.Err()
+		}
+		q.mutex.Lock()
+	}
+	if q.closed {
+		return ErrClosed
+	}
+	if q.writer == nil || q.written >= q.segmentSize {
+		if err := q.rotate(); err != nil {
+			return err
+		}
+	}
+
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(entry)))
+	if _, err := q.writer.Write(header[:]); err != nil {
+		return fmt.Errorf("failed to write to queue: %s", err)
+	}
+	if _, err := q.writer.Write(entry); err != nil {
+		return fmt.Errorf("failed to write to queue: %s", err)
+	}
+	size := int64(len(header) + len(entry))
+	q.written += size
+	q.size += size
+	signal(q.pushed)
+	return nil
+}
+
+// rotate closes the segment written to and starts the next one; called with mutex held
+func (q *Queue) rotate() error {
+	if q.writer != nil {
+		if err := q.closeWriter(); err != nil {
+			return err
+		}
+		q.writeSegment++
+	}
+	file, err := os.OpenFile(q.segmentPath(q.writeSegment), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create queue segment: %s", err)
+	}
+	q.writeFile = file
+	q.writer = bufio.NewWriterSize(file, readChunk)
+	q.written, q.flushed = 0, 0
+	return nil
+}
+
+// flush makes the buffered entries of the segment written to readable; called with mutex held
+func (q *Queue) flush() error {
+	if q.writer == nil || q.flushed == q.written {
+		return nil
+	}
+	if err := q.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush queue segment: %s", err)
+	}
+	q.flushed = q.written
+	return nil
+}
+
+// closeWriter completes the segment written to; called with mutex held
+func (q *Queue) closeWriter() error {
+	if err := q.flush(); err != nil {
+		return err
+	}
+	if err := q.writeFile.Close(); err != nil {
+		return fmt.Errorf("failed to close queue segment: %s", err)
+	}
+	q.sizes[q.writeSegment] = q.written
+	q.writeFile, q.writer = nil, nil
+	return nil
+}
+
+// Pop removes the oldest entry of the queue, waiting for one to be pushed; io.EOF is returned
+// once the queue is closed and every entry popped
+func (q *Queue) Pop(ctx context.Context) ([]byte, error) {
+	for {
+		if entry, ok := q.next(); ok {
+			q.mutex.Lock()
+			q.size -= int64(4 + len(entry))
+			q.mutex.Unlock()
+			signal(q.popped)
+			return entry, nil
+		}
+
+		q.mutex.Lock()
+		limit, complete, last, err := q.readable()
+		q.mutex.Unlock()
+		switch {
+		case err != nil:
+			return nil, err
+		case q.readOffset < limit:
+			if err := q.read(limit); err != nil {
+				return nil, err
+			}
+			continue
+		case complete && !last:
+			if err := q.nextSegment(); err != nil {
+				return nil, err
+			}
+			continue
+		case complete:
+			return nil, io.EOF
+		}
+
+		select {
+		case <-q.pushed:
+		case <-q.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// readable returns the bytes of the segment read that are in its file, if no more will be
+// written to it and if it's the last segment; called with mutex held
+func (q *Queue) readable() (limit int64, complete, last bool, err error) {
+	last = q.readSegment == q.writeSegment
+	if size, found := q.sizes[q.readSegment]; found {
+		return size, true, last, nil
+	}
+	if q.writer == nil {
+		// nothing pushed yet
+		return 0, q.closed, true, nil
+	}
+	// entries still buffered are flushed once the consumer caught up with the file
+	if q.readOffset == q.flushed {
+		err = q.flush()
+	}
+	return q.flushed, false, last, err
+}
+
+// next returns the first complete entry read from the segment, if any
+func (q *Queue) next() ([]byte, bool) {
+	if len(q.pending) < 4 {
+		return nil, false
+	}
+	size := int(binary.BigEndian.Uint32(q.pending))
+	if len(q.pending) < 4+size {
+		return nil, false
+	}
+	entry := make([]byte, size)
+	copy(entry, q.pending[4:4+size])
+	q.pending = q.pending[4+size:]
+	return entry, true
+}
+
+// read reads the segment up to limit, bytes known to be in the file
+func (q *Queue) read(limit int64) error {
+	if q.readFile == nil {
+		file, err := os.Open(q.segmentPath(q.readSegment))
+		if err != nil {
+			return fmt.Errorf("failed to open queue segment: %s", err)
+		}
+		q.readFile = file
+	}
+	size := min(limit-q.readOffset, readChunk)
+	// unread bytes move to the start of the buffer so it doesn't grow with the segment
+	start := len(q.pending)
+	q.pending = slices.Grow(append(q.buffer[:0], q.pending...), int(size))[:start+int(size)]
+	q.buffer = q.pending
+	if _, err := io.ReadFull(q.readFile, q.pending[start:]); err != nil {
+		return fmt.Errorf("failed to read queue segment: %s", err)
+	}
+	q.readOffset += size
+	return nil
+}
+
+// nextSegment removes the segment read and moves to the next one
+func (q *Queue) nextSegment() error {
+	if len(q.pending) > 0 {
+		return fmt.Errorf("queue segment[%d] ends with a partial entry", q.readSegment)
+	}
+	if q.readFile != nil {
+		q.readFile.Close()
+		q.readFile = nil
+	}
+	if err := os.Remove(q.segmentPath(q.readSegment)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove queue segment: %s", err)
+	}
+	q.mutex.Lock()
+	delete(q.sizes, q.readSegment)
+	q.mutex.Unlock()
+	q.readSegment++
+	q.readOffset = 0
+	return nil
+}
+
+// Close stops pushes; entries pushed already are still popped
+func (q *Queue) Close() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	close(q.done)
+	if q.writer != nil {
+		return q.closeWriter()
+	}
+	return nil
+}
+
+// Remove closes the queue and deletes its folder with the entries not popped; called once the
+// consumer stopped popping
+func (q *Queue) Remove() error {
+	if err := q.Close(); err != nil {
+		return err
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.removed {
+		return nil
+	}
+	q.removed = true
+	if q.readFile != nil {
+		q.readFile.Close()
+	}
+	return os.RemoveAll(q.dir)
+}
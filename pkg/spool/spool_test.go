@@ -0,0 +1,94 @@
+package spool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+	config := &Config{Path: t.TempDir(), SegmentSize: 64}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	queue, err := Open(config, "public.users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const entries = 500
+	go func() {
+		for i := 0; i < entries; i++ {
+			if err := queue.Push(ctx, []byte(fmt.Sprintf("entry-%d", i))); err != nil {
+				t.Error(err)
+			}
+		}
+		// empty entries are kept too
+		_ = queue.Push(ctx, nil)
+		queue.Close()
+	}()
+	for i := 0; i < entries; i++ {
+		entry, err := queue.Pop(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := fmt.Sprintf("entry-%d", i); string(entry) != expected {
+			t.Fatalf("expected %s, got %s", expected, entry)
+		}
+	}
+	if entry, err := queue.Pop(ctx); err != nil || len(entry) != 0 {
+		t.Fatalf("expected the empty entry, got %q, %v", entry, err)
+	}
+	if _, err := queue.Pop(ctx); err != io.EOF {
+		t.Fatalf("expected EOF once drained, got %v", err)
+	}
+	if err := queue.Push(ctx, []byte("late")); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected pushes to a closed queue to fail, got %v", err)
+	}
+	// read segments are removed
+	if files, _ := os.ReadDir(queue.dir); len(files) > 1 {
+		t.Fatalf("expected read segments to be removed, got %d files", len(files))
+	}
+	if err := queue.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(queue.dir); !os.IsNotExist(err) {
+		t.Fatalf("expected the queue folder to be removed, got %v", err)
+	}
+}
+
+func TestQueueMaxSize(t *testing.T) {
+	queue, err := Open(&Config{Path: t.TempDir(), SegmentSize: 1 << 20, MaxSize: 10}, "full")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer queue.Remove()
+	ctx := context.Background()
+	if err := queue.Push(ctx, []byte("0123456789")); err != nil {
+		t.Fatal(err)
+	}
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := queue.Push(timeout, []byte("more")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a full queue to hold pushes back, got %v", err)
+	}
+
+	pushed := make(chan error)
+	go func() {
+		pushed <- queue.Push(ctx, []byte("more"))
+	}()
+	if _, err := queue.Pop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-pushed; err != nil {
+		t.Fatalf("expected the push to go through once popped, got %v", err)
+	}
+	if entry, err := queue.Pop(ctx); err != nil || string(entry) != "more" {
+		t.Fatalf("expected more, got %q, %v", entry, err)
+	}
+}
@@ -0,0 +1,228 @@
+// Package sqlpool sizes and shares the connection pool of sql drivers, so parallel chunked
+// reads of many streams stay within the connection limit of the source
+package sqlpool
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Config is the pool section of a sql driver config
+type Config struct {
+	// connections open at once; defaults to 10
+	MaxConnections int `json:"max_connections,omitempty"`
+	// connections kept open while idle; defaults to max_connections
+	MaxIdleConnections int `json:"max_idle_connections,omitempty"`
+	// seconds an idle connection is kept; defaults to 300
+	IdleTimeout int `json:"idle_timeout,omitempty"`
+	// seconds a connection is reused for; 0 keeps connections until they are idle for too long
+	MaxLifetime int `json:"max_lifetime,omitempty"`
+	// connections a single stream may hold at once, so one large table can't starve the
+	// others; defaults to max_connections
+	StreamConnections int `json:"stream_connections,omitempty"`
+	// prepared statements of discovery and metadata queries kept per pool; defaults to 100,
+	// -1 disables the cache
+	StatementCacheSize int `json:"statement_cache_size,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	if c.MaxConnections < 0 || c.MaxIdleConnections < 0 || c.IdleTimeout < 0 || c.MaxLifetime < 0 || c.StreamConnections < 0 {
+		return fmt.Errorf("pool settings can't be negative")
+	}
+	if c.MaxConnections == 0 {
+		c.MaxConnections = 10
+	}
+	if c.MaxIdleConnections == 0 || c.MaxIdleConnections > c.MaxConnections {
+		c.MaxIdleConnections = c.MaxConnections
+	}
+	if c.IdleTimeout == 0 {
+		c.IdleTimeout = 300
+	}
+	if c.StreamConnections == 0 || c.StreamConnections > c.MaxConnections {
+		c.StreamConnections = c.MaxConnections
+	}
+	if c.StatementCacheSize == 0 {
+		c.StatementCacheSize = 100
+	}
+	return nil
+}
+
+// Pool is a sql connection pool with per stream connection budgets and a statement cache
+type Pool struct {
+	*sqlx.DB
+	config *Config
+
+	mutex   sync.Mutex
+	budgets map[string]chan struct{}
+
+	statements *statementCache
+}
+
+// New applies the limits of config, which must be validated, to db
+func New(db *sqlx.DB, config *Config) *Pool {
+	db.SetMaxOpenConns(config.MaxConnections)
+	db.SetMaxIdleConns(config.MaxIdleConnections)
+	db.SetConnMaxIdleTime(time.Duration(config.IdleTimeout) * time.Second)
+	db.SetConnMaxLifetime(time.Duration(config.MaxLifetime) * time.Second)
+	return &Pool{
+		DB:         db,
+		config:     config,
+		budgets:    make(map[string]chan struct{}),
+		statements: newStatementCache(config.StatementCacheSize),
+	}
+}
+
+// Acquire blocks until stream is within its connection budget; the returned function gives
+// the connection back and must be called once the connection is released to the pool
+func (p *Pool) Acquire(ctx context.Context, stream string) (func(), error) {
+	p.mutex.Lock()
+	budget, found := p.budgets[stream]
+	if !found {
+		budget = make(chan struct{}, p.config.StreamConnections)
+		p.budgets[stream] = budget
+	}
+	p.mutex.Unlock()
+
+	select {
+	case budget <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-budget })
+	}, nil
+}
+
+// SelectContext runs query through a cached prepared statement and scans all rows into dest
+func (p *Pool) SelectContext(ctx context.Context, dest any, query string, args ...any) error {
+	if p.statements == nil {
+		return p.DB.SelectContext(ctx, dest, query, args...)
+	}
+	return p.statements.with(query, p.prepare(ctx, query), func(stmt *sqlx.Stmt) error {
+		return stmt.SelectContext(ctx, dest, args...)
+	})
+}
+
+// GetContext runs query through a cached prepared statement and scans its row into dest
+func (p *Pool) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	if p.statements == nil {
+		return p.DB.GetContext(ctx, dest, query, args...)
+	}
+	return p.statements.with(query, p.prepare(ctx, query), func(stmt *sqlx.Stmt) error {
+		return stmt.GetContext(ctx, dest, args...)
+	})
+}
+
+func (p *Pool) prepare(ctx context.Context, query string) func() (*sqlx.Stmt, error) {
+	return func() (*sqlx.Stmt, error) {
+		return p.DB.PreparexContext(ctx, query)
+	}
+}
+
+// Close closes the cached statements and the connections of the pool
+func (p *Pool) Close() error {
+	if p.statements != nil {
+		p.statements.close()
+	}
+	stats := p.DB.Stats()
+	logger.Debugf("Closing sql pool; %d connections were waited for %s in total", stats.WaitCount, stats.WaitDuration)
+	return p.DB.Close()
+}
+
+// statementCache keeps the most recently used prepared statements; statements are safe for
+// concurrent use across connections
+type statementCache struct {
+	mutex   sync.Mutex
+	size    int
+	order   *list.List // front is the most recently used
+	entries map[string]*list.Element
+}
+
+type cachedStatement struct {
+	query string
+	stmt  *sqlx.Stmt
+	// queries running on the statement; an evicted statement is closed once they finish
+	users   int
+	evicted bool
+}
+
+func newStatementCache(size int) *statementCache {
+	if size < 0 {
+		return nil
+	}
+	return &statementCache{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// with runs use with the cached statement of query, preparing it when it isn't cached
+func (c *statementCache) with(query string, prepare func() (*sqlx.Stmt, error), use func(*sqlx.Stmt) error) error {
+	entry, err := c.acquire(query, prepare)
+	if err != nil {
+		return err
+	}
+	defer c.release(entry)
+	return use(entry.stmt)
+}
+
+func (c *statementCache) acquire(query string, prepare func() (*sqlx.Stmt, error)) (*cachedStatement, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, found := c.entries[query]; found {
+		c.order.MoveToFront(element)
+		entry := element.Value.(*cachedStatement)
+		entry.users++
+		return entry, nil
+	}
+
+	stmt, err := prepare()
+	if err != nil {
+		return nil, err
+	}
+	entry := &cachedStatement{query: query, stmt: stmt, users: 1}
+	c.entries[query] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Remove(c.order.Back()).(*cachedStatement)
+		delete(c.entries, oldest.query)
+		oldest.evicted = true
+		if oldest.users == 0 {
+			_ = oldest.stmt.Close()
+		}
+	}
+	return entry, nil
+}
+
+func (c *statementCache) release(entry *cachedStatement) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry.users--
+	if entry.evicted && entry.users == 0 {
+		_ = entry.stmt.Close()
+	}
+}
+
+// len is the number of cached statements
+func (c *statementCache) len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.order.Len()
+}
+
+func (c *statementCache) close() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, element := range c.entries {
+		entry := element.Value.(*cachedStatement)
+		entry.evicted = true
+		if entry.users == 0 {
+			_ = entry.stmt.Close()
+		}
+	}
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
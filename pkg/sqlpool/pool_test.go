@@ -0,0 +1,126 @@
+package sqlpool
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// countingConnector hands out connections whose statements return no rows and counts the
+// statements prepared and closed
+type countingConnector struct {
+	prepared atomic.Int32
+	closed   atomic.Int32
+}
+
+func (c *countingConnector) Connect(context.Context) (driver.Conn, error) {
+	return &countingConn{connector: c}, nil
+}
+
+func (c *countingConnector) Driver() driver.Driver { return nil }
+
+type countingConn struct{ connector *countingConnector }
+
+func (c *countingConn) Prepare(string) (driver.Stmt, error) {
+	c.connector.prepared.Add(1)
+	return &countingStmt{connector: c.connector}, nil
+}
+func (c *countingConn) Close() error              { return nil }
+func (c *countingConn) Begin() (driver.Tx, error) { return nil, driver.ErrSkip }
+
+type countingStmt struct{ connector *countingConnector }
+
+func (s *countingStmt) Close() error {
+	s.connector.closed.Add(1)
+	return nil
+}
+func (s *countingStmt) NumInput() int { return -1 }
+func (s *countingStmt) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+func (s *countingStmt) Query([]driver.Value) (driver.Rows, error) { return emptyRows{}, nil }
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string         { return []string{"id"} }
+func (emptyRows) Close() error              { return nil }
+func (emptyRows) Next([]driver.Value) error { return io.EOF }
+
+func newTestPool(t *testing.T, config *Config) (*Pool, *countingConnector) {
+	t.Helper()
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	connector := &countingConnector{}
+	return New(sqlx.NewDb(sql.OpenDB(connector), "counting"), config), connector
+}
+
+func TestStatementCache(t *testing.T) {
+	pool, connector := newTestPool(t, &Config{MaxConnections: 1, StatementCacheSize: 2})
+	defer pool.Close()
+
+	var ids []int
+	for _, query := range []string{"a", "b", "a", "a", "c", "b"} {
+		if err := pool.SelectContext(context.Background(), &ids, query); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a and b are prepared once each, c evicts b (least recently used), b is prepared again
+	if prepared := connector.prepared.Load(); prepared != 4 {
+		t.Fatalf("expected 4 prepared statements, got %d", prepared)
+	}
+	if closed := connector.closed.Load(); closed != 2 {
+		t.Fatalf("expected the 2 evicted statements to be closed, got %d", closed)
+	}
+	if pool.statements.len() != 2 {
+		t.Fatalf("expected 2 cached statements, got %d", pool.statements.len())
+	}
+}
+
+func TestStreamBudget(t *testing.T) {
+	pool, _ := newTestPool(t, &Config{MaxConnections: 4, StreamConnections: 2})
+	defer pool.Close()
+	ctx := context.Background()
+
+	first, err := pool.Acquire(ctx, "public.orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.Acquire(ctx, "public.orders"); err != nil {
+		t.Fatal(err)
+	}
+	// the budget of other streams is separate
+	if _, err := pool.Acquire(ctx, "public.users"); err != nil {
+		t.Fatal(err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Acquire(waitCtx, "public.orders"); err == nil {
+		t.Fatal("expected the third connection of a stream to wait for its budget")
+	}
+	first()
+	first() // releasing twice gives back one connection
+	if _, err := pool.Acquire(ctx, "public.orders"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateDefaults(t *testing.T) {
+	config := &Config{MaxConnections: 4, MaxIdleConnections: 8, StreamConnections: 6}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if config.MaxIdleConnections != 4 || config.StreamConnections != 4 || config.IdleTimeout != 300 || config.StatementCacheSize != 100 {
+		t.Fatalf("unexpected defaults %+v", config)
+	}
+	if err := (&Config{MaxConnections: -1}).Validate(); err == nil {
+		t.Fatal("expected negative limits to be rejected")
+	}
+}
@@ -0,0 +1,107 @@
+package statestore
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+)
+
+type BackendType string
+
+const (
+	BackendFile     BackendType = "file"
+	BackendIndexed  BackendType = "indexed"
+	BackendS3       BackendType = "s3"
+	BackendGCS      BackendType = "gcs"
+	BackendPostgres BackendType = "postgres"
+	BackendRedis    BackendType = "redis"
+)
+
+// gcs is reached through its s3 compatible api with hmac keys
+const gcsEndpoint = "https://storage.googleapis.com"
+
+// Config is read from the file passed with --state-config
+type Config struct {
+	Type BackendType `json:"type"`
+	// file and indexed backends; default to state.json and state.idx in the config folder
+	Path string `json:"path,omitempty"`
+	// identifies the state of the connection; object key for s3 and gcs, row key for
+	// postgres and key for redis
+	Key string `json:"key,omitempty"`
+	// s3 and gcs backends
+	Bucket    string `json:"bucket,omitempty"`
+	Region    string `json:"region,omitempty"`
+	Endpoint  string `json:"endpoint,omitempty"`
+	AccessKey string `json:"access_key,omitempty"`
+	SecretKey string `json:"secret_key,omitempty"`
+	// postgres backend; the table is created when missing
+	PostgresURL string `json:"postgres_url,omitempty"`
+	Table       string `json:"table,omitempty"`
+	// redis backend
+	RedisAddr     string `json:"redis_addr,omitempty"`
+	RedisPassword string `json:"redis_password,omitempty"`
+	RedisDB       int    `json:"redis_db,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	switch c.Type {
+	case "", BackendFile:
+		c.Type = BackendFile
+		if c.Path == "" {
+			c.Path = filepath.Join(viper.GetString("CONFIG_FOLDER"), "state.json")
+		}
+		return nil
+	case BackendIndexed:
+		if c.Path == "" {
+			c.Path = filepath.Join(viper.GetString("CONFIG_FOLDER"), "state.idx")
+		}
+		return nil
+	case BackendS3, BackendGCS:
+		if c.Bucket == "" {
+			return fmt.Errorf("bucket required for state backend[%s]", c.Type)
+		}
+		if c.Type == BackendGCS {
+			if c.Endpoint == "" {
+				c.Endpoint = gcsEndpoint
+			}
+			if c.Region == "" {
+				c.Region = "auto"
+			}
+		}
+		if c.Region == "" {
+			return fmt.Errorf("region required for state backend[%s]", c.Type)
+		}
+	case BackendPostgres:
+		if c.PostgresURL == "" {
+			return fmt.Errorf("postgres_url required for state backend[%s]", c.Type)
+		}
+		if c.Table == "" {
+			c.Table = "olake_state"
+		}
+	case BackendRedis:
+		if c.RedisAddr == "" {
+			return fmt.Errorf("redis_addr required for state backend[%s]", c.Type)
+		}
+	default:
+		return fmt.Errorf("invalid state backend type[%s]; expected one of file, indexed, s3, gcs, postgres, redis", c.Type)
+	}
+	if c.Key == "" {
+		return fmt.Errorf("key required for state backend[%s]", c.Type)
+	}
+	return nil
+}
+
+// Audit returns the config of the audit log kept beside the state: audit.jsonl next to the
+// state file, or the key suffixed with .audit; c must be validated
+func (c *Config) Audit() *Config {
+	audit := *c
+	if c.Type == BackendFile || c.Type == BackendIndexed {
+		// entries are lines of json, kept in a plain file next to an indexed state
+		audit.Type = BackendFile
+		audit.Path = filepath.Join(filepath.Dir(c.Path), "audit.jsonl")
+	} else {
+		audit.Key = c.Key + ".audit"
+	}
+	return &audit
+}
@@ -0,0 +1,359 @@
+package statestore
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/datazip-inc/olake/pkg/encryption"
+	"github.com/goccy/go-json"
+)
+
+const (
+	recordPut byte = iota + 1
+	recordRemove
+	recordCommit
+)
+
+// recordHeader is the kind, key length and data length of a record
+const recordHeader = 1 + 4 + 4
+
+// the log is compacted once it holds twice the bytes of the parts saved, and at least this
+const compactSize = 1 << 20
+
+// headerPart keys the state without its streams, as types.StateHeaderPart does
+const headerPart = "state"
+
+// streamPart keys the state of a stream, as types.StreamStatePart does
+func streamPart(namespace, stream string) string {
+	return namespace + "." + stream
+}
+
+// indexedStore keeps the state in a local file as a log of its parts, the state without its
+// streams and the state of each stream, so a checkpoint appends the streams it changed instead
+// of rewriting the state. Records are a kind byte, big endian uint32 key and data lengths, the
+// key, the data and a crc32 of all of them; a checkpoint ends with a commit record, and records
+// after the last commit are dropped on load, as a crash mid append leaves them. The log is
+// rewritten with the parts saved once it grows to twice their size.
+type indexedStore struct {
+	path string
+	file *os.File
+
+	loaded bool
+	parts  map[string][]byte // saved parts by key
+	size   int64             // bytes of the log up to its last commit
+	live   int64             // bytes of the records of saved parts
+}
+
+func (i *indexedStore) Load(_ context.Context) ([]byte, error) {
+	if err := i.load(); err != nil {
+		return nil, err
+	}
+	header, found := i.parts[headerPart]
+	if !found {
+		return nil, nil
+	}
+	header, err := encryption.Open(header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt state[%s]: %s", i.path, err)
+	}
+	state := map[string]json.RawMessage{}
+	if err := json.Unmarshal(header, &state); err != nil {
+		return nil, fmt.Errorf("failed to read state[%s]: %s", i.path, err)
+	}
+	if state == nil {
+		state = map[string]json.RawMessage{}
+	}
+	keys := make([]string, 0, len(i.parts))
+	for key := range i.parts {
+		if key != headerPart {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return header, nil
+	}
+	sort.Strings(keys)
+	streams := make([]json.RawMessage, 0, len(keys))
+	for _, key := range keys {
+		stream, err := encryption.Open(i.parts[key])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt state of stream[%s]: %s", key, err)
+		}
+		streams = append(streams, stream)
+	}
+	if state["streams"], err = json.Marshal(streams); err != nil {
+		return nil, err
+	}
+	return json.Marshal(state)
+}
+
+// Save replaces the saved parts with the parts of state
+func (i *indexedStore) Save(_ context.Context, state []byte) error {
+	if err := i.load(); err != nil {
+		return err
+	}
+	parts, err := splitState(state)
+	if err != nil {
+		return err
+	}
+	return i.rewrite(parts)
+}
+
+func (i *indexedStore) SaveParts(_ context.Context, parts map[string][]byte, full bool) error {
+	if err := i.load(); err != nil {
+		return err
+	}
+	if full {
+		saved := make(map[string][]byte, len(parts))
+		for key, data := range parts {
+			if data != nil {
+				saved[key] = data
+			}
+		}
+		return i.rewrite(saved)
+	}
+
+	if i.file == nil {
+		if err := os.MkdirAll(filepath.Dir(i.path), os.ModePerm); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(i.path, os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		i.file = file
+	}
+	// records of a checkpoint that failed mid append are dropped
+	if err := i.file.Truncate(i.size); err != nil {
+		return fmt.Errorf("failed to truncate state[%s]: %s", i.path, err)
+	}
+	if _, err := i.file.Seek(i.size, io.SeekStart); err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(i.file)
+	written := int64(0)
+	for key, data := range parts {
+		if data == nil {
+			written += writeRecord(writer, recordRemove, key, nil)
+		} else {
+			written += writeRecord(writer, recordPut, key, data)
+		}
+	}
+	written += writeRecord(writer, recordCommit, "", nil)
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write state[%s]: %s", i.path, err)
+	}
+	if err := i.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync state[%s]: %s", i.path, err)
+	}
+	i.size += written
+	for key, data := range parts {
+		i.apply(key, data)
+	}
+	if i.size > compactSize && i.size > 2*i.live {
+		return i.rewrite(i.parts)
+	}
+	return nil
+}
+
+// load reads the parts of the last checkpoint committed to the log, once
+func (i *indexedStore) load() error {
+	if i.loaded {
+		return nil
+	}
+	i.parts = make(map[string][]byte)
+	file, err := os.Open(i.path)
+	if os.IsNotExist(err) {
+		i.loaded = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	pending := map[string][]byte{}
+	offset := int64(0)
+	for {
+		kind, key, data, size, err := readRecord(reader)
+		if err != nil {
+			// io.EOF, or an incomplete checkpoint dropped with the records after it
+			break
+		}
+		offset += size
+		switch kind {
+		case recordPut:
+			pending[key] = data
+		case recordRemove:
+			pending[key] = nil
+		case recordCommit:
+			for key, data := range pending {
+				i.apply(key, data)
+			}
+			clear(pending)
+			i.size = offset
+		}
+	}
+	i.loaded = true
+	return nil
+}
+
+// apply keeps data as the saved part of key, removing it when nil
+func (i *indexedStore) apply(key string, data []byte) {
+	if previous, found := i.parts[key]; found {
+		i.live -= recordSize(key, previous)
+	}
+	if data == nil {
+		delete(i.parts, key)
+		return
+	}
+	i.parts[key] = data
+	i.live += recordSize(key, data)
+}
+
+// rewrite replaces the log with one checkpoint of parts
+func (i *indexedStore) rewrite(parts map[string][]byte) error {
+	if err := os.MkdirAll(filepath.Dir(i.path), os.ModePerm); err != nil {
+		return err
+	}
+	temp := i.path + ".tmp"
+	file, err := os.OpenFile(temp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(parts))
+	for key := range parts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	writer := bufio.NewWriter(file)
+	size, live := int64(0), int64(0)
+	for _, key := range keys {
+		written := writeRecord(writer, recordPut, key, parts[key])
+		size += written
+		live += written
+	}
+	size += writeRecord(writer, recordCommit, "", nil)
+	err = writer.Flush()
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write state[%s]: %s", i.path, err)
+	}
+	if i.file != nil {
+		i.file.Close()
+		i.file = nil
+	}
+	if err := os.Rename(temp, i.path); err != nil {
+		return err
+	}
+	i.parts, i.size, i.live = parts, size, live
+	return nil
+}
+
+func (i *indexedStore) Close() error {
+	if i.file == nil {
+		return nil
+	}
+	err := i.file.Close()
+	i.file = nil
+	return err
+}
+
+func recordSize(key string, data []byte) int64 {
+	return int64(recordHeader + len(key) + len(data) + 4)
+}
+
+// writeRecord buffers a record; errors surface when writer is flushed
+func writeRecord(writer *bufio.Writer, kind byte, key string, data []byte) int64 {
+	var header [recordHeader]byte
+	header[0] = kind
+	binary.BigEndian.PutUint32(header[1:5], uint32(len(key)))
+	binary.BigEndian.PutUint32(header[5:9], uint32(len(data)))
+	sum := crc32.NewIEEE()
+	sum.Write(header[:])
+	sum.Write([]byte(key))
+	sum.Write(data)
+	writer.Write(header[:])
+	writer.WriteString(key)
+	writer.Write(data)
+	binary.Write(writer, binary.BigEndian, sum.Sum32())
+	return recordSize(key, data)
+}
+
+// readRecord reads the next record; io.EOF is returned at the end of the log, other errors
+// for records cut short or corrupted
+func readRecord(reader *bufio.Reader) (kind byte, key string, data []byte, size int64, err error) {
+	var header [recordHeader]byte
+	if _, err = io.ReadFull(reader, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = fmt.Errorf("record cut short")
+		}
+		return
+	}
+	body := make([]byte, int(binary.BigEndian.Uint32(header[1:5]))+int(binary.BigEndian.Uint32(header[5:9]))+4)
+	if _, err = io.ReadFull(reader, body); err != nil {
+		return 0, "", nil, 0, fmt.Errorf("record cut short")
+	}
+	keyLength := int(binary.BigEndian.Uint32(header[1:5]))
+	sum := crc32.NewIEEE()
+	sum.Write(header[:])
+	sum.Write(body[:len(body)-4])
+	if sum.Sum32() != binary.BigEndian.Uint32(body[len(body)-4:]) {
+		return 0, "", nil, 0, fmt.Errorf("record checksum mismatch")
+	}
+	kind, key, data = header[0], string(body[:keyLength]), body[keyLength:len(body)-4]
+	return kind, key, data, int64(recordHeader + len(body)), nil
+}
+
+// splitState splits a serialized state into the parts it's saved as
+func splitState(state []byte) (map[string][]byte, error) {
+	state, err := encryption.Open(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt state: %s", err)
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(state, &fields); err != nil {
+		return nil, fmt.Errorf("failed to read state: %s", err)
+	}
+	parts := map[string][]byte{}
+	if raw, found := fields["streams"]; found {
+		delete(fields, "streams")
+		streams := []json.RawMessage{}
+		if err := json.Unmarshal(raw, &streams); err != nil {
+			return nil, fmt.Errorf("failed to read streams of state: %s", err)
+		}
+		for _, stream := range streams {
+			var id struct {
+				Stream    string `json:"stream"`
+				Namespace string `json:"namespace"`
+			}
+			if err := json.Unmarshal(stream, &id); err != nil {
+				return nil, fmt.Errorf("failed to read stream of state: %s", err)
+			}
+			if parts[streamPart(id.Namespace, id.Stream)], err = encryption.Seal(stream); err != nil {
+				return nil, err
+			}
+		}
+	}
+	header, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	if parts[headerPart], err = encryption.Seal(header); err != nil {
+		return nil, err
+	}
+	return parts, nil
+}
@@ -0,0 +1,62 @@
+package statestore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIndexedStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state.idx")
+	store := &indexedStore{path: path}
+	state := `{"type":"GLOBAL","global":{"state":{"lsn":"0/1"}},"streams":[{"stream":"users","namespace":"public","sync_mode":"","state":{"id":1}},{"stream":"orders","namespace":"public","sync_mode":"","state":{"id":2}}]}`
+	if err := store.Save(ctx, []byte(state)); err != nil {
+		t.Fatal(err)
+	}
+	// compacts once appended records outgrow the parts kept
+	large := []byte(fmt.Sprintf(`{"stream":"users","namespace":"public","state":{"id":%q}}`, strings.Repeat("x", 64<<10)))
+	for i := 0; i < 40; i++ {
+		if err := store.SaveParts(ctx, map[string][]byte{"public.users": large}, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if info, _ := os.Stat(path); info.Size() > 2*compactSize {
+		t.Fatalf("expected the log to be compacted, it holds %d bytes", info.Size())
+	}
+	if err := store.SaveParts(ctx, map[string][]byte{"public.orders": nil}, false); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	// records of a checkpoint cut short are dropped
+	file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	file.Write([]byte{recordPut, 0, 0, 0, 5, 0})
+	file.Close()
+
+	store = &indexedStore{path: path}
+	defer store.Close()
+	loaded, err := store.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{`"lsn":"0/1"`, `"type":"GLOBAL"`, string(large)} {
+		if !strings.Contains(string(loaded), expected) {
+			t.Fatalf("expected the last committed checkpoint, got %.200s", loaded)
+		}
+	}
+	if strings.Contains(string(loaded), `"orders"`) {
+		t.Fatal("expected the removed stream to stay removed")
+	}
+	// appends after the cut short records are read
+	if err := store.SaveParts(ctx, map[string][]byte{"public.orders": []byte(`{"stream":"orders","namespace":"public","state":{"id":3}}`)}, false); err != nil {
+		t.Fatal(err)
+	}
+	reopened := &indexedStore{path: path}
+	if loaded, _ := reopened.Load(ctx); !strings.Contains(string(loaded), `"id":3`) {
+		t.Fatalf("expected the appended stream, got %.200s", loaded)
+	}
+}
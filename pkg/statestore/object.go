@@ -0,0 +1,72 @@
+package statestore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// objectStore keeps the state in an s3 or gcs object; a put replaces the object atomically
+type objectStore struct {
+	client *s3.S3
+	bucket string
+	key    string
+}
+
+func newObjectStore(config *Config) (*objectStore, error) {
+	s3Config := aws.Config{
+		Region: aws.String(config.Region),
+	}
+	if config.Endpoint != "" {
+		s3Config.Endpoint = aws.String(config.Endpoint)
+	}
+	if config.AccessKey != "" && config.SecretKey != "" {
+		s3Config.Credentials = credentials.NewStaticCredentials(config.AccessKey, config.SecretKey, "")
+	}
+	sess, err := session.NewSession(&s3Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %s", err)
+	}
+	return &objectStore{client: s3.New(sess), bucket: config.Bucket, key: config.Key}, nil
+}
+
+func (o *objectStore) Load(ctx context.Context) ([]byte, error) {
+	output, err := o.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(o.bucket),
+		Key:    aws.String(o.key),
+	})
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download s3://%s/%s: %s", o.bucket, o.key, err)
+	}
+	defer output.Body.Close()
+	return io.ReadAll(output.Body)
+}
+
+func (o *objectStore) Save(ctx context.Context, state []byte) error {
+	_, err := o.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(o.bucket),
+		Key:         aws.String(o.key),
+		Body:        bytes.NewReader(state),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload s3://%s/%s: %s", o.bucket, o.key, err)
+	}
+	return nil
+}
+
+func (o *objectStore) Close() error {
+	return nil
+}
@@ -0,0 +1,61 @@
+package statestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// postgresStore keeps the state of every connection as a row of one table
+type postgresStore struct {
+	pool  *pgxpool.Pool
+	table string
+	key   string
+}
+
+func newPostgresStore(ctx context.Context, config *Config) (*postgresStore, error) {
+	pool, err := pgxpool.New(ctx, config.PostgresURL)
+	if err != nil {
+		return nil, err
+	}
+	store := &postgresStore{pool: pool, table: pgx.Identifier{config.Table}.Sanitize(), key: config.Key}
+	_, err = pool.Exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		key TEXT PRIMARY KEY,
+		state JSONB NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`, store.table))
+	if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to create state table[%s]: %s", config.Table, err)
+	}
+	return store, nil
+}
+
+func (p *postgresStore) Load(ctx context.Context) ([]byte, error) {
+	var state []byte
+	err := p.pool.QueryRow(ctx, fmt.Sprintf(`SELECT state FROM %s WHERE key = $1`, p.table), p.key).Scan(&state)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state[%s]: %s", p.key, err)
+	}
+	return state, nil
+}
+
+func (p *postgresStore) Save(ctx context.Context, state []byte) error {
+	_, err := p.pool.Exec(ctx, fmt.Sprintf(`INSERT INTO %s (key, state, updated_at) VALUES ($1, $2, now())
+		ON CONFLICT (key) DO UPDATE SET state = EXCLUDED.state, updated_at = EXCLUDED.updated_at`, p.table), p.key, state)
+	if err != nil {
+		return fmt.Errorf("failed to save state[%s]: %s", p.key, err)
+	}
+	return nil
+}
+
+func (p *postgresStore) Close() error {
+	p.pool.Close()
+	return nil
+}
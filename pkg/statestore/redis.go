@@ -0,0 +1,51 @@
+package statestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisStore keeps the state as a string value; persistence depends on the redis server
+// having AOF or RDB snapshots enabled
+type redisStore struct {
+	client *redis.Client
+	key    string
+}
+
+func newRedisStore(ctx context.Context, config *Config) (*redisStore, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     config.RedisAddr,
+		Password: config.RedisPassword,
+		DB:       config.RedisDB,
+	})
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &redisStore{client: client, key: config.Key}, nil
+}
+
+func (r *redisStore) Load(ctx context.Context) ([]byte, error) {
+	state, err := r.client.Get(ctx, r.key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state[%s]: %s", r.key, err)
+	}
+	return state, nil
+}
+
+func (r *redisStore) Save(ctx context.Context, state []byte) error {
+	if err := r.client.Set(ctx, r.key, state, 0).Err(); err != nil {
+		return fmt.Errorf("failed to save state[%s]: %s", r.key, err)
+	}
+	return nil
+}
+
+func (r *redisStore) Close() error {
+	return r.client.Close()
+}
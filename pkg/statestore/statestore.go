@@ -0,0 +1,112 @@
+// Package statestore persists sync state outside the local config folder, so deployments
+// without persistent volumes can still resume from the last checkpoint
+package statestore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// bounds a single load or save against a remote backend
+const operationTimeout = 30 * time.Second
+
+// Store loads and saves the serialized state of a connection
+type Store interface {
+	// Load returns nil when no state was saved yet
+	Load(ctx context.Context) ([]byte, error)
+	Save(ctx context.Context, state []byte) error
+	Close() error
+}
+
+func New(ctx context.Context, config *Config) (Store, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var store Store
+	var err error
+	switch config.Type {
+	case BackendFile:
+		store = &fileStore{path: config.Path}
+	case BackendIndexed:
+		store = &indexedStore{path: config.Path}
+	case BackendS3, BackendGCS:
+		store, err = newObjectStore(config)
+	case BackendPostgres:
+		store, err = newPostgresStore(ctx, config)
+	case BackendRedis:
+		store, err = newRedisStore(ctx, config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup state backend[%s]: %s", config.Type, err)
+	}
+	return store, nil
+}
+
+// PartStore saves the state part by part, so checkpoints only write the streams they changed;
+// parts are keyed as types.StatePartSaver keys them
+type PartStore interface {
+	Store
+	// SaveParts replaces the saved parts passed, removing those without data; with full, parts
+	// not passed are removed too
+	SaveParts(ctx context.Context, parts map[string][]byte, full bool) error
+}
+
+// Saver adapts a store to the state checkpoints, which carry no context
+type Saver struct {
+	Store Store
+}
+
+func (s *Saver) Save(state []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+	return s.Store.Save(ctx, state)
+}
+
+// PartSaver adapts a part store to the state checkpoints, which then only save the parts they
+// changed
+type PartSaver struct {
+	Saver
+	Parts PartStore
+}
+
+func (p *PartSaver) SaveParts(parts map[string][]byte, full bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+	return p.Parts.SaveParts(ctx, parts, full)
+}
+
+// fileStore keeps the state in a local file; replaced atomically so a crash mid write
+// leaves the previous checkpoint intact
+type fileStore struct {
+	path string
+}
+
+func (f *fileStore) Load(_ context.Context) ([]byte, error) {
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (f *fileStore) Save(_ context.Context, state []byte) error {
+	if err := os.MkdirAll(filepath.Dir(f.path), os.ModePerm); err != nil {
+		return err
+	}
+	temp := f.path + ".tmp"
+	if err := os.WriteFile(temp, state, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(temp, f.path)
+}
+
+func (f *fileStore) Close() error {
+	return nil
+}
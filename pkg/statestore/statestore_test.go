@@ -0,0 +1,57 @@
+package statestore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	store, err := New(ctx, &Config{Type: BackendFile, Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	state, err := store.Load(ctx)
+	if err != nil || state != nil {
+		t.Fatalf("expected no state before the first save, got %s, %v", state, err)
+	}
+	saver := &Saver{Store: store}
+	for _, checkpoint := range []string{`{"type":"STREAM"}`, `{"type":"GLOBAL"}`} {
+		if err := saver.Save([]byte(checkpoint)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	state, err = store.Load(ctx)
+	if err != nil || string(state) != `{"type":"GLOBAL"}` {
+		t.Fatalf("expected last checkpoint, got %s, %v", state, err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	gcs := &Config{Type: BackendGCS, Bucket: "states", Key: "orders/state.json"}
+	if err := gcs.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if gcs.Endpoint != gcsEndpoint {
+		t.Fatalf("expected gcs endpoint default, got %s", gcs.Endpoint)
+	}
+
+	postgres := &Config{Type: BackendPostgres, PostgresURL: "postgres://localhost/olake", Key: "orders"}
+	if err := postgres.Validate(); err != nil || postgres.Table != "olake_state" {
+		t.Fatalf("expected default table, got %s, %v", postgres.Table, err)
+	}
+
+	for _, config := range []*Config{
+		{Type: BackendS3, Bucket: "states", Region: "us-east-1"},
+		{Type: BackendRedis, Key: "orders"},
+		{Type: "etcd"},
+	} {
+		if err := config.Validate(); err == nil {
+			t.Fatalf("expected invalid config %+v to be rejected", config)
+		}
+	}
+}
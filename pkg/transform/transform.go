@@ -0,0 +1,164 @@
+// Package transform protects configured columns of records by encrypting, hashing or masking
+// their values, so PII never leaves the sync process in plain text
+package transform
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/datazip-inc/olake/types"
+	"github.com/goccy/go-json"
+)
+
+// Transformer applies the transforms of a stream; safe for concurrent use
+type Transformer struct {
+	columns map[string]func(value string) string
+}
+
+// New builds the transforms of configs; configs must be validated
+func New(configs []types.ColumnTransform) (*Transformer, error) {
+	transformer := &Transformer{columns: make(map[string]func(value string) string)}
+	for _, config := range configs {
+		switch config.Type {
+		case types.TransformEncrypt:
+			key, err := base64.StdEncoding.DecodeString(config.Key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid key of column[%s]: %s", config.Column, err)
+			}
+			encrypt, err := newEncrypter(key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid key of column[%s]: %s", config.Column, err)
+			}
+			transformer.columns[config.Column] = encrypt
+		case types.TransformHash:
+			salt := config.Salt
+			transformer.columns[config.Column] = func(value string) string {
+				sum := sha256.Sum256([]byte(salt + value))
+				return hex.EncodeToString(sum[:])
+			}
+		case types.TransformMask:
+			config := config
+			transformer.columns[config.Column] = func(value string) string {
+				return mask(value, config.KeepFirst, config.KeepLast, []rune(config.MaskChar)[0])
+			}
+		default:
+			return nil, fmt.Errorf("invalid transform type[%s] of column[%s]", config.Type, config.Column)
+		}
+	}
+	return transformer, nil
+}
+
+// Columns returns the transformed columns, sorted
+func (t *Transformer) Columns() []string {
+	columns := make([]string, 0, len(t.columns))
+	for column := range t.columns {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// Apply transforms the configured columns of record in place; null values stay null
+func (t *Transformer) Apply(record map[string]any) {
+	for column, transform := range t.columns {
+		value, found := record[column]
+		if !found || value == nil {
+			continue
+		}
+		record[column] = transform(stringify(value))
+	}
+}
+
+// stringify returns the text a value is transformed from
+func stringify(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case map[string]any, []any:
+		if data, err := json.Marshal(v); err == nil {
+			return string(data)
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// newEncrypter returns a deterministic AES-256-GCM encryption: the nonce is an HMAC of the
+// value, so equal values give equal ciphertexts. Ciphertexts are base64 encoded nonce and
+// sealed value and can be reversed with Decrypt.
+func newEncrypter(key []byte) (func(value string) string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	// a key of its own for nonces, derived from the encryption key
+	derive := hmac.New(sha256.New, key)
+	derive.Write([]byte("olake column nonce"))
+	nonceKey := derive.Sum(nil)
+
+	return func(value string) string {
+		mac := hmac.New(sha256.New, nonceKey)
+		mac.Write([]byte(value))
+		nonce := mac.Sum(nil)[:aead.NonceSize()]
+		return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(value), nil))
+	}, nil
+}
+
+// Decrypt reverses an encrypt transform with its key
+func Decrypt(key []byte, ciphertext string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode ciphertext: %s", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("ciphertext is truncated")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt; wrong key or tampered data: %s", err)
+	}
+	return string(plaintext), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// mask replaces letters and digits with char, leaving keepFirst and keepLast characters and
+// every separator as is, so masked values keep their format
+func mask(value string, keepFirst, keepLast int, char rune) string {
+	runes := []rune(value)
+	var builder strings.Builder
+	builder.Grow(len(value))
+	for idx, r := range runes {
+		if idx >= keepFirst && idx < len(runes)-keepLast && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			r = char
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
@@ -0,0 +1,86 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestTransformer(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	configs := []types.ColumnTransform{
+		{Column: "email", Type: types.TransformEncrypt, Key: base64.StdEncoding.EncodeToString(key)},
+		{Column: "ssn", Type: types.TransformHash, Salt: "pepper"},
+		{Column: "card", Type: types.TransformMask, KeepLast: 4},
+		{Column: "phone", Type: types.TransformMask, KeepFirst: 2, MaskChar: "#"},
+		{Column: "born", Type: types.TransformHash, Salt: "pepper"},
+	}
+	for idx := range configs {
+		if err := configs[idx].Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	transformer, err := New(configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if columns := strings.Join(transformer.Columns(), ","); columns != "born,card,email,phone,ssn" {
+		t.Fatalf("unexpected columns %s", columns)
+	}
+
+	record := map[string]any{
+		"email": "jane@example.com",
+		"ssn":   123456789,
+		"card":  "4111-1111-1111-1234",
+		"phone": "+1 (555) 010-9999",
+		"born":  time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC),
+		"name":  "Jane",
+	}
+	other := map[string]any{"email": "jane@example.com", "ssn": "123456789", "born": "1990-01-02T00:00:00Z", "card": nil}
+	transformer.Apply(record)
+	transformer.Apply(other)
+
+	if record["email"] == "jane@example.com" || record["email"] != other["email"] {
+		t.Fatalf("expected deterministic ciphertexts, got %v and %v", record["email"], other["email"])
+	}
+	if plaintext, err := Decrypt(key, record["email"].(string)); err != nil || plaintext != "jane@example.com" {
+		t.Fatalf("expected the email back, got %q: %v", plaintext, err)
+	}
+	if _, err := Decrypt(bytes.Repeat([]byte{8}, 32), record["email"].(string)); err == nil {
+		t.Fatal("expected a wrong key to fail")
+	}
+	if hash := record["ssn"].(string); len(hash) != 64 || hash != other["ssn"] {
+		t.Fatalf("expected equal sha-256 hex hashes of numbers and their text, got %v and %v", record["ssn"], other["ssn"])
+	}
+	if record["born"] != other["born"] {
+		t.Fatalf("expected timestamps hashed as RFC 3339, got %v and %v", record["born"], other["born"])
+	}
+	if record["card"] != "****-****-****-1234" {
+		t.Fatalf("unexpected masked card %v", record["card"])
+	}
+	if record["phone"] != "+1 (###) ###-####" {
+		t.Fatalf("unexpected masked phone %v", record["phone"])
+	}
+	if record["name"] != "Jane" || other["card"] != nil {
+		t.Fatalf("expected other columns and nulls as is, got %v and %v", record["name"], other["card"])
+	}
+}
+
+func TestColumnTransformValidate(t *testing.T) {
+	for _, config := range []types.ColumnTransform{
+		{Type: types.TransformHash, Salt: "s"},
+		{Column: "a", Type: "tokenize"},
+		{Column: "a", Type: types.TransformEncrypt, Key: base64.StdEncoding.EncodeToString([]byte("short"))},
+		{Column: "a", Type: types.TransformHash},
+		{Column: "a", Type: types.TransformMask, KeepLast: -1},
+		{Column: "a", Type: types.TransformMask, MaskChar: "**"},
+	} {
+		if err := config.Validate(); err == nil {
+			t.Fatalf("expected %+v to be invalid", config)
+		}
+	}
+}
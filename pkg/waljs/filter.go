@@ -1,23 +1,31 @@
 package waljs
 
 import (
-	"bytes"
 	"fmt"
 
-	"github.com/goccy/go-json"
-
+	"github.com/datazip-inc/olake/pkg/jsonx"
 	"github.com/datazip-inc/olake/protocol"
 	"github.com/datazip-inc/olake/utils"
 	"github.com/jackc/pglogrepl"
 )
 
+const (
+	// HeartbeatPrefix is the prefix of the messages heartbeats write to the wal
+	HeartbeatPrefix = "olake_heartbeat"
+	// WatermarkPrefix is the prefix of the messages written before chunks of snapshots are read
+	WatermarkPrefix = "olake_watermark"
+)
+
 type ChangeFilter struct {
 	tables map[string]protocol.Stream
+	// schema.table heartbeats are written to
+	heartbeatTable string
 }
 
-func NewChangeFilter(streams ...protocol.Stream) ChangeFilter {
+func NewChangeFilter(heartbeatTable string, streams ...protocol.Stream) ChangeFilter {
 	filter := ChangeFilter{
-		tables: make(map[string]protocol.Stream),
+		tables:         make(map[string]protocol.Stream),
+		heartbeatTable: heartbeatTable,
 	}
 
 	for _, stream := range streams {
@@ -27,19 +35,27 @@ func NewChangeFilter(streams ...protocol.Stream) ChangeFilter {
 	return filter
 }
 
-func (c ChangeFilter) FilterChange(lsn pglogrepl.LSN, change []byte, OnFiltered OnMessage) error {
+// FilterChange passes the changes of the synced tables in change to OnFiltered; heartbeat is
+// true when change only holds heartbeats and watermarks
+func (c ChangeFilter) FilterChange(lsn pglogrepl.LSN, change []byte, OnFiltered OnMessage) (heartbeat bool, err error) {
 	var changes WALMessage
-	if err := json.NewDecoder(bytes.NewReader(change)).Decode(&changes); err != nil {
-		return fmt.Errorf("failed to parse change received from wal logs: %s", err)
+	if err := jsonx.Unmarshal(change, &changes); err != nil {
+		return false, fmt.Errorf("failed to parse change received from wal logs: %s", err)
 	}
 
 	if len(changes.Change) == 0 {
-		return nil
+		return false, nil
 	}
 
+	heartbeat = true
 	// TODO: Parallel process changes
 	for _, ch := range changes.Change {
-		stream, exists := c.tables[utils.StreamIdentifier(ch.Table, ch.Schema)]
+		table := utils.StreamIdentifier(ch.Table, ch.Schema)
+		if (ch.Kind == "message" && (ch.Prefix == HeartbeatPrefix || ch.Prefix == WatermarkPrefix)) || (c.heartbeatTable != "" && table == c.heartbeatTable) {
+			continue
+		}
+		heartbeat = false
+		stream, exists := c.tables[table]
 		if !exists {
 			continue
 		}
@@ -66,9 +82,9 @@ func (c ChangeFilter) FilterChange(lsn pglogrepl.LSN, change []byte, OnFiltered
 		})
 
 		if err != nil {
-			return fmt.Errorf("failed to write filtered changed: %s", err)
+			return false, fmt.Errorf("failed to write filtered changed: %s", err)
 		}
 	}
 
-	return nil
+	return heartbeat, nil
 }
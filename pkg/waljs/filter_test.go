@@ -0,0 +1,38 @@
+package waljs
+
+import (
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestFilterChangeHeartbeats(t *testing.T) {
+	users := &types.ConfiguredStream{Stream: types.NewStream("users", "public")}
+	filter := NewChangeFilter("ops.heartbeat", users)
+	var changes []CDCChange
+	collect := func(change CDCChange) error {
+		changes = append(changes, change)
+		return nil
+	}
+
+	for _, message := range []string{
+		`{"change": [{"kind": "message", "transactional": true, "prefix": "olake_heartbeat", "content": "2024-01-01"}]}`,
+		`{"change": [{"kind": "insert", "schema": "ops", "table": "heartbeat", "columnnames": ["ts"], "columnvalues": ["2024-01-01"]}]}`,
+	} {
+		heartbeat, err := filter.FilterChange(0, []byte(message), collect)
+		if err != nil || !heartbeat {
+			t.Fatalf("expected %s to be a heartbeat: %v", message, err)
+		}
+	}
+
+	heartbeat, err := filter.FilterChange(0, []byte(`{"change": [
+		{"kind": "message", "prefix": "olake_heartbeat", "content": "2024-01-01"},
+		{"kind": "insert", "schema": "public", "table": "users", "columnnames": ["id"], "columnvalues": [1]}
+	]}`), collect)
+	if err != nil || heartbeat {
+		t.Fatalf("expected a change of users not to be a heartbeat: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Data["id"] != float64(1) {
+		t.Fatalf("expected only the change of users, got %+v", changes)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/datazip-inc/olake/types"
 	"github.com/datazip-inc/olake/typeutils"
 	"github.com/jackc/pglogrepl"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type Config struct {
@@ -18,6 +19,10 @@ type Config struct {
 	InitialWaitTime     time.Duration
 	TLSConfig           *tls.Config
 	BatchSize           int
+	// dials the replication connection, e.g. through a proxy; defaults to a direct dial
+	DialFunc pgconn.DialFunc
+	// schema.table heartbeats are written to; its changes are never synced
+	HeartbeatTable string
 }
 
 type WALState struct {
@@ -48,7 +53,9 @@ type WALMessage struct {
 	// NextLSN   pglogrepl.LSN `json:"nextlsn"`
 	Timestamp typeutils.Time `json:"timestamp"`
 	Change    []struct {
-		Kind         string        `json:"kind"`
+		Kind string `json:"kind"`
+		// prefix of messages written by pg_logical_emit_message
+		Prefix       string        `json:"prefix"`
 		Schema       string        `json:"schema"`
 		Table        string        `json:"table"`
 		Columnnames  []string      `json:"columnnames"`
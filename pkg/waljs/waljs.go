@@ -57,6 +57,9 @@ func NewConnection(ctx context.Context, db *sqlx.DB, config *Config) (*Socket, e
 		// TODO: use proper TLS Configurations
 		cfg.TLSConfig = &tls.Config{InsecureSkipVerify: false, MinVersion: tls.VersionTLS12}
 	}
+	if config.DialFunc != nil {
+		cfg.DialFunc = config.DialFunc
+	}
 
 	// Establish PostgreSQL connection
 	pgConn, err := pgconn.ConnectConfig(ctx, cfg)
@@ -81,7 +84,7 @@ func NewConnection(ctx context.Context, db *sqlx.DB, config *Config) (*Socket, e
 	// Create and return final connection object
 	return &Socket{
 		pgConn:            pgConn,
-		changeFilter:      NewChangeFilter(config.Tables.Array()...),
+		changeFilter:      NewChangeFilter(config.HeartbeatTable, config.Tables.Array()...),
 		ConfirmedFlushLSN: slot.LSN,
 		ClientXLogPos:     slot.LSN,
 		replicationSlot:   config.ReplicationSlotName,
@@ -149,8 +152,6 @@ func (s *Socket) StreamMessages(ctx context.Context, callback OnMessage) error {
 				}
 
 			case pglogrepl.XLogDataByteID:
-				// Reset the idle timer on receiving WAL data.
-				s.idleStartTime = time.Now()
 				xld, err := pglogrepl.ParseXLogData(copyData.Data[1:])
 				if err != nil {
 					return fmt.Errorf("failed to parse XLogData: %s", err)
@@ -158,9 +159,15 @@ func (s *Socket) StreamMessages(ctx context.Context, callback OnMessage) error {
 				// Calculate new LSN based on the received WAL data.
 				newLSN := xld.WALStart + pglogrepl.LSN(len(xld.WALData))
 				// Process change with the provided callback.
-				if err := s.changeFilter.FilterChange(newLSN, xld.WALData, callback); err != nil {
+				heartbeat, err := s.changeFilter.FilterChange(newLSN, xld.WALData, callback)
+				if err != nil {
 					return fmt.Errorf("failed to filter change: %s", err)
 				}
+				// Reset the idle timer on receiving WAL data; heartbeats advance the position
+				// without keeping an idle sync running
+				if !heartbeat {
+					s.idleStartTime = time.Now()
+				}
 				// Update the current LSN pointer.
 				s.ClientXLogPos = newLSN
 
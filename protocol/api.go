@@ -0,0 +1,184 @@
+package protocol
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/goccy/go-json"
+	"github.com/gorilla/mux"
+)
+
+// apiTokensEnv points to the tokens file of the api; without it the server answers every
+// request, so it must not be exposed beyond localhost
+const apiTokensEnv = "OLAKE_API_TOKENS"
+
+type Role string
+
+const (
+	// lists jobs and their status
+	RoleViewer Role = "viewer"
+	// triggers runs
+	RoleOperator Role = "operator"
+	// profiles the process through the debug endpoints
+	RoleAdmin Role = "admin"
+)
+
+// every role is granted what the roles below it are
+var roleLevels = map[Role]int{RoleViewer: 1, RoleOperator: 2, RoleAdmin: 3}
+
+// APITokens is read from the file in OLAKE_API_TOKENS
+type APITokens struct {
+	Tokens []*APIToken `json:"tokens" validate:"required,min=1,dive"`
+}
+
+// APIToken grants a role to the bearer of a token; only the hex encoded SHA-256 of the token
+// is kept, e.g. from echo -n <token> | sha256sum
+type APIToken struct {
+	Name        string `json:"name" validate:"required"`
+	Role        Role   `json:"role" validate:"required"`
+	TokenSHA256 string `json:"token_sha256" validate:"required"`
+
+	hash []byte
+}
+
+// JobStatus is a job of the running schedule as listed by the api
+type JobStatus struct {
+	ID        string    `json:"id"`
+	Workspace string    `json:"workspace,omitempty"`
+	Cron      string    `json:"cron"`
+	Running   bool      `json:"running"`
+	NextRun   time.Time `json:"next_run"`
+}
+
+// apiTokens authenticates api requests; nil leaves the api open
+var apiTokens *APITokens
+
+func loadAPITokens(path string) (*APITokens, error) {
+	tokens := &APITokens{}
+	if err := utils.UnmarshalFile(path, tokens); err != nil {
+		return nil, err
+	}
+	if err := tokens.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid api tokens[%s]: %s", path, err)
+	}
+	return tokens, nil
+}
+
+func (t *APITokens) Validate() error {
+	if err := utils.Validate(t); err != nil {
+		return err
+	}
+	names := make(map[string]bool)
+	for _, token := range t.Tokens {
+		if names[token.Name] {
+			return fmt.Errorf("duplicate token[%s]", token.Name)
+		}
+		names[token.Name] = true
+		if _, found := roleLevels[token.Role]; !found {
+			return fmt.Errorf("invalid role[%s] of token[%s]; expected one of viewer, operator, admin", token.Role, token.Name)
+		}
+		hash, err := hex.DecodeString(token.TokenSHA256)
+		if err != nil || len(hash) != sha256.Size {
+			return fmt.Errorf("token_sha256 of token[%s] must be a hex encoded SHA-256", token.Name)
+		}
+		token.hash = hash
+	}
+	return nil
+}
+
+// lookup returns the token of an Authorization header holding a bearer token
+func (t *APITokens) lookup(header string) *APIToken {
+	bearer, found := strings.CutPrefix(header, "Bearer ")
+	if !found || bearer == "" {
+		return nil
+	}
+	hash := sha256.Sum256([]byte(bearer))
+	for _, token := range t.Tokens {
+		if subtle.ConstantTimeCompare(hash[:], token.hash) == 1 {
+			return token
+		}
+	}
+	return nil
+}
+
+// authorize serves requests whose bearer token has at least role
+func authorize(role Role, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if apiTokens == nil {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		token := apiTokens.lookup(r.Header.Get("Authorization"))
+		if token == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="olake"`)
+			http.Error(w, "missing or invalid api token", http.StatusUnauthorized)
+			return
+		}
+		if roleLevels[token.Role] < roleLevels[role] {
+			logger.Warnf("Denied %s %s to token[%s] with role %s", r.Method, r.URL.Path, token.Name, token.Role)
+			http.Error(w, fmt.Sprintf("role %s required", role), http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// listJobs serves the jobs of the running schedule
+func listJobs(w http.ResponseWriter, _ *http.Request) {
+	schedule := runningSchedule.Load()
+	if schedule == nil {
+		http.Error(w, "no schedule is running", http.StatusNotFound)
+		return
+	}
+	jobs := make([]JobStatus, 0, len(schedule.jobs))
+	for _, job := range schedule.jobs {
+		status := JobStatus{ID: job.ID(), Cron: job.Cron, Running: job.running.Load(), NextRun: job.schedule.Next(time.Now()).UTC()}
+		if job.workspace != nil {
+			status.Workspace = job.workspace.Name
+		}
+		jobs = append(jobs, status)
+	}
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+// runJob starts a run of a job of the running schedule now
+func runJob(w http.ResponseWriter, r *http.Request) {
+	schedule := runningSchedule.Load()
+	if schedule == nil {
+		http.Error(w, "no schedule is running", http.StatusNotFound)
+		return
+	}
+	if schedule.ctx.Err() != nil {
+		http.Error(w, "scheduler is stopping", http.StatusServiceUnavailable)
+		return
+	}
+	id := mux.Vars(r)["id"]
+	for _, job := range schedule.jobs {
+		if job.ID() != id {
+			continue
+		}
+		if !job.start(schedule.ctx, schedule.executor, schedule.running, time.Now().UTC()) {
+			http.Error(w, fmt.Sprintf("previous run of job[%s] still in progress", id), http.StatusConflict)
+			return
+		}
+		logger.Infof("Triggered run of job[%s] through the api", id)
+		writeJSON(w, http.StatusAccepted, map[string]string{"job": id, "status": "started"})
+		return
+	}
+	http.Error(w, fmt.Sprintf("job[%s] not found", id), http.StatusNotFound)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Warnf("failed to write api response: %s", err)
+	}
+}
@@ -0,0 +1,88 @@
+package protocol
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestAPIAuthorization(t *testing.T) {
+	hash := func(token string) string {
+		sum := sha256.Sum256([]byte(token))
+		return hex.EncodeToString(sum[:])
+	}
+	tokens := &APITokens{Tokens: []*APIToken{
+		{Name: "dashboard", Role: RoleViewer, TokenSHA256: hash("view")},
+		{Name: "ci", Role: RoleOperator, TokenSHA256: hash("operate")},
+	}}
+	if err := tokens.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	apiTokens = tokens
+	defer func() { apiTokens = nil }()
+
+	executable, err := exec.LookPath("true")
+	if err != nil {
+		t.Skip("true not found")
+	}
+	viper.Set("CONFIG_FOLDER", t.TempDir())
+	config := &ScheduleConfig{Jobs: []*ScheduleJob{{Name: "hourly", Cron: "@hourly", Config: "c.json", Destination: "d.json", Catalog: "s.json"}}}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	var running sync.WaitGroup
+	runningSchedule.Store(&activeSchedule{ctx: context.Background(), executor: &processExecutor{executable: executable}, running: &running, jobs: config.AllJobs()})
+	defer runningSchedule.Store(nil)
+
+	router := newRouter()
+	request := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	for _, test := range []struct {
+		method, path, token string
+		status              int
+	}{
+		{http.MethodGet, "/healthz", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/jobs", "", http.StatusUnauthorized},
+		{http.MethodGet, "/api/v1/jobs", "wrong", http.StatusUnauthorized},
+		{http.MethodGet, "/api/v1/jobs", "view", http.StatusOK},
+		{http.MethodPost, "/api/v1/jobs/hourly/run", "view", http.StatusForbidden},
+		{http.MethodGet, "/debug/pprof/heap", "operate", http.StatusForbidden},
+		{http.MethodPost, "/api/v1/jobs/daily/run", "operate", http.StatusNotFound},
+		{http.MethodPost, "/api/v1/jobs/hourly/run", "operate", http.StatusAccepted},
+	} {
+		if recorder := request(test.method, test.path, test.token); recorder.Code != test.status {
+			t.Fatalf("%s %s with token %q: expected %d, got %d: %s", test.method, test.path, test.token, test.status, recorder.Code, recorder.Body)
+		}
+	}
+	running.Wait()
+
+	recorder := request(http.MethodGet, "/api/v1/jobs", "view")
+	if !strings.Contains(recorder.Body.String(), `"id":"hourly"`) {
+		t.Fatalf("unexpected jobs %s", recorder.Body)
+	}
+
+	invalid := &APITokens{Tokens: []*APIToken{{Name: "root", Role: "owner", TokenSHA256: hash("x")}}}
+	if err := invalid.Validate(); err == nil {
+		t.Fatal("expected an unknown role to be rejected")
+	}
+	invalid = &APITokens{Tokens: []*APIToken{{Name: "root", Role: RoleAdmin, TokenSHA256: "secret"}}}
+	if err := invalid.Validate(); err == nil {
+		t.Fatal("expected a token_sha256 that isn't a hash to be rejected")
+	}
+}
@@ -0,0 +1,70 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/encryption"
+	"github.com/datazip-inc/olake/pkg/oauth"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/spf13/cobra"
+)
+
+// time the user has to complete the authorization in the browser
+const authorizeTimeout = 10 * time.Minute
+
+// authorizeCmd runs the oauth2 authorization code flow of the driver and prints the refresh
+// token to put in its config
+var authorizeCmd = &cobra.Command{
+	Use:   "authorize",
+	Short: "Olake authorize command; obtains a refresh token for drivers authenticating with oauth2",
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		if !configured("config", configPath) {
+			return fmt.Errorf("--config not passed")
+		}
+		return utils.UnmarshalFileWithEnv(configPath, "config", connector.GetConfigRef())
+	},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		driver, ok := connector.(OAuthDriver)
+		if !ok {
+			return fmt.Errorf("%s does not authenticate with oauth2", connector.Type())
+		}
+		config, err := driver.OAuthConfig()
+		if err != nil {
+			return err
+		}
+		if err := config.Validate(); err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		ctx, cancel := context.WithTimeout(ctx, authorizeTimeout)
+		defer cancel()
+		token, err := oauth.Authorize(ctx, config, func(authURL string) {
+			logger.Infof("Open the following url in a browser and allow access; waiting on %s for the redirect", config.CallbackAddress)
+			fmt.Println(authURL)
+		})
+		if err != nil {
+			return err
+		}
+		if token.RefreshToken == "" {
+			return fmt.Errorf("provider did not issue a refresh token; check the scopes of the oauth client")
+		}
+
+		refreshToken := token.RefreshToken
+		if encryption.Enabled() {
+			if refreshToken, err = encryption.Encrypt([]byte(refreshToken)); err != nil {
+				return err
+			}
+		}
+		logger.Info("Authorization complete; set refresh_token in the config to the following value")
+		fmt.Println(refreshToken)
+		return nil
+	},
+}
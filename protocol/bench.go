@@ -0,0 +1,308 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/goccy/go-json"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var reportPath string
+
+// BenchReport is the outcome of a benchmark run; comparable across runs of the same config
+type BenchReport struct {
+	Driver       string          `json:"driver"`
+	Destination  string          `json:"destination"`
+	Streams      []string        `json:"streams"`
+	Records      int64           `json:"records"`
+	Bytes        int64           `json:"bytes"`
+	DurationMs   int64           `json:"duration_ms"`
+	RecordsPerS  float64         `json:"records_per_second"`
+	MBPerS       float64         `json:"mb_per_second"`
+	BatchSize    int64           `json:"batch_size"`
+	BatchLatency LatencySummary  `json:"batch_latency_ms"`
+	Allocations  AllocationStats `json:"allocations"`
+	GoVersion    string          `json:"go_version"`
+	NumCPU       int             `json:"num_cpu"`
+	StartedAt    time.Time       `json:"started_at"`
+}
+
+type LatencySummary struct {
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+type AllocationStats struct {
+	TotalBytes     uint64  `json:"total_bytes"`
+	Mallocs        uint64  `json:"mallocs"`
+	NumGC          uint32  `json:"num_gc"`
+	BytesPerRecord float64 `json:"bytes_per_record"`
+	PeakHeapBytes  uint64  `json:"peak_heap_bytes"`
+}
+
+// benchRecorder measures records passing through the writer pool
+type benchRecorder struct {
+	mutex     sync.Mutex
+	batchSize int64
+	records   int64
+	bytes     int64
+	lastBatch time.Time
+	latencies []time.Duration
+}
+
+func newBenchRecorder(batchSize int64) *benchRecorder {
+	return &benchRecorder{batchSize: batchSize, lastBatch: time.Now()}
+}
+
+func (b *benchRecorder) observe(record types.RawRecord) {
+	size := int64(len(record.OlakeID)) + utils.ApproximateSize(record.Data)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.records++
+	b.bytes += size
+	if b.records%b.batchSize == 0 {
+		now := time.Now()
+		b.latencies = append(b.latencies, now.Sub(b.lastBatch))
+		b.lastBatch = now
+	}
+}
+
+func (b *benchRecorder) latencySummary() LatencySummary {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if len(b.latencies) == 0 {
+		return LatencySummary{}
+	}
+
+	sorted := append([]time.Duration(nil), b.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	percentile := func(p float64) float64 {
+		idx := int(p * float64(len(sorted)-1))
+		return float64(sorted[idx].Microseconds()) / 1000
+	}
+	return LatencySummary{P50: percentile(0.50), P95: percentile(0.95), P99: percentile(0.99), Max: percentile(1)}
+}
+
+// benchCmd runs the source against a destination (the null writer by default) and reports throughput
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Olake bench command",
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		if !configured("config", configPath) {
+			return fmt.Errorf("--config not passed")
+		}
+		if err := utils.UnmarshalFileWithEnv(configPath, "config", connector.GetConfigRef()); err != nil {
+			return err
+		}
+
+		destinationConfig = &types.WriterConfig{Type: types.NullWriter, WriterConfig: map[string]any{}}
+		if configured("destination", destinationConfigPath) {
+			if err := utils.UnmarshalFileWithEnv(destinationConfigPath, "destination", destinationConfig); err != nil {
+				return err
+			}
+		}
+
+		if catalogPath != "" {
+			catalog = &types.Catalog{}
+			if err := types.CatalogFormat.LoadFile(catalogPath, catalog); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if err := connector.Setup(); err != nil {
+			return err
+		}
+		streams, err := benchStreams()
+		if err != nil {
+			return err
+		}
+		if len(streams) == 0 {
+			return fmt.Errorf("no streams to benchmark")
+		}
+
+		pool, err := NewWriter(cmd.Context(), destinationConfig)
+		if err != nil {
+			return err
+		}
+		if err := pool.setSourceTimezone(connector); err != nil {
+			return err
+		}
+		recorder := newBenchRecorder(batchSize)
+		pool.observer = recorder.observe
+
+		// benchmarks never resume from or persist state
+		state = &types.State{RWMutex: &sync.RWMutex{}, Type: types.StreamType}
+		connector.SetupState(state)
+
+		report := &BenchReport{
+			Driver:      connector.Type(),
+			Destination: string(destinationConfig.Type),
+			BatchSize:   batchSize,
+			GoVersion:   runtime.Version(),
+			NumCPU:      runtime.NumCPU(),
+			StartedAt:   time.Now().UTC(),
+		}
+		for _, stream := range streams {
+			report.Streams = append(report.Streams, stream.ID())
+		}
+
+		runtime.GC()
+		var before runtime.MemStats
+		runtime.ReadMemStats(&before)
+		peakHeap := sampleHeap(cmd.Context())
+
+		start := time.Now()
+		if err := pool.BeginSync(cmd.Context()); err != nil {
+			return err
+		}
+		err = utils.Concurrent(cmd.Context(), streams, concurrentStreamExecution, func(ctx context.Context, stream Stream, _ int) error {
+			logger.Infof("Benchmarking stream[%s] in %s", stream.ID(), stream.GetSyncMode())
+			return connector.Read(ctx, pool, stream)
+		})
+		if err != nil {
+			return pool.EndSync(cmd.Context(), fmt.Errorf("error occurred while reading records: %s", err))
+		}
+		if err := pool.Wait(); err != nil {
+			return pool.EndSync(cmd.Context(), fmt.Errorf("error occurred in writer pool: %s", err))
+		}
+		if err := pool.EndSync(cmd.Context(), nil); err != nil {
+			return err
+		}
+		elapsed := time.Since(start)
+
+		var after runtime.MemStats
+		runtime.ReadMemStats(&after)
+
+		report.Records = recorder.records
+		report.Bytes = recorder.bytes
+		report.DurationMs = elapsed.Milliseconds()
+		report.RecordsPerS = float64(recorder.records) / elapsed.Seconds()
+		report.MBPerS = float64(recorder.bytes) / (1024 * 1024) / elapsed.Seconds()
+		report.BatchLatency = recorder.latencySummary()
+		report.Allocations = AllocationStats{
+			TotalBytes:    after.TotalAlloc - before.TotalAlloc,
+			Mallocs:       after.Mallocs - before.Mallocs,
+			NumGC:         after.NumGC - before.NumGC,
+			PeakHeapBytes: max(peakHeap(), after.HeapInuse),
+		}
+		if recorder.records > 0 {
+			report.Allocations.BytesPerRecord = float64(report.Allocations.TotalBytes) / float64(recorder.records)
+		}
+
+		return writeBenchReport(report)
+	},
+}
+
+// benchStreams returns the catalog streams, or every discovered stream in its default mode
+func benchStreams() ([]Stream, error) {
+	discovered, err := discoverStreams(false)
+	if err != nil {
+		return nil, err
+	}
+
+	var streams []Stream
+	if catalog == nil {
+		for _, stream := range discovered {
+			if stream.SyncMode == "" || stream.SyncMode == types.CDC {
+				stream.SyncMode = types.FULLREFRESH
+			}
+			if !stream.SupportedSyncModes.Exists(stream.SyncMode) {
+				continue
+			}
+			streams = append(streams, &types.ConfiguredStream{Stream: stream})
+		}
+		return streams, nil
+	}
+
+	sourceStreams := types.StreamsToMap(discovered...)
+	for _, elem := range catalog.Streams {
+		source, found := sourceStreams[elem.ID()]
+		if !found {
+			logger.Warnf("Skipping; Configured Stream %s not found in source", elem.ID())
+			continue
+		}
+		if err := elem.Validate(source); err != nil {
+			logger.Warnf("Skipping; Configured Stream %s found invalid due to reason: %s", elem.ID(), err)
+			continue
+		}
+		elem.ApplyOverrides()
+		// change streams run until stopped, there's nothing to measure
+		if elem.GetSyncMode() == types.CDC {
+			logger.Warnf("Skipping; stream %s in cdc mode can not be benchmarked", elem.ID())
+			continue
+		}
+		streams = append(streams, elem)
+	}
+	return streams, nil
+}
+
+// sampleHeap tracks the heap size until the returned function is called
+func sampleHeap(ctx context.Context) func() uint64 {
+	ctx, cancel := context.WithCancel(ctx)
+	var peak uint64
+	var mutex sync.Mutex
+	go func() {
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				var stats runtime.MemStats
+				runtime.ReadMemStats(&stats)
+				mutex.Lock()
+				peak = max(peak, stats.HeapInuse)
+				mutex.Unlock()
+			}
+		}
+	}()
+
+	return func() uint64 {
+		cancel()
+		mutex.Lock()
+		defer mutex.Unlock()
+		return peak
+	}
+}
+
+func writeBenchReport(report *BenchReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	logger.Infof("Benchmark report: %s", data)
+
+	path := reportPath
+	if path == "" {
+		if noSave {
+			return nil
+		}
+		path = filepath.Join(viper.GetString("CONFIG_FOLDER"), "bench_report.json")
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write bench report: %s", err)
+	}
+	logger.Infof("Benchmark report written to %s", path)
+	return nil
+}
+
+func init() {
+	RootCmd.PersistentFlags().StringVarP(&reportPath, "report", "", "", "(Optional) Path of the benchmark report; defaults to bench_report.json in the config folder")
+}
@@ -0,0 +1,137 @@
+package protocol
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+	"github.com/goccy/go-json"
+	"github.com/spf13/viper"
+)
+
+// hashEntry is the hash of a row synced in the previous run along with its primary key values,
+// which are needed to emit a delete once the row disappears from the source
+type hashEntry struct {
+	Hash string         `json:"h"`
+	Keys map[string]any `json:"k,omitempty"`
+}
+
+// changeDetector lets only new and changed rows of a full refresh through by comparing row
+// hashes against the index stored by the previous run
+type changeDetector struct {
+	mutex       sync.Mutex
+	path        string
+	primaryKeys []string
+	previous    map[string]hashEntry
+	current     map[string]hashEntry
+}
+
+func newChangeDetector(stream Stream) (*changeDetector, error) {
+	detector := &changeDetector{
+		path:        filepath.Join(viper.GetString("CONFIG_FOLDER"), "hash_index", fmt.Sprintf("%s.%s.json", stream.Namespace(), stream.Name())),
+		primaryKeys: stream.GetStream().SourceDefinedPrimaryKey.Array(),
+		previous:    make(map[string]hashEntry),
+		current:     make(map[string]hashEntry),
+	}
+	sort.Strings(detector.primaryKeys)
+
+	data, err := os.ReadFile(detector.path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read hash index[%s]: %s", detector.path, err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &detector.previous); err != nil {
+			return nil, fmt.Errorf("failed to parse hash index[%s]: %s", detector.path, err)
+		}
+	}
+	return detector, nil
+}
+
+// Changed records the row and returns false when it is unchanged since the previous run
+func (c *changeDetector) Changed(record types.RawRecord) bool {
+	hash := rowHash(record.Data)
+	// without primary keys a row is only identified by its content
+	key := hash
+	var keys map[string]any
+	if len(c.primaryKeys) > 0 {
+		key = record.OlakeID
+		keys = make(map[string]any, len(c.primaryKeys))
+		for _, pk := range c.primaryKeys {
+			keys[pk] = record.Data[pk]
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.current[key] = hashEntry{Hash: hash, Keys: keys}
+	previous, found := c.previous[key]
+	return !found || previous.Hash != hash
+}
+
+// Deleted returns delete records for the rows of the previous run missing from this run
+func (c *changeDetector) Deleted() []types.RawRecord {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	deleteAt := time.Now().UTC().UnixMilli()
+	var deleted []types.RawRecord
+	for key, entry := range c.previous {
+		if _, found := c.current[key]; found {
+			continue
+		}
+		data := entry.Keys
+		if data == nil {
+			data = make(map[string]any)
+		}
+		deleted = append(deleted, types.CreateRawRecord(key, data, deleteAt))
+	}
+	return deleted
+}
+
+// Save replaces the stored index with the rows of this run
+func (c *changeDetector) Save() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	data, err := json.Marshal(c.current)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), os.ModePerm); err != nil {
+		return err
+	}
+	// write and rename so that a crash never leaves a truncated index behind
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, c.path)
+}
+
+// rowHash is a stable hash of column names and values
+func rowHash(data map[string]any) string {
+	columns := make([]string, 0, len(data))
+	for column := range data {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	hasher := sha256.New()
+	for _, column := range columns {
+		value, err := json.Marshal(data[column])
+		if err != nil {
+			value = []byte(fmt.Sprint(data[column]))
+		}
+		hasher.Write([]byte(column))
+		hasher.Write([]byte{0})
+		hasher.Write(value)
+		hasher.Write([]byte{0})
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
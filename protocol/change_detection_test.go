@@ -0,0 +1,47 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+	"github.com/spf13/viper"
+)
+
+func TestChangeDetector(t *testing.T) {
+	viper.Set("CONFIG_FOLDER", t.TempDir())
+	stream := &types.ConfiguredStream{Stream: types.NewStream("users", "public").WithPrimaryKey("id")}
+
+	run := func(rows ...map[string]any) (changed []any, deleted []any) {
+		detector, err := newChangeDetector(stream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, row := range rows {
+			if detector.Changed(types.CreateRawRecord(row["id"].(string), row, 0)) {
+				changed = append(changed, row["id"])
+			}
+		}
+		for _, record := range detector.Deleted() {
+			deleted = append(deleted, record.Data["id"])
+		}
+		if err := detector.Save(); err != nil {
+			t.Fatal(err)
+		}
+		return changed, deleted
+	}
+
+	changed, deleted := run(map[string]any{"id": "1", "name": "a"}, map[string]any{"id": "2", "name": "b"})
+	if len(changed) != 2 || len(deleted) != 0 {
+		t.Fatalf("first run: expected all rows, got changed=%v deleted=%v", changed, deleted)
+	}
+
+	changed, deleted = run(map[string]any{"id": "1", "name": "a"}, map[string]any{"id": "3", "name": "c"})
+	if len(changed) != 1 || changed[0] != "3" || len(deleted) != 1 || deleted[0] != "2" {
+		t.Fatalf("second run: got changed=%v deleted=%v", changed, deleted)
+	}
+
+	changed, _ = run(map[string]any{"id": "1", "name": "updated"}, map[string]any{"id": "3", "name": "c"})
+	if len(changed) != 1 || changed[0] != "1" {
+		t.Fatalf("third run: got changed=%v", changed)
+	}
+}
@@ -0,0 +1,23 @@
+package protocol
+
+import (
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/charset"
+	"github.com/datazip-inc/olake/types"
+)
+
+// convertText decodes the text of record from the charset of the stream and replaces what
+// isn't valid UTF-8; the first replacement of a stream is logged
+func (w *WriterPool) convertText(stream Stream, text *charset.Converter, record types.RawRecord) {
+	replaced := int64(text.Apply(record.Data))
+	if replaced == 0 {
+		return
+	}
+	if w.streamProgress(stream.ID()).replaced.Add(replaced) == replaced {
+		encoding := stream.Self().StreamMetadata.Encoding
+		if encoding == "" {
+			encoding = "UTF-8"
+		}
+		logger.Warnf("Replaced characters of stream[%s] that aren't valid %s with U+FFFD", stream.ID(), encoding)
+	}
+}
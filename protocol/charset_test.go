@@ -0,0 +1,43 @@
+package protocol
+
+import (
+	"context"
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestTextConversion(t *testing.T) {
+	ctx := context.Background()
+	sampler := &sampler{limit: 10}
+	pool, err := newSamplePool(ctx, nil, sampler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := &types.ConfiguredStream{Stream: types.NewStream("customers", "legacy"), StreamMetadata: types.StreamMetadata{Encoding: "latin1"}}
+	thread, err := pool.NewThread(ctx, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"caf\xe9", "\x81"} {
+		if err := thread.Insert(types.CreateRawRecord("id", map[string]any{"name": name}, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	thread.Close()
+	if err := pool.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sampler.records) != 2 || sampler.records[0]["name"] != "café" || sampler.records[1]["name"] != "�" {
+		t.Fatalf("unexpected records %v", sampler.records)
+	}
+	if replaced := pool.streamProgress(stream.ID()).replaced.Load(); replaced != 1 {
+		t.Fatalf("expected a replaced character, got %d", replaced)
+	}
+
+	stream.StreamMetadata.Encoding = "klingon"
+	if _, err := pool.NewThread(ctx, stream); err == nil {
+		t.Fatal("expected an unknown encoding to be rejected")
+	}
+}
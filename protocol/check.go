@@ -1,7 +1,10 @@
 package protocol
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/datazip-inc/olake/logger"
 	"github.com/datazip-inc/olake/types"
@@ -9,34 +12,44 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// deepCheck runs the pre-flight diagnostics of the driver and the destination
+var deepCheck bool
+
 // checkCmd represents the check command
 var checkCmd = &cobra.Command{
 	Use:   "check",
 	Short: "check command",
 	PreRunE: func(_ *cobra.Command, _ []string) error {
-		if configPath == "" {
+		if !configured("config", configPath) {
 			return fmt.Errorf("--config not passed")
 		}
 
-		if err := utils.UnmarshalFile(configPath, connector.GetConfigRef()); err != nil {
+		if err := utils.UnmarshalFileWithEnv(configPath, "config", connector.GetConfigRef()); err != nil {
 			return err
 		}
 
 		if catalogPath != "" {
 			catalog = &types.Catalog{}
-			if err := utils.UnmarshalFile(catalogPath, &catalog); err != nil {
+			if err := types.CatalogFormat.LoadFile(catalogPath, &catalog); err != nil {
+				return err
+			}
+		}
+
+		if deepCheck && configured("destination", destinationConfigPath) {
+			destinationConfig = &types.WriterConfig{}
+			if err := utils.UnmarshalFileWithEnv(destinationConfigPath, "destination", destinationConfig); err != nil {
 				return err
 			}
 		}
 
 		return nil
 	},
-	Run: func(_ *cobra.Command, _ []string) {
+	Run: func(cmd *cobra.Command, _ []string) {
 		err := func() error {
 			// Catalog has been passed setup and is driver; Connector should be setup
 			if catalog != nil {
 				// Get Source Streams
-				streams, err := connector.Discover(false)
+				streams, err := discoverStreams(false)
 				if err != nil {
 					return err
 				}
@@ -87,6 +100,22 @@ var checkCmd = &cobra.Command{
 				Status: types.ConnectionSucceed,
 			},
 		}
+		if deepCheck {
+			checks := runDeepChecks(cmd.Context())
+			var failed []string
+			for _, check := range checks {
+				if check.Passed {
+					logger.Infof("[PASS] %s %s: %s", check.Component, check.Name, check.Message)
+					continue
+				}
+				logger.Warnf("[FAIL] %s %s: %s; %s", check.Component, check.Name, check.Message, check.Remediation)
+				failed = append(failed, fmt.Sprintf("%s %s", check.Component, check.Name))
+			}
+			message.ConnectionStatus.Checks = checks
+			if err == nil && len(failed) > 0 {
+				err = fmt.Errorf("failed checks: %s", strings.Join(failed, ", "))
+			}
+		}
 		if err != nil {
 			message.ConnectionStatus.Message = err.Error()
 			message.ConnectionStatus.Status = types.ConnectionFailed
@@ -94,3 +123,57 @@ var checkCmd = &cobra.Command{
 		logger.Info(message)
 	},
 }
+
+func init() {
+	RootCmd.PersistentFlags().BoolVarP(&deepCheck, "deep", "", false, "(Optional) Verify privileges, server settings and destination access with remediation hints")
+}
+
+// runDeepChecks collects the diagnostics of the driver and, when passed, the destination
+func runDeepChecks(ctx context.Context) []types.CheckResult {
+	var results []types.CheckResult
+	collect := func(component string, checker any) {
+		deep, ok := checker.(DeepChecker)
+		if !ok {
+			return
+		}
+		for _, result := range deep.DeepCheck(ctx) {
+			result.Component = component
+			results = append(results, result)
+		}
+	}
+
+	collect("source", connector)
+	if destinationConfig != nil {
+		adapter, err := newAdapter(destinationConfig)
+		if err != nil {
+			return append(results, types.CheckResult{
+				Component:   "destination",
+				Name:        "config",
+				Message:     err.Error(),
+				Remediation: "fix the destination config",
+			})
+		}
+		collect("destination", adapter)
+	}
+	return results
+}
+
+// WritablePathCheck verifies files can be created under path, creating it when missing; shared
+// by writers staging files locally
+func WritablePathCheck(path string) types.CheckResult {
+	result := types.CheckResult{Name: "local path", Remediation: fmt.Sprintf("make %s writable by the user running the sync", path)}
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		result.Message = fmt.Sprintf("failed to create path: %s", err)
+		return result
+	}
+	file, err := os.CreateTemp(path, "olake-check-*")
+	if err != nil {
+		result.Message = fmt.Sprintf("path is not writable: %s", err)
+		return result
+	}
+	file.Close()
+	os.Remove(file.Name())
+	result.Passed = true
+	result.Message = fmt.Sprintf("%s is writable", path)
+	return result
+}
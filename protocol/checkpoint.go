@@ -0,0 +1,190 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/types"
+)
+
+var (
+	checkpointRecords int64
+	checkpointSeconds int
+)
+
+// checkpointer persists the state of a sync once the writer threads acknowledged the records
+// written before it, so a resumed sync never skips records the destination didn't get
+type checkpointer struct {
+	pool     *WriterPool
+	state    *types.State
+	every    int64
+	interval time.Duration
+	records  atomic.Int64 // written since the last checkpoint
+	wake     chan struct{}
+	stopped  chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	mutex    sync.Mutex
+}
+
+// threadBarrier lets the checkpointer wait on a writer thread to write the records it received
+type threadBarrier struct {
+	requests chan chan error
+	done     chan struct{}
+	err      error
+}
+
+// startCheckpoints defers persisting changes of state to checkpoints taken every
+// --checkpoint-every-records records and every --checkpoint-every-seconds; stop before the
+// final state is logged
+func (w *WriterPool) startCheckpoints(ctx context.Context, state *types.State) *checkpointer {
+	c := &checkpointer{
+		pool:     w,
+		state:    state,
+		every:    checkpointRecords,
+		interval: time.Duration(checkpointSeconds) * time.Second,
+		wake:     make(chan struct{}, 1),
+		stopped:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if c.every <= 0 {
+		c.every = batchSize
+	}
+	w.checkpoints = c
+	// rotated credentials and upload progress are checkpointed right away
+	state.DeferCheckpoints(c.request)
+	go c.run(ctx)
+	return c
+}
+
+func (c *checkpointer) run(ctx context.Context) {
+	defer close(c.done)
+	var tick <-chan time.Time
+	if c.interval > 0 {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-c.stopped:
+			return
+		case <-ctx.Done():
+			return
+		case <-tick:
+		case <-c.wake:
+		}
+		if err := c.checkpoint(ctx); err != nil {
+			logger.Warnf("Skipped state checkpoint: %s", err)
+		}
+	}
+}
+
+// written counts a record written by a thread
+func (c *checkpointer) written() {
+	if c.records.Add(1) >= c.every {
+		c.request()
+	}
+}
+
+// request asks for a checkpoint without waiting on it
+func (c *checkpointer) request() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// checkpoint persists the state once every thread wrote the records it received so far;
+// changes of the state follow the records they cover, so the snapshot covers only written
+// records
+func (c *checkpointer) checkpoint(ctx context.Context) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.records.Store(0)
+	snapshot := c.state.Snapshot()
+	if snapshot == nil {
+		return nil
+	}
+	if err := c.pool.flushThreads(ctx); err != nil {
+		snapshot.Discard()
+		return err
+	}
+	snapshot.Persist()
+	return nil
+}
+
+// stop waits on a checkpoint in progress and stops taking new ones
+func (c *checkpointer) stop() {
+	c.stopOnce.Do(func() {
+		close(c.stopped)
+		<-c.done
+	})
+}
+
+func (w *WriterPool) openBarrier() *threadBarrier {
+	barrier := &threadBarrier{requests: make(chan chan error), done: make(chan struct{})}
+	w.barriers.Store(barrier, struct{}{})
+	return barrier
+}
+
+// closeBarrier is called once the thread closed its writer with the error it ended with
+func (w *WriterPool) closeBarrier(barrier *threadBarrier, err error) {
+	barrier.err = err
+	if err != nil {
+		w.threadFailed.Store(true)
+	}
+	close(barrier.done)
+	w.barriers.Delete(barrier)
+}
+
+// flushThreads waits on every open thread to write, and flush when it buffers, the records it
+// received so far; threads closing meanwhile wrote all of theirs
+func (w *WriterPool) flushThreads(ctx context.Context) error {
+	var err error
+	w.barriers.Range(func(key, _ any) bool {
+		barrier := key.(*threadBarrier)
+		ack := make(chan error, 1)
+		select {
+		case barrier.requests <- ack:
+			select {
+			case err = <-ack:
+			case <-barrier.done:
+				err = barrier.err
+			}
+		case <-barrier.done:
+			err = barrier.err
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	if w.threadFailed.Load() {
+		return fmt.Errorf("a writer thread failed")
+	}
+	return nil
+}
+
+// flushWriter makes the records written by a thread durable when its writer buffers them
+func flushWriter(ctx context.Context, thread Writer) error {
+	flusher, ok := thread.(Flusher)
+	if !ok {
+		return nil
+	}
+	if err := flusher.Flush(ctx); err != nil {
+		return fmt.Errorf("failed to flush writer: %s", err)
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.PersistentFlags().Int64VarP(&checkpointRecords, "checkpoint-every-records", "", 0, "(Optional) Checkpoint state after this many records are written; defaults to --batch")
+	RootCmd.PersistentFlags().IntVarP(&checkpointSeconds, "checkpoint-every-seconds", "", 60, "(Optional) Checkpoint state at this interval; 0 disables. State is checkpointed only once writers acknowledged the records it covers")
+}
@@ -0,0 +1,114 @@
+package protocol
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+// bufferingWriter holds records back until they are flushed
+type bufferingWriter struct {
+	*sampleWriter
+	buffered int64
+	flushed  *atomic.Int64
+}
+
+func (b *bufferingWriter) Write(_ context.Context, _ types.RawRecord) error {
+	b.buffered++
+	return nil
+}
+
+func (b *bufferingWriter) Flush(_ context.Context) error {
+	b.flushed.Add(b.buffered)
+	b.buffered = 0
+	return nil
+}
+
+// checkpointSaver records the state saved along with the records flushed by then
+type checkpointSaver struct {
+	mutex   sync.Mutex
+	flushed *atomic.Int64
+	saved   []string
+	covered []int64
+}
+
+func (c *checkpointSaver) Save(state []byte) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.saved = append(c.saved, string(state))
+	c.covered = append(c.covered, c.flushed.Load())
+	return nil
+}
+
+func TestCheckpoints(t *testing.T) {
+	defer func(records int64, seconds int) {
+		checkpointRecords, checkpointSeconds = records, seconds
+	}(checkpointRecords, checkpointSeconds)
+	checkpointRecords, checkpointSeconds = 3, 0
+
+	ctx := context.Background()
+	pool, err := newSamplePool(ctx, nil, &sampler{limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flushed := &atomic.Int64{}
+	pool.init = func() Writer {
+		return &bufferingWriter{sampleWriter: &sampleWriter{}, flushed: flushed}
+	}
+	saver := &checkpointSaver{flushed: flushed}
+	state := &types.State{RWMutex: &sync.RWMutex{}, Type: types.StreamType, Saver: saver}
+	checkpoints := pool.startCheckpoints(ctx, state)
+	defer checkpoints.stop()
+
+	stream := &types.ConfiguredStream{Stream: types.NewStream("users", "public")}
+	thread, err := pool.NewThread(ctx, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	insert := func(id int) {
+		if err := thread.Insert(types.CreateRawRecord("id", map[string]any{"id": id}, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	insert(1)
+	insert(2)
+	state.SetCursor(stream, "id", 2)
+	saver.mutex.Lock()
+	if len(saver.saved) != 0 {
+		t.Fatalf("expected the cursor to wait for a checkpoint, got %v", saver.saved)
+	}
+	saver.mutex.Unlock()
+
+	insert(3)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		saver.mutex.Lock()
+		saved := len(saver.saved)
+		saver.mutex.Unlock()
+		if saved > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected a checkpoint after 3 records")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	checkpoints.stop()
+	thread.Close()
+	if err := pool.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(saver.saved[0], `"id":2`) {
+		t.Fatalf("expected the checkpoint to hold the cursor, got %s", saver.saved[0])
+	}
+	// records written before the cursor was set were flushed before it was saved
+	if saver.covered[0] < 2 {
+		t.Fatalf("expected the records before the cursor to be flushed first, %d were", saver.covered[0])
+	}
+}
@@ -0,0 +1,73 @@
+package protocol
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/datazip-inc/olake/pkg/ddl"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/spf13/cobra"
+)
+
+var ddlDialect string
+
+// generateDDLCmd prints the CREATE TABLE statements of the selected streams of the catalog,
+// for destinations where writers aren't allowed to create tables
+var generateDDLCmd = &cobra.Command{
+	Use:   "generate-ddl",
+	Short: "Olake generate-ddl command; prints CREATE TABLE statements for the selected streams of --catalog in the --dialect of the destination",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		dialect, err := ddl.ParseDialect(ddlDialect)
+		if err != nil {
+			return err
+		}
+		if catalogPath == "" {
+			return fmt.Errorf("--catalog not passed")
+		}
+		catalog = &types.Catalog{}
+		if err := types.CatalogFormat.LoadFile(catalogPath, catalog); err != nil {
+			return err
+		}
+		// naming and metadata columns of the destination shape the tables when passed
+		writerConfig := &types.WriterConfig{}
+		if configured("destination", destinationConfigPath) {
+			if err := utils.UnmarshalFileWithEnv(destinationConfigPath, "destination", writerConfig); err != nil {
+				return err
+			}
+			if writerConfig.Naming != nil {
+				if err := writerConfig.Naming.Validate(); err != nil {
+					return err
+				}
+			}
+		}
+
+		selected := make(map[string]types.StreamMetadata)
+		for namespace, streamsMetadata := range catalog.SelectedStreams {
+			for _, streamMetadata := range streamsMetadata {
+				selected[utils.StreamIdentifier(streamMetadata.StreamName, namespace)] = streamMetadata
+			}
+		}
+		statements := []string{}
+		for _, stream := range catalog.Streams {
+			streamMetadata, isSelected := selected[stream.ID()]
+			if catalog.SelectedStreams != nil && !isSelected {
+				continue
+			}
+			stream.StreamMetadata = streamMetadata
+			stream.ApplyOverrides()
+			table := ddl.FromStream(stream, writerConfig.Naming, writerConfig.MetadataColumns)
+			statements = append(statements, dialect.CreateTable(table))
+		}
+		if len(statements) == 0 {
+			return fmt.Errorf("no streams selected in catalog[%s]", catalogPath)
+		}
+		_, err = fmt.Fprint(cmd.OutOrStdout(), strings.Join(statements, "\n"))
+		return err
+	},
+}
+
+func init() {
+	commands = append(commands, generateDDLCmd)
+	RootCmd.PersistentFlags().StringVarP(&ddlDialect, "dialect", "", "", "(Required by generate-ddl) SQL dialect of the destination tables: postgres, snowflake or clickhouse")
+}
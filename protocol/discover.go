@@ -3,22 +3,32 @@ package protocol
 import (
 	"errors"
 	"fmt"
+	"os"
+	"sort"
 
+	"github.com/datazip-inc/olake/logger"
 	"github.com/datazip-inc/olake/types"
 	"github.com/datazip-inc/olake/utils"
 	"github.com/spf13/cobra"
 )
 
+var (
+	// file the catalog is written to in place of printing it
+	discoverOutput string
+	// streams sampled at once; the default of the driver when 0
+	discoverConcurrency int
+)
+
 // discoverCmd represents the read command
 var discoverCmd = &cobra.Command{
 	Use:   "discover",
 	Short: "discover command",
 	PreRunE: func(_ *cobra.Command, _ []string) error {
-		if configPath == "" {
+		if !configured("config", configPath) {
 			return fmt.Errorf("--config not passed")
 		}
 
-		if err := utils.UnmarshalFile(configPath, connector.GetConfigRef()); err != nil {
+		if err := utils.UnmarshalFileWithEnv(configPath, "config", connector.GetConfigRef()); err != nil {
 			return err
 		}
 
@@ -29,16 +39,82 @@ var discoverCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		streams, err := connector.Discover(true)
-		if err != nil {
+		streams, err := discoverStreams(true)
+		// streams failing to be sampled leave the others in the catalog
+		var failures *types.DiscoverError
+		if err != nil && !errors.As(err, &failures) {
 			return err
 		}
 
-		if len(streams) == 0 {
+		if len(streams) == 0 && err == nil {
 			return errors.New("no streams found in connector")
 		}
+		for _, stream := range streams {
+			logger.Infof("Stream %s supports sync modes %v with cursor fields %v and primary key %v", stream.ID(), stream.SupportedSyncModes, stream.AvailableCursorFields, stream.SourceDefinedPrimaryKey)
+		}
 
-		types.LogCatalog(streams)
+		if len(streams) > 0 {
+			types.LogCatalog(mergeCatalog(streams), discoverOutput)
+		}
+		if err != nil {
+			for _, failure := range failures.Failed {
+				logger.Errorf("failed to discover stream[%s]: %s", failure.Stream, failure.Error)
+			}
+			return fmt.Errorf("failed to discover %d streams; the catalog lists the %d others", len(failures.Failed), len(streams))
+		}
 		return nil
 	},
 }
+
+// mergeCatalog builds the catalog of streams on top of the existing one, --catalog or the
+// catalog file discover writes, so selections and overrides made in it are kept
+func mergeCatalog(streams []*types.Stream) *types.Catalog {
+	path := catalogPath
+	if path == "" {
+		path = types.CatalogPath(discoverOutput)
+	}
+	if path == "" {
+		return types.GetWrappedCatalog(streams)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return types.GetWrappedCatalog(streams)
+	}
+	previous := &types.Catalog{}
+	if err := types.CatalogFormat.LoadFile(path, previous); err != nil {
+		logger.Warnf("failed to load existing catalog, writing a new one: %s", err)
+		return types.GetWrappedCatalog(streams)
+	}
+
+	merged, changes := types.MergeCatalog(previous, streams)
+	logger.Infof("Merged discovered streams into catalog[%s] with %d changes", path, len(changes))
+	for _, change := range changes {
+		logger.Infof("catalog change in stream[%s]: %s", change.Stream, change.Change)
+	}
+	return merged
+}
+
+// discoverStreams discovers the source streams, keeping only the sync modes every stream can
+// actually be synced with
+func discoverStreams(discoverSchema bool) ([]*types.Stream, error) {
+	if discoverConcurrency < 0 {
+		return nil, fmt.Errorf("--discover-concurrency must not be negative")
+	}
+	if limiter, ok := connector.(DiscoverLimiter); ok {
+		limiter.SetDiscoverConcurrency(discoverConcurrency)
+	}
+	streams, err := connector.Discover(discoverSchema)
+	// a types.DiscoverError comes with the streams sampled
+	var failures *types.DiscoverError
+	if err != nil && !errors.As(err, &failures) {
+		return nil, err
+	}
+	for _, stream := range streams {
+		stream.ReconcileSyncModes(connector.ChangeStreamSupported())
+	}
+	// drivers discover streams concurrently; ordered so catalogs are written the same way by
+	// every discover
+	sort.Slice(streams, func(i, j int) bool {
+		return streams[i].ID() < streams[j].ID()
+	})
+	return streams, err
+}
@@ -0,0 +1,127 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/goccy/go-json"
+)
+
+func TestWriteCatalog(t *testing.T) {
+	users := types.NewStream("users", "app").WithSyncMode(types.FULLREFRESH).WithPrimaryKey("id")
+	users.UpsertField("id", types.Int64, false)
+	streams := []*types.Stream{users, types.NewStream("orders", "app"), types.NewStream("events", "audit")}
+
+	var written bytes.Buffer
+	if err := types.WriteCatalog(&written, types.GetWrappedCatalog(streams)); err != nil {
+		t.Fatal(err)
+	}
+	marshaled, err := json.Marshal(types.GetWrappedCatalog(streams))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected, actual any
+	if err := json.Unmarshal(marshaled, &expected); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(written.Bytes(), &actual); err != nil {
+		t.Fatalf("expected the written catalog to be valid json: %s\n%s", err, written.String())
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected the catalog\n%s\ngot\n%s", marshaled, written.String())
+	}
+}
+
+func TestDiscoverError(t *testing.T) {
+	failures := &types.DiscoverError{}
+	if failures.Err() != nil {
+		t.Fatal("expected no error without failed streams")
+	}
+	err := utils.Concurrent(context.Background(), []string{"app.users", "app.orders", "app.events"}, 2, func(_ context.Context, stream string, _ int) error {
+		if stream != "app.users" {
+			failures.Add(stream, fmt.Errorf("permission denied"))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var discoverErr *types.DiscoverError
+	if !errors.As(failures.Err(), &discoverErr) || len(discoverErr.Failed) != 2 {
+		t.Fatalf("expected the two failed streams to be reported, got %v", failures.Err())
+	}
+}
+
+func TestCatalogDeterministic(t *testing.T) {
+	stream := types.NewStream("users", "app").WithSyncMode(types.INCREMENTAL, types.FULLREFRESH, types.CDC).WithPrimaryKey("tenant", "id")
+	for _, column := range []string{"updated_at", "id", "name", "created_at"} {
+		stream.UpsertField(column, types.String, true)
+	}
+	first, err := json.Marshal(types.GetWrappedCatalog([]*types.Stream{stream}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		again, err := json.Marshal(types.GetWrappedCatalog([]*types.Stream{stream}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(first, again) {
+			t.Fatalf("expected the catalog to be marshaled the same way every time\n%s\n%s", first, again)
+		}
+	}
+	if !bytes.Contains(first, []byte(`"supported_sync_modes":["cdc","full_refresh","incremental"]`)) || !bytes.Contains(first, []byte(`"type":["null","string"]`)) {
+		t.Fatalf("expected sets to be sorted, got %s", first)
+	}
+}
+
+func TestMergeCatalog(t *testing.T) {
+	discovered := func() []*types.Stream {
+		users := types.NewStream("users", "app").WithSyncMode(types.FULLREFRESH, types.INCREMENTAL).WithCursorField("updated_at")
+		users.UpsertField("id", types.Int64, false)
+		users.UpsertField("updated_at", types.Timestamp, true)
+		users.SyncMode = types.FULLREFRESH
+		orders := types.NewStream("orders", "app").WithSyncMode(types.FULLREFRESH)
+		orders.UpsertField("id", types.Int64, false)
+		return []*types.Stream{orders, users}
+	}
+	previous := types.GetWrappedCatalog(discovered())
+	// the user picked incremental for users, overrode its key and only selected it
+	previous.Streams[1].Stream.SyncMode = types.INCREMENTAL
+	previous.Streams[1].CursorField = "updated_at"
+	previous.Streams[1].PrimaryKey = []string{"id"}
+	previous.SelectedStreams = map[string][]types.StreamMetadata{"app": {{StreamName: "users", SplitColumn: "id", Filter: "id > 10"}}}
+	previous.Streams = append(previous.Streams, &types.ConfiguredStream{Stream: types.NewStream("dropped", "app")})
+
+	streams := discovered()
+	streams[1].UpsertField("email", types.String, true)
+	streams = append(streams, types.NewStream("events", "app"))
+	merged, changes := types.MergeCatalog(previous, streams)
+
+	users := merged.Streams[1]
+	if users.Stream.SyncMode != types.INCREMENTAL || users.CursorField != "updated_at" || len(users.PrimaryKey) != 1 {
+		t.Fatalf("expected the overrides of users to be kept, got %+v", users)
+	}
+	if selected := merged.SelectedStreams["app"]; len(selected) != 1 || selected[0].Filter != "id > 10" {
+		t.Fatalf("expected only users to stay selected with its metadata, got %+v", merged.SelectedStreams)
+	}
+	reported := []string{}
+	for _, change := range changes {
+		reported = append(reported, change.Stream+": "+change.Change)
+	}
+	expected := []string{
+		"app.users: column[email] added as [null,string]",
+		"app.events: stream added; select it to sync it",
+		"app.dropped: stream removed from the source",
+	}
+	if !reflect.DeepEqual(reported, expected) {
+		t.Fatalf("expected changes %v, got %v", expected, reported)
+	}
+}
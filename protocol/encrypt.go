@@ -0,0 +1,32 @@
+package protocol
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/datazip-inc/olake/pkg/encryption"
+	"github.com/spf13/cobra"
+)
+
+// encryptCmd encrypts a value read from stdin so it can replace the plain value in a config
+var encryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Olake encrypt command; prints the value read from stdin encrypted with the key in " + encryption.KeyEnv + " or " + encryption.KMSKeyEnv,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		if !encryption.Enabled() {
+			return fmt.Errorf("neither %s nor %s is set", encryption.KeyEnv, encryption.KMSKeyEnv)
+		}
+		value, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read value from stdin: %s", err)
+		}
+		encrypted, err := encryption.Encrypt([]byte(strings.TrimRight(string(value), "\r\n")))
+		if err != nil {
+			return err
+		}
+		fmt.Println(encrypted)
+		return nil
+	},
+}
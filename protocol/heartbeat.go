@@ -0,0 +1,178 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/goccy/go-json"
+	"github.com/spf13/viper"
+)
+
+const (
+	heartbeatRunning   = "running"
+	heartbeatSucceeded = "succeeded"
+	heartbeatFailed    = "failed"
+)
+
+var (
+	heartbeatPath     string
+	heartbeatInterval time.Duration
+	// a sync without progress for longer is reported unhealthy; 0 disables the check
+	stallTimeout time.Duration
+
+	// heartbeat of the running sync served by /healthz
+	activeHeartbeat atomic.Pointer[heartbeat]
+)
+
+// HeartbeatStatus is written to the heartbeat file and served by /healthz; orchestrators treat
+// a stale updated_at as a dead process and a stale last_progress_at as a hung sync
+type HeartbeatStatus struct {
+	Status         string    `json:"status"`
+	SyncID         string    `json:"sync_id,omitempty"`
+	PID            int       `json:"pid"`
+	StartedAt      time.Time `json:"started_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	LastProgressAt time.Time `json:"last_progress_at"`
+	SyncedRecords  int64     `json:"synced_records"`
+	RunningThreads int64     `json:"running_threads"`
+	Stalled        bool      `json:"stalled"`
+	Error          string    `json:"error,omitempty"`
+}
+
+type heartbeat struct {
+	mutex  sync.Mutex
+	status HeartbeatStatus
+	pool   *WriterPool
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startHeartbeat rewrites the heartbeat file every interval until stop is called
+func startHeartbeat(ctx context.Context, pool *WriterPool) *heartbeat {
+	now := time.Now().UTC()
+	beat := &heartbeat{
+		status: HeartbeatStatus{Status: heartbeatRunning, SyncID: viper.GetString("SYNC_ID"), PID: os.Getpid(), StartedAt: now, UpdatedAt: now, LastProgressAt: now},
+		pool:   pool,
+		done:   make(chan struct{}),
+	}
+	ctx, beat.cancel = context.WithCancel(ctx)
+	beat.write()
+	activeHeartbeat.Store(beat)
+
+	go func() {
+		defer close(beat.done)
+		interval := heartbeatInterval
+		if interval <= 0 {
+			interval = 5 * time.Second
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				beat.update()
+				beat.write()
+			}
+		}
+	}()
+	return beat
+}
+
+// update records progress when records were synced since the last beat
+func (h *heartbeat) update() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	now := time.Now().UTC()
+	h.status.UpdatedAt = now
+	if records := h.pool.SyncedRecords(); records != h.status.SyncedRecords {
+		h.status.SyncedRecords = records
+		h.status.LastProgressAt = now
+	}
+	h.status.RunningThreads = h.pool.threadCounter.Load()
+	h.status.Stalled = stallTimeout > 0 && now.Sub(h.status.LastProgressAt) > stallTimeout
+}
+
+func (h *heartbeat) snapshot() HeartbeatStatus {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.status
+}
+
+// write replaces the heartbeat file so readers never see a partial file
+func (h *heartbeat) write() {
+	path := heartbeatFilePath()
+	data, err := json.Marshal(h.snapshot())
+	if err != nil {
+		logger.Warnf("failed to marshal heartbeat: %s", err)
+		return
+	}
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, data, 0644); err != nil {
+		logger.Warnf("failed to write heartbeat file[%s]: %s", path, err)
+		return
+	}
+	if err := os.Rename(temp, path); err != nil {
+		logger.Warnf("failed to replace heartbeat file[%s]: %s", path, err)
+	}
+}
+
+// stop writes the final status of the sync
+func (h *heartbeat) stop(syncErr error) {
+	h.cancel()
+	<-h.done
+	h.update()
+	h.mutex.Lock()
+	h.status.Status = heartbeatSucceeded
+	h.status.Stalled = false
+	if syncErr != nil {
+		h.status.Status = heartbeatFailed
+		if errors.Is(syncErr, ErrPartialSync) {
+			h.status.Status = syncPartial
+		}
+		h.status.Error = syncErr.Error()
+	}
+	h.mutex.Unlock()
+	h.write()
+	activeHeartbeat.CompareAndSwap(h, nil)
+}
+
+func heartbeatFilePath() string {
+	if heartbeatPath != "" {
+		return heartbeatPath
+	}
+	return filepath.Join(viper.GetString("CONFIG_FOLDER"), "heartbeat.json")
+}
+
+// healthz answers 200 while the process is idle or the sync progresses, 503 once the sync
+// stalled past the stall timeout
+func healthz(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	beat := activeHeartbeat.Load()
+	if beat == nil {
+		_, _ = w.Write([]byte(`{"status":"idle"}`))
+		return
+	}
+	beat.update()
+	status := beat.snapshot()
+	if status.Stalled {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		logger.Warnf("failed to write healthz response: %s", err)
+	}
+}
+
+func init() {
+	RootCmd.PersistentFlags().StringVarP(&heartbeatPath, "heartbeat-file", "", "", "(Optional) Path of the heartbeat file; defaults to heartbeat.json in the config folder")
+	RootCmd.PersistentFlags().DurationVarP(&heartbeatInterval, "heartbeat-interval", "", 5*time.Second, "(Optional) Interval between heartbeat file updates")
+	RootCmd.PersistentFlags().DurationVarP(&stallTimeout, "stall-timeout", "", 0, "(Optional) Report the sync unhealthy on /healthz after no records were synced for this long; 0 disables")
+}
@@ -0,0 +1,83 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+func readHeartbeat(t *testing.T, path string) HeartbeatStatus {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := HeartbeatStatus{}
+	if err := json.Unmarshal(data, &status); err != nil {
+		t.Fatal(err)
+	}
+	return status
+}
+
+func TestHeartbeatFile(t *testing.T) {
+	heartbeatPath = filepath.Join(t.TempDir(), "heartbeat.json")
+	defer func() { heartbeatPath = "" }()
+
+	pool := &WriterPool{}
+	beat := startHeartbeat(context.Background(), pool)
+	if status := readHeartbeat(t, heartbeatPath); status.Status != heartbeatRunning {
+		t.Errorf("expected running status, got %s", status.Status)
+	}
+
+	pool.recordCount.Add(5)
+	beat.stop(errors.New("source went away"))
+	status := readHeartbeat(t, heartbeatPath)
+	if status.Status != heartbeatFailed || status.Error != "source went away" {
+		t.Errorf("expected failed status with error, got %+v", status)
+	}
+	if status.SyncedRecords != 5 {
+		t.Errorf("expected 5 synced records, got %d", status.SyncedRecords)
+	}
+	if activeHeartbeat.Load() != nil {
+		t.Error("stopped heartbeat must not be served")
+	}
+}
+
+func TestHealthzStall(t *testing.T) {
+	heartbeatPath = filepath.Join(t.TempDir(), "heartbeat.json")
+	stallTimeout = time.Minute
+	defer func() { heartbeatPath, stallTimeout = "", 0 }()
+
+	recorder := httptest.NewRecorder()
+	healthz(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("expected idle process to be healthy, got %d", recorder.Code)
+	}
+
+	pool := &WriterPool{}
+	beat := startHeartbeat(context.Background(), pool)
+	defer beat.stop(nil)
+
+	recorder = httptest.NewRecorder()
+	healthz(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("expected progressing sync to be healthy, got %d", recorder.Code)
+	}
+
+	// no records synced since long before the stall timeout
+	beat.mutex.Lock()
+	beat.status.LastProgressAt = time.Now().Add(-2 * time.Minute)
+	beat.mutex.Unlock()
+	recorder = httptest.NewRecorder()
+	healthz(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected stalled sync to be unhealthy, got %d", recorder.Code)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/pprof"
+	"os"
 	"time"
 
 	"github.com/datazip-inc/olake/logger"
@@ -11,20 +12,26 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// debugPortEnv overrides the port of the debug server; 0 picks a free port
+const debugPortEnv = "OLAKE_DEBUG_PORT"
+
 func init() {
-	master := mux.NewRouter()
-	master.HandleFunc("/debug/pprof", pprof.Index)
-	master.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
-	master.Handle("/debug/pprof/profile", fgprof.Handler())
-	master.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
-	master.Handle("/debug/pprof/goroutine", pprof.Handler("goroutine"))
-	master.Handle("/debug/pprof/heap", pprof.Handler("heap"))
-	master.Handle("/debug/pprof/threadcreate", pprof.Handler("threadcreate"))
-	master.Handle("/debug/pprof/block", pprof.Handler("block"))
+	port := "8080"
+	if override, set := os.LookupEnv(debugPortEnv); set {
+		port = override
+	}
+
+	if path := os.Getenv(apiTokensEnv); path != "" {
+		tokens, err := loadAPITokens(path)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		apiTokens = tokens
+	}
 
 	server := &http.Server{
-		Addr:              fmt.Sprintf(":%d", 8080),
-		Handler:           master,
+		Addr:              fmt.Sprintf(":%s", port),
+		Handler:           newRouter(),
 		ReadTimeout:       time.Second * 60,
 		ReadHeaderTimeout: time.Second * 60,
 		IdleTimeout:       time.Second * 65,
@@ -36,3 +43,25 @@ func init() {
 		}
 	}()
 }
+
+// newRouter serves the debug endpoints to admins and the schedule api to the roles of its
+// routes; /healthz stays open for orchestrators
+func newRouter() *mux.Router {
+	master := mux.NewRouter()
+	debug := func(handler http.Handler) http.Handler {
+		return authorize(RoleAdmin, handler)
+	}
+	master.Handle("/debug/pprof", debug(http.HandlerFunc(pprof.Index)))
+	master.Handle("/debug/pprof/cmdline", debug(http.HandlerFunc(pprof.Cmdline)))
+	master.Handle("/debug/pprof/profile", debug(fgprof.Handler()))
+	master.Handle("/debug/pprof/symbol", debug(http.HandlerFunc(pprof.Symbol)))
+	master.Handle("/debug/pprof/goroutine", debug(pprof.Handler("goroutine")))
+	master.Handle("/debug/pprof/heap", debug(pprof.Handler("heap")))
+	master.Handle("/debug/pprof/threadcreate", debug(pprof.Handler("threadcreate")))
+	master.Handle("/debug/pprof/block", debug(pprof.Handler("block")))
+	master.HandleFunc("/healthz", healthz)
+
+	master.Handle("/api/v1/jobs", authorize(RoleViewer, http.HandlerFunc(listJobs))).Methods(http.MethodGet)
+	master.Handle("/api/v1/jobs/{id:[A-Za-z0-9_/-]+}/run", authorize(RoleOperator, http.HandlerFunc(runJob))).Methods(http.MethodPost)
+	return master
+}
@@ -2,7 +2,10 @@ package protocol
 
 import (
 	"context"
+	"time"
 
+	"github.com/datazip-inc/olake/pkg/ddl"
+	"github.com/datazip-inc/olake/pkg/oauth"
 	"github.com/datazip-inc/olake/types"
 )
 
@@ -27,15 +30,37 @@ type Driver interface {
 	Setup() error
 	// Discover discovers the streams; Returns cached if already discovered
 	Discover(discoverSchema bool) ([]*types.Stream, error)
-	// Read is dedicatedly designed for FULL_REFRESH and INCREMENTAL mode
-	Read(pool *WriterPool, stream Stream) error
+	// Read is dedicatedly designed for FULL_REFRESH and INCREMENTAL mode; must return once ctx
+	// is done so a wedged stream can be cancelled
+	Read(ctx context.Context, pool *WriterPool, stream Stream) error
 	ChangeStreamSupported() bool
 	SetupState(state *types.State)
 }
 
+// OAuthDriver is implemented by drivers authenticating with oauth2; enables the authorize
+// command and persists refresh tokens rotated by the provider in the state
+type OAuthDriver interface {
+	// config of the oauth2 client built from the driver config
+	OAuthConfig() (*oauth.Config, error)
+	// called with the state before Setup so a token rotated by a previous sync is used
+	SetCredentialStore(store oauth.CredentialStore)
+}
+
+// TimezoneReporter is implemented by drivers that know the time zone of the source server,
+// which naive timestamps are read in under the source policy
+type TimezoneReporter interface {
+	SourceTimezone() (*time.Location, error)
+}
+
+// DiscoverLimiter is implemented by drivers bounding the streams they sample at once while
+// discovering; set from --discover-concurrency
+type DiscoverLimiter interface {
+	SetDiscoverConcurrency(limit int)
+}
+
 // Bulk Read Driver
 type ChangeStreamDriver interface {
-	RunChangeStream(pool *WriterPool, streams ...Stream) error
+	RunChangeStream(ctx context.Context, pool *WriterPool, streams ...Stream) error
 	StateType() types.StateType
 }
 
@@ -49,14 +74,32 @@ type Writer interface {
 	Setup(stream Stream, opts *Options) error
 	// Write function being used by drivers
 	Write(ctx context.Context, record types.RawRecord) error
+	// Delete removes the row identified by the record's olake id, used in hard delete mode;
+	// append only destinations return ErrDeleteUnsupported
+	Delete(ctx context.Context, record types.RawRecord) error
 
 	// ReInitiationRequiredOnSchemaEvolution is implemented by Writers incase the writer needs to be re-initialized
 	// such as when writing parquet files, but in destinations like Kafka/Clickhouse/BigQuery they can handle
 	// schema update with an Alter Query
 	Normalization() bool
+	// SupportedTypes is the capability matrix of the writer; values of other types are coerced
+	// by the pool before they reach the writer
+	SupportedTypes() *types.Set[types.DataType]
 	Flattener() FlattenFunction
 	EvolveSchema(bool, bool, map[string]*types.Property, types.Record) error
 	Close() error
+
+	// Lifecycle hooks run on a dedicated instance, never on a thread writing records.
+	//
+	// BeginSync is called once before any stream is read
+	BeginSync(ctx context.Context) error
+	// BeginStream is called once per stream before its first thread is set up
+	BeginStream(ctx context.Context, stream Stream) error
+	// EndStream is called once per stream after all threads of the sync closed successfully;
+	// the place for atomic swaps, compaction or metadata commits
+	EndStream(ctx context.Context, stream Stream) error
+	// EndSync is called last; syncErr is set when the sync failed so the writer can clean up
+	EndSync(ctx context.Context, syncErr error) error
 }
 
 type Stream interface {
@@ -69,9 +112,69 @@ type Stream interface {
 	GetSyncMode() types.SyncMode
 	SupportedSyncModes() *types.Set[types.SyncMode]
 	Cursor() string
+	CursorFields() []string
 	Validate(source *types.Stream) error
 }
 
+// UploadTracker persists multipart upload progress of writers in state
+type UploadTracker interface {
+	TrackUpload(upload *types.PendingUpload)
+	CompleteUpload(uploadID string)
+	PendingUploads() []*types.PendingUpload
+}
+
+// UploadResumer is implemented by object store writers; called once per sync before any
+// thread is set up to resume or abort uploads interrupted in a previous sync
+type UploadResumer interface {
+	ResumeUploads(tracker UploadTracker) error
+}
+
+// Flusher is implemented by writers buffering records; Flush makes the records written so far
+// durable in the destination so the state covering them can be checkpointed
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// PartialWriter is implemented by writers keeping the columns a partial record leaves out
+// apart from the columns it sets to null, e.g. by leaving them out of the payload, so
+// upserts of the destination keep their value; streams sending partial records to other
+// writers fail
+type PartialWriter interface {
+	PartialRecords() bool
+}
+
+// PooledWriter is implemented by writers done with the data of a record once Write returns,
+// e.g. by encoding it right away; the maps of their records are reused for the records read
+// next, so writers buffering records must not implement it
+type PooledWriter interface {
+	PooledRecords() bool
+}
+
+// DeepChecker is implemented by drivers and writers with pre-flight diagnostics beyond Check,
+// such as privileges and server settings; run by `check --deep`
+type DeepChecker interface {
+	DeepCheck(ctx context.Context) []types.CheckResult
+}
+
+// StateChecker is implemented by drivers able to verify the positions kept in state still exist
+// in the source, such as replication slots and resume tokens; run by `state doctor`
+type StateChecker interface {
+	CheckState(ctx context.Context, state *types.State, streams []Stream) []types.StateIssue
+}
+
+// RowCounter is implemented by drivers and writers able to count the rows of a stream; writers
+// count the rows a reader of the destination sees. Used by quality checks and reconcile
+type RowCounter interface {
+	CountRows(ctx context.Context, stream Stream) (int64, error)
+}
+
+// RowScanner is implemented by drivers and writers able to read back every row of a stream;
+// writers return the latest version of every row and leave out deleted rows. Used by reconcile
+// to checksum rows
+type RowScanner interface {
+	ScanRows(ctx context.Context, stream Stream, fn func(row map[string]any) error) error
+}
+
 type State interface {
 	ResetStreams()
 	SetType(typ types.StateType)
@@ -82,3 +185,18 @@ type State interface {
 	RemoveChunk(stream *types.ConfiguredStream, chunk types.Chunk)
 	SetGlobalState(globalState any)
 }
+
+// Maintainer is implemented by writers of destinations that degrade as syncs add to them, e.g.
+// with small files or files left behind by failed syncs; run by `maintain`. result is filled in
+// as the work is done, so it stays accurate when an error is returned
+type Maintainer interface {
+	Maintain(ctx context.Context, stream Stream, options MaintainOptions, result *StreamMaintenance) error
+}
+
+// DDLExecutor is implemented by writers of SQL destinations; as the schema of a stream evolves,
+// the pool alters the table of the stream with the statements the schema_migration policy of
+// the destination allows, before EvolveSchema is called
+type DDLExecutor interface {
+	Dialect() ddl.Dialect
+	ExecDDL(ctx context.Context, statement string) error
+}
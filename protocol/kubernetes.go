@@ -0,0 +1,411 @@
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/goccy/go-json"
+	"github.com/spf13/viper"
+)
+
+// mounted into every pod by kubernetes
+const serviceAccountFolder = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// how often the status of a launched job is checked
+var kubernetesPollInterval = 5 * time.Second
+
+var nonNameCharacters = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// KubernetesConfig launches every sync as a kubernetes job, so syncs are isolated from each
+// other and from the scheduler. The scheduler authenticates with the service account of its
+// pod unless api_server and token_file are set.
+type KubernetesConfig struct {
+	// image of the driver, e.g. olakego/source-postgres:latest
+	Image string `json:"image" validate:"required"`
+	// defaults to the namespace of the scheduler pod, or default
+	Namespace      string `json:"namespace,omitempty"`
+	ServiceAccount string `json:"service_account,omitempty"`
+	// cpu and memory quantities, e.g. {"requests": {"cpu": "500m"}, "limits": {"memory": "2Gi"}}
+	Resources    map[string]map[string]string `json:"resources,omitempty"`
+	NodeSelector map[string]string            `json:"node_selector,omitempty"`
+	// persistent volume claim holding the config folder, mounted at mount_path; defaults to
+	// the folder of the schedule config, so job paths resolve the same way in the pods
+	ConfigVolumeClaim string `json:"config_volume_claim,omitempty"`
+	MountPath         string `json:"mount_path,omitempty"`
+	// secrets whose keys are set as environment variables of the syncs, e.g. credentials
+	EnvFromSecrets []string `json:"env_from_secrets,omitempty"`
+	// finished jobs are deleted by kubernetes after this many seconds; defaults to a day
+	TTLSecondsAfterFinished int `json:"ttl_seconds_after_finished,omitempty"`
+	// reaching the api from outside the cluster
+	APIServer string `json:"api_server,omitempty"`
+	TokenFile string `json:"token_file,omitempty"`
+	CAFile    string `json:"ca_file,omitempty"`
+}
+
+func (c *KubernetesConfig) Validate() error {
+	if c.Image == "" {
+		return fmt.Errorf("image not set")
+	}
+	if c.TTLSecondsAfterFinished < 0 {
+		return fmt.Errorf("negative ttl_seconds_after_finished")
+	}
+	if c.TTLSecondsAfterFinished == 0 {
+		c.TTLSecondsAfterFinished = 86400
+	}
+	if c.Namespace == "" {
+		c.Namespace = "default"
+		if namespace, err := os.ReadFile(serviceAccountFolder + "/namespace"); err == nil {
+			c.Namespace = strings.TrimSpace(string(namespace))
+		}
+	}
+	if c.APIServer == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" {
+			return fmt.Errorf("api_server not set and the scheduler isn't running in a kubernetes pod")
+		}
+		c.APIServer = "https://" + net.JoinHostPort(host, port)
+		if c.TokenFile == "" {
+			c.TokenFile = serviceAccountFolder + "/token"
+		}
+		if c.CAFile == "" {
+			c.CAFile = serviceAccountFolder + "/ca.crt"
+		}
+	}
+	for kind, quantities := range c.Resources {
+		if kind != "requests" && kind != "limits" {
+			return fmt.Errorf("invalid resources key[%s]; expected requests or limits", kind)
+		}
+		for resource := range quantities {
+			if resource != "cpu" && resource != "memory" && resource != "ephemeral-storage" {
+				return fmt.Errorf("invalid resource[%s]; expected cpu, memory or ephemeral-storage", resource)
+			}
+		}
+	}
+	return nil
+}
+
+// kubernetesExecutor creates a job per sync through the kubernetes api, streams the logs of
+// its pod to the scheduler log and waits for it to finish
+type kubernetesExecutor struct {
+	config    *KubernetesConfig
+	client    *http.Client
+	mountPath string
+}
+
+func newKubernetesExecutor(config *KubernetesConfig) (*kubernetesExecutor, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.CAFile != "" {
+		ca, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubernetes ca[%s]: %s", config.CAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates in kubernetes ca[%s]", config.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	mountPath := config.MountPath
+	if mountPath == "" {
+		mountPath = configFolder()
+	}
+	return &kubernetesExecutor{config: config, client: &http.Client{Transport: transport}, mountPath: mountPath}, nil
+}
+
+func (k *kubernetesExecutor) execute(job *ScheduleJob, syncID string, args []string) (int, error) {
+	// not tied to the scheduler context, like child processes
+	ctx := context.Background()
+	name := kubernetesJobName(job.ID(), syncID)
+	jobs := fmt.Sprintf("/apis/batch/v1/namespaces/%s/jobs", k.config.Namespace)
+	if err := k.request(ctx, http.MethodPost, jobs, k.jobManifest(name, job, syncID, args), nil); err != nil {
+		return -1, fmt.Errorf("failed to create kubernetes job[%s]: %s", name, err)
+	}
+	logger.Infof("Launched run[%s] of job[%s] as kubernetes job[%s/%s]", syncID, job.ID(), k.config.Namespace, name)
+
+	streaming := false
+	for {
+		pod, err := k.jobPod(ctx, name)
+		if err != nil {
+			logger.Warnf("failed to check pod of kubernetes job[%s]: %s", name, err)
+		} else if pod != nil {
+			if !streaming && pod.Status.Phase != "Pending" {
+				streaming = true
+				go k.streamLogs(ctx, job.ID(), pod.Metadata.Name)
+			}
+			if exitCode, terminated := pod.exitCode(); terminated {
+				if exitCode != 0 {
+					return exitCode, fmt.Errorf("kubernetes job[%s] exited with code %d", name, exitCode)
+				}
+				return 0, nil
+			}
+			if reason := pod.stuckReason(); reason != "" {
+				// the pod would wait forever, e.g. for an image that can't be pulled
+				if err := k.request(ctx, http.MethodDelete, jobs+"/"+name+"?propagationPolicy=Background", nil, nil); err != nil {
+					logger.Warnf("failed to delete kubernetes job[%s]: %s", name, err)
+				}
+				return -1, fmt.Errorf("pod of kubernetes job[%s] can't start: %s", name, reason)
+			}
+		}
+		var status kubernetesJob
+		if err := k.request(ctx, http.MethodGet, jobs+"/"+name, nil, &status); err != nil {
+			logger.Warnf("failed to check kubernetes job[%s]: %s", name, err)
+		} else if status.Status.Failed > 0 && pod == nil {
+			// e.g. the pod was evicted or couldn't be scheduled before the deadline
+			return -1, fmt.Errorf("kubernetes job[%s] failed", name)
+		}
+		time.Sleep(kubernetesPollInterval)
+	}
+}
+
+// jobManifest returns the job running the sync in a single pod that is never restarted; the
+// scheduler decides about retries
+func (k *kubernetesExecutor) jobManifest(name string, job *ScheduleJob, syncID string, args []string) map[string]any {
+	labels := map[string]string{
+		"app.kubernetes.io/managed-by": "olake",
+		"olake.io/job":                 kubernetesJobName(job.ID(), ""),
+		"olake.io/sync-id":             strings.ToLower(syncID),
+	}
+	container := map[string]any{
+		"name":  "sync",
+		"image": k.config.Image,
+		"args":  args,
+	}
+	var env []map[string]string
+	if job.workspace != nil {
+		container["workingDir"] = job.workspace.Folder
+		for _, variable := range job.workspace.env() {
+			key, value, _ := strings.Cut(variable, "=")
+			env = append(env, map[string]string{"name": key, "value": value})
+		}
+	}
+	if len(env) > 0 {
+		container["env"] = env
+	}
+	if len(k.config.EnvFromSecrets) > 0 {
+		var envFrom []map[string]any
+		for _, secret := range k.config.EnvFromSecrets {
+			envFrom = append(envFrom, map[string]any{"secretRef": map[string]string{"name": secret}})
+		}
+		container["envFrom"] = envFrom
+	}
+	if len(k.config.Resources) > 0 {
+		container["resources"] = k.config.Resources
+	}
+	podSpec := map[string]any{
+		"restartPolicy": "Never",
+		"containers":    []any{container},
+	}
+	if k.config.ConfigVolumeClaim != "" {
+		container["volumeMounts"] = []any{map[string]string{"name": "config", "mountPath": k.mountPath}}
+		podSpec["volumes"] = []any{map[string]any{
+			"name":                  "config",
+			"persistentVolumeClaim": map[string]string{"claimName": k.config.ConfigVolumeClaim},
+		}}
+	}
+	if k.config.ServiceAccount != "" {
+		podSpec["serviceAccountName"] = k.config.ServiceAccount
+	}
+	if len(k.config.NodeSelector) > 0 {
+		podSpec["nodeSelector"] = k.config.NodeSelector
+	}
+	return map[string]any{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata":   map[string]any{"name": name, "namespace": k.config.Namespace, "labels": labels},
+		"spec": map[string]any{
+			"backoffLimit":            0,
+			"ttlSecondsAfterFinished": k.config.TTLSecondsAfterFinished,
+			"template": map[string]any{
+				"metadata": map[string]any{"labels": labels},
+				"spec":     podSpec,
+			},
+		},
+	}
+}
+
+type kubernetesJob struct {
+	Status struct {
+		Succeeded int `json:"succeeded"`
+		Failed    int `json:"failed"`
+	} `json:"status"`
+}
+
+type kubernetesPod struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Status struct {
+		Phase             string `json:"phase"`
+		ContainerStatuses []struct {
+			State struct {
+				Waiting *struct {
+					Reason  string `json:"reason"`
+					Message string `json:"message"`
+				} `json:"waiting"`
+				Terminated *struct {
+					ExitCode int `json:"exitCode"`
+				} `json:"terminated"`
+			} `json:"state"`
+		} `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+// exitCode returns the exit code of the sync container once it terminated
+func (p *kubernetesPod) exitCode() (int, bool) {
+	for _, container := range p.Status.ContainerStatuses {
+		if terminated := container.State.Terminated; terminated != nil {
+			return terminated.ExitCode, true
+		}
+	}
+	return 0, false
+}
+
+// stuckReason returns why the sync container can't start when retrying won't help
+func (p *kubernetesPod) stuckReason() string {
+	for _, container := range p.Status.ContainerStatuses {
+		if waiting := container.State.Waiting; waiting != nil {
+			switch waiting.Reason {
+			case "ImagePullBackOff", "InvalidImageName", "CreateContainerConfigError":
+				return fmt.Sprintf("%s: %s", waiting.Reason, waiting.Message)
+			}
+		}
+	}
+	return ""
+}
+
+// jobPod returns the pod of the job; nil until it is created
+func (k *kubernetesExecutor) jobPod(ctx context.Context, name string) (*kubernetesPod, error) {
+	var pods struct {
+		Items []*kubernetesPod `json:"items"`
+	}
+	path := fmt.Sprintf("/api/v1/namespaces/%s/pods?labelSelector=%s", k.config.Namespace, url.QueryEscape("job-name="+name))
+	if err := k.request(ctx, http.MethodGet, path, nil, &pods); err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, nil
+	}
+	return pods.Items[0], nil
+}
+
+// streamLogs writes the log lines of the pod to the scheduler log until the pod exits
+func (k *kubernetesExecutor) streamLogs(ctx context.Context, job, pod string) {
+	endpoint := fmt.Sprintf("%s/api/v1/namespaces/%s/pods/%s/log?follow=true", k.config.APIServer, k.config.Namespace, pod)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		logger.Warnf("failed to stream logs of pod[%s]: %s", pod, err)
+		return
+	}
+	if err := k.authenticate(req); err != nil {
+		logger.Warnf("failed to stream logs of pod[%s]: %s", pod, err)
+		return
+	}
+	resp, err := k.client.Do(req)
+	if err != nil {
+		logger.Warnf("failed to stream logs of pod[%s]: %s", pod, err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		logger.Warnf("failed to stream logs of pod[%s]: status %s", pod, resp.Status)
+		return
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		logger.Infof("[%s] %s", job, scanner.Text())
+	}
+}
+
+// request sends body as json to the api path and decodes the response into dest when set
+func (k *kubernetesExecutor) request(ctx context.Context, method, path string, body, dest any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, k.config.APIServer+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if err := k.authenticate(req); err != nil {
+		return err
+	}
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: status %s: %s", method, req.URL.Path, resp.Status, bytes.TrimSpace(data))
+	}
+	if dest == nil {
+		return nil
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// authenticate sets the service account token; read for every request as kubernetes rotates it
+func (k *kubernetesExecutor) authenticate(req *http.Request) error {
+	if k.config.TokenFile == "" {
+		return nil
+	}
+	token, err := os.ReadFile(k.config.TokenFile)
+	if err != nil {
+		return fmt.Errorf("failed to read kubernetes token[%s]: %s", k.config.TokenFile, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	return nil
+}
+
+// kubernetesJobName returns a valid job name of at most 63 characters from the job id and the
+// sync id, which keeps names of runs unique
+func kubernetesJobName(jobID, syncID string) string {
+	name := strings.Trim(nonNameCharacters.ReplaceAllString(strings.ToLower(jobID), "-"), "-")
+	limit := 63 - len("olake-")
+	if syncID != "" {
+		limit -= len(syncID) + 1
+	}
+	if len(name) > limit {
+		name = strings.TrimRight(name[:limit], "-")
+	}
+	parts := []string{"olake", name}
+	if syncID != "" {
+		parts = append(parts, strings.ToLower(syncID))
+	}
+	return strings.Join(parts, "-")
+}
+
+// configFolder is the absolute folder of the schedule config
+func configFolder() string {
+	folder := viper.GetString("CONFIG_FOLDER")
+	if absolute, err := filepath.Abs(folder); err == nil {
+		return absolute
+	}
+	return folder
+}
@@ -0,0 +1,110 @@
+package protocol
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+func TestKubernetesExecutor(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("sa-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var mutex sync.Mutex
+	var manifest map[string]any
+	var logs []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if r.Header.Get("Authorization") != "Bearer sa-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/apis/batch/v1/namespaces/olake/jobs":
+			body, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(body, &manifest); err != nil {
+				t.Error(err)
+			}
+			w.WriteHeader(http.StatusCreated)
+		case r.URL.Path == "/api/v1/namespaces/olake/pods":
+			if r.URL.Query().Get("labelSelector") != "job-name=olake-analytics-hourly-01abc" {
+				t.Errorf("unexpected selector %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"items": [{"metadata": {"name": "pod-1"}, "status": {"phase": "Failed",
+				"containerStatuses": [{"state": {"terminated": {"exitCode": 2}}}]}}]}`))
+		case r.URL.Path == "/api/v1/namespaces/olake/pods/pod-1/log":
+			logs = append(logs, r.URL.Query().Get("follow"))
+			w.Write([]byte("sync finished\n"))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer api.Close()
+
+	config := &KubernetesConfig{
+		Image:             "olakego/source-postgres:latest",
+		Namespace:         "olake",
+		Resources:         map[string]map[string]string{"limits": {"memory": "2Gi"}},
+		NodeSelector:      map[string]string{"pool": "sync"},
+		ConfigVolumeClaim: "olake-config",
+		MountPath:         "/mnt/config",
+		EnvFromSecrets:    []string{"warehouse"},
+		APIServer:         api.URL,
+		TokenFile:         tokenFile,
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	executor, err := newKubernetesExecutor(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := &ScheduleJob{Name: "hourly", workspace: &Workspace{Name: "analytics", Folder: "/mnt/config/analytics", Env: map[string]string{"A": "1"}}}
+	exitCode, err := executor.execute(job, "01ABC", []string{"sync", "--config", "/mnt/config/analytics/config.json"})
+	if err == nil || exitCode != 2 {
+		t.Fatalf("expected exit code 2 of the pod, got %d: %v", exitCode, err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	data, _ := json.Marshal(manifest)
+	for _, expected := range []string{
+		`"name":"olake-analytics-hourly-01abc"`, `"backoffLimit":0`, `"restartPolicy":"Never"`, `"image":"olakego/source-postgres:latest"`,
+		`"args":["sync","--config","/mnt/config/analytics/config.json"]`, `"nodeSelector":{"pool":"sync"}`, `"limits":{"memory":"2Gi"}`,
+		`"claimName":"olake-config"`, `"mountPath":"/mnt/config"`, `"workingDir":"/mnt/config/analytics"`, `"env":[{"name":"A","value":"1"}]`,
+		`"secretRef":{"name":"warehouse"}`, `"ttlSecondsAfterFinished":86400`,
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Fatalf("expected %s in job manifest %s", expected, data)
+		}
+	}
+	// logs stream in the background once the pod left pending
+	for deadline := time.Now().Add(time.Second); len(logs) == 0 && time.Now().Before(deadline); {
+		mutex.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mutex.Lock()
+	}
+	if len(logs) != 1 || logs[0] != "true" {
+		t.Fatalf("expected the pod logs followed, got %v", logs)
+	}
+}
+
+func TestKubernetesJobName(t *testing.T) {
+	if name := kubernetesJobName("Team_A/orders", "01HV"); name != "olake-team-a-orders-01hv" {
+		t.Fatalf("unexpected name %s", name)
+	}
+	if name := kubernetesJobName(strings.Repeat("a", 80), "01HV8Z6Q3W5N4X2Y7R1T0KJ9BC"); len(name) > 63 || !strings.HasSuffix(name, "-01hv8z6q3w5n4x2y7r1t0kj9bc") {
+		t.Fatalf("expected names cut to 63 characters keeping the sync id, got %s", name)
+	}
+}
@@ -0,0 +1,155 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/offload"
+	"github.com/datazip-inc/olake/types"
+)
+
+// largeValueLimiter applies the large value policies of a stream; shared by its threads
+type largeValueLimiter struct {
+	once     sync.Once
+	err      error
+	stream   Stream
+	policies []types.LargeValuePolicy
+	store    offload.Store
+	warned   sync.Map // columns already warned about
+}
+
+// offloader opens the large value store of the destination on first use
+type offloader struct {
+	once   sync.Once
+	config *offload.Config
+	store  offload.Store
+	err    error
+}
+
+func (o *offloader) open() (offload.Store, error) {
+	// pools without a destination, such as samples, show where values would go
+	if o == nil {
+		return previewStore{}, nil
+	}
+	o.once.Do(func() {
+		config := o.config
+		if config == nil {
+			config = &offload.Config{}
+		}
+		o.store, o.err = offload.New(config)
+	})
+	return o.store, o.err
+}
+
+// largeValueLimiter returns the shared limiter of stream; nil when it has no large value
+// policies. Offloaded columns of the stream schema are typed as strings and skipped columns
+// made nullable on first use.
+func (w *WriterPool) largeValueLimiter(stream Stream) (*largeValueLimiter, error) {
+	policies := stream.Self().StreamMetadata.LargeValues
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	value, _ := w.largeValues.LoadOrStore(stream.ID(), &largeValueLimiter{stream: stream, policies: policies})
+	limiter := value.(*largeValueLimiter)
+	limiter.once.Do(func() {
+		properties := make(map[string]*types.Property)
+		for _, policy := range policies {
+			found, property := stream.Schema().GetProperty(policy.Column)
+			if !found {
+				continue
+			}
+			switch policy.Action {
+			case types.LargeValueOffload:
+				if limiter.store == nil {
+					if limiter.store, limiter.err = w.offloader.open(); limiter.err != nil {
+						return
+					}
+				}
+				offloaded := types.NewSet(types.String)
+				if property.Nullable() {
+					offloaded.Insert(types.Null)
+				}
+				properties[policy.Column] = &types.Property{Type: offloaded}
+			case types.LargeValueSkip:
+				if !property.Nullable() {
+					properties[policy.Column] = &types.Property{Type: types.NewSet(append(property.Type.Array(), types.Null)...)}
+				}
+			}
+		}
+		w.tmu.Lock()
+		stream.Schema().Override(properties)
+		w.tmu.Unlock()
+	})
+	if limiter.err != nil {
+		return nil, limiter.err
+	}
+	return limiter, nil
+}
+
+// Apply replaces the values of data above their column's max_bytes as its policy says
+func (l *largeValueLimiter) Apply(ctx context.Context, data map[string]any) error {
+	for idx := range l.policies {
+		policy := &l.policies[idx]
+		var size int
+		switch value := data[policy.Column].(type) {
+		case string:
+			size = len(value)
+		case []byte:
+			size = len(value)
+		default:
+			continue
+		}
+		if size <= policy.MaxBytes {
+			continue
+		}
+		limited, err := l.limit(ctx, policy, data[policy.Column])
+		if err != nil {
+			return err
+		}
+		data[policy.Column] = limited
+		if _, warned := l.warned.LoadOrStore(policy.Column, struct{}{}); !warned {
+			logger.Warnf("Values of column[%s] of stream[%s] larger than %d bytes are handled with policy %s", policy.Column, l.stream.ID(), policy.MaxBytes, policy.Action)
+		}
+	}
+	return nil
+}
+
+func (l *largeValueLimiter) limit(ctx context.Context, policy *types.LargeValuePolicy, value any) (any, error) {
+	switch policy.Action {
+	case types.LargeValueSkip:
+		return nil, nil
+	case types.LargeValueTruncate:
+		// copied so the large value isn't kept alive by the part written
+		if binary, ok := value.([]byte); ok {
+			return bytes.Clone(binary[:policy.MaxBytes]), nil
+		}
+		text := value.(string)
+		cut := policy.MaxBytes - len(policy.Marker)
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		return strings.Clone(text[:cut]) + policy.Marker, nil
+	default:
+		binary, ok := value.([]byte)
+		if !ok {
+			binary = []byte(value.(string))
+		}
+		url, err := l.store.Put(ctx, l.stream.Namespace(), l.stream.Name(), policy.Column, binary)
+		if err != nil {
+			return nil, fmt.Errorf("failed to offload column[%s]: %s", policy.Column, err)
+		}
+		return url, nil
+	}
+}
+
+// previewStore returns the url a value would be offloaded to without uploading it
+type previewStore struct{}
+
+func (previewStore) Put(_ context.Context, namespace, stream, column string, value []byte) (string, error) {
+	return "offload://" + offload.Key(namespace, stream, column, value), nil
+}
@@ -0,0 +1,58 @@
+package protocol
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/datazip-inc/olake/pkg/offload"
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestLargeValueLimiter(t *testing.T) {
+	stream := &types.ConfiguredStream{Stream: types.NewStream("users", "public")}
+	stream.Stream.UpsertField("bio", types.String, false)
+	stream.Stream.UpsertField("avatar", types.String, false)
+	stream.Stream.UpsertField("notes", types.String, false)
+	stream.StreamMetadata.LargeValues = []types.LargeValuePolicy{
+		{Column: "bio", MaxBytes: 10, Action: types.LargeValueTruncate, Marker: "…"},
+		{Column: "avatar", MaxBytes: 4, Action: types.LargeValueOffload},
+		{Column: "notes", MaxBytes: 4, Action: types.LargeValueSkip},
+	}
+	for idx := range stream.StreamMetadata.LargeValues {
+		if err := stream.StreamMetadata.LargeValues[idx].Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pool := &WriterPool{offloader: &offloader{config: &offload.Config{Path: t.TempDir()}}}
+	limiter, err := pool.largeValueLimiter(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, property := stream.Schema().GetProperty("notes"); !property.Nullable() {
+		t.Fatal("expected skipped column to be nullable")
+	}
+
+	data := map[string]any{"bio": "éééééé", "avatar": []byte("binary"), "notes": "long notes", "id": 1}
+	if err := limiter.Apply(context.Background(), data); err != nil {
+		t.Fatal(err)
+	}
+	// the cut backs off to the start of a character
+	if data["bio"] != "ééé…" {
+		t.Fatalf("unexpected truncated value %q", data["bio"])
+	}
+	if url, _ := data["avatar"].(string); !strings.HasPrefix(url, "file://") || !strings.Contains(url, "public/users/avatar/") {
+		t.Fatalf("expected a url of the offloaded value, got %v", data["avatar"])
+	}
+	if data["notes"] != nil || data["id"] != 1 {
+		t.Fatalf("unexpected values %v", data)
+	}
+
+	small := map[string]any{"bio": "short", "avatar": []byte("ok"), "notes": "ok"}
+	if err := limiter.Apply(context.Background(), small); err != nil {
+		t.Fatal(err)
+	}
+	if small["bio"] != "short" || string(small["avatar"].([]byte)) != "ok" || small["notes"] != "ok" {
+		t.Fatalf("expected small values to be kept, got %v", small)
+	}
+}
@@ -0,0 +1,84 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/datazip-inc/olake/logger"
+)
+
+// lifecycle calls the sync and stream hooks of a writer on a dedicated writer instance, so
+// hooks never run on a thread writing records
+type lifecycle struct {
+	writer  Writer
+	mutex   sync.Mutex // hooks of a writer never run concurrently
+	begun   []Stream   // streams in order of BeginStream
+	streams sync.Map   // stream id to *streamBegin
+	failed  sync.Map   // ids of streams that failed while the sync continued
+}
+
+type streamBegin struct {
+	once sync.Once
+	err  error
+}
+
+// BeginSync must be called once before any stream is read
+func (w *WriterPool) BeginSync(ctx context.Context) error {
+	w.lifecycle.mutex.Lock()
+	defer w.lifecycle.mutex.Unlock()
+	if err := w.lifecycle.writer.BeginSync(ctx); err != nil {
+		return fmt.Errorf("failed to begin sync in destination: %s", err)
+	}
+	return nil
+}
+
+// beginStream calls BeginStream once per stream, before its first writer thread is set up
+func (w *WriterPool) beginStream(ctx context.Context, stream Stream) error {
+	value, _ := w.lifecycle.streams.LoadOrStore(stream.ID(), &streamBegin{})
+	begin := value.(*streamBegin)
+	begin.once.Do(func() {
+		w.lifecycle.mutex.Lock()
+		defer w.lifecycle.mutex.Unlock()
+		if err := w.lifecycle.writer.BeginStream(ctx, stream); err != nil {
+			begin.err = fmt.Errorf("failed to begin stream[%s] in destination: %s", stream.ID(), err)
+			return
+		}
+		w.lifecycle.begun = append(w.lifecycle.begun, stream)
+	})
+	return begin.err
+}
+
+// MarkFailed excludes a stream that failed from finalization while the remaining streams of
+// the sync continue
+func (w *WriterPool) MarkFailed(stream Stream) {
+	w.lifecycle.failed.Store(stream.ID(), struct{}{})
+}
+
+// EndSync finalizes the sync in the destination once all writer threads are done. On success
+// EndStream is called for every written stream not marked failed before EndSync; on failure
+// only EndSync is called so the writer can clean up, and syncErr is returned.
+func (w *WriterPool) EndSync(ctx context.Context, syncErr error) error {
+	w.lifecycle.mutex.Lock()
+	defer w.lifecycle.mutex.Unlock()
+
+	if syncErr == nil {
+		for _, stream := range w.lifecycle.begun {
+			if _, failed := w.lifecycle.failed.Load(stream.ID()); failed {
+				continue
+			}
+			if err := w.lifecycle.writer.EndStream(ctx, stream); err != nil {
+				syncErr = fmt.Errorf("failed to end stream[%s] in destination: %s", stream.ID(), err)
+				break
+			}
+		}
+	}
+	if err := w.lifecycle.writer.EndSync(ctx, syncErr); err != nil {
+		if syncErr != nil {
+			logger.Errorf("failed to end failed sync in destination: %s", err)
+			return syncErr
+		}
+		return fmt.Errorf("failed to end sync in destination: %s", err)
+	}
+	return syncErr
+}
@@ -0,0 +1,73 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+// hookRecorder records lifecycle hook calls; other writer methods are not used
+type hookRecorder struct {
+	Writer
+	calls []string
+}
+
+func (h *hookRecorder) BeginSync(_ context.Context) error {
+	h.calls = append(h.calls, "BeginSync")
+	return nil
+}
+
+func (h *hookRecorder) BeginStream(_ context.Context, stream Stream) error {
+	h.calls = append(h.calls, "BeginStream "+stream.ID())
+	return nil
+}
+
+func (h *hookRecorder) EndStream(_ context.Context, stream Stream) error {
+	h.calls = append(h.calls, "EndStream "+stream.ID())
+	return nil
+}
+
+func (h *hookRecorder) EndSync(_ context.Context, syncErr error) error {
+	call := "EndSync"
+	if syncErr != nil {
+		call += " " + syncErr.Error()
+	}
+	h.calls = append(h.calls, call)
+	return nil
+}
+
+func TestLifecycle(t *testing.T) {
+	ctx := context.Background()
+	users := &types.ConfiguredStream{Stream: types.NewStream("users", "public")}
+	orders := &types.ConfiguredStream{Stream: types.NewStream("orders", "public")}
+
+	run := func(syncErr error) []string {
+		recorder := &hookRecorder{}
+		pool := &WriterPool{lifecycle: &lifecycle{writer: recorder}}
+		if err := pool.BeginSync(ctx); err != nil {
+			t.Fatal(err)
+		}
+		for _, stream := range []Stream{users, orders, users} {
+			if err := pool.beginStream(ctx, stream); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := pool.EndSync(ctx, syncErr); !errors.Is(err, syncErr) {
+			t.Fatalf("expected sync error %v, got %v", syncErr, err)
+		}
+		return recorder.calls
+	}
+
+	expected := []string{"BeginSync", "BeginStream public.users", "BeginStream public.orders", "EndStream public.users", "EndStream public.orders", "EndSync"}
+	if calls := run(nil); !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("successful sync: expected %v, got %v", expected, calls)
+	}
+
+	expected = []string{"BeginSync", "BeginStream public.users", "BeginStream public.orders", "EndSync failed"}
+	if calls := run(errors.New("failed")); !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("failed sync: expected %v, got %v", expected, calls)
+	}
+}
@@ -0,0 +1,119 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/goccy/go-json"
+	"github.com/spf13/viper"
+)
+
+const syncLockFile = "sync.lock"
+
+// take over the lock of a sync that died without releasing it
+var forceLock bool
+
+// LockHolder is written to the lock file; identifies the sync holding the lock
+type LockHolder struct {
+	SyncID    string    `json:"sync_id"`
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// syncLock keeps two syncs from writing the state of the same connection at once
+type syncLock struct {
+	path   string
+	holder LockHolder
+}
+
+// acquireSyncLock locks the folder state of the connection is written to; the lock file
+// outlives a crashed process, so a stale lock is only taken over with --force
+func acquireSyncLock() (*syncLock, error) {
+	folder := viper.GetString("CONFIG_FOLDER")
+	if folder == "" {
+		folder = filepath.Dir(configPath)
+	}
+	host, _ := os.Hostname()
+	lock := &syncLock{
+		path: filepath.Join(folder, syncLockFile),
+		holder: LockHolder{
+			SyncID:    viper.GetString("SYNC_ID"),
+			PID:       os.Getpid(),
+			Host:      host,
+			StartedAt: time.Now().UTC(),
+		},
+	}
+
+	err := lock.create()
+	if errors.Is(err, fs.ErrExist) {
+		holder, readErr := readLockHolder(lock.path)
+		switch {
+		case !forceLock && readErr != nil:
+			return nil, readErr
+		case !forceLock:
+			return nil, fmt.Errorf("sync[%s] with pid %d on %s holds lock[%s] since %s; pass --force if it is no longer running",
+				holder.SyncID, holder.PID, holder.Host, lock.path, holder.StartedAt.Format(time.RFC3339))
+		case readErr != nil:
+			logger.Warnf("Taking over unreadable lock: %s", readErr)
+		default:
+			logger.Warnf("Taking over lock[%s] of sync[%s] with pid %d on %s", lock.path, holder.SyncID, holder.PID, holder.Host)
+		}
+		if err := os.Remove(lock.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove stale lock[%s]: %s", lock.path, err)
+		}
+		// created exclusively again so only one of several forced syncs wins
+		err = lock.create()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock[%s]: %s", lock.path, err)
+	}
+	return lock, nil
+}
+
+func (l *syncLock) create() error {
+	data, err := json.Marshal(l.holder)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(data)
+	return err
+}
+
+// release removes the lock unless another sync took it over
+func (l *syncLock) release() {
+	holder, err := readLockHolder(l.path)
+	if err != nil {
+		logger.Warnf("failed to release lock: %s", err)
+		return
+	}
+	if holder.SyncID != l.holder.SyncID || holder.PID != l.holder.PID {
+		logger.Warnf("lock[%s] was taken over by sync[%s]", l.path, holder.SyncID)
+		return
+	}
+	if err := os.Remove(l.path); err != nil {
+		logger.Warnf("failed to release lock[%s]: %s", l.path, err)
+	}
+}
+
+func readLockHolder(path string) (*LockHolder, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock[%s]: %s", path, err)
+	}
+	holder := &LockHolder{}
+	if err := json.Unmarshal(data, holder); err != nil {
+		return nil, fmt.Errorf("failed to parse lock[%s]: %s", path, err)
+	}
+	return holder, nil
+}
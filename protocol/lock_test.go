@@ -0,0 +1,43 @@
+package protocol
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestSyncLock(t *testing.T) {
+	folder := t.TempDir()
+	viper.Set("CONFIG_FOLDER", folder)
+	defer func() { forceLock = false }()
+
+	viper.Set("SYNC_ID", "first")
+	first, err := acquireSyncLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Set("SYNC_ID", "second")
+	if _, err := acquireSyncLock(); err == nil {
+		t.Fatal("expected second sync to be refused while the lock is held")
+	}
+
+	forceLock = true
+	second, err := acquireSyncLock()
+	if err != nil {
+		t.Fatalf("expected --force to take over the lock: %s", err)
+	}
+	// the first sync must not remove the lock it lost
+	first.release()
+	holder, err := readLockHolder(filepath.Join(folder, syncLockFile))
+	if err != nil || holder.SyncID != "second" {
+		t.Fatalf("expected lock to stay with the second sync, got %+v, %v", holder, err)
+	}
+
+	second.release()
+	if _, err := os.Stat(filepath.Join(folder, syncLockFile)); !os.IsNotExist(err) {
+		t.Fatalf("expected lock to be released, got %v", err)
+	}
+}
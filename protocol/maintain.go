@@ -0,0 +1,172 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	maintainSucceeded = "succeeded"
+	maintainSkipped   = "skipped"
+	maintainFailed    = "failed"
+)
+
+var (
+	maintainTargetFileSize int64
+	maintainRetention      time.Duration
+	maintainDryRun         bool
+)
+
+// MaintainOptions bound what maintain changes in the destination
+type MaintainOptions struct {
+	// files smaller than this are compacted together into files of about this size
+	TargetFileSize int64
+	// files left behind by failed syncs or compactions are removed once older than this, so
+	// files a running writer hasn't finished are kept
+	Retention time.Duration
+	// reports what would be done without changing the destination
+	DryRun bool
+}
+
+// MaintainReport is logged and written to maintain_<sync id>.json by the maintain command
+type MaintainReport struct {
+	SyncID  string              `json:"sync_id"`
+	Status  string              `json:"status"`
+	DryRun  bool                `json:"dry_run,omitempty"`
+	Streams []StreamMaintenance `json:"streams"`
+}
+
+// StreamMaintenance is what maintain did to the files of a stream
+type StreamMaintenance struct {
+	Stream string `json:"stream"`
+	Status string `json:"status"`
+	// small files merged, and the files they were merged into
+	CompactedFiles int `json:"compacted_files"`
+	WrittenFiles   int `json:"written_files"`
+	// incomplete files and inputs of interrupted compactions removed
+	RemovedFiles int    `json:"removed_files"`
+	Message      string `json:"message,omitempty"`
+}
+
+// maintainCmd compacts small files and removes files left behind by failed syncs in the
+// destination of the selected streams, so reading them doesn't slow down as syncs add files
+var maintainCmd = &cobra.Command{
+	Use:   "maintain",
+	Short: "Olake maintain command; compacts small files and removes files left behind by failed syncs in the destination of the selected streams, or of --stream",
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		if !configured("destination", destinationConfigPath) {
+			return fmt.Errorf("--destination not passed")
+		} else if catalogPath == "" {
+			return fmt.Errorf("--catalog not passed")
+		}
+		if maintainTargetFileSize <= 0 {
+			return fmt.Errorf("--target-file-size must be positive")
+		}
+		if maintainRetention < 0 {
+			return fmt.Errorf("--retention must not be negative")
+		}
+		// the lock syncs of the connection hold is next to its catalog
+		if configPath == "" && !noSave {
+			viper.Set("CONFIG_FOLDER", filepath.Dir(catalogPath))
+		}
+		destinationConfig = &types.WriterConfig{}
+		if err := utils.UnmarshalFileWithEnv(destinationConfigPath, "destination", destinationConfig); err != nil {
+			return err
+		}
+		catalog = &types.Catalog{}
+		return types.CatalogFormat.LoadFile(catalogPath, catalog)
+	},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		// files are rewritten; a sync writing them at once would lose records
+		lock, err := acquireSyncLock()
+		if err != nil {
+			return err
+		}
+		defer lock.release()
+
+		pool, err := NewWriter(cmd.Context(), destinationConfig)
+		if err != nil {
+			return err
+		}
+		options := MaintainOptions{TargetFileSize: maintainTargetFileSize, Retention: maintainRetention, DryRun: maintainDryRun}
+		report := MaintainReport{SyncID: viper.GetString("SYNC_ID"), Status: maintainSucceeded, DryRun: maintainDryRun}
+		for _, stream := range maintainStreams(catalog) {
+			result := maintainStream(cmd.Context(), pool, stream, options)
+			if result.Status == maintainFailed {
+				report.Status = maintainFailed
+			}
+			report.Streams = append(report.Streams, result)
+		}
+
+		logger.Info(report)
+		if err := logger.FileLogger(report, logger.RunFileName("maintain"), ".json"); err != nil {
+			logger.Warnf("failed to write maintain report: %s", err)
+		}
+		if report.Status == maintainFailed {
+			return fmt.Errorf("maintenance of some streams failed")
+		}
+		return nil
+	},
+}
+
+// maintainStreams returns --stream, or the selected streams of catalog
+func maintainStreams(catalog *types.Catalog) []*types.ConfiguredStream {
+	selected := make(map[string]bool)
+	for namespace, streamsMetadata := range catalog.SelectedStreams {
+		for _, streamMetadata := range streamsMetadata {
+			selected[utils.StreamIdentifier(streamMetadata.StreamName, namespace)] = true
+		}
+	}
+	var streams []*types.ConfiguredStream
+	for _, stream := range catalogStreams(catalog) {
+		if sampleStream != "" && stream.ID() != sampleStream && stream.Name() != sampleStream {
+			continue
+		}
+		if sampleStream == "" && catalog.SelectedStreams != nil && !selected[stream.ID()] {
+			continue
+		}
+		streams = append(streams, stream)
+	}
+	return streams
+}
+
+func maintainStream(ctx context.Context, pool *WriterPool, stream Stream, options MaintainOptions) StreamMaintenance {
+	result := StreamMaintenance{Stream: stream.ID(), Status: maintainSkipped}
+	if _, ok := pool.init().(Maintainer); !ok {
+		result.Message = fmt.Sprintf("destination %s has nothing to maintain", destinationConfig.Type)
+		return result
+	}
+	writer, err := pool.destinationWriter(stream)
+	if err != nil {
+		result.Status, result.Message = maintainFailed, fmt.Sprintf("failed to set up destination writer: %s", err)
+		return result
+	}
+	defer writer.Close()
+
+	result.Status = maintainSucceeded
+	err = writer.(Maintainer).Maintain(ctx, stream, options, &result)
+	switch {
+	case errors.Is(err, ErrMaintainUnsupported):
+		result.Status, result.Message = maintainSkipped, err.Error()
+	case err != nil:
+		result.Status, result.Message = maintainFailed, err.Error()
+		logger.Errorf("failed to maintain stream[%s]: %s", stream.ID(), err)
+	}
+	return result
+}
+
+func init() {
+	commands = append(commands, maintainCmd)
+	RootCmd.PersistentFlags().Int64VarP(&maintainTargetFileSize, "target-file-size", "", 128<<20, "(Optional) Size in bytes maintain compacts smaller files of a stream into")
+	RootCmd.PersistentFlags().DurationVarP(&maintainRetention, "retention", "", 24*time.Hour, "(Optional) Age after which maintain removes files left behind by failed syncs")
+	RootCmd.PersistentFlags().BoolVarP(&maintainDryRun, "dry-run", "", false, "(Optional) Report what maintain would compact and remove without changing the destination")
+}
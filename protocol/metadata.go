@@ -0,0 +1,43 @@
+package protocol
+
+import (
+	"time"
+
+	"github.com/datazip-inc/olake/constants"
+	"github.com/datazip-inc/olake/types"
+)
+
+// WithChangeStream marks threads writing change stream events so their records are tagged
+// as upserts instead of reads
+func WithChangeStream() ThreadOptions {
+	return func(opt *Options) {
+		opt.changeStream = true
+	}
+}
+
+// addMetadataColumns sets the configured metadata columns on the record data; the raw id is
+// hashed before any metadata is added so it only depends on the source row
+func addMetadataColumns(config *types.MetadataColumnsConfig, record *types.RawRecord, opts *Options) {
+	if config == nil {
+		return
+	}
+	if config.RawID {
+		record.Data[constants.MetaRawID] = rowHash(record.Data)
+	}
+	if config.SyncedAt {
+		record.Data[constants.MetaSyncedAt] = time.UnixMilli(record.OlakeTimestamp).UTC()
+	}
+	if config.SyncID {
+		record.Data[constants.MetaSyncID] = opts.SyncID
+	}
+	if config.Op {
+		op := types.OpRead
+		switch {
+		case record.DeleteTime != 0:
+			op = types.OpDelete
+		case opts.changeStream:
+			op = types.OpUpsert
+		}
+		record.Data[constants.MetaOp] = string(op)
+	}
+}
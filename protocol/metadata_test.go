@@ -0,0 +1,47 @@
+package protocol
+
+import (
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/constants"
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestAddMetadataColumns(t *testing.T) {
+	config := &types.MetadataColumnsConfig{SyncedAt: true, SyncID: true, RawID: true, Op: true}
+	now := time.Now().UTC()
+	newRecord := func(deleteTime int64) types.RawRecord {
+		record := types.CreateRawRecord("1", map[string]any{"id": 1, "name": "a"}, deleteTime)
+		record.OlakeTimestamp = now.UnixMilli()
+		return record
+	}
+
+	read := newRecord(0)
+	addMetadataColumns(config, &read, &Options{SyncID: "run"})
+	if read.Data[constants.MetaOp] != string(types.OpRead) || read.Data[constants.MetaSyncID] != "run" {
+		t.Fatalf("unexpected metadata: %v", read.Data)
+	}
+	if syncedAt := read.Data[constants.MetaSyncedAt].(time.Time); syncedAt.UnixMilli() != now.UnixMilli() {
+		t.Fatalf("expected synced at %s, got %s", now, syncedAt)
+	}
+
+	// the raw id only depends on the source row
+	upsert := newRecord(0)
+	addMetadataColumns(config, &upsert, &Options{SyncID: "next", changeStream: true})
+	if upsert.Data[constants.MetaOp] != string(types.OpUpsert) {
+		t.Fatalf("expected upsert, got %v", upsert.Data[constants.MetaOp])
+	}
+	if upsert.Data[constants.MetaRawID] != read.Data[constants.MetaRawID] {
+		t.Fatalf("raw id differs between identical rows: %v and %v", upsert.Data[constants.MetaRawID], read.Data[constants.MetaRawID])
+	}
+
+	deleted := newRecord(now.UnixMilli())
+	addMetadataColumns(&types.MetadataColumnsConfig{Op: true}, &deleted, &Options{changeStream: true})
+	if deleted.Data[constants.MetaOp] != string(types.OpDelete) {
+		t.Fatalf("expected delete, got %v", deleted.Data[constants.MetaOp])
+	}
+	if _, found := deleted.Data[constants.MetaSyncID]; found {
+		t.Fatal("unconfigured column was added")
+	}
+}
@@ -0,0 +1,40 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/ddl"
+	"github.com/datazip-inc/olake/types"
+)
+
+// overrideSchema updates the schema of stream with the evolved columns of a thread and, for
+// writers of SQL destinations, alters the table of the stream as the schema_migration policy
+// allows. Every statement run is logged, and changes left out are warned about. Tables are
+// altered under tmu, so threads of the stream evolving the same columns wait for the table to
+// hold them instead of writing to it first
+func (w *WriterPool) overrideSchema(ctx context.Context, thread Writer, stream Stream, properties map[string]*types.Property) error {
+	w.tmu.Lock()
+	defer w.tmu.Unlock()
+	executor, migrates := thread.(DDLExecutor)
+	if !migrates || w.migration == "" || w.migration == types.MigrateNone {
+		stream.Schema().Override(properties)
+		return nil
+	}
+
+	before := ddl.FromStream(stream.Self(), w.naming, w.metadata)
+	stream.Schema().Override(properties)
+	after := ddl.FromStream(stream.Self(), w.naming, w.metadata)
+	statements, skipped := executor.Dialect().Migration(after, ddl.Diff(before, after), w.migration)
+	for _, change := range skipped {
+		logger.Warnf("Skipping migration of table[%s] of stream[%s] under schema_migration[%s]: %s", after.Name, stream.ID(), w.migration, change)
+	}
+	for _, statement := range statements {
+		if err := executor.ExecDDL(ctx, statement); err != nil {
+			return fmt.Errorf("failed to migrate table[%s] of stream[%s] with [%s]: %s", after.Name, stream.ID(), statement, err)
+		}
+		logger.Infof("Migrated table[%s] of stream[%s]: %s", after.Name, stream.ID(), statement)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package protocol
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/datazip-inc/olake/pkg/ddl"
+	"github.com/datazip-inc/olake/types"
+)
+
+// ddlRecorder records the statements it's asked to run; other writer methods are not used
+type ddlRecorder struct {
+	Writer
+	statements []string
+}
+
+func (d *ddlRecorder) Dialect() ddl.Dialect {
+	return ddl.Postgres
+}
+
+func (d *ddlRecorder) ExecDDL(_ context.Context, statement string) error {
+	d.statements = append(d.statements, statement)
+	return nil
+}
+
+func TestOverrideSchema(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		policy     types.MigrationPolicy
+		statements []string
+	}{
+		{types.MigrateNone, nil},
+		{types.MigrateAddColumns, []string{`ALTER TABLE "public"."users" ADD COLUMN IF NOT EXISTS "email" TEXT;`}},
+		{types.MigrateWiden, []string{
+			`ALTER TABLE "public"."users" ALTER COLUMN "age" TYPE TEXT USING "age"::TEXT;`,
+			`ALTER TABLE "public"."users" ADD COLUMN IF NOT EXISTS "email" TEXT;`,
+		}},
+	} {
+		stream := types.NewStream("users", "public").WithPrimaryKey("id")
+		stream.UpsertField("id", types.Int64, false)
+		stream.UpsertField("age", types.Int64, true)
+		configured := &types.ConfiguredStream{Stream: stream}
+
+		recorder := &ddlRecorder{}
+		pool := &WriterPool{migration: test.policy}
+		evolved := map[string]*types.Property{
+			"email": {Type: types.NewSet(types.String, types.Null)},
+			"age":   {Type: types.NewSet(types.Int64, types.String, types.Null)},
+		}
+		if err := pool.overrideSchema(ctx, recorder, configured, evolved); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(recorder.statements, test.statements) {
+			t.Errorf("expected %v under %s, got %v", test.statements, test.policy, recorder.statements)
+		}
+		if found, _ := configured.Schema().GetProperty("email"); !found {
+			t.Errorf("expected the schema to be evolved under %s", test.policy)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/notify"
+	"github.com/datazip-inc/olake/types"
+	"github.com/spf13/viper"
+)
+
+// syncNotifier notifies the configured webhooks of the start and end of a sync
+type syncNotifier struct {
+	*notify.Notifier
+	startedAt time.Time
+	// set once created by the sync, the end notification carries their records and summary
+	pool     *WriterPool
+	failures *streamFailures
+}
+
+// startNotifications posts the start of the sync; nil when the destination has no notifications
+func startNotifications(ctx context.Context, config *types.WriterConfig) (*syncNotifier, error) {
+	if config.Notifications == nil {
+		return nil, nil
+	}
+	notifier, err := notify.New(config.Notifications)
+	if err != nil {
+		return nil, err
+	}
+	n := &syncNotifier{Notifier: notifier, startedAt: time.Now().UTC()}
+	n.Notify(ctx, n.notification(notify.EventStart))
+	return n, nil
+}
+
+func (n *syncNotifier) statsEmitters() []logger.StatsEmitter {
+	if n == nil {
+		return nil
+	}
+	return n.StatsEmitters()
+}
+
+// finish posts the outcome of the sync, err being the error the sync returns
+func (n *syncNotifier) finish(ctx context.Context, err error) {
+	if n == nil {
+		return
+	}
+	defer n.Close()
+	event := notify.EventSuccess
+	switch {
+	case errors.Is(err, ErrPartialSync):
+		event = notify.EventPartial
+	case err != nil:
+		event = notify.EventFailure
+	}
+	notification := n.notification(event)
+	finishedAt := time.Now().UTC()
+	notification.FinishedAt = &finishedAt
+	if err != nil {
+		notification.Error = err.Error()
+	}
+	if n.pool != nil {
+		notification.Records = n.pool.SyncedRecords()
+	}
+	if n.failures != nil && n.failures.summary != nil {
+		notification.Summary = n.failures.summary
+	}
+	// the sync context may already be cancelled, the outcome is still worth delivering
+	n.Notify(context.WithoutCancel(ctx), notification)
+}
+
+func (n *syncNotifier) notification(event notify.Event) *notify.Notification {
+	return &notify.Notification{
+		Event:       event,
+		SyncID:      viper.GetString("SYNC_ID"),
+		Driver:      connector.Type(),
+		Destination: string(destinationConfig.Type),
+		StartedAt:   n.startedAt,
+	}
+}
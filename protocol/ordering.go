@@ -0,0 +1,114 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/datazip-inc/olake/logger"
+)
+
+// streamSchedule is the start order of standard mode streams; a stream waits for its
+// dependencies to finish reading before it starts
+type streamSchedule struct {
+	order        []Stream
+	dependencies map[string][]string
+	done         map[string]*completion
+}
+
+// completion is closed once a stream finished reading; err is set before closing
+type completion struct {
+	closed chan struct{}
+	err    error
+}
+
+// scheduleStreams orders streams by priority while keeping every stream after its
+// dependencies. Streams start in this order, so a stream only ever waits on streams that
+// already hold a slot of the concurrency group.
+func scheduleStreams(streams []Stream) (*streamSchedule, error) {
+	schedule := &streamSchedule{
+		dependencies: make(map[string][]string),
+		done:         make(map[string]*completion),
+	}
+	for _, stream := range streams {
+		schedule.done[stream.ID()] = &completion{closed: make(chan struct{})}
+	}
+	dependents := make(map[string][]string)
+	pending := make(map[string]int)
+	for _, stream := range streams {
+		for _, dependency := range stream.Self().StreamMetadata.DependsOn {
+			if _, found := schedule.done[dependency]; !found {
+				// unselected and cdc streams aren't read by the scheduler
+				logger.Warnf("Ignoring dependency of stream[%s] on stream[%s]; not a selected full refresh or incremental stream", stream.ID(), dependency)
+				continue
+			}
+			schedule.dependencies[stream.ID()] = append(schedule.dependencies[stream.ID()], dependency)
+			dependents[dependency] = append(dependents[dependency], stream.ID())
+			pending[stream.ID()]++
+		}
+	}
+
+	// kahn's algorithm picking the highest priority among the ready streams; ties keep the
+	// catalog order
+	position := make(map[string]int, len(streams))
+	for idx, stream := range streams {
+		position[stream.ID()] = idx
+	}
+	ready := []Stream{}
+	for _, stream := range streams {
+		if pending[stream.ID()] == 0 {
+			ready = append(ready, stream)
+		}
+	}
+	for len(ready) > 0 {
+		sort.SliceStable(ready, func(i, j int) bool {
+			pi, pj := ready[i].Self().StreamMetadata.Priority, ready[j].Self().StreamMetadata.Priority
+			if pi != pj {
+				return pi > pj
+			}
+			return position[ready[i].ID()] < position[ready[j].ID()]
+		})
+		next := ready[0]
+		ready = ready[1:]
+		schedule.order = append(schedule.order, next)
+		for _, dependent := range dependents[next.ID()] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, streams[position[dependent]])
+			}
+		}
+	}
+
+	if len(schedule.order) != len(streams) {
+		cyclic := []string{}
+		for _, stream := range streams {
+			if pending[stream.ID()] > 0 {
+				cyclic = append(cyclic, stream.ID())
+			}
+		}
+		return nil, fmt.Errorf("stream dependencies form a cycle between streams %v", cyclic)
+	}
+	return schedule, nil
+}
+
+// wait blocks until every dependency of stream finished reading; fails when one of them failed
+func (s *streamSchedule) wait(ctx context.Context, stream Stream) error {
+	for _, dependency := range s.dependencies[stream.ID()] {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("stream[%s] not started; dependency[%s] did not finish: %s", stream.ID(), dependency, ctx.Err())
+		case <-s.done[dependency].closed:
+			if s.done[dependency].err != nil {
+				return fmt.Errorf("stream[%s] not started; dependency[%s] failed", stream.ID(), dependency)
+			}
+		}
+	}
+	return nil
+}
+
+// finish releases the streams depending on stream; err fails them
+func (s *streamSchedule) finish(stream Stream, err error) {
+	done := s.done[stream.ID()]
+	done.err = err
+	close(done.closed)
+}
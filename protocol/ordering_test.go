@@ -0,0 +1,68 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestScheduleStreams(t *testing.T) {
+	newStream := func(name string, priority int, dependsOn ...string) Stream {
+		return &types.ConfiguredStream{
+			Stream:         types.NewStream(name, "public"),
+			StreamMetadata: types.StreamMetadata{StreamName: name, Priority: priority, DependsOn: dependsOn},
+		}
+	}
+
+	orders := newStream("orders", 10, "public.customers", "public.products")
+	streams := []Stream{
+		newStream("events", 0),
+		orders,
+		newStream("customers", 0),
+		newStream("products", 5, "public.missing"),
+	}
+	schedule, err := scheduleStreams(streams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := []string{}
+	for _, stream := range schedule.order {
+		order = append(order, stream.Name())
+	}
+	expected := []string{"products", "events", "customers", "orders"}
+	for idx := range expected {
+		if order[idx] != expected[idx] {
+			t.Fatalf("expected order %v, got %v", expected, order)
+		}
+	}
+
+	// orders waits until both dependencies finished
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	schedule.finish(schedule.order[0], nil)
+	if err := schedule.wait(ctx, orders); err == nil {
+		t.Fatal("expected wait to fail while customers is running")
+	}
+	schedule.finish(schedule.order[2], nil)
+	if err := schedule.wait(context.Background(), orders); err != nil {
+		t.Fatal(err)
+	}
+
+	// dependents of a failed stream fail
+	failed, err := scheduleStreams([]Stream{newStream("customers", 0), newStream("orders", 0, "public.customers")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed.finish(failed.order[0], errors.New("read failed"))
+	if err := failed.wait(context.Background(), failed.order[1]); err == nil {
+		t.Fatal("expected wait to fail after the dependency failed")
+	}
+
+	_, err = scheduleStreams([]Stream{newStream("a", 0, "public.b"), newStream("b", 0, "public.a")})
+	if err == nil {
+		t.Fatal("expected cycle to be rejected")
+	}
+}
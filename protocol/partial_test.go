@@ -0,0 +1,58 @@
+package protocol
+
+import (
+	"context"
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestPartialRecords(t *testing.T) {
+	ctx := context.Background()
+	samples := &sampler{limit: 10}
+	pool, err := newSamplePool(ctx, nil, samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := &types.ConfiguredStream{Stream: types.NewStream("users", "app"), StreamMetadata: types.StreamMetadata{Filter: `plan == "pro"`}}
+	thread, err := pool.NewThread(ctx, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the filter column is missing, the update must not be left out
+	if err := thread.Insert(types.CreatePartialRawRecord("1", map[string]any{"_id": 1, "email": nil})); err != nil {
+		t.Fatal(err)
+	}
+	thread.Close()
+	if err := pool.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if len(samples.records) != 1 {
+		t.Fatalf("expected the partial record to be written, got %v", samples.records)
+	}
+	record := samples.records[0]
+	if value, found := record["email"]; !found || value != nil {
+		t.Fatalf("expected email to be null, got %v", record)
+	}
+	if _, found := record["plan"]; found {
+		t.Fatalf("expected plan to stay missing, got %v", record)
+	}
+
+	// writers that can't tell missing columns from null ones would null them
+	pool, err = newSamplePool(ctx, nil, &sampler{limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.init = func() Writer {
+		return struct{ Writer }{&sampleWriter{sampler: samples}}
+	}
+	thread, err = pool.NewThread(ctx, &types.ConfiguredStream{Stream: types.NewStream("users", "app")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = thread.Insert(types.CreatePartialRawRecord("1", map[string]any{"_id": 1}))
+	thread.Close()
+	if err := pool.Wait(); err == nil {
+		t.Fatal("expected partial records to fail a writer without support for them")
+	}
+}
@@ -0,0 +1,67 @@
+package protocol
+
+import (
+	"context"
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+// pooledSampleWriter claims to be done with records once written while keeping them, so the
+// records it keeps show which maps were released
+type pooledSampleWriter struct {
+	*sampleWriter
+}
+
+func (pooledSampleWriter) PooledRecords() bool {
+	return true
+}
+
+func TestPooledRecords(t *testing.T) {
+	ctx := context.Background()
+	write := func(pooled bool, create func(data map[string]any) types.RawRecord) types.Record {
+		samples := &sampler{limit: 10}
+		pool, err := newSamplePool(ctx, nil, samples)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pooled {
+			pool.init = func() Writer {
+				return pooledSampleWriter{&sampleWriter{sampler: samples}}
+			}
+		}
+		thread, err := pool.NewThread(ctx, &types.ConfiguredStream{Stream: types.NewStream("users", "app")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := types.NewRecord()
+		data["_id"] = int64(1)
+		if err := thread.Insert(create(data)); err != nil {
+			t.Fatal(err)
+		}
+		thread.Close()
+		if err := pool.Wait(); err != nil {
+			t.Fatal(err)
+		}
+		if len(samples.records) != 1 {
+			t.Fatalf("expected the record to be written, got %v", samples.records)
+		}
+		return samples.records[0]
+	}
+	pooledRecord := func(data map[string]any) types.RawRecord {
+		return types.CreatePooledRawRecord("1", data, 0)
+	}
+	rawRecord := func(data map[string]any) types.RawRecord {
+		return types.CreateRawRecord("1", data, 0)
+	}
+
+	if record := write(true, pooledRecord); len(record) != 0 {
+		t.Fatalf("expected the record to be released once written, got %v", record)
+	}
+	if record := write(false, pooledRecord); record["_id"] != int64(1) {
+		t.Fatalf("expected writers keeping records to keep their data, got %v", record)
+	}
+	if record := write(true, rawRecord); record["_id"] != int64(1) {
+		t.Fatalf("expected records not taken from the pool to be left alone, got %v", record)
+	}
+}
@@ -0,0 +1,185 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/rs/zerolog"
+)
+
+const (
+	progressInterval = 500 * time.Millisecond
+	progressBarWidth = 30
+)
+
+// render progress bars in place of console logs
+var interactive bool
+
+// progressDisplay redraws a progress bar per stream in place; warnings and errors are
+// printed above the bars, other messages only go to the log file
+type progressDisplay struct {
+	mutex   sync.Mutex
+	out     *os.File
+	pool    *WriterPool
+	streams []string
+	// when the records of a stream were first and last seen to change; rates cover only
+	// the time a stream was read
+	activity map[string]*streamActivity
+	// lines drawn by the last redraw, cleared before the next
+	lines    int
+	restore  func()
+	cancel   context.CancelFunc
+	done     chan struct{}
+	stopping sync.Once
+}
+
+// startProgress returns nil unless --interactive is passed and stdout is a terminal, in
+// which case console logs keep scrolling as before
+func startProgress(ctx context.Context, pool *WriterPool, streams []string) *progressDisplay {
+	if !interactive || !isTerminal(os.Stdout) {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	display := &progressDisplay{
+		out:      os.Stdout,
+		pool:     pool,
+		streams:  streams,
+		activity: make(map[string]*streamActivity),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	display.restore = logger.RedirectConsole(display, zerolog.WarnLevel)
+
+	go func() {
+		defer close(display.done)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				display.mutex.Lock()
+				display.redraw()
+				display.mutex.Unlock()
+			}
+		}
+	}()
+	return display
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Write prints a console message above the bars
+func (p *progressDisplay) Write(message []byte) (int, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.clear()
+	n, err := p.out.Write(message)
+	p.draw()
+	return n, err
+}
+
+// stop draws the final state of the bars and gives the console back to the logs
+func (p *progressDisplay) stop() {
+	if p == nil {
+		return
+	}
+	p.stopping.Do(func() {
+		p.cancel()
+		<-p.done
+		p.mutex.Lock()
+		p.redraw()
+		p.lines = 0
+		p.mutex.Unlock()
+		p.restore()
+	})
+}
+
+func (p *progressDisplay) clear() {
+	if p.lines > 0 {
+		// move to the first bar and clear everything below
+		fmt.Fprintf(p.out, "\033[%dA\033[J", p.lines)
+		p.lines = 0
+	}
+}
+
+func (p *progressDisplay) redraw() {
+	p.clear()
+	p.draw()
+}
+
+type streamActivity struct {
+	first, last time.Time
+	synced      int64
+}
+
+// elapsed returns the time the stream has been read for as of now
+func (p *progressDisplay) elapsed(stream string, synced int64) time.Duration {
+	now := time.Now()
+	activity, found := p.activity[stream]
+	if !found {
+		if synced == 0 {
+			return 0
+		}
+		// the records were read since the previous redraw at most
+		activity = &streamActivity{first: now.Add(-progressInterval), last: now, synced: synced}
+		p.activity[stream] = activity
+	}
+	if synced != activity.synced {
+		activity.synced, activity.last = synced, now
+	}
+	return activity.last.Sub(activity.first)
+}
+
+func (p *progressDisplay) draw() {
+	width := 0
+	for _, stream := range p.streams {
+		width = max(width, len(stream))
+	}
+
+	var buf bytes.Buffer
+	for _, stream := range p.streams {
+		progress := p.pool.streamProgress(stream)
+		synced, total := progress.synced.Load(), progress.total.Load()
+		status := ""
+		if _, failed := p.pool.lifecycle.failed.Load(stream); failed {
+			status = " failed"
+		}
+		fmt.Fprintf(&buf, "%-*s %s%s\n", width, stream, progressLine(synced, total, p.elapsed(stream, synced)), status)
+	}
+	_, _ = p.out.Write(buf.Bytes())
+	p.lines = len(p.streams)
+}
+
+// progressLine renders the bar, records and remaining time; the total is an estimate of the
+// driver, or 0 when unknown
+func progressLine(synced, total int64, elapsed time.Duration) string {
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(synced) / elapsed.Seconds()
+	}
+	if total <= 0 {
+		return fmt.Sprintf("[%s] %d records %.0f rps", strings.Repeat("-", progressBarWidth), synced, rate)
+	}
+
+	ratio := min(float64(synced)/float64(total), 1)
+	filled := int(ratio * progressBarWidth)
+	eta := "--"
+	if remaining := total - synced; remaining <= 0 {
+		eta = "0s"
+	} else if rate > 0 {
+		eta = time.Duration(float64(remaining) / rate * float64(time.Second)).Round(time.Second).String()
+	}
+	return fmt.Sprintf("[%s%s] %3.0f%% %d/%d records %.0f rps ETA %s",
+		strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled), ratio*100, synced, total, rate, eta)
+}
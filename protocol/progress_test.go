@@ -0,0 +1,24 @@
+package protocol
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressLine(t *testing.T) {
+	line := progressLine(500, 1000, 10*time.Second)
+	for _, expected := range []string{"[" + strings.Repeat("=", 15) + strings.Repeat(" ", 15) + "]", " 50%", "500/1000 records", "50 rps", "ETA 10s"} {
+		if !strings.Contains(line, expected) {
+			t.Fatalf("expected %q in %q", expected, line)
+		}
+	}
+
+	// estimates can fall short of the records synced
+	if line := progressLine(1200, 1000, time.Second); !strings.Contains(line, "100%") || !strings.Contains(line, "ETA 0s") {
+		t.Fatalf("expected a full bar, got %q", line)
+	}
+	if line := progressLine(42, 0, time.Second); !strings.Contains(line, "42 records") || strings.Contains(line, "ETA") {
+		t.Fatalf("expected no estimate without a total, got %q", line)
+	}
+}
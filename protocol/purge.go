@@ -0,0 +1,281 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/filter"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/goccy/go-json"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	purgeSucceeded = "succeeded"
+	purgeFailed    = "failed"
+)
+
+var (
+	purgeKeysPath  string
+	purgeFilter    string
+	purgeAuditPath string
+)
+
+// PurgeAudit is appended as a json line to the purge audit log for every purge. Rows are
+// identified by their olake ids only, so the log holds no personal data.
+type PurgeAudit struct {
+	Time        time.Time `json:"time"`
+	SyncID      string    `json:"sync_id"`
+	Stream      string    `json:"stream"`
+	Destination string    `json:"destination"`
+	Filter      string    `json:"filter,omitempty"`
+	Keys        int       `json:"keys"`
+	Deleted     int       `json:"deleted"`
+	OlakeIDs    []string  `json:"olake_ids"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// purgeCmd deletes rows of a stream from the destination, for right to be forgotten requests
+var purgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Olake purge command; deletes the rows of --stream with the primary keys in --keys, or of the source rows matching --purge-filter, from the destination and records it in an audit log",
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		if !configured("destination", destinationConfigPath) {
+			return fmt.Errorf("--destination not passed")
+		} else if catalogPath == "" {
+			return fmt.Errorf("--catalog not passed")
+		} else if sampleStream == "" {
+			return fmt.Errorf("--stream not passed")
+		}
+		if (purgeKeysPath == "") == (purgeFilter == "") {
+			return fmt.Errorf("pass one of --keys and --purge-filter")
+		}
+		if purgeFilter != "" {
+			// filters select rows of the source
+			if !configured("config", configPath) {
+				return fmt.Errorf("--config not passed; needed to read the source rows --purge-filter selects")
+			}
+			if err := utils.UnmarshalFileWithEnv(configPath, "config", connector.GetConfigRef()); err != nil {
+				return err
+			}
+		}
+		destinationConfig = &types.WriterConfig{}
+		if err := utils.UnmarshalFileWithEnv(destinationConfigPath, "destination", destinationConfig); err != nil {
+			return err
+		}
+		catalog = &types.Catalog{}
+		return types.CatalogFormat.LoadFile(catalogPath, catalog)
+	},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		stream, err := purgeStream()
+		if err != nil {
+			return err
+		}
+		pool, err := NewWriter(cmd.Context(), destinationConfig)
+		if err != nil {
+			return err
+		}
+
+		audit := &PurgeAudit{
+			Time:        time.Now().UTC(),
+			SyncID:      viper.GetString("SYNC_ID"),
+			Stream:      stream.ID(),
+			Destination: string(destinationConfig.Type),
+			Filter:      purgeFilter,
+			OlakeIDs:    []string{},
+			Status:      purgeSucceeded,
+		}
+		err = purge(cmd.Context(), pool, stream, audit)
+		if err != nil {
+			audit.Status = purgeFailed
+			audit.Error = err.Error()
+		}
+		if auditErr := writePurgeAudit(audit); auditErr != nil {
+			logger.Errorf("failed to record purge in audit log: %s", auditErr)
+			if err == nil {
+				err = auditErr
+			}
+		}
+		return err
+	},
+}
+
+// purgeStream returns the catalog stream to purge by namespace.name, or by name when unique
+func purgeStream() (*types.ConfiguredStream, error) {
+	var stream *types.ConfiguredStream
+	for _, elem := range catalog.Streams {
+		if elem.ID() != sampleStream && elem.Name() != sampleStream {
+			continue
+		}
+		if stream != nil {
+			return nil, fmt.Errorf("stream[%s] exists in several namespaces; pass namespace.name", sampleStream)
+		}
+		stream = elem
+	}
+	if stream == nil {
+		return nil, fmt.Errorf("stream[%s] not found in catalog[%s]", sampleStream, catalogPath)
+	}
+	for _, streamMetadata := range catalog.SelectedStreams[stream.Namespace()] {
+		if streamMetadata.StreamName == stream.Name() {
+			stream.StreamMetadata = streamMetadata
+		}
+	}
+	stream.ApplyOverrides()
+	if stream.GetStream().SourceDefinedPrimaryKey.Len() == 0 {
+		return nil, fmt.Errorf("stream[%s] has no primary key; rows can't be identified", stream.ID())
+	}
+	return stream, nil
+}
+
+// purge deletes the rows of the keys of stream through a writer of the destination; key
+// columns are transformed like the sync does, olake ids are derived from the plain keys
+func purge(ctx context.Context, pool *WriterPool, stream *types.ConfiguredStream, audit *PurgeAudit) error {
+	primaryKey := stream.GetStream().SourceDefinedPrimaryKey.Array()
+	var keys []map[string]any
+	var err error
+	if purgeFilter != "" {
+		keys, err = purgeSourceKeys(ctx, stream, primaryKey)
+	} else {
+		keys, err = readPurgeKeys(purgeKeysPath)
+	}
+	if err != nil {
+		return err
+	}
+	audit.Keys = len(keys)
+
+	transformer, err := pool.columnTransformer(stream)
+	if err != nil {
+		return err
+	}
+	writer, err := pool.destinationWriter(stream)
+	if err != nil {
+		return fmt.Errorf("failed to set up destination writer: %s", err)
+	}
+	deleteTime := time.Now().UTC().UnixMilli()
+	for idx, key := range keys {
+		data := make(map[string]any, len(primaryKey))
+		for _, column := range primaryKey {
+			value, found := key[column]
+			if !found {
+				writer.Close()
+				return fmt.Errorf("key %d misses primary key column[%s]", idx+1, column)
+			}
+			data[column] = value
+		}
+		olakeID := utils.GetKeysHash(data, primaryKey...)
+		if transformer != nil {
+			transformer.Apply(data)
+		}
+		if err := writer.Delete(ctx, types.CreateRawRecord(olakeID, data, deleteTime)); err != nil {
+			writer.Close()
+			if errors.Is(err, ErrDeleteUnsupported) {
+				return fmt.Errorf("destination %s can't purge rows: %s", audit.Destination, err)
+			}
+			return fmt.Errorf("failed to delete row[%s]: %s", olakeID, err)
+		}
+		audit.OlakeIDs = append(audit.OlakeIDs, olakeID)
+	}
+	// writers flush buffered deletes on close
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to flush deletes: %s", err)
+	}
+	audit.Deleted = len(audit.OlakeIDs)
+	logger.Infof("Purged %d rows of stream[%s] from destination %s", audit.Deleted, stream.ID(), audit.Destination)
+	return nil
+}
+
+// purgeSourceKeys returns the primary keys of the source rows matching --purge-filter
+func purgeSourceKeys(ctx context.Context, stream Stream, primaryKey []string) ([]map[string]any, error) {
+	rowFilter, err := filter.Compile(purgeFilter)
+	if err != nil {
+		return nil, err
+	}
+	if err := connector.Setup(); err != nil {
+		return nil, err
+	}
+	scanner, ok := connector.(RowScanner)
+	if !ok {
+		return nil, fmt.Errorf("driver %s can't read back rows for --purge-filter; pass --keys", connector.Type())
+	}
+	var keys []map[string]any
+	err = scanner.ScanRows(ctx, stream, func(row map[string]any) error {
+		matched, err := rowFilter.Match(row)
+		if err != nil || !matched {
+			return err
+		}
+		key := make(map[string]any, len(primaryKey))
+		for _, column := range primaryKey {
+			key[column] = row[column]
+		}
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source rows: %s", err)
+	}
+	return keys, nil
+}
+
+// readPurgeKeys reads primary key objects from a json array or from json lines; numbers are
+// kept as written so olake ids match the ones derived from source values
+func readPurgeKeys(path string) ([]map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys[%s]: %s", path, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var keys []map[string]any
+	for {
+		var value any
+		if err := decoder.Decode(&value); err == io.EOF {
+			return keys, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse keys[%s]: %s", path, err)
+		}
+		values, isArray := value.([]any)
+		if !isArray {
+			values = []any{value}
+		}
+		for _, value := range values {
+			key, ok := value.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid key %v in keys[%s]; expected an object of primary key columns", value, path)
+			}
+			keys = append(keys, key)
+		}
+	}
+}
+
+// writePurgeAudit logs the purge and appends it to --audit-log, by default purge_audit.jsonl
+// next to the catalog; purges are recorded even with --no-save
+func writePurgeAudit(audit *PurgeAudit) error {
+	logger.Info(audit)
+	path := purgeAuditPath
+	if path == "" {
+		path = filepath.Join(filepath.Dir(catalogPath), "purge_audit.jsonl")
+	}
+	line, err := json.Marshal(audit)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,110 @@
+package protocol
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/datazip-inc/olake/pkg/transform"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+)
+
+// deleteRecorder records deletes; other writer methods are not used
+type deleteRecorder struct {
+	Writer
+	deletes []types.RawRecord
+	closed  bool
+}
+
+func (d *deleteRecorder) GetConfigRef() Config {
+	return &sampleConfig{}
+}
+
+func (d *deleteRecorder) Setup(_ Stream, _ *Options) error {
+	return nil
+}
+
+func (d *deleteRecorder) Delete(_ context.Context, record types.RawRecord) error {
+	d.deletes = append(d.deletes, record)
+	return nil
+}
+
+func (d *deleteRecorder) Close() error {
+	d.closed = true
+	return nil
+}
+
+func TestReadPurgeKeys(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"array.json":  `[{"id": 12345678901234567}, {"id": 2}]`,
+		"lines.jsonl": "{\"id\": 12345678901234567}\n{\"id\": 2}\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		keys, err := readPurgeKeys(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// large numbers keep their digits so olake ids match
+		if len(keys) != 2 || utils.GetKeysHash(keys[0], "id") != utils.GetKeysHash(map[string]any{"id": int64(12345678901234567)}, "id") {
+			t.Fatalf("%s: unexpected keys %v", name, keys)
+		}
+	}
+
+	path := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(path, []byte(`[1, 2]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readPurgeKeys(path); err == nil {
+		t.Fatal("expected keys that aren't objects to be rejected")
+	}
+}
+
+func TestPurge(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+	stream := &types.ConfiguredStream{
+		Stream:         types.NewStream("users", "public").WithPrimaryKey("email"),
+		StreamMetadata: types.StreamMetadata{Transforms: []types.ColumnTransform{{Column: "email", Type: types.TransformEncrypt, Key: key}}},
+	}
+	stream.Stream.UpsertField("email", types.String, false)
+	path := filepath.Join(t.TempDir(), "keys.jsonl")
+	if err := os.WriteFile(path, []byte("{\"email\": \"jane@example.com\"}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	purgeKeysPath, purgeFilter = path, ""
+	defer func() { purgeKeysPath = "" }()
+
+	recorder := &deleteRecorder{}
+	pool := &WriterPool{config: map[string]any{}, init: func() Writer { return recorder }}
+	audit := &PurgeAudit{}
+	if err := purge(context.Background(), pool, stream, audit); err != nil {
+		t.Fatal(err)
+	}
+	if !recorder.closed || len(recorder.deletes) != 1 || audit.Deleted != 1 {
+		t.Fatalf("expected 1 delete flushed, got %+v and %+v", recorder.deletes, audit)
+	}
+	deleted := recorder.deletes[0]
+	if deleted.OlakeID != utils.GetKeysHash(map[string]any{"email": "jane@example.com"}, "email") || audit.OlakeIDs[0] != deleted.OlakeID {
+		t.Fatalf("expected the olake id of the plain key, got %s", deleted.OlakeID)
+	}
+	if plaintext, err := transform.Decrypt([]byte(strings.Repeat("k", 32)), deleted.Data["email"].(string)); err != nil || plaintext != "jane@example.com" {
+		t.Fatalf("expected the key encrypted like the sync writes it, got %v", deleted.Data)
+	}
+	if deleted.DeleteTime == 0 {
+		t.Fatal("expected the delete time to be set")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"id": 1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := purge(context.Background(), pool, stream, &PurgeAudit{}); err == nil {
+		t.Fatal("expected keys missing primary key columns to fail")
+	}
+}
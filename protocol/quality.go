@@ -0,0 +1,245 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+)
+
+const (
+	qualityPassed  = "passed"
+	qualityFailed  = "failed"
+	qualitySkipped = "skipped"
+	syncQuality    = "quality_failed"
+
+	// QualityCheckExitCode is the exit code of a sync whose streams were written but failed
+	// data quality checks
+	QualityCheckExitCode = 3
+)
+
+// ErrQualityCheck is returned by a sync that wrote all streams but failed quality checks
+var ErrQualityCheck = errors.New("data quality checks failed")
+
+// QualityResult is the outcome of one quality check of a stream in the sync summary
+type QualityResult struct {
+	Check   string `json:"check"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// qualityTracker gathers null counts and cursor bounds of the records written of a stream
+// with quality checks
+type qualityTracker struct {
+	config     *types.QualityConfig
+	cursor     string
+	cursorType types.DataType
+
+	mutex   sync.Mutex
+	records int64
+	nulls   map[string]int64
+	min     any
+	max     any
+	err     error // first cursor value that couldn't be compared
+}
+
+// qualityTracker returns the shared tracker of stream; nil when it has no quality checks
+func (w *WriterPool) qualityTracker(stream Stream) *qualityTracker {
+	config := stream.Self().StreamMetadata.Quality
+	if config == nil {
+		return nil
+	}
+	if tracker, found := w.quality.Load(stream.ID()); found {
+		return tracker.(*qualityTracker)
+	}
+	tracker := &qualityTracker{config: config, nulls: make(map[string]int64)}
+	// composite cursors are bounded by their first column
+	if columns := stream.CursorFields(); stream.GetSyncMode() == types.INCREMENTAL && len(columns) > 0 {
+		tracker.cursor = columns[0]
+		tracker.cursorType, _ = stream.Schema().GetType(tracker.cursor)
+	}
+	actual, _ := w.quality.LoadOrStore(stream.ID(), tracker)
+	return actual.(*qualityTracker)
+}
+
+func (q *qualityTracker) observe(record types.RawRecord) {
+	// deletes only carry primary keys and partial records the changed columns
+	if record.DeleteTime != 0 || record.Partial {
+		return
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.records++
+	for column := range q.config.MaxNullRate {
+		if value, found := record.Data[column]; !found || value == nil {
+			q.nulls[column]++
+		}
+	}
+	if q.cursor == "" || q.err != nil {
+		return
+	}
+	value := record.Data[q.cursor]
+	if value == nil {
+		return
+	}
+	if q.min == nil {
+		q.min, q.max = value, value
+		return
+	}
+	if q.err = q.extend(value); q.err != nil {
+		q.err = fmt.Errorf("failed to compare cursor value[%v]: %s", value, q.err)
+	}
+}
+
+func (q *qualityTracker) extend(value any) error {
+	lower, err := typeutils.CompareCursor(q.cursorType, value, q.min)
+	if err != nil {
+		return err
+	}
+	if lower < 0 {
+		q.min = value
+	}
+	higher, err := typeutils.CompareCursor(q.cursorType, value, q.max)
+	if err != nil {
+		return err
+	}
+	if higher > 0 {
+		q.max = value
+	}
+	return nil
+}
+
+// runQualityChecks checks the written streams with quality checks; failed streams are skipped
+func runQualityChecks(ctx context.Context, pool *WriterPool, streams []Stream) map[string][]QualityResult {
+	results := make(map[string][]QualityResult)
+	for _, stream := range streams {
+		config := stream.Self().StreamMetadata.Quality
+		if config == nil {
+			continue
+		}
+		if _, failed := pool.lifecycle.failed.Load(stream.ID()); failed {
+			continue
+		}
+		var checks []QualityResult
+		if config.RowCount {
+			checks = append(checks, checkRowCount(ctx, pool, stream, config))
+		}
+		tracker := pool.qualityTracker(stream)
+		checks = append(checks, tracker.results()...)
+		for _, check := range checks {
+			if check.Status == qualityFailed {
+				logger.Warnf("Stream %s failed quality check %s: %s", stream.ID(), check.Check, check.Message)
+			}
+		}
+		results[stream.ID()] = checks
+	}
+	return results
+}
+
+// checkRowCount compares the source rows with the destination rows; destinations that can't
+// count rows are compared by the records written, which only holds all rows in full refresh
+func checkRowCount(ctx context.Context, pool *WriterPool, stream Stream, config *types.QualityConfig) QualityResult {
+	result := QualityResult{Check: "row_count", Status: qualitySkipped}
+	counter, ok := connector.(RowCounter)
+	if !ok {
+		result.Message = fmt.Sprintf("driver %s can't count rows", connector.Type())
+		return result
+	}
+	source, err := counter.CountRows(ctx, stream)
+	if err != nil {
+		result.Status = qualityFailed
+		result.Message = fmt.Sprintf("failed to count source rows: %s", err)
+		return result
+	}
+
+	reader, err := pool.destinationReader(stream)
+	if err != nil {
+		result.Status = qualityFailed
+		result.Message = fmt.Sprintf("failed to set up destination reader: %s", err)
+		return result
+	}
+	if reader != nil {
+		defer reader.Close()
+	}
+	var destination int64
+	if counter, ok := reader.(RowCounter); ok {
+		if destination, err = counter.CountRows(ctx, stream); err != nil {
+			result.Status = qualityFailed
+			result.Message = fmt.Sprintf("failed to count destination rows: %s", err)
+			return result
+		}
+	} else if stream.GetSyncMode() == types.FULLREFRESH && !stream.Self().StreamMetadata.ChangeDetection {
+		destination = pool.streamProgress(stream.ID()).synced.Load()
+	} else {
+		result.Message = "destination can't count rows; only full refresh streams are compared with the records written"
+		return result
+	}
+
+	allowed := config.RowCountTolerance * float64(source)
+	result.Status = qualityPassed
+	if math.Abs(float64(source-destination)) > allowed {
+		result.Status = qualityFailed
+	}
+	result.Message = fmt.Sprintf("source has %d rows, destination %d", source, destination)
+	if config.RowCountTolerance > 0 {
+		result.Message += fmt.Sprintf(" (tolerance %.0f rows)", allowed)
+	}
+	return result
+}
+
+// results checks the null rates and cursor bounds of the records written
+func (q *qualityTracker) results() []QualityResult {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	var results []QualityResult
+
+	columns := make([]string, 0, len(q.config.MaxNullRate))
+	for column := range q.config.MaxNullRate {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	for _, column := range columns {
+		result := QualityResult{Check: fmt.Sprintf("null_rate[%s]", column), Status: qualitySkipped, Message: "no records written"}
+		if q.records > 0 {
+			rate := float64(q.nulls[column]) / float64(q.records)
+			result.Status = qualityPassed
+			if rate > q.config.MaxNullRate[column] {
+				result.Status = qualityFailed
+			}
+			result.Message = fmt.Sprintf("%d of %d records null (%.4f, max %.4f)", q.nulls[column], q.records, rate, q.config.MaxNullRate[column])
+		}
+		results = append(results, result)
+	}
+
+	if q.cursor == "" {
+		return results
+	}
+	result := QualityResult{Check: fmt.Sprintf("cursor_range[%s]", q.cursor), Status: qualitySkipped, Message: "no cursor values read"}
+	switch {
+	case q.err != nil:
+		result.Status = qualityFailed
+		result.Message = q.err.Error()
+	case q.min != nil:
+		result.Status = qualityPassed
+		result.Message = fmt.Sprintf("min %v, max %v", q.min, q.max)
+		if q.config.CursorMin != nil {
+			if below, err := typeutils.CompareCursor(q.cursorType, q.min, q.config.CursorMin); err != nil || below < 0 {
+				result.Status = qualityFailed
+				result.Message += fmt.Sprintf("; below cursor_min %v", q.config.CursorMin)
+			}
+		}
+		if q.config.CursorMax != nil {
+			if above, err := typeutils.CompareCursor(q.cursorType, q.max, q.config.CursorMax); err != nil || above > 0 {
+				result.Status = qualityFailed
+				result.Message += fmt.Sprintf("; above cursor_max %v", q.config.CursorMax)
+			}
+		}
+	}
+	return append(results, result)
+}
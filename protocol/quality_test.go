@@ -0,0 +1,53 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestQualityTracker(t *testing.T) {
+	stream := types.NewStream("orders", "public")
+	stream.UpsertField("updated_at", types.Timestamp, false)
+	stream.SyncMode = types.INCREMENTAL
+	configured := &types.ConfiguredStream{Stream: stream, CursorField: "updated_at"}
+	configured.StreamMetadata.Quality = &types.QualityConfig{
+		MaxNullRate: map[string]float64{"email": 0.25},
+		CursorMax:   "2024-06-01T00:00:00Z",
+	}
+
+	pool := &WriterPool{lifecycle: &lifecycle{}}
+	tracker := pool.qualityTracker(configured)
+	for _, data := range []map[string]any{
+		{"email": "a@example.com", "updated_at": "2024-05-02T00:00:00Z"},
+		{"email": nil, "updated_at": "2024-05-01T00:00:00Z"},
+		{"updated_at": "2024-06-02T00:00:00Z"},
+		{"email": "d@example.com", "updated_at": "2024-05-03T00:00:00Z"},
+	} {
+		tracker.observe(types.RawRecord{Data: data})
+	}
+	// deletes only carry keys and are not counted
+	tracker.observe(types.RawRecord{Data: map[string]any{}, DeleteTime: 1})
+
+	results := runQualityChecks(context.Background(), pool, []Stream{configured})[configured.ID()]
+	if len(results) != 2 {
+		t.Fatalf("expected a null rate and a cursor range check, got %+v", results)
+	}
+	if results[0].Check != "null_rate[email]" || results[0].Status != qualityFailed {
+		t.Fatalf("expected 2 of 4 null emails to fail the null rate, got %+v", results[0])
+	}
+	if results[1].Status != qualityFailed || results[1].Message != "min 2024-05-01T00:00:00Z, max 2024-06-02T00:00:00Z; above cursor_max 2024-06-01T00:00:00Z" {
+		t.Fatalf("expected the cursor range to exceed cursor_max, got %+v", results[1])
+	}
+
+	failures := newStreamFailures()
+	failures.quality = map[string][]QualityResult{configured.ID(): results}
+	if err := failures.report(pool, []string{configured.ID()}); !errors.Is(err, ErrQualityCheck) {
+		t.Fatalf("expected failed quality checks to fail the sync, got %v", err)
+	}
+	if failures.summary.Status != syncQuality {
+		t.Fatalf("expected summary status %s, got %s", syncQuality, failures.summary.Status)
+	}
+}
@@ -0,0 +1,139 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/datazip-inc/olake/pkg/spool"
+	"github.com/datazip-inc/olake/types"
+)
+
+func init() {
+	// concrete types of the values records hold, besides the basic ones gob knows
+	gob.Register(time.Time{})
+	gob.Register([]any{})
+	gob.Register(map[string]any{})
+	gob.Register(types.Record{})
+	gob.Register(types.WKB{})
+	gob.Register(types.RawJSON{})
+}
+
+// queuedRecord is a record as kept in a queue; insert time and metadata columns are added by
+// the thread once it's popped
+type queuedRecord struct {
+	OlakeID    string
+	Data       map[string]any
+	DeleteTime int64
+	Partial    bool
+}
+
+// recordQueue keeps the records inserted into a thread on disk until its writer gets to them,
+// so readers of sources with strict connection time limits aren't held back by slow writers.
+// Checkpoint barriers are queued as empty entries, so the thread only acknowledges them once
+// it wrote every record inserted before.
+type recordQueue struct {
+	queue    *spool.Queue
+	records  chan types.RawRecord // popped records; closed once the queue is drained
+	requests chan chan error      // barriers popped from the queue
+	barriers chan chan error      // barriers in the queue, in order
+	err      error                // why records closed early; read after it closed
+}
+
+func newRecordQueue(config *spool.Config, stream Stream) (*recordQueue, error) {
+	queue, err := spool.Open(config, stream.ID())
+	if err != nil {
+		return nil, err
+	}
+	return &recordQueue{
+		queue:    queue,
+		records:  make(chan types.RawRecord),
+		requests: make(chan chan error),
+		barriers: make(chan chan error, 1),
+	}, nil
+}
+
+// push queues record; pooled data is released once encoded
+func (r *recordQueue) push(ctx context.Context, record types.RawRecord) error {
+	var entry bytes.Buffer
+	queued := queuedRecord{OlakeID: record.OlakeID, Data: record.Data, DeleteTime: record.DeleteTime, Partial: record.Partial}
+	if err := gob.NewEncoder(&entry).Encode(&queued); err != nil {
+		return fmt.Errorf("failed to queue record: %s", err)
+	}
+	if err := r.queue.Push(ctx, entry.Bytes()); err != nil {
+		return err
+	}
+	record.Release()
+	return nil
+}
+
+// forward queues the barrier requests of the thread behind the records inserted so far
+func (r *recordQueue) forward(ctx context.Context, barrier *threadBarrier) {
+	for {
+		select {
+		case ack := <-barrier.requests:
+			err := r.queue.Push(ctx, nil)
+			if errors.Is(err, spool.ErrClosed) {
+				// inserts are over; the barrier is passed once the thread wrote them all
+				return
+			}
+			if err != nil {
+				ack <- fmt.Errorf("failed to queue checkpoint: %s", err)
+				continue
+			}
+			select {
+			case r.barriers <- ack:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// pump pops queued records and barriers for the thread until the queue is drained
+func (r *recordQueue) pump(ctx context.Context) {
+	defer close(r.records)
+	for {
+		entry, err := r.queue.Pop(ctx)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			r.err = fmt.Errorf("failed to read queued records: %s", err)
+			return
+		}
+		if len(entry) == 0 {
+			var ack chan error
+			select {
+			case ack = <-r.barriers:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case r.requests <- ack:
+			case <-ctx.Done():
+				ack <- ctx.Err()
+				return
+			}
+			continue
+		}
+		queued := queuedRecord{Data: types.NewRecord()}
+		if err := gob.NewDecoder(bytes.NewReader(entry)).Decode(&queued); err != nil {
+			r.err = fmt.Errorf("failed to decode queued record: %s", err)
+			return
+		}
+		record := types.CreatePooledRawRecord(queued.OlakeID, queued.Data, queued.DeleteTime)
+		record.Partial = queued.Partial
+		select {
+		case r.records <- record:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package protocol
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/pkg/spool"
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestRecordQueue(t *testing.T) {
+	ctx := context.Background()
+	samples := &sampler{limit: 1000}
+	pool, err := newSamplePool(ctx, nil, samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := t.TempDir()
+	pool.queue = &spool.Config{Path: path, SegmentSize: 256}
+	thread, err := pool.NewThread(ctx, &types.ConfiguredStream{Stream: types.NewStream("users", "app")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	data := map[string]any{
+		"_id":     int64(1),
+		"score":   1.5,
+		"name":    "jane",
+		"created": created,
+		"avatar":  []byte{1, 2, 3},
+		"tags":    []any{"a", int64(2)},
+		"address": map[string]any{"city": "Pune"},
+		"deleted": nil,
+	}
+	if err := thread.Insert(types.CreateRawRecord("1", data, 0)); err != nil {
+		t.Fatal(err)
+	}
+	for i := int64(2); i <= 100; i++ {
+		if err := thread.Insert(types.CreateRawRecord("n", map[string]any{"_id": i}, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a checkpoint only passes once the records inserted before are written
+	if err := pool.flushThreads(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if records := samples.snapshot(); len(records) != 100 {
+		t.Fatalf("expected the queued records to be written before the checkpoint, got %d", len(records))
+	}
+	thread.Close()
+	if err := pool.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	written := samples.snapshot()[0]
+	for column, value := range data {
+		if !reflect.DeepEqual(written[column], value) {
+			t.Errorf("expected column[%s] to keep %#v through the queue, got %#v", column, value, written[column])
+		}
+	}
+	if folders, _ := os.ReadDir(path); len(folders) != 0 {
+		t.Fatalf("expected the queue to be removed with the thread, got %d folders", len(folders))
+	}
+}
@@ -0,0 +1,374 @@
+package protocol
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/datazip-inc/olake/constants"
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	reconcileMatched    = "matched"
+	reconcileMismatched = "mismatched"
+	reconcileSkipped    = "skipped"
+	reconcileFailed     = "failed"
+
+	// rows of streams without a single integer primary key are checksummed in this many
+	// buckets of their key hash
+	reconcileHashBuckets = 64
+	// mismatched key ranges listed per stream
+	reconcileMaxRanges = 100
+)
+
+var (
+	reconcileChecksum  bool
+	reconcileRangeSize int64
+)
+
+// olakeColumns are added by olake to the rows written; they are left out of row checksums
+var olakeColumns = map[string]bool{
+	constants.OlakeID:        true,
+	constants.OlakeTimestamp: true,
+	constants.CDCDeletedAt:   true,
+	constants.MetaSyncedAt:   true,
+	constants.MetaSyncID:     true,
+	constants.MetaRawID:      true,
+	constants.MetaOp:         true,
+}
+
+// ReconcileReport is logged and written to reconcile_<sync id>.json by the reconcile command
+type ReconcileReport struct {
+	SyncID  string                 `json:"sync_id"`
+	Status  string                 `json:"status"`
+	Streams []StreamReconciliation `json:"streams"`
+}
+
+// StreamReconciliation compares the rows of a stream in the source and the destination
+type StreamReconciliation struct {
+	Stream          string `json:"stream"`
+	Status          string `json:"status"`
+	SourceRows      *int64 `json:"source_rows,omitempty"`
+	DestinationRows *int64 `json:"destination_rows,omitempty"`
+	// key ranges whose rows differ; compared with --checksum
+	MismatchedRanges []RangeMismatch `json:"mismatched_ranges,omitempty"`
+	Message          string          `json:"message,omitempty"`
+}
+
+// RangeMismatch is a key range whose rows differ between source and destination
+type RangeMismatch struct {
+	Range           string `json:"range"`
+	SourceRows      int64  `json:"source_rows"`
+	DestinationRows int64  `json:"destination_rows"`
+}
+
+// reconcileCmd compares the rows of the selected streams in the source and the destination
+// without syncing them
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Olake reconcile command; compares source and destination row counts, and with --checksum row checksums over key ranges, of the selected streams",
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		if !configured("config", configPath) {
+			return fmt.Errorf("--config not passed")
+		} else if !configured("destination", destinationConfigPath) {
+			return fmt.Errorf("--destination not passed")
+		} else if catalogPath == "" {
+			return fmt.Errorf("--catalog not passed")
+		}
+		if reconcileRangeSize <= 0 {
+			return fmt.Errorf("--checksum-range-size must be positive")
+		}
+		if err := utils.UnmarshalFileWithEnv(configPath, "config", connector.GetConfigRef()); err != nil {
+			return err
+		}
+		destinationConfig = &types.WriterConfig{}
+		if err := utils.UnmarshalFileWithEnv(destinationConfigPath, "destination", destinationConfig); err != nil {
+			return err
+		}
+		catalog = &types.Catalog{}
+		return types.CatalogFormat.LoadFile(catalogPath, catalog)
+	},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if err := connector.Setup(); err != nil {
+			return err
+		}
+		streams, err := discoverStreams(false)
+		if err != nil {
+			return err
+		}
+		pool, err := NewWriter(cmd.Context(), destinationConfig)
+		if err != nil {
+			return err
+		}
+
+		streamsMap := types.StreamsToMap(streams...)
+		selected := make(map[string]types.StreamMetadata)
+		for namespace, streamsMetadata := range catalog.SelectedStreams {
+			for _, streamMetadata := range streamsMetadata {
+				selected[utils.StreamIdentifier(streamMetadata.StreamName, namespace)] = streamMetadata
+			}
+		}
+
+		report := ReconcileReport{SyncID: viper.GetString("SYNC_ID"), Status: reconcileMatched}
+		for _, stream := range catalog.Streams {
+			streamMetadata, isSelected := selected[stream.ID()]
+			if catalog.SelectedStreams != nil && !isSelected {
+				continue
+			}
+			stream.StreamMetadata = streamMetadata
+			source, found := streamsMap[stream.ID()]
+			if !found {
+				logger.Warnf("Skipping; Configured Stream %s not found in source", stream.ID())
+				continue
+			}
+			if err := stream.Validate(source); err != nil {
+				logger.Warnf("Skipping; Configured Stream %s found invalid due to reason: %s", stream.ID(), err)
+				continue
+			}
+			stream.ApplyOverrides()
+
+			result := reconcileStream(cmd.Context(), pool, stream)
+			if result.Status == reconcileMismatched || result.Status == reconcileFailed {
+				report.Status = reconcileMismatched
+			}
+			report.Streams = append(report.Streams, result)
+		}
+
+		logger.Info(report)
+		if err := logger.FileLogger(report, logger.RunFileName("reconcile"), ".json"); err != nil {
+			logger.Warnf("failed to write reconcile report: %s", err)
+		}
+		if report.Status != reconcileMatched {
+			return fmt.Errorf("source and destination differ: %w", ErrQualityCheck)
+		}
+		return nil
+	},
+}
+
+func reconcileStream(ctx context.Context, pool *WriterPool, stream Stream) StreamReconciliation {
+	result := StreamReconciliation{Stream: stream.ID(), Status: reconcileSkipped}
+	fail := func(err error) StreamReconciliation {
+		result.Status = reconcileFailed
+		result.Message = err.Error()
+		logger.Errorf("failed to reconcile stream[%s]: %s", stream.ID(), err)
+		return result
+	}
+
+	reader, err := pool.destinationReader(stream)
+	if err != nil {
+		return fail(fmt.Errorf("failed to set up destination reader: %s", err))
+	}
+	if reader == nil {
+		result.Message = "destination can't read back rows"
+		return result
+	}
+	defer reader.Close()
+
+	sourceCounter, sourceCounts := connector.(RowCounter)
+	destinationCounter, destinationCounts := reader.(RowCounter)
+	// filtered rows are only left out of checksums, source counts include them
+	if stream.Self().StreamMetadata.Filter != "" {
+		result.Message = "row counts skipped; the stream is filtered"
+	} else if sourceCounts && destinationCounts {
+		source, err := sourceCounter.CountRows(ctx, stream)
+		if err != nil {
+			return fail(fmt.Errorf("failed to count source rows: %s", err))
+		}
+		destination, err := destinationCounter.CountRows(ctx, stream)
+		if err != nil {
+			return fail(fmt.Errorf("failed to count destination rows: %s", err))
+		}
+		result.SourceRows, result.DestinationRows = &source, &destination
+		result.Status = reconcileMatched
+		if source != destination {
+			result.Status = reconcileMismatched
+		}
+	} else {
+		result.Message = "row counts skipped; source or destination can't count rows"
+	}
+
+	if !reconcileChecksum {
+		return result
+	}
+	sourceScanner, sourceScans := connector.(RowScanner)
+	destinationScanner, destinationScans := reader.(RowScanner)
+	if !sourceScans || !destinationScans {
+		result.Message = "checksums skipped; source or destination can't read back rows"
+		return result
+	}
+
+	flatten := func(row types.Record) (types.Record, error) { return row, nil }
+	if reader.Normalization() {
+		// the destination holds rows as the writer flattened them
+		flatten = reader.Flattener()
+	}
+	// source rows are filtered and transformed like the sync does; transformed key columns
+	// become strings, so the key ranges are built after
+	rowFilter, err := pool.rowFilter(stream)
+	if err != nil {
+		return fail(err)
+	}
+	transformer, err := pool.columnTransformer(stream)
+	if err != nil {
+		return fail(err)
+	}
+	sourceRanges, destinationRanges := newKeyRanges(stream), newKeyRanges(stream)
+	err = sourceScanner.ScanRows(ctx, stream, func(row map[string]any) error {
+		if rowFilter != nil {
+			if matched, err := rowFilter.Match(row); err != nil || !matched {
+				return err
+			}
+		}
+		if transformer != nil {
+			transformer.Apply(row)
+		}
+		flattened, err := flatten(types.Record(row))
+		if err != nil {
+			return err
+		}
+		return sourceRanges.add(flattened)
+	})
+	if err != nil {
+		return fail(fmt.Errorf("failed to checksum source rows: %s", err))
+	}
+	if err := destinationScanner.ScanRows(ctx, stream, destinationRanges.add); err != nil {
+		return fail(fmt.Errorf("failed to checksum destination rows: %s", err))
+	}
+
+	result.MismatchedRanges = sourceRanges.diff(destinationRanges)
+	if len(result.MismatchedRanges) > 0 {
+		result.Status = reconcileMismatched
+	} else if result.Status == reconcileSkipped {
+		result.Status = reconcileMatched
+	}
+	if len(result.MismatchedRanges) > reconcileMaxRanges {
+		result.Message = fmt.Sprintf("%d key ranges differ; the first %d are listed", len(result.MismatchedRanges), reconcileMaxRanges)
+		result.MismatchedRanges = result.MismatchedRanges[:reconcileMaxRanges]
+	}
+	return result
+}
+
+// keyRanges checksums rows by ranges of their integer primary key, or by buckets of their key
+// hash when the key is not a single integer column
+type keyRanges struct {
+	column string   // single integer key column
+	keys   []string // key columns; rows are their own key without one
+	ranges map[int64]*rangeChecksum
+}
+
+// rangeChecksum sums row hashes so it doesn't depend on the order rows are read in
+type rangeChecksum struct {
+	rows int64
+	sum  uint64
+}
+
+func newKeyRanges(stream Stream) *keyRanges {
+	ranges := &keyRanges{keys: stream.GetStream().SourceDefinedPrimaryKey.Array(), ranges: make(map[int64]*rangeChecksum)}
+	if len(ranges.keys) == 1 {
+		if typ, err := stream.Schema().GetType(ranges.keys[0]); err == nil && typ == types.Int64 {
+			ranges.column = ranges.keys[0]
+		}
+	}
+	return ranges
+}
+
+func (k *keyRanges) add(row map[string]any) error {
+	values := make(map[string]any, len(row))
+	for column, value := range row {
+		if value != nil && !olakeColumns[column] {
+			values[column] = value
+		}
+	}
+	hash := checksum(rowHash(values))
+
+	var bucket int64
+	if k.column != "" {
+		key, err := typeutils.ReformatInt64(values[k.column])
+		if err != nil {
+			// decoded numbers keep their text
+			if key, err = strconv.ParseInt(fmt.Sprint(values[k.column]), 10, 64); err != nil {
+				return fmt.Errorf("invalid key[%v] of column[%s]: %s", values[k.column], k.column, err)
+			}
+		}
+		bucket = key / reconcileRangeSize
+		if key < 0 && key%reconcileRangeSize != 0 {
+			bucket--
+		}
+	} else {
+		keyHash := hash
+		if len(k.keys) > 0 {
+			keys := make(map[string]any, len(k.keys))
+			for _, key := range k.keys {
+				keys[key] = values[key]
+			}
+			keyHash = checksum(rowHash(keys))
+		}
+		bucket = int64(keyHash % reconcileHashBuckets)
+	}
+
+	checksum, found := k.ranges[bucket]
+	if !found {
+		checksum = &rangeChecksum{}
+		k.ranges[bucket] = checksum
+	}
+	checksum.rows++
+	checksum.sum += hash
+	return nil
+}
+
+// diff returns the ranges whose rows differ from other, in key order
+func (k *keyRanges) diff(other *keyRanges) []RangeMismatch {
+	buckets := make(map[int64]bool)
+	for bucket := range k.ranges {
+		buckets[bucket] = true
+	}
+	for bucket := range other.ranges {
+		buckets[bucket] = true
+	}
+	ordered := make([]int64, 0, len(buckets))
+	for bucket := range buckets {
+		ordered = append(ordered, bucket)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i] < ordered[j] })
+
+	var mismatches []RangeMismatch
+	for _, bucket := range ordered {
+		mine, theirs := k.ranges[bucket], other.ranges[bucket]
+		if mine == nil {
+			mine = &rangeChecksum{}
+		}
+		if theirs == nil {
+			theirs = &rangeChecksum{}
+		}
+		if *mine == *theirs {
+			continue
+		}
+		mismatch := RangeMismatch{Range: fmt.Sprintf("key hash bucket %d of %d", bucket, reconcileHashBuckets), SourceRows: mine.rows, DestinationRows: theirs.rows}
+		if k.column != "" {
+			mismatch.Range = fmt.Sprintf("%s in [%d, %d)", k.column, bucket*reconcileRangeSize, (bucket+1)*reconcileRangeSize)
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+	return mismatches
+}
+
+// checksum folds a hex encoded row hash into 64 bits
+func checksum(hash string) uint64 {
+	decoded, _ := hex.DecodeString(hash[:16])
+	return binary.BigEndian.Uint64(decoded)
+}
+
+func init() {
+	commands = append(commands, reconcileCmd)
+	RootCmd.PersistentFlags().BoolVarP(&reconcileChecksum, "checksum", "", false, "(Optional) Compare row checksums over primary key ranges in reconcile; reads every row of both sides")
+	RootCmd.PersistentFlags().Int64VarP(&reconcileRangeSize, "checksum-range-size", "", 100000, "(Optional) Width of the integer primary key ranges checksummed by reconcile")
+}
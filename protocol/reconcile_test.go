@@ -0,0 +1,56 @@
+package protocol
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+	"github.com/goccy/go-json"
+)
+
+func TestKeyRanges(t *testing.T) {
+	defer func(previous int64) { reconcileRangeSize = previous }(reconcileRangeSize)
+	reconcileRangeSize = 10
+
+	stream := types.NewStream("orders", "public").WithPrimaryKey("id")
+	stream.UpsertField("id", types.Int64, false)
+	configured := &types.ConfiguredStream{Stream: stream}
+
+	source, destination := newKeyRanges(configured), newKeyRanges(configured)
+	for id := int64(-5); id < 30; id++ {
+		if err := source.add(map[string]any{"id": id, "note": "same"}); err != nil {
+			t.Fatal(err)
+		}
+		// destinations return decoded numbers and the olake columns, which are not compared
+		row := map[string]any{"id": json.Number(strconv.FormatInt(id, 10)), "note": "same", "olake_id": "x", "empty": nil}
+		if id == 12 {
+			row["note"] = "changed"
+		}
+		if id == -3 {
+			continue
+		}
+		if err := destination.add(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mismatches := source.diff(destination)
+	if len(mismatches) != 2 {
+		t.Fatalf("expected 2 mismatched ranges, got %+v", mismatches)
+	}
+	if mismatches[0].Range != "id in [-10, 0)" || mismatches[0].SourceRows != 5 || mismatches[0].DestinationRows != 4 {
+		t.Fatalf("expected the missing row in [-10, 0), got %+v", mismatches[0])
+	}
+	if mismatches[1].Range != "id in [10, 20)" || mismatches[1].SourceRows != mismatches[1].DestinationRows {
+		t.Fatalf("expected the changed row in [10, 20), got %+v", mismatches[1])
+	}
+
+	// streams without an integer key are compared by buckets of their key hash
+	keyless := &types.ConfiguredStream{Stream: types.NewStream("events", "public")}
+	source, destination = newKeyRanges(keyless), newKeyRanges(keyless)
+	_ = source.add(map[string]any{"name": "a"})
+	_ = destination.add(map[string]any{"name": "b"})
+	if mismatches := source.diff(destination); len(mismatches) == 0 {
+		t.Fatal("expected rows of keyless streams to be compared")
+	}
+}
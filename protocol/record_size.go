@@ -0,0 +1,77 @@
+package protocol
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+)
+
+// limitRecordSize applies the max record size of the destination to record; records above it
+// are truncated to fit when the policy says so, an error is returned for the others
+func (w *WriterPool) limitRecordSize(stream Stream, record types.RawRecord) error {
+	size := typeutils.RecordSize(record.Data)
+	if size <= w.recordSize.MaxBytes {
+		return nil
+	}
+	w.streamProgress(stream.ID()).oversized.Add(1)
+	if w.recordSize.OnOversize == types.RecordSizeTruncate && truncateRecord(record.Data, size-w.recordSize.MaxBytes, w.recordSize.Marker) {
+		logger.ThrottledWarnf("record-size:"+stream.ID(), "truncated record of %d bytes of stream[%s] to max_bytes %d", size, stream.ID(), w.recordSize.MaxBytes)
+		return nil
+	}
+	return fmt.Errorf("record of %d bytes is larger than max_bytes %d of max_record_size", size, w.recordSize.MaxBytes)
+}
+
+// truncateRecord cuts the largest text and binary values of data by excess bytes in total;
+// data is left as is when its values can't be cut by that much
+func truncateRecord(data map[string]any, excess int, marker string) bool {
+	columns := make([]string, 0, len(data))
+	for column, value := range data {
+		switch value.(type) {
+		case string, []byte:
+			columns = append(columns, column)
+		}
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		return typeutils.ValueSize(data[columns[i]]) > typeutils.ValueSize(data[columns[j]])
+	})
+
+	cuts := make(map[string]int)
+	for _, column := range columns {
+		if excess <= 0 {
+			break
+		}
+		length, suffix := typeutils.ValueSize(data[column]), 0
+		if _, isText := data[column].(string); isText {
+			suffix = len(marker)
+		}
+		if length <= suffix {
+			continue
+		}
+		cut := max(length-excess-suffix, 0)
+		cuts[column] = cut
+		excess -= length - cut - suffix
+	}
+	if excess > 0 {
+		return false
+	}
+
+	// copied so the large values aren't kept alive by the parts written
+	for column, cut := range cuts {
+		switch value := data[column].(type) {
+		case []byte:
+			data[column] = bytes.Clone(value[:cut])
+		case string:
+			for cut > 0 && !utf8.RuneStart(value[cut]) {
+				cut--
+			}
+			data[column] = strings.Clone(value[:cut]) + marker
+		}
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package protocol
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+)
+
+func TestLimitRecordSize(t *testing.T) {
+	config := &types.RecordSizeConfig{MaxBytes: 100, OnOversize: types.RecordSizeTruncate}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	pool := &WriterPool{recordSize: config}
+	stream := &types.ConfiguredStream{Stream: types.NewStream("documents", "app")}
+
+	small := types.CreateRawRecord("1", map[string]any{"id": 1, "body": "short"}, 0)
+	if err := pool.limitRecordSize(stream, small); err != nil || small.Data["body"] != "short" {
+		t.Fatalf("expected a small record to be kept, got %v: %v", small.Data, err)
+	}
+
+	large := types.CreateRawRecord("2", map[string]any{"id": 2, "body": strings.Repeat("é", 100), "raw": make([]byte, 40), "title": "kept"}, 0)
+	if err := pool.limitRecordSize(stream, large); err != nil {
+		t.Fatal(err)
+	}
+	if size := typeutils.RecordSize(large.Data); size > config.MaxBytes {
+		t.Fatalf("expected the record to fit in %d bytes, got %d", config.MaxBytes, size)
+	}
+	body := large.Data["body"].(string)
+	if !strings.HasSuffix(body, types.DefaultTruncateMarker) || strings.ContainsRune(body, '�') || large.Data["title"] != "kept" {
+		t.Fatalf("expected the largest value to be truncated, got %v", large.Data)
+	}
+
+	// keys and numbers can't be truncated
+	wide := make(map[string]any)
+	for _, column := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"} {
+		wide[column] = 1
+	}
+	wide["note"] = "unchanged"
+	if err := pool.limitRecordSize(stream, types.CreateRawRecord("3", wide, 0)); err == nil || wide["note"] != "unchanged" {
+		t.Fatalf("expected a record that can't be truncated to fail unchanged, got %v: %v", wide, err)
+	}
+
+	config.OnOversize = types.RecordSizeDeadLetter
+	if err := pool.limitRecordSize(stream, types.CreateRawRecord("4", map[string]any{"body": strings.Repeat("x", 200)}, 0)); err == nil {
+		t.Fatal("expected an oversized record to be rejected")
+	}
+	if oversized := pool.streamProgress(stream.ID()).oversized.Load(); oversized != 3 {
+		t.Fatalf("expected 3 oversized records, got %d", oversized)
+	}
+}
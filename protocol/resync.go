@@ -0,0 +1,47 @@
+package protocol
+
+import (
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+)
+
+// beginResync resets the state of a stream whose resync policy is due, so the driver reads it
+// in full; returns whether it did
+func beginResync(state *types.State, stream Stream, now time.Time) bool {
+	policy := stream.Self().StreamMetadata.Resync
+	if policy == nil {
+		return false
+	}
+	due := false
+	if runs := state.GetCursor(stream.Self(), types.ResyncRunsKey); runs != nil && policy.EveryRuns > 0 {
+		count, err := typeutils.ReformatInt64(runs)
+		due = err == nil && count+1 >= int64(policy.EveryRuns)
+	}
+	if resyncedAt := state.GetCursor(stream.Self(), types.ResyncedAtKey); resyncedAt != nil && policy.EveryDays > 0 {
+		last, err := typeutils.ReformatDate(resyncedAt)
+		due = due || (err == nil && !now.Before(last.AddDate(0, 0, policy.EveryDays)))
+	}
+	if !due {
+		return false
+	}
+	logger.Infof("Resyncing stream[%s] in full as its resync policy is due", stream.ID())
+	state.ResetStream(stream.Self())
+	return true
+}
+
+// endResync counts a successful run of a stream with a resync policy in its state; policies
+// added to a stream start counting from its first run with them
+func endResync(state *types.State, stream Stream, resynced bool, now time.Time) {
+	if stream.Self().StreamMetadata.Resync == nil {
+		return
+	}
+	runs, _ := typeutils.ReformatInt64(state.GetCursor(stream.Self(), types.ResyncRunsKey))
+	if resynced || state.GetCursor(stream.Self(), types.ResyncedAtKey) == nil {
+		runs = -1
+		state.SetCursor(stream.Self(), types.ResyncedAtKey, now.UTC().Format(time.RFC3339))
+	}
+	state.SetCursor(stream.Self(), types.ResyncRunsKey, runs+1)
+}
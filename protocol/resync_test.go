@@ -0,0 +1,47 @@
+package protocol
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestResync(t *testing.T) {
+	state := &types.State{RWMutex: &sync.RWMutex{}, Type: types.StreamType}
+	state.DeferCheckpoints(func() {})
+	stream := &types.ConfiguredStream{
+		Stream:         types.NewStream("orders", "public"),
+		CursorField:    "updated_at",
+		StreamMetadata: types.StreamMetadata{Resync: &types.ResyncPolicy{EveryRuns: 3, EveryDays: 7}},
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	run := func() bool {
+		resynced := beginResync(state, stream, now)
+		state.SetCursor(stream, stream.Cursor(), now.Format(time.RFC3339))
+		endResync(state, stream, resynced, now)
+		now = now.Add(time.Hour)
+		return resynced
+	}
+
+	// the first run reads in full without a cursor, so it starts the count
+	var resyncs []bool
+	for range 6 {
+		resyncs = append(resyncs, run())
+	}
+	if expected := []bool{false, false, false, true, false, false}; !slices.Equal(resyncs, expected) {
+		t.Fatalf("expected resyncs %v, got %v", expected, resyncs)
+	}
+
+	// days count too
+	stream.StreamMetadata.Resync.EveryRuns = 0
+	now = now.AddDate(0, 0, 7)
+	if !beginResync(state, stream, now) {
+		t.Fatal("expected a resync 7 days after the last one")
+	}
+	if cursor := state.GetCursor(stream, stream.Cursor()); cursor != nil {
+		t.Fatalf("expected the cursor to be reset, got %v", cursor)
+	}
+}
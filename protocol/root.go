@@ -3,12 +3,15 @@ package protocol
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/datazip-inc/olake/logger"
 	"github.com/datazip-inc/olake/types"
 	"github.com/datazip-inc/olake/utils"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -19,6 +22,8 @@ var (
 	catalogPath           string
 	batchSize             int64
 	noSave                bool
+	syncID                string
+	driverName            string
 
 	catalog           *types.Catalog
 	state             *types.State
@@ -39,11 +44,20 @@ var RootCmd = &cobra.Command{
 	Use:   "olake",
 	Short: "root command",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyFlagEnv(cmd.PersistentFlags()); err != nil {
+			return err
+		}
 		// set global variables
 		if !noSave {
 			viper.Set("CONFIG_FOLDER", filepath.Dir(configPath))
 		}
-		// logger uses CONFIG_FOLDER
+		// identifies the run in logs, stats, state and destination metadata so concurrent
+		// runs sharing a config folder stay apart
+		if syncID == "" {
+			syncID = utils.ULID()
+		}
+		viper.Set("SYNC_ID", syncID)
+		// logger uses CONFIG_FOLDER and SYNC_ID
 		logger.Init()
 
 		if len(args) == 0 {
@@ -53,26 +67,117 @@ var RootCmd = &cobra.Command{
 		if ok := utils.IsValidSubcommand(commands, args[0]); !ok {
 			return fmt.Errorf("'%s' is an invalid command. Use 'olake --help' to display usage guide", args[0])
 		}
+		// recorded with the changes the command makes in the audit log
+		viper.Set("COMMAND", args[0])
 
 		return nil
 	},
 }
 
-func CreateRootCommand(_ bool, driver any) *cobra.Command {
+// applyFlagEnv sets the flags not passed from their OLAKE_<FLAG> environment variables, e.g.
+// OLAKE_STATE_CONFIG for --state-config, so containers can be configured without arguments
+func applyFlagEnv(flags *pflag.FlagSet) error {
+	var err error
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed || err != nil {
+			return
+		}
+		name := utils.EnvName(flag.Name)
+		if value, set := os.LookupEnv(name); set {
+			if setErr := flags.Set(flag.Name, value); setErr != nil {
+				err = fmt.Errorf("invalid %s: %s", name, setErr)
+			}
+		}
+	})
+	return err
+}
+
+// configured reports if the config file of flag is passed, or its fields are set through
+// OLAKE_<FLAG>__<FIELD> environment variables
+func configured(flag, path string) bool {
+	return path != "" || utils.HasEnvFields(flag)
+}
+
+func CreateRootCommand(_ bool, driver Driver) *cobra.Command {
 	RootCmd.AddCommand(commands...)
-	connector = driver.(Driver)
+	connector = driver
 
 	return RootCmd
 }
 
+// DriverName returns the driver to run: --driver, else the driver key of the --config file,
+// else defaultName
+func DriverName(defaultName string) string {
+	if driverName != "" {
+		return driverName
+	}
+	if configured("config", configPath) {
+		// read errors are reported by the command loading the config
+		selector := struct {
+			Driver string `json:"driver"`
+		}{}
+		if err := utils.UnmarshalFileWithEnv(configPath, "config", &selector); err == nil && selector.Driver != "" {
+			return selector.Driver
+		}
+	}
+	return defaultName
+}
+
 func init() {
-	commands = append(commands, specCmd, checkCmd, discoverCmd, syncCmd)
+	commands = append(commands, specCmd, checkCmd, discoverCmd, syncCmd, benchCmd, scheduleCmd, encryptCmd, authorizeCmd, sampleCmd, purgeCmd, stateCmd)
+	stateCmd.AddCommand(stateShowCmd, stateResetCmd, stateDoctorCmd)
 	RootCmd.PersistentFlags().StringVarP(&configPath, "config", "", "", "(Required) Config for connector")
 	RootCmd.PersistentFlags().StringVarP(&destinationConfigPath, "destination", "", "", "(Required) Destination config for connector")
 	RootCmd.PersistentFlags().StringVarP(&catalogPath, "catalog", "", "", "(Required) Catalog for connector")
 	RootCmd.PersistentFlags().StringVarP(&statePath, "state", "", "", "(Required) State for connector")
+	RootCmd.PersistentFlags().StringVarP(&stateConfigPath, "state-config", "", "", "(Optional) Config of the backend state is loaded from and checkpointed to in place of --state")
+	RootCmd.PersistentFlags().StringVarP(&sampleStream, "stream", "", "", "(Required by sample and purge) Stream as namespace.name, or its name when unique; limits state show, state reset and maintain to it")
+	RootCmd.PersistentFlags().IntVarP(&sampleLimit, "limit", "", 50, "(Optional) Records printed by sample")
+	RootCmd.PersistentFlags().StringVarP(&sampleFormat, "format", "", "json", "(Optional) Output of sample: json or table")
+	RootCmd.PersistentFlags().StringVarP(&purgeKeysPath, "keys", "", "", "(Optional) Primary keys of the rows purge deletes, as a json array or json lines of objects")
+	RootCmd.PersistentFlags().StringVarP(&purgeFilter, "purge-filter", "", "", "(Optional) Expression selecting the source rows whose keys purge deletes; needs --config")
+	RootCmd.PersistentFlags().StringVarP(&purgeAuditPath, "audit-log", "", "", "(Optional) Json lines file purges are recorded in; defaults to purge_audit.jsonl next to the catalog")
+	RootCmd.PersistentFlags().StringVarP(&discoverOutput, "output", "", "", "(Optional) File discover writes the catalog to, one stream at a time, instead of printing it; defaults to catalog.json in the config folder")
+	RootCmd.PersistentFlags().IntVarP(&discoverConcurrency, "discover-concurrency", "", 0, "(Optional) Streams discover samples at once; defaults to max_threads of the source config")
 	RootCmd.PersistentFlags().Int64VarP(&batchSize, "batch", "", 10000, "(Optional) Batch size for connector")
 	RootCmd.PersistentFlags().BoolVarP(&noSave, "no-save", "", false, "(Optional) Flag to skip logging artifacts in file")
+	RootCmd.PersistentFlags().StringVarP(&driverName, "driver", "", "", "(Optional) Driver to run in binaries bundling several; defaults to the driver key of the config, then the driver of the binary")
+	RootCmd.PersistentFlags().StringVarP(&syncID, "sync-id", "", "", "(Optional) ID of the run; generated when not passed")
+	// registered here as inits of files sorted after root.go run after the Execute below
+	RootCmd.PersistentFlags().DurationVarP(&syncTimeout, "timeout", "", 0, "(Optional) Cancel the sync after reading for this long; 0 disables")
+	RootCmd.PersistentFlags().DurationVarP(&streamTimeout, "stream-timeout", "", 0, "(Optional) Cancel and fail a full refresh or incremental stream after reading it for this long while other streams continue; 0 disables")
+	RootCmd.PersistentFlags().BoolVarP(&continueOnError, "continue-on-error", "", false, "(Optional) Record failed streams and sync the rest; the sync exits with code 2 when streams failed")
+	RootCmd.PersistentFlags().BoolVarP(&forceLock, "force", "", false, "(Optional) Take over the lock left by a sync of the same connection that is no longer running")
+	RootCmd.PersistentFlags().BoolVarP(&stateRepair, "repair", "", false, "(Optional) Reset the state of the streams state doctor finds issues with, so they are synced again from a snapshot")
+	RootCmd.PersistentFlags().StringVarP(&naiveTimestampPolicy, "naive-timestamps", "", string(types.NaiveTimestampsUTC), "(Optional) How timestamps stored without a time zone are read: utc, source (in the time zone of the source server) or passthrough (as text); overridden per stream by naive_timestamps")
+	RootCmd.PersistentFlags().StringVarP(&sourceTimezone, "source-timezone", "", "", "(Optional) Time zone of the source, e.g. Europe/Berlin, for naive timestamps read in it; defaults to the time zone the driver reports")
+	RootCmd.PersistentFlags().BoolVarP(&interactive, "interactive", "", false, "(Optional) Show progress bars per stream when stdout is a terminal; only warnings and errors are printed above them")
+	RootCmd.PersistentFlags().String("log-file", "", "(Optional) Path of the log file; defaults to logs/sync_<timestamp>_<sync id>/olake.log in the config folder")
+	RootCmd.PersistentFlags().Bool("no-log-file", false, "(Optional) Log to the console only, e.g. in read-only containers")
+	RootCmd.PersistentFlags().Int("log-max-size", 100, "(Optional) Size in MB at which the log file is rotated")
+	RootCmd.PersistentFlags().Int("log-max-backups", 5, "(Optional) Number of rotated log files to keep; 0 keeps all")
+	RootCmd.PersistentFlags().Int("log-max-age", 30, "(Optional) Days to keep rotated log files; 0 keeps them regardless of age")
+	RootCmd.PersistentFlags().Bool("log-compress", true, "(Optional) Gzip rotated log files")
+	RootCmd.PersistentFlags().Int("log-throttle", 10, "(Optional) Repetitive messages, e.g. of dropped records, logged per stream every minute; 0 logs all")
+	RootCmd.PersistentFlags().Int("log-queue-size", 10000, "(Optional) Log messages buffered while the console and log file catch up; info messages are dropped once full, 0 logs synchronously")
+	RootCmd.PersistentFlags().Duration("stats-interval", 2*time.Second, "(Optional) Interval at which stats.json is written and stats are pushed")
+	RootCmd.PersistentFlags().Bool("no-stats-history", false, "(Optional) Skip appending every stats sample to stats_history_<sync id>.jsonl in the config folder")
+	RootCmd.PersistentFlags().Int("stats-history-max-size", 10, "(Optional) Size in MB at which the stats history is rotated")
+	for key, flag := range map[string]string{
+		logger.LogFileKey:              "log-file",
+		logger.LogDisabledKey:          "no-log-file",
+		logger.LogMaxSizeKey:           "log-max-size",
+		logger.LogMaxBackupsKey:        "log-max-backups",
+		logger.LogMaxAgeKey:            "log-max-age",
+		logger.LogCompressKey:          "log-compress",
+		logger.LogQueueSizeKey:         "log-queue-size",
+		logger.LogThrottleKey:          "log-throttle",
+		logger.StatsIntervalKey:        "stats-interval",
+		logger.StatsHistoryDisabledKey: "no-stats-history",
+		logger.StatsHistoryMaxSizeKey:  "stats-history-max-size",
+	} {
+		_ = viper.BindPFlag(key, RootCmd.PersistentFlags().Lookup(flag))
+	}
 	// Disable Cobra CLI's built-in usage and error handling
 	RootCmd.SilenceUsage = true
 	RootCmd.SilenceErrors = true
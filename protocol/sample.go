@@ -0,0 +1,433 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/goccy/go-json"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	sampleFormatJSON  = "json"
+	sampleFormatTable = "table"
+
+	// longer values are cut in table cells
+	sampleCellWidth = 40
+)
+
+var (
+	sampleStream string
+	sampleLimit  int
+	sampleFormat string
+)
+
+// sampleCmd reads the first records of a stream through the transforms of the sync and prints
+// them, so selectors and transforms can be checked before a full run; nothing is written to
+// the destination and no state is saved
+var sampleCmd = &cobra.Command{
+	Use:   "sample",
+	Short: "Olake sample command; prints the first --limit records of --stream as they'd reach the destination, as json or a table",
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		if !configured("config", configPath) {
+			return fmt.Errorf("--config not passed")
+		} else if sampleStream == "" {
+			return fmt.Errorf("--stream not passed")
+		}
+		if sampleLimit <= 0 {
+			return fmt.Errorf("--limit must be positive")
+		}
+		if sampleFormat != sampleFormatJSON && sampleFormat != sampleFormatTable {
+			return fmt.Errorf("unsupported format[%s]; expected json or table", sampleFormat)
+		}
+		if err := utils.UnmarshalFileWithEnv(configPath, "config", connector.GetConfigRef()); err != nil {
+			return err
+		}
+		// the transforms of the destination apply when passed
+		if configured("destination", destinationConfigPath) {
+			destinationConfig = &types.WriterConfig{}
+			if err := utils.UnmarshalFileWithEnv(destinationConfigPath, "destination", destinationConfig); err != nil {
+				return err
+			}
+		}
+		if catalogPath != "" {
+			catalog = &types.Catalog{}
+			if err := types.CatalogFormat.LoadFile(catalogPath, catalog); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if err := connector.Setup(); err != nil {
+			return err
+		}
+		stream, err := sampleSourceStream()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
+		sampler := &sampler{limit: sampleLimit, full: cancel}
+		pool, err := newSamplePool(cmd.Context(), destinationConfig, sampler)
+		if err != nil {
+			return err
+		}
+		if err := pool.setSourceTimezone(connector); err != nil {
+			return err
+		}
+
+		// samples start from the beginning and never persist state
+		connector.SetupState(&types.State{RWMutex: &sync.RWMutex{}, Type: types.StreamType})
+		logger.Infof("Sampling %d records of stream[%s] in %s", sampleLimit, stream.ID(), stream.GetSyncMode())
+		readErr := connector.Read(ctx, pool, stream)
+		waitErr := pool.Wait()
+		// reading is cut short once the limit is reached
+		if !sampler.done() {
+			if readErr != nil {
+				return fmt.Errorf("error occurred while reading records: %s", readErr)
+			}
+			if waitErr != nil {
+				return fmt.Errorf("error occurred in writer pool: %s", waitErr)
+			}
+		}
+
+		records := sampler.snapshot()
+		if sampleFormat == sampleFormatTable {
+			return printSampleTable(cmd.OutOrStdout(), records)
+		}
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return err
+	},
+}
+
+// sampleSourceStream returns the stream to sample, configured by the catalog when passed and
+// read in full refresh otherwise; --stream is namespace.name, or the name when it's unique
+func sampleSourceStream() (*types.ConfiguredStream, error) {
+	streams, err := discoverStreams(false)
+	if err != nil {
+		return nil, err
+	}
+	var source *types.Stream
+	for _, stream := range streams {
+		if stream.ID() != sampleStream && stream.Name != sampleStream {
+			continue
+		}
+		if source != nil {
+			return nil, fmt.Errorf("stream[%s] exists in several namespaces; pass namespace.name", sampleStream)
+		}
+		source = stream
+	}
+	if source == nil {
+		return nil, fmt.Errorf("stream[%s] not found in source", sampleStream)
+	}
+
+	configured := source.Wrap(0)
+	if catalog != nil {
+		for _, elem := range catalog.Streams {
+			if elem.ID() != source.ID() {
+				continue
+			}
+			for _, streamMetadata := range catalog.SelectedStreams[elem.Namespace()] {
+				if streamMetadata.StreamName == elem.Name() {
+					elem.StreamMetadata = streamMetadata
+				}
+			}
+			if err := elem.Validate(source); err != nil {
+				return nil, fmt.Errorf("configured stream %s found invalid: %s", elem.ID(), err)
+			}
+			elem.ApplyOverrides()
+			configured = elem
+		}
+	}
+	// change streams never end; their initial snapshot is sampled instead
+	if configured.Stream.SyncMode == "" || configured.Stream.SyncMode == types.CDC {
+		configured.Stream.SyncMode = types.FULLREFRESH
+	}
+	if !source.SupportedSyncModes.Exists(configured.Stream.SyncMode) {
+		return nil, fmt.Errorf("stream[%s] can't be read in %s", source.ID(), configured.Stream.SyncMode)
+	}
+	// unchanged rows would be skipped against the hash index of the last sync
+	configured.StreamMetadata.ChangeDetection = false
+	return configured, nil
+}
+
+// sampler keeps the records written by the sample pool until limit is reached
+type sampler struct {
+	mutex   sync.Mutex
+	limit   int
+	records []types.Record
+	full    context.CancelFunc // stops reading once limit is reached
+}
+
+func (s *sampler) add(record types.RawRecord) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.records) >= s.limit {
+		return
+	}
+	s.records = append(s.records, record.Data)
+	if len(s.records) == s.limit {
+		s.full()
+	}
+}
+
+func (s *sampler) done() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.records) >= s.limit
+}
+
+func (s *sampler) snapshot() []types.Record {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]types.Record(nil), s.records...)
+}
+
+// newSamplePool returns a pool transforming records as the sync does and handing them to
+// sampler in place of the destination; config may be nil, records are then sampled as read.
+// The destination is never checked or connected to.
+func newSamplePool(ctx context.Context, config *types.WriterConfig, sampler *sampler) (*WriterPool, error) {
+	newDestination := func() Writer { return nil }
+	writerConfig := any(map[string]any{})
+	if config != nil {
+		newfunc, found := RegisteredWriters[config.Type]
+		if !found {
+			return nil, fmt.Errorf("invalid destination type has been passed [%s]", config.Type)
+		}
+		newDestination, writerConfig = newfunc, config.WriterConfig
+	} else {
+		config = &types.WriterConfig{}
+	}
+	capture := &sampleWriter{destination: newDestination(), sampler: sampler}
+	if capture.destination != nil {
+		if err := utils.Unmarshal(writerConfig, capture.GetConfigRef()); err != nil {
+			return nil, err
+		}
+	}
+
+	coercions, err := typeutils.ResolveCoercions(capture.SupportedTypes(), config.Coercion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve type coercions of destination[%s]: %s", config.Type, err)
+	}
+	contract := config.Contract
+	if contract != nil {
+		if err := contract.Validate(); err != nil {
+			return nil, err
+		}
+		// samples have no dead letter queue; violations are dropped with a warning
+		if contract.OnViolation == types.ViolationDeadLetter {
+			dropping := *contract
+			dropping.OnViolation = types.ViolationDrop
+			contract = &dropping
+		}
+	}
+	if config.Naming != nil {
+		if err := config.Naming.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	group, ctx := errgroup.WithContext(ctx)
+	return &WriterPool{
+		config: writerConfig,
+		init: func() Writer {
+			return &sampleWriter{destination: newDestination(), sampler: sampler}
+		},
+		group:     group,
+		groupCtx:  ctx,
+		contract:  contract,
+		naming:    config.Naming,
+		metadata:  config.MetadataColumns,
+		coercions: coercions,
+		lifecycle: &lifecycle{writer: capture},
+	}, nil
+}
+
+// sampleConfig is the config of the sample writer without a destination
+type sampleConfig struct{}
+
+func (c *sampleConfig) Validate() error {
+	return nil
+}
+
+// sampleWriter hands records to the sampler; it normalizes and flattens like destination,
+// which is never set up, or passes records as read when destination is nil
+type sampleWriter struct {
+	destination Writer
+	config      Config
+	sampler     *sampler
+}
+
+func (s *sampleWriter) GetConfigRef() Config {
+	s.config = &sampleConfig{}
+	if s.destination != nil {
+		s.config = s.destination.GetConfigRef()
+	}
+	return s.config
+}
+
+func (s *sampleWriter) Spec() any {
+	return sampleConfig{}
+}
+
+func (s *sampleWriter) Check() error {
+	return nil
+}
+
+func (s *sampleWriter) Type() string {
+	if s.destination != nil {
+		return s.destination.Type()
+	}
+	return "sample"
+}
+
+// Setup applies the config defaults the destination's normalization may depend on
+func (s *sampleWriter) Setup(_ Stream, _ *Options) error {
+	return s.config.Validate()
+}
+
+func (s *sampleWriter) Write(_ context.Context, record types.RawRecord) error {
+	s.sampler.add(record)
+	return nil
+}
+
+func (s *sampleWriter) Delete(_ context.Context, record types.RawRecord) error {
+	s.sampler.add(record)
+	return nil
+}
+
+// PartialRecords is supported since samples show the columns records carry
+func (s *sampleWriter) PartialRecords() bool {
+	return true
+}
+
+func (s *sampleWriter) Normalization() bool {
+	return s.destination != nil && s.destination.Normalization()
+}
+
+func (s *sampleWriter) SupportedTypes() *types.Set[types.DataType] {
+	if s.destination != nil {
+		return s.destination.SupportedTypes()
+	}
+	return types.NewSet(types.DataTypes...)
+}
+
+func (s *sampleWriter) Flattener() FlattenFunction {
+	if s.destination != nil {
+		return s.destination.Flattener()
+	}
+	return typeutils.NewFlattener().Flatten
+}
+
+func (s *sampleWriter) EvolveSchema(_, _ bool, _ map[string]*types.Property, _ types.Record) error {
+	return nil
+}
+
+func (s *sampleWriter) Close() error {
+	return nil
+}
+
+func (s *sampleWriter) BeginSync(_ context.Context) error {
+	return nil
+}
+
+func (s *sampleWriter) BeginStream(_ context.Context, _ Stream) error {
+	return nil
+}
+
+func (s *sampleWriter) EndStream(_ context.Context, _ Stream) error {
+	return nil
+}
+
+func (s *sampleWriter) EndSync(_ context.Context, _ error) error {
+	return nil
+}
+
+// printSampleTable prints records as a table with a column per key, olake columns last;
+// long values are cut
+func printSampleTable(out io.Writer, records []types.Record) error {
+	if len(records) == 0 {
+		_, err := fmt.Fprintln(out, "no records read")
+		return err
+	}
+	seen := make(map[string]bool)
+	var columns, olake []string
+	for _, record := range records {
+		for column := range record {
+			if seen[column] {
+				continue
+			}
+			seen[column] = true
+			if olakeColumns[column] {
+				olake = append(olake, column)
+			} else {
+				columns = append(columns, column)
+			}
+		}
+	}
+	sort.Strings(columns)
+	sort.Strings(olake)
+	columns = append(columns, olake...)
+
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(table, strings.Join(columns, "\t")); err != nil {
+		return err
+	}
+	cells := make([]string, len(columns))
+	for _, record := range records {
+		for idx, column := range columns {
+			cells[idx] = sampleCell(record[column])
+		}
+		if _, err := fmt.Fprintln(table, strings.Join(cells, "\t")); err != nil {
+			return err
+		}
+	}
+	return table.Flush()
+}
+
+var sampleLineBreaks = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ")
+
+// sampleCell renders a value on one line of at most sampleCellWidth characters
+func sampleCell(value any) string {
+	var cell string
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		cell = v.Format(time.RFC3339Nano)
+	case string:
+		cell = v
+	case map[string]any, []any, types.Record:
+		data, err := json.Marshal(v)
+		if err != nil {
+			cell = fmt.Sprint(v)
+		} else {
+			cell = string(data)
+		}
+	default:
+		cell = fmt.Sprint(v)
+	}
+	cell = sampleLineBreaks.Replace(cell)
+	if utf8.RuneCountInString(cell) > sampleCellWidth {
+		cell = string([]rune(cell)[:sampleCellWidth-1]) + "…"
+	}
+	return cell
+}
@@ -0,0 +1,68 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/datazip-inc/olake/constants"
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestSamplePool(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sampler := &sampler{limit: 2, full: cancel}
+	pool, err := newSamplePool(context.Background(), nil, sampler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := &types.ConfiguredStream{Stream: types.NewStream("users", "public")}
+	thread, err := pool.NewThread(ctx, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id := 0; id < 5; id++ {
+		// inserts fail once the limit cancelled reading
+		if err := thread.Insert(types.CreateRawRecord("id", map[string]any{"id": id}, 0)); err != nil {
+			break
+		}
+	}
+	thread.Close()
+	if err := pool.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	records := sampler.snapshot()
+	if len(records) != 2 || records[0]["id"] != 0 || records[1]["id"] != 1 {
+		t.Fatalf("expected the first 2 records, got %v", records)
+	}
+	if ctx.Err() == nil {
+		t.Fatal("expected reading to be cancelled once the limit was reached")
+	}
+}
+
+func TestPrintSampleTable(t *testing.T) {
+	records := []types.Record{
+		{"name": "ada", constants.OlakeID: "1", "tags": []any{"a", "b"}},
+		{"name": strings.Repeat("x", 50), "bio": "line\nbreak", constants.OlakeID: "2"},
+	}
+	var out bytes.Buffer
+	if err := printSampleTable(&out, records); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and 2 rows, got %q", out.String())
+	}
+	if header := strings.Fields(lines[0]); strings.Join(header, ",") != "bio,name,tags,"+constants.OlakeID {
+		t.Fatalf("expected sorted columns with olake columns last, got %v", header)
+	}
+	if !strings.Contains(lines[1], `["a","b"]`) {
+		t.Fatalf("expected arrays as json, got %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "line break") || !strings.Contains(lines[2], strings.Repeat("x", sampleCellWidth-1)+"…") {
+		t.Fatalf("expected values on one line and cut, got %q", lines[2])
+	}
+}
@@ -0,0 +1,475 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/goccy/go-json"
+	"github.com/robfig/cron/v3"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	runSucceeded = "succeeded"
+	runFailed    = "failed"
+	runSkipped   = "skipped"
+)
+
+var jobNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// runningSchedule is the schedule of the running daemon, whose jobs the api triggers
+var runningSchedule atomic.Pointer[activeSchedule]
+
+type activeSchedule struct {
+	ctx      context.Context
+	executor syncExecutor
+	running  *sync.WaitGroup
+	jobs     []*ScheduleJob
+}
+
+// syncExecutor starts the syncs of scheduled jobs
+type syncExecutor interface {
+	// execute runs the sync with args to completion and returns its exit code; -1 when the
+	// sync didn't exit on its own
+	execute(job *ScheduleJob, syncID string, args []string) (int, error)
+}
+
+// processExecutor runs every sync in a child process of the scheduler, so every run starts
+// with fresh state
+type processExecutor struct {
+	executable string
+}
+
+func (p *processExecutor) execute(job *ScheduleJob, _ string, args []string) (int, error) {
+	command := exec.Command(p.executable, args...)
+	// the scheduler holds the debug server port, runs of different jobs can overlap
+	command.Env = append(os.Environ(), debugPortEnv+"=0")
+	if job.workspace != nil {
+		command.Dir = job.workspace.Folder
+		command.Env = append(command.Env, job.workspace.env()...)
+	}
+	// output is discarded; the sync logs to its own log folder
+	err := command.Run()
+	if err == nil {
+		return 0, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), err
+	}
+	return -1, err
+}
+
+// ScheduleConfig lists the syncs run by the schedule command
+type ScheduleConfig struct {
+	Jobs []*ScheduleJob `json:"jobs,omitempty" validate:"dive"`
+	// teams sharing the scheduler, each with jobs isolated from the others
+	Workspaces []*Workspace `json:"workspaces,omitempty" validate:"dive"`
+	// launches every sync as a kubernetes job instead of a child process
+	Kubernetes *KubernetesConfig `json:"kubernetes,omitempty"`
+}
+
+// Workspace isolates the jobs of a team: their files must be inside its folder, they use its
+// state backend and credentials, and no more than its quota of them run at once
+type Workspace struct {
+	Name string `json:"name" validate:"required"`
+	// config folder of the workspace; relative job paths resolve in it, and the states, logs
+	// and run history of its jobs are kept in it. Defaults to workspaces/<name> next to the
+	// schedule config
+	Folder string `json:"folder,omitempty"`
+	// state backend config passed to the syncs as --state-config
+	StateConfig string `json:"state_config,omitempty"`
+	// environment variables set for the syncs of the workspace only, e.g. OLAKE_ENCRYPTION_KEY
+	// or cloud credentials
+	Env map[string]string `json:"env,omitempty"`
+	// syncs of the workspace running at once; runs wait for a slot, 0 means unlimited
+	MaxConcurrentRuns int            `json:"max_concurrent_runs,omitempty"`
+	Jobs              []*ScheduleJob `json:"jobs" validate:"required,min=1,dive"`
+
+	slots chan struct{}
+}
+
+// ScheduleJob is a sync run on a cron schedule
+type ScheduleJob struct {
+	Name string `json:"name" validate:"required"`
+	// standard five field cron expression or a descriptor such as @hourly
+	Cron        string `json:"cron" validate:"required"`
+	Config      string `json:"config" validate:"required"`
+	Destination string `json:"destination" validate:"required"`
+	Catalog     string `json:"catalog" validate:"required"`
+	// defaults to the state.json written by the previous run next to the config
+	State string `json:"state,omitempty"`
+	// random delay of up to this many seconds before each run; spreads jobs sharing a schedule
+	Jitter int `json:"jitter,omitempty"`
+	// additional sync flags, e.g. --continue-on-error
+	Args []string `json:"args,omitempty"`
+
+	schedule  cron.Schedule
+	running   atomic.Bool
+	workspace *Workspace
+}
+
+// JobRun is a line of the job history kept in schedule_history/<job>.jsonl
+type JobRun struct {
+	SyncID      string     `json:"sync_id,omitempty"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Status      string     `json:"status"`
+	ExitCode    int        `json:"exit_code"`
+	Error       string     `json:"error,omitempty"`
+}
+
+func (c *ScheduleConfig) Validate() error {
+	if err := utils.Validate(c); err != nil {
+		return err
+	}
+	if len(c.Jobs) == 0 && len(c.Workspaces) == 0 {
+		return fmt.Errorf("schedule has no jobs")
+	}
+	if err := validateJobs(c.Jobs); err != nil {
+		return err
+	}
+	if c.Kubernetes != nil {
+		if err := c.Kubernetes.Validate(); err != nil {
+			return fmt.Errorf("invalid kubernetes executor: %s", err)
+		}
+	}
+	names := make(map[string]bool)
+	for _, workspace := range c.Workspaces {
+		if !jobNamePattern.MatchString(workspace.Name) {
+			return fmt.Errorf("invalid workspace name[%s]; only letters, digits, _ and - are allowed", workspace.Name)
+		}
+		if names[workspace.Name] {
+			return fmt.Errorf("duplicate workspace[%s] in schedule", workspace.Name)
+		}
+		names[workspace.Name] = true
+		if err := workspace.validate(); err != nil {
+			return fmt.Errorf("workspace[%s]: %s", workspace.Name, err)
+		}
+	}
+	return nil
+}
+
+// AllJobs returns the jobs of the schedule and of its workspaces
+func (c *ScheduleConfig) AllJobs() []*ScheduleJob {
+	jobs := append([]*ScheduleJob{}, c.Jobs...)
+	for _, workspace := range c.Workspaces {
+		jobs = append(jobs, workspace.Jobs...)
+	}
+	return jobs
+}
+
+// validate resolves the paths of the workspace and its jobs in its folder and rejects paths
+// leading out of it, so a workspace can't read the configs or state of another
+func (w *Workspace) validate() error {
+	if w.MaxConcurrentRuns < 0 {
+		return fmt.Errorf("negative max_concurrent_runs")
+	}
+	if w.MaxConcurrentRuns > 0 {
+		w.slots = make(chan struct{}, w.MaxConcurrentRuns)
+	}
+	if w.Folder == "" {
+		w.Folder = filepath.Join("workspaces", w.Name)
+	}
+	if !filepath.IsAbs(w.Folder) {
+		w.Folder = filepath.Join(viper.GetString("CONFIG_FOLDER"), w.Folder)
+	}
+	folder, err := filepath.Abs(w.Folder)
+	if err != nil {
+		return err
+	}
+	w.Folder = folder
+	if err := os.MkdirAll(w.Folder, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create folder[%s]: %s", w.Folder, err)
+	}
+	if w.StateConfig, err = w.resolve(w.StateConfig); err != nil {
+		return err
+	}
+	if err := validateJobs(w.Jobs); err != nil {
+		return err
+	}
+	for _, job := range w.Jobs {
+		job.workspace = w
+		for _, path := range []*string{&job.Config, &job.Destination, &job.Catalog, &job.State} {
+			if *path, err = w.resolve(*path); err != nil {
+				return fmt.Errorf("job[%s]: %s", job.Name, err)
+			}
+		}
+		if job.State != "" && w.StateConfig != "" {
+			return fmt.Errorf("job[%s] sets a state while the workspace keeps state in a backend", job.Name)
+		}
+	}
+	return nil
+}
+
+// resolve returns path inside the folder of the workspace; empty paths stay empty
+func (w *Workspace) resolve(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(w.Folder, path)
+	}
+	path = filepath.Clean(path)
+	if relative, err := filepath.Rel(w.Folder, path); err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path[%s] is outside the workspace folder[%s]", path, w.Folder)
+	}
+	return path, nil
+}
+
+// acquire waits for a run slot of the workspace; false when ctx is done first
+func (w *Workspace) acquire(ctx context.Context, job string) bool {
+	if w.slots == nil {
+		return true
+	}
+	select {
+	case w.slots <- struct{}{}:
+		return true
+	default:
+	}
+	logger.Infof("Run of job[%s] waits for one of the %d run slots of workspace[%s]", job, w.MaxConcurrentRuns, w.Name)
+	select {
+	case w.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (w *Workspace) release() {
+	if w.slots != nil {
+		<-w.slots
+	}
+}
+
+// env returns the environment variables of the workspace, sorted
+func (w *Workspace) env() []string {
+	env := make([]string, 0, len(w.Env))
+	for key, value := range w.Env {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+func validateJobs(jobs []*ScheduleJob) error {
+	names := make(map[string]bool)
+	for _, job := range jobs {
+		if !jobNamePattern.MatchString(job.Name) {
+			return fmt.Errorf("invalid job name[%s]; only letters, digits, _ and - are allowed", job.Name)
+		}
+		if names[job.Name] {
+			return fmt.Errorf("duplicate job[%s] in schedule", job.Name)
+		}
+		names[job.Name] = true
+		schedule, err := cron.ParseStandard(job.Cron)
+		if err != nil {
+			return fmt.Errorf("invalid cron expression[%s] of job[%s]: %s", job.Cron, job.Name, err)
+		}
+		job.schedule = schedule
+		if job.Jitter < 0 {
+			return fmt.Errorf("negative jitter of job[%s]", job.Name)
+		}
+	}
+	return nil
+}
+
+// scheduleCmd runs syncs on cron schedules until interrupted
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Olake schedule command; runs the syncs listed in --config on cron schedules",
+	PreRunE: func(_ *cobra.Command, _ []string) error {
+		if !configured("config", configPath) {
+			return fmt.Errorf("--config not passed")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		config := &ScheduleConfig{}
+		if err := utils.UnmarshalFileWithEnv(configPath, "config", config); err != nil {
+			return err
+		}
+		if err := config.Validate(); err != nil {
+			return err
+		}
+		executor, err := newSyncExecutor(config)
+		if err != nil {
+			return err
+		}
+
+		// running syncs finish before the daemon exits
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		var running sync.WaitGroup
+		runningSchedule.Store(&activeSchedule{ctx: ctx, executor: executor, running: &running, jobs: config.AllJobs()})
+		defer runningSchedule.Store(nil)
+		for _, job := range config.AllJobs() {
+			logger.Infof("Scheduled job[%s] with cron[%s], next run at %s", job.ID(), job.Cron, job.schedule.Next(time.Now()).Format(time.RFC3339))
+			running.Add(1)
+			go func() {
+				defer running.Done()
+				job.loop(ctx, executor, &running)
+			}()
+		}
+		<-ctx.Done()
+		logger.Info("Stopping scheduler; waiting for running syncs to finish")
+		running.Wait()
+		return nil
+	},
+}
+
+// newSyncExecutor launches syncs as kubernetes jobs when the schedule configures them, in child
+// processes otherwise
+func newSyncExecutor(config *ScheduleConfig) (syncExecutor, error) {
+	if config.Kubernetes != nil {
+		return newKubernetesExecutor(config.Kubernetes)
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate the olake executable: %s", err)
+	}
+	return &processExecutor{executable: executable}, nil
+}
+
+// ID identifies the job in logs; jobs of workspaces are prefixed with the workspace name
+func (j *ScheduleJob) ID() string {
+	if j.workspace == nil {
+		return j.Name
+	}
+	return j.workspace.Name + "/" + j.Name
+}
+
+// loop triggers the job at every scheduled time until ctx is done; a trigger while the
+// previous run is still going is skipped
+func (j *ScheduleJob) loop(ctx context.Context, executor syncExecutor, running *sync.WaitGroup) {
+	for {
+		scheduledAt := j.schedule.Next(time.Now())
+		delay := time.Until(scheduledAt)
+		if j.Jitter > 0 {
+			delay += time.Duration(rand.Int64N(int64(j.Jitter) * int64(time.Second)))
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !j.start(ctx, executor, running, scheduledAt) {
+			logger.Warnf("Skipping run of job[%s] scheduled at %s; previous run still in progress", j.ID(), scheduledAt.Format(time.RFC3339))
+			j.record(&JobRun{ScheduledAt: scheduledAt, Status: runSkipped})
+		}
+	}
+}
+
+// start runs the job in the background; false when its previous run is still in progress
+func (j *ScheduleJob) start(ctx context.Context, executor syncExecutor, running *sync.WaitGroup, scheduledAt time.Time) bool {
+	if !j.running.CompareAndSwap(false, true) {
+		return false
+	}
+	running.Add(1)
+	go func() {
+		defer running.Done()
+		defer j.running.Store(false)
+		if workspace := j.workspace; workspace != nil {
+			if !workspace.acquire(ctx, j.ID()) {
+				j.record(&JobRun{ScheduledAt: scheduledAt, Status: runSkipped, Error: "scheduler stopped while waiting for a run slot"})
+				return
+			}
+			defer workspace.release()
+		}
+		j.record(j.run(executor, scheduledAt))
+	}()
+	return true
+}
+
+// run executes the sync through the executor; the run isn't tied to the scheduler context so a
+// shutdown doesn't interrupt it
+func (j *ScheduleJob) run(executor syncExecutor, scheduledAt time.Time) *JobRun {
+	startedAt := time.Now().UTC()
+	run := &JobRun{SyncID: utils.ULID(), ScheduledAt: scheduledAt, StartedAt: &startedAt}
+	args := []string{"sync", "--config", j.Config, "--destination", j.Destination, "--catalog", j.Catalog, "--sync-id", run.SyncID}
+	if j.workspace != nil && j.workspace.StateConfig != "" {
+		args = append(args, "--state-config", j.workspace.StateConfig)
+	} else if state := j.statePath(); state != "" {
+		args = append(args, "--state", state)
+	}
+	args = append(args, j.Args...)
+
+	logger.Infof("Starting run[%s] of job[%s]", run.SyncID, j.ID())
+	exitCode, err := executor.execute(j, run.SyncID, args)
+	finishedAt := time.Now().UTC()
+	run.FinishedAt = &finishedAt
+	run.Status = runSucceeded
+	if err != nil {
+		run.Status = runFailed
+		run.Error = err.Error()
+		run.ExitCode = exitCode
+		switch run.ExitCode {
+		case PartialSyncExitCode:
+			run.Status = syncPartial
+		case QualityCheckExitCode:
+			run.Status = syncQuality
+		}
+	}
+	logger.Infof("Run[%s] of job[%s] %s in %s", run.SyncID, j.ID(), run.Status, finishedAt.Sub(startedAt))
+	return run
+}
+
+// statePath returns the configured state or the one the previous run left in its config folder
+func (j *ScheduleJob) statePath() string {
+	if j.State != "" {
+		return j.State
+	}
+	previous := filepath.Join(filepath.Dir(j.Config), "state.json")
+	if _, err := os.Stat(previous); err == nil {
+		return previous
+	}
+	return ""
+}
+
+// record appends the run to the job history, kept in the folder of its workspace
+func (j *ScheduleJob) record(run *JobRun) {
+	folder := viper.GetString("CONFIG_FOLDER")
+	if j.workspace != nil {
+		folder = j.workspace.Folder
+	}
+	directory := filepath.Join(folder, "schedule_history")
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		logger.Warnf("failed to create schedule history folder[%s]: %s", directory, err)
+		return
+	}
+	data, err := json.Marshal(run)
+	if err != nil {
+		logger.Warnf("failed to marshal run of job[%s]: %s", j.ID(), err)
+		return
+	}
+	path := filepath.Join(directory, j.Name+".jsonl")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		logger.Warnf("failed to open schedule history[%s]: %s", path, err)
+		return
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		logger.Warnf("failed to write schedule history[%s]: %s", path, err)
+	}
+}
@@ -0,0 +1,114 @@
+package protocol
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestScheduleConfigValidate(t *testing.T) {
+	newConfig := func(name, expression string) *ScheduleConfig {
+		return &ScheduleConfig{Jobs: []*ScheduleJob{{Name: name, Cron: expression, Config: "c.json", Destination: "d.json", Catalog: "s.json"}}}
+	}
+
+	config := newConfig("hourly", "0 * * * *")
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+	if next := config.Jobs[0].schedule.Next(from); !next.Equal(time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected next run %s", next)
+	}
+
+	if err := newConfig("hourly", "every hour").Validate(); err == nil {
+		t.Fatal("expected invalid cron expression to be rejected")
+	}
+	if err := newConfig("../hourly", "@hourly").Validate(); err == nil {
+		t.Fatal("expected job name with path separators to be rejected")
+	}
+	duplicate := newConfig("hourly", "@hourly")
+	duplicate.Jobs = append(duplicate.Jobs, newConfig("hourly", "@daily").Jobs...)
+	if err := duplicate.Validate(); err == nil {
+		t.Fatal("expected duplicate job names to be rejected")
+	}
+}
+
+func TestScheduleJobHistory(t *testing.T) {
+	folder := t.TempDir()
+	viper.Set("CONFIG_FOLDER", folder)
+	job := &ScheduleJob{Name: "hourly", Config: filepath.Join(folder, "config.json")}
+
+	if state := job.statePath(); state != "" {
+		t.Fatalf("expected no state before the first run, got %s", state)
+	}
+	if err := os.WriteFile(filepath.Join(folder, "state.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if state := job.statePath(); state != filepath.Join(folder, "state.json") {
+		t.Fatalf("expected state of the previous run, got %s", state)
+	}
+
+	job.record(&JobRun{SyncID: "first", Status: runSucceeded})
+	job.record(&JobRun{Status: runSkipped})
+	data, err := os.ReadFile(filepath.Join(folder, "schedule_history", "hourly.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"sync_id":"first"`) || !strings.Contains(lines[1], `"status":"skipped"`) {
+		t.Fatalf("unexpected history: %s", data)
+	}
+}
+
+func TestScheduleWorkspaces(t *testing.T) {
+	folder := t.TempDir()
+	viper.Set("CONFIG_FOLDER", folder)
+	newConfig := func(config string) *ScheduleConfig {
+		return &ScheduleConfig{Workspaces: []*Workspace{{
+			Name:              "analytics",
+			StateConfig:       "state_config.json",
+			Env:               map[string]string{"B": "2", "A": "1"},
+			MaxConcurrentRuns: 1,
+			Jobs:              []*ScheduleJob{{Name: "hourly", Cron: "@hourly", Config: config, Destination: "d.json", Catalog: "s.json"}},
+		}}}
+	}
+
+	config := newConfig("c.json")
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	workspace := config.Workspaces[0]
+	job := config.AllJobs()[0]
+	if workspace.Folder != filepath.Join(folder, "workspaces", "analytics") || job.Config != filepath.Join(workspace.Folder, "c.json") {
+		t.Fatalf("expected paths resolved in the workspace folder, got %s and %s", workspace.Folder, job.Config)
+	}
+	if job.ID() != "analytics/hourly" || strings.Join(workspace.env(), ",") != "A=1,B=2" {
+		t.Fatalf("unexpected job id %s or env %v", job.ID(), workspace.env())
+	}
+	for _, path := range []string{"../other/c.json", filepath.Join(folder, "c.json")} {
+		if err := newConfig(path).Validate(); err == nil {
+			t.Fatalf("expected path %s outside the workspace to be rejected", path)
+		}
+	}
+
+	// a second run waits for the slot of the first
+	ctx, cancel := context.WithCancel(context.Background())
+	if !workspace.acquire(ctx, job.ID()) {
+		t.Fatal("expected a free slot")
+	}
+	cancel()
+	if workspace.acquire(ctx, job.ID()) {
+		t.Fatal("expected the quota to hold back a second run")
+	}
+	workspace.release()
+
+	job.record(&JobRun{SyncID: "first", Status: runSucceeded})
+	if _, err := os.Stat(filepath.Join(workspace.Folder, "schedule_history", "hourly.jsonl")); err != nil {
+		t.Fatalf("expected history in the workspace folder: %s", err)
+	}
+}
@@ -9,10 +9,12 @@ import (
 
 	"github.com/datazip-inc/olake/jsonschema"
 	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/audit"
 	"github.com/datazip-inc/olake/types"
 	"github.com/datazip-inc/olake/utils"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var (
@@ -24,7 +26,7 @@ var (
 var specCmd = &cobra.Command{
 	Use:   "spec",
 	Short: "spec command",
-	RunE: func(_ *cobra.Command, _ []string) error {
+	RunE: func(cmd *cobra.Command, _ []string) error {
 		wd, _ := os.Getwd()
 		specfile := path.Join(wd, "generated.json")
 		spec := make(map[string]interface{})
@@ -78,10 +80,16 @@ var specCmd = &cobra.Command{
 			Type: types.SpecMessage,
 		}
 		logger.Info(message)
+		configFile := path.Join(viper.GetString("CONFIG_FOLDER"), "config.json")
+		before, _ := os.ReadFile(configFile)
 		err := logger.FileLogger(message.Spec, "config", ".json")
 		if err != nil {
 			logger.Fatalf("failed to create spec file: %s", err)
 		}
+		after, _ := json.Marshal(message.Spec)
+		if err := audit.Record(cmd.Context(), audit.Config, configFile, "generate", before, after); err != nil {
+			logger.Warnf("failed to record config changes in audit log: %s", err)
+		}
 
 		return nil
 	},
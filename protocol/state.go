@@ -0,0 +1,385 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/audit"
+	"github.com/datazip-inc/olake/pkg/encryption"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/goccy/go-json"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// repair the issues found by state doctor
+var stateRepair bool
+
+// stateCmd groups the commands inspecting and editing the state of a connection, so cursors
+// and CDC positions aren't edited by hand
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Olake state command; shows, resets or repairs the state in --state or --state-config",
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		if statePath == "" && !configured("state-config", stateConfigPath) {
+			return fmt.Errorf("--state or --state-config not passed")
+		}
+		// state is written next to the config of the connection, where its syncs hold the lock
+		if configPath == "" && statePath != "" {
+			viper.Set("CONFIG_FOLDER", filepath.Dir(statePath))
+		}
+		loaded, err := loadState(cmd.Context())
+		if err != nil {
+			return err
+		}
+		state = loaded
+		return nil
+	},
+	PersistentPostRun: func(_ *cobra.Command, _ []string) {
+		closeStateStore()
+	},
+}
+
+// stateShowCmd prints the state, or the state of --stream, with credentials masked
+var stateShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Prints the state, or the cursor and chunks of --stream; credentials are masked",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		shown := state.Redacted()
+		if sampleStream != "" {
+			id, err := stateStreamID(state, sampleStream)
+			if err != nil {
+				return err
+			}
+			shown.Streams = slices.DeleteFunc(slices.Clone(state.Streams), func(elem *types.StreamState) bool {
+				return elem.Namespace+"."+elem.Stream != id
+			})
+		}
+		data, err := json.MarshalIndent(shown, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal state: %s", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	},
+}
+
+// stateResetCmd clears the state of --stream, or of all streams, so their next sync starts over
+var stateResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Clears the cursor, chunks and CDC position of --stream, or of all streams, so their next sync starts over",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		lock, err := acquireSyncLock()
+		if err != nil {
+			return err
+		}
+		defer lock.release()
+
+		before, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		if err := resetState(state, sampleStream); err != nil {
+			return err
+		}
+		after, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		if err := saveState(after); err != nil {
+			return err
+		}
+		return audit.Record(cmd.Context(), audit.State, stateLocation(), "state reset", before, after)
+	},
+}
+
+// stateDoctorCmd validates the state against the catalog and the source
+var stateDoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Validates the state against --catalog and, with --config, the source; --repair resets the streams with issues so they are synced again from a snapshot",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if catalogPath == "" {
+			return fmt.Errorf("--catalog not passed")
+		}
+		catalog = &types.Catalog{}
+		if err := types.CatalogFormat.LoadFile(catalogPath, catalog); err != nil {
+			return err
+		}
+		streams := catalogStreams(catalog)
+		issues := diagnoseState(state, streams)
+		if configured("config", configPath) {
+			if err := utils.UnmarshalFileWithEnv(configPath, "config", connector.GetConfigRef()); err != nil {
+				return err
+			}
+			if err := connector.Setup(); err != nil {
+				return err
+			}
+			if checker, ok := connector.(StateChecker); ok {
+				sources := make([]Stream, 0, len(streams))
+				for _, stream := range streams {
+					sources = append(sources, stream)
+				}
+				issues = append(issues, checker.CheckState(cmd.Context(), state, sources)...)
+			}
+		}
+
+		if len(issues) == 0 {
+			logger.Info("state is healthy")
+			return nil
+		}
+		for _, issue := range issues {
+			logger.Warnf("[ISSUE] %s; %s", issue.Message, issue.Remediation)
+		}
+		if !stateRepair {
+			return fmt.Errorf("found %d issues in state; pass --repair to reset the state they affect", len(issues))
+		}
+
+		lock, err := acquireSyncLock()
+		if err != nil {
+			return err
+		}
+		defer lock.release()
+		before, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		repairState(state, issues)
+		after, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		if err := saveState(after); err != nil {
+			return err
+		}
+		logger.Infof("repaired %d issues in state; the streams affected are synced again from a snapshot", len(issues))
+		return audit.Record(cmd.Context(), audit.State, stateLocation(), "state doctor", before, after)
+	},
+}
+
+// loadState reads the state in --state, or in the backend of --state-config
+func loadState(ctx context.Context) (*types.State, error) {
+	loaded := &types.State{
+		Type: types.StreamType,
+	}
+	if statePath != "" {
+		if err := types.StateFormat.LoadFile(statePath, loaded); err != nil {
+			return nil, err
+		}
+	}
+	if err := openStateStore(ctx, loaded); err != nil {
+		return nil, err
+	}
+	loaded.RWMutex = &sync.RWMutex{}
+	return loaded, nil
+}
+
+// saveState writes data, the state serialized, back to where it was loaded from
+func saveState(data []byte) error {
+	data, err := encryption.Seal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt state: %s", err)
+	}
+	if state.Saver != nil {
+		return state.Saver.Save(data)
+	}
+	if err := os.WriteFile(statePath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write state[%s]: %s", statePath, err)
+	}
+	return nil
+}
+
+// stateLocation is where changes of state commands are recorded to have happened
+func stateLocation() string {
+	if stateTarget != "" {
+		return stateTarget
+	}
+	return statePath
+}
+
+// resetState clears the state of stream, given as namespace.name or its name when unique;
+// all streams and the global CDC position are cleared when stream is empty. Credentials and
+// pending uploads are kept either way.
+func resetState(state *types.State, stream string) error {
+	state.Lock()
+	defer state.Unlock()
+	if stream == "" {
+		state.Streams = nil
+		state.Global = nil
+		return nil
+	}
+
+	id, err := stateStreamID(state, stream)
+	if err != nil {
+		return err
+	}
+	state.Streams = slices.DeleteFunc(state.Streams, func(elem *types.StreamState) bool {
+		return elem.Namespace+"."+elem.Stream == id
+	})
+	// streams left out of the global state are snapshotted again by CDC syncs
+	if global, ok := state.Global.(map[string]any); ok {
+		if streams, ok := global["streams"].([]any); ok {
+			global["streams"] = slices.DeleteFunc(streams, func(elem any) bool { return elem == id })
+		}
+	}
+	return nil
+}
+
+// stateStreamID returns the namespace.name of stream in state, matched by namespace.name or by
+// name when unique
+func stateStreamID(state *types.State, stream string) (string, error) {
+	ids := []string{}
+	for _, elem := range state.Streams {
+		ids = append(ids, elem.Namespace+"."+elem.Stream)
+	}
+	if global, ok := state.Global.(map[string]any); ok {
+		if streams, ok := global["streams"].([]any); ok {
+			for _, elem := range streams {
+				if id, ok := elem.(string); ok {
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+
+	if slices.Contains(ids, stream) {
+		return stream, nil
+	}
+	found := ""
+	for _, id := range ids {
+		if !strings.HasSuffix(id, "."+stream) || id == found {
+			continue
+		}
+		if found != "" {
+			return "", fmt.Errorf("stream[%s] exists in several namespaces; pass namespace.name", stream)
+		}
+		found = id
+	}
+	if found == "" {
+		return "", fmt.Errorf("stream[%s] not found in state", stream)
+	}
+	return found, nil
+}
+
+// catalogStreams returns the streams of the catalog, with the metadata of the selected ones
+func catalogStreams(catalog *types.Catalog) []*types.ConfiguredStream {
+	selected := make(map[string]types.StreamMetadata)
+	for namespace, streamsMetadata := range catalog.SelectedStreams {
+		for _, streamMetadata := range streamsMetadata {
+			selected[utils.StreamIdentifier(streamMetadata.StreamName, namespace)] = streamMetadata
+		}
+	}
+	for _, stream := range catalog.Streams {
+		if streamMetadata, isSelected := selected[stream.ID()]; isSelected {
+			stream.StreamMetadata = streamMetadata
+			stream.ApplyOverrides()
+		}
+	}
+	return catalog.Streams
+}
+
+// diagnoseState finds the state of streams missing from the catalog, and cursors the streams
+// can't resume from
+func diagnoseState(state *types.State, streams []*types.ConfiguredStream) []types.StateIssue {
+	configured := make(map[string]*types.ConfiguredStream, len(streams))
+	for _, stream := range streams {
+		configured[stream.ID()] = stream
+	}
+
+	var issues []types.StateIssue
+	for _, elem := range state.Streams {
+		id := elem.Namespace + "." + elem.Stream
+		stream, found := configured[id]
+		switch {
+		case !found:
+			issues = append(issues, types.StateIssue{
+				Streams:     []string{id},
+				Message:     fmt.Sprintf("stream[%s] is kept in state but missing from the catalog", id),
+				Remediation: "reset its state, or discover it again if it was left out by mistake",
+			})
+		case elem.SyncMode != "" && elem.SyncMode != string(stream.GetSyncMode()):
+			issues = append(issues, types.StateIssue{
+				Streams:     []string{id},
+				Message:     fmt.Sprintf("state of stream[%s] was kept by a %s sync but the stream syncs in %s mode", id, elem.SyncMode, stream.GetSyncMode()),
+				Remediation: "reset its state so it starts over in the new mode",
+			})
+		case stream.GetSyncMode() == types.INCREMENTAL:
+			if message := diagnoseCursor(elem, stream); message != "" {
+				issues = append(issues, types.StateIssue{
+					Streams:     []string{id},
+					Message:     message,
+					Remediation: "reset its state so it is read again from the initial cursor or the start of the table",
+				})
+			}
+		}
+	}
+
+	if global, ok := state.Global.(map[string]any); ok {
+		streams, _ := global["streams"].([]any)
+		for _, elem := range streams {
+			if id, ok := elem.(string); ok && configured[id] == nil {
+				issues = append(issues, types.StateIssue{
+					Streams:     []string{id},
+					Message:     fmt.Sprintf("stream[%s] is covered by the global state but missing from the catalog", id),
+					Remediation: "reset its state, or discover it again if it was left out by mistake",
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// diagnoseCursor returns why the cursor kept in elem can't be resumed from by stream; empty
+// when it can
+func diagnoseCursor(elem *types.StreamState, stream *types.ConfiguredStream) string {
+	value, found := elem.State.Load(stream.Cursor())
+	if !found {
+		stale := []string{}
+		elem.State.Range(func(key, _ any) bool {
+			if key != types.ResyncRunsKey && key != types.ResyncedAtKey {
+				stale = append(stale, fmt.Sprint(key))
+			}
+			return true
+		})
+		if len(stale) == 0 {
+			return ""
+		}
+		sort.Strings(stale)
+		return fmt.Sprintf("state of stream[%s] keeps cursor %v but the cursor of the stream is %s", stream.ID(), stale, stream.Cursor())
+	}
+	if _, err := typeutils.ReadCursor(stream.Schema(), stream.CursorFields(), value); err != nil {
+		return fmt.Sprintf("cursor of stream[%s] in state is invalid: %s", stream.ID(), err)
+	}
+	return ""
+}
+
+// repairState resets the state issues affect, so the streams are synced again from a snapshot
+func repairState(state *types.State, issues []types.StateIssue) {
+	for _, issue := range issues {
+		if issue.Global {
+			if global, ok := state.Global.(map[string]any); ok {
+				streams, _ := global["streams"].([]any)
+				for _, elem := range streams {
+					if id, ok := elem.(string); ok {
+						// the stream may have been reset by another issue
+						_ = resetState(state, id)
+					}
+				}
+			}
+			state.Lock()
+			state.Global = nil
+			state.Unlock()
+		}
+		for _, id := range issue.Streams {
+			_ = resetState(state, id)
+		}
+	}
+}
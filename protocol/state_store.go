@@ -0,0 +1,111 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/audit"
+	"github.com/datazip-inc/olake/pkg/encryption"
+	"github.com/datazip-inc/olake/pkg/statestore"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/goccy/go-json"
+	"github.com/spf13/viper"
+)
+
+var (
+	stateConfigPath string
+	// backend of the running sync; nil when state is kept in the config folder
+	stateStore statestore.Store
+	// audit log kept beside the state in the backend
+	auditStore statestore.Store
+	// where state changes are recorded to have happened
+	stateTarget string
+	// state as the sync loaded it
+	loadedState []byte
+)
+
+// openStateStore loads the state from the backend in --state-config and checkpoints it there
+func openStateStore(ctx context.Context, state *types.State) error {
+	if !configured("state-config", stateConfigPath) {
+		return nil
+	}
+	if statePath != "" {
+		return fmt.Errorf("--state and --state-config can not be passed together")
+	}
+
+	config := &statestore.Config{}
+	if err := utils.UnmarshalFileWithEnv(stateConfigPath, "state-config", config); err != nil {
+		return err
+	}
+	store, err := statestore.New(ctx, config)
+	if err != nil {
+		return err
+	}
+	data, err := store.Load(ctx)
+	if err != nil {
+		store.Close()
+		return err
+	}
+	if len(data) > 0 {
+		data, err = encryption.Open(data)
+		if err != nil {
+			store.Close()
+			return fmt.Errorf("failed to decrypt state of backend[%s]: %s", config.Type, err)
+		}
+		if data, _, err = types.StateFormat.UpgradeData(data); err != nil {
+			store.Close()
+			return fmt.Errorf("state of backend[%s]: %s", config.Type, err)
+		}
+		if err := json.Unmarshal(data, state); err != nil {
+			store.Close()
+			return fmt.Errorf("failed to unmarshal state of backend[%s]: %s", config.Type, err)
+		}
+	}
+	logger.Infof("Using state backend[%s]", config.Type)
+
+	if auditStore, err = statestore.New(ctx, config.Audit()); err != nil {
+		store.Close()
+		return fmt.Errorf("failed to setup audit log of state backend[%s]: %s", config.Type, err)
+	}
+	audit.Use(&audit.StoreLog{Store: auditStore})
+	stateTarget = fmt.Sprintf("state backend[%s]", config.Type)
+	stateStore = store
+	state.Saver = &statestore.Saver{Store: store}
+	if parts, ok := store.(statestore.PartStore); ok {
+		state.Saver = &statestore.PartSaver{Saver: statestore.Saver{Store: store}, Parts: parts}
+	}
+	return nil
+}
+
+func closeStateStore() {
+	if stateStore == nil {
+		return
+	}
+	if err := stateStore.Close(); err != nil {
+		logger.Warnf("failed to close state backend: %s", err)
+	}
+	if err := auditStore.Close(); err != nil {
+		logger.Warnf("failed to close audit log of state backend: %s", err)
+	}
+}
+
+// auditState records the changes made to the state since it was before
+func auditState(ctx context.Context, state *types.State, before []byte) {
+	state.RLock()
+	after, err := json.Marshal(state)
+	state.RUnlock()
+	if err != nil {
+		logger.Warnf("failed to record state changes in audit log: %s", err)
+		return
+	}
+	target := stateTarget
+	if target == "" {
+		target = filepath.Join(viper.GetString("CONFIG_FOLDER"), "state.json")
+	}
+	if err := audit.Record(ctx, audit.State, target, "sync", before, after); err != nil {
+		logger.Warnf("failed to record state changes in audit log: %s", err)
+	}
+}
@@ -0,0 +1,69 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/datazip-inc/olake/pkg/statestore"
+	"github.com/datazip-inc/olake/types"
+	"github.com/goccy/go-json"
+)
+
+func TestIndexedStateCheckpoints(t *testing.T) {
+	stream := func(name string) *types.ConfiguredStream {
+		return &types.ConfiguredStream{Stream: &types.Stream{Name: name, Namespace: "public"}}
+	}
+	path := filepath.Join(t.TempDir(), "state.idx")
+	config := &statestore.Config{Type: statestore.BackendIndexed, Path: path}
+	store, err := statestore.New(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saver := &statestore.PartSaver{Saver: statestore.Saver{Store: store}, Parts: store.(statestore.PartStore)}
+	state := &types.State{RWMutex: &sync.RWMutex{}, Type: types.StreamType, Saver: saver}
+	state.DeferCheckpoints(nil)
+	for i := 0; i < 100; i++ {
+		state.SetCursor(stream(fmt.Sprintf("table_%d", i)), "id", i)
+	}
+	state.Snapshot().Persist()
+	full, _ := os.Stat(path)
+
+	// later checkpoints only append the streams they changed
+	state.SetCursor(stream("table_7"), "id", 700)
+	state.ResetStream(stream("table_8"))
+	checkpoint := state.Snapshot()
+	state.SetCursor(stream("table_9"), "id", 900)
+	checkpoint.Persist()
+	appended, _ := os.Stat(path)
+	if appended.Size()-full.Size() > full.Size()/10 {
+		t.Fatalf("expected a checkpoint of 2 streams to append a few records, the log grew from %d to %d bytes", full.Size(), appended.Size())
+	}
+	store.Close()
+
+	store, err = statestore.New(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	data, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := &types.State{RWMutex: &sync.RWMutex{}}
+	if err := json.Unmarshal(data, loaded); err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Streams) != 99 || loaded.Type != types.StreamType {
+		t.Fatalf("expected 99 streams of a stream state, got %d of %s", len(loaded.Streams), loaded.Type)
+	}
+	cursors := map[string]any{"table_7": 700.0, "table_8": nil, "table_9": 9.0, "table_10": 10.0}
+	for name, cursor := range cursors {
+		if value := loaded.GetCursor(stream(name), "id"); value != cursor {
+			t.Fatalf("expected cursor %v of %s, got %v", cursor, name, value)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package protocol
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+	"github.com/goccy/go-json"
+)
+
+func TestResetState(t *testing.T) {
+	load := func() *types.State {
+		loaded := &types.State{}
+		data := `{
+			"type": "GLOBAL",
+			"global": {"state": {"lsn": "0/16B3748"}, "streams": ["app.users", "app.orders"]},
+			"streams": [
+				{"stream": "users", "namespace": "app", "sync_mode": "cdc", "state": {"chunks": []}},
+				{"stream": "events", "namespace": "app", "sync_mode": "incremental", "state": {"updated_at": "2024-01-01"}},
+				{"stream": "events", "namespace": "audit", "sync_mode": "incremental", "state": {"id": 10}}
+			],
+			"credentials": {"refresh_token": "secret"}
+		}`
+		if err := json.Unmarshal([]byte(data), loaded); err != nil {
+			t.Fatal(err)
+		}
+		loaded.RWMutex = &sync.RWMutex{}
+		return loaded
+	}
+
+	state := load()
+	if err := resetState(state, "users"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(state)
+	if strings.Contains(string(data), `"users"`) || !strings.Contains(string(data), `"app.orders"`) || !strings.Contains(string(data), "0/16B3748") {
+		t.Fatalf("expected users to be reset and the CDC position kept, got %s", data)
+	}
+
+	// orders only has a CDC position
+	if err := resetState(state, "app.orders"); err != nil {
+		t.Fatal(err)
+	}
+	if err := resetState(state, "orders"); err == nil {
+		t.Fatal("expected a stream missing from state to be rejected")
+	}
+	if err := resetState(state, "events"); err == nil || !strings.Contains(err.Error(), "several namespaces") {
+		t.Fatalf("expected an ambiguous stream to be rejected, got %v", err)
+	}
+	if err := resetState(state, "audit.events"); err != nil || len(state.Streams) != 1 || state.Streams[0].Namespace != "app" {
+		t.Fatalf("expected only app.events to be left, got %v: %v", state.Streams, err)
+	}
+
+	state = load()
+	if err := resetState(state, ""); err != nil {
+		t.Fatal(err)
+	}
+	if state.Global != nil || len(state.Streams) != 0 || state.Credentials["refresh_token"] != "secret" {
+		t.Fatalf("expected all streams to be reset and credentials kept, got %+v", state)
+	}
+}
+
+func TestDiagnoseState(t *testing.T) {
+	state := &types.State{}
+	data := `{
+		"type": "GLOBAL",
+		"global": {"state": {"lsn": "0/16B3748"}, "streams": ["app.users", "app.dropped"]},
+		"streams": [
+			{"stream": "orders", "namespace": "app", "sync_mode": "incremental", "state": {"updated_at": "yesterday"}},
+			{"stream": "events", "namespace": "app", "sync_mode": "incremental", "state": {"created_at": "2024-01-01T00:00:00Z"}},
+			{"stream": "items", "namespace": "app", "sync_mode": "incremental", "state": {"id": 10, "resync_runs": 2}},
+			{"stream": "users", "namespace": "app", "sync_mode": "cdc", "state": {"chunks": []}}
+		]
+	}`
+	if err := json.Unmarshal([]byte(data), state); err != nil {
+		t.Fatal(err)
+	}
+	state.RWMutex = &sync.RWMutex{}
+	incremental := func(name, cursor string, typ types.DataType) *types.ConfiguredStream {
+		stream := types.NewStream(name, "app").WithSyncMode(types.INCREMENTAL).WithCursorField(cursor)
+		stream.UpsertField(cursor, typ, false)
+		stream.SyncMode = types.INCREMENTAL
+		return &types.ConfiguredStream{Stream: stream, CursorField: cursor}
+	}
+	users := types.NewStream("users", "app")
+	users.SyncMode = types.CDC
+	streams := []*types.ConfiguredStream{
+		incremental("orders", "updated_at", types.Timestamp),
+		incremental("events", "updated_at", types.Timestamp),
+		incremental("items", "id", types.Int64),
+		{Stream: users},
+	}
+
+	issues := diagnoseState(state, streams)
+	affected := []string{}
+	for _, issue := range issues {
+		affected = append(affected, issue.Streams...)
+	}
+	// orders has an invalid cursor, events one of another column and dropped left the catalog
+	if expected := []string{"app.orders", "app.events", "app.dropped"}; !slices.Equal(affected, expected) {
+		t.Fatalf("expected issues with %v, got %+v", expected, issues)
+	}
+
+	repairState(state, append(issues, types.StateIssue{Global: true}))
+	if state.Global != nil || len(state.Streams) != 1 || state.Streams[0].Stream != "items" {
+		t.Fatalf("expected only the state of items to be kept, got %+v", state)
+	}
+	if issues := diagnoseState(state, streams); len(issues) != 0 {
+		t.Fatalf("expected the repaired state to be healthy, got %+v", issues)
+	}
+}
@@ -0,0 +1,141 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/spf13/viper"
+)
+
+const (
+	streamSucceeded = "succeeded"
+	streamFailed    = "failed"
+	syncPartial     = "partial"
+
+	// PartialSyncExitCode is the exit code of a sync that finished with failed streams
+	PartialSyncExitCode = 2
+)
+
+var (
+	// failed streams are recorded and the remaining streams keep syncing
+	continueOnError bool
+
+	// ErrPartialSync is returned by a sync that finished with failed streams under --continue-on-error
+	ErrPartialSync = errors.New("sync partially succeeded")
+)
+
+// StreamResult is the outcome of one stream in the sync summary
+type StreamResult struct {
+	Stream string `json:"stream"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// records written, and bytes of the records read and written
+	Records      int64 `json:"records"`
+	ReadBytes    int64 `json:"read_bytes"`
+	WrittenBytes int64 `json:"written_bytes"`
+	// records left out by the stream's filter
+	Filtered int64 `json:"filtered,omitempty"`
+	// characters replaced with U+FFFD as they weren't valid in the charset of the stream
+	ReplacedCharacters int64 `json:"replaced_characters,omitempty"`
+	// records above max_record_size of the destination, truncated or dead lettered
+	OversizedRecords int64 `json:"oversized_records,omitempty"`
+	// quality checks run once the stream was written
+	Quality []QualityResult `json:"quality,omitempty"`
+}
+
+// SyncSummary is logged and written to summary_<sync id>.json at the end of a sync
+type SyncSummary struct {
+	SyncID       string         `json:"sync_id"`
+	Status       string         `json:"status"`
+	Records      int64          `json:"records"`
+	ReadBytes    int64          `json:"read_bytes"`
+	WrittenBytes int64          `json:"written_bytes"`
+	Streams      []StreamResult `json:"streams"`
+}
+
+// streamFailures collects failed streams under --continue-on-error
+type streamFailures struct {
+	mutex  sync.Mutex
+	errors map[string]error
+	// quality check results per stream id, set before report
+	quality map[string][]QualityResult
+	// set by report, posted with the end of sync notification
+	summary *SyncSummary
+}
+
+func newStreamFailures() *streamFailures {
+	return &streamFailures{errors: make(map[string]error)}
+}
+
+// tolerate records err of the stream and returns nil when failures are tolerated
+func (f *streamFailures) tolerate(pool *WriterPool, stream Stream, err error) error {
+	if err == nil || !continueOnError {
+		return err
+	}
+	logger.Error(err, "Stream failed, continuing with the remaining streams")
+	pool.MarkFailed(stream)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.errors[stream.ID()] = err
+	return nil
+}
+
+func (f *streamFailures) count() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.errors)
+}
+
+// report logs the summary of the synced streams and returns ErrPartialSync when any failed,
+// else ErrQualityCheck when any quality check failed
+func (f *streamFailures) report(pool *WriterPool, streams []string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	summary := SyncSummary{SyncID: viper.GetString("SYNC_ID"), Status: streamSucceeded, Records: pool.SyncedRecords()}
+	summary.ReadBytes, summary.WrittenBytes = pool.SyncedBytes()
+	sort.Strings(streams)
+	qualityFailures := 0
+	for _, stream := range streams {
+		progress := pool.streamProgress(stream)
+		result := StreamResult{
+			Stream:             stream,
+			Status:             streamSucceeded,
+			Records:            progress.synced.Load(),
+			ReadBytes:          progress.read.Load(),
+			WrittenBytes:       progress.written.Load(),
+			Filtered:           progress.filtered.Load(),
+			ReplacedCharacters: progress.replaced.Load(),
+			OversizedRecords:   progress.oversized.Load(),
+			Quality:            f.quality[stream],
+		}
+		if err, failed := f.errors[stream]; failed {
+			result.Status = streamFailed
+			result.Error = err.Error()
+			summary.Status = syncPartial
+		}
+		for _, check := range result.Quality {
+			if check.Status == qualityFailed {
+				qualityFailures++
+			}
+		}
+		summary.Streams = append(summary.Streams, result)
+	}
+	if qualityFailures > 0 && summary.Status == streamSucceeded {
+		summary.Status = syncQuality
+	}
+	f.summary = &summary
+	logger.Info(summary)
+	if err := logger.FileLogger(summary, logger.RunFileName("summary"), ".json"); err != nil {
+		logger.Warnf("failed to write sync summary: %s", err)
+	}
+	if len(f.errors) > 0 {
+		return fmt.Errorf("%d of %d streams failed: %w", len(f.errors), len(streams), ErrPartialSync)
+	}
+	if qualityFailures > 0 {
+		return fmt.Errorf("%d checks failed: %w", qualityFailures, ErrQualityCheck)
+	}
+	return nil
+}
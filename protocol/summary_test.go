@@ -0,0 +1,63 @@
+package protocol
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestStreamFailures(t *testing.T) {
+	defer func(previous bool) { continueOnError = previous }(continueOnError)
+	pool := &WriterPool{lifecycle: &lifecycle{}}
+	stream := &types.ConfiguredStream{Stream: types.NewStream("orders", "public")}
+	readErr := errors.New("read failed")
+
+	continueOnError = false
+	failures := newStreamFailures()
+	if err := failures.tolerate(pool, stream, readErr); err != readErr {
+		t.Fatalf("expected the error to be returned without --continue-on-error, got %v", err)
+	}
+
+	continueOnError = true
+	if err := failures.tolerate(pool, stream, readErr); err != nil {
+		t.Fatalf("expected the error to be recorded, got %v", err)
+	}
+	if _, failed := pool.lifecycle.failed.Load(stream.ID()); !failed {
+		t.Fatal("expected the stream to be excluded from finalization")
+	}
+	err := failures.report(pool, []string{"public.customers", stream.ID()})
+	if !errors.Is(err, ErrPartialSync) {
+		t.Fatalf("expected partial sync, got %v", err)
+	}
+
+	if err := newStreamFailures().report(pool, []string{"public.customers"}); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+}
+
+func TestSyncedBytes(t *testing.T) {
+	pool := &WriterPool{lifecycle: &lifecycle{}}
+	orders := &types.ConfiguredStream{Stream: types.NewStream("orders", "public")}
+	users := &types.ConfiguredStream{Stream: types.NewStream("users", "public")}
+	record := types.CreateRawRecord("1", map[string]any{"id": int64(1), "email": "a@example.com"}, 0)
+	pool.countRead(orders, record)
+	pool.countRead(orders, record)
+	pool.countRecord(orders, 10)
+	pool.countRead(users, record)
+
+	failures := newStreamFailures()
+	if err := failures.report(pool, []string{orders.ID(), users.ID()}); err != nil {
+		t.Fatal(err)
+	}
+	// 28 bytes a record: the keys, 8 of the integer and 13 of the address
+	if summary := failures.summary; summary.ReadBytes != 3*28 || summary.WrittenBytes != 10 {
+		t.Fatalf("expected 84 bytes read and 10 written, got %d and %d", summary.ReadBytes, summary.WrittenBytes)
+	}
+	if result := failures.summary.Streams[0]; result.Stream != orders.ID() || result.ReadBytes != 56 || result.WrittenBytes != 10 || result.Records != 1 {
+		t.Fatalf("unexpected result of orders %+v", result)
+	}
+	if stats := pool.Stats().Streams[users.ID()]; stats.ReadBytes != 28 || stats.WrittenBytes != 0 {
+		t.Fatalf("unexpected stats of users %+v", stats)
+	}
+}
@@ -4,71 +4,103 @@ import (
 	"context"
 	"fmt"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/encryption"
 	"github.com/datazip-inc/olake/types"
 	"github.com/datazip-inc/olake/utils"
+	"github.com/goccy/go-json"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // syncCmd represents the read command
 var syncCmd = &cobra.Command{
 	Use:   "sync",
 	Short: "Olake sync command",
-	PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
-		if configPath == "" {
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		if !configured("config", configPath) {
 			return fmt.Errorf("--config not passed")
-		} else if destinationConfigPath == "" {
+		} else if !configured("destination", destinationConfigPath) {
 			return fmt.Errorf("--destination not passed")
 		} else if catalogPath == "" {
 			return fmt.Errorf("--catalog not passed")
 		}
 
 		// unmarshal source config
-		if err := utils.UnmarshalFile(configPath, connector.GetConfigRef()); err != nil {
+		if err := utils.UnmarshalFileWithEnv(configPath, "config", connector.GetConfigRef()); err != nil {
 			return err
 		}
 
 		// unmarshal destination config
 		destinationConfig = &types.WriterConfig{}
-		if err := utils.UnmarshalFile(destinationConfigPath, destinationConfig); err != nil {
+		if err := utils.UnmarshalFileWithEnv(destinationConfigPath, "destination", destinationConfig); err != nil {
 			return err
 		}
 
 		catalog = &types.Catalog{}
-		if err := utils.UnmarshalFile(catalogPath, catalog); err != nil {
+		if err := types.CatalogFormat.LoadFile(catalogPath, catalog); err != nil {
 			return err
 		}
 
-		// default state
-		state = &types.State{
-			Type: types.StreamType,
+		loaded, err := loadState(cmd.Context())
+		if err != nil {
+			return err
 		}
-		if statePath != "" {
-			if err := utils.UnmarshalFile(statePath, state); err != nil {
-				return err
-			}
+		state = loaded
+		state.SyncID = viper.GetString("SYNC_ID")
+		// changes made by the sync are recorded in the audit log against it
+		if loadedState, err = json.Marshal(state); err != nil {
+			return err
+		}
+		if !encryption.Enabled() {
+			stateBytes, _ := state.Redacted().MarshalJSON()
+			logger.Infof("Running sync with state: %s", stateBytes)
 		}
-
-		state.RWMutex = &sync.RWMutex{}
-		stateBytes, _ := state.MarshalJSON()
-		logger.Infof("Running sync with state: %s", stateBytes)
 		return nil
 	},
-	RunE: func(cmd *cobra.Command, _ []string) error {
+	RunE: func(cmd *cobra.Command, _ []string) (err error) {
+		lock, err := acquireSyncLock()
+		if err != nil {
+			return err
+		}
+		defer lock.release()
+		defer closeStateStore()
+		defer auditState(cmd.Context(), state, loadedState)
+
+		notifier, err := startNotifications(cmd.Context(), destinationConfig)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			notifier.finish(cmd.Context(), err)
+		}()
+
 		pool, err := NewWriter(cmd.Context(), destinationConfig)
 		if err != nil {
 			return err
 		}
+		if notifier != nil {
+			notifier.pool = pool
+		}
+		beat := startHeartbeat(cmd.Context(), pool)
+		defer func() {
+			beat.stop(err)
+		}()
+		if driver, ok := connector.(OAuthDriver); ok {
+			driver.SetCredentialStore(state)
+		}
 		// setup conector first
 		err = connector.Setup()
 		if err != nil {
 			return err
 		}
+		if err := pool.setSourceTimezone(connector); err != nil {
+			return err
+		}
 		// Get Source Streams
-		streams, err := connector.Discover(false)
+		streams, err := discoverStreams(false)
 		if err != nil {
 			return err
 		}
@@ -102,13 +134,14 @@ var syncCmd = &cobra.Command{
 				return false
 			}
 
+			elem.StreamMetadata = sMetadata
 			err := elem.Validate(source)
 			if err != nil {
 				logger.Warnf("Skipping; Configured Stream %s found invalid due to reason: %s", elem.ID(), err)
 				return false
 			}
+			elem.ApplyOverrides()
 
-			elem.StreamMetadata = sMetadata
 			selectedStreams = append(selectedStreams, elem.ID())
 
 			if elem.Stream.SyncMode == types.CDC {
@@ -121,14 +154,35 @@ var syncCmd = &cobra.Command{
 		})
 		logger.Infof("Valid selected streams are %s", strings.Join(selectedStreams, ", "))
 
+		// order streams by priority and dependencies
+		schedule, err := scheduleStreams(standardModeStreams)
+		if err != nil {
+			return err
+		}
+
 		// start monitoring stats
-		logger.StatsLogger(cmd.Context(), func() (int64, int64, int64) {
-			return pool.SyncedRecords(), pool.threadCounter.Load(), pool.GetRecordsToSync()
-		})
+		logger.StatsLogger(cmd.Context(), pool.Stats, notifier.statsEmitters()...)
 
 		// Setup State for Connector
 		connector.SetupState(state)
+		checkpoints := pool.startCheckpoints(cmd.Context(), state)
+		defer checkpoints.stop()
 
+		if err := pool.BeginSync(cmd.Context()); err != nil {
+			return err
+		}
+
+		display := startProgress(cmd.Context(), pool, selectedStreams)
+		defer display.stop()
+
+		// reads are bounded by the sync timeout; the writer lifecycle isn't so it can clean up
+		readCtx, cancelRead := syncContext(cmd.Context())
+		defer cancelRead()
+
+		failures := newStreamFailures()
+		if notifier != nil {
+			notifier.failures = failures
+		}
 		// Execute driver ChangeStreams mode
 		GlobalCxGroup.Add(func(_ context.Context) error { // context is not used to keep processes mutually exclusive
 			if connector.ChangeStreamSupported() {
@@ -139,9 +193,15 @@ var syncCmd = &cobra.Command{
 
 				logger.Info("Starting ChangeStream process in driver")
 
-				err := driver.RunChangeStream(pool, cdcStreams...)
+				err := driver.RunChangeStream(readCtx, pool, cdcStreams...)
 				if err != nil {
-					return fmt.Errorf("error occurred while reading records: %s", err)
+					err = fmt.Errorf("error occurred while reading records: %s", timeoutError(readCtx, err))
+					// the change stream serves all cdc streams, so all of them failed
+					for _, stream := range cdcStreams {
+						if err := failures.tolerate(pool, stream, err); err != nil {
+							return err
+						}
+					}
 				}
 			}
 			return nil
@@ -149,13 +209,28 @@ var syncCmd = &cobra.Command{
 
 		// Execute streams in Standard Stream mode
 		// TODO: Separate streams with FULL and Incremental here only
-		utils.ConcurrentInGroup(GlobalCxGroup, standardModeStreams, func(_ context.Context, stream Stream) error { // context is not used to keep processes mutually exclusive
+		utils.ConcurrentInGroup(GlobalCxGroup, schedule.order, func(ctx context.Context, stream Stream) (err error) { // context only cancels waiting on dependencies to keep processes mutually exclusive
+			defer func() {
+				schedule.finish(stream, err)
+				err = failures.tolerate(pool, stream, err)
+			}()
+			if err := schedule.wait(ctx, stream); err != nil {
+				return err
+			}
 			logger.Infof("Reading stream[%s] in %s", stream.ID(), stream.GetSyncMode())
 
 			streamStartTime := time.Now()
-			err := connector.Read(pool, stream)
+			// derived from the sync context, not ctx, so a failed stream doesn't cancel the others
+			streamCtx, cancel := streamContext(readCtx)
+			defer cancel()
+			resynced := beginResync(state, stream, streamStartTime)
+			err = connector.Read(streamCtx, pool, stream)
 			if err != nil {
-				return fmt.Errorf("error occurred while reading records: %s", err)
+				return logger.WrapErr(fmt.Errorf("error occurred while reading stream[%s]: %s", stream.ID(), timeoutError(streamCtx, err)), "stream", stream.ID(), "driver", connector.Type())
+			}
+			endResync(state, stream, resynced, streamStartTime)
+			if err := pool.EmitDeletes(cmd.Context(), stream); err != nil {
+				return fmt.Errorf("error occurred while emitting deletes: %s", err)
 			}
 
 			logger.Infof("Finished reading stream %s[%s] in %s", stream.Name(), stream.Namespace(), time.Since(streamStartTime).String())
@@ -164,17 +239,39 @@ var syncCmd = &cobra.Command{
 		})
 
 		if err := GlobalCxGroup.Block(); err != nil {
-			return err
+			return pool.EndSync(cmd.Context(), err)
 		}
 
 		// wait for writer pool to finish
-		if err := pool.Wait(); err != nil {
-			return fmt.Errorf("error occurred in writer pool: %s", err)
+		waitErr := pool.Wait()
+		// the final state below is persisted once every writer closed
+		checkpoints.stop()
+		if err := waitErr; err != nil {
+			// writer errors fail the read of their stream, so they are already recorded
+			if failures.count() == 0 {
+				return pool.EndSync(cmd.Context(), fmt.Errorf("error occurred in writer pool: %s", err))
+			}
+			logger.Warnf("writer errors of failed streams: %s", err)
+		}
+		display.stop()
+		// finalize destination only once all streams are written
+		if err := pool.EndSync(cmd.Context(), nil); err != nil {
+			return err
+		}
+		if err := pool.SaveHashIndexes(); err != nil {
+			return err
 		}
 
 		logger.Infof("Total records read: %d", pool.SyncedRecords())
+		for streamID, violations := range pool.Violations() {
+			logger.Warnf("Stream %s: %d records violated the data contract", streamID, violations)
+		}
+		if deadLetters := pool.DeadLetters(); deadLetters > 0 {
+			logger.Warnf("%d records were sent to the dead letter queue", deadLetters)
+		}
 		state.LogWithLock()
 
-		return nil
+		failures.quality = runQualityChecks(cmd.Context(), pool, append(standardModeStreams, cdcStreams...))
+		return failures.report(pool, selectedStreams)
 	},
 }
@@ -0,0 +1,40 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// bounds reading all streams of a sync; 0 disables
+	syncTimeout time.Duration
+	// bounds reading a single full refresh or incremental stream; 0 disables
+	streamTimeout time.Duration
+)
+
+// syncContext returns the context reads of a sync run with
+func syncContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if syncTimeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeoutCause(parent, syncTimeout, fmt.Errorf("sync timed out after %s", syncTimeout))
+}
+
+// streamContext returns the context a stream is read with; the sync timeout is inherited
+// from parent
+func streamContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if streamTimeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeoutCause(parent, streamTimeout, fmt.Errorf("stream timed out after %s", streamTimeout))
+}
+
+// timeoutError names the exceeded timeout; drivers mostly report a bare cancellation
+func timeoutError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s: %s", context.Cause(ctx), err)
+	}
+	return err
+}
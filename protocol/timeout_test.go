@@ -0,0 +1,33 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamContextTimeout(t *testing.T) {
+	defer func(previous time.Duration) { streamTimeout = previous }(streamTimeout)
+	streamTimeout = 10 * time.Millisecond
+
+	readCtx, cancelRead := syncContext(context.Background())
+	defer cancelRead()
+	streamCtx, cancel := streamContext(readCtx)
+	defer cancel()
+
+	<-streamCtx.Done()
+	if readCtx.Err() != nil {
+		t.Fatal("stream timeout cancelled the sync context")
+	}
+	err := timeoutError(streamCtx, errors.New("context deadline exceeded"))
+	if !strings.Contains(err.Error(), "stream timed out after 10ms") {
+		t.Fatalf("expected timeout to be named, got: %s", err)
+	}
+
+	// errors of streams still in time are kept as is
+	if err := timeoutError(readCtx, errors.New("failed")); err.Error() != "failed" {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,117 @@
+package protocol
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+)
+
+var (
+	naiveTimestampPolicy string
+	sourceTimezone       string
+)
+
+// naiveTimestamps reads the timestamp columns of a stream stored without a time zone; shared by
+// the threads of the stream
+type naiveTimestamps struct {
+	once    sync.Once
+	columns []string
+	reader  *typeutils.NaiveTimestamps
+	err     error
+}
+
+// setSourceTimezone sets the time zone naive timestamps are in under the source policy:
+// --source-timezone, else the time zone the driver reports
+func (w *WriterPool) setSourceTimezone(connector Connector) error {
+	if err := types.NaiveTimestampPolicy(naiveTimestampPolicy).Validate(); err != nil {
+		return fmt.Errorf("invalid --naive-timestamps: %s", err)
+	}
+	if sourceTimezone != "" {
+		location, err := time.LoadLocation(sourceTimezone)
+		if err != nil {
+			return fmt.Errorf("invalid --source-timezone[%s]: %s", sourceTimezone, err)
+		}
+		w.sourceLocation = location
+		return nil
+	}
+	reporter, ok := connector.(TimezoneReporter)
+	if !ok {
+		return nil
+	}
+	location, err := reporter.SourceTimezone()
+	if err != nil {
+		// only streams reading naive timestamps in the source time zone need it
+		logger.Warnf("failed to get time zone of the source, set --source-timezone to read naive timestamps in it: %s", err)
+		return nil
+	}
+	w.sourceLocation = location
+	return nil
+}
+
+// naiveTimestampReader returns the reader of the naive timestamp columns of stream; nil when
+// there are none or they are read as UTC, as drivers already read them. Columns passed through
+// are typed as strings on first use.
+func (w *WriterPool) naiveTimestampReader(stream Stream) (*naiveTimestamps, error) {
+	columns := stream.GetStream().NaiveTimestampColumns
+	if columns == nil || columns.Len() == 0 {
+		return nil, nil
+	}
+	policy := stream.Self().StreamMetadata.NaiveTimestamps
+	if policy == "" {
+		policy = types.NaiveTimestampPolicy(naiveTimestampPolicy)
+	}
+	if policy == "" || policy == types.NaiveTimestampsUTC {
+		return nil, nil
+	}
+	value, _ := w.timestamps.LoadOrStore(stream.ID(), &naiveTimestamps{})
+	timestamps := value.(*naiveTimestamps)
+	timestamps.once.Do(func() {
+		if policy == types.NaiveTimestampsSource && w.sourceLocation == nil {
+			timestamps.err = fmt.Errorf("time zone of the source of stream[%s] is unknown; set --source-timezone", stream.ID())
+			return
+		}
+		timestamps.reader = &typeutils.NaiveTimestamps{Policy: policy, Location: w.sourceLocation}
+		properties := make(map[string]*types.Property)
+		for _, column := range columns.Array() {
+			found, property := stream.Schema().GetProperty(column)
+			if !found {
+				continue
+			}
+			timestamps.columns = append(timestamps.columns, column)
+			if policy == types.NaiveTimestampsPassthrough {
+				text := types.NewSet(types.String)
+				if property.Nullable() {
+					text.Insert(types.Null)
+				}
+				properties[column] = &types.Property{Type: text}
+			}
+		}
+		w.tmu.Lock()
+		stream.Schema().Override(properties)
+		w.tmu.Unlock()
+	})
+	if timestamps.err != nil {
+		return nil, timestamps.err
+	}
+	return timestamps, nil
+}
+
+// apply reads the naive timestamps of data in place
+func (n *naiveTimestamps) apply(data map[string]any) error {
+	for _, column := range n.columns {
+		value, found := data[column]
+		if !found || value == nil {
+			continue
+		}
+		read, err := n.reader.Read(value)
+		if err != nil {
+			return fmt.Errorf("failed to read naive timestamp of column[%s]: %s", column, err)
+		}
+		data[column] = read
+	}
+	return nil
+}
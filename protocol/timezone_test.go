@@ -0,0 +1,48 @@
+package protocol
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestNaiveTimestampPolicies(t *testing.T) {
+	ctx := context.Background()
+	sampler := &sampler{limit: 10}
+	pool, err := newSamplePool(ctx, nil, sampler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newStream := func(name string, policy types.NaiveTimestampPolicy) *types.ConfiguredStream {
+		stream := types.NewStream(name, "public").WithNaiveTimestamps("created_at")
+		stream.UpsertField("created_at", types.Timestamp, true)
+		return &types.ConfiguredStream{Stream: stream, StreamMetadata: types.StreamMetadata{NaiveTimestamps: policy}}
+	}
+
+	// the source time zone must be known to read in it
+	if _, err := pool.NewThread(ctx, newStream("events", types.NaiveTimestampsSource)); err == nil {
+		t.Fatal("expected the source policy to need a time zone")
+	}
+
+	stream := newStream("visits", types.NaiveTimestampsPassthrough)
+	thread, err := pool.NewThread(ctx, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ, _ := stream.Schema().GetType("created_at"); typ != types.String {
+		t.Fatalf("expected passed through timestamps to be strings, got %s", typ)
+	}
+	record := map[string]any{"created_at": time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)}
+	if err := thread.Insert(types.CreateRawRecord("id", record, 0)); err != nil {
+		t.Fatal(err)
+	}
+	thread.Close()
+	if err := pool.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if len(sampler.records) != 1 || sampler.records[0]["created_at"] != "2024-03-01T10:30:00" {
+		t.Fatalf("unexpected records %v", sampler.records)
+	}
+}
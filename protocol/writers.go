@@ -2,6 +2,7 @@ package protocol
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -9,9 +10,15 @@ import (
 
 	"github.com/datazip-inc/olake/constants"
 	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/charset"
+	"github.com/datazip-inc/olake/pkg/dlq"
+	"github.com/datazip-inc/olake/pkg/filter"
+	"github.com/datazip-inc/olake/pkg/spool"
+	"github.com/datazip-inc/olake/pkg/transform"
 	"github.com/datazip-inc/olake/types"
 	"github.com/datazip-inc/olake/typeutils"
 	"github.com/datazip-inc/olake/utils"
+	"github.com/spf13/viper"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -21,10 +28,25 @@ type CloseFunction func()
 
 var RegisteredWriters = map[types.AdapterType]NewFunc{}
 
+var ErrDeleteUnsupported = errors.New("destination does not support deletes")
+
+var ErrPartialUnsupported = errors.New("destination does not support partial records")
+
+var ErrMaintainUnsupported = errors.New("destination can't be maintained with this config")
+
 type Options struct {
-	Identifier   string
-	Number       int64
-	errorChannel chan error
+	Identifier string
+	Number     int64
+	// destination namespace and table of the stream after applying naming rules
+	Namespace string
+	Table     string
+	// run the thread writes for; writers stamp it on files and messages
+	SyncID string
+	// set during sync; writers track multipart uploads in it
+	Uploads             UploadTracker
+	errorChannel        chan error
+	skipChangeDetection bool
+	changeStream        bool
 }
 
 type ThreadOptions func(opt *Options)
@@ -50,16 +72,46 @@ func WithErrorChannel(errChan chan error) ThreadOptions {
 type WriterPool struct {
 	totalRecords  atomic.Int64
 	recordCount   atomic.Int64
+	readBytes     atomic.Int64
+	writtenBytes  atomic.Int64
 	threadCounter atomic.Int64 // Used in naming files in S3 and global count for threads
 	config        any          // respective writer config
 	init          NewFunc      // To initialize exclusive destination threads
 	group         *errgroup.Group
 	groupCtx      context.Context
 	tmu           sync.Mutex // Mutex between threads
+	contract      *types.ContractConfig
+	naming        *types.NamingConfig
+	metadata      *types.MetadataColumnsConfig
+	migration     types.MigrationPolicy
+	coercions     map[types.DataType]types.DataType
+	coerced       sync.Map // stream id and column already warned about coercion
+	destinations  sync.Map // destination id to stream id; guards against name collisions
+	deadLetter    *dlq.Queue
+	violations    sync.Map // stream id to *atomic.Int64
+	progress      sync.Map // stream id to *streamProgress
+	detectors     sync.Map // stream id to *changeDetector
+	deleteStreams sync.Map // stream id to stream receiving its deletes
+	quality       sync.Map // stream id to *qualityTracker
+	filters       sync.Map // stream id to *filter.Filter
+	transforms    sync.Map // stream id to *streamTransforms
+	largeValues   sync.Map // stream id to *largeValueLimiter
+	timestamps    sync.Map // stream id to *naiveTimestamps
+	offloader     *offloader
+	recordSize    *types.RecordSizeConfig
+	queue         *spool.Config // queues records of threads on disk when set
+	// time zone naive timestamps are read in under the source policy; nil when unknown
+	sourceLocation *time.Location
+	observer       func(record types.RawRecord) // called after every write; used by bench
+	lifecycle      *lifecycle
+	checkpoints    *checkpointer // set up by the sync command
+	barriers       sync.Map      // *threadBarrier of every open thread
+	threadFailed   atomic.Bool
 }
 
 // Shouldn't the name be NewWriterPool?
-func NewWriter(ctx context.Context, config *types.WriterConfig) (*WriterPool, error) {
+// newAdapter returns a writer of the destination type loaded with its config
+func newAdapter(config *types.WriterConfig) (Writer, error) {
 	newfunc, found := RegisteredWriters[config.Type]
 	if !found {
 		return nil, fmt.Errorf("invalid destination type has been passed [%s]", config.Type)
@@ -69,12 +121,79 @@ func NewWriter(ctx context.Context, config *types.WriterConfig) (*WriterPool, er
 	if err := utils.Unmarshal(config.WriterConfig, adapter.GetConfigRef()); err != nil {
 		return nil, err
 	}
+	return adapter, nil
+}
+
+func NewWriter(ctx context.Context, config *types.WriterConfig) (*WriterPool, error) {
+	adapter, err := newAdapter(config)
+	if err != nil {
+		return nil, err
+	}
+	newfunc := RegisteredWriters[config.Type]
 
-	err := adapter.Check()
+	err = adapter.Check()
 	if err != nil {
 		return nil, fmt.Errorf("failed to test destination: %s", err)
 	}
 
+	if config.Contract != nil {
+		if err := config.Contract.Validate(); err != nil {
+			return nil, err
+		}
+		// dead lettering violations needs a queue; fall back to the default file sink
+		if config.Contract.OnViolation == types.ViolationDeadLetter && config.DeadLetter == nil {
+			config.DeadLetter = &dlq.Config{}
+		}
+	}
+
+	if config.MaxRecordSize != nil {
+		if err := config.MaxRecordSize.Validate(); err != nil {
+			return nil, err
+		}
+		// oversized records that aren't truncated need a queue
+		if config.DeadLetter == nil {
+			config.DeadLetter = &dlq.Config{}
+		}
+	}
+
+	// state is only set up by the sync command
+	if resumer, ok := adapter.(UploadResumer); ok && state != nil {
+		if err := resumer.ResumeUploads(state); err != nil {
+			return nil, fmt.Errorf("failed to resume interrupted uploads: %s", err)
+		}
+	}
+
+	coercions, err := typeutils.ResolveCoercions(adapter.SupportedTypes(), config.Coercion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve type coercions of destination[%s]: %s", config.Type, err)
+	}
+	for from, target := range coercions {
+		logger.Warnf("Values of type %s will be written as %s in destination[%s]", from, target, config.Type)
+	}
+
+	if config.Naming != nil {
+		if err := config.Naming.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Queue != nil {
+		if err := config.Queue.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.SchemaMigration.Validate(); err != nil {
+		return nil, err
+	}
+
+	var deadLetter *dlq.Queue
+	if config.DeadLetter != nil {
+		if deadLetter, err = dlq.New(config.DeadLetter); err != nil {
+			return nil, err
+		}
+	}
+
 	group, ctx := errgroup.WithContext(ctx)
 	return &WriterPool{
 		totalRecords:  atomic.Int64{},
@@ -85,6 +204,16 @@ func NewWriter(ctx context.Context, config *types.WriterConfig) (*WriterPool, er
 		group:         group,
 		groupCtx:      ctx,
 		tmu:           sync.Mutex{},
+		contract:      config.Contract,
+		naming:        config.Naming,
+		metadata:      config.MetadataColumns,
+		migration:     config.SchemaMigration,
+		coercions:     coercions,
+		lifecycle:     &lifecycle{writer: adapter},
+		deadLetter:    deadLetter,
+		offloader:     &offloader{config: config.LargeValueStore},
+		recordSize:    config.MaxRecordSize,
+		queue:         config.Queue,
 	}, nil
 }
 
@@ -100,9 +229,62 @@ func (w *WriterPool) NewThread(parent context.Context, stream Stream, options ..
 	for _, one := range options {
 		one(opts)
 	}
+	opts.Namespace, opts.Table = w.naming.Destination(stream.Self())
+	opts.SyncID = viper.GetString("SYNC_ID")
+	if state != nil {
+		opts.Uploads = state
+	}
+	destination := utils.StreamIdentifier(opts.Table, opts.Namespace)
+	if owner, loaded := w.destinations.LoadOrStore(destination, stream.ID()); loaded && owner != stream.ID() {
+		return nil, fmt.Errorf("streams[%s] and [%s] are both written to destination[%s]; adjust the naming config", owner, stream.ID(), destination)
+	}
+	// transformed columns are retyped before writers see the schema
+	transformer, err := w.columnTransformer(stream)
+	if err != nil {
+		return nil, err
+	}
+	limiter, err := w.largeValueLimiter(stream)
+	if err != nil {
+		return nil, err
+	}
+	text, err := charset.New(stream.Self().StreamMetadata.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	timestamps, err := w.naiveTimestampReader(stream)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.beginStream(parent, stream); err != nil {
+		return nil, err
+	}
+	var detector *changeDetector
+	if !opts.skipChangeDetection {
+		if detector, err = w.changeDetector(stream); err != nil {
+			return nil, err
+		}
+	}
+	deleteMode := stream.Self().StreamMetadata.DeleteMode
+	quality := w.qualityTracker(stream)
+	rowFilter, err := w.rowFilter(stream)
+	if err != nil {
+		return nil, err
+	}
 	var thread Writer
 	recordChan := make(chan types.RawRecord)
+	var queue *recordQueue
+	if w.queue != nil {
+		if queue, err = newRecordQueue(w.queue, stream); err != nil {
+			return nil, err
+		}
+	}
 	child, childCancel := context.WithCancel(parent)
+	barrier := w.openBarrier()
+	// records and barriers are received through the queue when there's one
+	records, requests := recordChan, barrier.requests
+	if queue != nil {
+		records, requests = queue.records, queue.requests
+	}
 
 	// fields to make sure schema evolution remain specifc to one thread
 	fields := make(typeutils.Fields)
@@ -140,9 +322,10 @@ func (w *WriterPool) NewThread(parent context.Context, stream Stream, options ..
 		// schema evolution
 		change, typeChange, mutations := fields.Process(flattenedData)
 		if change || typeChange {
-			w.tmu.Lock()
-			stream.Schema().Override(fields.ToProperties()) // update the schema in Stream
-			w.tmu.Unlock()
+			// update the schema in Stream
+			if err := w.overrideSchema(child, thread, stream, fields.ToProperties()); err != nil {
+				return nil, err
+			}
 			err := thread.EvolveSchema(change, typeChange, mutations.ToProperties(), flattenedData)
 			if err != nil {
 				return nil, fmt.Errorf("failed to evolve schema: %s", err)
@@ -165,12 +348,24 @@ func (w *WriterPool) NewThread(parent context.Context, stream Stream, options ..
 					close(opts.errorChannel)
 				}
 				thread.Close() // close it after closing inserts
+				if queue != nil {
+					// records left are dropped; the thread failed or the sync stopped
+					for range queue.records {
+					}
+					if err := queue.queue.Remove(); err != nil {
+						logger.Warnf("failed to remove record queue of stream[%s]: %s", stream.ID(), err)
+					}
+				}
 				w.threadCounter.Add(-1)
 			}()
 			// init writer first
 			if err := initNewWriter(); err != nil {
 				return err
 			}
+			if queue != nil {
+				go queue.forward(child, barrier)
+				go queue.pump(child)
+			}
 
 			return func() error {
 				for {
@@ -178,41 +373,193 @@ func (w *WriterPool) NewThread(parent context.Context, stream Stream, options ..
 					case <-parent.Done():
 						return nil
 					default:
-						record, ok := <-recordChan
+						var record types.RawRecord
+						var ok bool
+						select {
+						case ack := <-requests:
+							// records received before the barrier are written already
+							if err := flushWriter(child, thread); err != nil {
+								ack <- err
+								return err
+							}
+							ack <- nil
+							continue
+						case record, ok = <-records:
+						}
 						if !ok {
+							if queue != nil {
+								return queue.err
+							}
 							return nil
 						}
+						w.countRead(stream, record)
+						// writers not telling missing columns from null ones would null unchanged columns
+						if record.Partial && !partialRecords(thread) {
+							return fmt.Errorf("stream[%s] receives partial records: %s", stream.ID(), ErrPartialUnsupported)
+						}
+						// text is UTF-8 from here on
+						w.convertText(stream, text, record)
+						// timestamps without a time zone are read by the stream's policy; keys of deletes too
+						if timestamps != nil {
+							if err := timestamps.apply(record.Data); err != nil {
+								if rejectErr := w.reject(child, stream, record, dlq.StageTransform, err); rejectErr != nil {
+									return rejectErr
+								}
+								continue
+							}
+						}
+						// leave out rows the stream doesn't need; deletes only carry primary keys and
+						// partial records the changed columns
+						if rowFilter != nil && record.DeleteTime == 0 && !record.Partial {
+							matched, err := rowFilter.Match(record.Data)
+							if err != nil {
+								if rejectErr := w.reject(child, stream, record, dlq.StageTransform, err); rejectErr != nil {
+									return rejectErr
+								}
+								continue
+							}
+							if !matched {
+								w.streamProgress(stream.ID()).filtered.Add(1)
+								continue
+							}
+						}
+						// protect columns before anything else sees their values; keys of deletes too
+						if transformer != nil {
+							transformer.Apply(record.Data)
+						}
+						// shrink huge values before writers buffer them
+						if limiter != nil {
+							if err := limiter.Apply(child, record.Data); err != nil {
+								if rejectErr := w.reject(child, stream, record, dlq.StageTransform, err); rejectErr != nil {
+									return rejectErr
+								}
+								continue
+							}
+						}
+						// one huge record mustn't stall writers buffering records
+						if w.recordSize != nil && record.DeleteTime == 0 {
+							if err := w.limitRecordSize(stream, record); err != nil {
+								if rejectErr := w.reject(child, stream, record, dlq.StageTransform, err); rejectErr != nil {
+									return rejectErr
+								}
+								continue
+							}
+						}
+						if record.DeleteTime != 0 && deleteMode == types.DeleteHard {
+							if err := w.coerce(stream, record); err != nil {
+								if rejectErr := w.reject(child, stream, record, dlq.StageTransform, err); rejectErr != nil {
+									return rejectErr
+								}
+								continue
+							}
+							size := typeutils.RecordSize(record.Data)
+							if err := thread.Delete(child, record); err != nil {
+								if errors.Is(err, ErrDeleteUnsupported) {
+									return fmt.Errorf("stream[%s] is configured with delete mode %s: %s", stream.ID(), deleteMode, err)
+								}
+								if rejectErr := w.reject(child, stream, record, dlq.StageWrite, err); rejectErr != nil {
+									return rejectErr
+								}
+								continue
+							}
+							w.countRecord(stream, size)
+							continue
+						}
+						// validate against data contract; deletes only carry primary keys
+						if w.contract != nil && record.DeleteTime == 0 {
+							valid, err := w.checkContract(stream, record)
+							if err != nil {
+								return err
+							}
+							if !valid {
+								continue
+							}
+						}
+						// coerce only after validation, the contract is defined on source types
+						if err := w.coerce(stream, record); err != nil {
+							if rejectErr := w.reject(child, stream, record, dlq.StageTransform, err); rejectErr != nil {
+								return rejectErr
+							}
+							continue
+						}
 						// add insert time
 						record.OlakeTimestamp = time.Now().UTC().UnixMilli()
+						addMetadataColumns(w.metadata, &record, opts)
 						// check for normalization
+						var normalized types.Record
 						if thread.Normalization() {
 							normalizedData, err := normalizeFunc(record)
 							if err != nil {
-								return err
+								if rejectErr := w.reject(child, stream, record, dlq.StageTransform, err); rejectErr != nil {
+									return rejectErr
+								}
+								continue
 							}
+							// nothing refers to the source map past normalization
+							record.Release()
 							record.Data = normalizedData
+							normalized = normalizedData
 						}
-						// insert record
+						// insert record; sized before writers take the data over
+						size := typeutils.RecordSize(record.Data)
 						if err := thread.Write(child, record); err != nil {
-							return err
+							if rejectErr := w.reject(child, stream, record, dlq.StageWrite, err); rejectErr != nil {
+								return rejectErr
+							}
+							continue
 						}
-						w.recordCount.Add(1) // increase the record count
-
-						if w.SyncedRecords()%batchSize == 0 {
-							state.LogWithLock()
+						w.countRecord(stream, size) // increase the record count
+						if quality != nil {
+							quality.observe(record)
+						}
+						if w.observer != nil {
+							w.observer(record)
+						}
+						if pooledRecords(thread) {
+							record.Release()
+							types.ReleaseRecord(normalized)
 						}
 					}
 				}
 			}()
 		}()
+		w.closeBarrier(barrier, err)
 		if err != nil {
 			logger.Errorf("main writer closed, with error: %s", err)
 		}
 		return err
 	})
 
+	// deletes routed to a separate stream get their own writer thread, opened on first delete
+	var deletes *ThreadEvent
+	var deletesMutex sync.Mutex
+	insertDelete := func(record types.RawRecord) error {
+		deletesMutex.Lock()
+		defer deletesMutex.Unlock()
+		if deletes == nil {
+			var err error
+			if deletes, err = w.NewThread(parent, w.deletesStream(stream), WithIdentifier(opts.Identifier)); err != nil {
+				return err
+			}
+		}
+		return deletes.Insert(record)
+	}
+
 	return &ThreadEvent{
 		Insert: func(record types.RawRecord) error {
+			// unchanged rows since last full refresh
+			if detector != nil && !detector.Changed(record) {
+				return nil
+			}
+			if record.DeleteTime != 0 && deleteMode == types.DeleteSeparateStream {
+				return insertDelete(record)
+			}
+			if queue != nil {
+				if child.Err() != nil {
+					return fmt.Errorf("main writer closed")
+				}
+				return queue.push(child, record)
+			}
 			select {
 			case <-child.Done():
 				return fmt.Errorf("main writer closed")
@@ -221,24 +568,350 @@ func (w *WriterPool) NewThread(parent context.Context, stream Stream, options ..
 			}
 		},
 		Close: func() {
-			close(recordChan)
+			if queue != nil {
+				queue.queue.Close()
+			} else {
+				close(recordChan)
+			}
+			deletesMutex.Lock()
+			defer deletesMutex.Unlock()
+			if deletes != nil {
+				deletes.Close()
+			}
 		},
 	}, nil
 }
 
+// pooledRecords reports if writer is done with the data of a record once it is written
+func pooledRecords(writer Writer) bool {
+	pooled, ok := writer.(PooledWriter)
+	return ok && pooled.PooledRecords()
+}
+
+// partialRecords reports if writer keeps the columns a partial record leaves out
+func partialRecords(writer Writer) bool {
+	partial, ok := writer.(PartialWriter)
+	return ok && partial.PartialRecords()
+}
+
+// destinationReader sets up a writer of the destination on stream to count or read back its
+// rows; nil when the writer can do neither. The writer must be closed
+func (w *WriterPool) destinationReader(stream Stream) (Writer, error) {
+	probe := w.init()
+	_, counter := probe.(RowCounter)
+	_, scanner := probe.(RowScanner)
+	if !counter && !scanner {
+		return nil, nil
+	}
+	return w.destinationWriter(stream)
+}
+
+// destinationWriter sets up a writer of the destination on stream outside of the write
+// pipeline, e.g. to delete rows. The writer must be closed
+func (w *WriterPool) destinationWriter(stream Stream) (Writer, error) {
+	thread := w.init()
+	w.tmu.Lock()
+	err := utils.Unmarshal(w.config, thread.GetConfigRef())
+	w.tmu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	opts := &Options{SyncID: viper.GetString("SYNC_ID")}
+	opts.Namespace, opts.Table = w.naming.Destination(stream.Self())
+	if err := thread.Setup(stream, opts); err != nil {
+		return nil, err
+	}
+	return thread, nil
+}
+
+// rowFilter returns the shared compiled filter of stream; nil when it has none
+func (w *WriterPool) rowFilter(stream Stream) (*filter.Filter, error) {
+	expression := stream.Self().StreamMetadata.Filter
+	if expression == "" {
+		return nil, nil
+	}
+	if compiled, found := w.filters.Load(stream.ID()); found {
+		return compiled.(*filter.Filter), nil
+	}
+	compiled, err := filter.Compile(expression)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := w.filters.LoadOrStore(stream.ID(), compiled)
+	return actual.(*filter.Filter), nil
+}
+
+// streamTransforms is the column transformer shared by the threads of a stream
+type streamTransforms struct {
+	once        sync.Once
+	transformer *transform.Transformer
+	err         error
+}
+
+// columnTransformer returns the shared transformer of stream; nil when it has none. The
+// transformed columns of the stream schema are typed as strings on first use.
+func (w *WriterPool) columnTransformer(stream Stream) (*transform.Transformer, error) {
+	configs := stream.Self().StreamMetadata.Transforms
+	if len(configs) == 0 {
+		return nil, nil
+	}
+	value, _ := w.transforms.LoadOrStore(stream.ID(), &streamTransforms{})
+	transforms := value.(*streamTransforms)
+	transforms.once.Do(func() {
+		if transforms.transformer, transforms.err = transform.New(configs); transforms.err != nil {
+			return
+		}
+		properties := make(map[string]*types.Property)
+		for _, column := range transforms.transformer.Columns() {
+			if found, _ := stream.Schema().GetProperty(column); found {
+				properties[column] = &types.Property{Type: types.NewSet(types.String)}
+			}
+		}
+		w.tmu.Lock()
+		stream.Schema().Override(properties)
+		w.tmu.Unlock()
+	})
+	return transforms.transformer, transforms.err
+}
+
+// deletesStream returns the stream shared by all threads of stream for its deletes
+func (w *WriterPool) deletesStream(stream Stream) Stream {
+	deletes, _ := w.deleteStreams.LoadOrStore(stream.ID(), stream.Self().DeletesStream())
+	return deletes.(*types.ConfiguredStream)
+}
+
+// changeDetector returns the shared change detector of a full refresh stream with change
+// detection enabled; nil otherwise
+func (w *WriterPool) changeDetector(stream Stream) (*changeDetector, error) {
+	if stream.GetSyncMode() != types.FULLREFRESH || !stream.Self().StreamMetadata.ChangeDetection {
+		return nil, nil
+	}
+
+	w.tmu.Lock()
+	defer w.tmu.Unlock()
+	if detector, found := w.detectors.Load(stream.ID()); found {
+		return detector.(*changeDetector), nil
+	}
+	detector, err := newChangeDetector(stream)
+	if err != nil {
+		return nil, err
+	}
+	w.detectors.Store(stream.ID(), detector)
+	return detector, nil
+}
+
+// EmitDeletes writes delete records for rows that vanished since the previous full refresh;
+// must be called after the stream has been read completely
+func (w *WriterPool) EmitDeletes(ctx context.Context, stream Stream) error {
+	detector, found := w.detectors.Load(stream.ID())
+	if !found {
+		return nil
+	}
+	deleted := detector.(*changeDetector).Deleted()
+	if len(deleted) == 0 {
+		return nil
+	}
+
+	waitChannel := make(chan error, 1)
+	// deletes must not be filtered by the detector
+	insert, err := w.NewThread(ctx, stream, WithErrorChannel(waitChannel), func(opt *Options) {
+		opt.skipChangeDetection = true
+	})
+	if err != nil {
+		return err
+	}
+	for _, record := range deleted {
+		if err := insert.Insert(record); err != nil {
+			insert.Close()
+			return err
+		}
+	}
+	insert.Close()
+	logger.Infof("Emitted %d deletes for stream[%s] found by change detection", len(deleted), stream.ID())
+	return <-waitChannel
+}
+
+// SaveHashIndexes persists row hashes of change detection streams; only to be called once
+// every record has been written
+func (w *WriterPool) SaveHashIndexes() error {
+	var err error
+	w.detectors.Range(func(key, value any) bool {
+		// a failed stream wasn't read completely; its index must not replace the previous one
+		if _, failed := w.lifecycle.failed.Load(key); failed {
+			return true
+		}
+		if saveErr := value.(*changeDetector).Save(); saveErr != nil {
+			err = fmt.Errorf("failed to save hash index of stream[%s]: %s", key, saveErr)
+			return false
+		}
+		return true
+	})
+	return err
+}
+
+// coerce converts values of types not supported by the destination in place
+func (w *WriterPool) coerce(stream Stream, record types.RawRecord) error {
+	if len(w.coercions) == 0 {
+		return nil
+	}
+	for column, value := range record.Data {
+		from := typeutils.TypeFromValue(value)
+		target, found := w.coercions[from]
+		if !found {
+			continue
+		}
+		coerced, err := typeutils.Coerce(target, value)
+		if err != nil {
+			return fmt.Errorf("failed to coerce column[%s] from %s to %s: %s", column, from, target, err)
+		}
+		record.Data[column] = coerced
+		if _, warned := w.coerced.LoadOrStore(stream.ID()+"."+column, struct{}{}); !warned {
+			logger.Warnf("Coercing column[%s] of stream[%s] from %s to %s", column, stream.ID(), from, target)
+		}
+	}
+	return nil
+}
+
+// checkContract validates record against the stream schema and applies the configured
+// violation action; returns false when the record must be skipped
+func (w *WriterPool) checkContract(stream Stream, record types.RawRecord) (bool, error) {
+	validate := typeutils.ValidateRecord
+	if record.Partial {
+		validate = typeutils.ValidatePartialRecord
+	}
+	violation := validate(stream.Schema(), record.Data)
+	if violation == nil {
+		return true, nil
+	}
+
+	counter, _ := w.violations.LoadOrStore(stream.ID(), &atomic.Int64{})
+	counter.(*atomic.Int64).Add(1)
+
+	switch w.contract.OnViolation {
+	case types.ViolationDrop:
+		logger.ThrottledWarnf("contract:"+stream.ID(), "dropping record of stream[%s]: %s", stream.ID(), violation)
+		return false, nil
+	case types.ViolationDeadLetter:
+		return false, w.deadLetter.Send(w.groupCtx, dlq.NewLetter(stream.ID(), dlq.StageValidation, violation, record.Data))
+	default:
+		return false, fmt.Errorf("data contract violation in stream[%s]: %s", stream.ID(), violation)
+	}
+}
+
+// reject sends a failed record to the dead letter queue; the failure is returned as is when
+// no queue is configured
+func (w *WriterPool) reject(ctx context.Context, stream Stream, record types.RawRecord, stage dlq.Stage, reason error) error {
+	if w.deadLetter == nil {
+		return reason
+	}
+	logger.ThrottledWarnf("dead-letter:"+stream.ID(), "dead lettering record of stream[%s] at stage[%s]: %s", stream.ID(), stage, reason)
+	return w.deadLetter.Send(ctx, dlq.NewLetter(stream.ID(), stage, reason, record.Data))
+}
+
+// DeadLetters returns number of records sent to the dead letter queue
+func (w *WriterPool) DeadLetters() int64 {
+	if w.deadLetter == nil {
+		return 0
+	}
+	return w.deadLetter.Count()
+}
+
+// Violations returns data contract violations per stream id
+func (w *WriterPool) Violations() map[string]int64 {
+	violations := make(map[string]int64)
+	w.violations.Range(func(key, value any) bool {
+		violations[key.(string)] = value.(*atomic.Int64).Load()
+		return true
+	})
+	return violations
+}
+
 // Returns total records fetched at runtime
 func (w *WriterPool) SyncedRecords() int64 {
 	return w.recordCount.Load()
 }
 
-func (w *WriterPool) AddRecordsToSync(recordCount int64) {
+// AddRecordsToSync adds to the records expected of the stream; used to estimate the
+// remaining time
+func (w *WriterPool) AddRecordsToSync(stream Stream, recordCount int64) {
 	w.totalRecords.Add(recordCount)
+	w.streamProgress(stream.ID()).total.Add(recordCount)
+}
+
+// SyncedBytes returns the bytes of records read from the sources and written to the
+// destination, counted as max_record_size counts them, before serialization and compression
+func (w *WriterPool) SyncedBytes() (read, written int64) {
+	return w.readBytes.Load(), w.writtenBytes.Load()
+}
+
+// streamProgress counts the records expected and written of a stream
+type streamProgress struct {
+	total     atomic.Int64
+	synced    atomic.Int64
+	read      atomic.Int64 // bytes of the records read
+	written   atomic.Int64 // bytes of the records written
+	filtered  atomic.Int64 // left out by the stream's filter
+	replaced  atomic.Int64 // characters replaced with U+FFFD converting text to UTF-8
+	oversized atomic.Int64 // records above the max record size
+}
+
+func (w *WriterPool) streamProgress(streamID string) *streamProgress {
+	progress, _ := w.progress.LoadOrStore(streamID, &streamProgress{})
+	return progress.(*streamProgress)
+}
+
+// countRead counts the bytes of a record read from the source, before filters and transforms
+func (w *WriterPool) countRead(stream Stream, record types.RawRecord) {
+	size := int64(typeutils.RecordSize(record.Data))
+	w.readBytes.Add(size)
+	w.streamProgress(stream.ID()).read.Add(size)
+}
+
+// countRecord counts a record written with its size
+func (w *WriterPool) countRecord(stream Stream, size int) {
+	w.recordCount.Add(1)
+	w.writtenBytes.Add(int64(size))
+	progress := w.streamProgress(stream.ID())
+	progress.synced.Add(1)
+	progress.written.Add(int64(size))
+	if w.checkpoints != nil {
+		w.checkpoints.written()
+	}
 }
 
 func (w *WriterPool) GetRecordsToSync() int64 {
 	return w.totalRecords.Load()
 }
 
+// Stats samples the progress of the sync and its streams for the stats logger
+func (w *WriterPool) Stats() logger.Stats {
+	stats := logger.Stats{
+		SyncedRecords:  w.SyncedRecords(),
+		RunningThreads: w.threadCounter.Load(),
+		RecordsToSync:  w.GetRecordsToSync(),
+		Streams:        make(map[string]logger.StreamStats),
+	}
+	stats.ReadBytes, stats.WrittenBytes = w.SyncedBytes()
+	w.progress.Range(func(key, value any) bool {
+		progress := value.(*streamProgress)
+		stats.Streams[key.(string)] = logger.StreamStats{
+			SyncedRecords:      progress.synced.Load(),
+			RecordsToSync:      progress.total.Load(),
+			ReplacedCharacters: progress.replaced.Load(),
+			ReadBytes:          progress.read.Load(),
+			WrittenBytes:       progress.written.Load(),
+		}
+		return true
+	})
+	return stats
+}
+
 func (w *WriterPool) Wait() error {
-	return w.group.Wait()
+	err := w.group.Wait()
+	if w.deadLetter != nil {
+		if closeErr := w.deadLetter.Close(context.Background()); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close dead letter queue: %s", closeErr)
+		}
+	}
+	return err
 }
@@ -1,9 +1,8 @@
 package safego
 
 import (
+	"fmt"
 	"os"
-	"runtime/debug"
-	"strings"
 	"time"
 
 	"github.com/datazip-inc/olake/logger"
@@ -71,14 +70,12 @@ func (exec *Execution) WithRestartTimeout(timeout time.Duration) *Execution {
 func Recovery(exit bool) {
 	err := recover()
 	if err != nil {
-		logger.Error(err)
-		// capture stacks trace
-		for _, str := range strings.Split(string(debug.Stack()), "\n") {
-			logger.Error(strings.ReplaceAll(str, "\t", ""))
-		}
+		// the stack of the deferred call includes the panicking frames
+		logger.ErrorWithStack(fmt.Errorf("panic: %v", err))
 	}
 	if exit {
 		logger.Infof("Time of execution %v", time.Since(startTime).String())
+		logger.Close()
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,86 @@
+package testkit
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+	"github.com/goccy/go-json"
+)
+
+var update = flag.Bool("update", false, "update testkit golden files")
+
+// AssertGolden compares records with the golden file at path, ignoring the given columns
+// (e.g. sync timestamps); run tests with -update to rewrite the file
+func AssertGolden(t testing.TB, path string, records []types.RawRecord, ignoreColumns ...string) {
+	t.Helper()
+
+	actual, err := GoldenJSON(records, ignoreColumns...)
+	if err != nil {
+		t.Fatalf("failed to marshal records: %s", err)
+	}
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, actual, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file[%s]; run with -update to create it: %s", path, err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(expected), bytes.TrimSpace(actual)) {
+		t.Fatalf("records differ from golden file[%s]\nexpected:\n%s\nactual:\n%s", path, expected, actual)
+	}
+}
+
+// GoldenJSON renders records deterministically: sorted by olake id and then content, with
+// ignored columns and insert time dropped
+func GoldenJSON(records []types.RawRecord, ignoreColumns ...string) ([]byte, error) {
+	type goldenRecord struct {
+		OlakeID    string         `json:"olake_id"`
+		DeleteTime int64          `json:"cdc_deleted_at,omitempty"`
+		Data       map[string]any `json:"data"`
+	}
+
+	rendered := make([]goldenRecord, 0, len(records))
+	keys := make([]string, 0, len(records))
+	for _, record := range records {
+		data := make(map[string]any, len(record.Data))
+		for column, value := range record.Data {
+			data[column] = value
+		}
+		for _, column := range ignoreColumns {
+			delete(data, column)
+		}
+		golden := goldenRecord{OlakeID: record.OlakeID, DeleteTime: record.DeleteTime, Data: data}
+		key, err := json.Marshal(golden)
+		if err != nil {
+			return nil, err
+		}
+		rendered = append(rendered, golden)
+		keys = append(keys, string(key))
+	}
+
+	indexes := make([]int, len(rendered))
+	for idx := range indexes {
+		indexes[idx] = idx
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return keys[indexes[a]] < keys[indexes[b]]
+	})
+	sorted := make([]goldenRecord, 0, len(rendered))
+	for _, idx := range indexes {
+		sorted = append(sorted, rendered[idx])
+	}
+	return json.MarshalIndent(sorted, "", "  ")
+}
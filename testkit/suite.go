@@ -0,0 +1,325 @@
+// Package testkit is the acceptance suite for drivers: it checks that a driver conforms to the
+// protocol (spec, check, discover, read, state round trip and failure handling) against a
+// live source, and offers golden file helpers for comparing the records it reads.
+package testkit
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/goccy/go-json"
+	"github.com/spf13/viper"
+)
+
+// Suite describes the driver under test
+type Suite struct {
+	// NewDriver returns a fresh driver; called once per phase since drivers cache streams and state
+	NewDriver func() protocol.Driver
+	// Config is unmarshalled into the driver config reference
+	Config any
+	// Streams to read by id; every discovered stream when empty
+	Streams []string
+	// Timeout for reading a single stream; defaults to 5 minutes
+	Timeout time.Duration
+}
+
+// Run executes the acceptance suite as subtests of t
+func Run(t *testing.T, suite *Suite) {
+	if suite.Timeout == 0 {
+		suite.Timeout = 5 * time.Minute
+	}
+	// state and dead letters are written to the config folder
+	viper.Set("CONFIG_FOLDER", t.TempDir())
+
+	t.Run("Spec", suite.testSpec)
+	t.Run("Check", suite.testCheck)
+	t.Run("Discover", suite.testDiscover)
+	t.Run("Read", suite.testRead)
+	t.Run("StateRoundTrip", suite.testStateRoundTrip)
+	t.Run("WriterFailure", suite.testWriterFailure)
+}
+
+// Setup returns a driver with config loaded and Setup done
+func (s *Suite) Setup(t testing.TB) protocol.Driver {
+	t.Helper()
+	driver := s.NewDriver()
+	if err := utils.Unmarshal(s.Config, driver.GetConfigRef()); err != nil {
+		t.Fatalf("failed to unmarshal config: %s", err)
+	}
+	if err := driver.Setup(); err != nil {
+		t.Fatalf("setup failed: %s", err)
+	}
+	return driver
+}
+
+func (s *Suite) testSpec(t *testing.T) {
+	driver := s.NewDriver()
+	spec := driver.Spec()
+	if spec == nil {
+		t.Fatal("Spec() returned nil")
+	}
+	config := driver.GetConfigRef()
+	if reflect.ValueOf(config).Kind() != reflect.Pointer {
+		t.Fatalf("GetConfigRef() must return a pointer, got %T", config)
+	}
+	if specType, configType := reflect.TypeOf(spec), reflect.TypeOf(config).Elem(); specType != configType {
+		t.Fatalf("Spec() returns %s but config reference is %s", specType, configType)
+	}
+	if _, err := json.Marshal(spec); err != nil {
+		t.Fatalf("spec is not serializable: %s", err)
+	}
+	if driver.Type() == "" {
+		t.Fatal("Type() is empty")
+	}
+}
+
+func (s *Suite) testCheck(t *testing.T) {
+	if err := s.Setup(t).Check(); err != nil {
+		t.Fatalf("check failed: %s", err)
+	}
+}
+
+func (s *Suite) testDiscover(t *testing.T) {
+	driver := s.Setup(t)
+	streams, err := driver.Discover(true)
+	if err != nil {
+		t.Fatalf("discover failed: %s", err)
+	}
+	if len(streams) == 0 {
+		t.Fatal("no streams discovered")
+	}
+
+	ids := make(map[string]bool)
+	for _, stream := range streams {
+		if ids[stream.ID()] {
+			t.Errorf("stream[%s] discovered twice", stream.ID())
+		}
+		ids[stream.ID()] = true
+		ValidateStream(t, stream)
+	}
+
+	// a discovered catalog has to survive the trip through the catalog file
+	data, err := json.Marshal(types.GetWrappedCatalog(streams))
+	if err != nil {
+		t.Fatalf("failed to marshal catalog: %s", err)
+	}
+	catalog := &types.Catalog{}
+	if err := json.Unmarshal(data, catalog); err != nil {
+		t.Fatalf("failed to unmarshal catalog: %s", err)
+	}
+	if len(catalog.Streams) != len(streams) {
+		t.Fatalf("catalog round trip lost streams: %d of %d", len(catalog.Streams), len(streams))
+	}
+
+	// discover again must serve the same streams
+	again, err := driver.Discover(true)
+	if err != nil {
+		t.Fatalf("second discover failed: %s", err)
+	}
+	if len(again) != len(streams) {
+		t.Fatalf("second discover returned %d streams instead of %d", len(again), len(streams))
+	}
+}
+
+// ValidateStream checks the invariants every discovered stream must hold
+func ValidateStream(t testing.TB, stream *types.Stream) {
+	t.Helper()
+	if stream.Name == "" {
+		t.Errorf("stream with empty name in namespace[%s]", stream.Namespace)
+	}
+	if stream.SupportedSyncModes == nil || stream.SupportedSyncModes.Len() == 0 {
+		t.Errorf("stream[%s] supports no sync mode", stream.ID())
+	} else if stream.SyncMode != "" && !stream.SupportedSyncModes.Exists(stream.SyncMode) {
+		t.Errorf("stream[%s] defaults to unsupported sync mode[%s]", stream.ID(), stream.SyncMode)
+	}
+	if stream.Schema == nil {
+		t.Errorf("stream[%s] has no schema", stream.ID())
+		return
+	}
+
+	stream.Schema.Properties.Range(func(key, value any) bool {
+		property := value.(*types.Property)
+		if property.Type == nil || property.Type.Len() == 0 {
+			t.Errorf("stream[%s] column[%s] has no type", stream.ID(), key)
+		}
+		return true
+	})
+	stream.SourceDefinedPrimaryKey.Range(func(key string) {
+		if found, _ := stream.Schema.GetProperty(key); !found {
+			t.Errorf("stream[%s] primary key[%s] missing from schema", stream.ID(), key)
+		}
+	})
+	stream.AvailableCursorFields.Range(func(key string) {
+		if found, _ := stream.Schema.GetProperty(key); !found {
+			t.Errorf("stream[%s] cursor field[%s] missing from schema", stream.ID(), key)
+		}
+	})
+	if stream.SupportedSyncModes != nil && stream.SupportedSyncModes.Exists(types.INCREMENTAL) && stream.AvailableCursorFields.Len() == 0 {
+		t.Errorf("stream[%s] supports incremental without cursor fields", stream.ID())
+	}
+}
+
+// ReadStream reads a stream in the given mode into a fresh collector using state
+func (s *Suite) ReadStream(t testing.TB, driver protocol.Driver, stream *types.ConfiguredStream, state *types.State, failAfter int64) ([]types.RawRecord, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout)
+	defer cancel()
+
+	collector := NewCollector(failAfter)
+	pool, err := collector.NewPool(ctx)
+	if err != nil {
+		t.Fatalf("failed to create writer pool: %s", err)
+	}
+	driver.SetupState(state)
+
+	done := make(chan error, 1)
+	go func() {
+		err := driver.Read(ctx, pool, stream)
+		if waitErr := pool.Wait(); err == nil {
+			err = waitErr
+		}
+		done <- err
+	}()
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		t.Fatalf("reading stream[%s] did not finish within %s", stream.ID(), s.Timeout)
+	}
+	return collector.Records(stream.ID()), err
+}
+
+// NewState returns an empty stream state as set up by the sync command
+func NewState() *types.State {
+	return &types.State{RWMutex: &sync.RWMutex{}, Type: types.StreamType}
+}
+
+// selected returns the configured streams to read in mode
+func (s *Suite) selected(t testing.TB, driver protocol.Driver, mode types.SyncMode) []*types.ConfiguredStream {
+	t.Helper()
+	streams, err := driver.Discover(true)
+	if err != nil {
+		t.Fatalf("discover failed: %s", err)
+	}
+
+	var configured []*types.ConfiguredStream
+	for _, stream := range streams {
+		if len(s.Streams) > 0 && !utils.ExistInArray(s.Streams, stream.ID()) {
+			continue
+		}
+		if !stream.SupportedSyncModes.Exists(mode) {
+			continue
+		}
+		stream.SyncMode = mode
+		elem := &types.ConfiguredStream{Stream: stream}
+		if mode == types.INCREMENTAL {
+			elem.CursorField = stream.AvailableCursorFields.Array()[0]
+		}
+		configured = append(configured, elem)
+	}
+	return configured
+}
+
+func (s *Suite) testRead(t *testing.T) {
+	driver := s.Setup(t)
+	streams := s.selected(t, driver, types.FULLREFRESH)
+	if len(streams) == 0 {
+		t.Skip("no stream supports full refresh")
+	}
+
+	for _, stream := range streams {
+		t.Run(stream.ID(), func(t *testing.T) {
+			records, err := s.ReadStream(t, driver, stream, NewState(), 0)
+			if err != nil {
+				t.Fatalf("read failed: %s", err)
+			}
+
+			seen := make(map[string]bool)
+			for _, record := range records {
+				if err := typeutils.ValidateRecord(stream.Schema(), record.Data); err != nil {
+					t.Errorf("record[%s] does not match schema: %s", record.OlakeID, err)
+				}
+				if record.OlakeID == "" {
+					t.Errorf("record without olake id: %v", record.Data)
+				}
+				if stream.GetStream().SourceDefinedPrimaryKey.Len() > 0 && seen[record.OlakeID] {
+					t.Errorf("duplicate olake id[%s] in full refresh", record.OlakeID)
+				}
+				seen[record.OlakeID] = true
+			}
+			t.Logf("read %d records", len(records))
+		})
+	}
+}
+
+func (s *Suite) testStateRoundTrip(t *testing.T) {
+	streams := s.selected(t, s.Setup(t), types.INCREMENTAL)
+	if len(streams) == 0 {
+		t.Skip("no stream supports incremental")
+	}
+
+	for _, stream := range streams {
+		t.Run(stream.ID(), func(t *testing.T) {
+			state := NewState()
+			first, err := s.ReadStream(t, s.Setup(t), stream, state, 0)
+			if err != nil {
+				t.Fatalf("first read failed: %s", err)
+			}
+
+			// the state has to survive being written to and loaded from the state file
+			data, err := json.Marshal(state)
+			if err != nil {
+				t.Fatalf("failed to marshal state: %s", err)
+			}
+			loaded := NewState()
+			if err := json.Unmarshal(data, loaded); err != nil {
+				t.Fatalf("failed to unmarshal state %s: %s", data, err)
+			}
+			if len(first) > 0 && len(loaded.Streams) == 0 {
+				t.Fatalf("no state saved after reading %d records", len(first))
+			}
+
+			second, err := s.ReadStream(t, s.Setup(t), stream, loaded, 0)
+			if err != nil {
+				t.Fatalf("read from loaded state %s failed: %s", data, err)
+			}
+			// only rows changed in between and rows at the cursor boundary may be read again
+			if len(second) > len(first) {
+				t.Errorf("read from state returned %d records, first read returned %d", len(second), len(first))
+			}
+		})
+	}
+}
+
+func (s *Suite) testWriterFailure(t *testing.T) {
+	driver := s.Setup(t)
+	streams := s.selected(t, driver, types.FULLREFRESH)
+	if len(streams) == 0 {
+		t.Skip("no stream supports full refresh")
+	}
+
+	// the driver has to give up once the destination fails instead of hanging or succeeding
+	for _, stream := range streams {
+		records, err := s.ReadStream(t, driver, stream, NewState(), 0)
+		if err != nil {
+			t.Fatalf("stream[%s]: read failed: %s", stream.ID(), err)
+		}
+		if len(records) < 2 {
+			continue
+		}
+
+		_, err = s.ReadStream(t, driver, stream, NewState(), 1)
+		if err == nil {
+			t.Fatalf("stream[%s]: read succeeded although the writer failed", stream.ID())
+		}
+		return
+	}
+	t.Skip("no stream returned more than one record")
+}
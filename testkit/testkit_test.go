@@ -0,0 +1,122 @@
+package testkit
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/datazip-inc/olake/drivers/base"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+)
+
+type fakeConfig struct {
+	Rows int `json:"rows"`
+}
+
+func (c *fakeConfig) Validate() error {
+	return nil
+}
+
+// fakeDriver serves rows with ids 1..Rows; incremental reads start after the id cursor
+type fakeDriver struct {
+	*base.Driver
+	config *fakeConfig
+}
+
+func (f *fakeDriver) GetConfigRef() protocol.Config {
+	f.config = &fakeConfig{}
+	return f.config
+}
+
+func (f *fakeDriver) Spec() any {
+	return fakeConfig{}
+}
+
+func (f *fakeDriver) Setup() error {
+	return f.config.Validate()
+}
+
+func (f *fakeDriver) Check() error {
+	return nil
+}
+
+func (f *fakeDriver) Type() string {
+	return "Fake"
+}
+
+func (f *fakeDriver) SetupState(state *types.State) {
+	f.State = state
+}
+
+func (f *fakeDriver) Discover(_ bool) ([]*types.Stream, error) {
+	if streams := f.GetStreams(); len(streams) != 0 {
+		return streams, nil
+	}
+	stream := types.NewStream("rows", "fake").WithSyncMode(types.FULLREFRESH, types.INCREMENTAL).WithPrimaryKey("id").WithCursorField("id")
+	stream.UpsertField("id", types.Int64, false)
+	stream.UpsertField("name", types.String, true)
+	f.AddStream(stream)
+	return f.GetStreams(), nil
+}
+
+func (f *fakeDriver) Read(ctx context.Context, pool *protocol.WriterPool, stream protocol.Stream) (err error) {
+	start := 0
+	if stream.GetSyncMode() == types.INCREMENTAL {
+		if cursor, ok := f.State.GetCursor(stream.Self(), "id").(float64); ok {
+			start = int(cursor)
+		}
+	}
+
+	waitChannel := make(chan error, 1)
+	insert, err := pool.NewThread(ctx, stream, protocol.WithErrorChannel(waitChannel))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		insert.Close()
+		if err == nil {
+			err = <-waitChannel
+		}
+	}()
+
+	for id := start + 1; id <= f.config.Rows; id++ {
+		record := map[string]any{"id": id, "name": fmt.Sprintf("row-%d", id)}
+		if err := insert.Insert(types.CreateRawRecord(utils.GetKeysHash(record, "id"), record, 0)); err != nil {
+			return err
+		}
+	}
+	if stream.GetSyncMode() == types.INCREMENTAL {
+		f.State.SetCursor(stream.Self(), "id", f.config.Rows)
+	}
+	return nil
+}
+
+func TestSuite(t *testing.T) {
+	Run(t, &Suite{
+		NewDriver: func() protocol.Driver {
+			return &fakeDriver{Driver: base.NewBase()}
+		},
+		Config: map[string]any{"rows": 3},
+	})
+}
+
+func TestGolden(t *testing.T) {
+	records := []types.RawRecord{
+		types.CreateRawRecord("b", map[string]any{"id": 2, "synced_at": 100}, 0),
+		types.CreateRawRecord("a", map[string]any{"id": 1, "synced_at": 200}, 0),
+	}
+	path := filepath.Join(t.TempDir(), "rows.golden.json")
+
+	*update = true
+	AssertGolden(t, path, records, "synced_at")
+	*update = false
+
+	// order and ignored columns must not matter
+	AssertGolden(t, path, []types.RawRecord{
+		types.CreateRawRecord("a", map[string]any{"id": 1, "synced_at": 300}, 0),
+		types.CreateRawRecord("b", map[string]any{"id": 2}, 0),
+	}, "synced_at")
+}
@@ -0,0 +1,168 @@
+package testkit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+	"github.com/datazip-inc/olake/utils"
+)
+
+// MemoryWriterType is the destination type collecting records in memory
+const MemoryWriterType types.AdapterType = "TESTKIT_MEMORY"
+
+var collectors sync.Map // collector id to *Collector
+
+func init() {
+	protocol.RegisteredWriters[MemoryWriterType] = func() protocol.Writer {
+		return &memoryWriter{}
+	}
+}
+
+// Collector holds the records written by memory writers
+type Collector struct {
+	id        string
+	mutex     sync.Mutex
+	records   map[string][]types.RawRecord // stream id to records
+	failAfter int64
+	written   int64
+}
+
+// NewCollector registers a collector; failAfter > 0 makes writes fail once that many records are written
+func NewCollector(failAfter int64) *Collector {
+	collector := &Collector{
+		id:        utils.ULID(),
+		records:   make(map[string][]types.RawRecord),
+		failAfter: failAfter,
+	}
+	collectors.Store(collector.id, collector)
+	return collector
+}
+
+// NewPool returns a writer pool writing into the collector
+func (c *Collector) NewPool(ctx context.Context) (*protocol.WriterPool, error) {
+	return protocol.NewWriter(ctx, &types.WriterConfig{
+		Type:         MemoryWriterType,
+		WriterConfig: map[string]any{"collector": c.id},
+	})
+}
+
+// Records returns the records written for a stream
+func (c *Collector) Records(streamID string) []types.RawRecord {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]types.RawRecord(nil), c.records[streamID]...)
+}
+
+// Reset drops collected records
+func (c *Collector) Reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.records = make(map[string][]types.RawRecord)
+	c.written = 0
+}
+
+func (c *Collector) add(streamID string, record types.RawRecord) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.failAfter > 0 && c.written >= c.failAfter {
+		return fmt.Errorf("testkit: memory writer failing after %d records", c.failAfter)
+	}
+	c.written++
+	c.records[streamID] = append(c.records[streamID], record)
+	return nil
+}
+
+type memoryConfig struct {
+	Collector string `json:"collector"`
+}
+
+func (m *memoryConfig) Validate() error {
+	if m.Collector == "" {
+		return fmt.Errorf("collector not set")
+	}
+	return nil
+}
+
+type memoryWriter struct {
+	config    *memoryConfig
+	collector *Collector
+	stream    protocol.Stream
+}
+
+func (m *memoryWriter) GetConfigRef() protocol.Config {
+	m.config = &memoryConfig{}
+	return m.config
+}
+
+func (m *memoryWriter) Spec() any {
+	return memoryConfig{}
+}
+
+func (m *memoryWriter) Check() error {
+	if err := m.config.Validate(); err != nil {
+		return err
+	}
+	collector, found := collectors.Load(m.config.Collector)
+	if !found {
+		return fmt.Errorf("collector[%s] not registered", m.config.Collector)
+	}
+	m.collector = collector.(*Collector)
+	return nil
+}
+
+func (m *memoryWriter) Type() string {
+	return string(MemoryWriterType)
+}
+
+func (m *memoryWriter) Setup(stream protocol.Stream, _ *protocol.Options) error {
+	m.stream = stream
+	return m.Check()
+}
+
+func (m *memoryWriter) Write(_ context.Context, record types.RawRecord) error {
+	return m.collector.add(m.stream.ID(), record)
+}
+
+func (m *memoryWriter) Delete(_ context.Context, record types.RawRecord) error {
+	return m.collector.add(m.stream.ID(), record)
+}
+
+func (m *memoryWriter) Normalization() bool {
+	return false
+}
+
+func (m *memoryWriter) SupportedTypes() *types.Set[types.DataType] {
+	return types.NewSet(types.DataTypes...)
+}
+
+func (m *memoryWriter) Flattener() protocol.FlattenFunction {
+	return typeutils.NewFlattener().Flatten
+}
+
+func (m *memoryWriter) EvolveSchema(_, _ bool, _ map[string]*types.Property, _ types.Record) error {
+	return nil
+}
+
+func (m *memoryWriter) Close() error {
+	return nil
+}
+
+func (m *memoryWriter) BeginSync(_ context.Context) error {
+	return nil
+}
+
+func (m *memoryWriter) BeginStream(_ context.Context, _ protocol.Stream) error {
+	return nil
+}
+
+func (m *memoryWriter) EndStream(_ context.Context, _ protocol.Stream) error {
+	return nil
+}
+
+func (m *memoryWriter) EndSync(_ context.Context, _ error) error {
+	return nil
+}
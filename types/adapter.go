@@ -1,14 +1,73 @@
 package types
 
+import (
+	"fmt"
+
+	"github.com/datazip-inc/olake/pkg/dlq"
+	"github.com/datazip-inc/olake/pkg/notify"
+	"github.com/datazip-inc/olake/pkg/offload"
+	"github.com/datazip-inc/olake/pkg/spool"
+)
+
 type AdapterType string
 
 const (
-	Parquet   AdapterType = "PARQUET"
-	S3Iceberg AdapterType = "S3_ICEBERG"
+	Parquet    AdapterType = "PARQUET"
+	S3Iceberg  AdapterType = "S3_ICEBERG"
+	NullWriter AdapterType = "NULL"
+	Stdout     AdapterType = "STDOUT"
+	Kafka      AdapterType = "KAFKA"
+	File       AdapterType = "FILE"
+)
+
+// ViolationAction decides what happens to records breaking the stream schema
+type ViolationAction string
+
+const (
+	ViolationDrop       ViolationAction = "drop"
+	ViolationDeadLetter ViolationAction = "dead_letter"
+	ViolationFail       ViolationAction = "fail"
 )
 
 // TODO: Add validations
 type WriterConfig struct {
-	Type         AdapterType `json:"type"`
-	WriterConfig any         `json:"writer"`
+	Type         AdapterType     `json:"type"`
+	WriterConfig any             `json:"writer"`
+	Contract     *ContractConfig `json:"contract,omitempty"`
+	// rejected records go to the dead letter queue instead of failing the sync when set
+	DeadLetter *dlq.Config `json:"dead_letter,omitempty"`
+	// destination table naming; source names are kept when unset
+	Naming *NamingConfig `json:"naming,omitempty"`
+	// values of a source type are converted to the mapped type before write; types the writer
+	// doesn't support are coerced to a fallback even without a mapping
+	Coercion map[DataType]DataType `json:"coercion,omitempty"`
+	// lineage columns added to every written record
+	MetadataColumns *MetadataColumnsConfig `json:"metadata_columns,omitempty"`
+	// stats pushed to statsd and sync start/end posted to webhooks
+	Notifications *notify.Config `json:"notifications,omitempty"`
+	// where values of columns with the offload large value policy are uploaded; the file
+	// store in the config folder when unset
+	LargeValueStore *offload.Config `json:"large_value_store,omitempty"`
+	// records above the size are dead lettered or truncated
+	MaxRecordSize *RecordSizeConfig `json:"max_record_size,omitempty"`
+	// records are queued on disk between readers and writer threads when set
+	Queue *spool.Config `json:"queue,omitempty"`
+	// changes writers of SQL destinations make to tables as schemas evolve; none by default
+	SchemaMigration MigrationPolicy `json:"schema_migration,omitempty"`
+}
+
+// ContractConfig enables validation of every record against its stream schema before write
+type ContractConfig struct {
+	OnViolation ViolationAction `json:"on_violation"`
+}
+
+func (c *ContractConfig) Validate() error {
+	switch c.OnViolation {
+	case "":
+		c.OnViolation = ViolationFail
+	case ViolationDrop, ViolationDeadLetter, ViolationFail:
+	default:
+		return fmt.Errorf("invalid contract on_violation[%s]; expected one of drop, dead_letter, fail", c.OnViolation)
+	}
+	return nil
 }
@@ -1,5 +1,12 @@
 package types
 
+import (
+	"fmt"
+	"io"
+
+	"github.com/goccy/go-json"
+)
+
 // Message is a dto for olake output row representation
 type Message struct {
 	Type             MessageType            `json:"type"`
@@ -29,22 +36,75 @@ type Log struct {
 type StatusRow struct {
 	Status  ConnectionStatus `json:"status,omitempty"`
 	Message string           `json:"message,omitempty"`
+	// diagnostics of `check --deep`
+	Checks []CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult is the outcome of a single pre-flight diagnostic
+type CheckResult struct {
+	Component string `json:"component"` // source or destination
+	Name      string `json:"name"`
+	Passed    bool   `json:"passed"`
+	Message   string `json:"message,omitempty"`
+	// how to fix a failed check
+	Remediation string `json:"remediation,omitempty"`
 }
 
+// DeleteMode decides how deletes of a stream reach the destination
+type DeleteMode string
+
+const (
+	DeleteTombstone      DeleteMode = "tombstone"       // write the row with its _cdc_deleted_at column set (default)
+	DeleteHard           DeleteMode = "hard_delete"     // remove the row from the destination
+	DeleteSeparateStream DeleteMode = "separate_stream" // write the row to <stream>_deletes
+)
+
 type StreamMetadata struct {
 	SplitColumn    string `json:"split_column"`
 	PartitionRegex string `json:"partition_regex"`
 	StreamName     string `json:"stream_name"`
+	// writes records to folders by the time of a column; file and parquet writers
+	Partition *TemporalPartition `json:"partition,omitempty"`
+	// emit only new, changed and deleted rows in full refresh by comparing row hashes with the previous run
+	ChangeDetection bool `json:"change_detection,omitempty"`
+	// how deletes emitted by the source are applied; tombstone when empty
+	DeleteMode DeleteMode `json:"delete_mode,omitempty"`
+	// streams with a higher priority start first
+	Priority int `json:"priority,omitempty"`
+	// ids (namespace.name) of streams that must finish reading before this stream starts
+	DependsOn []string `json:"depends_on,omitempty"`
+	// checks run on the stream after it is written
+	Quality *QualityConfig `json:"quality,omitempty"`
+	// expression rows must match to be written, e.g. `status != "deleted"`; see pkg/filter
+	Filter string `json:"filter,omitempty"`
+	// columns encrypted, hashed or masked before records are written
+	Transforms []ColumnTransform `json:"transforms,omitempty"`
+	// columns whose values are truncated, skipped or offloaded above a size
+	LargeValues []LargeValuePolicy `json:"large_values,omitempty"`
+	// charset of the text the source returns, e.g. latin1; decoded into UTF-8 before anything else
+	Encoding string `json:"encoding,omitempty"`
+	// how timestamps stored without a time zone are read; overrides --naive-timestamps
+	NaiveTimestamps NaiveTimestampPolicy `json:"naive_timestamps,omitempty"`
+	// incremental syncs re-read rows this far before the cursor kept in state
+	Lookback Lookback `json:"lookback,omitempty"`
+	// where the first incremental sync starts, such as a start date or a min id; a list of
+	// values for composite cursors. The whole table is read when unset
+	InitialCursor any `json:"initial_cursor,omitempty"`
+	// reads the incremental stream in full again every few runs or days
+	Resync *ResyncPolicy `json:"resync,omitempty"`
 }
 
 // ConfiguredCatalog is a dto for formatted airbyte catalog serialization
 type Catalog struct {
+	// format version; older catalogs are upgraded on load
+	Version         int                         `json:"version,omitempty"`
 	SelectedStreams map[string][]StreamMetadata `json:"selected_streams,omitempty"`
 	Streams         []*ConfiguredStream         `json:"streams,omitempty"`
 }
 
 func GetWrappedCatalog(streams []*Stream) *Catalog {
 	catalog := &Catalog{
+		Version:         CatalogFormat.Version(),
 		Streams:         []*ConfiguredStream{},
 		SelectedStreams: make(map[string][]StreamMetadata),
 	}
@@ -62,3 +122,29 @@ func GetWrappedCatalog(streams []*Stream) *Catalog {
 
 	return catalog
 }
+
+// WriteCatalog writes catalog to w as json.Marshal does, one stream at a time, so catalogs of
+// tens of thousands of streams aren't marshaled at once
+func WriteCatalog(w io.Writer, catalog *Catalog) error {
+	header, err := json.Marshal(catalog.SelectedStreams)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, `{"version":%d,"selected_streams":%s,"streams":[`, catalog.Version, header); err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	for idx, stream := range catalog.Streams {
+		if idx > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		// the encoder ends every value with a newline, which json allows between elements
+		if err := encoder.Encode(stream); err != nil {
+			return fmt.Errorf("failed to write stream[%s] to catalog: %s", stream.ID(), err)
+		}
+	}
+	_, err = io.WriteString(w, "]}")
+	return err
+}
@@ -0,0 +1,122 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CatalogChange is a difference discover found between an existing catalog and the source
+type CatalogChange struct {
+	Stream string `json:"stream"`
+	Change string `json:"change"`
+}
+
+// MergeCatalog builds the catalog of the discovered streams on top of previous: streams keep
+// their selection with its metadata, sync mode, cursor field, primary key and excluded
+// columns, while schemas and supported sync modes are the discovered ones. New streams are
+// listed but left unselected; streams gone from the source are dropped. The changes made are
+// returned.
+func MergeCatalog(previous *Catalog, streams []*Stream) (*Catalog, []CatalogChange) {
+	existing := make(map[string]*ConfiguredStream)
+	for _, stream := range previous.Streams {
+		if stream.Stream != nil {
+			existing[stream.ID()] = stream
+		}
+	}
+	selected := make(map[string]StreamMetadata)
+	for namespace, metadata := range previous.SelectedStreams {
+		for _, one := range metadata {
+			selected[fmt.Sprintf("%s.%s", namespace, one.StreamName)] = one
+		}
+	}
+
+	merged := &Catalog{
+		Version:         CatalogFormat.Version(),
+		Streams:         []*ConfiguredStream{},
+		SelectedStreams: make(map[string][]StreamMetadata),
+	}
+	changes := []CatalogChange{}
+	change := func(stream, format string, args ...any) {
+		changes = append(changes, CatalogChange{Stream: stream, Change: fmt.Sprintf(format, args...)})
+	}
+	discovered := make(map[string]bool)
+	for _, stream := range streams {
+		id := stream.ID()
+		discovered[id] = true
+		configured := &ConfiguredStream{Stream: stream}
+		merged.Streams = append(merged.Streams, configured)
+
+		old, found := existing[id]
+		if !found {
+			change(id, "stream added; select it to sync it")
+			continue
+		}
+		configured.CursorField = old.CursorField
+		configured.PrimaryKey = old.PrimaryKey
+		configured.ExcludeColumns = old.ExcludeColumns
+		if mode := old.Stream.SyncMode; mode != "" && mode != stream.SyncMode {
+			if stream.SupportedSyncModes.Exists(mode) {
+				stream.SyncMode = mode
+			} else {
+				change(id, "sync mode %s is no longer supported; reset to %s", mode, stream.SyncMode)
+			}
+		}
+		for _, message := range diffSchemas(old.Stream.Schema, stream.Schema) {
+			change(id, "%s", message)
+		}
+		for _, cursor := range configured.CursorFields() {
+			if found, _ := stream.Schema.GetProperty(cursor); !found {
+				change(id, "cursor field[%s] is no longer a column", cursor)
+			}
+		}
+		if metadata, ok := selected[id]; ok {
+			merged.SelectedStreams[stream.Namespace] = append(merged.SelectedStreams[stream.Namespace], metadata)
+		}
+	}
+	for _, stream := range previous.Streams {
+		if stream.Stream != nil && !discovered[stream.ID()] {
+			change(stream.ID(), "stream removed from the source")
+		}
+	}
+	return merged, changes
+}
+
+// diffSchemas describes the columns added, removed or retyped between two schemas
+func diffSchemas(old, updated *TypeSchema) []string {
+	if old == nil || updated == nil {
+		return nil
+	}
+	types := func(schema *TypeSchema) map[string]string {
+		columns := make(map[string]string)
+		schema.Properties.Range(func(key, value any) bool {
+			names := []string{}
+			for _, typ := range value.(*Property).Type.Array() {
+				names = append(names, string(typ))
+			}
+			sort.Strings(names)
+			columns[key.(string)] = strings.Join(names, ",")
+			return true
+		})
+		return columns
+	}
+	before, after := types(old), types(updated)
+
+	messages := []string{}
+	for column, typ := range after {
+		previous, found := before[column]
+		switch {
+		case !found:
+			messages = append(messages, fmt.Sprintf("column[%s] added as [%s]", column, typ))
+		case previous != typ:
+			messages = append(messages, fmt.Sprintf("column[%s] changed from [%s] to [%s]", column, previous, typ))
+		}
+	}
+	for column := range before {
+		if _, found := after[column]; !found {
+			messages = append(messages, fmt.Sprintf("column[%s] removed", column))
+		}
+	}
+	sort.Strings(messages)
+	return messages
+}
@@ -19,15 +19,28 @@ const (
 	TimestampMilli DataType = "timestamp_milli" // storing datetime up to 3 precisions
 	TimestampMicro DataType = "timestamp_micro" // storing datetime up to 6 precisions
 	TimestampNano  DataType = "timestamp_nano"  // storing datetime up to 9 precisions
+	Bytes          DataType = "bytes"           // binary values such as bytea and blob
+	Geometry       DataType = "geometry"        // geospatial values, carried as WKB
+	JSON           DataType = "json"            // semi-structured values, carried as their JSON text
 )
 
+// DataTypes lists every data type a stream column can have
+var DataTypes = []DataType{Null, Int64, Float64, String, Bool, Object, Array, Unknown, Timestamp, TimestampMilli, TimestampMicro, TimestampNano, Bytes, Geometry, JSON}
+
 type Record map[string]any
 
+// RawRecord is a row read from the source; a column absent from Data is missing while a column
+// holding nil is null
 type RawRecord struct {
 	OlakeID        string         `parquet:"olake_id"`
 	Data           map[string]any `parquet:"data,json"`
 	DeleteTime     int64          `parquet:"cdc_deleted_at"`
 	OlakeTimestamp int64          `parquet:"olake_insert_time"`
+	// Partial records carry the changed columns only, such as updates of change streams;
+	// missing columns keep their value in the destination
+	Partial bool `parquet:"-"`
+	// Data was taken from NewRecord and is released once written
+	pooled bool
 }
 
 func CreateRawRecord(olakeID string, data map[string]any, deleteAt int64) RawRecord {
@@ -37,6 +50,17 @@ func CreateRawRecord(olakeID string, data map[string]any, deleteAt int64) RawRec
 		DeleteTime: deleteAt,
 	}
 }
+
+// CreatePartialRawRecord creates a record of the changed columns of a row; columns set to nil
+// are nulled in the destination and the others are left as they are
+func CreatePartialRawRecord(olakeID string, data map[string]any) RawRecord {
+	return RawRecord{
+		OlakeID: olakeID,
+		Data:    data,
+		Partial: true,
+	}
+}
+
 func (d DataType) ToNewParquet() parquet.Node {
 	var n parquet.Node
 
@@ -55,6 +79,11 @@ func (d DataType) ToNewParquet() parquet.Node {
 	case Object, Array:
 		// Ensure proper handling of nested structures
 		n = parquet.String()
+	case JSON:
+		n = parquet.JSON()
+	case Bytes, Geometry:
+		// geometries are stored as WKB like GeoParquet does
+		n = parquet.Leaf(parquet.ByteArrayType)
 	default:
 		n = parquet.Leaf(parquet.ByteArrayType)
 	}
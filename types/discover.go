@@ -0,0 +1,45 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// StreamFailure is a stream discover failed to sample
+type StreamFailure struct {
+	Stream string `json:"stream"`
+	Error  string `json:"error"`
+}
+
+// DiscoverError lists the streams discover failed to sample while it went on with the others;
+// safe for concurrent use by the goroutines sampling streams
+type DiscoverError struct {
+	mutex  sync.Mutex
+	Failed []StreamFailure `json:"failed_streams"`
+}
+
+// Add records that sampling stream failed with err
+func (d *DiscoverError) Add(stream string, err error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.Failed = append(d.Failed, StreamFailure{Stream: stream, Error: err.Error()})
+}
+
+// Err returns d when streams failed, else nil
+func (d *DiscoverError) Err() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if len(d.Failed) == 0 {
+		return nil
+	}
+	return d
+}
+
+func (d *DiscoverError) Error() string {
+	streams := make([]string, 0, len(d.Failed))
+	for _, failure := range d.Failed {
+		streams = append(streams, fmt.Sprintf("%s: %s", failure.Stream, failure.Error))
+	}
+	return fmt.Sprintf("failed to discover %d streams; %s", len(d.Failed), strings.Join(streams, "; "))
+}
@@ -0,0 +1,38 @@
+package types
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/datazip-inc/olake/pkg/geo"
+	"github.com/goccy/go-json"
+)
+
+// WKB is a value of a Geometry column; binary destinations store the WKB as is, text
+// destinations get its WKT
+type WKB []byte
+
+// NewWKB returns the WKB of a geometry in WKB, WKT or GeoJSON
+func NewWKB(v any) (WKB, error) {
+	if wkb, ok := v.(WKB); ok {
+		return wkb, nil
+	}
+	geometry, err := geo.Parse(v)
+	if err != nil {
+		return nil, err
+	}
+	return WKB(geometry.WKB()), nil
+}
+
+// String returns the WKT of the geometry, or its hex encoded WKB when it can't be decoded
+func (w WKB) String() string {
+	geometry, err := geo.ParseWKB(w)
+	if err != nil {
+		return strings.ToUpper(hex.EncodeToString(w))
+	}
+	return geometry.WKT()
+}
+
+func (w WKB) MarshalJSON() ([]byte, error) {
+	return json.Marshal(w.String())
+}
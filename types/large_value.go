@@ -0,0 +1,49 @@
+package types
+
+import "fmt"
+
+type LargeValueAction string
+
+const (
+	// cut to max_bytes and, for strings, end with the marker
+	LargeValueTruncate LargeValueAction = "truncate"
+	// written as null
+	LargeValueSkip LargeValueAction = "skip"
+	// uploaded to the large value store of the destination and written as its url
+	LargeValueOffload LargeValueAction = "offload"
+)
+
+// DefaultTruncateMarker ends truncated strings
+const DefaultTruncateMarker = "...[truncated]"
+
+// LargeValuePolicy decides what happens to values of a text or binary column larger than
+// max_bytes, so a single huge row can't exhaust memory of writers buffering records
+type LargeValuePolicy struct {
+	Column   string           `json:"column"`
+	MaxBytes int              `json:"max_bytes"`
+	Action   LargeValueAction `json:"action"`
+	// truncate: defaults to DefaultTruncateMarker; counted within max_bytes
+	Marker string `json:"marker,omitempty"`
+}
+
+func (p *LargeValuePolicy) Validate() error {
+	if p.Column == "" {
+		return fmt.Errorf("large value column not set")
+	}
+	if p.MaxBytes <= 0 {
+		return fmt.Errorf("max_bytes of column[%s] must be positive", p.Column)
+	}
+	switch p.Action {
+	case LargeValueTruncate:
+		if p.Marker == "" {
+			p.Marker = DefaultTruncateMarker
+		}
+		if len(p.Marker) >= p.MaxBytes {
+			return fmt.Errorf("marker of column[%s] must be shorter than max_bytes", p.Column)
+		}
+	case LargeValueSkip, LargeValueOffload:
+	default:
+		return fmt.Errorf("invalid large value action[%s] of column[%s]; valid are truncate, skip, offload", p.Action, p.Column)
+	}
+	return nil
+}
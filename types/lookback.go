@@ -0,0 +1,55 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Lookback is how far before the cursor kept in state incremental syncs start reading, so
+// rows arriving late or written with a skewed clock are read again; a duration such as "30m"
+// for timestamp cursors, or a count such as "1000" for integer cursors like ids
+type Lookback string
+
+// Parse returns the duration of a lookback for timestamp cursors, or its count for integer
+// cursors; both are zero without a lookback
+func (l Lookback) Parse() (time.Duration, int64, error) {
+	if l == "" {
+		return 0, 0, nil
+	}
+	if count, err := strconv.ParseInt(string(l), 10, 64); err == nil {
+		if count <= 0 {
+			return 0, 0, fmt.Errorf("lookback[%s] must be positive", l)
+		}
+		return 0, count, nil
+	}
+	duration, err := time.ParseDuration(string(l))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid lookback[%s]; expected a duration such as 30m or a count such as 1000", l)
+	}
+	if duration <= 0 {
+		return 0, 0, fmt.Errorf("lookback[%s] must be positive", l)
+	}
+	return duration, 0, nil
+}
+
+// validate checks the lookback fits the type of the first cursor column, the one it moves
+func (l Lookback) validate(cursorType DataType) error {
+	duration, count, err := l.Parse()
+	if err != nil {
+		return err
+	}
+	switch cursorType {
+	case Timestamp, TimestampMilli, TimestampMicro, TimestampNano:
+		if count != 0 {
+			return fmt.Errorf("lookback[%s] of a timestamp cursor must be a duration, such as 30m", l)
+		}
+	case Int64:
+		if duration != 0 {
+			return fmt.Errorf("lookback[%s] of an integer cursor must be a count, such as 1000", l)
+		}
+	default:
+		return fmt.Errorf("lookback needs a timestamp or integer cursor, not %s", cursorType)
+	}
+	return nil
+}
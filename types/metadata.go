@@ -0,0 +1,26 @@
+package types
+
+// Operation is the change a written record stands for, set in the _olake_op column
+type Operation string
+
+const (
+	// OpRead is a row read by a full refresh, incremental or backfill
+	OpRead Operation = "r"
+	// OpUpsert is a row inserted or updated through a change stream
+	OpUpsert Operation = "u"
+	// OpDelete is a row deleted in the source
+	OpDelete Operation = "d"
+)
+
+// MetadataColumnsConfig selects the metadata columns added to records before write; they let
+// destinations deduplicate replays and trace rows back to the run that wrote them
+type MetadataColumnsConfig struct {
+	// _olake_synced_at: time the record was handed to the writer
+	SyncedAt bool `json:"synced_at,omitempty"`
+	// _olake_sync_id: id of the run that wrote the record
+	SyncID bool `json:"sync_id,omitempty"`
+	// _olake_raw_id: hash of the source record, equal for identical rows across runs
+	RawID bool `json:"raw_id,omitempty"`
+	// _olake_op: one of r, u or d
+	Op bool `json:"op,omitempty"`
+}
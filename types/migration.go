@@ -0,0 +1,28 @@
+package types
+
+import "fmt"
+
+// MigrationPolicy bounds the changes the tables of SQL destinations get as the schema of their
+// stream evolves; each policy allows the changes of the ones before it
+type MigrationPolicy string
+
+const (
+	// MigrateNone leaves tables as they are
+	MigrateNone MigrationPolicy = "none"
+	// MigrateAddColumns adds new columns as nullable columns
+	MigrateAddColumns MigrationPolicy = "add_columns"
+	// MigrateWiden also widens columns in place to a type holding their earlier values, e.g.
+	// to text, and drops not null constraints of columns that became nullable
+	MigrateWiden MigrationPolicy = "widen"
+)
+
+func (p *MigrationPolicy) Validate() error {
+	switch *p {
+	case "":
+		*p = MigrateNone
+	case MigrateNone, MigrateAddColumns, MigrateWiden:
+	default:
+		return fmt.Errorf("invalid schema_migration[%s]; expected one of none, add_columns, widen", *p)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/datazip-inc/olake/utils"
+)
+
+type CaseFolding string
+
+const (
+	CasePreserve CaseFolding = ""
+	CaseLower    CaseFolding = "lower"
+	CaseUpper    CaseFolding = "upper"
+)
+
+// NamingConfig derives destination table names from source streams; the same rules are
+// applied for every writer
+type NamingConfig struct {
+	Prefix string      `json:"prefix,omitempty"`
+	Suffix string      `json:"suffix,omitempty"`
+	Case   CaseFolding `json:"case,omitempty"`
+	// replaces characters other than letters, digits and underscore with underscore
+	Sanitize bool `json:"sanitize,omitempty"`
+	// explicit stream id (namespace.name) to destination table name; used verbatim
+	Mapping map[string]string `json:"mapping,omitempty"`
+	// explicit source namespace to destination namespace; used verbatim
+	NamespaceMapping map[string]string `json:"namespace_mapping,omitempty"`
+}
+
+func (n *NamingConfig) Validate() error {
+	switch n.Case {
+	case CasePreserve, CaseLower, CaseUpper:
+	default:
+		return fmt.Errorf("invalid naming case[%s]; expected lower or upper", n.Case)
+	}
+	for source, destination := range n.Mapping {
+		if destination == "" {
+			return fmt.Errorf("empty destination name mapped for stream[%s]", source)
+		}
+	}
+	return nil
+}
+
+// Destination returns the destination namespace and table name of stream
+func (n *NamingConfig) Destination(stream *ConfiguredStream) (string, string) {
+	if n == nil {
+		return stream.Namespace(), stream.Name()
+	}
+
+	namespace, found := n.NamespaceMapping[stream.Namespace()]
+	if !found {
+		namespace = n.transform(stream.Namespace())
+	}
+	name, found := n.Mapping[stream.ID()]
+	if !found {
+		name = n.transform(n.Prefix + stream.Name() + n.Suffix)
+	}
+	return namespace, name
+}
+
+// DestinationID returns the destination identifier of stream in namespace.name form
+func (n *NamingConfig) DestinationID(stream *ConfiguredStream) string {
+	namespace, name := n.Destination(stream)
+	return utils.StreamIdentifier(name, namespace)
+}
+
+func (n *NamingConfig) transform(name string) string {
+	switch n.Case {
+	case CaseLower:
+		name = strings.ToLower(name)
+	case CaseUpper:
+		name = strings.ToUpper(name)
+	}
+	if n.Sanitize {
+		name = SanitizeIdentifier(name)
+	}
+	return name
+}
+
+// SanitizeIdentifier replaces characters other than letters, digits and underscore with
+// underscore, prefixing names that start with a digit
+func SanitizeIdentifier(name string) string {
+	if name == "" {
+		return name
+	}
+	sanitized := []rune(name)
+	for idx, char := range sanitized {
+		if !(char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9')) {
+			sanitized[idx] = '_'
+		}
+	}
+	// most destinations reject identifiers starting with a digit
+	if sanitized[0] >= '0' && sanitized[0] <= '9' {
+		return "_" + string(sanitized)
+	}
+	return string(sanitized)
+}
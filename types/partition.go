@@ -0,0 +1,85 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// PartitionGranularity is the period of time a temporal partition holds the records of
+type PartitionGranularity string
+
+const (
+	PartitionHour  PartitionGranularity = "hour"
+	PartitionDay   PartitionGranularity = "day"
+	PartitionWeek  PartitionGranularity = "week"
+	PartitionMonth PartitionGranularity = "month"
+	PartitionYear  PartitionGranularity = "year"
+)
+
+// DefaultPartition holds records without a time in the partition column, as in hive
+const DefaultPartition = "__HIVE_DEFAULT_PARTITION__"
+
+// TemporalPartition writes the records of a stream to folders by the time of one of their
+// columns, such as event_date=2024-03-01 for the day of created_at, instead of by sync time
+type TemporalPartition struct {
+	// timestamp column, or a column of dates or times as text
+	Column string `json:"column"`
+	// hour, day, week, month or year; day when empty
+	Granularity PartitionGranularity `json:"granularity,omitempty"`
+	// IANA time zone periods are cut in, e.g. Asia/Kolkata; UTC when empty
+	Timezone string `json:"timezone,omitempty"`
+	// key of the folders; <column>_<granularity> when empty
+	Name string `json:"name,omitempty"`
+
+	location *time.Location
+}
+
+func (p *TemporalPartition) Validate() error {
+	if p.Column == "" {
+		return fmt.Errorf("partition needs a column")
+	}
+	switch p.Granularity {
+	case "":
+		p.Granularity = PartitionDay
+	case PartitionHour, PartitionDay, PartitionWeek, PartitionMonth, PartitionYear:
+	default:
+		return fmt.Errorf("invalid partition granularity[%s]; valid are hour, day, week, month, year", p.Granularity)
+	}
+	location, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return fmt.Errorf("invalid partition timezone[%s]: %s", p.Timezone, err)
+	}
+	p.location = location
+	if p.Name == "" {
+		p.Name = fmt.Sprintf("%s_%s", p.Column, p.Granularity)
+	}
+	return nil
+}
+
+// Folder returns the folder of the records of timestamp, e.g. created_at_day=2024-03-01; or
+// of records without a time when timestamp is nil
+func (p *TemporalPartition) Folder(timestamp *time.Time) string {
+	if timestamp == nil {
+		return p.Name + "=" + DefaultPartition
+	}
+	location := p.location
+	if location == nil {
+		location = time.UTC
+	}
+	local := timestamp.In(location)
+	var value string
+	switch p.Granularity {
+	case PartitionHour:
+		value = local.Format("2006-01-02-15")
+	case PartitionWeek:
+		year, week := local.ISOWeek()
+		value = fmt.Sprintf("%04d-W%02d", year, week)
+	case PartitionMonth:
+		value = local.Format("2006-01")
+	case PartitionYear:
+		value = local.Format("2006")
+	default:
+		value = local.Format("2006-01-02")
+	}
+	return p.Name + "=" + value
+}
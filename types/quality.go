@@ -0,0 +1,29 @@
+package types
+
+import "fmt"
+
+// QualityConfig lists the data quality checks run on a stream once the sync wrote it; failed
+// checks are reported in the sync summary and fail the sync
+type QualityConfig struct {
+	// compare the rows of the stream in the source with the rows in the destination
+	RowCount bool `json:"row_count,omitempty"`
+	// fraction of the source rows the destination count may differ by
+	RowCountTolerance float64 `json:"row_count_tolerance,omitempty"`
+	// column to the highest fraction of null values allowed among the records written
+	MaxNullRate map[string]float64 `json:"max_null_rate,omitempty"`
+	// bounds of the cursor values read; numbers, or strings for string and timestamp cursors
+	CursorMin any `json:"cursor_min,omitempty"`
+	CursorMax any `json:"cursor_max,omitempty"`
+}
+
+func (q *QualityConfig) Validate() error {
+	if q.RowCountTolerance < 0 || q.RowCountTolerance > 1 {
+		return fmt.Errorf("invalid row_count_tolerance[%v]; expected a fraction between 0 and 1", q.RowCountTolerance)
+	}
+	for column, rate := range q.MaxNullRate {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("invalid max_null_rate[%v] of column[%s]; expected a fraction between 0 and 1", rate, column)
+		}
+	}
+	return nil
+}
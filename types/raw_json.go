@@ -0,0 +1,45 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/goccy/go-json"
+)
+
+// RawJSON is a value of a JSON column; it is kept as its JSON text so destinations with JSON
+// or variant columns get it as is, instead of flattened or quoted
+type RawJSON []byte
+
+// NewRawJSON returns the JSON text of v; strings and bytes must already be JSON
+func NewRawJSON(v any) (RawJSON, error) {
+	switch value := v.(type) {
+	case RawJSON:
+		return value, nil
+	case []byte:
+		if !json.Valid(value) {
+			return nil, fmt.Errorf("invalid json: %s", value)
+		}
+		return RawJSON(value), nil
+	case string:
+		if !json.Valid([]byte(value)) {
+			return nil, fmt.Errorf("invalid json: %s", value)
+		}
+		return RawJSON(value), nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %v (type:%T) as json: %s", v, v, err)
+	}
+	return RawJSON(data), nil
+}
+
+func (r RawJSON) String() string {
+	return string(r)
+}
+
+func (r RawJSON) MarshalJSON() ([]byte, error) {
+	if len(r) == 0 {
+		return []byte("null"), nil
+	}
+	return r, nil
+}
@@ -0,0 +1,46 @@
+package types
+
+import "sync"
+
+// maxPooledColumns bounds the records returned to the pool, so one wide row doesn't keep its
+// map alive for the rest of the sync
+const maxPooledColumns = 1024
+
+var records = sync.Pool{
+	New: func() any {
+		return make(Record)
+	},
+}
+
+// NewRecord returns an empty record from the pool; its map is reused once the record is
+// released
+func NewRecord() Record {
+	return records.Get().(Record)
+}
+
+// ReleaseRecord returns record to the pool; neither the map nor the record may be used
+// afterwards
+func ReleaseRecord(record map[string]any) {
+	if record == nil || len(record) > maxPooledColumns {
+		return
+	}
+	clear(record)
+	records.Put(Record(record))
+}
+
+// CreatePooledRawRecord creates a record of data taken from NewRecord; the writer thread
+// releases data once it is done with it, so the reader must not use data after inserting it
+func CreatePooledRawRecord(olakeID string, data map[string]any, deleteAt int64) RawRecord {
+	record := CreateRawRecord(olakeID, data, deleteAt)
+	record.pooled = true
+	return record
+}
+
+// Release returns the data of a pooled record to the pool; a no-op for other records
+func (r *RawRecord) Release() {
+	if r.pooled {
+		ReleaseRecord(r.Data)
+		r.Data = nil
+		r.pooled = false
+	}
+}
@@ -0,0 +1,42 @@
+package types
+
+import "fmt"
+
+type RecordSizeAction string
+
+const (
+	// sent to the dead letter queue
+	RecordSizeDeadLetter RecordSizeAction = "dead_letter"
+	// the largest text and binary values are cut until the record fits
+	RecordSizeTruncate RecordSizeAction = "truncate"
+)
+
+// RecordSizeConfig caps the size of records, counted as the bytes of their keys and values,
+// so a single huge document can't stall writers buffering records
+type RecordSizeConfig struct {
+	MaxBytes   int              `json:"max_bytes"`
+	OnOversize RecordSizeAction `json:"on_oversize"`
+	// truncate: ends truncated strings; defaults to DefaultTruncateMarker
+	Marker string `json:"marker,omitempty"`
+}
+
+func (c *RecordSizeConfig) Validate() error {
+	if c.MaxBytes <= 0 {
+		return fmt.Errorf("max_bytes of max_record_size must be positive")
+	}
+	switch c.OnOversize {
+	case "":
+		c.OnOversize = RecordSizeDeadLetter
+	case RecordSizeTruncate:
+		if c.Marker == "" {
+			c.Marker = DefaultTruncateMarker
+		}
+		if len(c.Marker) >= c.MaxBytes {
+			return fmt.Errorf("marker of max_record_size must be shorter than max_bytes")
+		}
+	case RecordSizeDeadLetter:
+	default:
+		return fmt.Errorf("invalid max_record_size on_oversize[%s]; expected one of dead_letter, truncate", c.OnOversize)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package types
+
+import "fmt"
+
+const (
+	// runs of a stream since it was last read in full, kept in its state
+	ResyncRunsKey = "resync_runs"
+	// when a stream was last read in full, kept in its state
+	ResyncedAtKey = "resynced_at"
+)
+
+// ResyncPolicy reads an incremental stream in full again from time to time, to heal drift
+// such as rows changed without their cursor moving; whichever limit is reached first
+type ResyncPolicy struct {
+	// runs of the stream between full reads
+	EveryRuns int `json:"every_runs,omitempty"`
+	// days between full reads
+	EveryDays int `json:"every_days,omitempty"`
+}
+
+func (p *ResyncPolicy) Validate() error {
+	if p.EveryRuns < 0 || p.EveryDays < 0 {
+		return fmt.Errorf("resync every_runs and every_days can't be negative")
+	}
+	if p.EveryRuns == 0 && p.EveryDays == 0 {
+		return fmt.Errorf("resync needs every_runs or every_days")
+	}
+	return nil
+}
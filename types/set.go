@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/goccy/go-json"
@@ -205,6 +206,26 @@ func (st *Set[T]) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON writes the elements in a stable order, so catalogs and states diff meaningfully
+// between runs; ordered by their text, then by hash
 func (st *Set[T]) MarshalJSON() ([]byte, error) {
-	return json.Marshal(st.Array())
+	type element struct {
+		text, hash string
+		value      T
+	}
+	elements := make([]element, 0, len(st.storage))
+	for hash, value := range st.storage {
+		elements = append(elements, element{text: fmt.Sprint(value), hash: hash, value: value})
+	}
+	sort.Slice(elements, func(i, j int) bool {
+		if elements[i].text != elements[j].text {
+			return elements[i].text < elements[j].text
+		}
+		return elements[i].hash < elements[j].hash
+	})
+	arr := make([]T, 0, len(elements))
+	for _, element := range elements {
+		arr = append(arr, element.value)
+	}
+	return json.Marshal(arr)
 }
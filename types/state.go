@@ -6,6 +6,7 @@ import (
 	"sync/atomic"
 
 	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/encryption"
 	"github.com/datazip-inc/olake/utils"
 	"github.com/goccy/go-json"
 )
@@ -28,9 +29,53 @@ const (
 // State is a dto for airbyte state serialization
 type State struct {
 	*sync.RWMutex `json:"-"`
-	Type          StateType      `json:"type"`
-	Global        any            `json:"global,omitempty"`
-	Streams       []*StreamState `json:"streams,omitempty"` // TODO: make it set
+	// format version; always written as the current version, older states are upgraded on load
+	Version int            `json:"version,omitempty"`
+	Type    StateType      `json:"type"`
+	Global  any            `json:"global,omitempty"`
+	Streams []*StreamState `json:"streams,omitempty"` // TODO: make it set
+	// multipart uploads of writers not completed yet
+	Uploads []*PendingUpload `json:"uploads,omitempty"`
+	// run that last checkpointed the state
+	SyncID string `json:"sync_id,omitempty"`
+	// refresh tokens and other credentials rotated by the source, e.g. by oauth2 token
+	// refreshes; masked in logs
+	Credentials map[string]string `json:"credentials,omitempty"`
+	// persists checkpoints in place of state.json in the config folder when set
+	Saver StateSaver `json:"-"`
+
+	// set by DeferCheckpoints; changes are then persisted by Checkpoint only
+	deferred bool
+	dirty    bool
+	request  func()
+	// streams changed since the last checkpoint, by part key, for savers persisting parts
+	changedStreams map[string]struct{}
+	// if every part was saved once; the first checkpoint of StatePartSaver holds them all
+	partsSaved bool
+}
+
+// StateSaver persists a serialized state checkpoint
+type StateSaver interface {
+	Save(state []byte) error
+}
+
+// StatePartSaver persists checkpoints part by part, so states of thousands of streams aren't
+// rewritten as a whole at every checkpoint; only the streams changed since the previous one
+// are saved. Parts are the state without its streams, keyed StateHeaderPart, and the states of
+// streams, keyed by StreamStatePart, serialized as the whole state is; nil for streams removed
+// from the state. Parts not passed are kept as saved, unless full is set and they are removed.
+type StatePartSaver interface {
+	StateSaver
+	SaveParts(parts map[string][]byte, full bool) error
+}
+
+// StateHeaderPart keys the part of the state without its streams; stream parts always hold a
+// dot so the keys don't collide
+const StateHeaderPart = "state"
+
+// StreamStatePart keys the part of the state of a stream
+func StreamStatePart(namespace, stream string) string {
+	return namespace + "." + stream
 }
 
 var (
@@ -54,8 +99,27 @@ func (s *State) InitialState(stream *ConfiguredStream) *StreamState {
 func (s *State) ResetStreams() {
 	s.Lock()
 	defer s.Unlock()
+	removed := make([]string, 0, len(s.Streams))
+	for _, stream := range s.Streams {
+		removed = append(removed, stream.part())
+	}
 	s.Streams = nil
-	s.LogState()
+	s.changed(false, removed...)
+}
+
+// ResetStream clears the cursor, chunks and any other state of stream so its next read starts
+// over
+func (s *State) ResetStream(stream *ConfiguredStream) {
+	s.Lock()
+	defer s.Unlock()
+	index, contains := utils.ArrayContains(s.Streams, func(elem *StreamState) bool {
+		return elem.Namespace == stream.Namespace() && elem.Stream == stream.Name()
+	})
+	if !contains {
+		return
+	}
+	s.Streams = append(s.Streams[:index], s.Streams[index+1:]...)
+	s.changed(false, StreamStatePart(stream.Namespace(), stream.Name()))
 }
 
 func (s *State) SetCursor(stream *ConfiguredStream, key string, value any) {
@@ -74,7 +138,7 @@ func (s *State) SetCursor(stream *ConfiguredStream, key string, value any) {
 		newStream.HoldsValue.Store(true)
 		s.Streams = append(s.Streams, newStream)
 	}
-	s.LogState()
+	s.changed(false, StreamStatePart(stream.Namespace(), stream.Name()))
 }
 
 func (s *State) GetCursor(stream *ConfiguredStream, key string) any {
@@ -127,7 +191,7 @@ func (s *State) SetChunks(stream *ConfiguredStream, chunks *Set[Chunk]) {
 		newStream.HoldsValue.Store(true)
 		s.Streams = append(s.Streams, newStream)
 	}
-	s.LogState()
+	s.changed(false, StreamStatePart(stream.Namespace(), stream.Name()))
 }
 
 // remove chunk
@@ -145,18 +209,94 @@ func (s *State) RemoveChunk(stream *ConfiguredStream, chunk Chunk) {
 			s.Streams[index].State.Store(ChunksKey, stateChunks)
 		}
 	}
-	s.LogState()
+	s.changed(false, StreamStatePart(stream.Namespace(), stream.Name()))
 }
 
 func (s *State) SetGlobalState(globalState any) {
 	s.Lock()
 	defer s.Unlock()
 	s.Global = globalState
-	s.LogState()
+	s.changed(false)
+}
+
+// TrackUpload records the progress of a multipart upload; a copy is stored so the caller may
+// continue mutating upload
+func (s *State) TrackUpload(upload *PendingUpload) {
+	s.Lock()
+	defer s.Unlock()
+
+	tracked := *upload
+	tracked.Parts = append([]UploadPart(nil), upload.Parts...)
+	index, contains := utils.ArrayContains(s.Uploads, func(elem *PendingUpload) bool {
+		return elem.UploadID == upload.UploadID
+	})
+	if contains {
+		s.Uploads[index] = &tracked
+	} else {
+		s.Uploads = append(s.Uploads, &tracked)
+	}
+	s.changed(true)
+}
+
+// CompleteUpload removes a completed or aborted multipart upload
+func (s *State) CompleteUpload(uploadID string) {
+	s.Lock()
+	defer s.Unlock()
+
+	index, contains := utils.ArrayContains(s.Uploads, func(elem *PendingUpload) bool {
+		return elem.UploadID == uploadID
+	})
+	if contains {
+		s.Uploads = append(s.Uploads[:index], s.Uploads[index+1:]...)
+	}
+	s.changed(true)
+}
+
+// PendingUploads returns the multipart uploads left incomplete by previous syncs
+func (s *State) PendingUploads() []*PendingUpload {
+	s.RLock()
+	defer s.RUnlock()
+
+	uploads := make([]*PendingUpload, 0, len(s.Uploads))
+	for _, upload := range s.Uploads {
+		copied := *upload
+		copied.Parts = append([]UploadPart(nil), upload.Parts...)
+		uploads = append(uploads, &copied)
+	}
+	return uploads
+}
+
+// SetCredential persists a credential, e.g. a rotated refresh token, with the state
+func (s *State) SetCredential(key, value string) {
+	s.Lock()
+	defer s.Unlock()
+	if s.Credentials == nil {
+		s.Credentials = make(map[string]string)
+	}
+	s.Credentials[key] = value
+	s.changed(true)
+}
+
+func (s *State) GetCredential(key string) string {
+	s.RLock()
+	defer s.RUnlock()
+	return s.Credentials[key]
+}
+
+// Redacted returns a copy of the state to log, with credentials masked
+func (s *State) Redacted() *State {
+	redacted := *s
+	if len(s.Credentials) > 0 {
+		redacted.Credentials = make(map[string]string, len(s.Credentials))
+		for key := range s.Credentials {
+			redacted.Credentials[key] = "REDACTED"
+		}
+	}
+	return &redacted
 }
 
 func (s *State) isZero() bool {
-	return s.Global == nil && len(s.Streams) == 0
+	return s.Global == nil && len(s.Streams) == 0 && len(s.Uploads) == 0 && len(s.Credentials) == 0
 }
 
 func (s *State) MarshalJSON() ([]byte, error) {
@@ -166,6 +306,7 @@ func (s *State) MarshalJSON() ([]byte, error) {
 
 	type Alias State
 	p := Alias(*s)
+	p.Version = StateFormat.Version()
 
 	populatedStreams := []*StreamState{}
 	for _, stream := range p.Streams {
@@ -190,16 +331,186 @@ func (s *State) LogState() {
 		logger.Info("state is empty")
 		return
 	}
+	s.dirty = false
+	s.logMessage()
+	s.capture().save()
+}
+
+// DeferCheckpoints holds changes back from being persisted until Checkpoint; the sync
+// checkpoints once writers acknowledged the records a change covers. request is called,
+// with the lock held, for changes that must be checkpointed soon such as rotated credentials
+func (s *State) DeferCheckpoints(request func()) {
+	s.Lock()
+	defer s.Unlock()
+	s.deferred = true
+	s.request = request
+}
+
+// changed persists the state after a change of streams, or of the rest of the state when none
+// are passed, or marks it for the next checkpoint when deferred
+func (s *State) changed(urgent bool, streams ...string) {
+	if len(streams) > 0 {
+		if s.changedStreams == nil {
+			s.changedStreams = make(map[string]struct{})
+		}
+		for _, stream := range streams {
+			s.changedStreams[stream] = struct{}{}
+		}
+	}
+	if !s.deferred {
+		s.LogState()
+		return
+	}
+	s.dirty = true
+	if urgent && s.request != nil {
+		s.request()
+	}
+}
+
+// Snapshot serializes the state changed since the last checkpoint; nil when unchanged
+func (s *State) Snapshot() *StateCheckpoint {
+	s.Lock()
+	defer s.Unlock()
+	if !s.dirty || s.isZero() {
+		return nil
+	}
+	s.dirty = false
+	checkpoint := s.capture()
+	// encrypted state stays out of the logs
+	if !encryption.Enabled() {
+		message, err := json.Marshal(Message{Type: StateMessage, State: s.Redacted()})
+		if err != nil {
+			logger.Fatalf("failed to marshal state: %s", err)
+		}
+		checkpoint.message = message
+	}
+	return checkpoint
+}
+
+// StateCheckpoint is a serialized state, persisted once what it covers is written; parts are
+// set in place of data for savers persisting parts
+type StateCheckpoint struct {
+	state   *State
+	data    []byte
+	parts   map[string][]byte
+	full    bool
+	message []byte
+}
+
+// Discard marks a snapshot that couldn't be persisted as changed again
+func (c *StateCheckpoint) Discard() {
+	c.state.Lock()
+	defer c.state.Unlock()
+	c.state.dirty = true
+	if c.full {
+		c.state.partsSaved = false
+	}
+	if c.state.changedStreams == nil {
+		c.state.changedStreams = make(map[string]struct{})
+	}
+	for key := range c.parts {
+		if key != StateHeaderPart {
+			c.state.changedStreams[key] = struct{}{}
+		}
+	}
+}
+
+// Persist saves a snapshot taken by Snapshot
+func (c *StateCheckpoint) Persist() {
+	if c.message != nil {
+		logger.Info(json.RawMessage(c.message))
+	}
+	c.save()
+}
+
+func (c *StateCheckpoint) save() {
+	saver, parts := c.state.Saver.(StatePartSaver)
+	if !parts {
+		c.state.persist(c.data)
+		return
+	}
+	if err := saver.SaveParts(c.parts, c.full); err != nil {
+		logger.Fatalf("failed to save state: %s", err)
+	}
+}
 
-	message := Message{
-		Type:  StateMessage,
-		State: s,
+// capture serializes the state to persist, only the parts changed since the last checkpoint
+// for savers persisting parts; needs the lock
+func (s *State) capture() *StateCheckpoint {
+	checkpoint := &StateCheckpoint{state: s}
+	if _, parts := s.Saver.(StatePartSaver); !parts {
+		checkpoint.data = s.serialize()
+		return checkpoint
+	}
+	checkpoint.full = !s.partsSaved
+	checkpoint.parts = s.serializeParts(checkpoint.full)
+	s.partsSaved = true
+	clear(s.changedStreams)
+	return checkpoint
+}
+
+// serializeParts marshals and seals the rest of the state, and its streams changed since the
+// last checkpoint or all of them when full; needs the lock
+func (s *State) serializeParts(full bool) map[string][]byte {
+	type Alias State
+	header := Alias(*s)
+	header.Version = StateFormat.Version()
+	header.Streams = nil
+	parts := map[string][]byte{StateHeaderPart: seal(header)}
+	if !full {
+		// streams changed and gone from the state are removed
+		for key := range s.changedStreams {
+			parts[key] = nil
+		}
+	}
+	for _, stream := range s.Streams {
+		key := stream.part()
+		if _, changed := s.changedStreams[key]; (full || changed) && stream.HoldsValue.Load() {
+			parts[key] = seal(stream)
+		}
+	}
+	return parts
+}
+
+func (s *State) logMessage() {
+	// encrypted state stays out of the logs
+	if !encryption.Enabled() {
+		message := Message{
+			Type:  StateMessage,
+			State: s.Redacted(),
+		}
+		// TODO: Only Log in logs file, not in CLI
+		logger.Info(message)
+	}
+}
+
+// serialize marshals and, when enabled, encrypts the state; needs the lock
+func (s *State) serialize() []byte {
+	return seal(s)
+}
+
+func seal(value any) []byte {
+	data, err := json.Marshal(value)
+	if err != nil {
+		logger.Fatalf("failed to marshal state: %s", err)
+	}
+	data, err = encryption.Seal(data)
+	if err != nil {
+		logger.Fatalf("failed to encrypt state: %s", err)
+	}
+	return data
+}
+
+func (s *State) persist(data []byte) {
+	if s.Saver != nil {
+		if err := s.Saver.Save(data); err != nil {
+			logger.Fatalf("failed to save state: %s", err)
+		}
+		return
 	}
-	// TODO: Only Log in logs file, not in CLI
-	logger.Info(message)
 
 	// log to file
-	err := logger.FileLogger(message.State, "state", ".json")
+	err := logger.FileLogger(json.RawMessage(data), "state", ".json")
 	if err != nil {
 		logger.Fatalf("failed to create state file: %s", err)
 	}
@@ -211,6 +522,22 @@ type Chunk struct {
 	Max any `json:"max"`
 }
 
+// UploadPart is an uploaded part of a multipart upload
+type UploadPart struct {
+	Number int64  `json:"number"`
+	ETag   string `json:"etag"`
+}
+
+// PendingUpload tracks a multipart upload of a local file to an object store
+type PendingUpload struct {
+	Bucket    string       `json:"bucket"`
+	Key       string       `json:"key"`
+	UploadID  string       `json:"upload_id"`
+	LocalPath string       `json:"local_path"`
+	Offset    int64        `json:"offset"` // bytes of the local file uploaded so far
+	Parts     []UploadPart `json:"parts,omitempty"`
+}
+
 type StreamState struct {
 	HoldsValue atomic.Bool `json:"-"` // If State holds some value and should not be excluded during unmarshaling then value true
 
@@ -220,6 +547,10 @@ type StreamState struct {
 	State     sync.Map `json:"state"`
 }
 
+func (s *StreamState) part() string {
+	return StreamStatePart(s.Namespace, s.Stream)
+}
+
 // MarshalJSON custom marshaller to handle sync.Map encoding
 func (s *StreamState) MarshalJSON() ([]byte, error) {
 	// Create a map to temporarily store data for JSON marshaling
@@ -338,3 +669,13 @@ func (g *Global[T]) UnmarshalJSON(data []byte) error {
 	*g = Global[T](temp)
 	return nil
 }
+
+// StateIssue is a problem `state doctor` found in the state; repaired by resetting the state of
+// Streams, and of the global state and the streams it covers when Global is set, so they are
+// synced again from a snapshot
+type StateIssue struct {
+	Streams     []string `json:"streams,omitempty"`
+	Global      bool     `json:"global,omitempty"`
+	Message     string   `json:"message"`
+	Remediation string   `json:"remediation,omitempty"`
+}
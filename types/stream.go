@@ -1,10 +1,17 @@
 package types
 
 import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+
 	"github.com/goccy/go-json"
+	"github.com/spf13/viper"
 
 	"github.com/datazip-inc/olake/jsonschema/schema"
 	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/audit"
 	"github.com/datazip-inc/olake/utils"
 )
 
@@ -17,12 +24,15 @@ type Stream struct {
 	Namespace string `json:"namespace,omitempty"`
 	// Possible Schema of the Stream
 	Schema *TypeSchema `json:"type_schema,omitempty"`
-	// Supported sync modes from driver for the respective Stream
-	SupportedSyncModes *Set[SyncMode] `json:"supported_sync_modes,omitempty"`
+	// Supported sync modes from driver for the respective Stream; always present so catalog
+	// editors can offer only valid choices
+	SupportedSyncModes *Set[SyncMode] `json:"supported_sync_modes"`
 	// Primary key if available
-	SourceDefinedPrimaryKey *Set[string] `json:"source_defined_primary_key,omitempty"`
+	SourceDefinedPrimaryKey *Set[string] `json:"source_defined_primary_key"`
 	// Available cursor fields supported by driver
-	AvailableCursorFields *Set[string] `json:"available_cursor_fields,omitempty"`
+	AvailableCursorFields *Set[string] `json:"available_cursor_fields"`
+	// Timestamp columns the source stores without a time zone
+	NaiveTimestampColumns *Set[string] `json:"naive_timestamp_columns,omitempty"`
 	// Input of JSON Schema from Client to be parsed by driver
 	AdditionalProperties string `json:"additional_properties,omitempty"`
 	// Renderable JSON Schema for additional properties supported by respective driver for individual stream
@@ -69,11 +79,40 @@ func (s *Stream) WithCursorField(columns ...string) *Stream {
 	return s
 }
 
+func (s *Stream) WithNaiveTimestamps(columns ...string) *Stream {
+	if s.NaiveTimestampColumns == nil {
+		s.NaiveTimestampColumns = NewSet[string]()
+	}
+	for _, column := range columns {
+		s.NaiveTimestampColumns.Insert(column)
+	}
+
+	return s
+}
+
 func (s *Stream) WithSchema(schema *TypeSchema) *Stream {
 	s.Schema = schema
 	return s
 }
 
+// ReconcileSyncModes drops the sync modes the stream can't be synced with; incremental needs
+// a cursor field and cdc needs a driver running change streams
+func (s *Stream) ReconcileSyncModes(changeStreamSupported bool) {
+	if s.SupportedSyncModes.Exists(INCREMENTAL) && s.AvailableCursorFields.Len() == 0 {
+		s.SupportedSyncModes.Remove(INCREMENTAL)
+	}
+	if s.SupportedSyncModes.Exists(CDC) && !changeStreamSupported {
+		s.SupportedSyncModes.Remove(CDC)
+	}
+	// default mode must stay one of the supported
+	if s.SyncMode != "" && !s.SupportedSyncModes.Exists(s.SyncMode) {
+		s.SyncMode = ""
+		if s.SupportedSyncModes.Exists(FULLREFRESH) {
+			s.SyncMode = FULLREFRESH
+		}
+	}
+}
+
 // Add or Update Column in Stream Type Schema
 func (s *Stream) UpsertField(column string, typ DataType, nullable bool) {
 	types := []DataType{typ}
@@ -119,15 +158,64 @@ func StreamsToMap(streams ...*Stream) map[string]*Stream {
 	return output
 }
 
-func LogCatalog(streams []*Stream) {
-	message := Message{
-		Type:    CatalogMessage,
-		Catalog: GetWrappedCatalog(streams),
+// CatalogPath returns the file discover writes the catalog to: output, else catalog.json in the
+// config folder; empty when neither is set
+func CatalogPath(output string) string {
+	if output != "" || viper.GetString("CONFIG_FOLDER") == "" {
+		return output
 	}
-	logger.Info(message)
-	// write catalog to the specified file
-	err := logger.FileLogger(message.Catalog, "catalog", ".json")
-	if err != nil {
+	return filepath.Join(viper.GetString("CONFIG_FOLDER"), "catalog.json")
+}
+
+// LogCatalog writes catalog to output, catalog.json in the config folder by default, and prints
+// it unless written to output; the catalog is written one stream at a time so huge catalogs
+// aren't held in memory twice
+func LogCatalog(catalog *Catalog, output string) {
+	if output == "" {
+		logger.Info(Message{
+			Type:    CatalogMessage,
+			Catalog: catalog,
+		})
+	}
+	path := CatalogPath(output)
+	if path == "" {
+		logger.Fatalf("failed to create catalog file: config folder is not set")
+	}
+	before, _ := os.ReadFile(path)
+	if err := writeCatalogFile(path, catalog); err != nil {
 		logger.Fatalf("failed to create catalog file: %s", err)
 	}
+	if output != "" {
+		logger.Infof("Catalog of %d streams written to %s", len(catalog.Streams), output)
+	}
+	after, _ := os.ReadFile(path)
+	if err := audit.Record(context.Background(), audit.Catalog, path, "discover", before, after); err != nil {
+		logger.Warnf("failed to record catalog changes in audit log: %s", err)
+	}
+}
+
+// writeCatalogFile writes the catalog next to path and renames it over path once complete, so
+// an interrupted discover leaves the previous catalog intact
+func writeCatalogFile(path string, catalog *Catalog) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	if err := WriteCatalog(writer, catalog); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if err := file.Chmod(0o644); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
 }
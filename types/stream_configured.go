@@ -2,6 +2,12 @@ package types
 
 import (
 	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/charset"
+	"github.com/datazip-inc/olake/pkg/filter"
 )
 
 // Input/Processed object for Stream
@@ -14,9 +20,12 @@ type ConfiguredStream struct {
 	// Column that's being used as cursor; MUST NOT BE mutated
 	//
 	// Cursor field is used in Incremental and in Mixed type CDC Read where connector uses
-	// this field as recovery column incase of some inconsistencies
+	// this field as recovery column incase of some inconsistencies. Comma separated columns,
+	// e.g. "updated_at,id", make a composite cursor compared lexicographically
 	CursorField    string   `json:"cursor_field,omitempty"`
 	ExcludeColumns []string `json:"exclude_columns,omitempty"` // TODO: Implement excluding columns from fetching
+	// Replaces the driver detected primary key; for tables without one or with an unsuitable one
+	PrimaryKey []string `json:"primary_key,omitempty"`
 }
 
 func (s *ConfiguredStream) ID() string {
@@ -55,6 +64,82 @@ func (s *ConfiguredStream) Cursor() string {
 	return s.CursorField
 }
 
+// CursorFields returns the columns of the cursor, most significant first; one column unless
+// the cursor is composite
+func (s *ConfiguredStream) CursorFields() []string {
+	if s.CursorField == "" {
+		return nil
+	}
+	columns := strings.Split(s.CursorField, ",")
+	for idx := range columns {
+		columns[idx] = strings.TrimSpace(columns[idx])
+	}
+	return columns
+}
+
+// ApplyOverrides makes the catalog primary key override visible to drivers and writers; must
+// only be called once the stream is validated
+func (s *ConfiguredStream) ApplyOverrides() {
+	if len(s.PrimaryKey) > 0 {
+		s.Stream.SourceDefinedPrimaryKey = NewSet(s.PrimaryKey...)
+	}
+}
+
+func validateCursorOverride(source *Stream, column string) error {
+	if column == "" {
+		return fmt.Errorf("cursor field not set")
+	}
+	found, property := source.Schema.GetProperty(column)
+	if !found {
+		return fmt.Errorf("column missing from stream schema")
+	}
+	switch property.DataType() {
+	case Int64, Float64, String, Timestamp, TimestampMilli, TimestampMicro, TimestampNano:
+		return nil
+	default:
+		return fmt.Errorf("column type %s is not orderable", property.DataType())
+	}
+}
+
+// validateCursor checks every column of the cursor of an incremental stream
+func (s *ConfiguredStream) validateCursor(source *Stream) error {
+	columns := s.CursorFields()
+	if len(columns) == 0 {
+		return fmt.Errorf("invalid cursor field; valid are %v or any column of orderable type: cursor field not set", source.AvailableCursorFields)
+	}
+	seen := make(map[string]bool)
+	for _, column := range columns {
+		if seen[column] {
+			return fmt.Errorf("column[%s] is repeated in cursor field [%s]", column, s.CursorField)
+		}
+		seen[column] = true
+		if source.AvailableCursorFields.Exists(column) {
+			continue
+		}
+		// any orderable column may be chosen as cursor in place of the detected ones
+		if err := validateCursorOverride(source, column); err != nil {
+			return fmt.Errorf("invalid cursor field [%s]; valid are %v or any column of orderable type: %s", column, source.AvailableCursorFields, err)
+		}
+		if len(columns) == 1 {
+			logger.Warnf("Stream %s uses cursor field [%s] instead of the detected %v", s.ID(), column, source.AvailableCursorFields)
+		}
+	}
+	return nil
+}
+
+// DeletesStream returns the stream receiving deletes of s in separate stream delete mode
+func (s *ConfiguredStream) DeletesStream() *ConfiguredStream {
+	stream := NewStream(s.Name()+"_deletes", s.Namespace())
+	stream.SyncMode = s.GetSyncMode()
+	stream.SupportedSyncModes = s.SupportedSyncModes()
+	stream.SourceDefinedPrimaryKey = s.GetStream().SourceDefinedPrimaryKey
+	return &ConfiguredStream{
+		Stream: stream,
+		// keys of deletes are protected like the keys of the stream
+		StreamMetadata: StreamMetadata{StreamName: stream.Name, Transforms: s.StreamMetadata.Transforms},
+	}
+}
+
 // Delete keys from Stream State
 // func (s *ConfiguredStream) DeleteStateKeys(keys ...string) []any {
 // 	values := []any{}
@@ -75,8 +160,129 @@ func (s *ConfiguredStream) Validate(source *Stream) error {
 	}
 
 	// no cursor validation in cdc and backfill sync
-	if s.Stream.SyncMode == INCREMENTAL && !source.AvailableCursorFields.Exists(s.CursorField) {
-		return fmt.Errorf("invalid cursor field [%s]; valid are %v", s.CursorField, source.AvailableCursorFields)
+	if s.Stream.SyncMode == INCREMENTAL {
+		if err := s.validateCursor(source); err != nil {
+			return err
+		}
+	}
+
+	if initial := s.StreamMetadata.InitialCursor; initial != nil {
+		if s.Stream.SyncMode != INCREMENTAL {
+			return fmt.Errorf("initial_cursor needs an incremental stream")
+		}
+		// values are checked against the cursor types by the driver
+		if columns := s.CursorFields(); len(columns) > 1 {
+			if values, ok := initial.([]any); !ok || len(values) != len(columns) {
+				return fmt.Errorf("initial_cursor of composite cursor [%s] must be a list of %d values", s.CursorField, len(columns))
+			}
+		}
+	}
+
+	if resync := s.StreamMetadata.Resync; resync != nil {
+		if s.Stream.SyncMode != INCREMENTAL {
+			return fmt.Errorf("resync needs an incremental stream")
+		}
+		if err := resync.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if s.StreamMetadata.Lookback != "" {
+		if s.Stream.SyncMode != INCREMENTAL {
+			return fmt.Errorf("lookback needs an incremental stream")
+		}
+		cursorType, err := source.Schema.GetType(s.CursorFields()[0])
+		if err != nil {
+			return err
+		}
+		if err := s.StreamMetadata.Lookback.validate(cursorType); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range s.PrimaryKey {
+		if found, _ := source.Schema.GetProperty(key); !found {
+			return fmt.Errorf("invalid primary key [%s]; column missing from stream schema", key)
+		}
+	}
+
+	switch s.StreamMetadata.DeleteMode {
+	case "", DeleteTombstone, DeleteHard, DeleteSeparateStream:
+	default:
+		return fmt.Errorf("invalid delete mode [%s]; valid are tombstone, hard_delete, separate_stream", s.StreamMetadata.DeleteMode)
+	}
+
+	if quality := s.StreamMetadata.Quality; quality != nil {
+		if err := quality.Validate(); err != nil {
+			return err
+		}
+		if (quality.CursorMin != nil || quality.CursorMax != nil) && s.Stream.SyncMode != INCREMENTAL {
+			return fmt.Errorf("quality cursor_min and cursor_max need an incremental stream")
+		}
+	}
+
+	if partition := s.StreamMetadata.Partition; partition != nil {
+		if s.StreamMetadata.PartitionRegex != "" {
+			return fmt.Errorf("partition and partition_regex can't both be set")
+		}
+		if err := partition.Validate(); err != nil {
+			return err
+		}
+		if found, _ := source.Schema.GetProperty(partition.Column); !found {
+			return fmt.Errorf("invalid partition column [%s]; column missing from stream schema", partition.Column)
+		}
+	}
+
+	if err := s.StreamMetadata.NaiveTimestamps.Validate(); err != nil {
+		return err
+	}
+	// the source knows which columns have no time zone; catalogs of older versions don't list them
+	s.Stream.NaiveTimestampColumns = source.NaiveTimestampColumns
+
+	if s.StreamMetadata.Encoding != "" {
+		if _, err := charset.Lookup(s.StreamMetadata.Encoding); err != nil {
+			return err
+		}
+	}
+
+	if s.StreamMetadata.Filter != "" {
+		if _, err := filter.Compile(s.StreamMetadata.Filter); err != nil {
+			return err
+		}
+	}
+
+	transformed := make(map[string]bool)
+	for idx := range s.StreamMetadata.Transforms {
+		transform := &s.StreamMetadata.Transforms[idx]
+		if err := transform.Validate(); err != nil {
+			return err
+		}
+		if transformed[transform.Column] {
+			return fmt.Errorf("column[%s] is transformed more than once", transform.Column)
+		}
+		transformed[transform.Column] = true
+		// drivers read the cursor of the records they emit
+		if s.Stream.SyncMode == INCREMENTAL && slices.Contains(s.CursorFields(), transform.Column) {
+			return fmt.Errorf("cursor field [%s] can't be transformed", transform.Column)
+		}
+	}
+
+	limited := make(map[string]bool)
+	for idx := range s.StreamMetadata.LargeValues {
+		policy := &s.StreamMetadata.LargeValues[idx]
+		if err := policy.Validate(); err != nil {
+			return err
+		}
+		if limited[policy.Column] {
+			return fmt.Errorf("column[%s] has more than one large value policy", policy.Column)
+		}
+		limited[policy.Column] = true
+		if s.Stream.SyncMode == INCREMENTAL && slices.Contains(s.CursorFields(), policy.Column) {
+			return fmt.Errorf("cursor field [%s] can't have a large value policy", policy.Column)
+		}
+		if s.Stream.SourceDefinedPrimaryKey.Exists(policy.Column) {
+			return fmt.Errorf("primary key [%s] can't have a large value policy", policy.Column)
+		}
 	}
 
 	if source.SourceDefinedPrimaryKey.ProperSubsetOf(s.Stream.SourceDefinedPrimaryKey) {
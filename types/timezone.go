@@ -0,0 +1,25 @@
+package types
+
+import "fmt"
+
+// NaiveTimestampPolicy is how the values of timestamp columns the source stores without a time
+// zone, such as postgres timestamp columns, are read
+type NaiveTimestampPolicy string
+
+const (
+	// the wall clock is UTC; the default
+	NaiveTimestampsUTC NaiveTimestampPolicy = "utc"
+	// the wall clock is in the time zone of the source server and converted to UTC
+	NaiveTimestampsSource NaiveTimestampPolicy = "source"
+	// the wall clock is written as text, e.g. 2024-03-01T10:30:00, so nothing is shifted
+	NaiveTimestampsPassthrough NaiveTimestampPolicy = "passthrough"
+)
+
+func (p NaiveTimestampPolicy) Validate() error {
+	switch p {
+	case "", NaiveTimestampsUTC, NaiveTimestampsSource, NaiveTimestampsPassthrough:
+		return nil
+	default:
+		return fmt.Errorf("invalid naive timestamp policy[%s]; valid are utc, source, passthrough", p)
+	}
+}
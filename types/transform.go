@@ -0,0 +1,67 @@
+package types
+
+import (
+	"encoding/base64"
+	"fmt"
+	"unicode/utf8"
+)
+
+type TransformType string
+
+const (
+	// deterministic AES-256-GCM; equal values encrypt to equal ciphertexts so joins still work
+	TransformEncrypt TransformType = "encrypt"
+	// hex encoded SHA-256 of the salt followed by the value
+	TransformHash TransformType = "hash"
+	// letters and digits replaced by the mask character; length and separators are kept
+	TransformMask TransformType = "mask"
+)
+
+// ColumnTransform protects a column of a stream before its records leave the sync; values
+// are written as strings
+type ColumnTransform struct {
+	Column string        `json:"column"`
+	Type   TransformType `json:"type"`
+	// encrypt: base64 encoded 32 byte key; keep it encrypted with the encrypt command
+	Key string `json:"key,omitempty"`
+	// hash: prepended to every value so hashes can't be looked up in precomputed tables
+	Salt string `json:"salt,omitempty"`
+	// mask: characters left as is at the start and end of values
+	KeepFirst int `json:"keep_first,omitempty"`
+	KeepLast  int `json:"keep_last,omitempty"`
+	// mask: defaults to *
+	MaskChar string `json:"mask_char,omitempty"`
+}
+
+func (t *ColumnTransform) Validate() error {
+	if t.Column == "" {
+		return fmt.Errorf("transform column not set")
+	}
+	switch t.Type {
+	case TransformEncrypt:
+		key, err := base64.StdEncoding.DecodeString(t.Key)
+		if err != nil {
+			return fmt.Errorf("invalid key of column[%s]: %s", t.Column, err)
+		}
+		if len(key) != 32 {
+			return fmt.Errorf("key of column[%s] must be 32 bytes, got %d", t.Column, len(key))
+		}
+	case TransformHash:
+		if t.Salt == "" {
+			return fmt.Errorf("salt of column[%s] not set", t.Column)
+		}
+	case TransformMask:
+		if t.KeepFirst < 0 || t.KeepLast < 0 {
+			return fmt.Errorf("keep_first and keep_last of column[%s] can't be negative", t.Column)
+		}
+		if t.MaskChar == "" {
+			t.MaskChar = "*"
+		}
+		if utf8.RuneCountInString(t.MaskChar) != 1 {
+			return fmt.Errorf("mask_char of column[%s] must be a single character", t.Column)
+		}
+	default:
+		return fmt.Errorf("invalid transform type[%s] of column[%s]; valid are encrypt, hash, mask", t.Type, t.Column)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/datazip-inc/olake/pkg/migrate"
+)
+
+// CatalogFormat upgrades catalogs written by older releases; append a migration whenever the
+// catalog format changes
+var CatalogFormat = &migrate.Format{
+	Name: "catalog",
+	Migrations: []migrate.Migration{{
+		From:        0,
+		Description: "selected streams listed by name become stream metadata",
+		Up: func(document map[string]any) error {
+			selected, ok := document["selected_streams"].(map[string]any)
+			if !ok {
+				return nil
+			}
+			for namespace, value := range selected {
+				streams, ok := value.([]any)
+				if !ok {
+					return fmt.Errorf("selected_streams[%s] is not a list", namespace)
+				}
+				for idx, stream := range streams {
+					if name, ok := stream.(string); ok {
+						streams[idx] = map[string]any{"stream_name": name, "partition_regex": "", "split_column": ""}
+					}
+				}
+			}
+			return nil
+		},
+	}},
+}
+
+// StateFormat upgrades states written by older releases; append a migration whenever the
+// state format changes
+var StateFormat = &migrate.Format{
+	Name: "state",
+	Migrations: []migrate.Migration{{
+		From:        0,
+		Description: "state type inferred when missing",
+		Up: func(document map[string]any) error {
+			if typ, _ := document["type"].(string); typ != "" {
+				return nil
+			}
+			_, hasGlobal := document["global"]
+			_, hasStreams := document["streams"]
+			switch {
+			case hasGlobal && hasStreams:
+				document["type"] = string(MixedType)
+			case hasGlobal:
+				document["type"] = string(GlobalType)
+			default:
+				document["type"] = string(StreamType)
+			}
+			return nil
+		},
+	}},
+}
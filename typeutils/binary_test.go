@@ -0,0 +1,69 @@
+package typeutils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestBinaryValues(t *testing.T) {
+	// SRID=4326;POINT(1 2) as postgis returns it
+	ewkb := "0101000020E6100000000000000000F03F0000000000000040"
+
+	fields := Fields{"payload": NewField(types.Bytes), "location": NewField(types.Geometry), "name": NewField(types.String)}
+	record := types.Record{"payload": `\x00ff`, "location": []byte(ewkb), "name": []byte("olake")}
+	if change, typeChange, _ := fields.Process(record); change || typeChange {
+		t.Fatal("expected bytes and geometries in their columns not to change the schema")
+	}
+	if err := ReformatRecord(fields, record); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(record["payload"].([]byte), []byte{0, 255}) || record["name"] != "olake" {
+		t.Fatalf("unexpected record %v", record)
+	}
+	location, isWKB := record["location"].(types.WKB)
+	if !isWKB || location.String() != "SRID=4326;POINT (1 2)" {
+		t.Fatalf("unexpected geometry %v", record["location"])
+	}
+	if typ := TypeFromValue(location); typ != types.Geometry {
+		t.Fatalf("expected a geometry, got %s", typ)
+	}
+
+	// text destinations get the wkt
+	if text, err := Coerce(types.String, location); err != nil || text != "SRID=4326;POINT (1 2)" {
+		t.Fatalf("unexpected string %v: %v", text, err)
+	}
+	if GetCommonAncestorType(types.Bytes, types.Int64) != types.String {
+		t.Fatal("expected bytes mixed with other types to be strings")
+	}
+
+	// parquet keeps both as they are
+	schema := &types.TypeSchema{}
+	schema.AddTypes("payload", types.Bytes)
+	schema.AddTypes("location", types.Geometry)
+	var buffer bytes.Buffer
+	writer := parquet.NewGenericWriter[any](&buffer, schema.ToParquet())
+	if _, err := writer.Write([]any{map[string]any{"payload": record["payload"], "location": location}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file, err := parquet.OpenFile(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := make([]parquet.Row, 1)
+	if n, _ := file.RowGroups()[0].Rows().ReadRows(rows); n != 1 {
+		t.Fatalf("expected a row, got %d", n)
+	}
+	written := make(map[string][]byte)
+	for _, value := range rows[0] {
+		written[file.Schema().Columns()[value.Column()][0]] = value.ByteArray()
+	}
+	if !bytes.Equal(written["payload"], []byte{0, 255}) || !bytes.Equal(written["location"], location) {
+		t.Fatalf("unexpected row %v", written)
+	}
+}
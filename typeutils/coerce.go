@@ -0,0 +1,142 @@
+package typeutils
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+	"github.com/goccy/go-json"
+)
+
+// fallbacks lists, in order of preference, the types a value can be coerced to when the
+// destination doesn't support its own type
+var fallbacks = map[types.DataType][]types.DataType{
+	types.TimestampNano:  {types.TimestampMicro, types.TimestampMilli, types.Timestamp, types.String},
+	types.TimestampMicro: {types.TimestampMilli, types.Timestamp, types.String},
+	types.TimestampMilli: {types.Timestamp, types.String},
+	types.Timestamp:      {types.TimestampMilli, types.TimestampMicro, types.String},
+	types.Object:         {types.String},
+	types.Array:          {types.String},
+	types.Bool:           {types.Int64, types.String},
+	types.Int64:          {types.Float64, types.String},
+	types.Float64:        {types.String},
+	types.Unknown:        {types.String},
+	types.Bytes:          {types.String},
+	types.Geometry:       {types.Bytes, types.String},
+	types.JSON:           {types.String},
+}
+
+// ResolveCoercions returns the type each data type gets converted to before being written to a
+// destination supporting the given types; configured coercions take precedence over fallbacks
+func ResolveCoercions(supported *types.Set[types.DataType], configured map[types.DataType]types.DataType) (map[types.DataType]types.DataType, error) {
+	coercions := make(map[types.DataType]types.DataType)
+	for _, from := range types.DataTypes {
+		if target, found := configured[from]; found {
+			if !supported.Exists(target) {
+				return nil, fmt.Errorf("coercion of %s to %s is not possible; destination doesn't support %s", from, target, target)
+			}
+			if target != from {
+				coercions[from] = target
+			}
+			continue
+		}
+		// null values are written as is everywhere
+		if from == types.Null || supported.Exists(from) {
+			continue
+		}
+		target, found := fallback(from, supported)
+		if !found {
+			return nil, fmt.Errorf("destination doesn't support %s and no coercion is possible", from)
+		}
+		coercions[from] = target
+	}
+	for from := range configured {
+		if !isDataType(from) {
+			return nil, fmt.Errorf("invalid coercion source type[%s]", from)
+		}
+	}
+	return coercions, nil
+}
+
+func fallback(from types.DataType, supported *types.Set[types.DataType]) (types.DataType, bool) {
+	for _, target := range fallbacks[from] {
+		if supported.Exists(target) {
+			return target, true
+		}
+	}
+	return "", false
+}
+
+func isDataType(typ types.DataType) bool {
+	for _, one := range types.DataTypes {
+		if one == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Coerce converts v into the target type; timestamps are always converted to UTC
+func Coerce(target types.DataType, v any) (any, error) {
+	switch target {
+	case types.String:
+		switch value := v.(type) {
+		case time.Time:
+			return value.UTC().Format(time.RFC3339Nano), nil
+		case types.WKB:
+			return value.String(), nil
+		case types.RawJSON:
+			return value.String(), nil
+		case float32:
+			return strconv.FormatFloat(float64(value), 'f', -1, 32), nil
+		case float64:
+			return strconv.FormatFloat(value, 'f', -1, 64), nil
+		}
+		switch reflect.ValueOf(v).Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array:
+			if _, isBytes := v.([]byte); isBytes {
+				break
+			}
+			data, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to coerce %v to string: %s", v, err)
+			}
+			return string(data), nil
+		}
+		return ReformatValue(types.String, v)
+	case types.Timestamp, types.TimestampMilli, types.TimestampMicro, types.TimestampNano:
+		value, err := ReformatDate(v)
+		if err != nil {
+			return nil, err
+		}
+		return value.UTC().Truncate(timestampPrecision(target)), nil
+	case types.Int64:
+		switch value := v.(type) {
+		case bool:
+			if value {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		case time.Time:
+			return value.UnixMilli(), nil
+		}
+		return ReformatInt64(v)
+	default:
+		return ReformatValue(target, v)
+	}
+}
+
+func timestampPrecision(typ types.DataType) time.Duration {
+	switch typ {
+	case types.TimestampMilli:
+		return time.Millisecond
+	case types.TimestampMicro:
+		return time.Microsecond
+	case types.TimestampNano:
+		return time.Nanosecond
+	default:
+		return time.Second
+	}
+}
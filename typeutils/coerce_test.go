@@ -0,0 +1,81 @@
+package typeutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestResolveCoercions(t *testing.T) {
+	supported := types.NewSet(types.Int64, types.String, types.Bool, types.TimestampMilli)
+
+	coercions, err := ResolveCoercions(supported, map[types.DataType]types.DataType{types.Bool: types.String})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[types.DataType]types.DataType{
+		types.Bool:           types.String,
+		types.Float64:        types.String,
+		types.Object:         types.String,
+		types.Array:          types.String,
+		types.Unknown:        types.String,
+		types.Timestamp:      types.TimestampMilli,
+		types.TimestampMicro: types.TimestampMilli,
+		types.TimestampNano:  types.TimestampMilli,
+		types.Bytes:          types.String,
+		types.Geometry:       types.String,
+		types.JSON:           types.String,
+	}
+	if len(coercions) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, coercions)
+	}
+	for from, target := range expected {
+		if coercions[from] != target {
+			t.Errorf("expected %s to be coerced to %s, got %s", from, target, coercions[from])
+		}
+	}
+
+	if _, err := ResolveCoercions(supported, map[types.DataType]types.DataType{types.Object: types.Float64}); err == nil {
+		t.Error("expected error for coercion to unsupported type")
+	}
+	if _, err := ResolveCoercions(types.NewSet(types.Int64), nil); err == nil {
+		t.Error("expected error when no fallback is supported")
+	}
+}
+
+func TestCoerce(t *testing.T) {
+	zone := time.FixedZone("IST", 5*3600+1800)
+	moment := time.Date(2024, 3, 1, 10, 30, 0, 123456789, zone)
+
+	tests := []struct {
+		name     string
+		target   types.DataType
+		value    any
+		expected any
+	}{
+		{"object to string", types.String, map[string]any{"a": 1}, `{"a":1}`},
+		{"array to string", types.String, []any{1, "b"}, `[1,"b"]`},
+		{"float to string", types.String, 1.5, "1.5"},
+		{"timestamp to string in utc", types.String, moment, "2024-03-01T05:00:00.123456789Z"},
+		{"bool to integer", types.Int64, true, int64(1)},
+		{"timestamp to milli precision in utc", types.TimestampMilli, moment, time.Date(2024, 3, 1, 5, 0, 0, 123000000, time.UTC)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value, err := Coerce(test.target, test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected, isTime := test.expected.(time.Time); isTime {
+				if !expected.Equal(value.(time.Time)) || value.(time.Time).Location() != time.UTC {
+					t.Fatalf("expected %v, got %v", expected, value)
+				}
+				return
+			}
+			if value != test.expected {
+				t.Fatalf("expected %v, got %v", test.expected, value)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package typeutils
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+// ValidateRecord checks a record against the stream schema (the data contract); columns that
+// are missing from the schema are left to schema evolution
+func ValidateRecord(schema *types.TypeSchema, record map[string]any) error {
+	return validateRecord(schema, record, false)
+}
+
+// ValidatePartialRecord checks the columns of a partial record against the stream schema;
+// columns missing from the record keep their value and aren't checked
+func ValidatePartialRecord(schema *types.TypeSchema, record map[string]any) error {
+	return validateRecord(schema, record, true)
+}
+
+func validateRecord(schema *types.TypeSchema, record map[string]any, partial bool) error {
+	var violation error
+	schema.Properties.Range(func(key, value any) bool {
+		column := key.(string)
+		property := value.(*types.Property)
+
+		val, found := record[column]
+		if !found && partial {
+			return true
+		}
+		if !found || val == nil {
+			if !property.Nullable() {
+				violation = fmt.Errorf("column[%s] is not nullable but value is missing", column)
+				return false
+			}
+			return true
+		}
+
+		if !conforms(property, val) {
+			violation = fmt.Errorf("value[%v] of type[%s] does not conform to column[%s] of type%s", val, TypeFromValue(val), column, property.Type)
+			return false
+		}
+		return true
+	})
+
+	return violation
+}
+
+func conforms(property *types.Property, val any) bool {
+	actual := TypeFromValue(val)
+	if property.Type.Exists(actual) {
+		return true
+	}
+
+	for _, expected := range property.Type.Array() {
+		switch expected {
+		case types.Unknown:
+			return true
+		case types.Float64:
+			if actual == types.Int64 {
+				return true
+			}
+		case types.Int64:
+			// json decoded numbers are floats
+			if float, ok := val.(float64); ok && float == math.Trunc(float) {
+				return true
+			}
+		case types.String:
+			if actual == types.Bytes {
+				return true
+			}
+		case types.Bytes:
+			if actual == types.String {
+				return true
+			}
+		case types.Geometry:
+			if _, err := types.NewWKB(val); err == nil {
+				return true
+			}
+		case types.JSON:
+			if _, err := types.NewRawJSON(val); err == nil {
+				return true
+			}
+		case types.Timestamp, types.TimestampMilli, types.TimestampMicro, types.TimestampNano:
+			switch actual {
+			case types.Timestamp, types.TimestampMilli, types.TimestampMicro, types.TimestampNano:
+				return true
+			case types.String:
+				if _, err := ReformatDate(val); err == nil {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
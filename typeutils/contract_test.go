@@ -0,0 +1,49 @@
+package typeutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestValidateRecord(t *testing.T) {
+	schema := types.NewTypeSchema()
+	schema.AddTypes("id", types.Int64)
+	schema.AddTypes("price", types.Float64, types.Null)
+	schema.AddTypes("created_at", types.Timestamp)
+
+	now := time.Now()
+	tests := []struct {
+		name   string
+		record map[string]any
+		valid  bool
+	}{
+		{"valid", map[string]any{"id": 1, "price": 2.5, "created_at": now}, true},
+		{"integral float for integer", map[string]any{"id": float64(3), "created_at": now}, true},
+		{"int for number", map[string]any{"id": 1, "price": 2, "created_at": now}, true},
+		{"date string for timestamp", map[string]any{"id": 1, "created_at": "2024-01-02T15:04:05"}, true},
+		{"new column", map[string]any{"id": 1, "created_at": now, "extra": "x"}, true},
+		{"missing required", map[string]any{"price": 1.5, "created_at": now}, false},
+		{"string for integer", map[string]any{"id": "1", "created_at": now}, false},
+		{"fraction for integer", map[string]any{"id": 1.5, "created_at": now}, false},
+		{"garbage timestamp", map[string]any{"id": 1, "created_at": "yesterday"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateRecord(schema, test.record)
+			if (err == nil) != test.valid {
+				t.Fatalf("expected valid=%v, got %v", test.valid, err)
+			}
+		})
+	}
+
+	// partial records leave out unchanged columns, but can't null required ones
+	if err := ValidatePartialRecord(schema, map[string]any{"price": nil}); err != nil {
+		t.Fatalf("expected missing columns of a partial record to be valid, got %v", err)
+	}
+	if err := ValidatePartialRecord(schema, map[string]any{"id": nil}); err == nil {
+		t.Fatal("expected a partial record nulling a required column to be invalid")
+	}
+}
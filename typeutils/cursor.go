@@ -0,0 +1,123 @@
+package typeutils
+
+import (
+	"fmt"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+// CompareCursor returns -1, 0 or 1 as a is lower than, equal to or higher than b
+func CompareCursor(typ types.DataType, a, b any) (int, error) {
+	switch typ {
+	case types.Timestamp, types.TimestampMilli, types.TimestampMicro, types.TimestampNano:
+		first, err := ReformatDate(a)
+		if err != nil {
+			return 0, err
+		}
+		second, err := ReformatDate(b)
+		if err != nil {
+			return 0, err
+		}
+		return first.Compare(second), nil
+	case types.Int64, types.Float64:
+		first, err := ReformatFloat64(a)
+		if err != nil {
+			return 0, err
+		}
+		second, err := ReformatFloat64(b)
+		if err != nil {
+			return 0, err
+		}
+		switch x, y := first.(float64), second.(float64); {
+		case x < y:
+			return -1, nil
+		case x > y:
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		first, second := fmt.Sprint(a), fmt.Sprint(b)
+		switch {
+		case first < second:
+			return -1, nil
+		case first > second:
+			return 1, nil
+		}
+		return 0, nil
+	}
+}
+
+// CompareCursors compares the values of composite cursors lexicographically: the first
+// columns that differ decide, each compared as its type with CompareCursor
+func CompareCursors(columnTypes []types.DataType, a, b []any) (int, error) {
+	if len(a) != len(columnTypes) || len(b) != len(columnTypes) {
+		return 0, fmt.Errorf("expected cursors of %d values, got %d and %d", len(columnTypes), len(a), len(b))
+	}
+	for idx, typ := range columnTypes {
+		result, err := CompareCursor(typ, a[idx], b[idx])
+		if err != nil || result != 0 {
+			return result, err
+		}
+	}
+	return 0, nil
+}
+
+// ApplyLookback moves the value of a cursor column back by lookback; timestamps by its
+// duration and integers by its count
+func ApplyLookback(lookback types.Lookback, typ types.DataType, value any) (any, error) {
+	duration, count, err := lookback.Parse()
+	if err != nil || (duration == 0 && count == 0) {
+		return value, err
+	}
+	switch typ {
+	case types.Timestamp, types.TimestampMilli, types.TimestampMicro, types.TimestampNano:
+		timestamp, err := ReformatDate(value)
+		if err != nil {
+			return nil, err
+		}
+		return timestamp.Add(-duration), nil
+	case types.Int64:
+		integer, err := ReformatInt64(value)
+		if err != nil {
+			return nil, err
+		}
+		return integer - count, nil
+	default:
+		return nil, fmt.Errorf("lookback needs a timestamp or integer cursor, not %s", typ)
+	}
+}
+
+// ReadCursor returns the values of the cursor columns kept in state, typed like the columns
+// of schema; nil when state holds none. Composite cursors are kept as a list of values
+func ReadCursor(schema *types.TypeSchema, columns []string, state any) ([]any, error) {
+	if state == nil {
+		return nil, nil
+	}
+	values := []any{state}
+	if len(columns) > 1 {
+		list, ok := state.([]any)
+		if !ok || len(list) != len(columns) {
+			return nil, fmt.Errorf("expected a list of %d cursor values, got %v", len(columns), state)
+		}
+		values = list
+	}
+	typed := make([]any, len(values))
+	for idx, column := range columns {
+		typ, err := schema.GetType(column)
+		if err != nil {
+			return nil, err
+		}
+		if typed[idx], err = ReformatValue(typ, values[idx]); err != nil {
+			return nil, fmt.Errorf("invalid value[%v] of cursor column[%s]: %s", values[idx], column, err)
+		}
+	}
+	return typed, nil
+}
+
+// CursorState returns the values of the cursor columns as kept in state by ReadCursor
+func CursorState(values []any) any {
+	if len(values) == 1 {
+		return values[0]
+	}
+	return values
+}
@@ -0,0 +1,83 @@
+package typeutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestCompareCursor(t *testing.T) {
+	for _, test := range []struct {
+		typ      types.DataType
+		a, b     any
+		expected int
+	}{
+		{types.Int64, int64(3), float64(10), -1},
+		{types.Float64, 2.5, int64(2), 1},
+		{types.String, "b", "a", 1},
+		{types.Timestamp, "2024-01-01T00:00:00Z", "2024-01-01T00:00:00Z", 0},
+	} {
+		result, err := CompareCursor(test.typ, test.a, test.b)
+		if err != nil || result != test.expected {
+			t.Errorf("compare %v and %v as %s: expected %d, got %d (%v)", test.a, test.b, test.typ, test.expected, result, err)
+		}
+	}
+}
+
+func TestCompositeCursor(t *testing.T) {
+	columnTypes := []types.DataType{types.Timestamp, types.Int64}
+	noon := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		a, b     []any
+		expected int
+	}{
+		{[]any{noon, int64(9)}, []any{noon.Add(time.Second), int64(1)}, -1},
+		{[]any{noon, int64(9)}, []any{noon, int64(1)}, 1},
+		{[]any{noon, int64(9)}, []any{"2024-01-01T12:00:00Z", float64(9)}, 0},
+	} {
+		result, err := CompareCursors(columnTypes, test.a, test.b)
+		if err != nil || result != test.expected {
+			t.Errorf("compare %v and %v: expected %d, got %d (%v)", test.a, test.b, test.expected, result, err)
+		}
+	}
+	if _, err := CompareCursors(columnTypes, []any{noon}, []any{noon, 1}); err == nil {
+		t.Fatal("expected cursors of a different length to be rejected")
+	}
+
+	schema := types.NewTypeSchema()
+	schema.AddTypes("updated_at", types.Timestamp)
+	schema.AddTypes("id", types.Int64)
+	columns := []string{"updated_at", "id"}
+	state := CursorState([]any{noon, int64(7)})
+	// state is read back from json
+	cursor, err := ReadCursor(schema, columns, []any{"2024-01-01T12:00:00Z", float64(7)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same, err := CompareCursors(columnTypes, cursor, state.([]any)); err != nil || same != 0 || cursor[1] != int64(7) {
+		t.Fatalf("expected the cursor kept in state, got %v: %v", cursor, err)
+	}
+	if _, err := ReadCursor(schema, columns, "2024-01-01T12:00:00Z"); err == nil {
+		t.Fatal("expected a single value of a composite cursor to be rejected")
+	}
+	if single := CursorState([]any{int64(7)}); single != int64(7) {
+		t.Fatalf("expected single cursors to be kept as their value, got %v", single)
+	}
+}
+
+func TestApplyLookback(t *testing.T) {
+	noon := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if value, err := ApplyLookback("30m", types.Timestamp, "2024-01-01T12:00:00Z"); err != nil || !value.(time.Time).Equal(noon.Add(-30*time.Minute)) {
+		t.Fatalf("expected the timestamp 30 minutes earlier, got %v: %v", value, err)
+	}
+	if value, err := ApplyLookback("1000", types.Int64, float64(5000)); err != nil || value != int64(4000) {
+		t.Fatalf("expected the id 1000 lower, got %v: %v", value, err)
+	}
+	if value, err := ApplyLookback("", types.String, "b"); err != nil || value != "b" {
+		t.Fatalf("expected no lookback to keep the value, got %v: %v", value, err)
+	}
+	if _, err := ApplyLookback("30m", types.String, "b"); err == nil {
+		t.Fatal("expected a lookback of a string cursor to be rejected")
+	}
+}
@@ -14,6 +14,14 @@ func TypeFromValue(v interface{}) types.DataType {
 	if v == nil {
 		return types.Null
 	}
+	switch v.(type) {
+	case types.WKB:
+		return types.Geometry
+	case types.RawJSON:
+		return types.JSON
+	case []byte:
+		return types.Bytes
+	}
 
 	// Check if v is a pointer and get the underlying element type if it is
 	valType := reflect.TypeOf(v)
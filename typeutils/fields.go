@@ -110,7 +110,7 @@ func (f Fields) Process(record types.Record) (bool, bool, Fields) {
 		detectedType := TypeFromValue(value)
 		if val, found := f[key]; found {
 			currentType := val.getType()
-			if detectedType != types.Null && currentType != detectedType { // compare current type
+			if detectedType != types.Null && currentType != detectedType && !fits(currentType, detectedType) { // compare current type
 				f[key].Merge(NewField(detectedType)) // merged data types for this field
 				updatedType := f[key].getType()
 				if updatedType != currentType {
@@ -186,7 +186,8 @@ func (f *Field) getType() types.DataType {
 	}
 
 	if len(typs) == 0 {
-		logger.Fatal("Field typeOccurrence can't be empty")
+		// never exit from the goroutines sampling streams
+		logger.Error("Field typeOccurrence can't be empty")
 		return types.Unknown
 	}
 
@@ -236,8 +237,33 @@ func (f *Field) Merge(anotherField *Field) {
 	}
 }
 
+// fits reports if values detected as detected are written to a column of typ as they are:
+// drivers read text of some types as bytes, geometries arrive as wkb, wkt or geojson and json
+// as any value
+func fits(typ, detected types.DataType) bool {
+	switch typ {
+	case types.String:
+		return detected == types.Bytes
+	case types.Bytes:
+		return detected == types.String
+	case types.Geometry:
+		return detected == types.Bytes || detected == types.String || detected == types.Object
+	case types.JSON:
+		return true
+	}
+	return false
+}
+
 // GetCommonAncestorType returns lowest common ancestor type
 func GetCommonAncestorType(t1, t2 types.DataType) types.DataType {
+	// json holds objects and arrays
+	if (t1 == types.JSON && (t2 == types.Object || t2 == types.Array)) || (t2 == types.JSON && (t1 == types.Object || t1 == types.Array)) {
+		return types.JSON
+	}
+	// binary values, geometries and json are outside the typecast tree
+	if t1 != t2 && (t1 == types.JSON || t2 == types.JSON || t1 == types.Bytes || t2 == types.Bytes || t1 == types.Geometry || t2 == types.Geometry) {
+		return types.String
+	}
 	return lowestCommonAncestor(typecastTree, t1, t2)
 }
 
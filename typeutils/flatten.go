@@ -1,12 +1,12 @@
 package typeutils
 
 import (
+	"bytes"
 	"fmt"
 	"reflect"
 	"strings"
 
-	"github.com/goccy/go-json"
-
+	"github.com/datazip-inc/olake/pkg/jsonx"
 	"github.com/datazip-inc/olake/types"
 )
 
@@ -25,7 +25,7 @@ func NewFlattener() Flattener {
 }
 
 func (f *FlattenerImpl) Flatten(json types.Record) (types.Record, error) {
-	destination := make(types.Record)
+	destination := types.NewRecord()
 
 	for key, value := range json {
 		err := f.flatten(key, value, destination)
@@ -40,20 +40,26 @@ func (f *FlattenerImpl) Flatten(json types.Record) (types.Record, error) {
 // Reformat key
 func (f *FlattenerImpl) flatten(key string, value any, destination types.Record) error {
 	key = Reformat(key)
+	// binary values, geometries and json are columns of their own, not arrays or objects
+	switch value.(type) {
+	case []byte, types.WKB, types.RawJSON:
+		destination[key] = value
+		return nil
+	}
 	t := reflect.ValueOf(value)
 	switch t.Kind() {
 	case reflect.Slice: // Stringify arrays
-		b, err := json.Marshal(value)
+		text, err := stringify(value)
 		if err != nil {
 			return fmt.Errorf("error marshaling array with key %s: %v", key, err)
 		}
-		destination[key] = string(b)
+		destination[key] = text
 	case reflect.Map: // Stringify nested maps
-		b, err := json.Marshal(value)
+		text, err := stringify(value)
 		if err != nil {
 			return fmt.Errorf("error marshaling array with key[%s] and value %v: %v", key, value, err)
 		}
-		destination[key] = string(b)
+		destination[key] = text
 	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
 		reflect.Float32, reflect.Float64, reflect.String:
@@ -67,6 +73,16 @@ func (f *FlattenerImpl) flatten(key string, value any, destination types.Record)
 	return nil
 }
 
+// stringify returns value as json text, encoded in a pooled buffer
+func stringify(value any) (string, error) {
+	buf := jsonx.GetBuffer()
+	defer jsonx.PutBuffer(buf)
+	if err := jsonx.Encode(buf, value); err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte{'\n'})), nil
+}
+
 // Reformat makes all keys to lower case and replaces all special symbols with '_'
 func Reformat(key string) string {
 	key = strings.ToLower(key)
@@ -0,0 +1,78 @@
+package typeutils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/datazip-inc/olake/types"
+	"github.com/goccy/go-json"
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestJSONValues(t *testing.T) {
+	fields := Fields{"attributes": NewField(types.JSON)}
+	record := types.Record{"attributes": []byte(`{"size": 2, "tags": ["a"]}`)}
+	flattened, err := NewFlattener().Flatten(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change, typeChange, _ := fields.Process(flattened); change || typeChange {
+		t.Fatal("expected json in its column not to change the schema")
+	}
+	if err := ReformatRecord(fields, flattened); err != nil {
+		t.Fatal(err)
+	}
+	value, isJSON := flattened["attributes"].(types.RawJSON)
+	if !isJSON || value.String() != `{"size": 2, "tags": ["a"]}` {
+		t.Fatalf("unexpected value %v", flattened["attributes"])
+	}
+
+	// json values are neither flattened nor quoted
+	flattened, err = NewFlattener().Flatten(types.Record{"attributes": value})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(flattened)
+	if err != nil || string(data) != `{"attributes":{"size":2,"tags":["a"]}}` {
+		t.Fatalf("unexpected json %s: %v", data, err)
+	}
+
+	// objects coerced to json
+	coerced, err := Coerce(types.JSON, map[string]any{"a": 1})
+	if err != nil || TypeFromValue(coerced) != types.JSON || coerced.(types.RawJSON).String() != `{"a":1}` {
+		t.Fatalf("unexpected coerced value %v: %v", coerced, err)
+	}
+	if text, err := Coerce(types.String, coerced); err != nil || text != `{"a":1}` {
+		t.Fatalf("unexpected string %v: %v", text, err)
+	}
+	if GetCommonAncestorType(types.Object, types.JSON) != types.JSON || GetCommonAncestorType(types.JSON, types.Int64) != types.String {
+		t.Fatal("unexpected common ancestor of json")
+	}
+	if _, err := ReformatValue(types.JSON, "not json"); err == nil {
+		t.Fatal("expected text that isn't json to be rejected")
+	}
+
+	// parquet stores the text in a json column
+	schema := &types.TypeSchema{}
+	schema.AddTypes("attributes", types.JSON)
+	var buffer bytes.Buffer
+	writer := parquet.NewGenericWriter[any](&buffer, schema.ToParquet())
+	if _, err := writer.Write([]any{map[string]any{"attributes": value}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file, err := parquet.OpenFile(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	column := file.Schema().Fields()[0]
+	if column.Type().LogicalType() == nil || column.Type().LogicalType().Json == nil {
+		t.Fatalf("expected a json column, got %s", column.Type())
+	}
+	rows := make([]parquet.Row, 1)
+	if n, _ := file.RowGroups()[0].Rows().ReadRows(rows); n != 1 || string(rows[0][0].ByteArray()) != value.String() {
+		t.Fatalf("unexpected rows %v", rows[:n])
+	}
+}
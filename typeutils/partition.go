@@ -0,0 +1,24 @@
+package typeutils
+
+import (
+	"time"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/types"
+)
+
+// PartitionFolder returns the folder of record in partition; records without a time in the
+// partition column go to the default partition
+func PartitionFolder(partition *types.TemporalPartition, record map[string]any) string {
+	value := record[partition.Column]
+	if value == nil {
+		return partition.Folder(nil)
+	}
+	converted, err := ReformatValue(types.Timestamp, value)
+	timestamp, ok := converted.(time.Time)
+	if err != nil || !ok {
+		logger.ThrottledWarnf("partition:"+partition.Column, "value[%v] of partition column[%s] is not a time; written to the default partition", value, partition.Column)
+		return partition.Folder(nil)
+	}
+	return partition.Folder(&timestamp)
+}
@@ -0,0 +1,43 @@
+package typeutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestPartitionFolder(t *testing.T) {
+	if _, err := time.LoadLocation("Asia/Kolkata"); err != nil {
+		t.Skip("time zone database not available")
+	}
+	// 2024-12-30T20:00:00Z is 2024-12-31T01:30 in Kolkata, in the first ISO week of 2025
+	value := time.Date(2024, 12, 30, 20, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		partition types.TemporalPartition
+		value     any
+		expected  string
+	}{
+		{types.TemporalPartition{Column: "created_at"}, value, "created_at_day=2024-12-30"},
+		{types.TemporalPartition{Column: "created_at", Timezone: "Asia/Kolkata"}, value, "created_at_day=2024-12-31"},
+		{types.TemporalPartition{Column: "created_at", Granularity: types.PartitionHour, Timezone: "Asia/Kolkata"}, value, "created_at_hour=2024-12-31-01"},
+		{types.TemporalPartition{Column: "created_at", Granularity: types.PartitionWeek, Timezone: "Asia/Kolkata"}, value, "created_at_week=2025-W01"},
+		{types.TemporalPartition{Column: "created_at", Granularity: types.PartitionMonth}, "2024-03-01 10:30:00", "created_at_month=2024-03"},
+		{types.TemporalPartition{Column: "created_at", Granularity: types.PartitionYear, Name: "year"}, value, "year=2024"},
+		{types.TemporalPartition{Column: "created_at"}, nil, "created_at_day=" + types.DefaultPartition},
+		{types.TemporalPartition{Column: "created_at"}, "not a time", "created_at_day=" + types.DefaultPartition},
+	} {
+		if err := test.partition.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		folder := PartitionFolder(&test.partition, map[string]any{"created_at": test.value})
+		if folder != test.expected {
+			t.Errorf("expected %s for %v, got %s", test.expected, test.value, folder)
+		}
+	}
+
+	invalid := types.TemporalPartition{Column: "created_at", Granularity: "minute"}
+	if err := invalid.Validate(); err == nil {
+		t.Error("expected an invalid granularity to fail")
+	}
+}
@@ -2,8 +2,10 @@ package typeutils
 
 import (
 	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/datazip-inc/olake/types"
@@ -115,6 +117,12 @@ func ReformatValue(dataType types.DataType, v any) (any, error) {
 		}
 	case types.Float64:
 		return ReformatFloat64(v)
+	case types.Bytes:
+		return ReformatBytes(v)
+	case types.Geometry:
+		return types.NewWKB(v)
+	case types.JSON:
+		return types.NewRawJSON(v)
 	case types.Array:
 		if value, isArray := v.([]any); isArray {
 			return value, nil
@@ -284,6 +292,29 @@ func ReformatFloat64(v interface{}) (interface{}, error) {
 	return float64(0), fmt.Errorf("failed to change %v (type:%T) to float64", v, v)
 }
 
+// ReformatBytes returns binary values as bytes; strings in the hex format of postgres, e.g.
+// \x0102 from change streams, are decoded
+func ReformatBytes(v any) ([]byte, error) {
+	switch value := v.(type) {
+	case []byte:
+		return value, nil
+	case types.WKB:
+		return []byte(value), nil
+	case string:
+		if encoded, found := strings.CutPrefix(value, `\x`); found {
+			decoded, err := hex.DecodeString(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("invalid hex encoded bytes: %s", err)
+			}
+			return decoded, nil
+		}
+		return []byte(value), nil
+	case primitive.Binary:
+		return value.Data, nil
+	}
+	return nil, fmt.Errorf("failed to change %v (type:%T) to bytes", v, v)
+}
+
 func ReformatByteArraysToString(data map[string]any) map[string]any {
 	for key, value := range data {
 		switch value := value.(type) {
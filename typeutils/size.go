@@ -0,0 +1,45 @@
+package typeutils
+
+import "github.com/datazip-inc/olake/types"
+
+// fixedSize is counted for values other than text, binary and nested ones, such as numbers,
+// booleans and timestamps
+const fixedSize = 8
+
+// RecordSize returns the size of a record as the bytes of its keys and values; a cheap
+// estimate of its serialized size that needs no encoding
+func RecordSize(record map[string]any) int {
+	size := 0
+	for key, value := range record {
+		size += len(key) + ValueSize(value)
+	}
+	return size
+}
+
+// ValueSize returns the bytes of a value as counted by RecordSize
+func ValueSize(value any) int {
+	switch value := value.(type) {
+	case nil:
+		return 0
+	case string:
+		return len(value)
+	case []byte:
+		return len(value)
+	case types.WKB:
+		return len(value)
+	case types.RawJSON:
+		return len(value)
+	case map[string]any:
+		return RecordSize(value)
+	case types.Record:
+		return RecordSize(value)
+	case []any:
+		size := 0
+		for _, elem := range value {
+			size += ValueSize(elem)
+		}
+		return size
+	default:
+		return fixedSize
+	}
+}
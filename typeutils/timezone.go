@@ -0,0 +1,41 @@
+package typeutils
+
+import (
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+// NaiveLayout is the text of timestamps without a time zone under the passthrough policy
+const NaiveLayout = "2006-01-02T15:04:05.999999999"
+
+// NaiveTimestamps reads the values of timestamp columns without a time zone by a policy
+type NaiveTimestamps struct {
+	Policy types.NaiveTimestampPolicy
+	// time zone of the source server, used by the source policy
+	Location *time.Location
+}
+
+// Read returns the UTC timestamp of the wall clock of v, or its text with passthrough; the
+// zone drivers attach to such values is ignored
+func (n *NaiveTimestamps) Read(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if text, isString := v.(string); isString && n.Policy == types.NaiveTimestampsPassthrough {
+		return text, nil
+	}
+	parsed, err := ReformatDate(v)
+	if err != nil {
+		return nil, err
+	}
+	location := time.UTC
+	if n.Policy == types.NaiveTimestampsSource && n.Location != nil {
+		location = n.Location
+	}
+	wall := time.Date(parsed.Year(), parsed.Month(), parsed.Day(), parsed.Hour(), parsed.Minute(), parsed.Second(), parsed.Nanosecond(), location)
+	if n.Policy == types.NaiveTimestampsPassthrough {
+		return wall.Format(NaiveLayout), nil
+	}
+	return wall.UTC(), nil
+}
@@ -0,0 +1,44 @@
+package typeutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestNaiveTimestamps(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	// drivers attach UTC or the session zone to naive values, change streams carry text
+	wall := time.Date(2024, 3, 1, 10, 30, 0, 0, time.FixedZone("", 2*60*60))
+	for _, test := range []struct {
+		policy   types.NaiveTimestampPolicy
+		value    any
+		expected any
+	}{
+		{types.NaiveTimestampsUTC, wall, time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)},
+		{types.NaiveTimestampsSource, wall, time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)},
+		{types.NaiveTimestampsSource, "2024-07-01 10:30:00", time.Date(2024, 7, 1, 8, 30, 0, 0, time.UTC)},
+		{types.NaiveTimestampsPassthrough, wall, "2024-03-01T10:30:00"},
+		{types.NaiveTimestampsPassthrough, "2024-03-01 10:30:00", "2024-03-01 10:30:00"},
+	} {
+		reader := &NaiveTimestamps{Policy: test.policy, Location: berlin}
+		read, err := reader.Read(test.value)
+		if err != nil {
+			t.Fatalf("%s %v: %s", test.policy, test.value, err)
+		}
+		if timestamp, ok := read.(time.Time); ok {
+			if !timestamp.Equal(test.expected.(time.Time)) || timestamp.Location() != time.UTC {
+				t.Errorf("%s %v: expected %v, got %v", test.policy, test.value, test.expected, read)
+			}
+		} else if read != test.expected {
+			t.Errorf("%s %v: expected %v, got %v", test.policy, test.value, test.expected, read)
+		}
+	}
+	if err := types.NaiveTimestampPolicy("local").Validate(); err == nil {
+		t.Fatal("expected an unknown policy to be rejected")
+	}
+}
@@ -0,0 +1,246 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/datazip-inc/olake/pkg/encryption"
+	"github.com/goccy/go-json"
+)
+
+// EnvPrefix starts the environment variables flags and config fields are read from
+const EnvPrefix = "OLAKE_"
+
+// separates the nested keys of config fields in environment variables
+const envFieldSeparator = "__"
+
+// EnvName returns the environment variable of a flag, e.g. OLAKE_STATE_CONFIG for --state-config
+func EnvName(flag string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// envFields returns the environment variables setting fields of the config file of flag,
+// e.g. OLAKE_DESTINATION__WRITER__BUCKET, sorted so they apply in a stable order
+func envFields(flag string) []string {
+	prefix := EnvName(flag) + envFieldSeparator
+	var variables []string
+	for _, variable := range os.Environ() {
+		if strings.HasPrefix(variable, prefix) {
+			variables = append(variables, variable)
+		}
+	}
+	sort.Strings(variables)
+	return variables
+}
+
+// HasEnvFields reports if environment variables set fields of the config file of flag, so the
+// config can be given without a file
+func HasEnvFields(flag string) bool {
+	return len(envFields(flag)) > 0
+}
+
+// UnmarshalFileWithEnv unmarshals the config file of flag into dest like UnmarshalFile, with
+// the fields set by environment variables overridden: OLAKE_DESTINATION__WRITER__BUCKET=b sets
+// writer.bucket of the --destination config. Keys match case insensitively and digits index
+// arrays. The file may be empty when the environment holds the whole config.
+func UnmarshalFileWithEnv(file, flag string, dest any) error {
+	variables := envFields(flag)
+	if len(variables) == 0 {
+		return UnmarshalFile(file, dest)
+	}
+
+	document := map[string]any{}
+	if file != "" {
+		if err := CheckIfFilesExists(file); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("file not found : %s", err)
+		}
+		if data, err = encryption.Open(data); err != nil {
+			return fmt.Errorf("failed to decrypt file[%s]: %s", file, err)
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&document); err != nil {
+			return fmt.Errorf("failed to unmarshal file[%s]: %s", file, err)
+		}
+	}
+
+	prefix := EnvName(flag) + envFieldSeparator
+	for _, variable := range variables {
+		name, value, _ := strings.Cut(variable, "=")
+		path := strings.Split(strings.ToLower(strings.TrimPrefix(name, prefix)), envFieldSeparator)
+		updated, err := setEnvField(document, path, value, reflect.TypeOf(dest))
+		if err != nil {
+			return fmt.Errorf("invalid %s: %s", name, err)
+		}
+		document = updated.(map[string]any)
+	}
+
+	data, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	// environment variables may hold encrypted values too
+	if data, err = encryption.Open(data); err != nil {
+		return fmt.Errorf("failed to decrypt environment of --%s: %s", flag, err)
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("failed to unmarshal config of --%s with its environment variables: %s", flag, err)
+	}
+	return nil
+}
+
+// setEnvField sets path of node to value and returns the updated node; typ is the go type the
+// node is unmarshaled into, nil when unknown
+func setEnvField(node any, path []string, value string, typ reflect.Type) (any, error) {
+	if len(path) == 0 {
+		return envValue(node, value, typ)
+	}
+	key := path[0]
+	if key == "" {
+		return nil, fmt.Errorf("empty key")
+	}
+	switch current := node.(type) {
+	case []any:
+		idx, err := strconv.Atoi(key)
+		if err != nil || idx < 0 || idx > len(current) {
+			return nil, fmt.Errorf("invalid index[%s] of an array of %d elements", key, len(current))
+		}
+		var elem any
+		if idx < len(current) {
+			elem = current[idx]
+		}
+		updated, err := setEnvField(elem, path[1:], value, elemType(typ, key))
+		if err != nil {
+			return nil, err
+		}
+		if idx == len(current) {
+			return append(current, updated), nil
+		}
+		current[idx] = updated
+		return current, nil
+	case map[string]any:
+		// keys of the file keep their case
+		for existing := range current {
+			if strings.EqualFold(existing, key) {
+				key = existing
+				break
+			}
+		}
+		updated, err := setEnvField(current[key], path[1:], value, elemType(typ, key))
+		if err != nil {
+			return nil, err
+		}
+		current[key] = updated
+		return current, nil
+	case nil:
+		if _, err := strconv.Atoi(key); err == nil && isKind(typ, reflect.Slice, reflect.Array) {
+			return setEnvField([]any{}, path, value, typ)
+		}
+		return setEnvField(map[string]any{}, path, value, typ)
+	default:
+		return nil, fmt.Errorf("can't set key[%s] of a %T value", key, node)
+	}
+}
+
+// envValue converts value to the type of the field, or to the type of the value it replaces
+func envValue(existing any, value string, typ reflect.Type) (any, error) {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() == reflect.Interface {
+		if _, isString := existing.(string); isString {
+			return value, nil
+		}
+		// json values, such as numbers, booleans and objects, keep their type
+		var decoded any
+		decoder := json.NewDecoder(strings.NewReader(value))
+		decoder.UseNumber()
+		if err := decoder.Decode(&decoded); err == nil && !decoder.More() {
+			return decoded, nil
+		}
+		return value, nil
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		return value, nil
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("expected a boolean, got %s", value)
+		}
+		return parsed, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8,
+		reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, fmt.Errorf("expected a number, got %s", value)
+		}
+		return json.Number(value), nil
+	}
+	var decoded any
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		// lists of strings may be given comma separated
+		if typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.String {
+			return strings.Split(value, ","), nil
+		}
+		return nil, fmt.Errorf("expected json, got %s", value)
+	}
+	return decoded, nil
+}
+
+// elemType returns the type of key in a value of typ, nil when unknown
+func elemType(typ reflect.Type, key string) reflect.Type {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == nil {
+		return nil
+	}
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return typ.Elem()
+	case reflect.Struct:
+		for idx := 0; idx < typ.NumField(); idx++ {
+			field := typ.Field(idx)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if field.Anonymous && name == "" {
+				if found := elemType(field.Type, key); found != nil {
+					return found
+				}
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			if field.IsExported() && name != "-" && strings.EqualFold(name, key) {
+				return field.Type
+			}
+		}
+	}
+	return nil
+}
+
+func isKind(typ reflect.Type, kinds ...reflect.Kind) bool {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == nil {
+		return false
+	}
+	for _, kind := range kinds {
+		if typ.Kind() == kind {
+			return true
+		}
+	}
+	return false
+}
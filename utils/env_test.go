@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalFileWithEnv(t *testing.T) {
+	type source struct {
+		Host     string   `json:"host"`
+		Port     int      `json:"port"`
+		SSL      bool     `json:"ssl"`
+		Password string   `json:"password"`
+		Schemas  []string `json:"schemas"`
+		Options  any      `json:"options"`
+	}
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"host": "localhost", "port": 5432, "Password": "file", "options": {"mode": "1"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("OLAKE_CONFIG__HOST", "db.internal")
+	t.Setenv("OLAKE_CONFIG__PORT", "6543")
+	t.Setenv("OLAKE_CONFIG__SSL", "true")
+	t.Setenv("OLAKE_CONFIG__PASSWORD", "00123")
+	t.Setenv("OLAKE_CONFIG__SCHEMAS", "public,sales")
+	t.Setenv("OLAKE_CONFIG__OPTIONS__MODE", "2")
+	t.Setenv("OLAKE_CONFIG__OPTIONS__RETRIES", "3")
+
+	config := &source{}
+	if err := UnmarshalFileWithEnv(path, "config", config); err != nil {
+		t.Fatal(err)
+	}
+	expected := &source{
+		Host: "db.internal", Port: 6543, SSL: true, Password: "00123", Schemas: []string{"public", "sales"},
+		// untyped values that were strings stay strings, new ones are parsed as json
+		Options: map[string]any{"mode": "2", "retries": float64(3)},
+	}
+	if !reflect.DeepEqual(config, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, config)
+	}
+
+	// the environment alone is a config
+	config = &source{}
+	if err := UnmarshalFileWithEnv("", "config", config); err != nil || config.Host != "db.internal" || config.Port != 6543 {
+		t.Fatalf("expected a config from the environment, got %+v: %v", config, err)
+	}
+
+	t.Setenv("OLAKE_CONFIG__PORT", "default")
+	if err := UnmarshalFileWithEnv(path, "config", &source{}); err == nil {
+		t.Fatal("expected a port that isn't a number to be rejected")
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	if name := EnvName("state-config"); name != "OLAKE_STATE_CONFIG" {
+		t.Fatalf("unexpected name %s", name)
+	}
+	if HasEnvFields("destination") {
+		t.Fatal("expected no destination fields in the environment")
+	}
+	t.Setenv("OLAKE_DESTINATION__TYPE", "PARQUET")
+	if !HasEnvFields("destination") {
+		t.Fatal("expected destination fields in the environment")
+	}
+}
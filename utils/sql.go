@@ -1,6 +1,16 @@
 package utils
 
-import "database/sql"
+import (
+	"database/sql"
+	"sync"
+)
+
+// scanTargets are pointers rows are scanned into, pooled since every row needs one per column
+var scanTargets = sync.Pool{
+	New: func() any {
+		return new([]any)
+	},
+}
 
 func MapScan(rows *sql.Rows, dest map[string]any) error {
 	columns, err := rows.Columns()
@@ -8,17 +18,21 @@ func MapScan(rows *sql.Rows, dest map[string]any) error {
 		return err
 	}
 
-	scanValues := make([]any, len(columns))
-	for i := range scanValues {
-		scanValues[i] = new(any) // Allocate pointers for scanning
+	targets := scanTargets.Get().(*[]any)
+	defer scanTargets.Put(targets)
+	for len(*targets) < len(columns) {
+		*targets = append(*targets, new(any)) // Allocate pointers for scanning
 	}
+	scanValues := (*targets)[:len(columns)]
 
 	if err := rows.Scan(scanValues...); err != nil {
 		return err
 	}
 
 	for i, col := range columns {
-		dest[col] = *(scanValues[i].(*any)) // Dereference pointer before storing
+		value := scanValues[i].(*any)
+		dest[col] = *value // Dereference pointer before storing
+		*value = nil
 	}
 
 	return nil
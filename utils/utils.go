@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/encryption"
 	"github.com/goccy/go-json"
 	"github.com/oklog/ulid"
 
@@ -148,6 +149,12 @@ func UnmarshalFile(file string, dest any) error {
 		return fmt.Errorf("file not found : %s", err)
 	}
 
+	// encrypted state files and encrypted config values
+	data, err = encryption.Open(data)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt file[%s]: %s", file, err)
+	}
+
 	err = json.Unmarshal(data, dest)
 	if err != nil {
 		return fmt.Errorf("failed to unmarshal file[%s]: %s", file, err)
@@ -283,3 +290,35 @@ func CompareInterfaceValue(a, b interface{}) int {
 	}
 	return 0
 }
+
+// ApproximateSize estimates the encoded size of a value in bytes without serializing it
+func ApproximateSize(value any) int64 {
+	switch v := value.(type) {
+	case nil:
+		return 4
+	case string:
+		return int64(len(v))
+	case []byte:
+		return int64(len(v))
+	case bool:
+		return 1
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return 8
+	case time.Time:
+		return 24
+	case map[string]any:
+		size := int64(0)
+		for key, elem := range v {
+			size += int64(len(key)) + ApproximateSize(elem)
+		}
+		return size
+	case []any:
+		size := int64(0)
+		for _, elem := range v {
+			size += ApproximateSize(elem)
+		}
+		return size
+	default:
+		return int64(len(fmt.Sprint(v)))
+	}
+}
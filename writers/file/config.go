@@ -0,0 +1,80 @@
+package file
+
+import (
+	"fmt"
+
+	"github.com/datazip-inc/olake/pkg/compress"
+	"github.com/datazip-inc/olake/utils"
+)
+
+const (
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+	FormatAvro  = "avro"
+)
+
+type Config struct {
+	Path string `json:"local_path" validate:"required"`
+	// jsonl (default), csv or avro
+	Format string `json:"format,omitempty"`
+	// none (default), gzip, zstd, snappy or lz4; avro files only support none, gzip and snappy
+	// as they compress blocks inside the file
+	Compression compress.Codec `json:"compression,omitempty"`
+	// a new file is started once the current one reaches either limit; 0 is unlimited
+	MaxFileSize int64 `json:"max_file_size,omitempty"` // bytes
+	MaxRecords  int   `json:"max_records,omitempty"`
+	// csv files start with a header line of the column names unless disabled
+	Header    *bool  `json:"header,omitempty"`
+	Delimiter string `json:"delimiter,omitempty"` // csv field delimiter; defaults to ","
+	// csv and avro files are always normalized as they need columns
+	Normalization bool `json:"normalization,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	switch c.Format {
+	case "":
+		c.Format = FormatJSONL
+	case FormatJSONL, FormatCSV, FormatAvro:
+	default:
+		return fmt.Errorf("invalid format[%s]; expected jsonl, csv or avro", c.Format)
+	}
+	if c.Compression == "" {
+		c.Compression = compress.None
+	}
+	if err := c.Compression.Validate(); err != nil {
+		return err
+	}
+	if c.Format == FormatAvro {
+		if _, err := avroCompression(c.Compression); err != nil {
+			return err
+		}
+	}
+	if c.MaxFileSize < 0 || c.MaxRecords < 0 {
+		return fmt.Errorf("max_file_size and max_records must not be negative")
+	}
+	if c.Header == nil {
+		header := true
+		c.Header = &header
+	}
+	if c.Delimiter == "" {
+		c.Delimiter = ","
+	}
+	if delimiter := []rune(c.Delimiter); len(delimiter) != 1 || delimiter[0] == '"' || delimiter[0] == '\r' || delimiter[0] == '\n' {
+		return fmt.Errorf("invalid delimiter[%s]; expected a single character other than quote or newline", c.Delimiter)
+	}
+	return utils.Validate(c)
+}
+
+// avroCompression maps the codec to the avro block codec name
+func avroCompression(codec compress.Codec) (string, error) {
+	switch codec {
+	case compress.None:
+		return "null", nil
+	case compress.Gzip:
+		return "deflate", nil
+	case compress.Snappy:
+		return "snappy", nil
+	default:
+		return "", fmt.Errorf("compression[%s] is not supported by avro files; expected none, gzip or snappy", codec)
+	}
+}
@@ -0,0 +1,118 @@
+package file
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/datazip-inc/olake/constants"
+	"github.com/datazip-inc/olake/pkg/jsonx"
+	"github.com/datazip-inc/olake/pkg/serde"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+)
+
+// recordWriter encodes records of one file in its format
+type recordWriter interface {
+	Write(record types.RawRecord) error
+	// Flush writes everything buffered by the encoder to the underlying writer
+	Flush() error
+}
+
+// rawLine is a jsonl line of records written without normalization
+type rawLine struct {
+	OlakeID        string         `json:"olake_id"`
+	OlakeTimestamp int64          `json:"olake_insert_time"`
+	DeleteTime     int64          `json:"_cdc_deleted_at,omitempty"`
+	Partial        bool           `json:"olake_partial,omitempty"`
+	Data           map[string]any `json:"data"`
+}
+
+type jsonlWriter struct {
+	writer     io.Writer
+	normalized bool
+}
+
+func (j *jsonlWriter) Write(record types.RawRecord) error {
+	var line any = record.Data
+	if !j.normalized {
+		line = rawLine{OlakeID: record.OlakeID, OlakeTimestamp: record.OlakeTimestamp, DeleteTime: record.DeleteTime, Partial: record.Partial, Data: record.Data}
+	}
+	buf := jsonx.GetBuffer()
+	defer jsonx.PutBuffer(buf)
+	if err := jsonx.Encode(buf, line); err != nil {
+		return fmt.Errorf("failed to marshal record: %s", err)
+	}
+	_, err := j.writer.Write(buf.Bytes())
+	return err
+}
+
+func (j *jsonlWriter) Flush() error {
+	return nil
+}
+
+// csvWriter writes the columns of the stream schema at the time the file was opened; quoting
+// follows RFC 4180
+type csvWriter struct {
+	writer  *csv.Writer
+	columns []string
+	row     []string
+}
+
+func newCSVWriter(w io.Writer, schema *types.TypeSchema, delimiter rune, header bool) (*csvWriter, error) {
+	var columns []string
+	schema.Properties.Range(func(key, _ any) bool {
+		columns = append(columns, key.(string))
+		return true
+	})
+	sort.Slice(columns, func(i, j int) bool {
+		// olake id leads so files are easy to eyeball
+		if columns[i] == constants.OlakeID || columns[j] == constants.OlakeID {
+			return columns[i] == constants.OlakeID
+		}
+		return columns[i] < columns[j]
+	})
+
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+	if header {
+		if err := writer.Write(columns); err != nil {
+			return nil, fmt.Errorf("failed to write csv header: %s", err)
+		}
+	}
+	return &csvWriter{writer: writer, columns: columns, row: make([]string, len(columns))}, nil
+}
+
+func (c *csvWriter) Write(record types.RawRecord) error {
+	for idx, column := range c.columns {
+		value, found := record.Data[column]
+		if !found || value == nil {
+			c.row[idx] = ""
+			continue
+		}
+		converted, err := typeutils.Coerce(types.String, value)
+		if err != nil {
+			return fmt.Errorf("failed to convert column[%s]: %s", column, err)
+		}
+		c.row[idx] = converted.(string)
+	}
+	return c.writer.Write(c.row)
+}
+
+func (c *csvWriter) Flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type avroWriter struct {
+	writer *serde.AvroFileWriter
+}
+
+func (a *avroWriter) Write(record types.RawRecord) error {
+	return a.writer.Write(record.Data)
+}
+
+func (a *avroWriter) Flush() error {
+	return a.writer.Flush()
+}
@@ -0,0 +1,272 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/compress"
+	"github.com/datazip-inc/olake/pkg/serde"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+	"github.com/datazip-inc/olake/utils"
+)
+
+const bufferSize = 64 * 1024
+
+// File destination writes JSON lines, CSV or Avro files to a local path; a new file is started
+// once the current one reaches the configured size or record count. Streams with a temporal
+// partition keep a file open in the folder of each partition written to.
+type File struct {
+	config    *Config
+	stream    protocol.Stream
+	table     string
+	directory string             // local_path/namespace/table
+	outputs   map[string]*output // open files by partition folder, "" when not partitioned
+}
+
+// output is an open file; opened on the first record so no empty files are left behind
+type output struct {
+	path       string
+	file       *os.File
+	counter    *countingWriter
+	compressor io.WriteCloser
+	buffer     *bufio.Writer
+	writer     recordWriter
+	records    int
+}
+
+// countingWriter counts the bytes that reached the file
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.writer.Write(p)
+	c.count += int64(n)
+	return n, err
+}
+
+// GetConfigRef returns the config reference for the file writer.
+func (f *File) GetConfigRef() protocol.Config {
+	f.config = &Config{}
+	return f.config
+}
+
+// Spec returns a new Config instance.
+func (f *File) Spec() any {
+	return Config{}
+}
+
+// Check validates the config and creates the local path.
+func (f *File) Check() error {
+	if err := f.config.Validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
+	if err := os.MkdirAll(f.config.Path, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create directories[%s]: %s", f.config.Path, err)
+	}
+	return nil
+}
+
+// DeepCheck verifies files can be created under the local path.
+func (f *File) DeepCheck(_ context.Context) []types.CheckResult {
+	if err := f.config.Validate(); err != nil {
+		return []types.CheckResult{{Name: "config", Message: err.Error(), Remediation: "fix the writer config"}}
+	}
+	return []types.CheckResult{protocol.WritablePathCheck(f.config.Path)}
+}
+
+func (f *File) Setup(stream protocol.Stream, options *protocol.Options) error {
+	if err := f.config.Validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
+	f.stream = stream
+	f.table = options.Table
+	f.directory = filepath.Join(f.config.Path, options.Namespace, options.Table)
+	f.outputs = make(map[string]*output)
+	return nil
+}
+
+// open starts a new file typed by the current stream schema in the partition folder
+func (f *File) open(folder string) (*output, error) {
+	directory := filepath.Join(f.directory, folder)
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create directories[%s]: %s", directory, err)
+	}
+	codec := f.config.Compression
+	if f.config.Format == FormatAvro {
+		// avro files compress their blocks
+		codec = compress.None
+	}
+	path := filepath.Join(directory, utils.TimestampedFileName(f.config.Format+codec.Extension()))
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file[%s]: %s", path, err)
+	}
+
+	out := &output{path: path, file: file, counter: &countingWriter{writer: file}}
+	if out.compressor, err = compress.NewWriter(out.counter, codec); err != nil {
+		file.Close()
+		return nil, err
+	}
+	out.buffer = bufio.NewWriterSize(out.compressor, bufferSize)
+
+	switch f.config.Format {
+	case FormatCSV:
+		out.writer, err = newCSVWriter(out.buffer, f.stream.Schema(), []rune(f.config.Delimiter)[0], *f.config.Header)
+	case FormatAvro:
+		compression, _ := avroCompression(f.config.Compression)
+		var writer *serde.AvroFileWriter
+		writer, err = serde.NewAvroFileWriter(out.buffer, f.table, f.stream.Schema(), compression)
+		out.writer = &avroWriter{writer: writer}
+	default:
+		out.writer = &jsonlWriter{writer: out.buffer, normalized: f.config.Normalization}
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	f.outputs[folder] = out
+	return out, nil
+}
+
+func (f *File) Write(_ context.Context, record types.RawRecord) error {
+	folder := ""
+	if partition := f.stream.Self().StreamMetadata.Partition; partition != nil {
+		folder = typeutils.PartitionFolder(partition, record.Data)
+	}
+	out, found := f.outputs[folder]
+	if !found {
+		var err error
+		if out, err = f.open(folder); err != nil {
+			return err
+		}
+	}
+	if err := out.writer.Write(record); err != nil {
+		return fmt.Errorf("failed to write to file[%s]: %s", out.path, err)
+	}
+	out.records++
+	if f.full(out) {
+		return f.closeFile(folder)
+	}
+	return nil
+}
+
+// full reports whether out reached a limit; the size counts buffered bytes before compression
+// so compressed files end up smaller than max_file_size
+func (f *File) full(out *output) bool {
+	if f.config.MaxRecords > 0 && out.records >= f.config.MaxRecords {
+		return true
+	}
+	return f.config.MaxFileSize > 0 && out.counter.count+int64(out.buffer.Buffered()) >= f.config.MaxFileSize
+}
+
+// closeFile closes the open file of the partition folder
+func (f *File) closeFile(folder string) error {
+	out := f.outputs[folder]
+	delete(f.outputs, folder)
+	err := func() error {
+		if err := out.writer.Flush(); err != nil {
+			return err
+		}
+		if err := out.buffer.Flush(); err != nil {
+			return err
+		}
+		return out.compressor.Close()
+	}()
+	if closeErr := out.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to close file[%s]: %s", out.path, err)
+	}
+	logger.Debugf("Closed file[%s] with %d records", out.path, out.records)
+	return nil
+}
+
+// Delete is not possible on append only files.
+func (f *File) Delete(_ context.Context, _ types.RawRecord) error {
+	return protocol.ErrDeleteUnsupported
+}
+
+// Close closes the open files.
+func (f *File) Close() error {
+	var err error
+	for folder := range f.outputs {
+		if closeErr := f.closeFile(folder); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// Flush closes the open files so the records written so far are complete in the destination.
+func (f *File) Flush(_ context.Context) error {
+	return f.Close()
+}
+
+// EvolveSchema starts new files for the evolved columns of csv and avro files.
+func (f *File) EvolveSchema(change, typeChange bool, _ map[string]*types.Property, _ types.Record) error {
+	if f.config.Format == FormatJSONL || !(change || typeChange) {
+		return nil
+	}
+	return f.Close()
+}
+
+// Type returns the type of the writer.
+func (f *File) Type() string {
+	return string(types.File)
+}
+
+func (f *File) Normalization() bool {
+	return f.config.Normalization || f.config.Format != FormatJSONL
+}
+
+// PooledRecords is true as records are encoded as they are written.
+func (f *File) PooledRecords() bool {
+	return true
+}
+
+// PartialRecords is supported by jsonl files, whose lines leave missing columns out.
+func (f *File) PartialRecords() bool {
+	return f.config.Format == FormatJSONL
+}
+
+func (f *File) SupportedTypes() *types.Set[types.DataType] {
+	return types.NewSet(types.DataTypes...)
+}
+
+// Flattener returns a flattening function for records.
+func (f *File) Flattener() protocol.FlattenFunction {
+	flattener := typeutils.NewFlattener()
+	return flattener.Flatten
+}
+
+func (f *File) BeginSync(_ context.Context) error {
+	return nil
+}
+
+func (f *File) BeginStream(_ context.Context, _ protocol.Stream) error {
+	return nil
+}
+
+func (f *File) EndStream(_ context.Context, _ protocol.Stream) error {
+	return nil
+}
+
+func (f *File) EndSync(_ context.Context, _ error) error {
+	return nil
+}
+
+func init() {
+	protocol.RegisteredWriters[types.File] = func() protocol.Writer {
+		return new(File)
+	}
+}
@@ -0,0 +1,212 @@
+package file
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/datazip-inc/olake/constants"
+	"github.com/datazip-inc/olake/pkg/compress"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/goccy/go-json"
+	"github.com/linkedin/goavro/v2"
+)
+
+func setupWriter(t *testing.T, config *Config) (*File, string) {
+	t.Helper()
+	config.Path = t.TempDir()
+	stream := types.NewStream("orders", "shop")
+	stream.UpsertField(constants.OlakeID, types.String, false)
+	stream.UpsertField("id", types.Int64, false)
+	stream.UpsertField("note", types.String, true)
+
+	writer := &File{config: config}
+	if err := writer.Setup(stream.Wrap(0), &protocol.Options{Namespace: "shop", Table: "orders"}); err != nil {
+		t.Fatal(err)
+	}
+	return writer, filepath.Join(config.Path, "shop", "orders")
+}
+
+func writeRecords(t *testing.T, writer *File, records ...map[string]any) {
+	t.Helper()
+	for idx, data := range records {
+		data[constants.OlakeID] = string(rune('a' + idx))
+		if err := writer.Write(context.Background(), types.CreateRawRecord(data[constants.OlakeID].(string), data, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func listFiles(t *testing.T, directory string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, entry := range entries {
+		files = append(files, filepath.Join(directory, entry.Name()))
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestCSVEscaping(t *testing.T) {
+	writer, directory := setupWriter(t, &Config{Format: FormatCSV})
+	writeRecords(t, writer,
+		map[string]any{"id": int64(1), "note": "plain"},
+		map[string]any{"id": int64(2), "note": "comma, \"quote\"\nnewline"},
+		map[string]any{"id": int64(3)},
+	)
+
+	files := listFiles(t, directory)
+	if len(files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(files))
+	}
+	file, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{
+		{constants.OlakeID, "id", "note"},
+		{"a", "1", "plain"},
+		{"b", "2", "comma, \"quote\"\nnewline"},
+		{"c", "3", ""},
+	}
+	if len(rows) != len(expected) {
+		t.Fatalf("expected %d rows, got %v", len(expected), rows)
+	}
+	for idx := range expected {
+		for column := range expected[idx] {
+			if rows[idx][column] != expected[idx][column] {
+				t.Errorf("row %d column %d: expected %q, got %q", idx, column, expected[idx][column], rows[idx][column])
+			}
+		}
+	}
+}
+
+func TestRolloverOnMaxRecords(t *testing.T) {
+	writer, directory := setupWriter(t, &Config{Format: FormatJSONL, Normalization: true, Compression: compress.Gzip, MaxRecords: 2})
+	writeRecords(t, writer,
+		map[string]any{"id": int64(1)},
+		map[string]any{"id": int64(2)},
+		map[string]any{"id": int64(3)},
+	)
+
+	files := listFiles(t, directory)
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(files))
+	}
+	lines := 0
+	for _, path := range files {
+		if filepath.Ext(path) != ".gz" {
+			t.Errorf("expected gzip extension, got %s", path)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader, err := compress.NewReader(file, compress.Gzip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoder := json.NewDecoder(reader)
+		for decoder.More() {
+			record := make(map[string]any)
+			if err := decoder.Decode(&record); err != nil {
+				t.Fatal(err)
+			}
+			lines++
+		}
+		reader.Close()
+		file.Close()
+	}
+	if lines != 3 {
+		t.Errorf("expected 3 records across files, got %d", lines)
+	}
+}
+
+func TestAvroFile(t *testing.T) {
+	writer, directory := setupWriter(t, &Config{Format: FormatAvro, Compression: compress.Snappy})
+	writeRecords(t, writer,
+		map[string]any{"id": int64(1), "note": "first"},
+		map[string]any{"id": int64(2)},
+	)
+
+	files := listFiles(t, directory)
+	if len(files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(files))
+	}
+	file, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	reader, err := goavro.NewOCFReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for reader.Scan() {
+		if _, err := reader.Read(); err != nil {
+			t.Fatal(err)
+		}
+		count++
+	}
+	if count != 2 {
+		t.Errorf("expected 2 records, got %d", count)
+	}
+}
+
+func TestAvroRejectsUnsupportedCompression(t *testing.T) {
+	config := &Config{Path: t.TempDir(), Format: FormatAvro, Compression: compress.Zstd}
+	if err := config.Validate(); err == nil {
+		t.Error("expected error for zstd compressed avro files")
+	}
+}
+
+func TestTemporalPartition(t *testing.T) {
+	writer, directory := setupWriter(t, &Config{Format: FormatJSONL, Normalization: true})
+	partition := &types.TemporalPartition{Column: "note", Granularity: types.PartitionMonth}
+	if err := partition.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	writer.stream.Self().StreamMetadata.Partition = partition
+	writeRecords(t, writer,
+		map[string]any{"id": int64(1), "note": "2024-03-01T10:00:00Z"},
+		map[string]any{"id": int64(2), "note": "2024-04-01T10:00:00Z"},
+		map[string]any{"id": int64(3), "note": "2024-03-31T10:00:00Z"},
+		map[string]any{"id": int64(4)},
+	)
+
+	folders := listFiles(t, directory)
+	expected := []string{"note_month=2024-03", "note_month=2024-04", "note_month=" + types.DefaultPartition}
+	sort.Strings(expected)
+	if len(folders) != len(expected) {
+		t.Fatalf("expected folders %v, got %v", expected, folders)
+	}
+	for idx, folder := range folders {
+		if filepath.Base(folder) != expected[idx] {
+			t.Fatalf("expected folders %v, got %v", expected, folders)
+		}
+		if files := listFiles(t, folder); len(files) != 1 {
+			t.Fatalf("expected 1 file in %s, got %d", folder, len(files))
+		}
+	}
+	// records are read back from every partition
+	if count, err := writer.CountRows(context.Background(), nil); err != nil || count != 4 {
+		t.Fatalf("expected 4 rows, got %d: %v", count, err)
+	}
+}
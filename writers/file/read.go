@@ -0,0 +1,230 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/datazip-inc/olake/constants"
+	"github.com/datazip-inc/olake/pkg/compress"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/goccy/go-json"
+	"github.com/linkedin/goavro/v2"
+)
+
+// version is the latest row written for an olake id
+type version struct {
+	insertTime int64
+	deleted    bool
+	row        map[string]any
+}
+
+// CountRows counts the rows of the stream in its files: the olake ids whose latest version
+// isn't a delete.
+func (f *File) CountRows(ctx context.Context, _ protocol.Stream) (int64, error) {
+	versions, err := f.readBack(ctx, false)
+	if err != nil {
+		return 0, err
+	}
+	count := int64(0)
+	for _, version := range versions {
+		if !version.deleted {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ScanRows reads back the latest version of every row of the stream that isn't deleted;
+// only jsonl files keep values comparable with the source.
+func (f *File) ScanRows(ctx context.Context, _ protocol.Stream, fn func(row map[string]any) error) error {
+	if f.config.Format != FormatJSONL {
+		return fmt.Errorf("rows can only be read back from jsonl files, not %s", f.config.Format)
+	}
+	versions, err := f.readBack(ctx, true)
+	if err != nil {
+		return err
+	}
+	for _, version := range versions {
+		if version.deleted {
+			continue
+		}
+		if err := fn(version.row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readBack reads every file of the stream in the configured format and keeps the latest
+// version of every olake id; rows are kept only when keepRows is set
+func (f *File) readBack(ctx context.Context, keepRows bool) (map[string]*version, error) {
+	suffix := "." + f.config.Format + f.config.Compression.Extension()
+	if f.config.Format == FormatAvro {
+		suffix = "." + FormatAvro
+	}
+	// files of partitioned streams are in partition folders
+	paths := []string{}
+	err := filepath.WalkDir(f.directory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), suffix) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return map[string]*version{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files[%s]: %s", f.directory, err)
+	}
+
+	versions := make(map[string]*version)
+	keep := func(id string, insertTime int64, deleted bool, row map[string]any) {
+		if latest, found := versions[id]; found && latest.insertTime > insertTime {
+			return
+		}
+		if !keepRows {
+			row = nil
+		}
+		versions[id] = &version{insertTime: insertTime, deleted: deleted, row: row}
+	}
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := f.readFile(path, keep); err != nil {
+			return nil, fmt.Errorf("failed to read file[%s]: %s", path, err)
+		}
+	}
+	return versions, nil
+}
+
+func (f *File) readFile(path string, keep func(id string, insertTime int64, deleted bool, row map[string]any)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if f.config.Format == FormatAvro {
+		return readAvro(file, keep)
+	}
+	reader, err := compress.NewReader(bufio.NewReaderSize(file, bufferSize), f.config.Compression)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if f.config.Format == FormatCSV {
+		return f.readCSV(reader, keep)
+	}
+	return f.readJSONL(reader, keep)
+}
+
+func (f *File) readJSONL(reader io.Reader, keep func(id string, insertTime int64, deleted bool, row map[string]any)) error {
+	decoder := json.NewDecoder(reader)
+	// keeps numbers as written so checksums match the source
+	decoder.UseNumber()
+	for {
+		row := make(map[string]any)
+		if err := decoder.Decode(&row); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		id, _ := row[constants.OlakeID].(string)
+		insertTime := jsonInt(row[constants.OlakeTimestamp])
+		deleted := jsonInt(row[constants.CDCDeletedAt]) != 0
+		if !f.config.Normalization {
+			// raw lines nest the record under data
+			data, _ := row["data"].(map[string]any)
+			row = data
+		}
+		keep(id, insertTime, deleted, row)
+	}
+}
+
+func (f *File) readCSV(reader io.Reader, keep func(id string, insertTime int64, deleted bool, row map[string]any)) error {
+	if !*f.config.Header {
+		return fmt.Errorf("csv files without a header can't be read back")
+	}
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = []rune(f.config.Delimiter)[0]
+	// files of an evolved schema have more columns
+	csvReader.FieldsPerRecord = -1
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		row := make(map[string]any, len(header))
+		for idx, column := range header {
+			if idx < len(record) && record[idx] != "" {
+				row[column] = record[idx]
+			}
+		}
+		id, _ := row[constants.OlakeID].(string)
+		keep(id, jsonInt(row[constants.OlakeTimestamp]), jsonInt(row[constants.CDCDeletedAt]) != 0, row)
+	}
+}
+
+func readAvro(reader io.Reader, keep func(id string, insertTime int64, deleted bool, row map[string]any)) error {
+	ocf, err := goavro.NewOCFReader(bufio.NewReaderSize(reader, bufferSize))
+	if err != nil {
+		return err
+	}
+	for ocf.Scan() {
+		datum, err := ocf.Read()
+		if err != nil {
+			return err
+		}
+		record, _ := datum.(map[string]any)
+		row := make(map[string]any, len(record))
+		for column, value := range record {
+			// every field is a union with null
+			if union, ok := value.(map[string]any); ok {
+				for _, member := range union {
+					value = member
+				}
+			}
+			if value != nil {
+				row[column] = value
+			}
+		}
+		id, _ := row[constants.OlakeID].(string)
+		keep(id, jsonInt(row[constants.OlakeTimestamp]), jsonInt(row[constants.CDCDeletedAt]) != 0, row)
+	}
+	return ocf.Err()
+}
+
+// jsonInt reads an integer column decoded from any of the file formats; 0 when unset
+func jsonInt(value any) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case json.Number:
+		parsed, _ := v.Int64()
+		return parsed
+	case string:
+		parsed, _ := strconv.ParseInt(v, 10, 64)
+		return parsed
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,69 @@
+package file
+
+import (
+	"context"
+	"testing"
+
+	"github.com/datazip-inc/olake/constants"
+	"github.com/datazip-inc/olake/pkg/compress"
+	"github.com/datazip-inc/olake/types"
+)
+
+func TestReadBack(t *testing.T) {
+	for _, config := range []*Config{
+		{Format: FormatJSONL},
+		{Format: FormatJSONL, Normalization: true, Compression: compress.Gzip},
+		{Format: FormatCSV},
+		{Format: FormatAvro},
+	} {
+		writer, _ := setupWriter(t, config)
+		if config.Format != FormatJSONL {
+			writer.stream.Schema().AddTypes(constants.OlakeTimestamp, types.Int64)
+			writer.stream.Schema().AddTypes(constants.CDCDeletedAt, types.Int64, types.Null)
+		}
+		for _, record := range []types.RawRecord{
+			{OlakeID: "a", OlakeTimestamp: 1, Data: map[string]any{"id": int64(1), "note": "first"}},
+			{OlakeID: "b", OlakeTimestamp: 1, Data: map[string]any{"id": int64(2)}},
+			{OlakeID: "c", OlakeTimestamp: 1, Data: map[string]any{"id": int64(3)}},
+			// a newer version of a and a delete of c
+			{OlakeID: "a", OlakeTimestamp: 2, Data: map[string]any{"id": int64(1), "note": "second"}},
+			{OlakeID: "c", OlakeTimestamp: 2, DeleteTime: 2, Data: map[string]any{"id": int64(3)}},
+		} {
+			// normalized records carry the olake columns, as the writer pool adds them
+			if writer.Normalization() {
+				record.Data[constants.OlakeID] = record.OlakeID
+				record.Data[constants.OlakeTimestamp] = record.OlakeTimestamp
+				if record.DeleteTime != 0 {
+					record.Data[constants.CDCDeletedAt] = record.DeleteTime
+				}
+			}
+			if err := writer.Write(context.Background(), record); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		count, err := writer.CountRows(context.Background(), nil)
+		if err != nil || count != 2 {
+			t.Fatalf("%s: expected 2 rows, got %d (%v)", config.Format, count, err)
+		}
+		if config.Format != FormatJSONL {
+			if err := writer.ScanRows(context.Background(), nil, func(map[string]any) error { return nil }); err == nil {
+				t.Fatalf("%s: expected rows of files other than jsonl not to be read back", config.Format)
+			}
+			continue
+		}
+		notes := map[string]bool{}
+		err = writer.ScanRows(context.Background(), nil, func(row map[string]any) error {
+			if note, ok := row["note"].(string); ok {
+				notes[note] = true
+			}
+			return nil
+		})
+		if err != nil || len(notes) != 1 || !notes["second"] {
+			t.Fatalf("expected the latest version of the rows, got %v (%v)", notes, err)
+		}
+	}
+}
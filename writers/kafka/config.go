@@ -0,0 +1,72 @@
+package kafka
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/datazip-inc/olake/pkg/batching"
+	"github.com/datazip-inc/olake/pkg/network"
+	"github.com/datazip-inc/olake/pkg/schemaregistry"
+	"github.com/datazip-inc/olake/pkg/serde"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/segmentio/kafka-go"
+)
+
+type Config struct {
+	Brokers []string `json:"brokers" validate:"required,gt=0"`
+	// all streams are produced to this topic when set; otherwise each stream gets a topic named
+	// after its destination namespace and table
+	Topic     string `json:"topic,omitempty"`
+	BatchSize int    `json:"batch_size,omitempty"`
+	// batches start at batch_size and are sized by the latency of producing them when set
+	AdaptiveBatch *batching.Config `json:"adaptive_batch,omitempty"`
+	// payload format; json (default), avro or protobuf
+	Format serde.Format `json:"format,omitempty"`
+	// payloads are framed with the id of a schema registered per topic when set
+	SchemaRegistry *schemaregistry.Config `json:"schema_registry,omitempty"`
+	// proxy and tls settings of the broker connections
+	Network *network.Config `json:"network,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	if err := c.Network.Validate(); err != nil {
+		return err
+	}
+	if c.BatchSize < 0 {
+		return fmt.Errorf("batch_size must not be negative")
+	}
+	if c.BatchSize == 0 {
+		c.BatchSize = 1000
+	}
+	if err := c.AdaptiveBatch.Validate(); err != nil {
+		return err
+	}
+	if c.Format == "" {
+		c.Format = serde.JSON
+	}
+	if err := c.Format.Validate(); err != nil {
+		return err
+	}
+	if c.SchemaRegistry != nil {
+		if err := c.SchemaRegistry.Validate(); err != nil {
+			return err
+		}
+	}
+	return utils.Validate(c)
+}
+
+// dialer connects to brokers for checks, transport produces messages; both go through the
+// configured proxy with the configured tls
+func (c *Config) dialer() (*kafka.Dialer, *kafka.Transport, error) {
+	tlsConfig, err := c.Network.TLSConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	dialer := &kafka.Dialer{Timeout: 10 * time.Second, DualStack: true, TLS: tlsConfig}
+	transport := &kafka.Transport{TLS: tlsConfig}
+	if c.Network.Enabled() {
+		dialer.DialFunc = c.Network.DialContext
+		transport.Dial = c.Network.DialContext
+	}
+	return dialer, transport, nil
+}
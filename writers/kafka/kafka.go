@@ -0,0 +1,311 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/datazip-inc/olake/constants"
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/pkg/batching"
+	"github.com/datazip-inc/olake/pkg/schemaregistry"
+	"github.com/datazip-inc/olake/pkg/serde"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/segmentio/kafka-go"
+)
+
+// Kafka destination produces a JSON, Avro or Protobuf message per record keyed by olake id;
+// deletes are produced as tombstones so compacted topics drop the key.
+type Kafka struct {
+	config   *Config
+	stream   protocol.Stream
+	topic    string
+	table    string // destination table, names the Avro record and Protobuf message
+	writer   *kafka.Writer
+	registry *schemaregistry.Client
+	encoder  serde.Encoder
+	schemaID int
+	buffer   []kafka.Message
+	headers  []kafka.Header
+	sizer    *batching.Sizer // nil for a static batch size
+}
+
+// GetConfigRef returns the config reference for the kafka writer.
+func (k *Kafka) GetConfigRef() protocol.Config {
+	k.config = &Config{}
+	return k.config
+}
+
+// Spec returns a new Config instance.
+func (k *Kafka) Spec() any {
+	return Config{}
+}
+
+// Check validates the config and connects to the first reachable broker.
+func (k *Kafka) Check() error {
+	if err := k.config.Validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
+	dialer, _, err := k.config.dialer()
+	if err != nil {
+		return err
+	}
+	for _, broker := range k.config.Brokers {
+		var conn *kafka.Conn
+		if conn, err = dialer.Dial("tcp", broker); err == nil {
+			return conn.Close()
+		}
+	}
+	return fmt.Errorf("failed to connect to kafka brokers: %s", err)
+}
+
+// DeepCheck verifies a broker is reachable, cluster metadata is readable and the schema
+// registry answers when configured.
+func (k *Kafka) DeepCheck(ctx context.Context) []types.CheckResult {
+	if err := k.config.Validate(); err != nil {
+		return []types.CheckResult{{Name: "config", Message: err.Error(), Remediation: "fix the writer config"}}
+	}
+	dialer, _, err := k.config.dialer()
+	if err != nil {
+		return []types.CheckResult{{Name: "config", Message: err.Error(), Remediation: "fix the network section of the writer config"}}
+	}
+	var conn *kafka.Conn
+	for _, broker := range k.config.Brokers {
+		if conn, err = dialer.DialContext(ctx, "tcp", broker); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return []types.CheckResult{{Name: "connection", Message: err.Error(), Remediation: "verify the brokers are reachable from this network"}}
+	}
+	defer conn.Close()
+	results := []types.CheckResult{{Name: "connection", Passed: true, Message: fmt.Sprintf("connected to %s", conn.RemoteAddr())}}
+
+	if _, err := conn.ReadPartitions(); err != nil {
+		results = append(results, types.CheckResult{Name: "topic metadata", Message: err.Error(), Remediation: "allow DESCRIBE on topics for the client principal"})
+	} else {
+		results = append(results, types.CheckResult{Name: "topic metadata", Passed: true, Message: "readable"})
+	}
+
+	if k.config.SchemaRegistry != nil {
+		result := types.CheckResult{Name: "schema registry", Passed: true, Message: "reachable"}
+		registry, err := schemaregistry.New(k.config.SchemaRegistry)
+		if err == nil {
+			// a subject that is never registered; not found proves the registry answers
+			_, err = registry.Latest(ctx, "olake-check-value")
+		}
+		if err != nil && !errors.Is(err, schemaregistry.ErrSubjectNotFound) {
+			result.Passed = false
+			result.Message = err.Error()
+			result.Remediation = "verify the schema registry url and credentials"
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func (k *Kafka) Setup(stream protocol.Stream, options *protocol.Options) error {
+	if err := k.config.Validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
+	k.stream = stream
+	k.table = options.Table
+	k.headers = []kafka.Header{{Key: constants.SyncID, Value: []byte(options.SyncID)}}
+	k.topic = k.config.Topic
+	if k.topic == "" {
+		k.topic = utils.StreamIdentifier(options.Table, options.Namespace)
+	}
+	_, transport, err := k.config.dialer()
+	if err != nil {
+		return err
+	}
+	k.sizer = batching.NewSizer(k.config.AdaptiveBatch, k.config.BatchSize)
+	batchSize := k.config.BatchSize
+	if k.config.AdaptiveBatch != nil {
+		// batches are produced in a single request at any size
+		batchSize = k.config.AdaptiveBatch.MaxSize
+	}
+	k.writer = &kafka.Writer{
+		Addr:                   kafka.TCP(k.config.Brokers...),
+		Transport:              transport,
+		Topic:                  k.topic,
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		AllowAutoTopicCreation: true,
+		BatchSize:              batchSize,
+	}
+
+	if k.config.SchemaRegistry != nil {
+		registry, err := schemaregistry.New(k.config.SchemaRegistry)
+		if err != nil {
+			return err
+		}
+		k.registry = registry
+	}
+	return k.prepareEncoder(context.Background())
+}
+
+// prepareEncoder builds the payload encoder of the current stream schema and registers its
+// schema under the topic subject
+func (k *Kafka) prepareEncoder(ctx context.Context) error {
+	encoder, err := serde.NewEncoder(k.config.Format, k.table, k.stream.Schema())
+	if err != nil {
+		return fmt.Errorf("failed to build %s encoder of stream[%s]: %s", k.config.Format, k.stream.ID(), err)
+	}
+	k.encoder = encoder
+	if k.registry == nil {
+		return nil
+	}
+
+	schemaType, schema := schemaregistry.JSON, encoder.Schema()
+	switch k.config.Format {
+	case serde.Avro:
+		schemaType = schemaregistry.Avro
+	case serde.Protobuf:
+		schemaType = schemaregistry.Protobuf
+	default:
+		if schema, err = schemaregistry.JSONSchema(k.stream.Schema()); err != nil {
+			return fmt.Errorf("failed to build json schema of stream[%s]: %s", k.stream.ID(), err)
+		}
+	}
+	k.schemaID, err = k.registry.Register(ctx, schemaregistry.TopicSubject(k.topic), schemaType, schema)
+	return err
+}
+
+func (k *Kafka) Write(ctx context.Context, record types.RawRecord) error {
+	value, err := k.encoder.Encode(record.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %s", err)
+	}
+	if k.registry != nil {
+		if k.config.Format == serde.Protobuf {
+			value = schemaregistry.FrameProtobuf(k.schemaID, value)
+		} else {
+			value = schemaregistry.Frame(k.schemaID, value)
+		}
+	}
+	headers := k.headers
+	if record.Partial {
+		headers = append(headers[:len(headers):len(headers)], kafka.Header{Key: constants.Partial, Value: []byte("true")})
+	}
+	return k.produce(ctx, kafka.Message{Key: []byte(record.OlakeID), Value: value, Headers: headers})
+}
+
+// Delete produces a tombstone for the record key.
+func (k *Kafka) Delete(ctx context.Context, record types.RawRecord) error {
+	return k.produce(ctx, kafka.Message{Key: []byte(record.OlakeID), Headers: k.headers})
+}
+
+func (k *Kafka) produce(ctx context.Context, message kafka.Message) error {
+	k.buffer = append(k.buffer, message)
+	if len(k.buffer) < k.sizer.Size(k.config.BatchSize) {
+		return nil
+	}
+	return k.flush(ctx)
+}
+
+func (k *Kafka) flush(ctx context.Context) error {
+	if len(k.buffer) == 0 {
+		return nil
+	}
+	start := time.Now()
+	err := k.writer.WriteMessages(ctx, k.buffer...)
+	k.sizer.Observe(time.Since(start), err)
+	if err != nil {
+		return fmt.Errorf("failed to produce to topic[%s]: %s", k.topic, err)
+	}
+	k.buffer = k.buffer[:0]
+	return nil
+}
+
+// Flush produces buffered messages.
+func (k *Kafka) Flush(ctx context.Context) error {
+	return k.flush(ctx)
+}
+
+// Close produces buffered messages and closes the producer.
+func (k *Kafka) Close() error {
+	if k.writer == nil {
+		return nil
+	}
+	if err := k.flush(context.Background()); err != nil {
+		return err
+	}
+	logger.Debugf("Closing producer of topic[%s]", k.topic)
+	return k.writer.Close()
+}
+
+// EvolveSchema rebuilds the encoder and registers the evolved stream schema so new payloads
+// reference it.
+func (k *Kafka) EvolveSchema(change, typeChange bool, _ map[string]*types.Property, _ types.Record) error {
+	if !(change || typeChange) || (k.registry == nil && k.config.Format == serde.JSON) {
+		return nil
+	}
+	// messages encoded with the previous schema must be produced first
+	if err := k.flush(context.Background()); err != nil {
+		return err
+	}
+	return k.prepareEncoder(context.Background())
+}
+
+// Type returns the type of the writer.
+func (k *Kafka) Type() string {
+	return string(types.Kafka)
+}
+
+// Normalization is always on so the stream schema follows the produced payloads.
+func (k *Kafka) Normalization() bool {
+	return true
+}
+
+func (k *Kafka) SupportedTypes() *types.Set[types.DataType] {
+	return types.NewSet(types.DataTypes...)
+}
+
+// PooledRecords is true as records are encoded to messages as they are written.
+func (k *Kafka) PooledRecords() bool {
+	return true
+}
+
+// PartialRecords is supported by JSON payloads, which leave missing columns out; partial
+// messages carry an olake_partial header so consumers merge them into the row.
+func (k *Kafka) PartialRecords() bool {
+	return k.config.Format == serde.JSON
+}
+
+// Flattener keeps nested values; JSON payloads carry them as is, Avro and Protobuf payloads as
+// JSON strings.
+func (k *Kafka) Flattener() protocol.FlattenFunction {
+	return func(record types.Record) (types.Record, error) {
+		copied := make(types.Record, len(record))
+		for key, value := range record {
+			copied[key] = value
+		}
+		return copied, nil
+	}
+}
+
+func (k *Kafka) BeginSync(_ context.Context) error {
+	return nil
+}
+
+func (k *Kafka) BeginStream(_ context.Context, _ protocol.Stream) error {
+	return nil
+}
+
+func (k *Kafka) EndStream(_ context.Context, _ protocol.Stream) error {
+	return nil
+}
+
+func (k *Kafka) EndSync(_ context.Context, _ error) error {
+	return nil
+}
+
+func init() {
+	protocol.RegisteredWriters[types.Kafka] = func() protocol.Writer {
+		return new(Kafka)
+	}
+}
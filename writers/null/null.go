@@ -0,0 +1,105 @@
+package null
+
+import (
+	"context"
+
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+	"github.com/datazip-inc/olake/utils"
+)
+
+type Config struct {
+	// run normalization so that its cost is part of a benchmark
+	Normalization bool `json:"normalization,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	return utils.Validate(c)
+}
+
+// Null destination discards every record.
+type Null struct {
+	config *Config
+}
+
+// GetConfigRef returns the config reference for the null writer.
+func (n *Null) GetConfigRef() protocol.Config {
+	n.config = &Config{}
+	return n.config
+}
+
+// Spec returns a new Config instance.
+func (n *Null) Spec() any {
+	return Config{}
+}
+
+func (n *Null) Setup(_ protocol.Stream, _ *protocol.Options) error {
+	return nil
+}
+
+func (n *Null) Write(_ context.Context, _ types.RawRecord) error {
+	return nil
+}
+
+func (n *Null) Delete(_ context.Context, _ types.RawRecord) error {
+	return nil
+}
+
+// PooledRecords is true as records are discarded.
+func (n *Null) PooledRecords() bool {
+	return true
+}
+
+func (n *Null) Check() error {
+	return n.config.Validate()
+}
+
+func (n *Null) Close() error {
+	return nil
+}
+
+func (n *Null) EvolveSchema(_, _ bool, _ map[string]*types.Property, _ types.Record) error {
+	return nil
+}
+
+// Type returns the type of the writer.
+func (n *Null) Type() string {
+	return string(types.NullWriter)
+}
+
+func (n *Null) Normalization() bool {
+	return n.config.Normalization
+}
+
+func (n *Null) SupportedTypes() *types.Set[types.DataType] {
+	return types.NewSet(types.DataTypes...)
+}
+
+// Flattener returns a flattening function for records.
+func (n *Null) Flattener() protocol.FlattenFunction {
+	flattener := typeutils.NewFlattener()
+	return flattener.Flatten
+}
+
+func init() {
+	protocol.RegisteredWriters[types.NullWriter] = func() protocol.Writer {
+		return new(Null)
+	}
+}
+
+func (n *Null) BeginSync(_ context.Context) error {
+	return nil
+}
+
+func (n *Null) BeginStream(_ context.Context, _ protocol.Stream) error {
+	return nil
+}
+
+func (n *Null) EndStream(_ context.Context, _ protocol.Stream) error {
+	return nil
+}
+
+func (n *Null) EndSync(_ context.Context, _ error) error {
+	return nil
+}
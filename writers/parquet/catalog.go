@@ -0,0 +1,284 @@
+package parquet
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/glue"
+	"github.com/aws/aws-sdk-go/service/glue/glueiface"
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/types"
+	pqgo "github.com/parquet-go/parquet-go"
+)
+
+// GlueCatalog registers tables in the AWS Glue data catalog, which Athena queries
+const GlueCatalog = "glue"
+
+// partitions created per glue request, the most it takes
+const gluePartitionBatch = 100
+
+// hiveFolder matches a folder of a hive style partition, key=value
+var hiveFolder = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)=`)
+
+// CatalogConfig registers the table of every stream uploaded to s3, and the partitions its
+// files are uploaded to, in a metastore, so engines such as Athena and Presto query new data
+// without crawlers or MSCK REPAIR TABLE
+type CatalogConfig struct {
+	// metastore tables are registered in; only glue is supported
+	Type string `json:"type"`
+	// database tables are registered in, created when missing; the namespace of each stream
+	// when empty
+	Database string `json:"database,omitempty"`
+	// region of the catalog; s3_region when empty
+	Region string `json:"region,omitempty"`
+	// account of the catalog; the account of the credentials when empty
+	CatalogID string `json:"catalog_id,omitempty"`
+}
+
+func (c *CatalogConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.Type != GlueCatalog {
+		return fmt.Errorf("unsupported catalog type[%s]; only glue is supported", c.Type)
+	}
+	return nil
+}
+
+// partitionKeys returns the keys of the hive style partitions the stream is written to; the
+// folders of partition_regex must each be a key=value pair for them to be registered
+func partitionKeys(metadata types.StreamMetadata) ([]string, error) {
+	if metadata.Partition != nil {
+		return []string{metadata.Partition.Name}, nil
+	}
+	var keys []string
+	for _, folder := range strings.Split(strings.Trim(metadata.PartitionRegex, "/"), "/") {
+		if folder == "" {
+			continue
+		}
+		match := hiveFolder.FindStringSubmatch(folder)
+		if match == nil {
+			return nil, fmt.Errorf("folder[%s] of partition_regex isn't hive style, e.g. day={created_at,,DD}; it can't be registered in the catalog", folder)
+		}
+		keys = append(keys, match[1])
+	}
+	return keys, nil
+}
+
+// catalogTable registers the table of a stream, and the partitions files were uploaded to, in
+// the glue catalog
+type catalogTable struct {
+	client    glueiface.GlueAPI
+	catalogID *string
+	database  string
+	table     string
+	location  string   // s3 uri of the folder of the table
+	keys      []string // partition keys
+	columns   []*glue.Column
+}
+
+// hiveColumns returns the columns of files of schema as hive types, leaving out partition keys
+func hiveColumns(schema *pqgo.Schema, keys []string) []*glue.Column {
+	var columns []*glue.Column
+	for _, field := range schema.Fields() {
+		if slices.Contains(keys, field.Name()) {
+			continue
+		}
+		columns = append(columns, &glue.Column{Name: aws.String(strings.ToLower(field.Name())), Type: aws.String(hiveType(field))})
+	}
+	return columns
+}
+
+// hiveType is the hive type readers see the values of field as; timestamps are kept in int64
+// columns without a logical type and are read as bigint
+func hiveType(field pqgo.Field) string {
+	switch field.Type().Kind() {
+	case pqgo.Boolean:
+		return "boolean"
+	case pqgo.Int32:
+		return "int"
+	case pqgo.Int64:
+		return "bigint"
+	case pqgo.Float:
+		return "float"
+	case pqgo.Double:
+		return "double"
+	case pqgo.ByteArray:
+		if logical := field.Type().LogicalType(); logical != nil && (logical.UTF8 != nil || logical.Json != nil) {
+			return "string"
+		}
+		return "binary"
+	default:
+		return "string"
+	}
+}
+
+// register creates the database and table when missing, adds the columns files gained to the
+// table and adds the partitions of folders, relative to the folder of the table
+func (c *catalogTable) register(folders []string) error {
+	if err := c.ensureDatabase(); err != nil {
+		return fmt.Errorf("failed to create database[%s]: %s", c.database, err)
+	}
+	if err := c.ensureTable(); err != nil {
+		return fmt.Errorf("failed to register table[%s.%s]: %s", c.database, c.table, err)
+	}
+	if len(c.keys) == 0 {
+		return nil
+	}
+
+	var partitions []*glue.PartitionInput
+	for _, folder := range folders {
+		values, err := c.partitionValues(folder)
+		if err != nil {
+			logger.Warnf("Skipping registration of folder[%s] of table[%s.%s]: %s", folder, c.database, c.table, err)
+			continue
+		}
+		descriptor := c.storageDescriptor(c.columns)
+		descriptor.Location = aws.String(c.location + filepath.ToSlash(folder) + "/")
+		partitions = append(partitions, &glue.PartitionInput{Values: aws.StringSlice(values), StorageDescriptor: descriptor})
+	}
+	for start := 0; start < len(partitions); start += gluePartitionBatch {
+		end := min(start+gluePartitionBatch, len(partitions))
+		output, err := c.client.BatchCreatePartition(&glue.BatchCreatePartitionInput{
+			CatalogId:          c.catalogID,
+			DatabaseName:       aws.String(c.database),
+			TableName:          aws.String(c.table),
+			PartitionInputList: partitions[start:end],
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add partitions of table[%s.%s]: %s", c.database, c.table, err)
+		}
+		for _, failed := range output.Errors {
+			// partitions written to by earlier syncs
+			if failed.ErrorDetail == nil || aws.StringValue(failed.ErrorDetail.ErrorCode) == glue.ErrCodeAlreadyExistsException {
+				continue
+			}
+			return fmt.Errorf("failed to add partition%v of table[%s.%s]: %s", aws.StringValueSlice(failed.PartitionValues), c.database, c.table, aws.StringValue(failed.ErrorDetail.ErrorMessage))
+		}
+	}
+	logger.Infof("Registered %d partitions of table[%s.%s] in glue", len(partitions), c.database, c.table)
+	return nil
+}
+
+// partitionValues reads the values of the partition keys from the folders of a partition
+func (c *catalogTable) partitionValues(folder string) ([]string, error) {
+	parts := strings.Split(filepath.ToSlash(folder), "/")
+	if len(parts) != len(c.keys) {
+		return nil, fmt.Errorf("expected folders of partition keys %v", c.keys)
+	}
+	values := make([]string, 0, len(parts))
+	for idx, part := range parts {
+		key, value, found := strings.Cut(part, "=")
+		if !found || key != c.keys[idx] {
+			return nil, fmt.Errorf("expected a folder of partition key[%s], got %s", c.keys[idx], part)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func (c *catalogTable) ensureDatabase() error {
+	_, err := c.client.GetDatabase(&glue.GetDatabaseInput{CatalogId: c.catalogID, Name: aws.String(c.database)})
+	if !isGlueError(err, glue.ErrCodeEntityNotFoundException) {
+		return err
+	}
+	_, err = c.client.CreateDatabase(&glue.CreateDatabaseInput{CatalogId: c.catalogID, DatabaseInput: &glue.DatabaseInput{Name: aws.String(c.database)}})
+	if isGlueError(err, glue.ErrCodeAlreadyExistsException) {
+		return nil
+	}
+	return err
+}
+
+// ensureTable creates the table, or adds the columns it's missing and updates the types of
+// columns that changed; columns are never dropped as earlier files hold them
+func (c *catalogTable) ensureTable() error {
+	get := func() (*glue.GetTableOutput, error) {
+		return c.client.GetTable(&glue.GetTableInput{CatalogId: c.catalogID, DatabaseName: aws.String(c.database), Name: aws.String(c.table)})
+	}
+	current, err := get()
+	if isGlueError(err, glue.ErrCodeEntityNotFoundException) {
+		_, err = c.client.CreateTable(&glue.CreateTableInput{CatalogId: c.catalogID, DatabaseName: aws.String(c.database), TableInput: c.tableInput(c.columns)})
+		if !isGlueError(err, glue.ErrCodeAlreadyExistsException) {
+			return err
+		}
+		// created by another writer of the stream meanwhile
+		current, err = get()
+	}
+	if err != nil {
+		return err
+	}
+
+	var registeredKeys []string
+	for _, key := range current.Table.PartitionKeys {
+		registeredKeys = append(registeredKeys, aws.StringValue(key.Name))
+	}
+	if strings.Join(registeredKeys, ",") != strings.Join(c.keys, ",") {
+		return fmt.Errorf("table is partitioned by %v, not by %v; drop it to register it again", registeredKeys, c.keys)
+	}
+
+	var columns []*glue.Column
+	if current.Table.StorageDescriptor != nil {
+		columns = current.Table.StorageDescriptor.Columns
+	}
+	changed := false
+	for _, column := range c.columns {
+		idx := -1
+		for i, registered := range columns {
+			if aws.StringValue(registered.Name) == aws.StringValue(column.Name) {
+				idx = i
+				break
+			}
+		}
+		switch {
+		case idx < 0:
+			columns = append(columns, column)
+			changed = true
+		case aws.StringValue(columns[idx].Type) != aws.StringValue(column.Type):
+			columns[idx] = column
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	_, err = c.client.UpdateTable(&glue.UpdateTableInput{CatalogId: c.catalogID, DatabaseName: aws.String(c.database), TableInput: c.tableInput(columns)})
+	if err == nil {
+		logger.Infof("Updated columns of table[%s.%s] in glue", c.database, c.table)
+	}
+	return err
+}
+
+func (c *catalogTable) tableInput(columns []*glue.Column) *glue.TableInput {
+	keys := make([]*glue.Column, 0, len(c.keys))
+	for _, key := range c.keys {
+		keys = append(keys, &glue.Column{Name: aws.String(key), Type: aws.String("string")})
+	}
+	return &glue.TableInput{
+		Name:              aws.String(c.table),
+		TableType:         aws.String("EXTERNAL_TABLE"),
+		Parameters:        map[string]*string{"classification": aws.String("parquet"), "EXTERNAL": aws.String("TRUE")},
+		PartitionKeys:     keys,
+		StorageDescriptor: c.storageDescriptor(columns),
+	}
+}
+
+func (c *catalogTable) storageDescriptor(columns []*glue.Column) *glue.StorageDescriptor {
+	return &glue.StorageDescriptor{
+		Columns:      columns,
+		Location:     aws.String(c.location),
+		InputFormat:  aws.String("org.apache.hadoop.hive.ql.io.parquet.MapredParquetInputFormat"),
+		OutputFormat: aws.String("org.apache.hadoop.hive.ql.io.parquet.MapredParquetOutputFormat"),
+		SerdeInfo:    &glue.SerDeInfo{SerializationLibrary: aws.String("org.apache.hadoop.hive.ql.io.parquet.serde.ParquetHiveSerDe")},
+	}
+}
+
+func isGlueError(err error, code string) bool {
+	var awsErr awserr.Error
+	return errors.As(err, &awsErr) && awsErr.Code() == code
+}
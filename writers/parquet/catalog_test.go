@@ -0,0 +1,123 @@
+package parquet
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/glue"
+	"github.com/aws/aws-sdk-go/service/glue/glueiface"
+	"github.com/datazip-inc/olake/types"
+)
+
+// fakeGlue keeps one database and its tables and partitions in memory
+type fakeGlue struct {
+	glueiface.GlueAPI
+	database   bool
+	tables     map[string]*glue.TableInput
+	partitions map[string]bool
+	updates    int
+}
+
+func (f *fakeGlue) GetDatabase(_ *glue.GetDatabaseInput) (*glue.GetDatabaseOutput, error) {
+	if !f.database {
+		return nil, awserr.New(glue.ErrCodeEntityNotFoundException, "not found", nil)
+	}
+	return &glue.GetDatabaseOutput{}, nil
+}
+
+func (f *fakeGlue) CreateDatabase(_ *glue.CreateDatabaseInput) (*glue.CreateDatabaseOutput, error) {
+	f.database = true
+	return &glue.CreateDatabaseOutput{}, nil
+}
+
+func (f *fakeGlue) GetTable(input *glue.GetTableInput) (*glue.GetTableOutput, error) {
+	table, found := f.tables[*input.Name]
+	if !found {
+		return nil, awserr.New(glue.ErrCodeEntityNotFoundException, "not found", nil)
+	}
+	return &glue.GetTableOutput{Table: &glue.TableData{Name: table.Name, PartitionKeys: table.PartitionKeys, StorageDescriptor: table.StorageDescriptor}}, nil
+}
+
+func (f *fakeGlue) CreateTable(input *glue.CreateTableInput) (*glue.CreateTableOutput, error) {
+	f.tables[*input.TableInput.Name] = input.TableInput
+	return &glue.CreateTableOutput{}, nil
+}
+
+func (f *fakeGlue) UpdateTable(input *glue.UpdateTableInput) (*glue.UpdateTableOutput, error) {
+	f.tables[*input.TableInput.Name] = input.TableInput
+	f.updates++
+	return &glue.UpdateTableOutput{}, nil
+}
+
+func (f *fakeGlue) BatchCreatePartition(input *glue.BatchCreatePartitionInput) (*glue.BatchCreatePartitionOutput, error) {
+	output := &glue.BatchCreatePartitionOutput{}
+	for _, partition := range input.PartitionInputList {
+		location := *partition.StorageDescriptor.Location
+		if f.partitions[location] {
+			output.Errors = append(output.Errors, &glue.PartitionError{PartitionValues: partition.Values, ErrorDetail: &glue.ErrorDetail{ErrorCode: aws.String(glue.ErrCodeAlreadyExistsException)}})
+		}
+		f.partitions[location] = true
+	}
+	return output, nil
+}
+
+func TestPartitionKeys(t *testing.T) {
+	keys, err := partitionKeys(types.StreamMetadata{PartitionRegex: "/year={created_at,,YY}/month={created_at,,MM}"})
+	if err != nil || len(keys) != 2 || keys[0] != "year" || keys[1] != "month" {
+		t.Fatalf("expected the keys of hive style folders, got %v: %v", keys, err)
+	}
+	if _, err := partitionKeys(types.StreamMetadata{PartitionRegex: "/{created_at,,DD}"}); err == nil {
+		t.Fatal("expected folders without keys to be refused")
+	}
+	partition := &types.TemporalPartition{Column: "created_at"}
+	if err := partition.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ := partitionKeys(types.StreamMetadata{Partition: partition}); len(keys) != 1 || keys[0] != "created_at_day" {
+		t.Fatalf("expected the name of the temporal partition, got %v", keys)
+	}
+}
+
+func TestCatalogRegister(t *testing.T) {
+	client := &fakeGlue{tables: map[string]*glue.TableInput{}, partitions: map[string]bool{}}
+	schema := columnsSchema()
+	keys := []string{"created_at_day"}
+	table := &catalogTable{client: client, database: "shop", table: "orders", location: "s3://bucket/shop/orders/", keys: keys, columns: hiveColumns(schema.ToParquet(), keys)}
+
+	if err := table.register([]string{"created_at_day=2024-03-01", "created_at_day=2024-03-02", "not_a_partition"}); err != nil {
+		t.Fatal(err)
+	}
+	registered := client.tables["orders"]
+	if !client.database || registered == nil || len(registered.PartitionKeys) != 1 {
+		t.Fatalf("expected the database and the partitioned table to be created, got %+v", registered)
+	}
+	columns := map[string]string{}
+	for _, column := range registered.StorageDescriptor.Columns {
+		columns[*column.Name] = *column.Type
+	}
+	for column, expected := range map[string]string{"id": "bigint", "score": "double", "email": "string", "active": "boolean", "avatar": "binary", "profile": "string", "updated_at": "bigint"} {
+		if columns[column] != expected {
+			t.Errorf("expected column[%s] of type %s, got %s", column, expected, columns[column])
+		}
+	}
+	if len(client.partitions) != 2 || !client.partitions["s3://bucket/shop/orders/created_at_day=2024-03-01/"] {
+		t.Fatalf("expected the 2 partitions, got %v", client.partitions)
+	}
+
+	// partitions of earlier syncs are left as they are; new columns are added
+	schema.AddTypes("note", types.String, types.Null)
+	table.columns = hiveColumns(schema.ToParquet(), keys)
+	if err := table.register([]string{"created_at_day=2024-03-02", "created_at_day=2024-03-03"}); err != nil {
+		t.Fatal(err)
+	}
+	if client.updates != 1 || len(client.tables["orders"].StorageDescriptor.Columns) != len(columns)+1 || len(client.partitions) != 3 {
+		t.Fatalf("expected the new column and partition to be added, got %d updates and partitions %v", client.updates, client.partitions)
+	}
+
+	// partition keys can't change
+	table.keys = []string{"created_at_month"}
+	if err := table.register(nil); err == nil {
+		t.Fatal("expected a change of partition keys to fail")
+	}
+}
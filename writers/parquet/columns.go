@@ -0,0 +1,197 @@
+package parquet
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+	"unsafe"
+
+	pqgo "github.com/parquet-go/parquet-go"
+)
+
+// columnBatchRows is the number of normalized records converted at once
+const columnBatchRows = 1024
+
+// columnConverter converts the values of a column, nil for null, to parquet values in place
+// of the column in rows
+type columnConverter func(column int, values []any, rows []pqgo.Row) error
+
+// columnBatch keeps the values of normalized records column by column until a batch is full;
+// each column is then converted with the converter of its parquet kind, picked once per
+// column, instead of reflecting on every cell as writing rows of maps does. Only values are
+// kept, not the records.
+type columnBatch struct {
+	names     []string
+	convert   []columnConverter
+	interners []*interner // of each column, nil for columns not interned
+	values    [][]any     // values of each column, one per record
+	rows      []pqgo.Row  // reused between flushes
+}
+
+// newColumnBatch returns a batch of the columns of schema; strings of the columns in
+// interners are interned
+func newColumnBatch(schema *pqgo.Schema, interners map[string]*interner) (*columnBatch, error) {
+	batch := &columnBatch{}
+	for _, path := range schema.Columns() {
+		leaf, found := schema.Lookup(path...)
+		if !found {
+			return nil, fmt.Errorf("column[%v] missing from parquet schema", path)
+		}
+		convert, err := converterOf(leaf.Node.Type().Kind())
+		if err != nil {
+			return nil, fmt.Errorf("column[%s]: %s", path[0], err)
+		}
+		batch.names = append(batch.names, path[0])
+		batch.convert = append(batch.convert, convert)
+		batch.interners = append(batch.interners, interners[path[0]])
+		batch.values = append(batch.values, make([]any, 0, columnBatchRows))
+	}
+	return batch, nil
+}
+
+// add keeps the values of record; reports if the batch is full
+func (b *columnBatch) add(record map[string]any) bool {
+	for column, name := range b.names {
+		value := record[name]
+		if text, ok := value.(string); ok && b.interners[column] != nil {
+			value = b.interners[column].intern(text)
+		}
+		b.values[column] = append(b.values[column], value)
+	}
+	return b.len() >= columnBatchRows
+}
+
+func (b *columnBatch) len() int {
+	if len(b.values) == 0 {
+		return 0
+	}
+	return len(b.values[0])
+}
+
+// flush converts the batch a column at a time and writes its rows
+func (b *columnBatch) flush(writer *pqgo.GenericWriter[any]) error {
+	count := b.len()
+	if count == 0 {
+		return nil
+	}
+	defer b.reset()
+	for len(b.rows) < count {
+		b.rows = append(b.rows, make(pqgo.Row, len(b.names)))
+	}
+	rows := b.rows[:count]
+	for column, convert := range b.convert {
+		if err := convert(column, b.values[column], rows); err != nil {
+			return fmt.Errorf("failed to convert column[%s], %d records are not written: %s", b.names[column], count, err)
+		}
+	}
+	_, err := writer.WriteRows(rows)
+	return err
+}
+
+func (b *columnBatch) reset() {
+	for column := range b.values {
+		clear(b.values[column])
+		b.values[column] = b.values[column][:0]
+	}
+}
+
+func converterOf(kind pqgo.Kind) (columnConverter, error) {
+	switch kind {
+	case pqgo.Boolean:
+		return convertColumn(kind, func(value any) (pqgo.Value, bool) {
+			typed, ok := value.(bool)
+			return pqgo.BooleanValue(typed), ok
+		}), nil
+	case pqgo.Int64:
+		return convertColumn(kind, func(value any) (pqgo.Value, bool) {
+			switch typed := value.(type) {
+			case int64:
+				return pqgo.Int64Value(typed), true
+			case time.Time:
+				// columns without a timestamp logical type hold nanoseconds
+				return pqgo.Int64Value(typed.UnixNano()), true
+			}
+			return pqgo.Value{}, false
+		}), nil
+	case pqgo.Double:
+		return convertColumn(kind, func(value any) (pqgo.Value, bool) {
+			typed, ok := value.(float64)
+			return pqgo.DoubleValue(typed), ok
+		}), nil
+	case pqgo.ByteArray:
+		return convertColumn(kind, func(value any) (pqgo.Value, bool) {
+			switch typed := value.(type) {
+			case string:
+				// parquet copies the bytes, the string isn't changed
+				return pqgo.ByteArrayValue(unsafe.Slice(unsafe.StringData(typed), len(typed))), true
+			case []byte:
+				return pqgo.ByteArrayValue(typed), true
+			}
+			return pqgo.Value{}, false
+		}), nil
+	default:
+		return nil, fmt.Errorf("parquet kind %s is not supported", kind)
+	}
+}
+
+// convertColumn converts values with fast, the conversion of the type normalized records hold
+// for kind, falling back to reflection for other types
+func convertColumn(kind pqgo.Kind, fast func(value any) (pqgo.Value, bool)) columnConverter {
+	return func(column int, values []any, rows []pqgo.Row) error {
+		for idx, value := range values {
+			if value == nil {
+				rows[idx][column] = pqgo.Value{}.Level(0, 0, column)
+				continue
+			}
+			converted, ok := fast(value)
+			if !ok {
+				var err error
+				if converted, err = reflectValue(kind, value); err != nil {
+					return err
+				}
+				if converted.IsNull() {
+					rows[idx][column] = converted.Level(0, 0, column)
+					continue
+				}
+			}
+			rows[idx][column] = converted.Level(0, 1, column)
+		}
+		return nil
+	}
+}
+
+// reflectValue converts value as parquet does when writing rows of maps
+func reflectValue(kind pqgo.Kind, value any) (pqgo.Value, error) {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return pqgo.Value{}, nil
+		}
+		v = v.Elem()
+	}
+	switch kind {
+	case pqgo.Boolean:
+		if v.Kind() == reflect.Bool {
+			return pqgo.BooleanValue(v.Bool()), nil
+		}
+	case pqgo.Int64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return pqgo.Int64Value(v.Int()), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return pqgo.Int64Value(int64(v.Uint())), nil
+		}
+	case pqgo.Double:
+		if v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64 {
+			return pqgo.DoubleValue(v.Float()), nil
+		}
+	case pqgo.ByteArray:
+		switch {
+		case v.Kind() == reflect.String:
+			return pqgo.ByteArrayValue([]byte(v.String())), nil
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			return pqgo.ByteArrayValue(v.Bytes()), nil
+		}
+	}
+	return pqgo.Value{}, fmt.Errorf("can't write value of type %T to a column of kind %s", value, kind)
+}
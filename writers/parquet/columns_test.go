@@ -0,0 +1,135 @@
+package parquet
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/types"
+	pqgo "github.com/parquet-go/parquet-go"
+)
+
+func columnsSchema() *types.TypeSchema {
+	schema := types.NewTypeSchema()
+	for name, typ := range map[string]types.DataType{
+		"id":         types.Int64,
+		"score":      types.Float64,
+		"email":      types.String,
+		"active":     types.Bool,
+		"updated_at": types.TimestampMicro,
+		"avatar":     types.Bytes,
+		"tags":       types.Array,
+		"profile":    types.JSON,
+	} {
+		schema.AddTypes(name, typ, types.Null)
+	}
+	return schema
+}
+
+func columnsRecords(count int) []map[string]any {
+	records := make([]map[string]any, count)
+	for i := range records {
+		record := map[string]any{
+			"id":         int64(i),
+			"score":      float64(i) / 3,
+			"email":      fmt.Sprintf("user%d@example.com", i),
+			"active":     i%2 == 0,
+			"updated_at": time.Date(2024, 1, 2, 3, 4, 5, i*1000, time.UTC),
+			"avatar":     []byte{byte(i), 1, 2},
+			"tags":       `["a","b"]`,
+			"profile":    types.RawJSON(`{"plan":"pro"}`),
+		}
+		// nulls and missing columns
+		if i%3 == 0 {
+			record["email"] = nil
+			delete(record, "avatar")
+		}
+		records[i] = record
+	}
+	return records
+}
+
+func TestColumnBatch(t *testing.T) {
+	schema := columnsSchema().ToParquet()
+	records := columnsRecords(2*columnBatchRows + 7)
+
+	var rowWise bytes.Buffer
+	writer := pqgo.NewGenericWriter[any](&rowWise, schema)
+	for _, record := range records {
+		if _, err := writer.Write([]any{record}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var columnWise bytes.Buffer
+	writer = pqgo.NewGenericWriter[any](&columnWise, schema)
+	batch, err := newColumnBatch(schema, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range records {
+		if batch.add(record) {
+			if err := batch.flush(writer); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := batch.flush(writer); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(rowWise.Bytes(), columnWise.Bytes()) {
+		t.Fatal("expected batched columns to write the same file as rows of maps")
+	}
+
+	// other types convert as parquet converts them, or fail the batch
+	writer = pqgo.NewGenericWriter[any](&bytes.Buffer{}, schema)
+	batch.add(map[string]any{"id": int32(7), "score": float32(1.5), "email": []byte("raw")})
+	if err := batch.flush(writer); err != nil {
+		t.Fatal(err)
+	}
+	batch.add(map[string]any{"id": "seven"})
+	if err := batch.flush(writer); err == nil || batch.len() != 0 {
+		t.Fatalf("expected a string in an integer column to fail and reset the batch, got %v", err)
+	}
+}
+
+func BenchmarkWriteRows(b *testing.B) {
+	schema := columnsSchema().ToParquet()
+	records := columnsRecords(columnBatchRows)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		writer := pqgo.NewGenericWriter[any](&bytes.Buffer{}, schema)
+		for _, record := range records {
+			if _, err := writer.Write([]any{record}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkColumnBatch(b *testing.B) {
+	schema := columnsSchema().ToParquet()
+	records := columnsRecords(columnBatchRows)
+	batch, err := newColumnBatch(schema, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		writer := pqgo.NewGenericWriter[any](&bytes.Buffer{}, schema)
+		for _, record := range records {
+			batch.add(record)
+		}
+		if err := batch.flush(writer); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package parquet
 
 import (
+	"fmt"
+
+	"github.com/datazip-inc/olake/pkg/compress"
+	"github.com/datazip-inc/olake/pkg/network"
 	"github.com/datazip-inc/olake/utils"
 )
 
@@ -12,8 +16,40 @@ type Config struct {
 	AccessKey     string `json:"s3_access_key,omitempty"`
 	SecretKey     string `json:"s3_secret_key,omitempty"`
 	Prefix        string `json:"s3_path,omitempty"`
+	// column chunk compression: snappy (default), gzip, zstd, lz4 or none
+	Compression compress.Codec `json:"compression,omitempty"`
+	// proxy and tls settings of the connection to s3
+	Network *network.Config `json:"network,omitempty"`
+	// string columns of normalized records with few distinct values, e.g. status or country;
+	// their values are kept once in memory and dictionary encoded in files
+	InternColumns []string `json:"intern_columns,omitempty"`
+	// distinct values of a column kept at most; later ones are kept as they are. 10000 by default
+	InternLimit int `json:"intern_limit,omitempty"`
+	// registers tables and partitions uploaded to s3 in a metastore, e.g. glue
+	Catalog *CatalogConfig `json:"catalog,omitempty"`
 }
 
 func (c *Config) Validate() error {
+	if c.Compression == "" {
+		c.Compression = compress.Snappy
+	}
+	if err := c.Compression.Validate(); err != nil {
+		return err
+	}
+	if err := c.Network.Validate(); err != nil {
+		return err
+	}
+	if c.InternLimit < 0 {
+		return fmt.Errorf("intern_limit must not be negative")
+	}
+	if c.InternLimit == 0 {
+		c.InternLimit = 10000
+	}
+	if err := c.Catalog.Validate(); err != nil {
+		return err
+	}
+	if c.Catalog != nil && c.Bucket == "" {
+		return fmt.Errorf("catalog needs s3_bucket; only files uploaded to s3 are registered")
+	}
 	return utils.Validate(c)
 }
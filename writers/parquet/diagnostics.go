@@ -0,0 +1,79 @@
+package parquet
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/utils"
+)
+
+// DeepCheck verifies the staging path and, with a bucket configured, every S3 permission a
+// sync uses: listing, writing, deleting and multipart uploads.
+func (p *Parquet) DeepCheck(ctx context.Context) []types.CheckResult {
+	if err := p.config.Validate(); err != nil {
+		return []types.CheckResult{{Name: "config", Message: err.Error(), Remediation: "fix the writer config"}}
+	}
+	localPath := p.config.Path
+	if localPath == "" {
+		localPath = os.TempDir()
+	}
+	results := []types.CheckResult{protocol.WritablePathCheck(localPath)}
+	if p.config.Bucket == "" {
+		return results
+	}
+
+	if err := p.initS3Writer(); err != nil || p.s3Client == nil {
+		message := "s3_region is required with s3_bucket"
+		if err != nil {
+			message = err.Error()
+		}
+		return append(results, types.CheckResult{Name: "s3 session", Message: message, Remediation: "set s3_region and valid credentials"})
+	}
+
+	bucket := aws.String(p.config.Bucket)
+	check := func(name, remediation string, call func() error) bool {
+		result := types.CheckResult{Name: name, Passed: true, Message: "allowed"}
+		if err := call(); err != nil {
+			result.Passed = false
+			result.Message = err.Error()
+			result.Remediation = remediation
+		}
+		results = append(results, result)
+		return result.Passed
+	}
+
+	if !check("bucket access", fmt.Sprintf("verify bucket %s exists in %s and allow s3:ListBucket", p.config.Bucket, p.config.Region), func() error {
+		_, err := p.s3Client.HeadBucketWithContext(ctx, &s3.HeadBucketInput{Bucket: bucket})
+		return err
+	}) {
+		return results
+	}
+
+	key := aws.String(strings.TrimPrefix(path.Join(p.config.Prefix, "olake_writer_test", utils.TimestampedFileName("txt")), "/"))
+	if check("object write", "allow s3:PutObject on the bucket prefix", func() error {
+		_, err := p.s3Client.PutObjectWithContext(ctx, &s3.PutObjectInput{Bucket: bucket, Key: key, Body: strings.NewReader("S3 write test")})
+		return err
+	}) {
+		check("object delete", "allow s3:DeleteObject on the bucket prefix", func() error {
+			_, err := p.s3Client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{Bucket: bucket, Key: key})
+			return err
+		})
+	}
+
+	check("multipart upload", "allow s3:PutObject and s3:AbortMultipartUpload on the bucket prefix", func() error {
+		upload, err := p.s3Client.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{Bucket: bucket, Key: key})
+		if err != nil {
+			return err
+		}
+		_, err = p.s3Client.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{Bucket: bucket, Key: key, UploadId: upload.UploadId})
+		return err
+	})
+	return results
+}
@@ -0,0 +1,39 @@
+package parquet
+
+import (
+	"strings"
+
+	"github.com/datazip-inc/olake/logger"
+)
+
+// interner keeps one copy of each distinct value of a string column with few distinct values,
+// so batches and the records they are read from don't each hold their own; not safe for
+// concurrent use
+type interner struct {
+	column string
+	values map[string]string
+	limit  int
+	warned bool
+}
+
+func newInterner(column string, limit int) *interner {
+	return &interner{column: column, values: make(map[string]string), limit: limit}
+}
+
+// intern returns the kept copy of value; past the limit, new values are returned as they are
+func (i *interner) intern(value string) string {
+	if kept, found := i.values[value]; found {
+		return kept
+	}
+	if len(i.values) >= i.limit {
+		if !i.warned {
+			logger.Warnf("Column[%s] has more than %d distinct values; only those are interned", i.column, i.limit)
+			i.warned = true
+		}
+		return value
+	}
+	// a copy of its own so the buffer value was read into isn't kept alive
+	value = strings.Clone(value)
+	i.values[value] = value
+	return value
+}
@@ -0,0 +1,68 @@
+package parquet
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"testing"
+	"unsafe"
+
+	pqgo "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+func TestInterner(t *testing.T) {
+	interner := newInterner("status", 2)
+	buffer := []byte("active")
+	first := interner.intern(string(buffer))
+	second := interner.intern("active")
+	if unsafe.StringData(first) != unsafe.StringData(second) {
+		t.Fatal("expected equal values to share one copy")
+	}
+	interner.intern("inactive")
+	if interner.intern("deleted"); len(interner.values) != 2 {
+		t.Fatalf("expected at most 2 values to be kept, got %d", len(interner.values))
+	}
+}
+
+func TestDictionarySchema(t *testing.T) {
+	interners := map[string]*interner{"email": newInterner("email", 10), "id": newInterner("id", 10)}
+	schema := dictionarySchema(columnsSchema().ToParquet(), interners)
+	records := columnsRecords(100)
+	for i, record := range records {
+		if record["email"] != nil {
+			record["email"] = fmt.Sprintf("user%d@example.com", i%4)
+		}
+	}
+
+	var file bytes.Buffer
+	writer := pqgo.NewGenericWriter[any](&file, schema)
+	batch, err := newColumnBatch(schema, interners)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range records {
+		batch.add(record)
+	}
+	if err := batch.flush(writer); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := pqgo.OpenFile(bytes.NewReader(file.Bytes()), int64(file.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, column := range reader.Metadata().RowGroups[0].Columns {
+		dictionary := slices.Contains(column.MetaData.Encoding, format.RLEDictionary)
+		// only string columns are dictionary encoded
+		if expected := column.MetaData.PathInSchema[0] == "email"; dictionary != expected {
+			t.Fatalf("column[%s]: expected dictionary encoding %t, got encodings %v", column.MetaData.PathInSchema[0], expected, column.MetaData.Encoding)
+		}
+	}
+	if len(interners["email"].values) != 4 {
+		t.Fatalf("expected 4 interned emails, got %d", len(interners["email"].values))
+	}
+}
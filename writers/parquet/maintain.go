@@ -0,0 +1,232 @@
+package parquet
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/datazip-inc/olake/constants"
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/utils"
+	pqgo "github.com/parquet-go/parquet-go"
+)
+
+const (
+	// lists, in the metadata of a compacted file, the files it was compacted from; they are
+	// removed once it's in place, or by the next maintenance if that was interrupted
+	compactedFromKey = "olake.compacted_from"
+	// suffix of compacted files until they're complete
+	compactingExt = ".compacting"
+)
+
+// maintainedFile is a complete parquet file of a folder being maintained
+type maintainedFile struct {
+	name    string
+	size    int64
+	file    *os.File
+	parquet *pqgo.File
+}
+
+// Maintain compacts the small files of every folder of the stream, its partitions included,
+// and removes files left incomplete by failed syncs and compactions. Only files kept under
+// local_path are maintained; files uploaded to s3 aren't kept locally.
+func (p *Parquet) Maintain(ctx context.Context, _ protocol.Stream, options protocol.MaintainOptions, result *protocol.StreamMaintenance) error {
+	if p.s3Client != nil {
+		return fmt.Errorf("files uploaded to s3 are not maintained: %w", protocol.ErrMaintainUnsupported)
+	}
+	root := filepath.Join(p.config.Path, p.basePath)
+	// files this writer opened on setup are incomplete until it's closed
+	open := make(map[string]bool)
+	for basePath, files := range p.partitionedFiles {
+		for _, file := range files {
+			open[filepath.Join(p.config.Path, basePath, file.fileName)] = true
+		}
+	}
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return p.maintainFolder(path, open, options, result)
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// maintainFolder maintains the files of directory, leaving its subfolders out
+func (p *Parquet) maintainFolder(directory string, open map[string]bool, options protocol.MaintainOptions, result *protocol.StreamMaintenance) error {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return fmt.Errorf("failed to list files[%s]: %s", directory, err)
+	}
+	expiry := time.Now().Add(-options.Retention)
+	remove := func(name, reason string) error {
+		result.RemovedFiles++
+		if options.DryRun {
+			logger.Infof("Would remove file[%s] (%s)", filepath.Join(directory, name), reason)
+			return nil
+		}
+		if err := os.Remove(filepath.Join(directory, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove file[%s]: %s", name, err)
+		}
+		logger.Infof("Removed file[%s] (%s)", filepath.Join(directory, name), reason)
+		return nil
+	}
+
+	var files []*maintainedFile
+	defer func() {
+		for _, file := range files {
+			file.file.Close()
+		}
+	}()
+	compacted := make(map[string]bool)
+	for _, entry := range entries {
+		path := filepath.Join(directory, entry.Name())
+		if entry.IsDir() || open[path] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case strings.HasSuffix(entry.Name(), compactingExt):
+			if info.ModTime().Before(expiry) {
+				if err := remove(entry.Name(), "interrupted compaction"); err != nil {
+					return err
+				}
+			}
+			continue
+		case !strings.HasSuffix(entry.Name(), "."+constants.ParquetFileExt):
+			continue
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		parquetFile, err := pqgo.OpenFile(file, info.Size())
+		if err != nil {
+			file.Close()
+			// files of failed syncs end without a footer; recent ones may still be written
+			if info.ModTime().Before(expiry) {
+				if err := remove(entry.Name(), fmt.Sprintf("incomplete: %s", err)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		files = append(files, &maintainedFile{name: entry.Name(), size: info.Size(), file: file, parquet: parquetFile})
+		if inputs, found := parquetFile.Lookup(compactedFromKey); found {
+			for _, input := range strings.Split(inputs, ",") {
+				compacted[input] = true
+			}
+		}
+	}
+
+	// files compacted by an interrupted maintenance are already in the file they were merged into
+	groups := make(map[string][]*maintainedFile)
+	for _, file := range files {
+		if compacted[file.name] {
+			if err := remove(file.name, "already compacted"); err != nil {
+				return err
+			}
+			continue
+		}
+		if file.size >= options.TargetFileSize {
+			continue
+		}
+		// only files of the same schema are merged
+		schema := file.parquet.Schema().String()
+		groups[schema] = append(groups[schema], file)
+	}
+
+	for _, candidates := range groups {
+		// timestamped names keep records in the order they were written
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].name < candidates[j].name })
+		for start := 0; start < len(candidates); {
+			end, size := start, int64(0)
+			for end < len(candidates) && (end == start || size+candidates[end].size <= options.TargetFileSize) {
+				size += candidates[end].size
+				end++
+			}
+			if end-start > 1 {
+				if err := p.compact(directory, candidates[start:end], options, result); err != nil {
+					return err
+				}
+			}
+			start = end
+		}
+	}
+	return nil
+}
+
+// compact merges files into one file of directory, then removes them
+func (p *Parquet) compact(directory string, files []*maintainedFile, options protocol.MaintainOptions, result *protocol.StreamMaintenance) error {
+	names := make([]string, 0, len(files))
+	rowGroups := make([]pqgo.RowGroup, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.name)
+		rowGroups = append(rowGroups, file.parquet.RowGroups()...)
+	}
+	if options.DryRun {
+		logger.Infof("Would compact %d files of folder[%s]: %s", len(files), directory, strings.Join(names, ", "))
+		result.CompactedFiles += len(files)
+		result.WrittenFiles++
+		return nil
+	}
+
+	merged, err := pqgo.MergeRowGroups(rowGroups)
+	if err != nil {
+		return fmt.Errorf("failed to merge files of folder[%s]: %s", directory, err)
+	}
+	name := utils.TimestampedFileName(constants.ParquetFileExt)
+	path := filepath.Join(directory, name)
+	temp := path + compactingExt
+	out, err := os.Create(temp)
+	if err != nil {
+		return fmt.Errorf("failed to create file[%s]: %s", temp, err)
+	}
+	err = func() error {
+		writer := pqgo.NewWriter(out, files[0].parquet.Schema(), pqgo.Compression(p.compressionCodec()),
+			pqgo.KeyValueMetadata(constants.SyncID, p.options.SyncID), pqgo.KeyValueMetadata(compactedFromKey, strings.Join(names, ",")))
+		rows := merged.Rows()
+		defer rows.Close()
+		if _, err := pqgo.CopyRows(writer, rows); err != nil {
+			return err
+		}
+		if err := writer.Close(); err != nil {
+			return err
+		}
+		return out.Sync()
+	}()
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp, path)
+	}
+	if err != nil {
+		os.Remove(temp)
+		return fmt.Errorf("failed to compact files of folder[%s]: %s", directory, err)
+	}
+	result.WrittenFiles++
+
+	for _, file := range files {
+		if err := os.Remove(filepath.Join(directory, file.name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove compacted file[%s]: %s", file.name, err)
+		}
+		result.CompactedFiles++
+	}
+	logger.Infof("Compacted %d files of folder[%s] into file[%s]", len(files), directory, name)
+	return nil
+}
@@ -0,0 +1,104 @@
+package parquet
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	pqgo "github.com/parquet-go/parquet-go"
+)
+
+func TestMaintain(t *testing.T) {
+	path := t.TempDir()
+	stream := types.NewStream("orders", "shop")
+	stream.UpsertField("id", types.Int64, false)
+	setup := func() *Parquet {
+		writer := &Parquet{config: &Config{Path: path}}
+		if err := writer.Setup(stream.Wrap(0), &protocol.Options{Namespace: "shop", Table: "orders", SyncID: "sync"}); err != nil {
+			t.Fatal(err)
+		}
+		return writer
+	}
+	directory := filepath.Join(path, "shop", "orders")
+	listFiles := func() []string {
+		entries, _ := os.ReadDir(directory)
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return names
+	}
+	// a file per sync
+	for sync := 0; sync < 3; sync++ {
+		writer := setup()
+		for id := 0; id < 2; id++ {
+			if err := writer.Write(context.Background(), types.CreateRawRecord("id", map[string]any{"id": int64(id)}, 0)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	inputs := listFiles()
+	// left behind by a failed sync and an interrupted compaction
+	old := time.Now().Add(-2 * time.Hour)
+	for _, name := range []string{"failed.parquet", "merged.parquet" + compactingExt} {
+		os.WriteFile(filepath.Join(directory, name), []byte("PAR1"), 0o600)
+		os.Chtimes(filepath.Join(directory, name), old, old)
+	}
+
+	maintain := func() protocol.StreamMaintenance {
+		writer := setup()
+		defer writer.Close()
+		result := protocol.StreamMaintenance{}
+		options := protocol.MaintainOptions{TargetFileSize: 1 << 20, Retention: time.Hour}
+		if err := writer.Maintain(context.Background(), stream.Wrap(0), options, &result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	result := maintain()
+	if result.CompactedFiles != 3 || result.WrittenFiles != 1 || result.RemovedFiles != 2 {
+		t.Fatalf("expected 3 files compacted into 1 and 2 removed, got %+v", result)
+	}
+	files := listFiles()
+	if len(files) != 1 {
+		t.Fatalf("expected the compacted file only, got %v", files)
+	}
+	file, err := os.Open(filepath.Join(directory, files[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	info, _ := file.Stat()
+	compacted, err := pqgo.OpenFile(file, info.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compacted.NumRows() != 6 {
+		t.Fatalf("expected the 6 records of the compacted files, got %d", compacted.NumRows())
+	}
+	if from, _ := compacted.Lookup(compactedFromKey); from != strings.Join(inputs, ",") {
+		t.Fatalf("expected the compacted files in the metadata, got %s", from)
+	}
+
+	// inputs left by a compaction interrupted before removing them are removed, not compacted
+	// again
+	writer := setup()
+	writer.Write(context.Background(), types.CreateRawRecord("id", map[string]any{"id": int64(0)}, 0))
+	writer.Close()
+	for _, name := range listFiles() {
+		if name != files[0] {
+			os.Rename(filepath.Join(directory, name), filepath.Join(directory, inputs[0]))
+		}
+	}
+	if result := maintain(); result.RemovedFiles != 1 || result.CompactedFiles != 0 {
+		t.Fatalf("expected the compacted input to be removed, got %+v", result)
+	}
+}
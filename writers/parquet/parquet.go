@@ -3,23 +3,29 @@ package parquet
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/glue"
+	"github.com/aws/aws-sdk-go/service/glue/glueiface"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/datazip-inc/olake/constants"
 	"github.com/datazip-inc/olake/logger"
+	pkgcompress "github.com/datazip-inc/olake/pkg/compress"
 	"github.com/datazip-inc/olake/protocol"
 	"github.com/datazip-inc/olake/types"
 	"github.com/datazip-inc/olake/typeutils"
 	"github.com/datazip-inc/olake/utils"
 	pqgo "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
 	"github.com/xitongsys/parquet-go-source/local"
 	"github.com/xitongsys/parquet-go/source"
 )
@@ -29,6 +35,7 @@ type FileMetadata struct {
 	recordCount int
 	writer      any
 	parquetFile source.ParquetFile
+	batch       *columnBatch // values of normalized records not written yet
 }
 
 // Parquet destination writes Parquet files to a local path and optionally uploads them to S3.
@@ -39,6 +46,11 @@ type Parquet struct {
 	basePath         string                    // construct with streamNamespace/streamName
 	partitionedFiles map[string][]FileMetadata // mapping of basePath/{regex} -> pqFiles
 	s3Client         *s3.S3
+	uploads          protocol.UploadTracker // tracks multipart uploads in state; nil outside sync
+	rawRows          []types.RawRecord      // reused to write one raw record
+	interners        map[string]*interner   // of the columns values are interned for
+	glue             glueiface.GlueAPI      // set when a catalog is configured
+	catalog          *catalogTable          // the table of the stream in the catalog
 }
 
 // GetConfigRef returns the config reference for the parquet writer.
@@ -64,11 +76,25 @@ func (p *Parquet) initS3Writer() error {
 	if p.config.AccessKey != "" && p.config.SecretKey != "" {
 		s3Config.Credentials = credentials.NewStaticCredentials(p.config.AccessKey, p.config.SecretKey, "")
 	}
+	if p.config.Network.Enabled() {
+		transport, err := p.config.Network.HTTPTransport()
+		if err != nil {
+			return err
+		}
+		s3Config.HTTPClient = &http.Client{Transport: transport}
+	}
 	sess, err := session.NewSession(&s3Config)
 	if err != nil {
 		return fmt.Errorf("failed to create AWS session: %s", err)
 	}
 	p.s3Client = s3.New(sess)
+	if p.config.Catalog != nil {
+		region := p.config.Catalog.Region
+		if region == "" {
+			region = p.config.Region
+		}
+		p.glue = glue.New(sess, aws.NewConfig().WithRegion(region))
+	}
 
 	return nil
 }
@@ -89,34 +115,82 @@ func (p *Parquet) createNewPartitionFile(basePath string) error {
 		return fmt.Errorf("failed to create parquet file writer: %s", err)
 	}
 
-	writer := func() any {
-		if p.config.Normalization {
-			return pqgo.NewGenericWriter[any](pqFile, p.stream.Schema().ToParquet(), pqgo.Compression(&pqgo.Snappy))
+	options := []pqgo.WriterOption{pqgo.Compression(p.compressionCodec()), pqgo.KeyValueMetadata(constants.SyncID, p.options.SyncID)}
+	var writer any
+	var batch *columnBatch
+	if p.config.Normalization {
+		schema := dictionarySchema(p.stream.Schema().ToParquet(), p.interners)
+		writer = pqgo.NewGenericWriter[any](pqFile, append(options, schema)...)
+		if batch, err = newColumnBatch(schema, p.interners); err != nil {
+			return fmt.Errorf("failed to prepare columns of parquet file: %s", err)
 		}
-		return pqgo.NewGenericWriter[types.RawRecord](pqFile, pqgo.Compression(&pqgo.Snappy))
-	}()
+	} else {
+		writer = pqgo.NewGenericWriter[types.RawRecord](pqFile, options...)
+	}
 
 	p.partitionedFiles[basePath] = append(p.partitionedFiles[basePath], FileMetadata{
 		fileName:    fileName,
 		parquetFile: pqFile,
 		writer:      writer,
+		batch:       batch,
 	})
 
 	return nil
 }
 
+// dictionarySchema returns schema with the interned string columns dictionary encoded, so
+// their pages hold each distinct value once
+func dictionarySchema(schema *pqgo.Schema, interners map[string]*interner) *pqgo.Schema {
+	if len(interners) == 0 {
+		return schema
+	}
+	group := pqgo.Group{}
+	for _, field := range schema.Fields() {
+		var node pqgo.Node = field
+		if _, interned := interners[field.Name()]; interned && field.Type().Kind() == pqgo.ByteArray {
+			node = pqgo.Encoded(field, &pqgo.RLEDictionary)
+		}
+		group[field.Name()] = node
+	}
+	return pqgo.NewSchema(schema.Name(), group)
+}
+
+// compressionCodec maps the configured compression to the parquet column codec
+func (p *Parquet) compressionCodec() compress.Codec {
+	switch p.config.Compression {
+	case pkgcompress.None:
+		return &pqgo.Uncompressed
+	case pkgcompress.Gzip:
+		return &pqgo.Gzip
+	case pkgcompress.Zstd:
+		return &pqgo.Zstd
+	case pkgcompress.LZ4:
+		return &pqgo.Lz4Raw
+	default:
+		return &pqgo.Snappy
+	}
+}
+
 // Setup configures the parquet writer, including local paths, file names, and optional S3 setup.
 func (p *Parquet) Setup(stream protocol.Stream, options *protocol.Options) error {
+	if err := p.config.Validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
 	p.options = options
 	p.stream = stream
+	p.uploads = options.Uploads
 	p.partitionedFiles = make(map[string][]FileMetadata)
+	p.interners = make(map[string]*interner)
+	for _, column := range p.config.InternColumns {
+		p.interners[column] = newInterner(column, p.config.InternLimit)
+	}
 
 	// for s3 p.config.path may not be provided
 	if p.config.Path == "" {
 		p.config.Path = os.TempDir()
 	}
 
-	p.basePath = filepath.Join(p.stream.Namespace(), p.stream.Name())
+	p.basePath = filepath.Join(options.Namespace, options.Table)
 	err := p.createNewPartitionFile(p.basePath)
 	if err != nil {
 		return fmt.Errorf("failed to create new partition file: %s", err)
@@ -126,9 +200,43 @@ func (p *Parquet) Setup(stream protocol.Stream, options *protocol.Options) error
 	if err != nil {
 		return err
 	}
+	if p.glue != nil {
+		if p.catalog, err = p.catalogTable(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// catalogTable returns the table of the stream in the catalog
+func (p *Parquet) catalogTable() (*catalogTable, error) {
+	keys, err := partitionKeys(p.stream.Self().StreamMetadata)
+	if err != nil {
+		return nil, err
+	}
+	schema := pqgo.SchemaOf(types.RawRecord{})
+	if p.config.Normalization {
+		schema = p.stream.Schema().ToParquet()
+	}
+	database := p.config.Catalog.Database
+	if database == "" {
+		database = p.options.Namespace
+	}
+	var catalogID *string
+	if p.config.Catalog.CatalogID != "" {
+		catalogID = aws.String(p.config.Catalog.CatalogID)
+	}
+	return &catalogTable{
+		client:    p.glue,
+		catalogID: catalogID,
+		database:  strings.ToLower(database),
+		table:     strings.ToLower(p.options.Table),
+		location:  fmt.Sprintf("s3://%s/%s/", p.config.Bucket, filepath.ToSlash(filepath.Join(p.config.Prefix, p.basePath))),
+		keys:      keys,
+		columns:   hiveColumns(schema, keys),
+	}, nil
+}
+
 // Write writes a record to the Parquet file.
 func (p *Parquet) Write(_ context.Context, record types.RawRecord) error {
 	partitionedPath := p.getPartitionedFilePath(record.Data)
@@ -150,9 +258,13 @@ func (p *Parquet) Write(_ context.Context, record types.RawRecord) error {
 	fileMetadata := &partitionFolder[len(partitionFolder)-1]
 	var err error
 	if p.config.Normalization {
-		_, err = fileMetadata.writer.(*pqgo.GenericWriter[any]).Write([]any{record.Data})
+		if fileMetadata.batch.add(record.Data) {
+			err = fileMetadata.batch.flush(fileMetadata.writer.(*pqgo.GenericWriter[any]))
+		}
 	} else {
-		_, err = fileMetadata.writer.(*pqgo.GenericWriter[types.RawRecord]).Write([]types.RawRecord{record})
+		p.rawRows = append(p.rawRows[:0], record)
+		_, err = fileMetadata.writer.(*pqgo.GenericWriter[types.RawRecord]).Write(p.rawRows)
+		p.rawRows[0] = types.RawRecord{}
 	}
 	if err != nil {
 		return fmt.Errorf("failed to write in parquet file: %s", err)
@@ -161,8 +273,22 @@ func (p *Parquet) Write(_ context.Context, record types.RawRecord) error {
 	return nil
 }
 
+// PooledRecords is true as the values of normalized records are batched, not the records, and
+// raw records are copied to the column buffers as they are written.
+func (p *Parquet) PooledRecords() bool {
+	return true
+}
+
+// Delete is not possible on immutable parquet files.
+func (p *Parquet) Delete(_ context.Context, _ types.RawRecord) error {
+	return protocol.ErrDeleteUnsupported
+}
+
 // Check validates local paths and S3 credentials if applicable.
 func (p *Parquet) Check() error {
+	if err := p.config.Validate(); err != nil {
+		return fmt.Errorf("failed to validate config: %s", err)
+	}
 	// check for s3 writer configuration
 	err := p.initS3Writer()
 	if err != nil {
@@ -213,6 +339,8 @@ func (p *Parquet) Close() error {
 		logger.Debugf("Deleted file [%s] with %d records (%s).", filePath, recordCount, reason)
 	}
 
+	// partition folders files were uploaded to, relative to the folder of the table
+	uploaded := make(map[string]bool)
 	for basePath, parquetFiles := range p.partitionedFiles {
 		for _, fileMetadata := range parquetFiles {
 			// TODO: Async file close and S3 upload (Good First Issue)
@@ -228,7 +356,10 @@ func (p *Parquet) Close() error {
 			// Close writers
 			var err error
 			if p.config.Normalization {
-				err = fileMetadata.writer.(*pqgo.GenericWriter[any]).Close()
+				writer := fileMetadata.writer.(*pqgo.GenericWriter[any])
+				if err = fileMetadata.batch.flush(writer); err == nil {
+					err = writer.Close()
+				}
 			} else {
 				err = fileMetadata.writer.(*pqgo.GenericWriter[types.RawRecord]).Close()
 			}
@@ -243,34 +374,37 @@ func (p *Parquet) Close() error {
 			logger.Infof("Finished writing file [%s] with %d records.", filePath, fileMetadata.recordCount)
 
 			if p.s3Client != nil {
-				// Open file for S3 upload
-				file, err := os.Open(filePath)
-				if err != nil {
-					return fmt.Errorf("failed to open local file for S3 upload: %s", err)
-				}
-				defer file.Close()
-
 				// Construct S3 key path
-				if p.config.Prefix != "" {
-					basePath = filepath.Join(p.config.Prefix, basePath)
-				}
-				s3KeyPath := filepath.Join(basePath, fileMetadata.fileName)
+				s3KeyPath := filepath.Join(p.config.Prefix, basePath, fileMetadata.fileName)
 
 				// Upload to S3
-				_, err = p.s3Client.PutObject(&s3.PutObjectInput{
-					Bucket: aws.String(p.config.Bucket),
-					Key:    aws.String(s3KeyPath),
-					Body:   file,
-				})
-				if err != nil {
+				if err := p.upload(filePath, s3KeyPath); err != nil {
 					return fmt.Errorf("failed to upload file to S3 (bucket: %s, path: %s): %s", p.config.Bucket, s3KeyPath, err)
 				}
 
 				// Remove local file after successful upload
 				removeLocalFile(filePath, "uploaded to S3", fileMetadata.recordCount)
 				logger.Infof("Successfully uploaded file to S3: s3://%s/%s", p.config.Bucket, s3KeyPath)
+				folder, _ := filepath.Rel(p.basePath, basePath)
+				uploaded[folder] = true
+			}
+		}
+	}
+	if p.catalog != nil && len(uploaded) > 0 {
+		folders := make([]string, 0, len(uploaded))
+		for folder := range uploaded {
+			if folder != "." {
+				folders = append(folders, folder)
 			}
 		}
+		sort.Strings(folders)
+		// the columns of the latest files, as the schema may have evolved while writing
+		if p.config.Normalization {
+			p.catalog.columns = hiveColumns(p.stream.Schema().ToParquet(), p.catalog.keys)
+		}
+		if err := p.catalog.register(folders); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -304,7 +438,15 @@ func (p *Parquet) Normalization() bool {
 	return p.config.Normalization
 }
 
+// SupportedTypes returns every data type; nested values are stored as JSON strings.
+func (p *Parquet) SupportedTypes() *types.Set[types.DataType] {
+	return types.NewSet(types.DataTypes...)
+}
+
 func (p *Parquet) getPartitionedFilePath(values map[string]any) string {
+	if partition := p.stream.Self().StreamMetadata.Partition; partition != nil {
+		return filepath.Join(p.basePath, typeutils.PartitionFolder(partition, values))
+	}
 	pattern := p.stream.Self().StreamMetadata.PartitionRegex
 	if pattern == "" {
 		return p.basePath
@@ -345,7 +487,7 @@ func (p *Parquet) getPartitionedFilePath(values map[string]any) string {
 						}
 					}
 				} else {
-					logger.Debugf("Failed to convert value to timestamp: %s", err)
+					logger.ThrottledDebugf("partition:"+colName, "Failed to convert value to timestamp: %s", err)
 				}
 			}
 			return fmt.Sprintf("%v", value)
@@ -368,3 +510,23 @@ func init() {
 		return new(Parquet)
 	}
 }
+
+// BeginSync is a no-op; parquet files are complete as soon as their thread closes.
+func (p *Parquet) BeginSync(_ context.Context) error {
+	return nil
+}
+
+// BeginStream is a no-op.
+func (p *Parquet) BeginStream(_ context.Context, _ protocol.Stream) error {
+	return nil
+}
+
+// EndStream is a no-op.
+func (p *Parquet) EndStream(_ context.Context, _ protocol.Stream) error {
+	return nil
+}
+
+// EndSync is a no-op; interrupted uploads are resumed by the next sync.
+func (p *Parquet) EndSync(_ context.Context, _ error) error {
+	return nil
+}
@@ -0,0 +1,134 @@
+package parquet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/datazip-inc/olake/logger"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+)
+
+// uploadPartSize must stay above the S3 minimum part size of 5MB
+const uploadPartSize = 16 * 1024 * 1024
+
+// upload uploads the local file in parts, tracking progress so an interrupted upload can be
+// resumed by the next sync
+func (p *Parquet) upload(filePath, key string) error {
+	output, err := p.s3Client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket: aws.String(p.config.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create multipart upload: %s", err)
+	}
+
+	pending := &types.PendingUpload{
+		Bucket:    p.config.Bucket,
+		Key:       key,
+		UploadID:  aws.StringValue(output.UploadId),
+		LocalPath: filePath,
+	}
+	p.trackUpload(pending)
+	return p.resumeUpload(pending)
+}
+
+// resumeUpload uploads the parts of the local file after pending.Offset and completes the upload
+func (p *Parquet) resumeUpload(pending *types.PendingUpload) error {
+	file, err := os.Open(pending.LocalPath)
+	if err != nil {
+		return fmt.Errorf("failed to open local file for S3 upload: %s", err)
+	}
+	defer file.Close()
+
+	buffer := make([]byte, uploadPartSize)
+	for {
+		read, err := file.ReadAt(buffer, pending.Offset)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read local file[%s]: %s", pending.LocalPath, err)
+		}
+		if read == 0 {
+			break
+		}
+
+		number := int64(len(pending.Parts) + 1)
+		part, err := p.s3Client.UploadPart(&s3.UploadPartInput{
+			Bucket:     aws.String(pending.Bucket),
+			Key:        aws.String(pending.Key),
+			UploadId:   aws.String(pending.UploadID),
+			PartNumber: aws.Int64(number),
+			Body:       bytes.NewReader(buffer[:read]),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upload part %d of s3://%s/%s: %s", number, pending.Bucket, pending.Key, err)
+		}
+		pending.Parts = append(pending.Parts, types.UploadPart{Number: number, ETag: aws.StringValue(part.ETag)})
+		pending.Offset += int64(read)
+		p.trackUpload(pending)
+	}
+
+	completed := make([]*s3.CompletedPart, 0, len(pending.Parts))
+	for _, part := range pending.Parts {
+		completed = append(completed, &s3.CompletedPart{PartNumber: aws.Int64(part.Number), ETag: aws.String(part.ETag)})
+	}
+	_, err = p.s3Client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(pending.Bucket),
+		Key:             aws.String(pending.Key),
+		UploadId:        aws.String(pending.UploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete upload of s3://%s/%s: %s", pending.Bucket, pending.Key, err)
+	}
+	if p.uploads != nil {
+		p.uploads.CompleteUpload(pending.UploadID)
+	}
+	return nil
+}
+
+// ResumeUploads completes uploads interrupted in a previous sync whose local file still exists
+// and aborts the rest so no orphaned parts are left behind.
+func (p *Parquet) ResumeUploads(tracker protocol.UploadTracker) error {
+	p.uploads = tracker
+	for _, pending := range tracker.PendingUploads() {
+		if p.s3Client == nil || pending.Bucket != p.config.Bucket {
+			logger.Warnf("Skipping interrupted upload of s3://%s/%s; destination not configured for bucket", pending.Bucket, pending.Key)
+			continue
+		}
+
+		info, err := os.Stat(pending.LocalPath)
+		if err != nil || info.Size() < pending.Offset {
+			_, err := p.s3Client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+				Bucket:   aws.String(pending.Bucket),
+				Key:      aws.String(pending.Key),
+				UploadId: aws.String(pending.UploadID),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to abort upload of s3://%s/%s: %s", pending.Bucket, pending.Key, err)
+			}
+			tracker.CompleteUpload(pending.UploadID)
+			logger.Warnf("Aborted interrupted upload of s3://%s/%s; local file[%s] is missing", pending.Bucket, pending.Key, pending.LocalPath)
+			continue
+		}
+
+		logger.Infof("Resuming interrupted upload of s3://%s/%s from byte %d", pending.Bucket, pending.Key, pending.Offset)
+		if err := p.resumeUpload(pending); err != nil {
+			return err
+		}
+		if err := os.Remove(pending.LocalPath); err != nil {
+			logger.Warnf("Failed to delete uploaded file [%s]: %s", pending.LocalPath, err)
+		}
+	}
+	return nil
+}
+
+func (p *Parquet) trackUpload(pending *types.PendingUpload) {
+	if p.uploads != nil {
+		p.uploads.TrackUpload(pending)
+	}
+}
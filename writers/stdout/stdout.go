@@ -0,0 +1,223 @@
+package stdout
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/datazip-inc/olake/pkg/frames"
+	"github.com/datazip-inc/olake/pkg/jsonx"
+	"github.com/datazip-inc/olake/protocol"
+	"github.com/datazip-inc/olake/types"
+	"github.com/datazip-inc/olake/typeutils"
+	"github.com/datazip-inc/olake/utils"
+	"github.com/goccy/go-json"
+)
+
+const (
+	FormatJSONL  = "jsonl"
+	FormatPretty = "pretty"
+	FormatFrames = "frames"
+
+	defaultFrameSize = 1000
+)
+
+// output is shared by all writer threads so that lines and frames never interleave
+var (
+	outputMutex sync.Mutex
+	output      = bufio.NewWriter(os.Stdout)
+	// created with the first frame written
+	frameWriter *frames.Writer
+)
+
+type Config struct {
+	// jsonl (default) prints a record per line, pretty prints indented json and frames writes
+	// batches of json lines as zstd compressed frames prefixed with their length, for processes
+	// consuming the records over a pipe; see pkg/frames
+	Format string `json:"format,omitempty"`
+	// records per frame of the frames format; defaults to 1000
+	FrameSize     int  `json:"frame_size,omitempty"`
+	Normalization bool `json:"normalization,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	switch c.Format {
+	case "":
+		c.Format = FormatJSONL
+	case FormatJSONL, FormatPretty, FormatFrames:
+	default:
+		return fmt.Errorf("invalid format[%s]; expected jsonl, pretty or frames", c.Format)
+	}
+	if c.FrameSize < 0 {
+		return fmt.Errorf("frame_size must not be negative")
+	} else if c.FrameSize == 0 {
+		c.FrameSize = defaultFrameSize
+	}
+	return utils.Validate(c)
+}
+
+type line struct {
+	Stream     string         `json:"stream"`
+	OlakeID    string         `json:"olake_id"`
+	DeleteTime int64          `json:"cdc_deleted_at,omitempty"`
+	Data       map[string]any `json:"data"`
+}
+
+// Stdout destination prints records for debugging.
+type Stdout struct {
+	config *Config
+	// destination identifier printed with every record
+	destination string
+	// records of the thread not yet written in a frame
+	pending [][]byte
+}
+
+// GetConfigRef returns the config reference for the stdout writer.
+func (s *Stdout) GetConfigRef() protocol.Config {
+	s.config = &Config{}
+	return s.config
+}
+
+// Spec returns a new Config instance.
+func (s *Stdout) Spec() any {
+	return Config{}
+}
+
+func (s *Stdout) Setup(_ protocol.Stream, options *protocol.Options) error {
+	s.destination = utils.StreamIdentifier(options.Table, options.Namespace)
+	return s.config.Validate()
+}
+
+func (s *Stdout) Write(_ context.Context, record types.RawRecord) error {
+	return s.print(record)
+}
+
+func (s *Stdout) Delete(_ context.Context, record types.RawRecord) error {
+	return s.print(record)
+}
+
+func (s *Stdout) print(record types.RawRecord) error {
+	entry := line{Stream: s.destination, OlakeID: record.OlakeID, DeleteTime: record.DeleteTime, Data: record.Data}
+	buf := jsonx.GetBuffer()
+	defer jsonx.PutBuffer(buf)
+	switch s.config.Format {
+	case FormatPretty:
+		data, err := json.MarshalIndent(entry, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal record: %s", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	case FormatFrames:
+		// kept until the frame is written
+		data, err := jsonx.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal record: %s", err)
+		}
+		s.pending = append(s.pending, data)
+		if len(s.pending) < s.config.FrameSize {
+			return nil
+		}
+		return s.writeFrame()
+	default:
+		if err := jsonx.Encode(buf, entry); err != nil {
+			return fmt.Errorf("failed to marshal record: %s", err)
+		}
+	}
+
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	if _, err := output.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write to stdout: %s", err)
+	}
+	return nil
+}
+
+// PooledRecords is true as records are encoded as they are printed.
+func (s *Stdout) PooledRecords() bool {
+	return true
+}
+
+func (s *Stdout) Check() error {
+	return s.config.Validate()
+}
+
+// writeFrame writes the pending records of the thread as one frame
+func (s *Stdout) writeFrame() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	if frameWriter == nil {
+		writer, err := frames.NewWriter(output)
+		if err != nil {
+			return fmt.Errorf("failed to create frame writer: %s", err)
+		}
+		frameWriter = writer
+	}
+	if err := frameWriter.WriteFrame(s.pending); err != nil {
+		return fmt.Errorf("failed to write frame to stdout: %s", err)
+	}
+	s.pending = s.pending[:0]
+	return nil
+}
+
+// Close flushes the buffered output.
+func (s *Stdout) Close() error {
+	if s.config.Format == FormatFrames {
+		if err := s.writeFrame(); err != nil {
+			return err
+		}
+	}
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	return output.Flush()
+}
+
+func (s *Stdout) EvolveSchema(_, _ bool, _ map[string]*types.Property, _ types.Record) error {
+	return nil
+}
+
+// Type returns the type of the writer.
+func (s *Stdout) Type() string {
+	return string(types.Stdout)
+}
+
+func (s *Stdout) Normalization() bool {
+	return s.config.Normalization
+}
+
+func (s *Stdout) SupportedTypes() *types.Set[types.DataType] {
+	return types.NewSet(types.DataTypes...)
+}
+
+// Flattener returns a flattening function for records.
+func (s *Stdout) Flattener() protocol.FlattenFunction {
+	flattener := typeutils.NewFlattener()
+	return flattener.Flatten
+}
+
+func init() {
+	protocol.RegisteredWriters[types.Stdout] = func() protocol.Writer {
+		return new(Stdout)
+	}
+}
+
+func (s *Stdout) BeginSync(_ context.Context) error {
+	return nil
+}
+
+func (s *Stdout) BeginStream(_ context.Context, _ protocol.Stream) error {
+	return nil
+}
+
+func (s *Stdout) EndStream(_ context.Context, _ protocol.Stream) error {
+	return nil
+}
+
+func (s *Stdout) EndSync(_ context.Context, _ error) error {
+	return nil
+}